@@ -0,0 +1,247 @@
+package gocosmos
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AccessToken is an Azure AD access token, mirrors azcore.AccessToken.
+//
+// Available since v0.1.1
+type AccessToken struct {
+	Token     string
+	ExpiresOn time.Time
+}
+
+// TokenRequestOptions contains options used to request an access token, mirrors policy.TokenRequestOptions.
+//
+// Available since v0.1.1
+type TokenRequestOptions struct {
+	Scopes []string
+}
+
+// TokenCredential provides Azure AD (Entra ID) access tokens, used to authenticate requests instead of the account key.
+//
+// This interface mirrors azcore.TokenCredential. A credential from the azidentity package can be adapted as follows:
+//     type azCred struct{ cred azcore.TokenCredential }
+//     func (c azCred) GetToken(ctx context.Context, opts gocosmos.TokenRequestOptions) (gocosmos.AccessToken, error) {
+//         t, err := c.cred.GetToken(ctx, policy.TokenRequestOptions{Scopes: opts.Scopes})
+//         return gocosmos.AccessToken{Token: t.Token, ExpiresOn: t.ExpiresOn}, err
+//     }
+//
+// Available since v0.1.1
+type TokenCredential interface {
+	GetToken(ctx context.Context, opts TokenRequestOptions) (AccessToken, error)
+}
+
+const (
+	// aadTokenRefreshBefore is how long before expiry a cached token is refreshed.
+	aadTokenRefreshBefore = 5 * time.Minute
+
+	// aadTokenTimeout is the timeout to acquire a new token.
+	aadTokenTimeout = 30 * time.Second
+)
+
+// aadTokenProvider caches the access token obtained from a TokenCredential and refreshes it before expiry.
+// It is safe for concurrent use and can be shared among RestClient instances.
+type aadTokenProvider struct {
+	cred  TokenCredential
+	scope string
+	lock  sync.Mutex
+	token AccessToken
+}
+
+// newAadTokenProvider creates a new aadTokenProvider, token scope is derived from the account endpoint.
+func newAadTokenProvider(cred TokenCredential, endpoint string) (*aadTokenProvider, error) {
+	if cred == nil {
+		return nil, errors.New("token credential is nil")
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, errors.New("invalid AccountEndpoint: " + endpoint)
+	}
+	return &aadTokenProvider{cred: cred, scope: u.Scheme + "://" + u.Host + "/.default"}, nil
+}
+
+// getToken returns the cached token, or acquires a new one if the cached token is about to expire.
+func (p *aadTokenProvider) getToken() (string, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.token.Token != "" && time.Now().Add(aadTokenRefreshBefore).Before(p.token.ExpiresOn) {
+		return p.token.Token, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), aadTokenTimeout)
+	defer cancel()
+	token, err := p.cred.GetToken(ctx, TokenRequestOptions{Scopes: []string{p.scope}})
+	if err != nil {
+		return "", err
+	}
+	if token.Token == "" {
+		return "", errors.New("token credential returned an empty token")
+	}
+	p.token = token
+	return token.Token, nil
+}
+
+var (
+	msiTokenProviders     = make(map[string]*aadTokenProvider)
+	msiTokenProvidersLock sync.Mutex
+)
+
+// getMsiTokenProvider returns the managed identity token provider for the endpoint/client id, tokens are shared among
+// RestClient instances created from the same connection string.
+func getMsiTokenProvider(endpoint, clientId string) (*aadTokenProvider, error) {
+	msiTokenProvidersLock.Lock()
+	defer msiTokenProvidersLock.Unlock()
+	key := endpoint + ";" + clientId
+	if p, ok := msiTokenProviders[key]; ok {
+		return p, nil
+	}
+	p, err := newAadTokenProvider(&msiTokenCredential{httpClient: &http.Client{Timeout: aadTokenTimeout}, clientId: clientId}, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	msiTokenProviders[key] = p
+	return p, nil
+}
+
+const (
+	// msiImdsEndpoint is the Azure Instance Metadata Service endpoint to acquire managed identity tokens.
+	msiImdsEndpoint = "http://169.254.169.254/metadata/identity/oauth2/token"
+)
+
+// msiTokenCredential acquires tokens from the managed identity endpoint: the App Service/Functions identity endpoint if
+// environment variables IDENTITY_ENDPOINT and IDENTITY_HEADER are set, the Azure Instance Metadata Service otherwise.
+type msiTokenCredential struct {
+	httpClient *http.Client
+	clientId   string // client id of the user-assigned managed identity, empty to use the system-assigned identity
+}
+
+// GetToken implements TokenCredential.GetToken.
+func (c *msiTokenCredential) GetToken(ctx context.Context, opts TokenRequestOptions) (AccessToken, error) {
+	if len(opts.Scopes) == 0 {
+		return AccessToken{}, errors.New("no scope is specified")
+	}
+	params := url.Values{"resource": {strings.TrimSuffix(opts.Scopes[0], "/.default")}}
+	endpoint, header := os.Getenv("IDENTITY_ENDPOINT"), os.Getenv("IDENTITY_HEADER")
+	if endpoint != "" && header != "" {
+		params.Set("api-version", "2019-08-01")
+	} else {
+		endpoint, header = msiImdsEndpoint, ""
+		params.Set("api-version", "2018-02-01")
+	}
+	if c.clientId != "" {
+		params.Set("client_id", c.clientId)
+	}
+	req, err := http.NewRequest("GET", endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return AccessToken{}, err
+	}
+	req = req.WithContext(ctx)
+	if header != "" {
+		req.Header.Set("X-IDENTITY-HEADER", header)
+	} else {
+		req.Header.Set("Metadata", "true")
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return AccessToken{}, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return AccessToken{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return AccessToken{}, fmt.Errorf("cannot acquire managed identity token; StatusCode=%d;Body=%s", resp.StatusCode, body)
+	}
+	var token struct {
+		AccessToken string      `json:"access_token"`
+		ExpiresOn   json.Number `json:"expires_on"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return AccessToken{}, fmt.Errorf("cannot parse managed identity token: %s", err)
+	}
+	expiresOn, err := token.ExpiresOn.Int64()
+	if err != nil {
+		return AccessToken{}, fmt.Errorf("cannot parse managed identity token expiry <%s>: %s", token.ExpiresOn, err)
+	}
+	return AccessToken{Token: token.AccessToken, ExpiresOn: time.Unix(expiresOn, 0)}, nil
+}
+
+// accountKeys holds the account keys used to sign requests. It is safe for concurrent use and can be shared among
+// RestClient instances, so that keys can be rotated at runtime.
+type accountKeys struct {
+	lock   sync.RWMutex
+	keys   [][]byte // primary key first, followed by secondary key (if any)
+	active int      // index of the key currently used to sign requests
+}
+
+type ctxKey string
+
+const ctxKeyAuthSignInfo = ctxKey("authSignInfo")
+
+// authSignInfo captures how a request was signed, so that it can be re-signed with another key.
+type authSignInfo struct {
+	method, resType, resId string
+	keyIndex               int
+}
+
+// newAccountKeys creates a new accountKeys from the base64-encoded primary and (optional) secondary keys.
+func newAccountKeys(primaryKey, secondaryKey string) (*accountKeys, error) {
+	k := &accountKeys{}
+	return k, k.set(primaryKey, secondaryKey)
+}
+
+// set replaces the keys, the primary key becomes active.
+func (k *accountKeys) set(primaryKey, secondaryKey string) error {
+	keys := make([][]byte, 0, 2)
+	for i, encoded := range []string{primaryKey, secondaryKey} {
+		if i > 0 && encoded == "" {
+			continue
+		}
+		if i == 0 && encoded == "" {
+			return errors.New("primary account key is empty")
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return fmt.Errorf("cannot base64 decode account key: %s", err)
+		}
+		keys = append(keys, key)
+	}
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	k.keys, k.active = keys, 0
+	return nil
+}
+
+// get returns the active key and its index.
+func (k *accountKeys) get() ([]byte, int) {
+	k.lock.RLock()
+	defer k.lock.RUnlock()
+	return k.keys[k.active], k.active
+}
+
+// fallback is called when a request signed with key at failedIndex has been rejected. It switches to the next key
+// (unless another request has already done so) and returns true if the request should be retried with the active key.
+func (k *accountKeys) fallback(failedIndex int) bool {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	if len(k.keys) < 2 {
+		return false
+	}
+	if k.active == failedIndex {
+		k.active = (k.active + 1) % len(k.keys)
+	}
+	return k.active != failedIndex
+}
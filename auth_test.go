@@ -0,0 +1,225 @@
+package gocosmos
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+type _testTokenCredential struct {
+	numCalls int
+	scopes   []string
+	ttl      time.Duration
+	err      error
+}
+
+func (c *_testTokenCredential) GetToken(_ context.Context, opts TokenRequestOptions) (AccessToken, error) {
+	c.numCalls++
+	c.scopes = opts.Scopes
+	if c.err != nil {
+		return AccessToken{}, c.err
+	}
+	return AccessToken{Token: "token" + string(rune('0'+c.numCalls)), ExpiresOn: time.Now().Add(c.ttl)}, nil
+}
+
+func TestNewRestClientWithTokenCredential(t *testing.T) {
+	name := "TestNewRestClientWithTokenCredential"
+	cred := &_testTokenCredential{ttl: time.Hour}
+	if _, err := NewRestClientWithTokenCredential(nil, "AccountEndpoint=https://localhost:8081/", nil); err == nil {
+		t.Fatalf("%s failed: expected error for nil credential", name)
+	}
+	if _, err := NewRestClientWithTokenCredential(nil, "DefaultDb=mydb", cred); err == nil {
+		t.Fatalf("%s failed: expected error for missing endpoint", name)
+	}
+	client, err := NewRestClientWithTokenCredential(nil, "AccountEndpoint=https://myaccount.documents.azure.com:443/", cred)
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+
+	for i := 0; i < 3; i++ {
+		req, _ := http.NewRequest("GET", "https://myaccount.documents.azure.com:443/dbs", nil)
+		req = client.addAuthHeader(req, "GET", "dbs", "")
+		if auth, _ := url.QueryUnescape(req.Header.Get("Authorization")); auth != "type=aad&ver=1.0&sig=token1" {
+			t.Fatalf("%s failed: unexpected Authorization header %#v", name, auth)
+		}
+		if req.Header.Get("X-Ms-Date") == "" {
+			t.Fatalf("%s failed: X-Ms-Date header is missing", name)
+		}
+	}
+	if cred.numCalls != 1 {
+		t.Fatalf("%s failed: token must be cached, but credential was called %d times", name, cred.numCalls)
+	}
+	if len(cred.scopes) != 1 || cred.scopes[0] != "https://myaccount.documents.azure.com:443/.default" {
+		t.Fatalf("%s failed: unexpected scopes %#v", name, cred.scopes)
+	}
+}
+
+func TestAadTokenProvider_refresh(t *testing.T) {
+	name := "TestAadTokenProvider_refresh"
+	cred := &_testTokenCredential{ttl: time.Minute}
+	p, _ := newAadTokenProvider(cred, "https://localhost:8081")
+	p.getToken()
+	if token, err := p.getToken(); err != nil || token != "token2" || cred.numCalls != 2 {
+		t.Fatalf("%s failed: token about to expire must be refreshed (%s/%s/%d)", name, token, err, cred.numCalls)
+	}
+
+	cred.err = errors.New("error")
+	if _, err := p.getToken(); err == nil {
+		t.Fatalf("%s failed: expected error from credential", name)
+	}
+}
+
+func TestNewConnectorWithTokenCredential(t *testing.T) {
+	name := "TestNewConnectorWithTokenCredential"
+	cred := &_testTokenCredential{ttl: time.Hour}
+	if _, err := NewConnectorWithTokenCredential("AccountEndpoint=https://localhost:8081", nil); err == nil {
+		t.Fatalf("%s failed: expected error for nil credential", name)
+	}
+	connector, err := NewConnectorWithTokenCredential("AccountEndpoint=https://localhost:8081;DefaultDb=mydb", cred)
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	conn, err := connector.Connect(context.Background())
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if c := conn.(*Conn); c.defaultDb != "mydb" || c.restClient.tokenProvider != connector.tokenProvider {
+		t.Fatalf("%s failed: connection must use default db and shared token provider", name)
+	}
+	if _, ok := connector.Driver().(*Driver); !ok {
+		t.Fatalf("%s failed: expected *Driver", name)
+	}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+}
+
+func TestMsiTokenCredential(t *testing.T) {
+	name := "TestMsiTokenCredential"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-IDENTITY-HEADER") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		q := r.URL.Query()
+		if q.Get("resource") != "https://myaccount.documents.azure.com:443" || q.Get("client_id") != "myclient" || q.Get("api-version") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"access_token":"msitoken","expires_on":"1900000000","resource":"https://myaccount.documents.azure.com:443"}`))
+	}))
+	defer server.Close()
+	os.Setenv("IDENTITY_ENDPOINT", server.URL)
+	os.Setenv("IDENTITY_HEADER", "secret")
+	defer os.Unsetenv("IDENTITY_ENDPOINT")
+	defer os.Unsetenv("IDENTITY_HEADER")
+
+	cred := &msiTokenCredential{httpClient: server.Client(), clientId: "myclient"}
+	token, err := cred.GetToken(context.Background(), TokenRequestOptions{Scopes: []string{"https://myaccount.documents.azure.com:443/.default"}})
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if token.Token != "msitoken" || token.ExpiresOn.Unix() != 1900000000 {
+		t.Fatalf("%s failed: unexpected token %#v", name, token)
+	}
+
+	cred.clientId = ""
+	if _, err := cred.GetToken(context.Background(), TokenRequestOptions{Scopes: []string{"https://myaccount.documents.azure.com:443/.default"}}); err == nil {
+		t.Fatalf("%s failed: expected error for non-200 response", name)
+	}
+}
+
+func TestNewRestClient_AuthMode(t *testing.T) {
+	name := "TestNewRestClient_AuthMode"
+	client1, err := NewRestClient(nil, "AccountEndpoint=https://myaccount.documents.azure.com:443/;AuthMode=msi")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	client2, _ := NewRestClient(nil, "AccountEndpoint=https://myaccount.documents.azure.com:443/;AuthMode=MSI")
+	if client1.tokenProvider == nil || client1.tokenProvider != client2.tokenProvider {
+		t.Fatalf("%s failed: managed identity token provider must be shared", name)
+	}
+	if client, _ := NewRestClient(nil, "AccountEndpoint=https://myaccount.documents.azure.com:443/;AuthMode=msi;MsiClientId=abc"); client.tokenProvider == client1.tokenProvider {
+		t.Fatalf("%s failed: token provider of different client ids must not be shared", name)
+	}
+	if _, err := NewRestClient(nil, "AccountEndpoint=https://myaccount.documents.azure.com:443/;AuthMode=key"); err == nil {
+		t.Fatalf("%s failed: expected error for missing AccountKey", name)
+	}
+	if _, err := NewRestClient(nil, "AccountEndpoint=https://myaccount.documents.azure.com:443/;AccountKey=abc;AuthMode=invalid"); err == nil {
+		t.Fatalf("%s failed: expected error for invalid AuthMode", name)
+	}
+}
+
+func TestRestClient_SecondaryAccountKey(t *testing.T) {
+	name := "TestRestClient_SecondaryAccountKey"
+	primaryKey, secondaryKey := "cHJpbWFyeQ==", "c2Vjb25kYXJ5"
+	numRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		numRequests++
+		// only requests signed with the secondary key are accepted
+		stringToSign := strings.ToLower("GET\ndbs\n\n" + r.Header.Get("X-Ms-Date") + "\n\n")
+		h := hmac.New(sha256.New, []byte("secondary"))
+		h.Write([]byte(stringToSign))
+		validAuth := url.QueryEscape("type=master&ver=1.0&sig=" + base64.StdEncoding.EncodeToString(h.Sum(nil)))
+		if r.Header.Get("Authorization") != validAuth {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"_rid":"","Databases":[],"_count":0}`))
+	}))
+	defer server.Close()
+
+	client, err := NewRestClient(nil, "AccountEndpoint="+server.URL+";AccountKey="+primaryKey+";SecondaryAccountKey="+secondaryKey)
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+
+	if result := client.ListDatabases(); result.Error() != nil || numRequests != 2 {
+		t.Fatalf("%s failed: expected fallback to secondary key (%d requests): %s", name, numRequests, result.Error())
+	}
+	if result := client.ListDatabases(); result.Error() != nil || numRequests != 3 {
+		t.Fatalf("%s failed: expected secondary key to stay active (%d requests): %s", name, numRequests, result.Error())
+	}
+
+	if err := client.SetAccountKeys("invalid-base64", ""); err == nil {
+		t.Fatalf("%s failed: expected error for invalid key", name)
+	}
+	if err := client.SetAccountKeys(primaryKey, ""); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if result := client.ListDatabases(); result.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("%s failed: expected StatusCode=401 without fallback key but received %d", name, result.StatusCode)
+	}
+}
+
+func TestConnector_SetAccountKeys(t *testing.T) {
+	name := "TestConnector_SetAccountKeys"
+	connector, err := NewConnectorFromConnStr("AccountEndpoint=https://localhost:8081;AccountKey=cHJpbWFyeQ==")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	conn1, _ := connector.Connect(context.Background())
+	conn2, _ := connector.Connect(context.Background())
+	if err := connector.SetAccountKeys("c2Vjb25kYXJ5", ""); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	for _, conn := range []interface{}{conn1, conn2} {
+		if key, _ := conn.(*Conn).restClient.keys.get(); string(key) != "secondary" {
+			t.Fatalf("%s failed: new key must be used by all connections, but received %s", name, key)
+		}
+	}
+
+	aadConnector, _ := NewConnectorWithTokenCredential("AccountEndpoint=https://localhost:8081", &_testTokenCredential{ttl: time.Hour})
+	if err := aadConnector.SetAccountKeys("c2Vjb25kYXJ5", ""); err == nil {
+		t.Fatalf("%s failed: expected error for Azure AD connector", name)
+	}
+}
@@ -0,0 +1,197 @@
+package gocosmos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is matched (via errors.Is) by errors returned when a request is rejected by an open circuit breaker,
+// see CircuitOpenError.
+//
+// Available since v0.1.1
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitOpenError is returned, without sending the request, when the circuit breaker of the target endpoint is open
+// (see connection string option CircuitBreakerThreshold).
+//
+// Available since v0.1.1
+type CircuitOpenError struct {
+	Endpoint   string        // host of the endpoint, e.g. "myaccount.documents.azure.com:443"
+	RetryAfter time.Duration // remaining time until the breaker lets a probe request through
+}
+
+// Error implements error.Error.
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker is open for endpoint %s, retry after %s", e.Endpoint, e.RetryAfter)
+}
+
+// Is returns true if target is ErrCircuitOpen.
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+const (
+	circuitClosed = iota
+	circuitOpen
+	circuitHalfOpen // the cooldown has elapsed and a probe request is in flight
+)
+
+// circuitState is the state of the circuit breaker of an endpoint.
+type circuitState struct {
+	state    int
+	failures int       // number of consecutive failures
+	openedAt time.Time // time the breaker was last opened
+}
+
+// circuitBreaker tracks failures per endpoint: the breaker of an endpoint opens after threshold consecutive failures
+// (StatusCode>=500 or requests that receive no response), rejecting requests with CircuitOpenError. Once cooldown has
+// elapsed, a single probe request is let through: the breaker closes if it succeeds, or opens again if it fails.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	lock      sync.Mutex
+	endpoints map[string]*circuitState
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, endpoints: make(map[string]*circuitState)}
+}
+
+// allow checks if a request to the endpoint can be sent, returning true if the request is a probe.
+func (b *circuitBreaker) allow(endpoint string) (bool, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	s, ok := b.endpoints[endpoint]
+	if !ok || s.state == circuitClosed {
+		return false, nil
+	}
+	elapsed := time.Since(s.openedAt)
+	if s.state == circuitOpen && elapsed >= b.cooldown {
+		s.state = circuitHalfOpen
+		return true, nil
+	}
+	retryAfter := b.cooldown - elapsed
+	if retryAfter < 0 {
+		retryAfter = 0
+	}
+	return false, &CircuitOpenError{Endpoint: endpoint, RetryAfter: retryAfter}
+}
+
+// record records the outcome of a request to the endpoint, returning the new state of the breaker if it has changed
+// (-1 otherwise).
+func (b *circuitBreaker) record(endpoint string, isProbe, failed bool) int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	s, ok := b.endpoints[endpoint]
+	if !ok {
+		if !failed {
+			return -1
+		}
+		s = &circuitState{}
+		b.endpoints[endpoint] = s
+	}
+	if !failed {
+		s.failures = 0
+		if isProbe {
+			s.state = circuitClosed
+			return circuitClosed
+		}
+		return -1
+	}
+	s.failures++
+	if isProbe || (s.state == circuitClosed && s.failures >= b.threshold) {
+		s.state, s.openedAt = circuitOpen, time.Now()
+		return circuitOpen
+	}
+	return -1
+}
+
+// breakerTransport is a http.RoundTripper that guards requests with a circuitBreaker.
+type breakerTransport struct {
+	base    http.RoundTripper
+	breaker *circuitBreaker
+	client  *RestClient // the client whose logger receives state changes
+}
+
+// RoundTrip implements http.RoundTripper.RoundTrip.
+func (t *breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := req.URL.Host
+	isProbe, err := t.breaker.allow(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(req)
+	// requests canceled by the caller say nothing about the endpoint's health, but a canceled probe must not leave the
+	// breaker half-open forever
+	failed := err == nil && resp.StatusCode >= 500 || err != nil && (isProbe || !errors.Is(err, context.Canceled))
+	switch t.breaker.record(endpoint, isProbe, failed) {
+	case circuitOpen:
+		t.client.log(LogLevelWarn, "circuit breaker opened", map[string]interface{}{
+			"endpoint": endpoint, "cooldown_ms": t.breaker.cooldown.Milliseconds()})
+	case circuitClosed:
+		t.client.log(LogLevelInfo, "circuit breaker closed", map[string]interface{}{"endpoint": endpoint})
+	}
+	return resp, err
+}
+
+var (
+	sharedBreakersLock sync.Mutex
+	sharedBreakers     = make(map[string]*circuitBreaker)
+)
+
+// _sharedCircuitBreaker returns the circuitBreaker shared by all clients of the account endpoint configured with the
+// same settings, so that all connections of a sql.DB see the same endpoint health.
+func _sharedCircuitBreaker(endpoint string, threshold int, cooldown time.Duration) *circuitBreaker {
+	key := endpoint + "|" + strconv.Itoa(threshold) + "|" + cooldown.String()
+	sharedBreakersLock.Lock()
+	defer sharedBreakersLock.Unlock()
+	breaker, ok := sharedBreakers[key]
+	if !ok {
+		breaker = newCircuitBreaker(threshold, cooldown)
+		sharedBreakers[key] = breaker
+	}
+	return breaker
+}
+
+// _parseCircuitBreaker parses connection string options CircuitBreakerThreshold and CircuitBreakerCooldownMs, returning
+// nil if the circuit breaker is not enabled.
+func _parseCircuitBreaker(params map[string]string, endpoint string) (*circuitBreaker, error) {
+	v, ok := params["CIRCUITBREAKERTHRESHOLD"]
+	if !ok {
+		return nil, nil
+	}
+	threshold, err := strconv.Atoi(v)
+	if err != nil || threshold < 0 {
+		return nil, fmt.Errorf("invalid CircuitBreakerThreshold <%s>", v)
+	}
+	cooldownMs := 30000
+	if v, ok := params["CIRCUITBREAKERCOOLDOWNMS"]; ok {
+		if cooldownMs, err = strconv.Atoi(v); err != nil || cooldownMs <= 0 {
+			return nil, fmt.Errorf("invalid CircuitBreakerCooldownMs <%s>", v)
+		}
+	}
+	if threshold == 0 {
+		return nil, nil
+	}
+	return _sharedCircuitBreaker(endpoint, threshold, time.Duration(cooldownMs)*time.Millisecond), nil
+}
+
+// _withCircuitBreaker returns a copy of httpClient whose transport is guarded by the circuit breaker.
+func _withCircuitBreaker(httpClient *http.Client, timeout time.Duration, breaker *circuitBreaker, restClient *RestClient) *http.Client {
+	guarded := &http.Client{Timeout: timeout}
+	if httpClient != nil {
+		copied := *httpClient
+		guarded = &copied
+	}
+	base := guarded.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	guarded.Transport = &breakerTransport{base: base, breaker: breaker, client: restClient}
+	return guarded
+}
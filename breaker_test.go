@@ -0,0 +1,110 @@
+package gocosmos
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	name := "TestCircuitBreaker"
+	breaker := newCircuitBreaker(2, 20*time.Millisecond)
+	if isProbe, err := breaker.allow("host1"); isProbe || err != nil {
+		t.Fatalf("%s failed: closed breaker must allow requests %#v / %s", name, isProbe, err)
+	}
+	breaker.record("host1", false, true)
+	breaker.record("host1", false, false)
+	if state := breaker.record("host1", false, true); state != -1 {
+		t.Fatalf("%s failed: successes must reset consecutive failures", name)
+	}
+	if state := breaker.record("host1", false, true); state != circuitOpen {
+		t.Fatalf("%s failed: expected breaker to open but received %d", name, state)
+	}
+	_, err := breaker.allow("host1")
+	var openErr *CircuitOpenError
+	if !errors.Is(err, ErrCircuitOpen) || !errors.As(err, &openErr) || openErr.Endpoint != "host1" || openErr.RetryAfter <= 0 {
+		t.Fatalf("%s failed: expected CircuitOpenError but received %#v", name, err)
+	}
+	if _, err := breaker.allow("host2"); err != nil {
+		t.Fatalf("%s failed: breakers of other endpoints must not be affected: %s", name, err)
+	}
+
+	time.Sleep(25 * time.Millisecond)
+	if isProbe, err := breaker.allow("host1"); !isProbe || err != nil {
+		t.Fatalf("%s failed: expected probe request after cooldown %#v / %s", name, isProbe, err)
+	}
+	if _, err := breaker.allow("host1"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("%s failed: only one probe request must be let through", name)
+	}
+	if state := breaker.record("host1", true, true); state != circuitOpen {
+		t.Fatalf("%s failed: failed probe must reopen the breaker, received %d", name, state)
+	}
+	time.Sleep(25 * time.Millisecond)
+	isProbe, _ := breaker.allow("host1")
+	if state := breaker.record("host1", isProbe, false); state != circuitClosed {
+		t.Fatalf("%s failed: successful probe must close the breaker, received %d", name, state)
+	}
+	if isProbe, err := breaker.allow("host1"); isProbe || err != nil {
+		t.Fatalf("%s failed: closed breaker must allow requests %#v / %s", name, isProbe, err)
+	}
+}
+
+func TestRestClient_CircuitBreaker(t *testing.T) {
+	name := "TestRestClient_CircuitBreaker"
+	var requests, healthy int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"id":"mydb"}`))
+	}))
+	defer server.Close()
+	connStr := "AccountEndpoint=" + server.URL + ";AccountKey=cHJpbWFyeQ==;CircuitBreakerThreshold=3;CircuitBreakerCooldownMs=50"
+	client, err := NewRestClient(nil, connStr)
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	logger := &_recordingLogger{}
+	client.SetLogger(logger)
+	for i := 0; i < 3; i++ {
+		if result := client.GetDatabase("mydb"); result.StatusCode != http.StatusServiceUnavailable {
+			t.Fatalf("%s failed: expected StatusCode=503 but received %d / %s", name, result.StatusCode, result.Error())
+		}
+	}
+	result := client.GetDatabase("mydb")
+	if !errors.Is(result.CallErr, ErrCircuitOpen) || atomic.LoadInt32(&requests) != 3 {
+		t.Fatalf("%s failed: expected request to fail fast but received %#v / %d requests", name, result.CallErr, requests)
+	}
+	if logger.find(LogLevelWarn, "circuit breaker opened") == nil {
+		t.Fatalf("%s failed: opening the breaker must be logged", name)
+	}
+	if other, _ := NewRestClient(nil, connStr); !errors.Is(other.GetDatabase("mydb").CallErr, ErrCircuitOpen) {
+		t.Fatalf("%s failed: clients of the same endpoint must share the breaker", name)
+	}
+
+	atomic.StoreInt32(&healthy, 1)
+	time.Sleep(60 * time.Millisecond)
+	if result := client.GetDatabase("mydb"); result.Error() != nil || result.Id != "mydb" {
+		t.Fatalf("%s failed: expected probe request to succeed but received %s", name, result.Error())
+	}
+	if result := client.GetDatabase("mydb"); result.Error() != nil {
+		t.Fatalf("%s failed: breaker must be closed after successful probe: %s", name, result.Error())
+	}
+	if logger.find(LogLevelInfo, "circuit breaker closed") == nil {
+		t.Fatalf("%s failed: closing the breaker must be logged", name)
+	}
+
+	for _, invalid := range []string{"CircuitBreakerThreshold=-1", "CircuitBreakerThreshold=abc", "CircuitBreakerThreshold=1;CircuitBreakerCooldownMs=0"} {
+		if _, err := NewRestClient(nil, "AccountEndpoint="+server.URL+";AccountKey=cHJpbWFyeQ==;"+invalid); err == nil {
+			t.Fatalf("%s failed: expected error for %s", name, invalid)
+		}
+	}
+	if client, _ := NewRestClient(nil, "AccountEndpoint="+server.URL+";AccountKey=cHJpbWFyeQ==;CircuitBreakerThreshold=0"); client == nil {
+		t.Fatalf("%s failed: CircuitBreakerThreshold=0 must disable the breaker", name)
+	}
+}
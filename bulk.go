@@ -0,0 +1,189 @@
+package gocosmos
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// BulkDocumentsReq specifies a request to create/upsert documents in bulk.
+//
+// Available since v0.1.1
+type BulkDocumentsReq struct {
+	DbName, CollName string
+	// PartitionKeyPath is the partition key path of the collection (e.g. "/username"), or comma-separated paths of a
+	// hierarchical partition key (e.g. "/tenant,/user"). If empty, the paths are fetched from the collection's metadata.
+	PartitionKeyPath string
+	// Documents to be written.
+	Documents []map[string]interface{}
+	// Concurrency is the maximum number of concurrent requests, default value is 8.
+	Concurrency int
+	// MaxRetries is the maximum number of retries when a request is throttled (StatusCode=429), default value is 5.
+	MaxRetries int
+}
+
+// BulkDocResult captures the result of writing a single document in bulk operation.
+//
+// Available since v0.1.1
+type BulkDocResult struct {
+	Index         int     // index of the document in the request
+	StatusCode    int     // HTTP status code returned from CosmosDB
+	RequestCharge float64 // number of request units consumed, including retries
+	DocInfo       DocInfo // the written document, available if the operation was successful
+	Error         error   // error occurred while writing the document, nil if successful
+}
+
+// RespBulkDocs captures the response from BulkCreateDocuments/BulkUpsertDocuments call.
+//
+// Available since v0.1.1
+type RespBulkDocs struct {
+	// CallErr holds error that prevented the bulk operation from starting (e.g. collection metadata could not be fetched).
+	CallErr error
+	// Results holds result of each document, in the same order as the request's documents.
+	Results []BulkDocResult
+	// RequestCharge is the total number of request units consumed by the bulk operation.
+	RequestCharge float64
+	// NumSucceeded and NumFailed are number of documents written successfully and failed.
+	NumSucceeded, NumFailed int
+}
+
+// Error returns CallErr if not nil, otherwise returns the error of the first failed document (if any).
+func (r *RespBulkDocs) Error() error {
+	if r.CallErr != nil {
+		return r.CallErr
+	}
+	for _, result := range r.Results {
+		if result.Error != nil {
+			return result.Error
+		}
+	}
+	return nil
+}
+
+// BulkCreateDocuments creates documents in bulk.
+//
+// Documents are grouped by partition key value (documents with the same partition key value always belong to the same
+// partition key range) and groups are written concurrently. Throttled requests (StatusCode=429) are retried after the
+// delay suggested by the server, or the delay of the client's retry policy if the server does not suggest one.
+//
+// Available since v0.1.1
+func (c *RestClient) BulkCreateDocuments(r BulkDocumentsReq) *RespBulkDocs {
+	return c.bulkWriteDocuments(r, false)
+}
+
+// BulkUpsertDocuments creates or replaces documents in bulk. See BulkCreateDocuments for details.
+//
+// Available since v0.1.1
+func (c *RestClient) BulkUpsertDocuments(r BulkDocumentsReq) *RespBulkDocs {
+	return c.bulkWriteDocuments(r, true)
+}
+
+// _extractPkValue extracts value at the partition key path (e.g. "/address/city") from a document.
+func _extractPkValue(doc map[string]interface{}, pkPath string) (interface{}, bool) {
+	parts, err := _parsePkPath(pkPath)
+	if err != nil {
+		return nil, false
+	}
+	var value interface{} = doc
+	for _, part := range parts {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+func (c *RestClient) bulkWriteDocuments(r BulkDocumentsReq, isUpsert bool) *RespBulkDocs {
+	result := &RespBulkDocs{Results: make([]BulkDocResult, len(r.Documents))}
+	pkPaths, err := c.fetchPkPaths(r.DbName, r.CollName, r.PartitionKeyPath)
+	if err != nil {
+		result.CallErr = err
+		return result
+	}
+	concurrency := r.Concurrency
+	if concurrency <= 0 {
+		concurrency = 8
+	}
+	maxRetries := r.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = 5
+	}
+
+	// group documents by partition key value
+	groups := make(map[string][]int)
+	groupKeys := make([]string, 0)
+	pkValues := make([][]interface{}, len(r.Documents))
+	for i, doc := range r.Documents {
+		result.Results[i].Index = i
+		docPkValues, err := _extractPkValues(doc, pkPaths)
+		if err != nil {
+			result.Results[i].Error = errors.New(err.Error() + " #" + strconv.Itoa(i))
+			continue
+		}
+		pkValues[i] = docPkValues
+		js, _ := json.Marshal(docPkValues)
+		key := string(js)
+		if _, ok := groups[key]; !ok {
+			groupKeys = append(groupKeys, key)
+		}
+		groups[key] = append(groups[key], i)
+	}
+
+	groupCh := make(chan []int, len(groupKeys))
+	for _, key := range groupKeys {
+		groupCh <- groups[key]
+	}
+	close(groupCh)
+	wg := sync.WaitGroup{}
+	for w := 0; w < concurrency && w < len(groupKeys); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for indexes := range groupCh {
+				for _, i := range indexes {
+					spec := DocumentSpec{DbName: r.DbName, CollName: r.CollName, IsUpsert: isUpsert,
+						PartitionKeyValues: pkValues[i], DocumentData: r.Documents[i]}
+					docResult := &result.Results[i]
+					for retry := 0; ; retry++ {
+						restResult := c.CreateDocument(spec)
+						docResult.StatusCode = restResult.StatusCode
+						if restResult.RequestCharge > 0 {
+							docResult.RequestCharge += restResult.RequestCharge
+						}
+						if restResult.StatusCode == 429 && retry < maxRetries {
+							delayMs, err := strconv.Atoi(restResult.RespHeader["X-MS-RETRY-AFTER-MS"])
+							if err != nil || delayMs <= 0 {
+								delayMs = int(c.retryPolicy.delay(retry).Milliseconds())
+							}
+							c.log(LogLevelInfo, "document write throttled, retrying", map[string]interface{}{
+								"db": r.DbName, "collection": r.CollName, "retry": retry + 1, "delay_ms": delayMs})
+							c.stats.retried()
+							time.Sleep(time.Duration(delayMs) * time.Millisecond)
+							continue
+						}
+						docResult.Error = restResult.Error()
+						docResult.DocInfo = restResult.DocInfo
+						break
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, docResult := range result.Results {
+		result.RequestCharge += docResult.RequestCharge
+		if docResult.Error == nil {
+			result.NumSucceeded++
+		} else {
+			result.NumFailed++
+		}
+	}
+	return result
+}
@@ -0,0 +1,69 @@
+package gocosmos
+
+import (
+	"strconv"
+	"testing"
+)
+
+func Test_extractPkValue(t *testing.T) {
+	name := "Test_extractPkValue"
+	doc := map[string]interface{}{"id": "1", "username": "user", "address": map[string]interface{}{"city": "HCM"}}
+	testData := map[string]interface{}{"/id": "1", "/username": "user", "/address/city": "HCM"}
+	for path, expected := range testData {
+		if v, ok := _extractPkValue(doc, path); !ok || v != expected {
+			t.Fatalf("%s failed: <%s> expected %#v but received %#v", name, path, expected, v)
+		}
+	}
+	for _, path := range []string{"/email", "/address/street", "/username/first"} {
+		if v, ok := _extractPkValue(doc, path); ok {
+			t.Fatalf("%s failed: <%s> expected not found but received %#v", name, path, v)
+		}
+	}
+}
+
+func TestRestClient_BulkDocuments(t *testing.T) {
+	name := "TestRestClient_BulkDocuments"
+	client := _newRestClient(t, name)
+
+	dbname := "mydb"
+	collname := "mytable"
+	client.DeleteDatabase(dbname)
+	client.CreateDatabase(DatabaseSpec{Id: dbname})
+	client.CreateCollection(CollectionSpec{DbName: dbname, CollName: collname,
+		PartitionKeyInfo: map[string]interface{}{"paths": []string{"/username"}, "kind": "Hash"}})
+
+	docs := make([]map[string]interface{}, 0)
+	for i := 0; i < 100; i++ {
+		docs = append(docs, map[string]interface{}{"id": strconv.Itoa(i), "username": "user" + strconv.Itoa(i%10), "index": i})
+	}
+	docs = append(docs, map[string]interface{}{"id": "nopk"})
+	result := client.BulkCreateDocuments(BulkDocumentsReq{DbName: dbname, CollName: collname, Documents: docs})
+	if result.CallErr != nil {
+		t.Fatalf("%s failed: %s", name, result.CallErr)
+	}
+	if result.NumSucceeded != 100 || result.NumFailed != 1 || result.Results[100].Error == nil {
+		t.Fatalf("%s failed: expected 100 succeeded/1 failed but received %d/%d", name, result.NumSucceeded, result.NumFailed)
+	}
+	if result.RequestCharge <= 0 {
+		t.Fatalf("%s failed: expected request charge to be positive but received %#v", name, result.RequestCharge)
+	}
+	for i := 0; i < 100; i++ {
+		if r := result.Results[i]; r.Index != i || r.Error != nil || r.DocInfo.Id() != strconv.Itoa(i) {
+			t.Fatalf("%s failed: unexpected result for document #%d: %#v", name, i, r)
+		}
+	}
+
+	result = client.BulkCreateDocuments(BulkDocumentsReq{DbName: dbname, CollName: collname, PartitionKeyPath: "/username", Documents: docs[:10]})
+	if result.NumFailed != 10 || result.Results[0].StatusCode != 409 || result.Results[0].Error == nil {
+		t.Fatalf("%s failed: expected conflicts but received %#v", name, result.Results[0])
+	}
+	result = client.BulkUpsertDocuments(BulkDocumentsReq{DbName: dbname, CollName: collname, Documents: docs[:100], Concurrency: 4})
+	if result.Error() != nil || result.NumSucceeded != 100 {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	}
+
+	result = client.BulkCreateDocuments(BulkDocumentsReq{DbName: dbname, CollName: "notexist", Documents: docs})
+	if result.CallErr == nil {
+		t.Fatalf("%s failed: expected error for non-existing collection", name)
+	}
+}
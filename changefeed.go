@@ -0,0 +1,346 @@
+package gocosmos
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/btnguyen2k/consu/reddo"
+)
+
+// ChangeFeedHandler is invoked by ChangeFeedProcessor with a batch of changed documents of a partition key range.
+// Progress of the partition key range is checkpointed only if the handler returns nil, otherwise the same batch is
+// delivered again on the next poll.
+//
+// Available since v0.1.1
+type ChangeFeedHandler func(pkrangeId string, docs []DocInfo) error
+
+// ChangeFeedProcessorOptions specifies settings of a ChangeFeedProcessor.
+//
+// Available since v0.1.1
+type ChangeFeedProcessorOptions struct {
+	// DbName and CollName specify the monitored collection.
+	DbName, CollName string
+	// LeaseDbName and LeaseCollName specify the lease collection, which must exist and be partitioned by "/id".
+	LeaseDbName, LeaseCollName string
+	// LeasePrefix is the prefix of lease documents' id, default value is "<db-name>.<collection-name>.".
+	// Processors sharing the same lease collection and prefix cooperate to process the change feed.
+	LeasePrefix string
+	// InstanceName identifies the processor instance, default value is generated from host name and process id.
+	InstanceName string
+	// StartFromBeginning, if true, processes changes from the beginning of the collection; otherwise only changes made
+	// after the lease is first created are processed.
+	StartFromBeginning bool
+	// MaxItemCount is the maximum number of documents per batch, 0 means server's default.
+	MaxItemCount int
+	// PollInterval is the delay between polls, default value is 5 seconds.
+	PollInterval time.Duration
+	// LeaseExpiration is the duration after which a lease not renewed by its owner can be taken over by other
+	// instances, default value is 60 seconds.
+	LeaseExpiration time.Duration
+	// ErrorHandler, if not nil, is invoked with errors occurred during processing.
+	ErrorHandler func(err error)
+}
+
+// ChangeFeedProcessor reads the change feed of a collection and delivers changed documents to a ChangeFeedHandler.
+//
+// Each partition key range of the monitored collection is tracked by a lease document stored in the lease collection.
+// The lease records the owner instance and the continuation of the partition key range. Multiple processor instances
+// sharing the same lease collection and lease prefix distribute the partition key ranges among themselves; leases of
+// stopped or crashed instances are taken over once expired.
+//
+// Available since v0.1.1
+type ChangeFeedProcessor struct {
+	client  *RestClient
+	opts    ChangeFeedProcessorOptions
+	handler ChangeFeedHandler
+	lock    sync.Mutex
+	stopCh  chan struct{}
+	doneCh  chan struct{}
+}
+
+// NewChangeFeedProcessor constructs a new ChangeFeedProcessor instance.
+//
+// Available since v0.1.1
+func NewChangeFeedProcessor(client *RestClient, opts ChangeFeedProcessorOptions, handler ChangeFeedHandler) (*ChangeFeedProcessor, error) {
+	if client == nil {
+		return nil, errors.New("rest client is nil")
+	}
+	if handler == nil {
+		return nil, errors.New("change feed handler is nil")
+	}
+	if opts.DbName == "" || opts.CollName == "" {
+		return nil, errors.New("database/collection is missing")
+	}
+	if opts.LeaseDbName == "" || opts.LeaseCollName == "" {
+		return nil, errors.New("lease database/collection is missing")
+	}
+	if opts.LeasePrefix == "" {
+		opts.LeasePrefix = opts.DbName + "." + opts.CollName + "."
+	}
+	if opts.InstanceName == "" {
+		hostname, _ := os.Hostname()
+		opts.InstanceName = fmt.Sprintf("%s-%d-%d", hostname, os.Getpid(), time.Now().UnixNano())
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 5 * time.Second
+	}
+	if opts.LeaseExpiration <= 0 {
+		opts.LeaseExpiration = 60 * time.Second
+	}
+	return &ChangeFeedProcessor{client: client, opts: opts, handler: handler}, nil
+}
+
+// InstanceName returns the name identifying this processor instance.
+func (p *ChangeFeedProcessor) InstanceName() string {
+	return p.opts.InstanceName
+}
+
+// Start starts processing the change feed in background.
+func (p *ChangeFeedProcessor) Start() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.stopCh != nil {
+		return errors.New("change feed processor has already been started")
+	}
+	p.stopCh = make(chan struct{})
+	p.doneCh = make(chan struct{})
+	go p.run(p.stopCh, p.doneCh)
+	return nil
+}
+
+// Stop stops processing the change feed and releases all leases owned by this instance.
+func (p *ChangeFeedProcessor) Stop() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.stopCh == nil {
+		return
+	}
+	close(p.stopCh)
+	<-p.doneCh
+	p.stopCh, p.doneCh = nil, nil
+	p.releaseLeases()
+}
+
+func (p *ChangeFeedProcessor) run(stopCh, doneCh chan struct{}) {
+	defer close(doneCh)
+	ticker := time.NewTicker(p.opts.PollInterval)
+	defer ticker.Stop()
+	for {
+		p.runOnce(stopCh)
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *ChangeFeedProcessor) onError(err error) {
+	if err != nil && p.opts.ErrorHandler != nil {
+		p.opts.ErrorHandler(err)
+	}
+}
+
+func _leaseStr(lease DocInfo, attr string) string {
+	v, _ := lease.GetAttrAsTypeUnsafe(attr, reddo.TypeString).(string)
+	return v
+}
+
+func _leaseTimestamp(lease DocInfo) int64 {
+	v, _ := lease.GetAttrAsTypeUnsafe("timestamp", reddo.TypeInt).(int64)
+	return v
+}
+
+func (p *ChangeFeedProcessor) isExpired(lease DocInfo, now time.Time) bool {
+	return now.Unix()-_leaseTimestamp(lease) > int64(p.opts.LeaseExpiration/time.Second)
+}
+
+// loadLeases fetches all lease documents of this processor group, indexed by partition key range id.
+func (p *ChangeFeedProcessor) loadLeases() (map[string]DocInfo, error) {
+	query := QueryReq{DbName: p.opts.LeaseDbName, CollName: p.opts.LeaseCollName, CrossPartitionEnabled: true,
+		Query:  "SELECT * FROM c WHERE STARTSWITH(c.id, @prefix)",
+		Params: []interface{}{map[string]interface{}{"name": "@prefix", "value": p.opts.LeasePrefix}},
+	}
+	leases := make(map[string]DocInfo)
+	for {
+		result := p.client.QueryDocuments(query)
+		if err := result.Error(); err != nil {
+			return nil, err
+		}
+		for _, doc := range result.Documents {
+			leases[_leaseStr(doc, "pkrangeId")] = doc
+		}
+		if result.ContinuationToken == "" {
+			break
+		}
+		query.ContinuationToken = result.ContinuationToken
+	}
+	return leases, nil
+}
+
+// replaceLease updates a lease document using optimistic concurrency, returns the updated lease or nil if the lease
+// has been modified by another instance.
+func (p *ChangeFeedProcessor) replaceLease(lease DocInfo, owner, continuation string) DocInfo {
+	doc := lease.RemoveSystemAttrs()
+	doc["owner"] = owner
+	doc["continuation"] = continuation
+	doc["timestamp"] = time.Now().Unix()
+	id := _leaseStr(lease, "id")
+	result := p.client.ReplaceDocument(lease.Etag(), DocumentSpec{DbName: p.opts.LeaseDbName, CollName: p.opts.LeaseCollName,
+		PartitionKeyValues: []interface{}{id}, DocumentData: doc})
+	if err := result.Error(); err != nil {
+		if result.StatusCode != 412 {
+			p.onError(err)
+		}
+		return nil
+	}
+	return result.DocInfo
+}
+
+func (p *ChangeFeedProcessor) createLease(pkrangeId, continuation string) DocInfo {
+	id := p.opts.LeasePrefix + pkrangeId
+	doc := map[string]interface{}{"id": id, "pkrangeId": pkrangeId, "owner": "", "continuation": continuation, "timestamp": 0}
+	result := p.client.CreateDocument(DocumentSpec{DbName: p.opts.LeaseDbName, CollName: p.opts.LeaseCollName,
+		PartitionKeyValues: []interface{}{id}, DocumentData: doc})
+	if err := result.Error(); err != nil {
+		if result.StatusCode != 409 {
+			p.onError(err)
+		}
+		return nil
+	}
+	return result.DocInfo
+}
+
+func (p *ChangeFeedProcessor) runOnce(stopCh chan struct{}) {
+	listResult := p.client.ListPartitionKeyRanges(p.opts.DbName, p.opts.CollName)
+	if err := listResult.Error(); err != nil {
+		p.onError(err)
+		return
+	}
+	pkranges := listResult.PartitionKeyRanges
+	sort.Slice(pkranges, func(i, j int) bool { return pkranges[i].Id < pkranges[j].Id })
+	leases, err := p.loadLeases()
+	if err != nil {
+		p.onError(err)
+		return
+	}
+
+	// make sure every partition key range has a lease; after a split, child ranges resume from the parent's continuation
+	initialContinuation := "*"
+	if p.opts.StartFromBeginning {
+		initialContinuation = ""
+	}
+	currentRanges := make(map[string]bool)
+	for _, pkrange := range pkranges {
+		currentRanges[pkrange.Id] = true
+		if _, ok := leases[pkrange.Id]; ok {
+			continue
+		}
+		continuation := initialContinuation
+		for _, parent := range pkrange.Parents {
+			if parentLease, ok := leases[parent]; ok {
+				continuation = _leaseStr(parentLease, "continuation")
+				break
+			}
+		}
+		if lease := p.createLease(pkrange.Id, continuation); lease != nil {
+			leases[pkrange.Id] = lease
+		}
+	}
+	for pkrangeId, lease := range leases {
+		if !currentRanges[pkrangeId] {
+			// partition key range no longer exists (e.g. it has been split)
+			id := _leaseStr(lease, "id")
+			p.client.DeleteDocument(DocReq{DbName: p.opts.LeaseDbName, CollName: p.opts.LeaseCollName, DocId: id,
+				PartitionKeyValues: []interface{}{id}, MatchEtag: lease.Etag()})
+			delete(leases, pkrangeId)
+		}
+	}
+
+	// distribute partition key ranges evenly among active instances
+	now := time.Now()
+	owners := map[string]bool{p.opts.InstanceName: true}
+	numOwned := 0
+	for _, lease := range leases {
+		if owner := _leaseStr(lease, "owner"); owner != "" && !p.isExpired(lease, now) {
+			owners[owner] = true
+			if owner == p.opts.InstanceName {
+				numOwned++
+			}
+		}
+	}
+	fairShare := (len(pkranges) + len(owners) - 1) / len(owners)
+	for _, pkrange := range pkranges {
+		lease, ok := leases[pkrange.Id]
+		if !ok {
+			continue
+		}
+		owner := _leaseStr(lease, "owner")
+		if owner != p.opts.InstanceName {
+			if (owner != "" && !p.isExpired(lease, now)) || numOwned >= fairShare {
+				continue
+			}
+			if lease = p.replaceLease(lease, p.opts.InstanceName, _leaseStr(lease, "continuation")); lease == nil {
+				continue
+			}
+			numOwned++
+		}
+		p.processRange(stopCh, pkrange.Id, lease)
+		select {
+		case <-stopCh:
+			return
+		default:
+		}
+	}
+}
+
+// processRange reads changes of a partition key range until there is no more change, checkpointing after each batch.
+func (p *ChangeFeedProcessor) processRange(stopCh chan struct{}, pkrangeId string, lease DocInfo) {
+	for {
+		continuation := _leaseStr(lease, "continuation")
+		result := p.client.ReadChangeFeed(ChangeFeedReq{DbName: p.opts.DbName, CollName: p.opts.CollName,
+			PartitionKeyRangeId: pkrangeId, Continuation: continuation, MaxItemCount: p.opts.MaxItemCount})
+		if err := result.Error(); err != nil {
+			p.onError(err)
+			p.replaceLease(lease, p.opts.InstanceName, continuation)
+			return
+		}
+		if len(result.Documents) > 0 {
+			if err := p.handler(pkrangeId, result.Documents); err != nil {
+				p.onError(err)
+				p.replaceLease(lease, p.opts.InstanceName, continuation)
+				return
+			}
+		}
+		if lease = p.replaceLease(lease, p.opts.InstanceName, result.Continuation); lease == nil {
+			// lease has been taken over by another instance
+			return
+		}
+		if len(result.Documents) == 0 || result.StatusCode == 304 {
+			return
+		}
+		select {
+		case <-stopCh:
+			return
+		default:
+		}
+	}
+}
+
+// releaseLeases gives up all leases owned by this instance so that other instances can take them over immediately.
+func (p *ChangeFeedProcessor) releaseLeases() {
+	leases, err := p.loadLeases()
+	if err != nil {
+		p.onError(err)
+		return
+	}
+	for _, lease := range leases {
+		if _leaseStr(lease, "owner") == p.opts.InstanceName {
+			p.replaceLease(lease, "", _leaseStr(lease, "continuation"))
+		}
+	}
+}
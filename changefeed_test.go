@@ -0,0 +1,105 @@
+package gocosmos
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewChangeFeedProcessor(t *testing.T) {
+	name := "TestNewChangeFeedProcessor"
+	client := &RestClient{}
+	handler := func(_ string, _ []DocInfo) error { return nil }
+	opts := ChangeFeedProcessorOptions{DbName: "mydb", CollName: "mytable", LeaseDbName: "mydb", LeaseCollName: "leases"}
+
+	if _, err := NewChangeFeedProcessor(nil, opts, handler); err == nil {
+		t.Fatalf("%s failed: expected error for nil rest client", name)
+	}
+	if _, err := NewChangeFeedProcessor(client, opts, nil); err == nil {
+		t.Fatalf("%s failed: expected error for nil handler", name)
+	}
+	if _, err := NewChangeFeedProcessor(client, ChangeFeedProcessorOptions{DbName: "mydb", CollName: "mytable"}, handler); err == nil {
+		t.Fatalf("%s failed: expected error for missing lease collection", name)
+	}
+
+	p, err := NewChangeFeedProcessor(client, opts, handler)
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if p.opts.LeasePrefix != "mydb.mytable." {
+		t.Fatalf("%s failed: <lease-prefix> expected %#v but received %#v", name, "mydb.mytable.", p.opts.LeasePrefix)
+	}
+	if p.InstanceName() == "" || p.opts.PollInterval <= 0 || p.opts.LeaseExpiration <= 0 {
+		t.Fatalf("%s failed: default options are not populated %#v", name, p.opts)
+	}
+}
+
+func TestChangeFeedProcessor(t *testing.T) {
+	name := "TestChangeFeedProcessor"
+	client := _newRestClient(t, name)
+
+	dbname := "mydb"
+	collname := "mytable"
+	leasecoll := "leases"
+	client.DeleteDatabase(dbname)
+	client.CreateDatabase(DatabaseSpec{Id: dbname})
+	client.CreateCollection(CollectionSpec{DbName: dbname, CollName: collname,
+		PartitionKeyInfo: map[string]interface{}{"paths": []string{"/id"}, "kind": "Hash"}})
+	client.CreateCollection(CollectionSpec{DbName: dbname, CollName: leasecoll,
+		PartitionKeyInfo: map[string]interface{}{"paths": []string{"/id"}, "kind": "Hash"}})
+
+	lock := sync.Mutex{}
+	received := make(map[string]bool)
+	handler := func(_ string, docs []DocInfo) error {
+		lock.Lock()
+		defer lock.Unlock()
+		for _, doc := range docs {
+			received[doc.Id()] = true
+		}
+		return nil
+	}
+	opts := ChangeFeedProcessorOptions{DbName: dbname, CollName: collname, LeaseDbName: dbname, LeaseCollName: leasecoll,
+		StartFromBeginning: true, PollInterval: 500 * time.Millisecond,
+		ErrorHandler: func(err error) { t.Logf("%s: %s", name, err) }}
+	p, err := NewChangeFeedProcessor(client, opts, handler)
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+
+	for i := 0; i < 10; i++ {
+		id := strconv.Itoa(i)
+		client.CreateDocument(DocumentSpec{DbName: dbname, CollName: collname, PartitionKeyValues: []interface{}{id}, DocumentData: map[string]interface{}{"id": id}})
+	}
+	if err := p.Start(); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if err := p.Start(); err == nil {
+		t.Fatalf("%s failed: processor must not be started twice", name)
+	}
+	for i := 0; i < 20; i++ {
+		lock.Lock()
+		n := len(received)
+		lock.Unlock()
+		if n >= 10 {
+			break
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	p.Stop()
+	if len(received) != 10 {
+		t.Fatalf("%s failed: expected 10 changed documents but received %#v", name, received)
+	}
+
+	leases := client.QueryDocuments(QueryReq{DbName: dbname, CollName: leasecoll, Query: "SELECT * FROM c", CrossPartitionEnabled: true})
+	if leases.Error() != nil {
+		t.Fatalf("%s failed: %s", name, leases.Error())
+	} else if len(leases.Documents) == 0 {
+		t.Fatalf("%s failed: expected lease documents to be created", name)
+	}
+	for _, lease := range leases.Documents {
+		if lease["owner"] != "" || lease["continuation"] == "" {
+			t.Fatalf("%s failed: lease must be released and checkpointed %#v", name, lease)
+		}
+	}
+}
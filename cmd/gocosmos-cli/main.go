@@ -0,0 +1,96 @@
+/*
+Command gocosmos-cli is an interactive shell to execute gocosmos SQL statements (see SQL.md) against an Azure Cosmos DB
+account, useful for ad-hoc inspection and demos:
+
+	gocosmos-cli -dsn "AccountEndpoint=https://localhost:8081/;AccountKey=<cosmosdb-account-key>;DefaultDb=mydb"
+
+Statements are terminated by ";" and may span multiple lines. Results of queries (SELECT and LIST statements) are printed
+as tables; each statement is reported with its execution time and the request units it consumed. The DSN can also be
+supplied via environment variable GOCOSMOS_DSN.
+
+Type "help" for the list of shell commands, "exit" or "quit" to leave the shell.
+
+Available since v0.1.1
+*/
+package main
+
+import (
+	"crypto/tls"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/btnguyen2k/gocosmos"
+)
+
+// chargeRecorder is a http.RoundTripper that sums up request units consumed by requests.
+type chargeRecorder struct {
+	transport http.RoundTripper
+	lock      sync.Mutex
+	charge    float64
+}
+
+// RoundTrip implements http.RoundTripper.RoundTrip.
+func (r *chargeRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.transport.RoundTrip(req)
+	if err == nil {
+		if v, e := strconv.ParseFloat(resp.Header.Get("X-Ms-Request-Charge"), 64); e == nil {
+			r.lock.Lock()
+			r.charge += v
+			r.lock.Unlock()
+		}
+	}
+	return resp, err
+}
+
+// reset returns the request units consumed since last reset.
+func (r *chargeRecorder) reset() float64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	charge := r.charge
+	r.charge = 0
+	return charge
+}
+
+// _openDb opens a connection pool to the account specified by dsn, recording request units consumed by all requests.
+func _openDb(dsn string, insecureSkipVerify bool) (*sql.DB, *chargeRecorder, error) {
+	connector, err := gocosmos.NewConnectorFromConnStr(dsn)
+	if err != nil {
+		return nil, nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if insecureSkipVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	recorder := &chargeRecorder{transport: transport}
+	connector.SetTransport(recorder)
+	return sql.OpenDB(connector), recorder, nil
+}
+
+func run(dsn string, insecureSkipVerify bool) error {
+	if dsn == "" {
+		return errors.New("-dsn is required")
+	}
+	db, recorder, err := _openDb(dsn, insecureSkipVerify)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	sh := &shell{db: db, recorder: recorder, out: os.Stdout}
+	return sh.run(os.Stdin)
+}
+
+func main() {
+	dsn := flag.String("dsn", os.Getenv("GOCOSMOS_DSN"), "connection string, default is value of environment variable GOCOSMOS_DSN")
+	insecure := flag.Bool("insecure", false, "skip TLS certificate verification (e.g. to connect to the Cosmos DB emulator)")
+	flag.Parse()
+	if err := run(*dsn, *insecure); err != nil {
+		fmt.Fprintln(os.Stderr, "gocosmos-cli:", err)
+		os.Exit(1)
+	}
+}
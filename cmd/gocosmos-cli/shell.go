@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	prompt         = "gocosmos> "
+	promptContinue = "       -> "
+	helpText       = `Statements (see SQL.md) are terminated by ";" and may span multiple lines.
+Shell commands:
+  help          show this help
+  exit, quit    leave the shell
+`
+)
+
+// shell executes statements read from an input, printing results to out.
+type shell struct {
+	db       *sql.DB
+	recorder *chargeRecorder
+	out      io.Writer
+}
+
+// run reads and executes statements until the input is exhausted or the user leaves the shell.
+func (sh *shell) run(in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	buf := ""
+	fmt.Fprint(sh.out, prompt)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if buf == "" {
+			switch strings.ToLower(strings.TrimSuffix(line, ";")) {
+			case "exit", "quit":
+				return nil
+			case "help":
+				fmt.Fprint(sh.out, helpText)
+				line = ""
+			}
+		}
+		buf = strings.TrimSpace(buf + "\n" + line)
+		if _isComplete(buf) {
+			sh.execute(strings.TrimSpace(strings.TrimSuffix(buf, ";")))
+			buf = ""
+		}
+		if buf == "" {
+			fmt.Fprint(sh.out, prompt)
+		} else {
+			fmt.Fprint(sh.out, promptContinue)
+		}
+	}
+	fmt.Fprintln(sh.out)
+	return scanner.Err()
+}
+
+// _isComplete checks if buf ends with a ";" that terminates the statement, i.e. is not inside a string literal.
+func _isComplete(buf string) bool {
+	var quote rune // quote character of the string literal being scanned, 0 if none
+	escaped, last := false, rune(0)
+	for _, r := range buf {
+		switch {
+		case quote != 0 && escaped:
+			escaped = false
+		case quote != 0 && r == '\\':
+			escaped = true
+		case r == quote:
+			quote = 0
+		case quote == 0 && (r == '"' || r == '\''):
+			quote = r
+		}
+		last = r
+	}
+	return quote == 0 && last == ';'
+}
+
+// _isQuery checks if a statement returns rows, i.e. should be executed with sql.DB.Query.
+func _isQuery(stmt string) bool {
+	fields := strings.Fields(stmt)
+	if len(fields) == 0 {
+		return false
+	}
+	keyword := strings.ToUpper(fields[0])
+	if keyword == "INSERT" || keyword == "UPSERT" || keyword == "UPDATE" || keyword == "DELETE" {
+		for _, field := range fields[1:] {
+			if strings.EqualFold(field, "RETURNING") {
+				return true
+			}
+		}
+	}
+	return keyword == "SELECT" || keyword == "LIST" || keyword == "SHOW"
+}
+
+// execute executes a statement and prints its result, execution time and consumed request units.
+func (sh *shell) execute(stmt string) {
+	if stmt == "" {
+		return
+	}
+	sh.recorder.reset()
+	start := time.Now()
+	summary := ""
+	if _isQuery(stmt) {
+		rows, err := sh.db.Query(stmt)
+		if err == nil {
+			var columns []string
+			var records [][]string
+			if columns, records, err = _fetchRows(rows); err == nil {
+				_printTable(sh.out, columns, records)
+				summary = fmt.Sprintf("%d row(s)", len(records))
+			}
+		}
+		if err != nil {
+			fmt.Fprintln(sh.out, "Error:", err)
+			return
+		}
+	} else {
+		result, err := sh.db.Exec(stmt)
+		if err != nil {
+			fmt.Fprintln(sh.out, "Error:", err)
+			return
+		}
+		numRows, _ := result.RowsAffected()
+		summary = fmt.Sprintf("%d row(s) affected", numRows)
+	}
+	fmt.Fprintf(sh.out, "%s (%s, %.2f RU)\n", summary, time.Since(start).Round(time.Millisecond), sh.recorder.reset())
+}
+
+// _formatValue formats a value returned from a query to be displayed in a table cell.
+func _formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case map[string]interface{}, []interface{}:
+		js, _ := json.Marshal(v)
+		return string(js)
+	}
+	return fmt.Sprint(v)
+}
+
+// _fetchRows fetches all rows of a query result, formatting values as strings.
+func _fetchRows(rows *sql.Rows) ([]string, [][]string, error) {
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, nil, err
+	}
+	records := make([][]string, 0)
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		scanValues := make([]interface{}, len(columns))
+		for i := range values {
+			scanValues[i] = &values[i]
+		}
+		if err := rows.Scan(scanValues...); err != nil {
+			return nil, nil, err
+		}
+		record := make([]string, len(columns))
+		for i, v := range values {
+			record[i] = _formatValue(v)
+		}
+		records = append(records, record)
+	}
+	return columns, records, rows.Err()
+}
+
+// _printTable prints rows as a table:
+//
+//     +----+----------+
+//     | id | username |
+//     +----+----------+
+//     | 1  | user1    |
+//     +----+----------+
+func _printTable(out io.Writer, columns []string, records [][]string) {
+	if len(columns) == 0 {
+		return
+	}
+	widths := make([]int, len(columns))
+	for i, column := range columns {
+		widths[i] = utf8.RuneCountInString(column)
+	}
+	for _, record := range records {
+		for i, cell := range record {
+			if w := utf8.RuneCountInString(cell); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+	border := "+"
+	for _, w := range widths {
+		border += strings.Repeat("-", w+2) + "+"
+	}
+	printRow := func(cells []string) {
+		line := "|"
+		for i, cell := range cells {
+			line += " " + cell + strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)) + " |"
+		}
+		fmt.Fprintln(out, line)
+	}
+	fmt.Fprintln(out, border)
+	printRow(columns)
+	fmt.Fprintln(out, border)
+	for _, record := range records {
+		printRow(record)
+	}
+	fmt.Fprintln(out, border)
+}
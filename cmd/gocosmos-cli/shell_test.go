@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_isQuery(t *testing.T) {
+	name := "Test_isQuery"
+	testData := map[string]bool{"SELECT * FROM c WITH db=mydb": true, "list databases": true, "\n  LIST COLLECTIONS": true,
+		"CREATE DATABASE mydb": false, "SHOW THROUGHPUT DATABASE mydb": true, "DELETE FROM mydb.users WHERE id=1": false, "": false,
+		"INSERT INTO mydb.users (id) VALUES (1) RETURNING *": true}
+	for stmt, expected := range testData {
+		if output := _isQuery(stmt); output != expected {
+			t.Fatalf("%s failed: <%s> expected %#v but received %#v", name, stmt, expected, output)
+		}
+	}
+}
+
+func Test_isComplete(t *testing.T) {
+	name := "Test_isComplete"
+	testData := map[string]bool{"LIST DATABASES;": true, "LIST DATABASES": false, `INSERT INTO c (a) VALUES ("\"x;`: false,
+		`INSERT INTO c (a) VALUES ("\"x;\ny\"");`: true, `SELECT * FROM c WHERE c.a='\';`: false, `SELECT * FROM c WHERE c.a='\\';`: true}
+	for buf, expected := range testData {
+		if output := _isComplete(buf); output != expected {
+			t.Fatalf("%s failed: <%s> expected %#v but received %#v", name, buf, expected, output)
+		}
+	}
+}
+
+func Test_printTable(t *testing.T) {
+	name := "Test_printTable"
+	out := &bytes.Buffer{}
+	_printTable(out, []string{"id", "name"}, [][]string{{"1", "Thành"}, {"10", "NULL"}})
+	expected := `+----+-------+
+| id | name  |
++----+-------+
+| 1  | Thành |
+| 10 | NULL  |
++----+-------+
+`
+	if out.String() != expected {
+		t.Fatalf("%s failed: expected\n%s\nbut received\n%s", name, expected, out.String())
+	}
+}
+
+func Test_formatValue(t *testing.T) {
+	name := "Test_formatValue"
+	testData := []struct {
+		input    interface{}
+		expected string
+	}{
+		{nil, "NULL"}, {"a", "a"}, {[]byte(`{"a":1}`), `{"a":1}`}, {1.5, "1.5"}, {true, "true"},
+		{map[string]interface{}{"a": 1}, `{"a":1}`}, {[]interface{}{1, "b"}, `[1,"b"]`},
+	}
+	for _, data := range testData {
+		if output := _formatValue(data.input); output != data.expected {
+			t.Fatalf("%s failed: <%#v> expected %#v but received %#v", name, data.input, data.expected, output)
+		}
+	}
+}
+
+func TestShell_run(t *testing.T) {
+	name := "TestShell_run"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Ms-Request-Charge", "1.5")
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/dbs":
+			w.Write([]byte(`{"Databases":[{"id":"db1"},{"id":"db2"}],"_count":2}`))
+		case r.Method == "POST" && r.URL.Path == "/dbs":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"mydb"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	db, recorder, err := _openDb("AccountEndpoint="+server.URL+";AccountKey=C2y6yDjf5/R+ob0N8A7Cgv30VRDJIWEHLM+4QDU5DE2nQ9nDuVTqobD4b8mGGyPMbIZnqyMsEcaGQy67XIw/Jw==", false)
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	defer db.Close()
+
+	out := &bytes.Buffer{}
+	sh := &shell{db: db, recorder: recorder, out: out}
+	input := "help\nLIST\n  DATABASES;\nCREATE DATABASE mydb;\nDROP DATABASE notfound;\nexit\nLIST DATABASES;\n"
+	if err := sh.run(strings.NewReader(input)); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	output := out.String()
+	expected := []string{"Shell commands:", promptContinue, "| db1 ", "2 row(s) (", ", 1.50 RU)", "1 row(s) affected (", "Error:"}
+	for _, str := range expected {
+		if !strings.Contains(output, str) {
+			t.Fatalf("%s failed: expected %#v in output:\n%s", name, str, output)
+		}
+	}
+	if strings.Count(output, "| db1 ") != 1 {
+		t.Fatalf("%s failed: statements after exit must not be executed:\n%s", name, output)
+	}
+}
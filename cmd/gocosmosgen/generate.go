@@ -0,0 +1,312 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// genConfig specifies what code to generate.
+type genConfig struct {
+	Package  string   // name of the package of the generated code
+	TypeName string   // name of the document struct type
+	DbName   string   // database name
+	CollName string   // collection name
+	PkPaths  []string // partition key paths of the collection, empty if the collection is non-partitioned
+}
+
+// _goName converts a document field name to an exported Go identifier, e.g. "first_name" becomes "FirstName".
+func _goName(name string) string {
+	result := ""
+	upperNext := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upperNext = true
+			continue
+		}
+		if upperNext {
+			r = unicode.ToUpper(r)
+			upperNext = false
+		}
+		result += string(r)
+	}
+	if result == "" || !unicode.IsLetter([]rune(result)[0]) {
+		result = "F" + result
+	}
+	return result
+}
+
+// structField is a field of a generated struct.
+type structField struct {
+	name    string // document field name
+	goName  string // Go field name
+	typ     *typeInfo
+	goType  string
+	jsonTag string
+}
+
+type structDef struct {
+	name   string
+	fields []structField
+}
+
+type generator struct {
+	structs []*structDef
+}
+
+// _sortedFieldNames returns field names of an object, "id" first then in alphabetical order.
+func _sortedFieldNames(t *typeInfo) []string {
+	names := make([]string, 0, len(t.fields))
+	for name := range t.fields {
+		if name != "id" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if t.fields["id"] != nil {
+		names = append([]string{"id"}, names...)
+	}
+	return names
+}
+
+// goType returns the Go type of a document field, generating struct types for nested objects.
+func (g *generator) goType(typeName string, t *typeInfo) string {
+	switch t.kind {
+	case kindString, kindInt, kindFloat, kindBool:
+		return t.kind
+	case kindObject:
+		if len(t.fields) == 0 {
+			return "map[string]interface{}"
+		}
+		g.addStruct(typeName, t)
+		return typeName
+	case kindArray:
+		if t.elem == nil || t.elem.kind == kindNull {
+			return "[]interface{}"
+		}
+		return "[]" + g.goType(typeName+"Item", t.elem)
+	}
+	return "interface{}"
+}
+
+func (g *generator) addStruct(typeName string, t *typeInfo) *structDef {
+	def := &structDef{name: typeName}
+	g.structs = append(g.structs, def)
+	usedNames := make(map[string]bool)
+	for _, name := range _sortedFieldNames(t) {
+		goName := _goName(name)
+		for i := 2; usedNames[goName]; i++ {
+			goName = _goName(name) + strconv.Itoa(i)
+		}
+		usedNames[goName] = true
+		ft := t.fields[name]
+		tag := name
+		if ft.optional {
+			tag += ",omitempty"
+		}
+		def.fields = append(def.fields, structField{name: name, goName: goName, typ: ft, jsonTag: tag})
+	}
+	for i := range def.fields {
+		def.fields[i].goType = g.goType(typeName+def.fields[i].goName, def.fields[i].typ)
+	}
+	return def
+}
+
+// pkExpr builds the Go expression referencing the field at a partition key path of a document.
+func (g *generator) pkExpr(pkPath string) (string, error) {
+	if !strings.HasPrefix(pkPath, "/") || len(pkPath) < 2 {
+		return "", errors.New("invalid partition key path " + pkPath)
+	}
+	def := g.structs[0]
+	expr := "doc"
+	for _, part := range strings.Split(pkPath[1:], "/") {
+		if len(part) > 1 && strings.HasPrefix(part, `"`) && strings.HasSuffix(part, `"`) {
+			part = part[1 : len(part)-1]
+		}
+		var field *structField
+		if def != nil {
+			for i := range def.fields {
+				if def.fields[i].name == part {
+					field = &def.fields[i]
+				}
+			}
+		}
+		if field == nil {
+			return "", errors.New("field at partition key path " + pkPath + " is not found in schema")
+		}
+		expr += "." + field.goName
+		def = g.findStruct(field.goType)
+	}
+	return expr, nil
+}
+
+func (g *generator) findStruct(name string) *structDef {
+	for _, def := range g.structs {
+		if def.name == name {
+			return def
+		}
+	}
+	return nil
+}
+
+var storeTemplate = template.Must(template.New("store").Parse(`
+// {{.Type}}Store provides typed access to documents of collection {{.Db}}.{{.Coll}} through a database/sql connection
+// pool opened with the gocosmos driver.
+type {{.Type}}Store struct {
+	DB *sql.DB
+}
+
+// New{{.Type}}Store creates a new {{.Type}}Store.
+func New{{.Type}}Store(db *sql.DB) *{{.Type}}Store {
+	return &{{.Type}}Store{DB: db}
+}
+{{if .PkExprs}}
+// pk returns the partition key value of a document.
+func (s *{{.Type}}Store) pk(doc *{{.Type}}) interface{} {
+	return {{if eq (len .PkExprs) 1}}{{index .PkExprs 0}}{{else}}[]interface{}{ {{- range $i, $e := .PkExprs}}{{if $i}}, {{end}}{{$e}}{{end -}} }{{end}}
+}
+{{end}}
+func (s *{{.Type}}Store) write(ctx context.Context, verb string, doc *{{.Type}}) error {
+	js, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal(js, &data); err != nil {
+		return err
+	}
+	fields := make([]string, 0, len(data))
+	for field := range data {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	placeholders := make([]string, len(fields))
+	args := make([]interface{}, len(fields), len(fields)+1)
+	for i, field := range fields {
+		placeholders[i] = ":" + strconv.Itoa(i+1)
+		args[i] = data[field]
+	}
+	{{- if .PkExprs}}
+	args = append(args, s.pk(doc))
+	{{- end}}
+	query := verb + " INTO {{.Db}}.{{.Coll}} (" + strings.Join(fields, ",") + ") VALUES (" + strings.Join(placeholders, ",") + ")"
+	_, err = s.DB.ExecContext(ctx, query, args...)
+	return err
+}
+
+// Insert stores a new document. The returned error matches gocosmos.ErrConflict (see errors.Is) if a document with the
+// same id already exists.
+func (s *{{.Type}}Store) Insert(ctx context.Context, doc *{{.Type}}) error {
+	return s.write(ctx, "INSERT", doc)
+}
+
+// Upsert stores a new document or replaces the existing one.
+func (s *{{.Type}}Store) Upsert(ctx context.Context, doc *{{.Type}}) error {
+	return s.write(ctx, "UPSERT", doc)
+}
+
+// Delete removes a document. gocosmos.ErrNotFound is returned if the document does not exist.
+func (s *{{.Type}}Store) Delete(ctx context.Context, doc *{{.Type}}) error {
+	_, err := s.DB.ExecContext(ctx, "DELETE FROM {{.Db}}.{{.Coll}} WHERE id=:1", doc.Id{{if .PkExprs}}, s.pk(doc){{end}})
+	return err
+}
+
+// Get fetches a document by id. sql.ErrNoRows is returned if the document does not exist.
+func (s *{{.Type}}Store) Get(ctx context.Context, id string) (*{{.Type}}, error) {
+	docs, err := s.Query(ctx, "c.id=:1", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &docs[0], nil
+}
+
+// Query fetches documents matching a condition (e.g. "c.age>:1"), across all partitions. An empty condition matches all
+// documents.
+func (s *{{.Type}}Store) Query(ctx context.Context, where string, args ...interface{}) ([]{{.Type}}, error) {
+	query := "SELECT CROSS PARTITION * FROM c"
+	if where != "" {
+		query += " WHERE " + where
+	}
+	rows, err := s.DB.QueryContext(ctx, query+" WITH db={{.Db}} WITH collection={{.Coll}} WITH objects_as_json=false", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	docs := make([]{{.Type}}, 0)
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		scanValues := make([]interface{}, len(columns))
+		for i := range values {
+			scanValues[i] = &values[i]
+		}
+		if err := rows.Scan(scanValues...); err != nil {
+			return nil, err
+		}
+		data := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			if values[i] != nil {
+				data[column] = values[i]
+			}
+		}
+		var doc {{.Type}}
+		if err := gocosmos.ScanJson(data, &doc); err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, rows.Err()
+}
+`))
+
+// _generate generates Go source code of the document struct (and structs of nested objects) and its store.
+func _generate(cfg genConfig, schema *typeInfo) ([]byte, error) {
+	if schema.kind != kindObject || schema.fields["id"] == nil {
+		return nil, errors.New("schema must be an object with field id")
+	}
+	g := &generator{}
+	g.addStruct(cfg.TypeName, schema)
+	pkExprs := make([]string, 0, len(cfg.PkPaths))
+	for _, pkPath := range cfg.PkPaths {
+		expr, err := g.pkExpr(pkPath)
+		if err != nil {
+			return nil, err
+		}
+		pkExprs = append(pkExprs, expr)
+	}
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "// Code generated by gocosmosgen. DO NOT EDIT.\n\npackage %s\n\n", cfg.Package)
+	buf.WriteString("import (\n\"context\"\n\"database/sql\"\n\"encoding/json\"\n\"sort\"\n\"strconv\"\n\"strings\"\n\n\"github.com/btnguyen2k/gocosmos\"\n)\n")
+	for i, def := range g.structs {
+		if i == 0 {
+			fmt.Fprintf(buf, "\n// %s is a document of collection %s.%s.\n", def.name, cfg.DbName, cfg.CollName)
+		} else {
+			fmt.Fprintf(buf, "\n// %s is a nested object of %s.\n", def.name, cfg.TypeName)
+		}
+		fmt.Fprintf(buf, "type %s struct {\n", def.name)
+		for _, f := range def.fields {
+			fmt.Fprintf(buf, "%s %s `json:%s`\n", f.goName, f.goType, strconv.Quote(f.jsonTag))
+		}
+		buf.WriteString("}\n")
+	}
+	err := storeTemplate.Execute(buf, map[string]interface{}{
+		"Type": cfg.TypeName, "Db": cfg.DbName, "Coll": cfg.CollName, "PkExprs": pkExprs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
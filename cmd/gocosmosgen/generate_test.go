@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/btnguyen2k/gocosmos"
+)
+
+func Test_goName(t *testing.T) {
+	name := "Test_goName"
+	testData := map[string]string{"id": "Id", "first_name": "FirstName", "lastName": "LastName", "home-address": "HomeAddress",
+		"2fa": "F2fa", "_": "F"}
+	for input, expected := range testData {
+		if output := _goName(input); output != expected {
+			t.Fatalf("%s failed: <%s> expected %#v but received %#v", name, input, expected, output)
+		}
+	}
+}
+
+func Test_inferSchema(t *testing.T) {
+	name := "Test_inferSchema"
+	var docs []map[string]interface{}
+	json.Unmarshal([]byte(`[
+		{"id":"1","_rid":"x","tenant":"t1","age":1,"score":1,"tags":["a"],"address":{"city":"HCM"},"misc":"a"},
+		{"id":"2","_rid":"y","tenant":"t2","age":2,"score":1.5,"tags":[],"address":{"city":"HN","street":"s"},"misc":1,"nick":null}
+	]`), &docs)
+	schema := _inferSchema(docs)
+	expected := map[string]string{"id": kindString, "tenant": kindString, "age": kindInt, "score": kindFloat, "tags": kindArray,
+		"address": kindObject, "misc": kindAny, "nick": kindNull}
+	if len(schema.fields) != len(expected) {
+		t.Fatalf("%s failed: unexpected fields %#v", name, schema.fields)
+	}
+	for field, kind := range expected {
+		if ft := schema.fields[field]; ft == nil || ft.kind != kind {
+			t.Fatalf("%s failed: <%s> expected kind %#v but received %#v", name, field, kind, ft)
+		}
+	}
+	if schema.fields["tags"].elem.kind != kindString {
+		t.Fatalf("%s failed: expected element kind %#v but received %#v", name, kindString, schema.fields["tags"].elem)
+	}
+	if schema.fields["tenant"].optional || !schema.fields["nick"].optional {
+		t.Fatalf("%s failed: unexpected optional flags", name)
+	}
+	address := schema.fields["address"]
+	if address.fields["city"].optional || !address.fields["street"].optional {
+		t.Fatalf("%s failed: unexpected optional flags of nested fields", name)
+	}
+}
+
+func Test_parseSchemaFile(t *testing.T) {
+	name := "Test_parseSchemaFile"
+	schema, err := _parseSchemaFile([]byte(`{"tenant":"string","age":"int?","address":{"city":"string"},"tags":["string"],"extra":"object"}`))
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if schema.fields["id"] == nil || schema.fields["id"].kind != kindString {
+		t.Fatalf("%s failed: field id must be added", name)
+	}
+	if ft := schema.fields["age"]; ft.kind != kindInt || !ft.optional {
+		t.Fatalf("%s failed: unexpected type of field age %#v", name, ft)
+	}
+	if ft := schema.fields["tags"]; ft.kind != kindArray || ft.elem.kind != kindString {
+		t.Fatalf("%s failed: unexpected type of field tags %#v", name, ft)
+	}
+
+	invalidSchemas := []string{`[]`, `{"a":"unknown"}`, `{"a":["string","int"]}`, `{"a":1}`, `{"id":"int"}`}
+	for _, schema := range invalidSchemas {
+		if _, err := _parseSchemaFile([]byte(schema)); err == nil {
+			t.Fatalf("%s failed: expected error for schema %s", name, schema)
+		}
+	}
+}
+
+func Test_generate(t *testing.T) {
+	name := "Test_generate"
+	schema, _ := _parseSchemaFile([]byte(`{"tenant":"string","age":"int?","address":{"city":"string"},"tags":[{"name":"string"}]}`))
+	code, err := _generate(genConfig{Package: "models", TypeName: "User", DbName: "mydb", CollName: "users", PkPaths: []string{"/tenant", "/address/city"}}, schema)
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	src := string(code)
+	expected := []string{
+		"package models",
+		"type User struct {",
+		"Id      string         `json:\"id\"`",
+		"Age     int64          `json:\"age,omitempty\"`",
+		"Address UserAddress    `json:\"address\"`",
+		"Tags    []UserTagsItem `json:\"tags\"`",
+		"type UserTagsItem struct {",
+		"return []interface{}{doc.Tenant, doc.Address.City}",
+		`"DELETE FROM mydb.users WHERE id=:1", doc.Id, s.pk(doc)`,
+		`" WITH db=mydb WITH collection=users WITH objects_as_json=false"`,
+		"func NewUserStore(db *sql.DB) *UserStore {",
+	}
+	for _, str := range expected {
+		if !strings.Contains(src, str) {
+			t.Fatalf("%s failed: expected %#v in generated code:\n%s", name, str, src)
+		}
+	}
+
+	code, err = _generate(genConfig{Package: "models", TypeName: "Item", DbName: "mydb", CollName: "items"}, schema)
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if src := string(code); strings.Contains(src, "s.pk(doc)") || !strings.Contains(src, `"DELETE FROM mydb.items WHERE id=:1", doc.Id)`) {
+		t.Fatalf("%s failed: partition key must not be supplied for non-partitioned collection:\n%s", name, src)
+	}
+
+	if _, err := _generate(genConfig{Package: "models", TypeName: "User", PkPaths: []string{"/address/zip"}}, schema); err == nil {
+		t.Fatalf("%s failed: expected error for unknown partition key path", name)
+	}
+}
+
+func Test_inspectCollection(t *testing.T) {
+	name := "Test_inspectCollection"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/dbs/mydb/colls/users":
+			w.Write([]byte(`{"id":"users","partitionKey":{"paths":["/tenant"],"kind":"Hash"}}`))
+		case "/dbs/mydb/colls/users/docs":
+			w.Write([]byte(`{"Documents":[{"id":"1","tenant":"t1","_ts":1},{"id":"2","tenant":"t2","grade":1}],"_count":2}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	client, err := gocosmos.NewRestClient(nil, "AccountEndpoint="+server.URL+";AccountKey=C2y6yDjf5/R+ob0N8A7Cgv30VRDJIWEHLM+4QDU5DE2nQ9nDuVTqobD4b8mGGyPMbIZnqyMsEcaGQy67XIw/Jw==")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	schema, pkPaths, err := _inspectCollection(client, "mydb", "users", 10)
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if len(pkPaths) != 1 || pkPaths[0] != "/tenant" {
+		t.Fatalf("%s failed: unexpected partition key paths %#v", name, pkPaths)
+	}
+	if len(schema.fields) != 3 || schema.fields["_ts"] != nil || !schema.fields["grade"].optional {
+		t.Fatalf("%s failed: unexpected schema %#v", name, schema.fields)
+	}
+	if _, _, err := _inspectCollection(client, "mydb", "notfound", 10); err == nil {
+		t.Fatalf("%s failed: expected error for non-existing collection", name)
+	}
+}
+
+func Test_run(t *testing.T) {
+	name := "Test_run"
+	dir, err := ioutil.TempDir("", "gocosmosgen")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	defer os.RemoveAll(dir)
+	schemaFile, outFile := filepath.Join(dir, "user.json"), filepath.Join(dir, "user_gen.go")
+	ioutil.WriteFile(schemaFile, []byte(`{"tenant":"string"}`), 0644)
+	if err := run([]string{"-schema", schemaFile, "-db", "mydb", "-coll", "user_profiles", "-pk", "/tenant", "-pkg", "models", "-out", outFile}); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	code, _ := ioutil.ReadFile(outFile)
+	if !strings.Contains(string(code), "type UserProfiles struct {") || !strings.Contains(string(code), "return doc.Tenant") {
+		t.Fatalf("%s failed: unexpected generated code:\n%s", name, code)
+	}
+
+	if err := run([]string{"-db", "mydb", "-coll", "users"}); err == nil {
+		t.Fatalf("%s failed: expected error if neither -dsn nor -schema is specified", name)
+	}
+	if err := run([]string{"-schema", schemaFile}); err == nil {
+		t.Fatalf("%s failed: expected error if -db and -coll are not specified", name)
+	}
+}
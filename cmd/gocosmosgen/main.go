@@ -0,0 +1,142 @@
+/*
+Command gocosmosgen generates typed Go structs and CRUD/query functions, bound to the gocosmos database/sql driver, for
+documents of an Azure Cosmos DB collection.
+
+The document schema is either inferred from documents sampled from the collection:
+
+	gocosmosgen -dsn "AccountEndpoint=...;AccountKey=..." -db mydb -coll users -type User -pkg models -out user_gen.go
+
+or read from a schema file (see _parseSchemaFile for the format), in which case the collection's partition key path(s)
+are specified via -pk:
+
+	gocosmosgen -schema user.json -db mydb -coll users -pk /tenant -type User -pkg models -out user_gen.go
+
+The generated code contains the document struct (plus structs of nested objects) and a <Type>Store with methods Insert,
+Upsert, Delete, Get and Query.
+
+Available since v0.1.1
+*/
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/btnguyen2k/gocosmos"
+)
+
+// _sampleDocuments fetches up to numDocs documents of a collection.
+func _sampleDocuments(client *gocosmos.RestClient, dbName, collName string, numDocs int) ([]map[string]interface{}, error) {
+	query := gocosmos.QueryReq{DbName: dbName, CollName: collName, Query: "SELECT TOP " + strconv.Itoa(numDocs) + " * FROM c",
+		MaxItemCount: numDocs, CrossPartitionEnabled: true}
+	docs := make([]map[string]interface{}, 0, numDocs)
+	for len(docs) < numDocs {
+		result := client.QueryDocuments(query)
+		if err := result.Error(); err != nil {
+			return nil, err
+		}
+		for _, doc := range result.Documents {
+			docs = append(docs, doc)
+		}
+		if result.ContinuationToken == "" {
+			break
+		}
+		query.ContinuationToken = result.ContinuationToken
+	}
+	if len(docs) > numDocs {
+		docs = docs[:numDocs]
+	}
+	return docs, nil
+}
+
+// _inspectCollection infers the document schema of a collection and fetches its partition key paths.
+func _inspectCollection(client *gocosmos.RestClient, dbName, collName string, numDocs int) (*typeInfo, []string, error) {
+	result := client.GetCollection(dbName, collName)
+	if err := result.Error(); err != nil {
+		return nil, nil, err
+	}
+	pkPaths := make([]string, 0)
+	if paths, ok := result.PartitionKey["paths"].([]interface{}); ok && result.PartitionKey["systemKey"] != true {
+		for _, path := range paths {
+			pkPaths = append(pkPaths, fmt.Sprint(path))
+		}
+	}
+	docs, err := _sampleDocuments(client, dbName, collName, numDocs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return _inferSchema(docs), pkPaths, nil
+}
+
+func run(args []string) error {
+	flags := flag.NewFlagSet("gocosmosgen", flag.ContinueOnError)
+	dsn := flags.String("dsn", "", "connection string (AccountEndpoint=...;AccountKey=...) to inspect the collection")
+	schemaFile := flags.String("schema", "", "schema file to read document schema from, instead of inspecting the collection")
+	dbName := flags.String("db", "", "database name (required)")
+	collName := flags.String("coll", "", "collection name (required)")
+	pk := flags.String("pk", "", "comma-separated partition key path(s), used with -schema")
+	typeName := flags.String("type", "", "name of the generated document type, default is derived from the collection name")
+	pkg := flags.String("pkg", "main", "package name of the generated code")
+	out := flags.String("out", "", "output file, default is stdout")
+	numDocs := flags.Int("samples", 100, "number of documents to sample when inspecting the collection")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *dbName == "" || *collName == "" {
+		return errors.New("-db and -coll are required")
+	}
+	if (*dsn == "") == (*schemaFile == "") {
+		return errors.New("either -dsn or -schema must be specified")
+	}
+
+	cfg := genConfig{Package: *pkg, TypeName: *typeName, DbName: *dbName, CollName: *collName}
+	if cfg.TypeName == "" {
+		cfg.TypeName = _goName(*collName)
+	}
+	var schema *typeInfo
+	var err error
+	if *schemaFile != "" {
+		var data []byte
+		if data, err = ioutil.ReadFile(*schemaFile); err != nil {
+			return err
+		}
+		if schema, err = _parseSchemaFile(data); err != nil {
+			return err
+		}
+		for _, path := range strings.Split(*pk, ",") {
+			if path = strings.TrimSpace(path); path != "" {
+				cfg.PkPaths = append(cfg.PkPaths, path)
+			}
+		}
+	} else {
+		client, err := gocosmos.NewRestClient(nil, *dsn)
+		if err != nil {
+			return err
+		}
+		if schema, cfg.PkPaths, err = _inspectCollection(client, *dbName, *collName, *numDocs); err != nil {
+			return err
+		}
+	}
+
+	code, err := _generate(cfg, schema)
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err = os.Stdout.Write(code)
+		return err
+	}
+	return ioutil.WriteFile(*out, code, 0644)
+}
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "gocosmosgen:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+)
+
+// kinds of document fields
+const (
+	kindNull   = "null"
+	kindString = "string"
+	kindInt    = "int64"
+	kindFloat  = "float64"
+	kindBool   = "bool"
+	kindObject = "object"
+	kindArray  = "array"
+	kindAny    = "interface{}"
+)
+
+// typeInfo describes the type of a document field.
+type typeInfo struct {
+	kind     string
+	fields   map[string]*typeInfo // fields of an object, nil means a free-form object
+	elem     *typeInfo            // element type of an array
+	optional bool                 // the field is missing from (or null in) some documents
+}
+
+// _inferType infers the type of a decoded JSON value.
+func _inferType(v interface{}) *typeInfo {
+	switch v := v.(type) {
+	case nil:
+		return &typeInfo{kind: kindNull, optional: true}
+	case string:
+		return &typeInfo{kind: kindString}
+	case bool:
+		return &typeInfo{kind: kindBool}
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return &typeInfo{kind: kindInt}
+		}
+		return &typeInfo{kind: kindFloat}
+	case map[string]interface{}:
+		t := &typeInfo{kind: kindObject, fields: make(map[string]*typeInfo, len(v))}
+		for name, fv := range v {
+			t.fields[name] = _inferType(fv)
+		}
+		return t
+	case []interface{}:
+		var elem *typeInfo
+		for _, ev := range v {
+			elem = _mergeType(elem, _inferType(ev))
+		}
+		return &typeInfo{kind: kindArray, elem: elem}
+	}
+	return &typeInfo{kind: kindAny}
+}
+
+// _mergeType merges types of the same field observed in different documents.
+func _mergeType(a, b *typeInfo) *typeInfo {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	optional := a.optional || b.optional
+	switch {
+	case a.kind == kindNull:
+		a, b = b, a
+		fallthrough
+	case b.kind == kindNull:
+		result := *a
+		result.optional = true
+		return &result
+	case a.kind == b.kind && a.kind == kindObject:
+		if a.fields == nil || b.fields == nil {
+			return &typeInfo{kind: kindObject, optional: optional}
+		}
+		result := &typeInfo{kind: kindObject, fields: make(map[string]*typeInfo), optional: optional}
+		for name, ft := range a.fields {
+			result.fields[name] = _mergeType(ft, b.fields[name])
+		}
+		for name, ft := range b.fields {
+			if a.fields[name] == nil {
+				result.fields[name] = ft
+			}
+		}
+		for name, ft := range result.fields {
+			if a.fields[name] == nil || b.fields[name] == nil {
+				// field is missing from some documents
+				optionalType := *ft
+				optionalType.optional = true
+				result.fields[name] = &optionalType
+			}
+		}
+		return result
+	case a.kind == b.kind && a.kind == kindArray:
+		return &typeInfo{kind: kindArray, elem: _mergeType(a.elem, b.elem), optional: optional}
+	case a.kind == b.kind:
+		return &typeInfo{kind: a.kind, optional: optional}
+	case (a.kind == kindInt && b.kind == kindFloat) || (a.kind == kindFloat && b.kind == kindInt):
+		return &typeInfo{kind: kindFloat, optional: optional}
+	}
+	return &typeInfo{kind: kindAny, optional: optional}
+}
+
+// _inferSchema infers the schema of documents sampled from a collection. System properties (e.g. _rid, _ts, _etag) are
+// ignored.
+func _inferSchema(docs []map[string]interface{}) *typeInfo {
+	var schema *typeInfo
+	for _, doc := range docs {
+		data := make(map[string]interface{}, len(doc))
+		for name, v := range doc {
+			if !strings.HasPrefix(name, "_") {
+				data[name] = v
+			}
+		}
+		schema = _mergeType(schema, _inferType(data))
+	}
+	if schema == nil {
+		schema = &typeInfo{kind: kindObject, fields: make(map[string]*typeInfo)}
+	}
+	if schema.fields["id"] == nil || schema.fields["id"].kind != kindString {
+		schema.fields["id"] = &typeInfo{kind: kindString}
+	}
+	return schema
+}
+
+var schemaTypeNames = map[string]string{
+	"string":  kindString,
+	"int":     kindInt,
+	"integer": kindInt,
+	"float":   kindFloat,
+	"number":  kindFloat,
+	"bool":    kindBool,
+	"boolean": kindBool,
+	"object":  kindObject,
+	"any":     kindAny,
+}
+
+// _parseSchemaType converts a type definition of a schema file to typeInfo.
+func _parseSchemaType(name string, def interface{}) (*typeInfo, error) {
+	switch def := def.(type) {
+	case string:
+		optional := strings.HasSuffix(def, "?")
+		kind, ok := schemaTypeNames[strings.ToLower(strings.TrimSuffix(def, "?"))]
+		if !ok {
+			return nil, errors.New("invalid type \"" + def + "\" of field " + name)
+		}
+		return &typeInfo{kind: kind, optional: optional}, nil
+	case map[string]interface{}:
+		t := &typeInfo{kind: kindObject, fields: make(map[string]*typeInfo, len(def))}
+		for fname, fdef := range def {
+			ft, err := _parseSchemaType(strings.TrimPrefix(name+"."+fname, "."), fdef)
+			if err != nil {
+				return nil, err
+			}
+			t.fields[fname] = ft
+		}
+		return t, nil
+	case []interface{}:
+		if len(def) != 1 {
+			return nil, errors.New("array type of field " + name + " must have exactly one element type")
+		}
+		elem, err := _parseSchemaType(name+"[]", def[0])
+		if err != nil {
+			return nil, err
+		}
+		return &typeInfo{kind: kindArray, elem: elem}, nil
+	}
+	return nil, errors.New("invalid type definition of field " + name)
+}
+
+// _parseSchemaFile parses content of a schema file, which is a JSON object mapping field names to types:
+//
+//     {
+//       "id": "string",
+//       "tenant": "string",
+//       "age": "int?",
+//       "address": {"city": "string", "street": "string"},
+//       "tags": ["string"]
+//     }
+//
+// Type is one of "string", "int", "float" (or "number"), "bool", "object" (a free-form object), "any", a nested object
+// or an array with one element type. Suffix "?" marks optional fields.
+func _parseSchemaFile(data []byte) (*typeInfo, error) {
+	var def map[string]interface{}
+	if err := json.Unmarshal(data, &def); err != nil {
+		return nil, err
+	}
+	schema, err := _parseSchemaType("", def)
+	if err != nil {
+		return nil, err
+	}
+	if schema.fields["id"] == nil {
+		schema.fields["id"] = &typeInfo{kind: kindString}
+	} else if schema.fields["id"].kind != kindString {
+		return nil, errors.New("field id must be a string")
+	}
+	return schema, nil
+}
@@ -0,0 +1,66 @@
+package gocosmos
+
+import (
+	"encoding/json"
+)
+
+// JsonCodec converts Go values to JSON and back. It is used whenever documents and statement parameters are sent to the
+// server (request bodies, including documents built from INSERT/UPSERT/UPDATE arguments and query parameters) and when
+// documents are received (results of document operations, queries and change feed), so that applications can plug in
+// their own encoding rules, e.g. to support decimal types, customize how structs are encoded or decode numbers as
+// json.Number to keep their precision:
+//
+//     type numberCodec struct{}
+//
+//     func (numberCodec) Marshal(v interface{}) ([]byte, error) {
+//         return json.Marshal(v)
+//     }
+//
+//     func (numberCodec) Unmarshal(data []byte, v interface{}) error {
+//         decoder := json.NewDecoder(bytes.NewReader(data))
+//         decoder.UseNumber()
+//         return decoder.Decode(v)
+//     }
+//
+// Implementations must be safe for concurrent use, and must support the json struct tags of the response types of
+// RestClient (e.g. RespQueryDocs).
+//
+// Available since v0.1.1
+type JsonCodec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// StdJsonCodec is the default JsonCodec, which uses encoding/json.
+//
+// Available since v0.1.1
+var StdJsonCodec JsonCodec = stdJsonCodec{}
+
+type stdJsonCodec struct{}
+
+// Marshal implements JsonCodec.Marshal.
+func (stdJsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal implements JsonCodec.Unmarshal.
+func (stdJsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// SetJsonCodec supplies the JsonCodec the client converts documents and parameters to JSON and back with. Passing nil
+// reverts to the default StdJsonCodec.
+//
+// Available since v0.1.1
+func (c *RestClient) SetJsonCodec(codec JsonCodec) *RestClient {
+	c.codec = codec
+	return c
+}
+
+// jsonCodec returns the client's JsonCodec, StdJsonCodec if none is supplied.
+func (c *RestClient) jsonCodec() JsonCodec {
+	if c == nil || c.codec == nil {
+		return StdJsonCodec
+	}
+	return c.codec
+}
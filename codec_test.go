@@ -0,0 +1,73 @@
+package gocosmos
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// _unescapedNumberCodec does not escape HTML characters and decodes numbers as json.Number.
+type _unescapedNumberCodec struct{}
+
+func (_unescapedNumberCodec) Marshal(v interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	err := encoder.Encode(v)
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), err
+}
+
+func (_unescapedNumberCodec) Unmarshal(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+func TestConnector_SetJsonCodec(t *testing.T) {
+	name := "TestConnector_SetJsonCodec"
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		body = string(data)
+		if r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/docs") && r.Header.Get("X-Ms-Documentdb-Isquery") == "" {
+			w.WriteHeader(http.StatusCreated)
+			w.Write(data)
+			return
+		}
+		w.Write([]byte(`{"_count":1,"Documents":[{"amount":12345678901234567890}]}`))
+	}))
+	defer server.Close()
+
+	connector, err := NewConnectorFromConnStr("AccountEndpoint=" + server.URL + ";AccountKey=cHJpbWFyeQ==;DefaultDb=db")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	db := sql.OpenDB(connector.SetJsonCodec(_unescapedNumberCodec{}))
+	defer db.Close()
+
+	if _, err := db.Exec("INSERT INTO coll JSON :1", struct {
+		Id   string `json:"id"`
+		Html string `json:"html"`
+	}{"1", "<b>x</b>"}, "1"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if !strings.Contains(body, `"html":"<b>x</b>"`) {
+		t.Fatalf("%s failed: document must be encoded with the supplied codec, request body %s", name, body)
+	}
+
+	var amount interface{}
+	if err := db.QueryRow("SELECT c.amount FROM c WITH collection=coll").Scan(&amount); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	} else if amount != json.Number("12345678901234567890") {
+		t.Fatalf("%s failed: documents must be decoded with the supplied codec, received %#v", name, amount)
+	}
+
+	if connector.SetJsonCodec(nil).codec != nil || (&RestClient{}).jsonCodec() != StdJsonCodec {
+		t.Fatalf("%s failed: nil codec must revert to StdJsonCodec", name)
+	}
+}
@@ -0,0 +1,462 @@
+package gocosmos
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds the settings of connections to an Azure Cosmos DB account, an alternative to the connection string for
+// programmatic setups. Zero values mean the default value of the matching connection string option (see NewRestClient).
+//
+// Example:
+//     connector, err := gocosmos.NewConnector(gocosmos.Config{
+//         Endpoint:         "https://myaccount.documents.azure.com:443/",
+//         AccountKey:       accountKey,
+//         DefaultDb:        "mydb",
+//         Timeout:          5 * time.Second,
+//         PreferredRegions: []string{"West US", "East US"},
+//     })
+//     db := sql.OpenDB(connector)
+//
+// Available since v0.1.1
+type Config struct {
+	Endpoint            string          // account endpoint, e.g. https://myaccount.documents.azure.com:443/ (connection string option AccountEndpoint)
+	AccountKey          string          // account key, not required if Credential is supplied (AccountKey)
+	SecondaryAccountKey string          // key to fall back to on StatusCode=401 (SecondaryAccountKey)
+	Credential          TokenCredential // if not nil, requests are authenticated with Azure AD (Entra ID) tokens obtained from it
+	DefaultDb           string          // default database of statements that do not specify one (DefaultDb)
+	ApiVersion          string          // Cosmos DB REST API version (Version)
+	Timeout             time.Duration   // request timeout, ignored if HttpClient is supplied (TimeoutMs)
+
+	PreferredRegions   []string // regions to route read requests to, in order of preference (PreferredRegions)
+	EndpointDiscovery  bool     // enables regional endpoint discovery (EndpointDiscovery)
+	DefaultConsistency string   // consistency level of read requests that do not specify one (DefaultConsistency)
+
+	TransientRetries        int           // retries of idempotent requests on transient network errors, negative disables (TransientRetries)
+	RetryBackoff            string        // growth of delays between retries: fixed, exponential or jitter (RetryBackoff)
+	RetryBaseDelay          time.Duration // delay before the first retry (RetryBaseDelayMs)
+	RetryMaxWait            time.Duration // maximum total delay between retries of a request (RetryMaxWaitMs)
+	CircuitBreakerThreshold int           // consecutive failures that open the circuit breaker, 0 disables (CircuitBreakerThreshold)
+	CircuitBreakerCooldown  time.Duration // time before a probe request is let through an open breaker (CircuitBreakerCooldownMs)
+	RuBudget                float64       // RU/s budget of requests (RuBudget)
+	TimeFormat              string        // format of time.Time parameters: rfc3339, epoch, epoch_ms or a Go time layout (TimeFormat)
+
+	AutoPartitionKey   bool // derive partition key values from document data (AutoPartitionKey)
+	ObjectsAsJson      bool // return nested objects and arrays of query results as JSON text (ObjectsAsJson)
+	ParseTime          bool // return _ts and RFC3339 string columns of query results as time.Time (ParseTime)
+	Flatten            bool // flatten nested objects of query results into parent.child columns (Flatten)
+	DisableCompression bool // do not request compressed responses (DisableCompression)
+	InsecureSkipVerify bool // disable TLS certificate verification, ignored if HttpClient is supplied (InsecureSkipVerify)
+
+	SharedSessionTokens bool // share session tokens across the connections of the pool (SharedSessionTokens)
+	AllowLoadLocalFiles bool // allow statement LOAD to read local files (AllowLoadLocalFiles)
+
+	DedicatedGatewayEndpoint    string        // dedicated gateway endpoint to send document reads and queries to (DedicatedGatewayEndpoint)
+	MaxIntegratedCacheStaleness time.Duration // max staleness of integrated cache entries served to reads (MaxIntegratedCacheStalenessMs)
+
+	HttpClient     *http.Client      // if not nil, used to send requests instead of the default client (see Connector.SetHttpClient)
+	JsonCodec      JsonCodec         // if not nil, used to convert documents to JSON and back (see Connector.SetJsonCodec)
+	FieldEncryptor *FieldEncryptor   // if not nil, used to encrypt/decrypt fields of documents (see Connector.SetFieldEncryptor)
+	Interceptors   []Interceptor     // intercept requests and responses (see Connector.SetInterceptors)
+	Options        map[string]string // other connection string options, e.g. {"MaxIdleConnsPerHost": "100"}
+}
+
+// connStr validates the config and builds the matching connection string.
+func (cfg Config) connStr() (string, error) {
+	if cfg.Endpoint == "" {
+		return "", errors.New("Endpoint is required")
+	}
+	if cfg.Timeout < 0 {
+		return "", fmt.Errorf("invalid Timeout <%s>", cfg.Timeout)
+	}
+	opts := cfg.options()
+	for _, opt := range opts {
+		if strings.Contains(opt[0], ";") || strings.Contains(opt[0], "=") || strings.Contains(opt[1], ";") {
+			return "", fmt.Errorf("invalid value of option %s: must not contain ';'", opt[0])
+		}
+	}
+	return cfg.String(), nil
+}
+
+// String returns the connection string (DSN) matching the config, to be used with sql.Open. ParseDSN is its reverse.
+//
+// Note: the connection string contains the account keys, if any.
+//
+// Available since v0.1.1
+func (cfg Config) String() string {
+	opts := cfg.options()
+	parts := make([]string, len(opts))
+	for i, opt := range opts {
+		parts[i] = opt[0] + "=" + opt[1]
+	}
+	return strings.Join(parts, ";")
+}
+
+// options returns the connection string options (name and value) matching the config, in order.
+func (cfg Config) options() [][2]string {
+	opts := make([][2]string, 0)
+	add := func(key, value string) {
+		opts = append(opts, [2]string{key, value})
+	}
+	add("AccountEndpoint", cfg.Endpoint)
+	if cfg.AccountKey != "" {
+		add("AccountKey", cfg.AccountKey)
+	}
+	if cfg.SecondaryAccountKey != "" {
+		add("SecondaryAccountKey", cfg.SecondaryAccountKey)
+	}
+	if cfg.DefaultDb != "" {
+		add("DefaultDb", cfg.DefaultDb)
+	}
+	if cfg.ApiVersion != "" {
+		add("Version", cfg.ApiVersion)
+	}
+	if cfg.Timeout > 0 {
+		add("TimeoutMs", strconv.FormatInt(cfg.Timeout.Milliseconds(), 10))
+	}
+	if len(cfg.PreferredRegions) > 0 {
+		add("PreferredRegions", strings.Join(cfg.PreferredRegions, ","))
+	}
+	if cfg.EndpointDiscovery {
+		add("EndpointDiscovery", "true")
+	}
+	if cfg.DefaultConsistency != "" {
+		add("DefaultConsistency", cfg.DefaultConsistency)
+	}
+	if cfg.TransientRetries < 0 {
+		add("TransientRetries", "0")
+	} else if cfg.TransientRetries > 0 {
+		add("TransientRetries", strconv.Itoa(cfg.TransientRetries))
+	}
+	if cfg.RetryBackoff != "" {
+		add("RetryBackoff", cfg.RetryBackoff)
+	}
+	if cfg.RetryBaseDelay != 0 {
+		add("RetryBaseDelayMs", strconv.FormatInt(cfg.RetryBaseDelay.Milliseconds(), 10))
+	}
+	if cfg.RetryMaxWait != 0 {
+		add("RetryMaxWaitMs", strconv.FormatInt(cfg.RetryMaxWait.Milliseconds(), 10))
+	}
+	if cfg.CircuitBreakerThreshold != 0 {
+		add("CircuitBreakerThreshold", strconv.Itoa(cfg.CircuitBreakerThreshold))
+	}
+	if cfg.CircuitBreakerCooldown != 0 {
+		add("CircuitBreakerCooldownMs", strconv.FormatInt(cfg.CircuitBreakerCooldown.Milliseconds(), 10))
+	}
+	if cfg.RuBudget != 0 {
+		add("RuBudget", strconv.FormatFloat(cfg.RuBudget, 'f', -1, 64))
+	}
+	if cfg.TimeFormat != "" {
+		add("TimeFormat", cfg.TimeFormat)
+	}
+	if cfg.DedicatedGatewayEndpoint != "" {
+		add("DedicatedGatewayEndpoint", cfg.DedicatedGatewayEndpoint)
+	}
+	if cfg.MaxIntegratedCacheStaleness > 0 {
+		add("MaxIntegratedCacheStalenessMs", strconv.FormatInt(cfg.MaxIntegratedCacheStaleness.Milliseconds(), 10))
+	}
+	for _, opt := range []struct {
+		key   string
+		value bool
+	}{
+		{"AutoPartitionKey", cfg.AutoPartitionKey},
+		{"ObjectsAsJson", cfg.ObjectsAsJson},
+		{"ParseTime", cfg.ParseTime},
+		{"Flatten", cfg.Flatten},
+		{"SharedSessionTokens", cfg.SharedSessionTokens},
+		{"AllowLoadLocalFiles", cfg.AllowLoadLocalFiles},
+		{"DisableCompression", cfg.DisableCompression},
+		{"InsecureSkipVerify", cfg.InsecureSkipVerify},
+	} {
+		if opt.value {
+			add(opt.key, "true")
+		}
+	}
+	keys := make([]string, 0, len(cfg.Options))
+	for k := range cfg.Options {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		add(k, cfg.Options[k])
+	}
+	return opts
+}
+
+// dsnOptions lists the names of supported connection string options, see NewRestClient.
+var dsnOptions = []string{"AccountEndpoint", "AccountKey", "SecondaryAccountKey", "AuthMode", "MsiClientId",
+	"DefaultDb", "Db", "Version", "TimeoutMs", "PreferredRegions", "EndpointDiscovery", "DefaultConsistency",
+	"TransientRetries", "CircuitBreakerThreshold", "CircuitBreakerCooldownMs", "RuBudget", "AutoPartitionKey",
+	"ObjectsAsJson", "DisableCompression", "InsecureSkipVerify", "LogLevel", "LogParams", "MaxIdleConns",
+	"MaxIdleConnsPerHost", "MaxConnsPerHost", "IdleConnTimeoutMs", "KeepAliveMs", "DialTimeoutMs",
+	"TlsHandshakeTimeoutMs", "StmtCacheSize", "TimeFormat", "ParseTime",
+	"ContinuationLimitKb", "MaxParallelism", "PrefetchPages", "RetryBackoff", "RetryBaseDelayMs", "RetryMaxWaitMs",
+	"SharedSessionTokens", "DedicatedGatewayEndpoint", "MaxIntegratedCacheStalenessMs",
+	"Flatten", "AllowLoadLocalFiles"}
+
+// _levenshtein returns the edit distance between two strings.
+func _levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = cur[j-1] + 1
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if prev[j-1]+cost < cur[j] {
+				cur[j] = prev[j-1] + cost
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// _unknownOptionError builds the error for an unknown connection string option, suggesting the closest supported one.
+func _unknownOptionError(key string) error {
+	suggestion, minDist := "", len(key)/3+1 // tolerate one typo every three characters
+	for _, opt := range dsnOptions {
+		if dist := _levenshtein(strings.ToUpper(key), strings.ToUpper(opt)); dist < minDist {
+			suggestion, minDist = opt, dist
+		}
+	}
+	if suggestion != "" {
+		return fmt.Errorf("unknown option <%s> in connection string, did you mean %s?", key, suggestion)
+	}
+	return fmt.Errorf("unknown option <%s> in connection string", key)
+}
+
+// ParseDSN parses a connection string (see NewRestClient) into a Config, validating option names and values.
+// Options that have no dedicated field in Config (e.g. LogLevel or MaxIdleConns) are stored in Config.Options.
+//
+// Available since v0.1.1
+func ParseDSN(dsn string) (*Config, error) {
+	canonical := make(map[string]string, len(dsnOptions))
+	for _, opt := range dsnOptions {
+		canonical[strings.ToUpper(opt)] = opt
+	}
+	cfg := &Config{}
+	for _, part := range strings.Split(_trimConnStr(dsn), ";") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		tokens := strings.SplitN(part, "=", 2)
+		key := strings.TrimSpace(tokens[0])
+		opt, ok := canonical[strings.ToUpper(key)]
+		if !ok {
+			return nil, _unknownOptionError(key)
+		}
+		if len(tokens) != 2 {
+			return nil, fmt.Errorf("missing value of option <%s> in connection string", key)
+		}
+		value := strings.TrimSpace(tokens[1])
+		var err error
+		switch opt {
+		case "AccountEndpoint":
+			cfg.Endpoint = value
+		case "AccountKey":
+			cfg.AccountKey = value
+		case "SecondaryAccountKey":
+			cfg.SecondaryAccountKey = value
+		case "DefaultDb", "Db":
+			cfg.DefaultDb = value
+		case "Version":
+			cfg.ApiVersion = value
+		case "TimeoutMs":
+			var ms int
+			if ms, err = strconv.Atoi(value); err != nil || ms < 0 {
+				return nil, fmt.Errorf("invalid TimeoutMs <%s>", value)
+			}
+			cfg.Timeout = time.Duration(ms) * time.Millisecond
+		case "PreferredRegions":
+			cfg.PreferredRegions = nil
+			for _, region := range strings.Split(value, ",") {
+				if region = strings.TrimSpace(region); region != "" {
+					cfg.PreferredRegions = append(cfg.PreferredRegions, region)
+				}
+			}
+		case "EndpointDiscovery":
+			cfg.EndpointDiscovery, err = strconv.ParseBool(value)
+		case "DefaultConsistency":
+			if _, err := _normalizeConsistencyLevel(value); err != nil {
+				return nil, err
+			}
+			cfg.DefaultConsistency = value
+		case "TransientRetries":
+			if cfg.TransientRetries, err = strconv.Atoi(value); err != nil || cfg.TransientRetries < 0 {
+				return nil, fmt.Errorf("invalid TransientRetries <%s>", value)
+			} else if cfg.TransientRetries == 0 {
+				cfg.TransientRetries = -1
+			}
+		case "RetryBackoff":
+			if _, err := _normalizeRetryBackoff(value); err != nil {
+				return nil, err
+			}
+			cfg.RetryBackoff = value
+		case "RetryBaseDelayMs", "RetryMaxWaitMs":
+			var ms int
+			if ms, err = strconv.Atoi(value); err != nil || ms <= 0 {
+				return nil, fmt.Errorf("invalid %s <%s>", opt, value)
+			}
+			if opt == "RetryBaseDelayMs" {
+				cfg.RetryBaseDelay = time.Duration(ms) * time.Millisecond
+			} else {
+				cfg.RetryMaxWait = time.Duration(ms) * time.Millisecond
+			}
+		case "CircuitBreakerThreshold":
+			if cfg.CircuitBreakerThreshold, err = strconv.Atoi(value); err != nil || cfg.CircuitBreakerThreshold < 0 {
+				return nil, fmt.Errorf("invalid CircuitBreakerThreshold <%s>", value)
+			}
+		case "CircuitBreakerCooldownMs":
+			var ms int
+			if ms, err = strconv.Atoi(value); err != nil || ms <= 0 {
+				return nil, fmt.Errorf("invalid CircuitBreakerCooldownMs <%s>", value)
+			}
+			cfg.CircuitBreakerCooldown = time.Duration(ms) * time.Millisecond
+		case "RuBudget":
+			if cfg.RuBudget, err = strconv.ParseFloat(value, 64); err != nil || cfg.RuBudget <= 0 {
+				return nil, fmt.Errorf("invalid RuBudget <%s>", value)
+			}
+		case "AutoPartitionKey":
+			cfg.AutoPartitionKey, err = strconv.ParseBool(value)
+		case "ObjectsAsJson":
+			cfg.ObjectsAsJson, err = strconv.ParseBool(value)
+		case "ParseTime":
+			cfg.ParseTime, err = strconv.ParseBool(value)
+		case "Flatten":
+			cfg.Flatten, err = strconv.ParseBool(value)
+		case "SharedSessionTokens":
+			cfg.SharedSessionTokens, err = strconv.ParseBool(value)
+		case "AllowLoadLocalFiles":
+			cfg.AllowLoadLocalFiles, err = strconv.ParseBool(value)
+		case "DedicatedGatewayEndpoint":
+			cfg.DedicatedGatewayEndpoint = value
+		case "MaxIntegratedCacheStalenessMs":
+			var ms int
+			if ms, err = strconv.Atoi(value); err != nil || ms <= 0 {
+				return nil, fmt.Errorf("invalid MaxIntegratedCacheStalenessMs <%s>", value)
+			}
+			cfg.MaxIntegratedCacheStaleness = time.Duration(ms) * time.Millisecond
+		case "TimeFormat":
+			if _, err := _parseTimeFormat(value); err != nil {
+				return nil, err
+			}
+			cfg.TimeFormat = value
+		case "DisableCompression":
+			cfg.DisableCompression, err = strconv.ParseBool(value)
+		case "InsecureSkipVerify":
+			cfg.InsecureSkipVerify, err = strconv.ParseBool(value)
+		default:
+			if cfg.Options == nil {
+				cfg.Options = make(map[string]string)
+			}
+			cfg.Options[opt] = value
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s <%s>", opt, value)
+		}
+	}
+	if cfg.Endpoint == "" {
+		return nil, errors.New("AccountEndpoint not found in connection string")
+	}
+	return cfg, nil
+}
+
+// NewConnector creates a new Connector from a Config, to be used with sql.OpenDB.
+//
+// If cfg.Credential is supplied, the connector authenticates requests with Azure AD (Entra ID) tokens (see
+// NewConnectorWithTokenCredential), otherwise with the account key (see NewConnectorFromConnStr).
+//
+// Available since v0.1.1
+func NewConnector(cfg Config) (*Connector, error) {
+	connStr, err := cfg.connStr()
+	if err != nil {
+		return nil, err
+	}
+	var connector *Connector
+	if cfg.Credential != nil {
+		connector, err = NewConnectorWithTokenCredential(connStr, cfg.Credential)
+	} else {
+		connector, err = NewConnectorFromConnStr(connStr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return connector.SetHttpClient(cfg.HttpClient).SetJsonCodec(cfg.JsonCodec).SetFieldEncryptor(cfg.FieldEncryptor).
+		SetInterceptors(cfg.Interceptors...), nil
+}
+
+// envOptions maps environment variables read by ConfigFromEnv to connection string options.
+var envOptions = [][2]string{
+	{"COSMOSDB_ENDPOINT", "AccountEndpoint"},
+	{"COSMOSDB_KEY", "AccountKey"},
+	{"COSMOSDB_SECONDARY_KEY", "SecondaryAccountKey"},
+	{"COSMOSDB_DATABASE", "DefaultDb"},
+	{"COSMOSDB_REGIONS", "PreferredRegions"},
+	{"COSMOSDB_TIMEOUT_MS", "TimeoutMs"},
+	{"COSMOSDB_CONSISTENCY", "DefaultConsistency"},
+	{"COSMOSDB_AUTH_MODE", "AuthMode"},
+}
+
+// DsnFromEnv is the connection string placeholder that makes the driver read the connection settings from environment
+// variables (see ConfigFromEnv). An empty connection string has the same effect.
+//
+// Available since v0.1.1
+const DsnFromEnv = "env"
+
+// ConfigFromEnv builds a Config from environment variables:
+//
+// - COSMOSDB_ENDPOINT (required): account endpoint.
+//
+// - COSMOSDB_KEY: account key, not required with managed identity or token credential authentication.
+//
+// - COSMOSDB_SECONDARY_KEY, COSMOSDB_DATABASE, COSMOSDB_REGIONS (comma-separated), COSMOSDB_TIMEOUT_MS,
+// COSMOSDB_CONSISTENCY, COSMOSDB_AUTH_MODE: connection string options SecondaryAccountKey, DefaultDb, PreferredRegions,
+// TimeoutMs, DefaultConsistency and AuthMode respectively.
+//
+// - COSMOSDB_OPTIONS: other connection string options, e.g. "LogLevel=debug;MaxIdleConnsPerHost=100".
+//
+// Available since v0.1.1
+func ConfigFromEnv() (*Config, error) {
+	parts := make([]string, 0)
+	for _, opt := range envOptions {
+		if v := strings.TrimSpace(os.Getenv(opt[0])); v != "" {
+			parts = append(parts, opt[1]+"="+v)
+		}
+	}
+	if os.Getenv("COSMOSDB_ENDPOINT") == "" {
+		return nil, errors.New("environment variable COSMOSDB_ENDPOINT is not set")
+	}
+	if v := strings.TrimSpace(os.Getenv("COSMOSDB_OPTIONS")); v != "" {
+		parts = append(parts, v)
+	}
+	return ParseDSN(strings.Join(parts, ";"))
+}
+
+// _resolveConnStr returns the connection string built from environment variables if connStr is empty or DsnFromEnv,
+// connStr otherwise.
+func _resolveConnStr(connStr string) (string, error) {
+	if v := strings.TrimSpace(connStr); v != "" && !strings.EqualFold(v, DsnFromEnv) {
+		return connStr, nil
+	}
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		return "", err
+	}
+	return cfg.String(), nil
+}
@@ -0,0 +1,155 @@
+package gocosmos
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestConfig_connStr(t *testing.T) {
+	name := "TestConfig_connStr"
+	cfg := Config{
+		Endpoint:                "https://localhost:8081/",
+		AccountKey:              "cHJpbWFyeQ==",
+		DefaultDb:               "mydb",
+		Timeout:                 5 * time.Second,
+		PreferredRegions:        []string{"West US", "East US"},
+		TransientRetries:        -1,
+		CircuitBreakerThreshold: 3,
+		CircuitBreakerCooldown:  time.Minute,
+		RuBudget:                400.5,
+		AutoPartitionKey:        true,
+		Options:                 map[string]string{"MaxIdleConnsPerHost": "100", "LogLevel": "debug"},
+	}
+	expected := "AccountEndpoint=https://localhost:8081/;AccountKey=cHJpbWFyeQ==;DefaultDb=mydb;TimeoutMs=5000;" +
+		"PreferredRegions=West US,East US;TransientRetries=0;CircuitBreakerThreshold=3;CircuitBreakerCooldownMs=60000;" +
+		"RuBudget=400.5;AutoPartitionKey=true;LogLevel=debug;MaxIdleConnsPerHost=100"
+	if connStr, err := cfg.connStr(); err != nil || connStr != expected {
+		t.Fatalf("%s failed: expected %s but received %s / %s", name, expected, connStr, err)
+	}
+
+	for _, cfg := range []Config{
+		{AccountKey: "cHJpbWFyeQ=="},
+		{Endpoint: "https://localhost:8081/", AccountKey: "cHJpbWFyeQ==", DefaultDb: "mydb;TimeoutMs=1"},
+		{Endpoint: "https://localhost:8081/", AccountKey: "cHJpbWFyeQ==", Timeout: -time.Second},
+	} {
+		if _, err := NewConnector(cfg); err == nil {
+			t.Fatalf("%s failed: expected error for config %#v", name, cfg)
+		}
+	}
+}
+
+func TestNewConnector(t *testing.T) {
+	name := "TestNewConnector"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"mycoll"}`))
+	}))
+	defer server.Close()
+
+	rt := &_recordingTransport{}
+	connector, err := NewConnector(Config{
+		Endpoint:   server.URL,
+		AccountKey: "cHJpbWFyeQ==",
+		DefaultDb:  "mydb",
+		HttpClient: &http.Client{Transport: rt},
+	})
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+	if _, err := db.Exec("CREATE COLLECTION mycoll WITH pk=/id"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if len(rt.requests) != 1 || rt.requests[0] != "POST /dbs/mydb/colls" {
+		t.Fatalf("%s failed: requests must go through the supplied client, recorded %#v", name, rt.requests)
+	}
+
+	if _, err := NewConnector(Config{Endpoint: server.URL}); err == nil {
+		t.Fatalf("%s failed: AccountKey or Credential must be required", name)
+	}
+}
+
+func TestParseDSN(t *testing.T) {
+	name := "TestParseDSN"
+	dsn := "AccountEndpoint=https://localhost:8081/;AccountKey=cHJpbWFyeQ==;DefaultDb=mydb;TimeoutMs=5000;" +
+		"PreferredRegions=West US,East US;TransientRetries=0;CircuitBreakerThreshold=3;CircuitBreakerCooldownMs=60000;" +
+		"RuBudget=400.5;AutoPartitionKey=true;LogLevel=debug;MaxIdleConnsPerHost=100"
+	cfg, err := ParseDSN(dsn)
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if cfg.Endpoint != "https://localhost:8081/" || cfg.DefaultDb != "mydb" || cfg.Timeout != 5*time.Second ||
+		len(cfg.PreferredRegions) != 2 || cfg.TransientRetries != -1 || cfg.CircuitBreakerCooldown != time.Minute ||
+		!cfg.AutoPartitionKey || cfg.Options["LogLevel"] != "debug" {
+		t.Fatalf("%s failed: unexpected config %#v", name, cfg)
+	}
+	if cfg.String() != dsn {
+		t.Fatalf("%s failed: expected %s but received %s", name, dsn, cfg.String())
+	}
+	if cfg, err := ParseDSN("accountendpoint=https://localhost:8081/;accountkey=cHJpbWFyeQ==;db=mydb;loglevel=info;"); err != nil ||
+		cfg.DefaultDb != "mydb" || cfg.Options["LogLevel"] != "info" {
+		t.Fatalf("%s failed: option names must be case-insensitive %#v / %s", name, cfg, err)
+	}
+
+	for dsn, expected := range map[string]string{
+		"AccountEndpoint=https://localhost:8081/;AccountKey=cHJpbWFyeQ==;TimeoutMS=1;PreferedRegions=West US": "unknown option <PreferedRegions> in connection string, did you mean PreferredRegions?",
+		"AccountEndpoint=https://localhost:8081/;Foo=bar":                                                     "unknown option <Foo> in connection string",
+		"AccountEndpoint=https://localhost:8081/;TimeoutMs=abc":                                               "invalid TimeoutMs <abc>",
+		"AccountEndpoint=https://localhost:8081/;AutoPartitionKey=maybe":                                      "invalid AutoPartitionKey <maybe>",
+		"AccountEndpoint=https://localhost:8081/;AccountKey":                                                  "missing value of option <AccountKey> in connection string",
+		"AccountKey=cHJpbWFyeQ==":                                                                             "AccountEndpoint not found in connection string",
+	} {
+		if _, err := ParseDSN(dsn); err == nil || err.Error() != expected {
+			t.Fatalf("%s failed: <%s> expected error %q but received %v", name, dsn, expected, err)
+		}
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	name := "TestConfigFromEnv"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"mycoll"}`))
+	}))
+	defer server.Close()
+	for _, key := range []string{"COSMOSDB_ENDPOINT", "COSMOSDB_KEY", "COSMOSDB_DATABASE", "COSMOSDB_REGIONS", "COSMOSDB_OPTIONS"} {
+		defer os.Setenv(key, os.Getenv(key))
+		os.Unsetenv(key)
+	}
+
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Fatalf("%s failed: COSMOSDB_ENDPOINT must be required", name)
+	}
+	os.Setenv("COSMOSDB_ENDPOINT", server.URL)
+	os.Setenv("COSMOSDB_KEY", "cHJpbWFyeQ==")
+	os.Setenv("COSMOSDB_DATABASE", "mydb")
+	os.Setenv("COSMOSDB_REGIONS", "West US, East US")
+	os.Setenv("COSMOSDB_OPTIONS", "LogLevel=debug;TransientRetries=1")
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if cfg.Endpoint != server.URL || cfg.AccountKey != "cHJpbWFyeQ==" || cfg.DefaultDb != "mydb" ||
+		len(cfg.PreferredRegions) != 2 || cfg.PreferredRegions[1] != "East US" || cfg.TransientRetries != 1 ||
+		cfg.Options["LogLevel"] != "debug" {
+		t.Fatalf("%s failed: unexpected config %#v", name, cfg)
+	}
+
+	os.Unsetenv("COSMOSDB_REGIONS")
+	for _, dsn := range []string{"", DsnFromEnv} {
+		db, _ := sql.Open("gocosmos", dsn)
+		if _, err := db.Exec("CREATE COLLECTION mycoll WITH pk=/id"); err != nil {
+			t.Fatalf("%s failed: <%s> %s", name, dsn, err)
+		}
+		db.Close()
+	}
+	os.Setenv("COSMOSDB_OPTIONS", "TimeoutMss=1")
+	if _, err := NewRestClient(nil, ""); err == nil || err.Error() != "unknown option <TimeoutMss> in connection string, did you mean TimeoutMs?" {
+		t.Fatalf("%s failed: invalid options must be reported, received %v", name, err)
+	}
+}
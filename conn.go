@@ -1,8 +1,14 @@
 package gocosmos
 
 import (
+	"context"
+	"crypto/rand"
 	"database/sql/driver"
 	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -10,15 +16,211 @@ var (
 	locGmt, _ = time.LoadLocation("GMT")
 )
 
+// IdGenerator generates the id of a document inserted without an "id" field.
+//
+// Available since v0.1.1
+type IdGenerator func() string
+
+// UuidGenerator is the default IdGenerator, which generates random (version 4) UUIDs.
+//
+// Available since v0.1.1
+func UuidGenerator() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // variant RFC 4122
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// connSession holds the per-session state of a connection.
+//
+// Statements may run with a copy of their Conn (e.g. bound to a deadline), so the state is shared via pointer.
+type connSession struct {
+	bad bool // set after a fatal error, the connection must not be reused
+}
+
 // Conn is Azure CosmosDB connection handle.
+//
+// Since v0.1.1, Conn implements driver.Validator and driver.SessionResetter: a connection reports itself bad after a
+// fatal error (authentication failure or transport error), and its per-session state (session tokens) is reset when it
+// is returned to the pool.
 type Conn struct {
-	restClient *RestClient // Azure CosmosDB REST API client.
-	defaultDb  string      // default database used in Cosmos DB operations.
+	restClient  *RestClient // Azure CosmosDB REST API client.
+	defaultDb   string      // default database used in Cosmos DB operations.
+	idGenerator IdGenerator // (since v0.1.1) generates id of documents inserted without an "id" field.
+	autoPk      bool        // (since v0.1.1) if true, partition key value is derived from document data instead of the last argument.
+
+	objectsAsJson bool         // (since v0.1.1) if true, nested objects and arrays of query results are returned as JSON text.
+	parseTime     bool         // (since v0.1.1) if true, _ts and RFC3339 string columns of query results are returned as time.Time.
+	flatten       bool         // (since v0.1.1) if true, nested objects of query results are flattened into parent.child columns.
+	timeFormat    string       // (since v0.1.1) format of time.Time parameters, see _parseTimeFormat.
+	session       *connSession // (since v0.1.1) per-session state, reset when the connection is returned to the pool.
+	stmtCache     *stmtCache   // (since v0.1.1) cache of parsed statements, nil if disabled.
+
+	maxParallelism int // (since v0.1.1) number of partition key ranges read concurrently by cross-partition queries.
+	prefetchPages  int // (since v0.1.1) if > 0, query rows are streamed with up to prefetchPages pages read ahead.
+
+	sharedSessions bool // (since v0.1.1) session tokens are shared with other connections, thus not reset with the session.
+
+	allowLoadLocalFiles bool // (since v0.1.1) if true, statement LOAD is allowed to read local files.
+}
+
+// _parseBoolOption returns the value of a boolean connection string option, false if not specified.
+func _parseBoolOption(params map[string]string, name string) (bool, error) {
+	v, ok := params[strings.ToUpper(name)]
+	if !ok {
+		return false, nil
+	}
+	value, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s <%s>", name, v)
+	}
+	return value, nil
+}
+
+// _parseIntOption returns the value of an integer connection string option, defaultValue if not specified.
+func _parseIntOption(params map[string]string, name string, defaultValue, min int) (int, error) {
+	v, ok := params[strings.ToUpper(name)]
+	if !ok {
+		return defaultValue, nil
+	}
+	value, err := strconv.Atoi(v)
+	if err != nil || value < min {
+		return 0, fmt.Errorf("invalid %s <%s>", name, v)
+	}
+	return value, nil
+}
+
+// newConn creates a new Conn that uses the supplied REST client. Default database and the other options of the
+// database/sql driver (see the "Connection string options" section of README.md) are taken from the connection string.
+func newConn(restClient *RestClient) (*Conn, error) {
+	params := restClient.params
+	defaultDb, ok := params["DEFAULTDB"]
+	if !ok {
+		defaultDb, _ = params["DB"]
+	}
+	conn := &Conn{restClient: restClient, defaultDb: defaultDb, idGenerator: UuidGenerator, session: &connSession{}}
+	for _, opt := range []struct {
+		name   string
+		target *bool
+	}{
+		{"AutoPartitionKey", &conn.autoPk},
+		{"ObjectsAsJson", &conn.objectsAsJson},
+		{"ParseTime", &conn.parseTime},
+		{"Flatten", &conn.flatten},
+		{"AllowLoadLocalFiles", &conn.allowLoadLocalFiles},
+	} {
+		var err error
+		if *opt.target, err = _parseBoolOption(params, opt.name); err != nil {
+			return nil, err
+		}
+	}
+	var err error
+	if conn.timeFormat, err = _parseTimeFormat(params["TIMEFORMAT"]); err != nil {
+		return nil, err
+	}
+	if conn.maxParallelism, err = _parseIntOption(params, "MaxParallelism", 0, 1); err != nil {
+		return nil, err
+	}
+	if conn.prefetchPages, err = _parseIntOption(params, "PrefetchPages", 0, 0); err != nil {
+		return nil, err
+	}
+	stmtCacheSize, err := _parseIntOption(params, "StmtCacheSize", defaultStmtCacheSize, 0)
+	if err != nil {
+		return nil, err
+	}
+	if stmtCacheSize > 0 {
+		conn.stmtCache = newStmtCache(stmtCacheSize)
+	}
+	conn.sharedSessions = restClient.sessions != nil
+	if !conn.sharedSessions {
+		restClient.sessions = newSessionTokens()
+	}
+	return conn, nil
+}
+
+// parse parses the query, or returns the statement cached from a previous parse of the same query.
+func (c *Conn) parse(query string) (driver.Stmt, error) {
+	if c.stmtCache == nil {
+		return parseQueryWithDefaultDb(c, c.defaultDb, query)
+	}
+	if stmt, ok := c.stmtCache.get(query); ok {
+		return stmt, nil
+	}
+	stmt, err := parseQueryWithDefaultDb(c, c.defaultDb, query)
+	if err == nil {
+		c.stmtCache.put(query, stmt)
+	}
+	return stmt, err
 }
 
 // Prepare implements driver.Conn.Prepare.
 func (c *Conn) Prepare(query string) (driver.Stmt, error) {
-	return parseQueryWithDefaultDb(c, c.defaultDb, query)
+	stmt, err := c.parse(query)
+	if c.restClient.logger == nil {
+		return stmt, err
+	}
+	if err != nil {
+		c.restClient.log(LogLevelWarn, "cannot parse statement", map[string]interface{}{"query": query, "error": err.Error()})
+		return nil, err
+	}
+	return &loggedStmt{Stmt: stmt, restClient: c.restClient, query: query}, nil
+}
+
+// PrepareContext implements driver.ConnPrepareContext.PrepareContext.
+//
+// Statements prepared by this function send requests with the context of the Exec/Query call, which can be used to
+// cancel the call or to collect diagnostics (see WithDiagnostics).
+//
+// Available since v0.1.1
+func (c *Conn) PrepareContext(_ context.Context, query string) (driver.Stmt, error) {
+	stmt, err := c.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return &contextStmt{Stmt: stmt, conn: c}, nil
+}
+
+// _isFatalConnError returns true if err means the connection cannot be reused: authentication failures (e.g. the
+// account key has been revoked) and transport errors. Errors caused by the caller (e.g. canceled context) and requests
+// rejected by the circuit breaker are not fatal.
+func _isFatalConnError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, ErrCircuitOpen) || errors.Is(err, ErrTimeout) {
+		return false
+	}
+	if errors.Is(err, ErrUnauthorized) {
+		return true
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// checkErr marks the connection bad if err is fatal.
+func (c *Conn) checkErr(err error) {
+	if c.session != nil && _isFatalConnError(err) {
+		c.session.bad = true
+	}
+}
+
+// IsValid implements driver.Validator.IsValid.
+//
+// Available since v0.1.1
+func (c *Conn) IsValid() bool {
+	return c.session == nil || !c.session.bad
+}
+
+// ResetSession implements driver.SessionResetter.ResetSession.
+//
+// Available since v0.1.1
+func (c *Conn) ResetSession(_ context.Context) error {
+	if !c.IsValid() {
+		return driver.ErrBadConn
+	}
+	if c.restClient.sessions != nil && !c.sharedSessions {
+		c.restClient.sessions.reset()
+	}
+	return nil
 }
 
 // Close implements driver.Conn.Close.
@@ -32,7 +234,18 @@ func (c *Conn) Begin() (driver.Tx, error) {
 }
 
 // CheckNamedValue implements driver.NamedValueChecker.CheckNamedValue.
+//
+// Since v0.1.1, time.Time (and *time.Time) parameters are converted according to the connection string option
+// TimeFormat (RFC3339 strings by default, see NewRestClient). Times nested in documents or slices are not converted.
 func (c *Conn) CheckNamedValue(value *driver.NamedValue) error {
-	// since CosmosDB is document db, it accepts any value types
+	switch v := value.Value.(type) {
+	case time.Time:
+		value.Value = _formatTime(v, c.timeFormat)
+	case *time.Time:
+		if v != nil {
+			value.Value = _formatTime(*v, c.timeFormat)
+		}
+	}
+	// since CosmosDB is document db, it accepts any other value types
 	return nil
 }
@@ -0,0 +1,196 @@
+package gocosmos
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Connector implements driver.Connector, to be used with sql.OpenDB.
+//
+// Available since v0.1.1
+type Connector struct {
+	connStr       string
+	tokenProvider *aadTokenProvider
+	keys          *accountKeys
+	timeout       time.Duration
+	httpClient    *http.Client
+	idGenerator   IdGenerator
+	metrics       MetricsReporter
+	logger        Logger
+	ruLimiter     *RuLimiter
+	codec         JsonCodec
+	encryptor     *FieldEncryptor
+	stats         *statsCollector
+	sessions      *sessionTokens
+	interceptors  []Interceptor
+	driver        *Driver
+}
+
+// NewConnectorFromConnStr creates a new Connector from a connection string, which has the same format as the one used
+// by Driver.Open.
+//
+// Unlike sql.Open, all connections created by the connector share the same account keys, which can be replaced at
+// runtime via SetAccountKeys.
+//
+// Available since v0.1.1
+func NewConnectorFromConnStr(connStr string) (*Connector, error) {
+	restClient, err := NewRestClient(nil, connStr)
+	if err != nil {
+		return nil, err
+	}
+	return &Connector{connStr: connStr, tokenProvider: restClient.tokenProvider, keys: restClient.keys, timeout: restClient.timeout, stats: &statsCollector{}, sessions: newSessionTokens(), driver: &Driver{}}, nil
+}
+
+// SetAccountKeys replaces the account keys used by all connections created by the connector, e.g. when keys are rotated.
+// If secondaryKey is not empty, it is used as fallback when a request signed with primaryKey is rejected with StatusCode=401.
+//
+// Available since v0.1.1
+func (c *Connector) SetAccountKeys(primaryKey, secondaryKey string) error {
+	if c.keys == nil {
+		return errors.New("connector is not authenticated with account key")
+	}
+	return c.keys.set(primaryKey, secondaryKey)
+}
+
+// SetHttpClient supplies the http.Client used by all connections created afterward by the connector, e.g. to configure
+// mTLS, corporate proxies or to record requests. The client's own timeout applies, TimeoutMs and InsecureSkipVerify in the
+// connection string are ignored. Passing nil reverts to the default client.
+//
+// Available since v0.1.1
+func (c *Connector) SetHttpClient(httpClient *http.Client) *Connector {
+	c.httpClient = httpClient
+	return c
+}
+
+// SetTransport supplies the http.RoundTripper used by all connections created afterward by the connector.
+// Request timeout is taken from TimeoutMs in the connection string. See SetHttpClient.
+//
+// Available since v0.1.1
+func (c *Connector) SetTransport(transport http.RoundTripper) *Connector {
+	return c.SetHttpClient(&http.Client{Transport: transport, Timeout: c.timeout})
+}
+
+// SetIdGenerator supplies the function that generates the id of documents inserted without an "id" field, used by all
+// connections created afterward by the connector. Passing nil reverts to the default UuidGenerator.
+//
+// Available since v0.1.1
+func (c *Connector) SetIdGenerator(idGenerator IdGenerator) *Connector {
+	c.idGenerator = idGenerator
+	return c
+}
+
+// SetMetricsReporter supplies the MetricsReporter that all connections created afterward by the connector report
+// metrics of requests to, e.g. a PrometheusReporter. Passing nil disables reporting.
+//
+// Available since v0.1.1
+func (c *Connector) SetMetricsReporter(reporter MetricsReporter) *Connector {
+	c.metrics = reporter
+	return c
+}
+
+// SetLogger supplies the Logger that all connections created afterward by the connector write log entries to (see
+// Logger). Passing nil disables logging.
+//
+// Available since v0.1.1
+func (c *Connector) SetLogger(logger Logger) *Connector {
+	c.logger = logger
+	return c
+}
+
+// SetRuLimiter supplies the RuLimiter that keeps the RU consumption of all connections created afterward by the
+// connector within a budget, replacing the one configured via connection string option RuBudget.
+//
+// Available since v0.1.1
+func (c *Connector) SetRuLimiter(limiter *RuLimiter) *Connector {
+	c.ruLimiter = limiter
+	return c
+}
+
+// SetJsonCodec supplies the JsonCodec that all connections created afterward by the connector convert documents and
+// statement parameters to JSON and back with. Passing nil reverts to the default StdJsonCodec.
+//
+// Available since v0.1.1
+func (c *Connector) SetJsonCodec(codec JsonCodec) *Connector {
+	c.codec = codec
+	return c
+}
+
+// SetFieldEncryptor supplies the FieldEncryptor that all connections created afterward by the connector encrypt and
+// decrypt fields of documents with. Passing nil disables encryption.
+//
+// Available since v0.1.1
+func (c *Connector) SetFieldEncryptor(encryptor *FieldEncryptor) *Connector {
+	c.encryptor = encryptor
+	return c
+}
+
+// SetInterceptors supplies the chain of interceptors of the requests sent by all connections created afterward by the
+// connector (see Interceptor). Passing no interceptor removes the chain.
+//
+// Available since v0.1.1
+func (c *Connector) SetInterceptors(interceptors ...Interceptor) *Connector {
+	c.interceptors = interceptors
+	return c
+}
+
+// NewConnectorWithTokenCredential creates a new Connector that authenticates requests with Azure AD (Entra ID) tokens
+// obtained from cred instead of the account key.
+//
+// connStr has the same format as the one used by Driver.Open, except that AccountKey is not required.
+// Tokens are cached and shared among all connections created by the connector, and refreshed before expiry.
+//
+// Example:
+//     connector, err := gocosmos.NewConnectorWithTokenCredential("AccountEndpoint=https://myaccount.documents.azure.com:443/;DefaultDb=mydb", cred)
+//     db := sql.OpenDB(connector)
+//
+// Available since v0.1.1
+func NewConnectorWithTokenCredential(connStr string, cred TokenCredential) (*Connector, error) {
+	restClient, err := NewRestClientWithTokenCredential(nil, connStr, cred)
+	if err != nil {
+		return nil, err
+	}
+	return &Connector{connStr: connStr, tokenProvider: restClient.tokenProvider, timeout: restClient.timeout, stats: &statsCollector{}, sessions: newSessionTokens(), driver: &Driver{}}, nil
+}
+
+// Connect implements driver.Connector.Connect.
+func (c *Connector) Connect(_ context.Context) (driver.Conn, error) {
+	restClient, err := newRestClient(c.httpClient, c.connStr, c.tokenProvider)
+	if err != nil {
+		return nil, err
+	}
+	if c.keys != nil {
+		restClient.keys = c.keys
+	}
+	restClient.metrics = c.metrics
+	restClient.logger = c.logger
+	restClient.codec = c.codec
+	restClient.encryptor = c.encryptor
+	restClient.stats = c.stats
+	restClient.interceptors = c.interceptors
+	sharedSessions, err := _parseBoolOption(restClient.params, "SharedSessionTokens")
+	if err != nil {
+		return nil, err
+	}
+	if sharedSessions {
+		restClient.sessions = c.sessions
+	}
+	if c.ruLimiter != nil {
+		restClient.ruLimiter = c.ruLimiter
+	}
+	conn, err := newConn(restClient)
+	if err != nil {
+		return nil, err
+	}
+	if c.idGenerator != nil {
+		conn.idGenerator = c.idGenerator
+	}
+	return conn, nil
+}
+
+// Driver implements driver.Connector.Driver.
+func (c *Connector) Driver() driver.Driver {
+	return c.driver
+}
@@ -0,0 +1,81 @@
+package gocosmos
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type _recordingTransport struct {
+	lock     sync.Mutex
+	requests []string
+}
+
+func (rt *_recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.lock.Lock()
+	rt.requests = append(rt.requests, req.Method+" "+req.URL.Path)
+	rt.lock.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestConnector_SetTransport(t *testing.T) {
+	name := "TestConnector_SetTransport"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"mydb"}`))
+	}))
+	defer server.Close()
+
+	connector, err := NewConnectorFromConnStr("AccountEndpoint=" + server.URL + ";AccountKey=cHJpbWFyeQ==;TimeoutMs=1234")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	rt := &_recordingTransport{}
+	if connector.SetTransport(rt).httpClient.Timeout != 1234*time.Millisecond {
+		t.Fatalf("%s failed: timeout must be taken from connection string", name)
+	}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+	if _, err := db.Exec("CREATE DATABASE mydb"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if len(rt.requests) != 1 || rt.requests[0] != "POST /dbs" {
+		t.Fatalf("%s failed: requests must go through the supplied transport, recorded %#v", name, rt.requests)
+	}
+
+	if connector.SetHttpClient(nil).httpClient != nil {
+		t.Fatalf("%s failed: http client must be reset", name)
+	}
+}
+
+func TestStmtWithTimeout(t *testing.T) {
+	name := "TestStmtWithTimeout"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"mydb"}`))
+	}))
+	defer server.Close()
+
+	db, _ := sql.Open("gocosmos", "AccountEndpoint="+server.URL+";AccountKey=cHJpbWFyeQ==")
+	defer db.Close()
+	if _, err := db.Exec("CREATE DATABASE mydb WITH timeout=50ms"); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("%s failed: expected ErrTimeout but received %#v", name, err)
+	}
+
+	// errors returned by the server are kept even if the deadline is exceeded in the meantime
+	if err := _timeoutError(&CosmosError{StatusCode: 409}, time.Now()); !errors.Is(err, ErrConflict) || errors.Is(err, ErrTimeout) {
+		t.Fatalf("%s failed: expected ErrConflict but received %#v", name, err)
+	}
+	if err := _timeoutError(context.DeadlineExceeded, time.Now().Add(time.Hour)); err != context.DeadlineExceeded {
+		t.Fatalf("%s failed: error must be kept before the deadline, received %#v", name, err)
+	}
+	if _, err := db.Exec("CREATE DATABASE mydb WITH timeout=5s"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+}
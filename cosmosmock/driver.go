@@ -0,0 +1,117 @@
+/*
+Package cosmosmock provides an in-memory Azure Cosmos DB backend for unit tests.
+
+Importing the package registers driver "gocosmos-mock" with database/sql. The driver accepts the same statements as the
+gocosmos driver (see SQL.md), but data is stored in-memory by a Store instead of an Azure Cosmos DB account, so that
+repositories can be unit-tested without network, account or emulator:
+
+	import (
+		"database/sql"
+		_ "github.com/btnguyen2k/gocosmos/cosmosmock"
+	)
+
+	db, err := sql.Open("gocosmos-mock", "Store=test;DefaultDb=mydb")
+	_, err = db.Exec("CREATE DATABASE mydb")
+
+Connections opened with the same store name share the same data. Data source name is "Store=<name>" followed by
+optional gocosmos connection string parameters (e.g. DefaultDb); AccountEndpoint and AccountKey are ignored. Use
+GetStore(name).Reset() to clear a store between tests.
+
+A Store can also be plugged into a gocosmos.RestClient or gocosmos.Connector as http.RoundTripper, see NewConnector.
+
+Available since v0.1.1
+*/
+package cosmosmock
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"sync"
+
+	"github.com/btnguyen2k/gocosmos"
+)
+
+const (
+	// DriverName is the name the mock driver is registered with database/sql.
+	DriverName = "gocosmos-mock"
+
+	// fake account used to sign requests sent to stores
+	mockEndpoint   = "http://localhost"
+	mockAccountKey = "C2y6yDjf5/R+ob0N8A7Cgv30VRDJIWEHLM+4QDU5DE2nQ9nDuVTqobD4b8mGGyPMbIZnqyMsEcaGQy67XIw/Jw=="
+)
+
+func init() {
+	sql.Register(DriverName, &Driver{})
+}
+
+var (
+	storesLock sync.Mutex
+	stores     = make(map[string]*Store)
+)
+
+// GetStore returns the named store, creating it if not exists.
+func GetStore(name string) *Store {
+	storesLock.Lock()
+	defer storesLock.Unlock()
+	store, ok := stores[name]
+	if !ok {
+		store = NewStore()
+		stores[name] = store
+	}
+	return store
+}
+
+// Driver is the mock driver for database/sql, backed by named in-memory stores.
+type Driver struct {
+}
+
+// Open implements driver.Driver.Open.
+//
+// dsn is expected in the following format:
+//     Store=<store-name>[;DefaultDb=<db-name>][;<other gocosmos connection string parameters>]
+//
+// If not supplied, store name is "default".
+func (d *Driver) Open(dsn string) (driver.Conn, error) {
+	storeName := "default"
+	params := make([]string, 0)
+	for _, part := range strings.Split(dsn, ";") {
+		tokens := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		switch strings.ToUpper(tokens[0]) {
+		case "":
+		case "STORE":
+			if len(tokens) > 1 {
+				storeName = strings.TrimSpace(tokens[1])
+			}
+		default:
+			params = append(params, part)
+		}
+	}
+	connector, err := NewConnector(GetStore(storeName), strings.Join(params, ";"))
+	if err != nil {
+		return nil, err
+	}
+	return connector.Connect(context.Background())
+}
+
+// NewConnector creates a gocosmos.Connector whose connections are served by the store. connStr contains optional
+// gocosmos connection string parameters (e.g. DefaultDb); AccountEndpoint and AccountKey are supplied by the function.
+//
+// Example:
+//     connector, err := cosmosmock.NewConnector(cosmosmock.NewStore(), "DefaultDb=mydb")
+//     db := sql.OpenDB(connector)
+func NewConnector(store *Store, connStr string) (*gocosmos.Connector, error) {
+	params := []string{"AccountEndpoint=" + mockEndpoint, "AccountKey=" + mockAccountKey}
+	for _, part := range strings.Split(connStr, ";") {
+		key := strings.ToUpper(strings.TrimSpace(strings.SplitN(part, "=", 2)[0]))
+		if key != "" && key != "ACCOUNTENDPOINT" && key != "ACCOUNTKEY" {
+			params = append(params, part)
+		}
+	}
+	connector, err := gocosmos.NewConnectorFromConnStr(strings.Join(params, ";"))
+	if err != nil {
+		return nil, err
+	}
+	return connector.SetTransport(store), nil
+}
@@ -0,0 +1,130 @@
+package cosmosmock
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/btnguyen2k/gocosmos"
+)
+
+func _openDb(t *testing.T, name, storeName string) *sql.DB {
+	GetStore(storeName).Reset()
+	db, err := sql.Open(DriverName, "Store="+storeName+";DefaultDb=mydb")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	return db
+}
+
+func TestDriver_crud(t *testing.T) {
+	name := "TestDriver_crud"
+	db := _openDb(t, name, name)
+	defer db.Close()
+
+	for _, stmt := range []string{"CREATE DATABASE mydb", "CREATE COLLECTION mydb.users WITH pk=/city WITH uk=/email"} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s failed: %s / %s", name, stmt, err)
+		}
+	}
+	if _, err := db.Exec("CREATE DATABASE mydb"); !errors.Is(err, gocosmos.ErrConflict) {
+		t.Fatalf("%s failed: expected ErrConflict but received %#v", name, err)
+	}
+
+	insert := "INSERT INTO mydb.users (id, name, age, city, email) VALUES (:1, :2, :3, :4, :5)"
+	users := [][]interface{}{
+		{"1", "Alice", 30, "Hanoi", "alice@example.com"},
+		{"2", "Bob", 25, "Saigon", "bob@example.com"},
+		{"3", "Carol", 35, "Hanoi", "carol@example.com"},
+	}
+	for _, user := range users {
+		if _, err := db.Exec(insert, append(user, user[3])...); err != nil {
+			t.Fatalf("%s failed: %s", name, err)
+		}
+	}
+	if _, err := db.Exec(insert, "1", "Alice", 30, "Hanoi", "other@example.com", "Hanoi"); !errors.Is(err, gocosmos.ErrConflict) {
+		t.Fatalf("%s failed: expected ErrConflict for duplicated id but received %#v", name, err)
+	}
+	if _, err := db.Exec(insert, "4", "Dave", 40, "Hanoi", "alice@example.com", "Hanoi"); !errors.Is(err, gocosmos.ErrConflict) {
+		t.Fatalf("%s failed: expected ErrConflict for duplicated unique key but received %#v", name, err)
+	}
+
+	rows, err := db.Query("SELECT CROSS PARTITION c.id, c.name FROM c WHERE c.age>:1 ORDER BY c.age DESC WITH collection=users", 26)
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	ids := make([]string, 0)
+	for rows.Next() {
+		var id, userName string
+		if err := rows.Scan(&id, &userName); err != nil {
+			t.Fatalf("%s failed: %s", name, err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if len(ids) != 2 || ids[0] != "3" || ids[1] != "1" {
+		t.Fatalf("%s failed: unexpected query result %#v", name, ids)
+	}
+
+	result, err := db.Exec(`UPDATE mydb.users SET age=31 WHERE id=:1`, "1", "Hanoi")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if n, _ := result.RowsAffected(); n != 1 {
+		t.Fatalf("%s failed: expected 1 row affected but received %d", name, n)
+	}
+	var age float64
+	if err := db.QueryRow(`SELECT c.age FROM c WHERE c.id=:1 WITH collection=users`, "1").Scan(&age); err != nil || age != 31 {
+		t.Fatalf("%s failed: expected age 31 but received %v / %s", name, age, err)
+	}
+	if result, err = db.Exec(`UPDATE mydb.users SET age=31 WHERE id=:1`, "1", "Saigon"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	} else if n, _ := result.RowsAffected(); n != 0 {
+		t.Fatalf("%s failed: document of another partition must not be updated", name)
+	}
+
+	if result, err = db.Exec(`DELETE FROM mydb.users WHERE id=:1`, "2", "Saigon"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	} else if n, _ := result.RowsAffected(); n != 1 {
+		t.Fatalf("%s failed: expected 1 row affected but received %d", name, n)
+	}
+	var count int
+	if err := db.QueryRow("SELECT CROSS PARTITION COUNT(1) AS n FROM c WITH collection=users").Scan(&count); err != nil || count != 2 {
+		t.Fatalf("%s failed: expected 2 documents but received %d / %s", name, count, err)
+	}
+
+	if _, err := db.Exec("DROP COLLECTION mydb.users"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if _, err := db.Query("SELECT * FROM c WITH collection=users"); !errors.Is(err, gocosmos.ErrNotFound) {
+		t.Fatalf("%s failed: expected ErrNotFound but received %#v", name, err)
+	}
+}
+
+func TestDriver_sharedStore(t *testing.T) {
+	name := "TestDriver_sharedStore"
+	db1 := _openDb(t, name, name)
+	defer db1.Close()
+	if _, err := db1.Exec("CREATE DATABASE mydb"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+
+	db2, _ := sql.Open(DriverName, "Store="+name)
+	defer db2.Close()
+	if _, err := db2.Exec("CREATE DATABASE mydb"); !errors.Is(err, gocosmos.ErrConflict) {
+		t.Fatalf("%s failed: connections of the same store must share data, received %#v", name, err)
+	}
+	db3 := sql.OpenDB(_mustConnector(t, name, NewStore()))
+	defer db3.Close()
+	if _, err := db3.Exec("CREATE DATABASE mydb"); err != nil {
+		t.Fatalf("%s failed: connections of different stores must not share data, received %s", name, err)
+	}
+}
+
+func _mustConnector(t *testing.T, name string, store *Store) *gocosmos.Connector {
+	connector, err := NewConnector(store, "DefaultDb=mydb")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	return connector
+}
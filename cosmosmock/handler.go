@@ -0,0 +1,525 @@
+package cosmosmock
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// response is the result of an operation on the store.
+type response struct {
+	status  int
+	body    interface{}
+	headers map[string]string
+}
+
+func _errorResp(status int, code, message string) *response {
+	return &response{status: status, body: map[string]interface{}{"code": code, "message": message}}
+}
+
+func _notFound(resourceType string) *response {
+	return _errorResp(http.StatusNotFound, "NotFound", "Entity with the specified id does not exist in the system. ResourceType: "+resourceType)
+}
+
+func _conflict(resourceType string) *response {
+	return _errorResp(http.StatusConflict, "Conflict", "Entity with the specified id already exists in the system. ResourceType: "+resourceType)
+}
+
+func _badRequest(message string) *response {
+	return _errorResp(http.StatusBadRequest, "BadRequest", message)
+}
+
+// ServeHTTP implements http.Handler.ServeHTTP.
+func (s *Store) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body []byte
+	if r.Body != nil {
+		body, _ = ioutil.ReadAll(r.Body)
+	}
+	s.lock.Lock()
+	resp := s.handle(r, body)
+	s.lock.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Ms-Request-Charge", "1")
+	w.Header().Set("X-Ms-Session-Token", "0:"+strconv.FormatInt(s.seq, 10))
+	for k, v := range resp.headers {
+		w.Header().Set(k, v)
+	}
+	w.WriteHeader(resp.status)
+	if resp.body != nil {
+		js, _ := json.Marshal(resp.body)
+		w.Write(js)
+	}
+}
+
+func (s *Store) handle(r *http.Request, body []byte) *response {
+	var params map[string]interface{}
+	if len(body) > 0 && body[0] == '{' {
+		if err := json.Unmarshal(body, &params); err != nil {
+			return _badRequest("invalid request body: " + err.Error())
+		}
+	}
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if segments[0] == "" {
+		segments = segments[:0]
+	}
+	switch {
+	case len(segments) == 0 && r.Method == "GET":
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		location := map[string]interface{}{"name": "Mock", "databaseAccountEndpoint": scheme + "://" + r.Host + "/"}
+		return &response{status: http.StatusOK, body: map[string]interface{}{"id": "mock", "_rid": "mock",
+			"writableLocations": []interface{}{location}, "readableLocations": []interface{}{location}}}
+	case len(segments) >= 1 && segments[0] == "offers":
+		return s.handleOffers(r, segments[1:], params)
+	case len(segments) >= 1 && segments[0] == "dbs":
+		return s.handleDbs(r, segments[1:], params, body)
+	}
+	return _badRequest("unsupported resource " + r.URL.Path)
+}
+
+// _page returns a page of items according to headers x-ms-max-item-count and x-ms-continuation.
+func _page(r *http.Request, items []interface{}) ([]interface{}, map[string]string) {
+	offset, _ := strconv.Atoi(r.Header.Get("X-Ms-Continuation"))
+	if offset < 0 || offset > len(items) {
+		offset = len(items)
+	}
+	maxItems, err := strconv.Atoi(r.Header.Get("X-Ms-Max-Item-Count"))
+	if err != nil || maxItems == 0 {
+		maxItems = 100
+	}
+	headers := make(map[string]string)
+	end := len(items)
+	if maxItems > 0 && offset+maxItems < end {
+		end = offset + maxItems
+		headers["X-Ms-Continuation"] = strconv.Itoa(end)
+	}
+	return items[offset:end], headers
+}
+
+func _listResp(r *http.Request, key string, items []interface{}) *response {
+	page, headers := _page(r, items)
+	return &response{status: http.StatusOK, body: map[string]interface{}{key: page, "_count": len(page)}, headers: headers}
+}
+
+func _sortedIds(m interface{}) []string {
+	ids := make([]string, 0)
+	switch m := m.(type) {
+	case map[string]*database:
+		for id := range m {
+			ids = append(ids, id)
+		}
+	case map[string]*collection:
+		for id := range m {
+			ids = append(ids, id)
+		}
+	case map[string]map[string]interface{}:
+		for id := range m {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+/*----------------------------------------------------------------------*/
+
+// createOffer creates the offer of a database/collection if throughput is specified in the request.
+func (s *Store) createOffer(r *http.Request, res map[string]interface{}) *response {
+	content := map[string]interface{}{}
+	if v := r.Header.Get("X-Ms-Offer-Throughput"); v != "" {
+		ru, err := strconv.Atoi(v)
+		if err != nil {
+			return _badRequest("invalid x-ms-offer-throughput")
+		}
+		content["offerThroughput"] = ru
+	} else if v := r.Header.Get("X-Ms-Cosmos-Offer-Autopilot-Settings"); v != "" {
+		var settings map[string]interface{}
+		if err := json.Unmarshal([]byte(v), &settings); err != nil {
+			return _badRequest("invalid x-ms-cosmos-offer-autopilot-settings")
+		}
+		content["offerAutopilotSettings"] = settings
+	} else {
+		return nil
+	}
+	offer := map[string]interface{}{"offerVersion": "V2", "offerType": "Invalid", "content": content,
+		"resource": res["_self"], "offerResourceId": res["_rid"]}
+	s.sysProps(offer, "")
+	offer["id"] = offer["_rid"]
+	offer["_self"] = "offers/" + offer["_rid"].(string) + "/"
+	s.offers[offer["_rid"].(string)] = offer
+	return nil
+}
+
+// deleteOffer deletes the offer of a database/collection.
+func (s *Store) deleteOffer(rid interface{}) {
+	for id, offer := range s.offers {
+		if offer["offerResourceId"] == rid {
+			delete(s.offers, id)
+		}
+	}
+}
+
+func (s *Store) handleOffers(r *http.Request, segments []string, params map[string]interface{}) *response {
+	switch {
+	case len(segments) == 0 && r.Method == "POST":
+		offers := make([]map[string]interface{}, 0, len(s.offers))
+		for _, id := range _sortedIds(s.offers) {
+			offers = append(offers, s.offers[id])
+		}
+		return s.query(r, params, offers, "Offers")
+	case len(segments) == 1 && r.Method == "GET":
+		if offer, ok := s.offers[segments[0]]; ok {
+			return &response{status: http.StatusOK, body: offer}
+		}
+		return _notFound("Offer")
+	case len(segments) == 1 && r.Method == "PUT":
+		offer, ok := s.offers[segments[0]]
+		if !ok {
+			return _notFound("Offer")
+		}
+		content, _ := params["content"].(map[string]interface{})
+		switch {
+		case r.Header.Get("X-Ms-Cosmos-Migrate-Offer-To-Autopilot") == "true":
+			content = map[string]interface{}{"offerAutopilotSettings": map[string]interface{}{"maxThroughput": 4000}}
+		case r.Header.Get("X-Ms-Cosmos-Migrate-Offer-To-Manual-Throughput") == "true":
+			content = map[string]interface{}{"offerThroughput": 400}
+		}
+		if content == nil {
+			return _badRequest("offer content is missing")
+		}
+		offer["content"] = content
+		s.sysProps(offer, offer["_self"].(string))
+		return &response{status: http.StatusOK, body: offer}
+	}
+	return _badRequest("unsupported offer operation")
+}
+
+/*----------------------------------------------------------------------*/
+
+func (s *Store) handleDbs(r *http.Request, segments []string, params map[string]interface{}, body []byte) *response {
+	switch {
+	case len(segments) == 0 && r.Method == "POST":
+		id, _ := params["id"].(string)
+		if id == "" {
+			return _badRequest("database id is missing")
+		}
+		if _, ok := s.dbs[id]; ok {
+			return _conflict("Database")
+		}
+		info := map[string]interface{}{"id": id}
+		s.sysProps(info, "dbs/"+id+"/")
+		info["_colls"], info["_users"] = "colls/", "users/"
+		if resp := s.createOffer(r, info); resp != nil {
+			return resp
+		}
+		s.dbs[id] = &database{info: info, colls: make(map[string]*collection)}
+		return &response{status: http.StatusCreated, body: info}
+	case len(segments) == 0 && r.Method == "GET":
+		dbs := make([]interface{}, 0, len(s.dbs))
+		for _, id := range _sortedIds(s.dbs) {
+			dbs = append(dbs, s.dbs[id].info)
+		}
+		return _listResp(r, "Databases", dbs)
+	}
+	db, ok := s.dbs[segments[0]]
+	if !ok {
+		return _notFound("Database")
+	}
+	switch {
+	case len(segments) == 1 && r.Method == "GET":
+		return &response{status: http.StatusOK, body: db.info}
+	case len(segments) == 1 && r.Method == "DELETE":
+		for _, coll := range db.colls {
+			s.deleteOffer(coll.info["_rid"])
+		}
+		s.deleteOffer(db.info["_rid"])
+		delete(s.dbs, segments[0])
+		return &response{status: http.StatusNoContent}
+	case len(segments) >= 2 && segments[1] == "colls":
+		return s.handleColls(r, db, segments[2:], params, body)
+	}
+	return _badRequest("unsupported database operation")
+}
+
+func (s *Store) handleColls(r *http.Request, db *database, segments []string, params map[string]interface{}, body []byte) *response {
+	switch {
+	case len(segments) == 0 && r.Method == "POST":
+		id, _ := params["id"].(string)
+		if id == "" {
+			return _badRequest("collection id is missing")
+		}
+		if _, ok := db.colls[id]; ok {
+			return _conflict("Collection")
+		}
+		info := params
+		if pk, _ := info["partitionKey"].(map[string]interface{}); pk == nil {
+			// non-partitioned collection
+			info["partitionKey"] = map[string]interface{}{"paths": []interface{}{"/_partitionKey"}, "kind": "Hash", "systemKey": true}
+		}
+		if info["indexingPolicy"] == nil {
+			info["indexingPolicy"] = map[string]interface{}{"indexingMode": "consistent", "automatic": true,
+				"includedPaths": []interface{}{map[string]interface{}{"path": "/*"}}, "excludedPaths": []interface{}{}}
+		}
+		s.sysProps(info, db.info["_self"].(string)+"colls/"+id+"/")
+		info["_docs"], info["_sprocs"], info["_triggers"], info["_udfs"], info["_conflicts"] = "docs/", "sprocs/", "triggers/", "udfs/", "conflicts/"
+		if resp := s.createOffer(r, info); resp != nil {
+			return resp
+		}
+		db.colls[id] = &collection{info: info, pkPaths: _parsePkPaths(info["partitionKey"].(map[string]interface{})),
+			docs: make(map[string]map[string]interface{}), scripts: make(map[string]map[string]map[string]interface{})}
+		return &response{status: http.StatusCreated, body: info}
+	case len(segments) == 0 && r.Method == "GET":
+		colls := make([]interface{}, 0, len(db.colls))
+		for _, id := range _sortedIds(db.colls) {
+			colls = append(colls, db.colls[id].info)
+		}
+		return _listResp(r, "DocumentCollections", colls)
+	}
+	coll, ok := db.colls[segments[0]]
+	if !ok {
+		return _notFound("Collection")
+	}
+	switch {
+	case len(segments) == 1 && r.Method == "GET":
+		return &response{status: http.StatusOK, body: coll.info}
+	case len(segments) == 1 && r.Method == "PUT":
+		for _, key := range []string{"indexingPolicy", "geospatialConfig", "defaultTtl"} {
+			if v, ok := params[key]; ok {
+				coll.info[key] = v
+			}
+		}
+		s.sysProps(coll.info, coll.info["_self"].(string))
+		return &response{status: http.StatusOK, body: coll.info}
+	case len(segments) == 1 && r.Method == "DELETE":
+		s.deleteOffer(coll.info["_rid"])
+		delete(db.colls, segments[0])
+		return &response{status: http.StatusNoContent}
+	case len(segments) == 2 && segments[1] == "pkranges" && r.Method == "GET":
+		pkrange := map[string]interface{}{"id": "0", "minInclusive": "", "maxExclusive": "FF", "parents": []interface{}{}}
+		return &response{status: http.StatusOK, body: map[string]interface{}{"PartitionKeyRanges": []interface{}{pkrange}, "_count": 1}}
+	case len(segments) >= 2 && segments[1] == "docs":
+		return s.handleDocs(r, coll, segments[2:], params, body)
+	case len(segments) >= 2 && (segments[1] == "sprocs" || segments[1] == "udfs" || segments[1] == "triggers"):
+		return s.handleScripts(r, coll, segments[1], segments[2:], params)
+	}
+	return _badRequest("unsupported collection operation")
+}
+
+var scriptListKeys = map[string]string{"sprocs": "StoredProcedures", "udfs": "UserDefinedFunctions", "triggers": "Triggers"}
+var scriptResourceTypes = map[string]string{"sprocs": "StoredProcedure", "udfs": "UserDefinedFunction", "triggers": "Trigger"}
+
+func (s *Store) handleScripts(r *http.Request, coll *collection, kind string, segments []string, params map[string]interface{}) *response {
+	scripts := coll.scripts[kind]
+	if scripts == nil {
+		scripts = make(map[string]map[string]interface{})
+		coll.scripts[kind] = scripts
+	}
+	switch {
+	case len(segments) == 0 && r.Method == "POST":
+		id, _ := params["id"].(string)
+		if _, ok := scripts[id]; ok {
+			return _conflict(scriptResourceTypes[kind])
+		}
+		s.sysProps(params, coll.info["_self"].(string)+kind+"/"+id+"/")
+		scripts[id] = params
+		return &response{status: http.StatusCreated, body: params}
+	case len(segments) == 0 && r.Method == "GET":
+		list := make([]interface{}, 0, len(scripts))
+		for _, id := range _sortedIds(scripts) {
+			list = append(list, scripts[id])
+		}
+		return _listResp(r, scriptListKeys[kind], list)
+	}
+	script, ok := scripts[segments[0]]
+	if !ok {
+		return _notFound(scriptResourceTypes[kind])
+	}
+	switch {
+	case len(segments) == 1 && r.Method == "GET":
+		return &response{status: http.StatusOK, body: script}
+	case len(segments) == 1 && r.Method == "PUT":
+		params["_rid"] = script["_rid"]
+		s.sysProps(params, script["_self"].(string))
+		scripts[segments[0]] = params
+		return &response{status: http.StatusOK, body: params}
+	case len(segments) == 1 && r.Method == "DELETE":
+		delete(scripts, segments[0])
+		return &response{status: http.StatusNoContent}
+	case len(segments) == 1 && r.Method == "POST" && kind == "sprocs":
+		return _badRequest("executing stored procedures is not supported by cosmosmock")
+	}
+	return _badRequest("unsupported script operation")
+}
+
+/*----------------------------------------------------------------------*/
+
+// partitionKey determines the partition key of the request from header x-ms-documentdb-partitionkey.
+func (c *collection) partitionKey(r *http.Request) (string, *response) {
+	pkKey, ok := _normalizePkHeader(r.Header.Get("X-Ms-Documentdb-PartitionKey"))
+	if !ok {
+		return "", _badRequest("invalid partition key header")
+	}
+	if pkKey == "" {
+		return "", _badRequest("partition key value must be supplied for this operation")
+	}
+	return pkKey, nil
+}
+
+func (s *Store) handleDocs(r *http.Request, coll *collection, segments []string, params map[string]interface{}, body []byte) *response {
+	switch {
+	case len(segments) == 0 && r.Method == "POST" && r.Header.Get("X-Ms-Documentdb-Isquery") == "true":
+		docs := coll.sortedDocs()
+		if pkKey, ok := _normalizePkHeader(r.Header.Get("X-Ms-Documentdb-PartitionKey")); ok && pkKey != "" {
+			filtered := make([]map[string]interface{}, 0)
+			for _, doc := range docs {
+				if coll.pkKey(doc) == pkKey {
+					filtered = append(filtered, doc)
+				}
+			}
+			docs = filtered
+		}
+		return s.query(r, params, docs, "Documents")
+	case len(segments) == 0 && r.Method == "POST":
+		return s.writeDoc(r, coll, params, "", r.Header.Get("X-Ms-Documentdb-Is-Upsert") == "true")
+	case len(segments) == 0 && r.Method == "GET" && r.Header.Get("A-Im") != "":
+		return s.changeFeed(r, coll)
+	case len(segments) == 0 && r.Method == "GET":
+		docs := make([]interface{}, 0, len(coll.docs))
+		for _, doc := range coll.sortedDocs() {
+			docs = append(docs, doc)
+		}
+		resp := _listResp(r, "Documents", docs)
+		resp.headers["Etag"] = `"` + strconv.FormatInt(s.seq, 10) + `"`
+		return resp
+	case len(segments) != 1:
+		return _badRequest("unsupported document operation")
+	}
+	pkKey, errResp := coll.partitionKey(r)
+	if errResp != nil {
+		return errResp
+	}
+	key := _docKey(pkKey, segments[0])
+	doc, ok := coll.docs[key]
+	if !ok {
+		return _notFound("Document")
+	}
+	if etag := r.Header.Get("If-Match"); etag != "" && etag != doc["_etag"] && r.Method != "GET" {
+		return _errorResp(http.StatusPreconditionFailed, "PreconditionFailed", "Operation cannot be performed because one of the specified precondition is not met.")
+	}
+	switch r.Method {
+	case "GET":
+		if etag := r.Header.Get("If-None-Match"); etag != "" && etag == doc["_etag"] {
+			return &response{status: http.StatusNotModified}
+		}
+		return &response{status: http.StatusOK, body: doc, headers: map[string]string{"Etag": doc["_etag"].(string)}}
+	case "PUT":
+		if params["id"] != segments[0] {
+			return _badRequest("id of the document does not match the request")
+		}
+		return s.writeDoc(r, coll, params, key, true)
+	case "DELETE":
+		delete(coll.docs, key)
+		return &response{status: http.StatusNoContent}
+	}
+	return _badRequest("unsupported document operation")
+}
+
+// writeDoc creates, upserts or replaces (if replaceKey is not empty) a document.
+func (s *Store) writeDoc(r *http.Request, coll *collection, doc map[string]interface{}, replaceKey string, upsert bool) *response {
+	id, ok := doc["id"].(string)
+	if !ok || id == "" {
+		return _badRequest("The input content is invalid because the required properties - 'id; ' - are missing")
+	}
+	pkKey := coll.pkKey(doc)
+	if header, ok := _normalizePkHeader(r.Header.Get("X-Ms-Documentdb-PartitionKey")); !ok || (header != "" && header != pkKey) {
+		return _badRequest("PartitionKey extracted from document doesn't match the one specified in the header")
+	}
+	key := _docKey(pkKey, id)
+	if replaceKey != "" && key != replaceKey {
+		return _badRequest("PartitionKey of the document cannot be changed")
+	}
+	existing, exists := coll.docs[key]
+	if exists && !upsert {
+		return _conflict("Document")
+	}
+	if coll.uniqueKeyConflict(pkKey, doc) {
+		return _errorResp(http.StatusConflict, "Conflict", "Unique index constraint violation.")
+	}
+	doc = _clone(doc)
+	for k := range doc {
+		if strings.HasPrefix(k, "_") && k != "_partitionKey" {
+			delete(doc, k)
+		}
+	}
+	if exists {
+		doc["_rid"] = existing["_rid"]
+	}
+	s.sysProps(doc, coll.info["_self"].(string)+"docs/"+id+"/")
+	doc["_attachments"] = "attachments/"
+	doc["_lsn"] = float64(s.seq)
+	coll.docs[key] = doc
+	status := http.StatusCreated
+	if exists {
+		status = http.StatusOK
+	}
+	return &response{status: status, body: doc, headers: map[string]string{"Etag": doc["_etag"].(string)}}
+}
+
+// changeFeed returns documents modified after the LSN specified by header If-None-Match.
+func (s *Store) changeFeed(r *http.Request, coll *collection) *response {
+	etag := strings.Trim(r.Header.Get("If-None-Match"), `"`)
+	current := `"` + strconv.FormatInt(s.seq, 10) + `"`
+	if etag == "*" {
+		return &response{status: http.StatusNotModified, headers: map[string]string{"Etag": current}}
+	}
+	lsn, _ := strconv.ParseFloat(etag, 64)
+	pkKey, _ := _normalizePkHeader(r.Header.Get("X-Ms-Documentdb-PartitionKey"))
+	maxItems, err := strconv.Atoi(r.Header.Get("X-Ms-Max-Item-Count"))
+	if err != nil || maxItems <= 0 {
+		maxItems = 100
+	}
+	docs := make([]interface{}, 0)
+	for _, doc := range coll.sortedDocs() {
+		if doc["_lsn"].(float64) <= lsn || (pkKey != "" && coll.pkKey(doc) != pkKey) {
+			continue
+		}
+		if len(docs) >= maxItems {
+			break
+		}
+		docs = append(docs, doc)
+		lsn = doc["_lsn"].(float64)
+	}
+	if len(docs) == 0 {
+		return &response{status: http.StatusNotModified, headers: map[string]string{"Etag": r.Header.Get("If-None-Match")}}
+	}
+	return &response{status: http.StatusOK, body: map[string]interface{}{"Documents": docs, "_count": len(docs)},
+		headers: map[string]string{"Etag": `"` + strconv.FormatFloat(lsn, 'f', -1, 64) + `"`}}
+}
+
+// query executes a query over resources.
+func (s *Store) query(r *http.Request, params map[string]interface{}, resources []map[string]interface{}, key string) *response {
+	queryStr, _ := params["query"].(string)
+	q, err := _parseQuery(queryStr)
+	if err != nil {
+		return _badRequest(err.Error())
+	}
+	queryParams := make(map[string]interface{})
+	list, _ := params["parameters"].([]interface{})
+	for _, p := range list {
+		if m, ok := p.(map[string]interface{}); ok {
+			name, _ := m["name"].(string)
+			queryParams[name] = m["value"]
+		}
+	}
+	results, err := q.execute(resources, queryParams)
+	if err != nil {
+		return _badRequest(err.Error())
+	}
+	return _listResp(r, key, results)
+}
@@ -0,0 +1,1419 @@
+package cosmosmock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// undefinedType represents the "undefined" value of Cosmos DB SQL, e.g. the value of a missing property.
+type undefinedType struct{}
+
+var undefined = undefinedType{}
+
+/*----------------------------------------------------------------------*/
+
+const (
+	tokEOF = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokParam
+	tokSymbol
+)
+
+type token struct {
+	kind int
+	text string // identifier, parameter name (including "@"), symbol or unquoted string
+	num  float64
+	pos  int
+}
+
+// _tokenize splits a query into tokens.
+func _tokenize(query string) ([]token, error) {
+	tokens := make([]token, 0)
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: string(runes[start:i]), pos: start})
+		case r == '@':
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokParam, text: string(runes[start:i]), pos: start})
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.' || runes[i] == 'e' || runes[i] == 'E' ||
+				((runes[i] == '+' || runes[i] == '-') && (runes[i-1] == 'e' || runes[i-1] == 'E'))) {
+				i++
+			}
+			num, err := strconv.ParseFloat(string(runes[start:i]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number at position %d", start)
+			}
+			tokens = append(tokens, token{kind: tokNumber, num: num, pos: start})
+		case r == '"' || r == '\'':
+			start := i
+			sb := strings.Builder{}
+			for i++; i < len(runes) && runes[i] != r; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+					switch runes[i] {
+					case 'n':
+						sb.WriteRune('\n')
+					case 't':
+						sb.WriteRune('\t')
+					case 'r':
+						sb.WriteRune('\r')
+					default:
+						sb.WriteRune(runes[i])
+					}
+					continue
+				}
+				sb.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", start)
+			}
+			i++
+			tokens = append(tokens, token{kind: tokString, text: sb.String(), pos: start})
+		default:
+			start := i
+			symbol := string(r)
+			if i+1 < len(runes) {
+				switch two := string(runes[i : i+2]); two {
+				case "!=", "<>", "<=", ">=", "||", "??":
+					symbol = two
+				}
+			}
+			if !strings.Contains("()[]{},.*+-/%=<>!|?:", symbol[:1]) {
+				return nil, fmt.Errorf("unexpected character '%c' at position %d", r, start)
+			}
+			i += len([]rune(symbol))
+			tokens = append(tokens, token{kind: tokSymbol, text: symbol, pos: start})
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(runes)}), nil
+}
+
+/*----------------------------------------------------------------------*/
+
+// evalEnv is the environment to evaluate expressions of a query.
+type evalEnv struct {
+	alias  string
+	doc    interface{}
+	params map[string]interface{}
+}
+
+type expr interface {
+	eval(env *evalEnv) (interface{}, error)
+}
+
+type literalExpr struct{ value interface{} }
+
+func (e *literalExpr) eval(_ *evalEnv) (interface{}, error) { return e.value, nil }
+
+type paramExpr struct{ name string }
+
+func (e *paramExpr) eval(env *evalEnv) (interface{}, error) {
+	v, ok := env.params[e.name]
+	if !ok {
+		return nil, errors.New("parameter " + e.name + " is not supplied")
+	}
+	return v, nil
+}
+
+type identExpr struct{ name string }
+
+func (e *identExpr) eval(env *evalEnv) (interface{}, error) {
+	if e.name != env.alias {
+		return nil, errors.New("identifier " + e.name + " could not be resolved")
+	}
+	return env.doc, nil
+}
+
+type memberExpr struct {
+	obj  expr
+	name expr
+}
+
+func (e *memberExpr) eval(env *evalEnv) (interface{}, error) {
+	obj, err := e.obj.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	name, err := e.name.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	switch obj := obj.(type) {
+	case map[string]interface{}:
+		if key, ok := name.(string); ok {
+			if v, ok := obj[key]; ok {
+				return v, nil
+			}
+		}
+	case []interface{}:
+		if index, ok := name.(float64); ok && index >= 0 && int(index) < len(obj) && index == math.Trunc(index) {
+			return obj[int(index)], nil
+		}
+	}
+	return undefined, nil
+}
+
+type arrayExpr struct{ elems []expr }
+
+func (e *arrayExpr) eval(env *evalEnv) (interface{}, error) {
+	result := make([]interface{}, 0, len(e.elems))
+	for _, elem := range e.elems {
+		v, err := elem.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		if v != undefined {
+			result = append(result, v)
+		}
+	}
+	return result, nil
+}
+
+type objectExpr struct {
+	keys  []string
+	props []expr
+}
+
+func (e *objectExpr) eval(env *evalEnv) (interface{}, error) {
+	result := make(map[string]interface{}, len(e.keys))
+	for i, key := range e.keys {
+		v, err := e.props[i].eval(env)
+		if err != nil {
+			return nil, err
+		}
+		if v != undefined {
+			result[key] = v
+		}
+	}
+	return result, nil
+}
+
+type unaryExpr struct {
+	op      string
+	operand expr
+}
+
+func (e *unaryExpr) eval(env *evalEnv) (interface{}, error) {
+	v, err := e.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	switch e.op {
+	case "NOT":
+		if b, ok := v.(bool); ok {
+			return !b, nil
+		}
+	case "-":
+		if n, ok := v.(float64); ok {
+			return -n, nil
+		}
+	}
+	return undefined, nil
+}
+
+type binaryExpr struct {
+	op          string
+	left, right expr
+}
+
+func (e *binaryExpr) eval(env *evalEnv) (interface{}, error) {
+	l, err := e.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	switch e.op {
+	case "AND":
+		if l == false {
+			return false, nil
+		}
+	case "OR":
+		if l == true {
+			return true, nil
+		}
+	case "??":
+		if l != undefined {
+			return l, nil
+		}
+	}
+	r, err := e.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	switch e.op {
+	case "AND":
+		if r == false {
+			return false, nil
+		}
+		if l == true && r == true {
+			return true, nil
+		}
+		return undefined, nil
+	case "OR":
+		if r == true {
+			return true, nil
+		}
+		if l == false && r == false {
+			return false, nil
+		}
+		return undefined, nil
+	case "??":
+		return r, nil
+	case "=", "!=", "<>", "<", "<=", ">", ">=":
+		return _compareOp(e.op, l, r), nil
+	case "||":
+		ls, lok := l.(string)
+		rs, rok := r.(string)
+		if lok && rok {
+			return ls + rs, nil
+		}
+		return undefined, nil
+	}
+	ln, lok := l.(float64)
+	rn, rok := r.(float64)
+	if !lok || !rok {
+		return undefined, nil
+	}
+	switch e.op {
+	case "+":
+		return ln + rn, nil
+	case "-":
+		return ln - rn, nil
+	case "*":
+		return ln * rn, nil
+	case "/":
+		return ln / rn, nil
+	case "%":
+		return math.Mod(ln, rn), nil
+	}
+	return undefined, nil
+}
+
+type inExpr struct {
+	operand expr
+	list    []expr
+	not     bool
+}
+
+func (e *inExpr) eval(env *evalEnv) (interface{}, error) {
+	v, err := e.operand.eval(env)
+	if err != nil || v == undefined {
+		return undefined, err
+	}
+	for _, item := range e.list {
+		iv, err := item.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		if _compareOp("=", v, iv) == true {
+			return !e.not, nil
+		}
+	}
+	return e.not, nil
+}
+
+type betweenExpr struct {
+	operand, low, high expr
+	not                bool
+}
+
+func (e *betweenExpr) eval(env *evalEnv) (interface{}, error) {
+	values := make([]interface{}, 3)
+	for i, x := range []expr{e.operand, e.low, e.high} {
+		v, err := x.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	ge, le := _compareOp(">=", values[0], values[1]), _compareOp("<=", values[0], values[2])
+	if ge == undefined || le == undefined {
+		return undefined, nil
+	}
+	return (ge == true && le == true) != e.not, nil
+}
+
+type likeExpr struct {
+	operand, pattern expr
+	not              bool
+}
+
+func (e *likeExpr) eval(env *evalEnv) (interface{}, error) {
+	v, err := e.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	p, err := e.pattern.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	s, sok := v.(string)
+	pattern, pok := p.(string)
+	if !sok || !pok {
+		return undefined, nil
+	}
+	re := "(?s)^"
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			re += ".*"
+		case '_':
+			re += "."
+		default:
+			re += regexp.QuoteMeta(string(r))
+		}
+	}
+	matched, err := regexp.MatchString(re+"$", s)
+	return matched != e.not, err
+}
+
+type conditionalExpr struct {
+	cond, then, otherwise expr
+}
+
+func (e *conditionalExpr) eval(env *evalEnv) (interface{}, error) {
+	c, err := e.cond.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if c == true {
+		return e.then.eval(env)
+	}
+	return e.otherwise.eval(env)
+}
+
+type funcExpr struct {
+	name string // upper-cased function name
+	args []expr
+}
+
+// aggregate functions are evaluated over all documents matching the query, see query.execute.
+var aggregateFuncs = map[string]bool{"COUNT": true, "SUM": true, "AVG": true, "MIN": true, "MAX": true}
+
+func (e *funcExpr) eval(env *evalEnv) (interface{}, error) {
+	if aggregateFuncs[e.name] {
+		return nil, errors.New("aggregate function " + e.name + " is only supported as a projection")
+	}
+	args := make([]interface{}, len(e.args))
+	for i, arg := range e.args {
+		v, err := arg.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	fn, ok := scalarFuncs[e.name]
+	if !ok {
+		return nil, errors.New("unsupported function " + e.name)
+	}
+	if len(args) < fn.minArgs || len(args) > fn.maxArgs {
+		return nil, fmt.Errorf("invalid number of arguments for function %s", e.name)
+	}
+	return fn.fn(args), nil
+}
+
+/*----------------------------------------------------------------------*/
+
+type scalarFunc struct {
+	minArgs, maxArgs int
+	fn               func(args []interface{}) interface{}
+}
+
+func _typeCheck(check func(v interface{}) bool) scalarFunc {
+	return scalarFunc{1, 1, func(args []interface{}) interface{} { return check(args[0]) }}
+}
+
+func _stringFunc(minArgs, maxArgs int, fn func(s string, args []interface{}) interface{}) scalarFunc {
+	return scalarFunc{minArgs, maxArgs, func(args []interface{}) interface{} {
+		s, ok := args[0].(string)
+		if !ok {
+			return undefined
+		}
+		return fn(s, args[1:])
+	}}
+}
+
+func _stringPredicate(pred func(s, sub string) bool) scalarFunc {
+	return _stringFunc(2, 3, func(s string, args []interface{}) interface{} {
+		sub, ok := args[0].(string)
+		if !ok {
+			return undefined
+		}
+		if len(args) > 1 && args[1] == true {
+			s, sub = strings.ToLower(s), strings.ToLower(sub)
+		}
+		return pred(s, sub)
+	})
+}
+
+func _mathFunc(fn func(float64) float64) scalarFunc {
+	return scalarFunc{1, 1, func(args []interface{}) interface{} {
+		if n, ok := args[0].(float64); ok {
+			return fn(n)
+		}
+		return undefined
+	}}
+}
+
+var scalarFuncs map[string]scalarFunc
+
+func init() {
+	scalarFuncs = map[string]scalarFunc{
+		"IS_DEFINED": _typeCheck(func(v interface{}) bool { return v != undefined }),
+		"IS_NULL":    _typeCheck(func(v interface{}) bool { return v == nil }),
+		"IS_BOOL":    _typeCheck(func(v interface{}) bool { _, ok := v.(bool); return ok }),
+		"IS_NUMBER":  _typeCheck(func(v interface{}) bool { _, ok := v.(float64); return ok }),
+		"IS_STRING":  _typeCheck(func(v interface{}) bool { _, ok := v.(string); return ok }),
+		"IS_ARRAY":   _typeCheck(func(v interface{}) bool { _, ok := v.([]interface{}); return ok }),
+		"IS_OBJECT":  _typeCheck(func(v interface{}) bool { _, ok := v.(map[string]interface{}); return ok }),
+		"IS_PRIMITIVE": _typeCheck(func(v interface{}) bool {
+			switch v.(type) {
+			case nil, bool, float64, string:
+				return true
+			}
+			return false
+		}),
+		"CONTAINS":   _stringPredicate(strings.Contains),
+		"STARTSWITH": _stringPredicate(strings.HasPrefix),
+		"ENDSWITH":   _stringPredicate(strings.HasSuffix),
+		"LOWER":      _stringFunc(1, 1, func(s string, _ []interface{}) interface{} { return strings.ToLower(s) }),
+		"UPPER":      _stringFunc(1, 1, func(s string, _ []interface{}) interface{} { return strings.ToUpper(s) }),
+		"TRIM":       _stringFunc(1, 1, func(s string, _ []interface{}) interface{} { return strings.TrimSpace(s) }),
+		"LTRIM":      _stringFunc(1, 1, func(s string, _ []interface{}) interface{} { return strings.TrimLeftFunc(s, unicode.IsSpace) }),
+		"RTRIM":      _stringFunc(1, 1, func(s string, _ []interface{}) interface{} { return strings.TrimRightFunc(s, unicode.IsSpace) }),
+		"LENGTH":     _stringFunc(1, 1, func(s string, _ []interface{}) interface{} { return float64(len([]rune(s))) }),
+		"INDEX_OF": _stringFunc(2, 2, func(s string, args []interface{}) interface{} {
+			sub, ok := args[0].(string)
+			if !ok {
+				return undefined
+			}
+			index := strings.Index(s, sub)
+			if index < 0 {
+				return float64(-1)
+			}
+			return float64(len([]rune(s[:index])))
+		}),
+		"SUBSTRING": _stringFunc(3, 3, func(s string, args []interface{}) interface{} {
+			start, sok := args[0].(float64)
+			length, lok := args[1].(float64)
+			if !sok || !lok {
+				return undefined
+			}
+			runes := []rune(s)
+			from := int(math.Max(0, math.Min(start, float64(len(runes)))))
+			to := int(math.Max(float64(from), math.Min(start+length, float64(len(runes)))))
+			return string(runes[from:to])
+		}),
+		"CONCAT": {2, math.MaxInt32, func(args []interface{}) interface{} {
+			result := ""
+			for _, arg := range args {
+				s, ok := arg.(string)
+				if !ok {
+					return undefined
+				}
+				result += s
+			}
+			return result
+		}},
+		"TOSTRING": {1, 1, func(args []interface{}) interface{} {
+			switch v := args[0].(type) {
+			case undefinedType:
+				return undefined
+			case string:
+				return v
+			}
+			js, _ := json.Marshal(args[0])
+			return string(js)
+		}},
+		"ARRAY_LENGTH": {1, 1, func(args []interface{}) interface{} {
+			if arr, ok := args[0].([]interface{}); ok {
+				return float64(len(arr))
+			}
+			return undefined
+		}},
+		"ARRAY_CONTAINS": {2, 3, func(args []interface{}) interface{} {
+			arr, ok := args[0].([]interface{})
+			if !ok {
+				return undefined
+			}
+			partial := len(args) > 2 && args[2] == true
+			for _, item := range arr {
+				if _compareOp("=", item, args[1]) == true || (partial && _partialMatch(item, args[1])) {
+					return true
+				}
+			}
+			return false
+		}},
+		"ABS":     _mathFunc(math.Abs),
+		"FLOOR":   _mathFunc(math.Floor),
+		"CEILING": _mathFunc(math.Ceil),
+		"ROUND":   _mathFunc(math.Round),
+		"TRUNC":   _mathFunc(math.Trunc),
+		"SQRT":    _mathFunc(math.Sqrt),
+		"IIF": {3, 3, func(args []interface{}) interface{} {
+			if args[0] == true {
+				return args[1]
+			}
+			return args[2]
+		}},
+	}
+}
+
+// _partialMatch checks if object item contains all properties of object partial.
+func _partialMatch(item, partial interface{}) bool {
+	im, iok := item.(map[string]interface{})
+	pm, pok := partial.(map[string]interface{})
+	if !iok || !pok {
+		return false
+	}
+	for k, v := range pm {
+		if _compareOp("=", im[k], v) != true {
+			return false
+		}
+	}
+	return true
+}
+
+// _typeOrder returns the order of a value's type used to sort values of different types:
+// undefined < null < boolean < number < string < array < object.
+func _typeOrder(v interface{}) int {
+	switch v.(type) {
+	case undefinedType:
+		return 0
+	case nil:
+		return 1
+	case bool:
+		return 2
+	case float64:
+		return 3
+	case string:
+		return 4
+	case []interface{}:
+		return 5
+	}
+	return 6
+}
+
+// _compareValues compares two values for sorting, returns -1, 0 or 1.
+func _compareValues(a, b interface{}) int {
+	ta, tb := _typeOrder(a), _typeOrder(b)
+	if ta != tb {
+		if ta < tb {
+			return -1
+		}
+		return 1
+	}
+	switch a := a.(type) {
+	case bool:
+		if a == b.(bool) {
+			return 0
+		}
+		if !a {
+			return -1
+		}
+		return 1
+	case float64:
+		if a < b.(float64) {
+			return -1
+		} else if a > b.(float64) {
+			return 1
+		}
+		return 0
+	case string:
+		return strings.Compare(a, b.(string))
+	case []interface{}, map[string]interface{}:
+		ja, _ := json.Marshal(a)
+		jb, _ := json.Marshal(b)
+		return strings.Compare(string(ja), string(jb))
+	}
+	return 0
+}
+
+// _compareOp evaluates comparison operators. Values of different types are not comparable (result is undefined).
+func _compareOp(op string, a, b interface{}) interface{} {
+	if a == undefined || b == undefined || _typeOrder(a) != _typeOrder(b) {
+		return undefined
+	}
+	switch op {
+	case "=":
+		return reflect.DeepEqual(a, b)
+	case "!=", "<>":
+		return !reflect.DeepEqual(a, b)
+	}
+	switch a.(type) {
+	case []interface{}, map[string]interface{}:
+		return undefined
+	}
+	c := _compareValues(a, b)
+	switch op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	}
+	return c >= 0
+}
+
+/*----------------------------------------------------------------------*/
+
+type projection struct {
+	expr  expr
+	alias string
+}
+
+type orderBy struct {
+	expr expr
+	desc bool
+}
+
+// query is a parsed SELECT query.
+type query struct {
+	distinct    bool
+	top         expr
+	value       expr // SELECT VALUE <expr>
+	star        bool // SELECT *
+	projections []projection
+	alias       string
+	where       expr
+	orderBy     []orderBy
+	offset      expr
+	limit       expr
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// isKeyword checks if the current token is the keyword.
+func (p *parser) isKeyword(keyword string) bool {
+	t := p.peek()
+	return t.kind == tokIdent && strings.EqualFold(t.text, keyword)
+}
+
+func (p *parser) acceptKeyword(keyword string) bool {
+	if p.isKeyword(keyword) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) isSymbol(symbol string) bool {
+	t := p.peek()
+	return t.kind == tokSymbol && t.text == symbol
+}
+
+func (p *parser) acceptSymbol(symbol string) bool {
+	if p.isSymbol(symbol) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("syntax error at position %d: %s", p.peek().pos, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) expectKeyword(keyword string) error {
+	if !p.acceptKeyword(keyword) {
+		return p.errorf("expected %s", keyword)
+	}
+	return nil
+}
+
+func (p *parser) expectSymbol(symbol string) error {
+	if !p.acceptSymbol(symbol) {
+		return p.errorf("expected '%s'", symbol)
+	}
+	return nil
+}
+
+var reservedWords = map[string]bool{"SELECT": true, "FROM": true, "WHERE": true, "ORDER": true, "BY": true, "OFFSET": true,
+	"LIMIT": true, "AND": true, "OR": true, "NOT": true, "IN": true, "BETWEEN": true, "LIKE": true, "AS": true, "VALUE": true,
+	"TOP": true, "DISTINCT": true, "ASC": true, "DESC": true, "JOIN": true, "GROUP": true}
+
+// parseIdent parses a (non-reserved) identifier.
+func (p *parser) parseIdent() (string, error) {
+	t := p.peek()
+	if t.kind != tokIdent || reservedWords[strings.ToUpper(t.text)] {
+		return "", p.errorf("expected identifier")
+	}
+	p.pos++
+	return t.text, nil
+}
+
+// _parseQuery parses a SELECT query.
+func _parseQuery(queryStr string) (*query, error) {
+	tokens, err := _tokenize(queryStr)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	q := &query{}
+	if err := p.expectKeyword("SELECT"); err != nil {
+		return nil, err
+	}
+	q.distinct = p.acceptKeyword("DISTINCT")
+	if p.acceptKeyword("TOP") {
+		if q.top, err = p.parsePrimary(); err != nil {
+			return nil, err
+		}
+	}
+	switch {
+	case p.acceptSymbol("*"):
+		q.star = true
+	case p.acceptKeyword("VALUE"):
+		if q.value, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	default:
+		for {
+			proj := projection{}
+			if proj.expr, err = p.parseExpr(); err != nil {
+				return nil, err
+			}
+			if p.acceptKeyword("AS") || (p.peek().kind == tokIdent && !reservedWords[strings.ToUpper(p.peek().text)]) {
+				if proj.alias, err = p.parseIdent(); err != nil {
+					return nil, err
+				}
+			}
+			q.projections = append(q.projections, proj)
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+	}
+	if err := p.expectKeyword("FROM"); err != nil {
+		return nil, err
+	}
+	if q.alias, err = p.parseIdent(); err != nil {
+		return nil, err
+	}
+	if p.acceptKeyword("AS") || (p.peek().kind == tokIdent && !reservedWords[strings.ToUpper(p.peek().text)]) {
+		if q.alias, err = p.parseIdent(); err != nil {
+			return nil, err
+		}
+	}
+	if p.isKeyword("JOIN") || p.isKeyword("GROUP") || p.isKeyword("IN") {
+		return nil, p.errorf("%s is not supported", strings.ToUpper(p.peek().text))
+	}
+	if p.acceptKeyword("WHERE") {
+		if q.where, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+	if p.isKeyword("GROUP") {
+		return nil, p.errorf("GROUP BY is not supported")
+	}
+	if p.acceptKeyword("ORDER") {
+		if err := p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		for {
+			ob := orderBy{}
+			if ob.expr, err = p.parseExpr(); err != nil {
+				return nil, err
+			}
+			if !p.acceptKeyword("ASC") {
+				ob.desc = p.acceptKeyword("DESC")
+			}
+			q.orderBy = append(q.orderBy, ob)
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+	}
+	if p.acceptKeyword("OFFSET") {
+		if q.offset, err = p.parsePrimary(); err != nil {
+			return nil, err
+		}
+		if err := p.expectKeyword("LIMIT"); err != nil {
+			return nil, err
+		}
+		if q.limit, err = p.parsePrimary(); err != nil {
+			return nil, err
+		}
+	}
+	if p.peek().kind != tokEOF {
+		return nil, p.errorf("unexpected token")
+	}
+	return q, nil
+}
+
+func (p *parser) parseExpr() (expr, error) {
+	cond, err := p.parseCoalesce()
+	if err != nil || !p.acceptSymbol("?") {
+		return cond, err
+	}
+	then, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectSymbol(":"); err != nil {
+		return nil, err
+	}
+	otherwise, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	return &conditionalExpr{cond: cond, then: then, otherwise: otherwise}, nil
+}
+
+func (p *parser) parseCoalesce() (expr, error) {
+	left, err := p.parseOr()
+	for err == nil && p.acceptSymbol("??") {
+		var right expr
+		if right, err = p.parseOr(); err == nil {
+			left = &binaryExpr{op: "??", left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+func (p *parser) parseOr() (expr, error) {
+	left, err := p.parseAnd()
+	for err == nil && p.acceptKeyword("OR") {
+		var right expr
+		if right, err = p.parseAnd(); err == nil {
+			left = &binaryExpr{op: "OR", left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+func (p *parser) parseAnd() (expr, error) {
+	left, err := p.parseNot()
+	for err == nil && p.acceptKeyword("AND") {
+		var right expr
+		if right, err = p.parseNot(); err == nil {
+			left = &binaryExpr{op: "AND", left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+func (p *parser) parseNot() (expr, error) {
+	if p.acceptKeyword("NOT") {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryExpr{op: "NOT", operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (expr, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	if t.kind == tokSymbol {
+		switch t.text {
+		case "=", "!=", "<>", "<", "<=", ">", ">=":
+			p.pos++
+			right, err := p.parseAdditive()
+			if err != nil {
+				return nil, err
+			}
+			return &binaryExpr{op: t.text, left: left, right: right}, nil
+		}
+	}
+	not := p.acceptKeyword("NOT")
+	switch {
+	case p.acceptKeyword("IN"):
+		if err := p.expectSymbol("("); err != nil {
+			return nil, err
+		}
+		list, err := p.parseExprList(")")
+		if err != nil {
+			return nil, err
+		}
+		return &inExpr{operand: left, list: list, not: not}, nil
+	case p.acceptKeyword("BETWEEN"):
+		low, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectKeyword("AND"); err != nil {
+			return nil, err
+		}
+		high, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		return &betweenExpr{operand: left, low: low, high: high, not: not}, nil
+	case p.acceptKeyword("LIKE"):
+		pattern, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		return &likeExpr{operand: left, pattern: pattern, not: not}, nil
+	}
+	if not {
+		return nil, p.errorf("expected IN, BETWEEN or LIKE")
+	}
+	return left, nil
+}
+
+func (p *parser) parseAdditive() (expr, error) {
+	left, err := p.parseMultiplicative()
+	for err == nil && (p.isSymbol("+") || p.isSymbol("-") || p.isSymbol("||")) {
+		op := p.next().text
+		var right expr
+		if right, err = p.parseMultiplicative(); err == nil {
+			left = &binaryExpr{op: op, left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+func (p *parser) parseMultiplicative() (expr, error) {
+	left, err := p.parseUnary()
+	for err == nil && (p.isSymbol("*") || p.isSymbol("/") || p.isSymbol("%")) {
+		op := p.next().text
+		var right expr
+		if right, err = p.parseUnary(); err == nil {
+			left = &binaryExpr{op: op, left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+func (p *parser) parseUnary() (expr, error) {
+	if p.acceptSymbol("-") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryExpr{op: "-", operand: operand}, nil
+	}
+	return p.parsePostfix()
+}
+
+// parsePostfix parses property accesses, e.g. c.address.city or c["address"]["city"].
+func (p *parser) parsePostfix() (expr, error) {
+	e, err := p.parsePrimary()
+	for err == nil {
+		switch {
+		case p.acceptSymbol("."):
+			t := p.next()
+			if t.kind != tokIdent {
+				return nil, p.errorf("expected property name")
+			}
+			e = &memberExpr{obj: e, name: &literalExpr{value: t.text}}
+		case p.acceptSymbol("["):
+			var name expr
+			if name, err = p.parseExpr(); err == nil {
+				if err = p.expectSymbol("]"); err == nil {
+					e = &memberExpr{obj: e, name: name}
+				}
+			}
+		default:
+			return e, nil
+		}
+	}
+	return nil, err
+}
+
+func (p *parser) parseExprList(closing string) ([]expr, error) {
+	list := make([]expr, 0)
+	if p.acceptSymbol(closing) {
+		return list, nil
+	}
+	for {
+		e, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+		if p.acceptSymbol(closing) {
+			return list, nil
+		}
+		if err := p.expectSymbol(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) parsePrimary() (expr, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		return &literalExpr{value: t.num}, nil
+	case tokString:
+		return &literalExpr{value: t.text}, nil
+	case tokParam:
+		return &paramExpr{name: t.text}, nil
+	case tokIdent:
+		switch strings.ToLower(t.text) {
+		case "true":
+			return &literalExpr{value: true}, nil
+		case "false":
+			return &literalExpr{value: false}, nil
+		case "null":
+			return &literalExpr{value: nil}, nil
+		case "undefined":
+			return &literalExpr{value: undefined}, nil
+		}
+		if p.acceptSymbol("(") {
+			name := strings.ToUpper(t.text)
+			if name == "COUNT" && p.isSymbol("*") {
+				// COUNT(*) is an alias of COUNT(1)
+				p.pos++
+				if err := p.expectSymbol(")"); err != nil {
+					return nil, err
+				}
+				return &funcExpr{name: name, args: []expr{&literalExpr{value: 1.0}}}, nil
+			}
+			if _, ok := scalarFuncs[name]; !ok && !aggregateFuncs[name] {
+				p.pos--
+				return nil, p.errorf("unsupported function %s", t.text)
+			}
+			args, err := p.parseExprList(")")
+			if err != nil {
+				return nil, err
+			}
+			return &funcExpr{name: name, args: args}, nil
+		}
+		if reservedWords[strings.ToUpper(t.text)] {
+			p.pos--
+			return nil, p.errorf("unexpected keyword %s", t.text)
+		}
+		return &identExpr{name: t.text}, nil
+	case tokSymbol:
+		switch t.text {
+		case "(":
+			e, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			return e, p.expectSymbol(")")
+		case "[":
+			elems, err := p.parseExprList("]")
+			if err != nil {
+				return nil, err
+			}
+			return &arrayExpr{elems: elems}, nil
+		case "{":
+			obj := &objectExpr{}
+			for !p.acceptSymbol("}") {
+				if len(obj.keys) > 0 {
+					if err := p.expectSymbol(","); err != nil {
+						return nil, err
+					}
+				}
+				key := p.next()
+				if key.kind != tokIdent && key.kind != tokString {
+					return nil, p.errorf("expected property name")
+				}
+				if err := p.expectSymbol(":"); err != nil {
+					return nil, err
+				}
+				value, err := p.parseExpr()
+				if err != nil {
+					return nil, err
+				}
+				obj.keys = append(obj.keys, key.text)
+				obj.props = append(obj.props, value)
+			}
+			return obj, nil
+		}
+	}
+	p.pos--
+	return nil, p.errorf("unexpected token")
+}
+
+/*----------------------------------------------------------------------*/
+
+// _evalInt evaluates an expression that must result in a non-negative integer (e.g. TOP, OFFSET, LIMIT).
+func _evalInt(e expr, env *evalEnv, clause string) (int, error) {
+	v, err := e.eval(env)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := v.(float64)
+	if !ok || n < 0 || n != math.Trunc(n) {
+		return 0, errors.New(clause + " must be a non-negative integer")
+	}
+	return int(n), nil
+}
+
+// _projectionName returns the name of a projected property: the alias, the name of the accessed property or "$<index>".
+func _projectionName(proj projection, index int) string {
+	if proj.alias != "" {
+		return proj.alias
+	}
+	if m, ok := proj.expr.(*memberExpr); ok {
+		if name, ok := m.name.(*literalExpr); ok {
+			if s, ok := name.value.(string); ok {
+				return s
+			}
+		}
+	}
+	if ident, ok := proj.expr.(*identExpr); ok {
+		return ident.name
+	}
+	return "$" + strconv.Itoa(index+1)
+}
+
+// _aggregate evaluates an aggregate function over documents.
+func _aggregate(fn *funcExpr, docs []interface{}, env *evalEnv) (interface{}, error) {
+	if len(fn.args) != 1 {
+		return nil, fmt.Errorf("invalid number of arguments for function %s", fn.name)
+	}
+	var result interface{} = undefined
+	count, sum := 0, 0.0
+	for _, doc := range docs {
+		env.doc = doc
+		v, err := fn.args[0].eval(env)
+		if err != nil {
+			return nil, err
+		}
+		if v == undefined {
+			continue
+		}
+		switch fn.name {
+		case "COUNT":
+			count++
+		case "SUM", "AVG":
+			n, ok := v.(float64)
+			if !ok {
+				return undefined, nil
+			}
+			count++
+			sum += n
+		case "MIN":
+			if result == undefined || _compareValues(v, result) < 0 {
+				result = v
+			}
+		case "MAX":
+			if result == undefined || _compareValues(v, result) > 0 {
+				result = v
+			}
+		}
+	}
+	switch fn.name {
+	case "COUNT":
+		return float64(count), nil
+	case "SUM":
+		return sum, nil
+	case "AVG":
+		if count == 0 {
+			return undefined, nil
+		}
+		return sum / float64(count), nil
+	}
+	return result, nil
+}
+
+func _isAggregate(e expr) bool {
+	fn, ok := e.(*funcExpr)
+	return ok && aggregateFuncs[fn.name]
+}
+
+// execute runs the query against documents, returning the result set.
+func (q *query) execute(docs []map[string]interface{}, params map[string]interface{}) ([]interface{}, error) {
+	env := &evalEnv{alias: q.alias, params: params}
+	matched := make([]interface{}, 0)
+	for _, doc := range docs {
+		env.doc = doc
+		if q.where != nil {
+			v, err := q.where.eval(env)
+			if err != nil {
+				return nil, err
+			}
+			if v != true {
+				continue
+			}
+		}
+		matched = append(matched, doc)
+	}
+
+	if len(q.orderBy) > 0 {
+		keys := make(map[int][]interface{}, len(matched))
+		for i, doc := range matched {
+			env.doc = doc
+			key := make([]interface{}, len(q.orderBy))
+			for j, ob := range q.orderBy {
+				v, err := ob.expr.eval(env)
+				if err != nil {
+					return nil, err
+				}
+				key[j] = v
+			}
+			keys[i] = key
+		}
+		indexes := make([]int, len(matched))
+		for i := range indexes {
+			indexes[i] = i
+		}
+		sort.SliceStable(indexes, func(a, b int) bool {
+			for j, ob := range q.orderBy {
+				c := _compareValues(keys[indexes[a]][j], keys[indexes[b]][j])
+				if ob.desc {
+					c = -c
+				}
+				if c != 0 {
+					return c < 0
+				}
+			}
+			return false
+		})
+		sorted := make([]interface{}, len(matched))
+		for i, index := range indexes {
+			sorted[i] = matched[index]
+		}
+		matched = sorted
+	}
+
+	results := make([]interface{}, 0, len(matched))
+	isAggregate := _isAggregate(q.value)
+	for _, proj := range q.projections {
+		if _isAggregate(proj.expr) {
+			isAggregate = true
+		} else if isAggregate {
+			return nil, errors.New("aggregate and non-aggregate projections cannot be mixed without GROUP BY")
+		}
+	}
+	switch {
+	case isAggregate && q.value != nil:
+		v, err := _aggregate(q.value.(*funcExpr), matched, env)
+		if err != nil {
+			return nil, err
+		}
+		if v != undefined {
+			results = append(results, v)
+		}
+	case isAggregate:
+		row := make(map[string]interface{})
+		for i, proj := range q.projections {
+			fn, ok := proj.expr.(*funcExpr)
+			if !ok || !aggregateFuncs[fn.name] {
+				return nil, errors.New("aggregate and non-aggregate projections cannot be mixed without GROUP BY")
+			}
+			v, err := _aggregate(fn, matched, env)
+			if err != nil {
+				return nil, err
+			}
+			if v != undefined {
+				row[_projectionName(proj, i)] = v
+			}
+		}
+		results = append(results, row)
+	default:
+		for _, doc := range matched {
+			env.doc = doc
+			switch {
+			case q.star:
+				results = append(results, doc)
+			case q.value != nil:
+				v, err := q.value.eval(env)
+				if err != nil {
+					return nil, err
+				}
+				if v != undefined {
+					results = append(results, v)
+				}
+			default:
+				row := make(map[string]interface{}, len(q.projections))
+				for i, proj := range q.projections {
+					v, err := proj.expr.eval(env)
+					if err != nil {
+						return nil, err
+					}
+					if v != undefined {
+						row[_projectionName(proj, i)] = v
+					}
+				}
+				results = append(results, row)
+			}
+		}
+	}
+
+	if q.distinct {
+		seen := make(map[string]bool)
+		distinct := make([]interface{}, 0, len(results))
+		for _, r := range results {
+			js, _ := json.Marshal(r)
+			if !seen[string(js)] {
+				seen[string(js)] = true
+				distinct = append(distinct, r)
+			}
+		}
+		results = distinct
+	}
+	if q.offset != nil {
+		offset, err := _evalInt(q.offset, env, "OFFSET")
+		if err != nil {
+			return nil, err
+		}
+		limit, err := _evalInt(q.limit, env, "LIMIT")
+		if err != nil {
+			return nil, err
+		}
+		if offset > len(results) {
+			offset = len(results)
+		}
+		if offset+limit < len(results) {
+			results = results[offset : offset+limit]
+		} else {
+			results = results[offset:]
+		}
+	}
+	if q.top != nil {
+		top, err := _evalInt(q.top, env, "TOP")
+		if err != nil {
+			return nil, err
+		}
+		if top < len(results) {
+			results = results[:top]
+		}
+	}
+	return results, nil
+}
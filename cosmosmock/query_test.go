@@ -0,0 +1,76 @@
+package cosmosmock
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func _testDocs() []map[string]interface{} {
+	var docs []map[string]interface{}
+	json.Unmarshal([]byte(`[
+		{"id":"1","name":"Alice","age":30,"city":"Hanoi","tags":["a","b"],"address":{"zip":"100"}},
+		{"id":"2","name":"Bob","age":25,"city":"Saigon","tags":["b"]},
+		{"id":"3","name":"Carol","age":35,"city":"Hanoi","tags":[],"address":{"zip":"200"}},
+		{"id":"4","name":"dave","city":null}
+	]`), &docs)
+	return docs
+}
+
+func TestQuery_execute(t *testing.T) {
+	name := "TestQuery_execute"
+	testCases := []struct {
+		query    string
+		params   map[string]interface{}
+		expected string
+	}{
+		{`SELECT * FROM c WHERE c.age > @age ORDER BY c.age`, map[string]interface{}{"@age": 26.0},
+			`[{"id":"1","name":"Alice","age":30,"city":"Hanoi","tags":["a","b"],"address":{"zip":"100"}},{"id":"3","name":"Carol","age":35,"city":"Hanoi","tags":[],"address":{"zip":"200"}}]`},
+		{`SELECT c.id, c.address.zip FROM c WHERE IS_DEFINED(c.address) ORDER BY c.id DESC`, nil, `[{"id":"3","zip":"200"},{"id":"1","zip":"100"}]`},
+		{`SELECT VALUE c.name FROM c WHERE c.city IN ("Hanoi", "Saigon") AND NOT STARTSWITH(c.name, "B")`, nil, `["Alice","Carol"]`},
+		{`SELECT VALUE COUNT(1) FROM c WHERE c.age BETWEEN 25 AND 30`, nil, `[2]`},
+		{`SELECT COUNT(1) AS n, SUM(c.age) AS total, MAX(c.name) AS m FROM c`, nil, `[{"m":"dave","n":4,"total":90}]`},
+		{`SELECT DISTINCT VALUE c.city FROM c WHERE IS_STRING(c.city) ORDER BY c.city`, nil, `["Hanoi","Saigon"]`},
+		{`SELECT TOP 2 VALUE c.id FROM c ORDER BY c.id`, nil, `["1","2"]`},
+		{`SELECT VALUE c.id FROM c ORDER BY c.id OFFSET 1 LIMIT 2`, nil, `["2","3"]`},
+		{`SELECT VALUE c.id FROM c WHERE ARRAY_CONTAINS(c.tags, "b") AND c.name LIKE "%o%"`, nil, `["2"]`},
+		{`SELECT VALUE {"n": UPPER(c.name), "old": c.age >= 30 ? true : false} FROM c WHERE c.id = "1"`, nil, `[{"n":"ALICE","old":true}]`},
+		{`SELECT VALUE c.id FROM c WHERE c.city = null`, nil, `["4"]`},
+		{`SELECT VALUE c.id FROM c WHERE c.age > "20"`, nil, `[]`},
+		{`SELECT VALUE c["name"] || "!" FROM c WHERE c.id = '2' -- comment`, nil, `["Bob!"]`},
+		{`SELECT VALUE (c.age ?? 0) * 2 FROM c ORDER BY c.id`, nil, `[60,50,70,0]`},
+	}
+	for _, testCase := range testCases {
+		q, err := _parseQuery(testCase.query)
+		if err != nil {
+			t.Fatalf("%s failed: %s / %s", name, testCase.query, err)
+		}
+		results, err := q.execute(_testDocs(), testCase.params)
+		if err != nil {
+			t.Fatalf("%s failed: %s / %s", name, testCase.query, err)
+		}
+		var expected, actual interface{}
+		json.Unmarshal([]byte(testCase.expected), &expected)
+		js, _ := json.Marshal(results)
+		json.Unmarshal(js, &actual)
+		if !reflect.DeepEqual(expected, actual) {
+			t.Fatalf("%s failed: %s\nexpected %s\nreceived %s", name, testCase.query, testCase.expected, js)
+		}
+	}
+}
+
+func TestParseQuery_error(t *testing.T) {
+	name := "TestParseQuery_error"
+	for _, queryStr := range []string{
+		`SELECT * FROM c JOIN t IN c.tags`,
+		`SELECT c.city, COUNT(1) FROM c GROUP BY c.city`,
+		`SELECT * FROM c WHERE c.name = "unterminated`,
+		`SELECT * FROM c WHERE`,
+		`DELETE FROM c`,
+		`SELECT * FROM c WHERE UNKNOWN_FUNC(c.id)`,
+	} {
+		if _, err := _parseQuery(queryStr); err == nil {
+			t.Fatalf("%s failed: expected error for query %s", name, queryStr)
+		}
+	}
+}
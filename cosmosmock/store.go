@@ -0,0 +1,174 @@
+package cosmosmock
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Store is an in-memory Azure Cosmos DB account. It implements the subset of the Cosmos DB REST API used by gocosmos
+// (databases, collections, documents, queries, change feed, offers and server-side scripts), both as a http.Handler and
+// as a http.RoundTripper, so that it can back a gocosmos.RestClient or a database/sql connection without network.
+//
+// Limitations: server-side scripts (stored procedures, triggers and user-defined functions) are stored but not executed,
+// queries support a single collection alias (no JOIN, GROUP BY or subqueries), documents never expire, and the
+// collection's indexing policy is not enforced.
+type Store struct {
+	lock   sync.Mutex
+	seq    int64
+	dbs    map[string]*database
+	offers map[string]map[string]interface{} // keyed by offer's _rid
+}
+
+type database struct {
+	info  map[string]interface{}
+	colls map[string]*collection
+}
+
+type collection struct {
+	info    map[string]interface{}
+	pkPaths [][]string
+	docs    map[string]map[string]interface{}            // keyed by _docKey(partition key, id)
+	scripts map[string]map[string]map[string]interface{} // "sprocs", "udfs" or "triggers" -> id -> script
+}
+
+// NewStore creates a new empty Store.
+func NewStore() *Store {
+	return &Store{dbs: make(map[string]*database), offers: make(map[string]map[string]interface{})}
+}
+
+// Reset removes all databases from the store.
+func (s *Store) Reset() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.dbs = make(map[string]*database)
+	s.offers = make(map[string]map[string]interface{})
+}
+
+// RoundTrip implements http.RoundTripper.RoundTrip, serving the request in-memory.
+func (s *Store) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorder := httptest.NewRecorder()
+	s.ServeHTTP(recorder, req)
+	resp := recorder.Result()
+	resp.Request = req
+	return resp, nil
+}
+
+// nextSeq returns the next number of the store's sequence, used to generate _rid, _etag and _lsn.
+func (s *Store) nextSeq() int64 {
+	s.seq++
+	return s.seq
+}
+
+// sysProps sets system properties of a resource.
+func (s *Store) sysProps(res map[string]interface{}, self string) {
+	seq := s.nextSeq()
+	if _, ok := res["_rid"]; !ok {
+		res["_rid"] = "rid" + strconv.FormatInt(seq, 10)
+	}
+	res["_self"] = self
+	res["_etag"] = `"` + strconv.FormatInt(seq, 10) + `"`
+	res["_ts"] = float64(time.Now().Unix())
+}
+
+// _clone deep-copies a JSON value.
+func _clone(v map[string]interface{}) map[string]interface{} {
+	js, _ := json.Marshal(v)
+	result := make(map[string]interface{})
+	json.Unmarshal(js, &result)
+	return result
+}
+
+/*----------------------------------------------------------------------*/
+
+// _parsePkPaths parses the partition key paths of a collection, e.g. ["/address/city"] becomes [["address","city"]].
+func _parsePkPaths(pkInfo map[string]interface{}) [][]string {
+	paths, _ := pkInfo["paths"].([]interface{})
+	result := make([][]string, 0, len(paths))
+	for _, path := range paths {
+		str, _ := path.(string)
+		parts := strings.Split(strings.TrimPrefix(str, "/"), "/")
+		for i, part := range parts {
+			if len(part) > 1 && strings.HasPrefix(part, `"`) && strings.HasSuffix(part, `"`) {
+				parts[i] = part[1 : len(part)-1]
+			}
+		}
+		result = append(result, parts)
+	}
+	return result
+}
+
+// pkKey computes the partition key of a document, as JSON array of partition key values (undefined values are {}).
+func (c *collection) pkKey(doc map[string]interface{}) string {
+	values := make([]interface{}, len(c.pkPaths))
+	for i, path := range c.pkPaths {
+		var v interface{} = doc
+		for _, part := range path {
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				v = undefined
+				break
+			}
+			if v, ok = m[part]; !ok {
+				v = undefined
+				break
+			}
+		}
+		if v == undefined {
+			v = map[string]interface{}{}
+		}
+		values[i] = v
+	}
+	js, _ := json.Marshal(values)
+	return string(js)
+}
+
+// _normalizePkHeader normalizes the value of header x-ms-documentdb-partitionkey, returns "" if the header is absent.
+func _normalizePkHeader(header string) (string, bool) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "null" || header == "[]" {
+		return "", true
+	}
+	var values []interface{}
+	if err := json.Unmarshal([]byte(header), &values); err != nil {
+		return "", false
+	}
+	js, _ := json.Marshal(values)
+	return string(js), true
+}
+
+func _docKey(pkKey, id string) string {
+	return pkKey + "\x00" + id
+}
+
+// uniqueKeyConflict checks if a document violates a unique key of the collection within its partition.
+func (c *collection) uniqueKeyConflict(pkKey string, doc map[string]interface{}) bool {
+	policy, _ := c.info["uniqueKeyPolicy"].(map[string]interface{})
+	uniqueKeys, _ := policy["uniqueKeys"].([]interface{})
+	for _, uk := range uniqueKeys {
+		paths, _ := uk.(map[string]interface{})["paths"].([]interface{})
+		ukColl := &collection{pkPaths: _parsePkPaths(map[string]interface{}{"paths": paths})}
+		value := ukColl.pkKey(doc)
+		for key, other := range c.docs {
+			if strings.HasPrefix(key, pkKey+"\x00") && other["id"] != doc["id"] && ukColl.pkKey(other) == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sortedDocs returns the collection's documents in order of modification.
+func (c *collection) sortedDocs() []map[string]interface{} {
+	docs := make([]map[string]interface{}, 0, len(c.docs))
+	for _, doc := range c.docs {
+		docs = append(docs, doc)
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i]["_lsn"].(float64) < docs[j]["_lsn"].(float64) })
+	return docs
+}
@@ -0,0 +1,105 @@
+package cosmosmock
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/btnguyen2k/gocosmos"
+)
+
+func _newRestClient(t *testing.T, name string, store *Store) *gocosmos.RestClient {
+	client, err := gocosmos.NewRestClient(&http.Client{Transport: store}, "AccountEndpoint="+mockEndpoint+";AccountKey="+mockAccountKey)
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	return client
+}
+
+func TestStore_documents(t *testing.T) {
+	name := "TestStore_documents"
+	client := _newRestClient(t, name, NewStore())
+	client.CreateDatabase(gocosmos.DatabaseSpec{Id: "mydb"})
+	pkInfo := map[string]interface{}{"paths": []string{"/pk"}, "kind": "Hash"}
+	if result := client.CreateCollection(gocosmos.CollectionSpec{DbName: "mydb", CollName: "mycoll", PartitionKeyInfo: pkInfo}); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	}
+
+	for _, id := range []string{"1", "2", "3"} {
+		doc := map[string]interface{}{"id": id, "pk": "p" + id, "value": id}
+		result := client.CreateDocument(gocosmos.DocumentSpec{DbName: "mydb", CollName: "mycoll", PartitionKeyValues: []interface{}{"p" + id}, DocumentData: doc})
+		if result.Error() != nil {
+			t.Fatalf("%s failed: %s", name, result.Error())
+		}
+	}
+	wrongPk := gocosmos.DocumentSpec{DbName: "mydb", CollName: "mycoll", PartitionKeyValues: []interface{}{"other"}, DocumentData: map[string]interface{}{"id": "4", "pk": "p4"}}
+	if result := client.CreateDocument(wrongPk); result.StatusCode != 400 {
+		t.Fatalf("%s failed: expected StatusCode 400 but received %d", name, result.StatusCode)
+	}
+
+	getResult := client.GetDocument(gocosmos.DocReq{DbName: "mydb", CollName: "mycoll", DocId: "2", PartitionKeyValues: []interface{}{"p2"}})
+	if getResult.Error() != nil || getResult.DocInfo["value"] != "2" {
+		t.Fatalf("%s failed: %#v / %s", name, getResult.DocInfo, getResult.Error())
+	}
+	if result := client.GetDocument(gocosmos.DocReq{DbName: "mydb", CollName: "mycoll", DocId: "2", PartitionKeyValues: []interface{}{"p1"}}); result.StatusCode != 404 {
+		t.Fatalf("%s failed: expected StatusCode 404 but received %d", name, result.StatusCode)
+	}
+	replaced := gocosmos.DocumentSpec{DbName: "mydb", CollName: "mycoll", PartitionKeyValues: []interface{}{"p2"}, DocumentData: map[string]interface{}{"id": "2", "pk": "p2", "value": "two"}}
+	if result := client.ReplaceDocument(`"0"`, replaced); result.StatusCode != 412 {
+		t.Fatalf("%s failed: expected StatusCode 412 but received %d", name, result.StatusCode)
+	}
+	if result := client.ReplaceDocument(getResult.DocInfo.Etag(), replaced); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	}
+
+	// paging
+	listResult := client.ListDocuments(gocosmos.ListDocsReq{DbName: "mydb", CollName: "mycoll", MaxItemCount: 2})
+	if listResult.Error() != nil || listResult.Count != 2 || listResult.ContinuationToken == "" {
+		t.Fatalf("%s failed: unexpected first page %#v / %s", name, listResult.Documents, listResult.Error())
+	}
+	listResult = client.ListDocuments(gocosmos.ListDocsReq{DbName: "mydb", CollName: "mycoll", MaxItemCount: 2, ContinuationToken: listResult.ContinuationToken})
+	if listResult.Error() != nil || listResult.Count != 1 || listResult.ContinuationToken != "" || listResult.Documents[0].Id() != "2" {
+		t.Fatalf("%s failed: unexpected second page %#v / %s", name, listResult.Documents, listResult.Error())
+	}
+
+	// change feed
+	feed := client.ReadChangeFeed(gocosmos.ChangeFeedReq{DbName: "mydb", CollName: "mycoll"})
+	if feed.Error() != nil || len(feed.Documents) != 3 || feed.Documents[2].Id() != "2" {
+		t.Fatalf("%s failed: unexpected change feed %#v / %s", name, feed.Documents, feed.Error())
+	}
+	if next := client.ReadChangeFeed(gocosmos.ChangeFeedReq{DbName: "mydb", CollName: "mycoll", Continuation: feed.Continuation}); next.StatusCode != 304 {
+		t.Fatalf("%s failed: expected StatusCode 304 but received %d", name, next.StatusCode)
+	}
+	client.DeleteDocument(gocosmos.DocReq{DbName: "mydb", CollName: "mycoll", DocId: "1", PartitionKeyValues: []interface{}{"p1"}})
+	client.CreateDocument(gocosmos.DocumentSpec{DbName: "mydb", CollName: "mycoll", IsUpsert: true, PartitionKeyValues: []interface{}{"p3"}, DocumentData: map[string]interface{}{"id": "3", "pk": "p3"}})
+	if next := client.ReadChangeFeed(gocosmos.ChangeFeedReq{DbName: "mydb", CollName: "mycoll", Continuation: feed.Continuation}); next.Error() != nil || len(next.Documents) != 1 || next.Documents[0].Id() != "3" {
+		t.Fatalf("%s failed: unexpected change feed %#v / %s", name, next.Documents, next.Error())
+	}
+}
+
+func TestStore_offers(t *testing.T) {
+	name := "TestStore_offers"
+	client := _newRestClient(t, name, NewStore())
+	dbResult := client.CreateDatabase(gocosmos.DatabaseSpec{Id: "mydb", Ru: 400})
+	if dbResult.Error() != nil {
+		t.Fatalf("%s failed: %s", name, dbResult.Error())
+	}
+	offer := client.GetOfferForResource(dbResult.Rid)
+	if offer.Error() != nil || offer.OfferThroughput() != 400 {
+		t.Fatalf("%s failed: unexpected offer %#v / %s", name, offer.OfferInfo, offer.Error())
+	}
+	if result := client.ReplaceOfferForResource(dbResult.Rid, 0, 6000); result.Error() != nil || !result.IsAutopilot() || result.AutopilotMaxThroughput() != 6000 {
+		t.Fatalf("%s failed: unexpected offer %#v / %s", name, result.OfferInfo, result.Error())
+	}
+
+	collResult := client.CreateCollection(gocosmos.CollectionSpec{DbName: "mydb", CollName: "mycoll"})
+	if collResult.Error() != nil {
+		t.Fatalf("%s failed: %s", name, collResult.Error())
+	}
+	if result := client.GetOfferForResource(collResult.Rid); result.StatusCode != 404 {
+		t.Fatalf("%s failed: expected StatusCode 404 but received %d", name, result.StatusCode)
+	}
+	client.DeleteDatabase("mydb")
+	if result := client.GetOfferForResource(dbResult.Rid); result.StatusCode != 404 {
+		t.Fatalf("%s failed: offer must be deleted together with the database, received %d", name, result.StatusCode)
+	}
+}
@@ -0,0 +1,13 @@
+module github.com/btnguyen2k/gocosmos/cosmosrepo
+
+go 1.18
+
+require github.com/btnguyen2k/gocosmos v0.1.1
+
+require (
+	github.com/btnguyen2k/consu/gjrc v0.1.1 // indirect
+	github.com/btnguyen2k/consu/reddo v0.1.4 // indirect
+	github.com/btnguyen2k/consu/semita v0.1.4 // indirect
+)
+
+replace github.com/btnguyen2k/gocosmos => ../
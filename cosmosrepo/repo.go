@@ -0,0 +1,200 @@
+/*
+Package cosmosrepo provides a typed data-access layer for Azure Cosmos DB SQL API, built on top of gocosmos' REST client.
+
+A Repo maps a Go struct type onto documents of a collection using struct tags (see TagName), and provides Create, Get,
+Query, Replace, Upsert and Delete operations without writing SQL strings:
+
+	type User struct {
+		Id       string  `cosmos:"id"`
+		Tenant   string  `cosmos:"tenant,pk"`
+		Username string  `cosmos:"username"`
+		Address  Address `cosmos:"address"`
+		Etag     string  `cosmos:"_etag"`
+	}
+
+	client, _ := gocosmos.NewRestClient(nil, "AccountEndpoint=...;AccountKey=...")
+	repo, _ := cosmosrepo.New[User](client, "mydb", "users")
+	user := &User{Tenant: "t1", Username: "btnguyen2k"}
+	err := repo.Create(user) // user.Id is generated if empty
+	user, err = repo.Get(user.Id, "t1")
+	users, err := repo.Query(cosmosrepo.Filter{"address.city": "HCM"})
+
+Available since v0.1.1
+*/
+package cosmosrepo
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/btnguyen2k/gocosmos"
+)
+
+// Filter specifies equality conditions of a query, keyed by document field name. Nested fields are referenced using dot
+// notation, e.g. "address.city".
+type Filter map[string]interface{}
+
+// Repo is a typed repository of documents of type T stored in a collection.
+type Repo[T any] struct {
+	client   *gocosmos.RestClient
+	dbName   string
+	collName string
+	meta     *structMeta
+
+	// IdGenerator generates ids of documents created with empty id, default is gocosmos.UuidGenerator.
+	IdGenerator gocosmos.IdGenerator
+}
+
+// New creates a new Repo that stores documents of type T (which must be a struct with an id field) in the collection
+// dbName.collName.
+func New[T any](client *gocosmos.RestClient, dbName, collName string) (*Repo[T], error) {
+	meta, err := _parseStructMeta(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return nil, err
+	}
+	return &Repo[T]{client: client, dbName: dbName, collName: collName, meta: meta, IdGenerator: gocosmos.UuidGenerator}, nil
+}
+
+// _mapError converts error of a REST call to the corresponding gocosmos error.
+func _mapError(statusCode int, err error) error {
+	if err == nil {
+		return nil
+	}
+	switch statusCode {
+	case 403:
+		return gocosmos.ErrForbidden
+	case 404:
+		return gocosmos.ErrNotFound
+	case 409:
+		return gocosmos.ErrConflict
+	case 412:
+		return gocosmos.ErrPreconditionFailed
+	}
+	return err
+}
+
+func (r *Repo[T]) write(doc *T, isUpsert bool) error {
+	v := reflect.ValueOf(doc).Elem()
+	if r.meta.id(v) == "" {
+		r.meta.setId(v, r.IdGenerator())
+	}
+	data, err := r.meta.toDocument(v)
+	if err != nil {
+		return err
+	}
+	spec := gocosmos.DocumentSpec{DbName: r.dbName, CollName: r.collName, IsUpsert: isUpsert,
+		PartitionKeyValues: r.meta.pkValues(v), DocumentData: data}
+	result := r.client.CreateDocument(spec)
+	if err := _mapError(result.StatusCode, result.Error()); err != nil {
+		return err
+	}
+	return r.meta.fromDocument(result.DocInfo, v)
+}
+
+// Create stores a new document. If the document's id is empty, it is generated by IdGenerator. Upon success, doc is
+// updated with the stored document (e.g. etag).
+//
+// gocosmos.ErrConflict is returned if a document with the same id already exists in the partition.
+func (r *Repo[T]) Create(doc *T) error {
+	return r.write(doc, false)
+}
+
+// Upsert creates a new document or replaces the existing one. See Create for details.
+func (r *Repo[T]) Upsert(doc *T) error {
+	return r.write(doc, true)
+}
+
+// Get fetches a document by id and partition key value(s). gocosmos.ErrNotFound is returned if the document does not
+// exist.
+func (r *Repo[T]) Get(id string, pkValues ...interface{}) (*T, error) {
+	result := r.client.GetDocument(gocosmos.DocReq{DbName: r.dbName, CollName: r.collName, DocId: id, PartitionKeyValues: pkValues})
+	if err := _mapError(result.StatusCode, result.Error()); err != nil {
+		return nil, err
+	}
+	doc := new(T)
+	return doc, r.meta.fromDocument(result.DocInfo, reflect.ValueOf(doc).Elem())
+}
+
+// Replace replaces an existing document. If T has an "_etag" field with non-empty value, the document is replaced only
+// if it has not been modified since (gocosmos.ErrPreconditionFailed is returned otherwise). Upon success, doc is updated
+// with the stored document.
+//
+// gocosmos.ErrNotFound is returned if the document does not exist.
+func (r *Repo[T]) Replace(doc *T) error {
+	v := reflect.ValueOf(doc).Elem()
+	data, err := r.meta.toDocument(v)
+	if err != nil {
+		return err
+	}
+	spec := gocosmos.DocumentSpec{DbName: r.dbName, CollName: r.collName, PartitionKeyValues: r.meta.pkValues(v), DocumentData: data}
+	result := r.client.ReplaceDocument(r.meta.etag(v), spec)
+	if err := _mapError(result.StatusCode, result.Error()); err != nil {
+		return err
+	}
+	return r.meta.fromDocument(result.DocInfo, v)
+}
+
+// Delete removes a document by id and partition key value(s). gocosmos.ErrNotFound is returned if the document does not
+// exist.
+func (r *Repo[T]) Delete(id string, pkValues ...interface{}) error {
+	result := r.client.DeleteDocument(gocosmos.DocReq{DbName: r.dbName, CollName: r.collName, DocId: id, PartitionKeyValues: pkValues})
+	return _mapError(result.StatusCode, result.Error())
+}
+
+// _fieldRef builds the reference to a (nested) document field, e.g. "address.city" becomes c["address"]["city"].
+func _fieldRef(name string) string {
+	ref := "c"
+	for _, part := range strings.Split(name, ".") {
+		ref += `["` + strings.ReplaceAll(part, `"`, `\"`) + `"]`
+	}
+	return ref
+}
+
+// _buildQuery builds the query selecting documents that match all conditions of the filter.
+func _buildQuery(filter Filter) (string, []interface{}) {
+	names := make([]string, 0, len(filter))
+	for name := range filter {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	query := "SELECT * FROM c"
+	params := make([]interface{}, 0, len(names))
+	for i, name := range names {
+		if i == 0 {
+			query += " WHERE "
+		} else {
+			query += " AND "
+		}
+		paramName := "@p" + strconv.Itoa(i+1)
+		query += _fieldRef(name) + "=" + paramName
+		params = append(params, map[string]interface{}{"name": paramName, "value": filter[name]})
+	}
+	return query, params
+}
+
+// Query fetches documents matching all conditions of the filter, across all partitions. An empty filter matches all
+// documents.
+func (r *Repo[T]) Query(filter Filter) ([]T, error) {
+	queryStr, params := _buildQuery(filter)
+	query := gocosmos.QueryReq{DbName: r.dbName, CollName: r.collName, Query: queryStr, Params: params, CrossPartitionEnabled: true}
+	docs := make([]T, 0)
+	for {
+		result := r.client.QueryDocuments(query)
+		if err := _mapError(result.StatusCode, result.Error()); err != nil {
+			return nil, err
+		}
+		for _, docInfo := range result.Documents {
+			var doc T
+			if err := r.meta.fromDocument(docInfo, reflect.ValueOf(&doc).Elem()); err != nil {
+				return nil, err
+			}
+			docs = append(docs, doc)
+		}
+		if result.ContinuationToken == "" {
+			return docs, nil
+		}
+		query.ContinuationToken = result.ContinuationToken
+	}
+}
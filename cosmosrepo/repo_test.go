@@ -0,0 +1,152 @@
+package cosmosrepo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/btnguyen2k/gocosmos"
+)
+
+type _fakeCosmos struct {
+	lock        sync.Mutex
+	docs        map[string]map[string]interface{}
+	lastQuery   map[string]interface{}
+	lastPkValue string
+	etagSeq     int
+}
+
+func (f *_fakeCosmos) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.lastPkValue = r.Header.Get("X-Ms-Documentdb-PartitionKey")
+	var body map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&body)
+	writeDoc := func(status int, doc map[string]interface{}) {
+		w.WriteHeader(status)
+		js, _ := json.Marshal(doc)
+		w.Write(js)
+	}
+	const prefix = "/dbs/db/colls/users/docs"
+	switch {
+	case r.Method == "POST" && r.URL.Path == prefix && r.Header.Get("X-Ms-Documentdb-Isquery") == "true":
+		f.lastQuery = body
+		docs := make([]map[string]interface{}, 0)
+		for _, doc := range f.docs {
+			docs = append(docs, doc)
+		}
+		js, _ := json.Marshal(map[string]interface{}{"Documents": docs, "_count": len(docs)})
+		w.Write(js)
+	case r.Method == "POST" && r.URL.Path == prefix:
+		id, _ := body["id"].(string)
+		if _, ok := f.docs[id]; ok && r.Header.Get("X-Ms-Documentdb-Is-Upsert") != "true" {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		f.etagSeq++
+		body["_etag"] = "etag" + string(rune('0'+f.etagSeq))
+		f.docs[id] = body
+		writeDoc(http.StatusCreated, body)
+	case strings.HasPrefix(r.URL.Path, prefix+"/"):
+		id := strings.TrimPrefix(r.URL.Path, prefix+"/")
+		doc, ok := f.docs[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.Method {
+		case "GET":
+			writeDoc(http.StatusOK, doc)
+		case "PUT":
+			if etag := r.Header.Get("If-Match"); etag != "" && etag != doc["_etag"] {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+			f.etagSeq++
+			body["_etag"] = "etag" + string(rune('0'+f.etagSeq))
+			f.docs[id] = body
+			writeDoc(http.StatusOK, body)
+		case "DELETE":
+			delete(f.docs, id)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func _newRepo(t *testing.T, name string) (*Repo[_user], *_fakeCosmos) {
+	fake := &_fakeCosmos{docs: make(map[string]map[string]interface{})}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	client, err := gocosmos.NewRestClient(nil, "AccountEndpoint="+server.URL+";AccountKey=C2y6yDjf5/R+ob0N8A7Cgv30VRDJIWEHLM+4QDU5DE2nQ9nDuVTqobD4b8mGGyPMbIZnqyMsEcaGQy67XIw/Jw==")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	repo, err := New[_user](client, "db", "users")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	return repo, fake
+}
+
+func TestRepo_CRUD(t *testing.T) {
+	name := "TestRepo_CRUD"
+	repo, fake := _newRepo(t, name)
+
+	user := &_user{Tenant: "t1", Username: "btnguyen2k", Address: _address{City: "HCM"}}
+	if err := repo.Create(user); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if user.Id == "" || user.Etag != "etag1" {
+		t.Fatalf("%s failed: id must be generated and etag populated, received %#v", name, user)
+	}
+	if fake.lastPkValue != `["t1"]` {
+		t.Fatalf("%s failed: <partition-key> expected %#v but received %#v", name, `["t1"]`, fake.lastPkValue)
+	}
+	if err := repo.Create(user); err != gocosmos.ErrConflict {
+		t.Fatalf("%s failed: expected ErrConflict but received %#v", name, err)
+	}
+
+	found, err := repo.Get(user.Id, "t1")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if *found != *user {
+		t.Fatalf("%s failed: expected %#v but received %#v", name, user, found)
+	}
+	if _, err := repo.Get("not-exist", "t1"); err != gocosmos.ErrNotFound {
+		t.Fatalf("%s failed: expected ErrNotFound but received %#v", name, err)
+	}
+
+	found.Username = "thanhnb"
+	if err := repo.Replace(found); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	user.Username = "stale"
+	if err := repo.Replace(user); err != gocosmos.ErrPreconditionFailed {
+		t.Fatalf("%s failed: expected ErrPreconditionFailed but received %#v", name, err)
+	}
+
+	users, err := repo.Query(Filter{"address.city": "HCM", "tenant": "t1"})
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if len(users) != 1 || users[0].Username != "thanhnb" {
+		t.Fatalf("%s failed: unexpected result %#v", name, users)
+	}
+	expectedQuery := `SELECT * FROM c WHERE c["address"]["city"]=@p1 AND c["tenant"]=@p2`
+	if fake.lastQuery["query"] != expectedQuery {
+		t.Fatalf("%s failed: expected query %#v but received %#v", name, expectedQuery, fake.lastQuery["query"])
+	}
+
+	if err := repo.Delete(user.Id, "t1"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if err := repo.Delete(user.Id, "t1"); err != gocosmos.ErrNotFound {
+		t.Fatalf("%s failed: expected ErrNotFound but received %#v", name, err)
+	}
+}
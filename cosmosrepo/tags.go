@@ -0,0 +1,152 @@
+package cosmosrepo
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// TagName is the name of struct tags that map struct fields to document fields.
+//
+// Tag syntax: `cosmos:"<field-name>[,pk][,omitempty]"`
+//
+// - field-name: name of the document field; "id" marks the document id (must be a string field) and "_etag" the
+// document's etag (used for optimistic concurrency). If empty, the name from the json tag (if any) or the Go field name
+// is used. Use "-" to skip the field.
+//
+// - pk: the field is the partition key. Multiple pk fields, in order of declaration, form a hierarchical partition key.
+//
+// - omitempty: the field is omitted from the document if it has zero value.
+const TagName = "cosmos"
+
+type fieldMeta struct {
+	index     []int
+	name      string
+	omitEmpty bool
+}
+
+// structMeta maps fields of a struct type to document fields.
+type structMeta struct {
+	fields    []fieldMeta
+	idIndex   int   // index of the id field in fields
+	etagIndex int   // index of the etag field in fields, -1 if none
+	pkIndexes []int // indexes of the partition key fields in fields
+}
+
+func _parseStructMeta(typ reflect.Type) (*structMeta, error) {
+	if typ.Kind() != reflect.Struct {
+		return nil, errors.New("cosmosrepo: " + typ.String() + " is not a struct")
+	}
+	meta := &structMeta{idIndex: -1, etagIndex: -1}
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		if sf.PkgPath != "" {
+			// unexported field
+			continue
+		}
+		tag, ok := sf.Tag.Lookup(TagName)
+		if !ok {
+			tag = sf.Tag.Get("json")
+		}
+		parts := strings.Split(tag, ",")
+		if parts[0] == "-" {
+			continue
+		}
+		field := fieldMeta{index: sf.Index, name: parts[0]}
+		if field.name == "" {
+			field.name = sf.Name
+		}
+		for _, opt := range parts[1:] {
+			switch strings.TrimSpace(opt) {
+			case "pk":
+				meta.pkIndexes = append(meta.pkIndexes, len(meta.fields))
+			case "omitempty":
+				field.omitEmpty = true
+			}
+		}
+		switch field.name {
+		case "id":
+			if sf.Type.Kind() != reflect.String {
+				return nil, errors.New("cosmosrepo: id field of " + typ.String() + " must be a string")
+			}
+			meta.idIndex = len(meta.fields)
+		case "_etag":
+			meta.etagIndex = len(meta.fields)
+		}
+		meta.fields = append(meta.fields, field)
+	}
+	if meta.idIndex < 0 {
+		return nil, errors.New("cosmosrepo: " + typ.String() + " has no id field")
+	}
+	return meta, nil
+}
+
+// id returns the document id of a struct value.
+func (m *structMeta) id(v reflect.Value) string {
+	return v.FieldByIndex(m.fields[m.idIndex].index).String()
+}
+
+// setId sets the document id of a struct value.
+func (m *structMeta) setId(v reflect.Value, id string) {
+	v.FieldByIndex(m.fields[m.idIndex].index).SetString(id)
+}
+
+// etag returns the etag of a struct value, empty if the struct has no etag field.
+func (m *structMeta) etag(v reflect.Value) string {
+	if m.etagIndex < 0 {
+		return ""
+	}
+	etag, _ := v.FieldByIndex(m.fields[m.etagIndex].index).Interface().(string)
+	return etag
+}
+
+// pkValues returns the partition key values of a struct value.
+func (m *structMeta) pkValues(v reflect.Value) []interface{} {
+	values := make([]interface{}, len(m.pkIndexes))
+	for i, index := range m.pkIndexes {
+		values[i] = v.FieldByIndex(m.fields[index].index).Interface()
+	}
+	return values
+}
+
+// toDocument converts a struct value to document data.
+func (m *structMeta) toDocument(v reflect.Value) (map[string]interface{}, error) {
+	doc := make(map[string]interface{}, len(m.fields))
+	for i, f := range m.fields {
+		if i == m.etagIndex {
+			// etag is maintained by the server
+			continue
+		}
+		fv := v.FieldByIndex(f.index)
+		if f.omitEmpty && fv.IsZero() {
+			continue
+		}
+		doc[f.name] = fv.Interface()
+	}
+	// normalize nested values (e.g. structs with json tags) to their JSON representation
+	js, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]interface{})
+	return result, json.Unmarshal(js, &result)
+}
+
+// fromDocument populates a struct value from document data.
+func (m *structMeta) fromDocument(doc map[string]interface{}, v reflect.Value) error {
+	for _, f := range m.fields {
+		value, ok := doc[f.name]
+		if !ok {
+			continue
+		}
+		js, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(js, v.FieldByIndex(f.index).Addr().Interface()); err != nil {
+			return errors.New("cosmosrepo: cannot populate field " + f.name + ": " + err.Error())
+		}
+	}
+	return nil
+}
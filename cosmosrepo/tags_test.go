@@ -0,0 +1,72 @@
+package cosmosrepo
+
+import (
+	"reflect"
+	"testing"
+)
+
+type _address struct {
+	City   string `json:"city"`
+	Street string `json:"street,omitempty"`
+}
+
+type _user struct {
+	Id       string   `cosmos:"id"`
+	Tenant   string   `cosmos:"tenant,pk"`
+	Username string   `json:"username"`
+	Email    string   `cosmos:",omitempty"`
+	Address  _address `cosmos:"address"`
+	Etag     string   `cosmos:"_etag"`
+	Secret   string   `cosmos:"-"`
+	internal string
+}
+
+func Test_parseStructMeta(t *testing.T) {
+	name := "Test_parseStructMeta"
+	meta, err := _parseStructMeta(reflect.TypeOf(_user{}))
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	names := make([]string, len(meta.fields))
+	for i, f := range meta.fields {
+		names[i] = f.name
+	}
+	if !reflect.DeepEqual(names, []string{"id", "tenant", "username", "Email", "address", "_etag"}) {
+		t.Fatalf("%s failed: unexpected fields %#v", name, names)
+	}
+	if meta.idIndex != 0 || meta.etagIndex != 5 || !reflect.DeepEqual(meta.pkIndexes, []int{1}) {
+		t.Fatalf("%s failed: unexpected meta %#v", name, meta)
+	}
+
+	for _, v := range []interface{}{"not a struct", struct{ Name string }{}, struct {
+		Id int `cosmos:"id"`
+	}{}} {
+		if _, err := _parseStructMeta(reflect.TypeOf(v)); err == nil {
+			t.Fatalf("%s failed: expected error for %T", name, v)
+		}
+	}
+}
+
+func TestStructMeta_Document(t *testing.T) {
+	name := "TestStructMeta_Document"
+	meta, _ := _parseStructMeta(reflect.TypeOf(_user{}))
+	user := _user{Id: "1", Tenant: "t1", Username: "btnguyen2k", Address: _address{City: "HCM"}, Etag: "etag", Secret: "s"}
+	doc, err := meta.toDocument(reflect.ValueOf(user))
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	expected := map[string]interface{}{"id": "1", "tenant": "t1", "username": "btnguyen2k", "address": map[string]interface{}{"city": "HCM"}}
+	if !reflect.DeepEqual(doc, expected) {
+		t.Fatalf("%s failed: expected %#v but received %#v", name, expected, doc)
+	}
+
+	var user2 _user
+	doc["_etag"] = "etag2"
+	if err := meta.fromDocument(doc, reflect.ValueOf(&user2).Elem()); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	user.Etag, user.Secret = "etag2", ""
+	if user2 != user {
+		t.Fatalf("%s failed: expected %#v but received %#v", name, user, user2)
+	}
+}
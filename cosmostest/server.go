@@ -0,0 +1,157 @@
+/*
+Package cosmostest provides a fake Azure Cosmos DB gateway for integration-style tests.
+
+A Server is a httptest.Server that emulates the subset of the Cosmos DB REST API used by gocosmos (databases,
+collections, documents, queries, change feed, offers and server-side scripts), backed by an in-memory
+cosmosmock.Store. Requests are authenticated with AccountKey just like the real gateway, so that tests exercise the
+complete driver stack (connection string parsing, request signing and HTTP transport) without network:
+
+	server := cosmostest.NewServer()
+	defer server.Close()
+	db, err := sql.Open("gocosmos", server.ConnStr()+";DefaultDb=mydb")
+
+Failures can be injected via Server.InjectFault to test error handling of applications.
+
+Available since v0.1.1
+*/
+package cosmostest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/btnguyen2k/gocosmos/cosmosmock"
+)
+
+// AccountKey is the key that clients must sign requests with (the well-known key of the Azure Cosmos DB emulator).
+const AccountKey = "C2y6yDjf5/R+ob0N8A7Cgv30VRDJIWEHLM+4QDU5DE2nQ9nDuVTqobD4b8mGGyPMbIZnqyMsEcaGQy67XIw/Jw=="
+
+// Server is a fake Azure Cosmos DB gateway.
+type Server struct {
+	*httptest.Server
+	Store *cosmosmock.Store // in-memory data of the server
+
+	lock     sync.Mutex
+	faults   []int // status codes returned by the next requests, see InjectFault
+	requests int
+}
+
+// NewServer starts and returns a new Server, listening on a plain HTTP endpoint. The caller should call Close when
+// finished, to shut it down.
+func NewServer() *Server {
+	s := &Server{Store: cosmosmock.NewStore()}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// NewTLSServer starts and returns a new Server, listening on a HTTPS endpoint with a self-signed certificate
+// (clients must connect with InsecureSkipVerify=true, which is included in ConnStr). The caller should call Close when
+// finished, to shut it down.
+func NewTLSServer() *Server {
+	s := &Server{Store: cosmosmock.NewStore()}
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// ConnStr returns the connection string to connect to the server, e.g. with sql.Open("gocosmos", server.ConnStr()).
+func (s *Server) ConnStr() string {
+	connStr := "AccountEndpoint=" + s.URL + ";AccountKey=" + AccountKey
+	if s.TLS != nil {
+		connStr += ";InsecureSkipVerify=true"
+	}
+	return connStr
+}
+
+// InjectFault makes the next count requests fail with the specified status code (e.g. 429, 503), without reaching
+// the store.
+func (s *Server) InjectFault(statusCode, count int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for i := 0; i < count; i++ {
+		s.faults = append(s.faults, statusCode)
+	}
+}
+
+// Requests returns the number of requests the server has received.
+func (s *Server) Requests() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.requests
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	s.requests++
+	fault := 0
+	if len(s.faults) > 0 {
+		fault, s.faults = s.faults[0], s.faults[1:]
+	}
+	s.lock.Unlock()
+
+	if fault != 0 {
+		if fault == http.StatusTooManyRequests {
+			w.Header().Set("X-Ms-Retry-After-Ms", "1")
+		}
+		_writeError(w, fault, http.StatusText(fault), "injected fault")
+		return
+	}
+	if !_verifyAuth(r) {
+		_writeError(w, http.StatusUnauthorized, "Unauthorized", "The input authorization token can't serve the request.")
+		return
+	}
+	s.Store.ServeHTTP(w, r)
+}
+
+func _writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	js, _ := json.Marshal(map[string]interface{}{"code": code, "message": message})
+	w.Write(js)
+}
+
+// _resource returns the resource type and resource id of a request, used to sign requests.
+func _resource(path string) (string, string) {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return "", ""
+	}
+	segments := strings.Split(path, "/")
+	if len(segments)%2 == 1 {
+		// feed of resources, e.g. dbs/mydb/colls
+		return segments[len(segments)-1], strings.Join(segments[:len(segments)-1], "/")
+	}
+	// a specific resource, e.g. dbs/mydb/colls/mycoll
+	return segments[len(segments)-2], path
+}
+
+// _verifyAuth verifies the Authorization header of a request. Requests authenticated with Azure AD tokens are accepted
+// as long as a token is present.
+func _verifyAuth(r *http.Request) bool {
+	authHeader, err := url.QueryUnescape(r.Header.Get("Authorization"))
+	if err != nil || authHeader == "" {
+		return false
+	}
+	params := make(map[string]string)
+	for _, part := range strings.Split(authHeader, "&") {
+		tokens := strings.SplitN(part, "=", 2)
+		if len(tokens) == 2 {
+			params[tokens[0]] = tokens[1]
+		}
+	}
+	if params["type"] == "aad" {
+		return params["sig"] != ""
+	}
+	key, _ := base64.StdEncoding.DecodeString(AccountKey)
+	resType, resId := _resource(r.URL.Path)
+	stringToSign := strings.ToLower(r.Method + "\n" + resType + "\n" + resId + "\n" + r.Header.Get("X-Ms-Date") + "\n\n")
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(stringToSign))
+	return params["type"] == "master" && params["sig"] == base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,103 @@
+package cosmostest
+
+import (
+	"database/sql"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/btnguyen2k/gocosmos"
+)
+
+func Test_resource(t *testing.T) {
+	name := "Test_resource"
+	testCases := []struct{ path, resType, resId string }{
+		{"/", "", ""},
+		{"/dbs", "dbs", ""},
+		{"/dbs/mydb", "dbs", "dbs/mydb"},
+		{"/dbs/mydb/colls", "colls", "dbs/mydb"},
+		{"/dbs/mydb/colls/mycoll/docs", "docs", "dbs/mydb/colls/mycoll"},
+		{"/dbs/mydb/colls/mycoll/docs/1", "docs", "dbs/mydb/colls/mycoll/docs/1"},
+		{"/offers/abc", "offers", "offers/abc"},
+	}
+	for _, testCase := range testCases {
+		resType, resId := _resource(testCase.path)
+		if resType != testCase.resType || resId != testCase.resId {
+			t.Fatalf("%s failed: %s expected %s/%s but received %s/%s", name, testCase.path, testCase.resType, testCase.resId, resType, resId)
+		}
+	}
+}
+
+func _testServer(t *testing.T, name string, server *Server) {
+	db, err := sql.Open("gocosmos", server.ConnStr()+";DefaultDb=mydb")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	defer db.Close()
+	for _, stmt := range []string{"CREATE DATABASE mydb", "CREATE COLLECTION mydb.users WITH pk=/id"} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s failed: %s / %s", name, stmt, err)
+		}
+	}
+	if _, err := db.Exec(`INSERT INTO mydb.users (id, name) VALUES (:1, :2)`, "1", "Alice", "1"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	var userName string
+	if err := db.QueryRow(`SELECT c.name FROM c WHERE c.id=:1 WITH collection=users`, "1").Scan(&userName); err != nil || userName != "Alice" {
+		t.Fatalf("%s failed: expected Alice but received %s / %s", name, userName, err)
+	}
+}
+
+func TestServer(t *testing.T) {
+	name := "TestServer"
+	server := NewServer()
+	defer server.Close()
+	_testServer(t, name, server)
+	if server.Requests() == 0 {
+		t.Fatalf("%s failed: requests must be counted", name)
+	}
+}
+
+func TestTLSServer(t *testing.T) {
+	name := "TestTLSServer"
+	server := NewTLSServer()
+	defer server.Close()
+	if !strings.Contains(server.ConnStr(), "InsecureSkipVerify=true") {
+		t.Fatalf("%s failed: unexpected connection string %s", name, server.ConnStr())
+	}
+	_testServer(t, name, server)
+}
+
+func TestServer_auth(t *testing.T) {
+	name := "TestServer_auth"
+	server := NewServer()
+	defer server.Close()
+	otherKey := "dGhpcyBpcyBub3QgdGhlIGFjY291bnQga2V5IG9mIHRoZSBmYWtlIHNlcnZlcg=="
+	client, err := gocosmos.NewRestClient(nil, "AccountEndpoint="+server.URL+";AccountKey="+otherKey)
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if result := client.CreateDatabase(gocosmos.DatabaseSpec{Id: "mydb"}); result.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("%s failed: expected StatusCode 401 but received %d", name, result.StatusCode)
+	}
+}
+
+func TestServer_InjectFault(t *testing.T) {
+	name := "TestServer_InjectFault"
+	server := NewServer()
+	defer server.Close()
+	client, err := gocosmos.NewRestClient(nil, server.ConnStr())
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	server.InjectFault(http.StatusServiceUnavailable, 1)
+	if result := client.CreateDatabase(gocosmos.DatabaseSpec{Id: "mydb"}); result.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("%s failed: expected StatusCode 503 but received %d", name, result.StatusCode)
+	}
+	if result := client.CreateDatabase(gocosmos.DatabaseSpec{Id: "mydb"}); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	}
+	if result := client.GetDatabase("mydb"); result.Error() != nil || result.Id != "mydb" {
+		t.Fatalf("%s failed: %#v / %s", name, result.DbInfo, result.Error())
+	}
+}
@@ -0,0 +1,202 @@
+package gocosmos
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/btnguyen2k/consu/gjrc"
+)
+
+// DiagnosticsAttempt captures a single HTTP request sent to the server. An operation may consist of several attempts,
+// e.g. retries, failovers to other regions or pages of a query.
+//
+// Available since v0.1.1
+type DiagnosticsAttempt struct {
+	Start         time.Time     // time the request was sent
+	Latency       time.Duration // time until the response was received
+	Operation     string        // operation name, e.g. "Read" or "Query" (see RequestMetrics)
+	ResourceType  string        // resource type, e.g. "docs" or "colls"
+	Endpoint      string        // host of the endpoint the request was sent to
+	StatusCode    int           // HTTP status code of the response, 0 if no response was received
+	RequestCharge float64       // request units consumed, from header x-ms-request-charge
+	ActivityId    string        // activity id of the request, from header x-ms-activity-id
+	SessionToken  string        // session token returned by the server, from header x-ms-session-token
+	Err           error         // error occurred if no response was received
+
+	CacheHit bool // true if the response was served by the integrated cache of a dedicated gateway, from header x-ms-cosmos-cachehit
+}
+
+// Diagnostics collects the timeline of requests sent on behalf of calls whose context it is attached to (see
+// WithDiagnostics), similar to CosmosDiagnostics of the .NET SDK. It is safe for concurrent use.
+//
+// Available since v0.1.1
+type Diagnostics struct {
+	lock     sync.Mutex
+	attempts []DiagnosticsAttempt
+}
+
+// NewDiagnostics creates a new empty Diagnostics.
+func NewDiagnostics() *Diagnostics {
+	return &Diagnostics{}
+}
+
+// Attempts returns the requests collected so far, in the order they were sent.
+func (d *Diagnostics) Attempts() []DiagnosticsAttempt {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return append([]DiagnosticsAttempt{}, d.attempts...)
+}
+
+// RequestCharge returns the total request units consumed by the requests collected so far.
+func (d *Diagnostics) RequestCharge() float64 {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	total := 0.0
+	for _, a := range d.attempts {
+		total += a.RequestCharge
+	}
+	return total
+}
+
+// SessionToken returns the session token returned by the server for the last request that returned one, "" if none.
+// A stateless tier can hand it to its client after a write, to be supplied to later reads via WithSessionToken or
+// "WITH session_token=<token>" (read-your-writes).
+func (d *Diagnostics) SessionToken() string {
+	attempts := d.Attempts()
+	for i := len(attempts) - 1; i >= 0; i-- {
+		if attempts[i].SessionToken != "" {
+			return attempts[i].SessionToken
+		}
+	}
+	return ""
+}
+
+// Reset clears the collected requests, so that the Diagnostics can be reused for the next call.
+func (d *Diagnostics) Reset() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.attempts = nil
+}
+
+// String returns a human-readable summary of the timeline, one line per request.
+func (d *Diagnostics) String() string {
+	attempts := d.Attempts()
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "%d request(s), %s RU", len(attempts), strconv.FormatFloat(d.RequestCharge(), 'f', -1, 64))
+	for i, a := range attempts {
+		fmt.Fprintf(sb, "\n#%d %s %s %s/%s status=%d latency=%s ru=%s activity_id=%s", i+1,
+			a.Start.Format(time.RFC3339Nano), a.Endpoint, a.Operation, a.ResourceType, a.StatusCode, a.Latency,
+			strconv.FormatFloat(a.RequestCharge, 'f', -1, 64), a.ActivityId)
+		if a.CacheHit {
+			sb.WriteString(" cache_hit=true")
+		}
+		if a.Err != nil {
+			fmt.Fprintf(sb, " error=%q", a.Err.Error())
+		}
+	}
+	return sb.String()
+}
+
+func (d *Diagnostics) record(req *http.Request, resp *gjrc.GjrcResponse, start time.Time) {
+	operation, resourceType := _requestOperation(req)
+	attempt := DiagnosticsAttempt{
+		Start:        start,
+		Latency:      time.Since(start),
+		Operation:    operation,
+		ResourceType: resourceType,
+		Endpoint:     req.URL.Host,
+		ActivityId:   req.Header.Get("X-Ms-Activity-Id"),
+		Err:          resp.Error(),
+	}
+	if httpResp := resp.HttpResponse(); httpResp != nil {
+		attempt.StatusCode = httpResp.StatusCode
+		attempt.RequestCharge, _ = strconv.ParseFloat(httpResp.Header.Get("X-Ms-Request-Charge"), 64)
+		if activityId := httpResp.Header.Get("X-Ms-Activity-Id"); activityId != "" {
+			attempt.ActivityId = activityId
+		}
+		attempt.SessionToken = httpResp.Header.Get("X-Ms-Session-Token")
+		attempt.CacheHit = strings.EqualFold(httpResp.Header.Get("X-Ms-Cosmos-Cachehit"), "true")
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.attempts = append(d.attempts, attempt)
+}
+
+type ctxKeyDiagnosticsType int
+
+const ctxKeyDiagnostics ctxKeyDiagnosticsType = 0
+
+// WithDiagnostics returns a copy of ctx to which diag is attached. Requests sent on behalf of calls made with the
+// returned context (e.g. sql.DB.ExecContext/QueryContext, or methods of a RestClient obtained via RestClient.WithContext)
+// are collected into diag.
+//
+// Example:
+//
+//     diag := gocosmos.NewDiagnostics()
+//     rows, err := db.QueryContext(gocosmos.WithDiagnostics(ctx, diag), "SELECT * FROM c WITH db=mydb WITH collection=mycoll")
+//     fmt.Println(diag)
+//
+// Available since v0.1.1
+func WithDiagnostics(ctx context.Context, diag *Diagnostics) context.Context {
+	return context.WithValue(ctx, ctxKeyDiagnostics, diag)
+}
+
+// DiagnosticsFromContext returns the Diagnostics attached to ctx, nil if none.
+//
+// Available since v0.1.1
+func DiagnosticsFromContext(ctx context.Context) *Diagnostics {
+	diag, _ := ctx.Value(ctxKeyDiagnostics).(*Diagnostics)
+	return diag
+}
+
+/*----------------------------------------------------------------------*/
+
+// contextStmt wraps a statement prepared by Conn.PrepareContext, so that requests sent when the statement is executed
+// carry the context of the Exec/Query call (e.g. for cancellation, diagnostics and custom headers) and the statement's
+// "WITH header.<name>=<value>" headers, and the connection is marked bad after fatal errors (see Conn.IsValid).
+type contextStmt struct {
+	driver.Stmt
+	conn *Conn // the connection that prepared the statement, marked bad after fatal errors
+}
+
+// bind returns a copy of the wrapped statement bound to a connection whose REST client sends requests with ctx (to which
+// the statement's custom headers are attached), see _rebindStmt.
+func (s *contextStmt) bind(ctx context.Context) driver.Stmt {
+	return _rebindStmt(s.Stmt, func(base *Stmt) *Conn {
+		boundCtx := ctx
+		if base.headers != nil {
+			boundCtx = WithHeaders(ctx, base.headers)
+		}
+		boundConn := *base.conn
+		boundConn.restClient = base.conn.restClient.WithContext(boundCtx)
+		return &boundConn
+	})
+}
+
+func _namedValuesToValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	return values
+}
+
+// ExecContext implements driver.StmtExecContext.ExecContext.
+func (s *contextStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	result, err := s.bind(ctx).Exec(_namedValuesToValues(args))
+	s.conn.checkErr(err)
+	return result, err
+}
+
+// QueryContext implements driver.StmtQueryContext.QueryContext.
+func (s *contextStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	rows, err := s.bind(ctx).Query(_namedValuesToValues(args))
+	s.conn.checkErr(err)
+	return rows, err
+}
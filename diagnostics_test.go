@@ -0,0 +1,138 @@
+package gocosmos
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDiagnostics(t *testing.T) {
+	name := "TestDiagnostics"
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			// drop the connection in the middle of the response
+			w.Header().Set("Content-Length", "100")
+			w.Write([]byte(`{"Documents":`))
+			return
+		}
+		w.Header().Set("X-Ms-Request-Charge", "2.5")
+		w.Header().Set("X-Ms-Activity-Id", "activity-1")
+		w.Write([]byte(`{"Documents":[{"id":"1"}],"_count":1}`))
+	}))
+	defer server.Close()
+	db, err := sql.Open("gocosmos", "AccountEndpoint="+server.URL+";AccountKey=cHJpbWFyeQ==")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	defer db.Close()
+
+	diag := NewDiagnostics()
+	ctx := WithDiagnostics(context.Background(), diag)
+	if DiagnosticsFromContext(ctx) != diag || DiagnosticsFromContext(context.Background()) != nil {
+		t.Fatalf("%s failed: Diagnostics must be attached to context", name)
+	}
+	rows, err := db.QueryContext(ctx, "SELECT * FROM c WITH db=mydb WITH collection=mycoll")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	rows.Close()
+	attempts := diag.Attempts()
+	if len(attempts) != 2 {
+		t.Fatalf("%s failed: expected 2 attempts but received %#v", name, attempts)
+	}
+	if attempts[0].Err == nil || attempts[0].RequestCharge != 0 || attempts[0].Operation != "Query" || attempts[0].ResourceType != "docs" {
+		t.Fatalf("%s failed: unexpected first attempt %#v", name, attempts[0])
+	}
+	if a := attempts[1]; a.Err != nil || a.StatusCode != 200 || a.RequestCharge != 2.5 || a.ActivityId != "activity-1" ||
+		a.Latency <= 0 || a.Start.Before(attempts[0].Start) || !strings.Contains(server.URL, a.Endpoint) {
+		t.Fatalf("%s failed: unexpected second attempt %#v", name, a)
+	}
+	if diag.RequestCharge() != 2.5 || !strings.HasPrefix(diag.String(), "2 request(s), 2.5 RU\n#1 ") {
+		t.Fatalf("%s failed: unexpected summary %s", name, diag)
+	}
+
+	diag.Reset()
+	stmt, _ := db.Prepare("SELECT * FROM c WITH db=mydb WITH collection=mycoll")
+	defer stmt.Close()
+	if _, err := stmt.Query(); err != nil || len(diag.Attempts()) != 0 {
+		t.Fatalf("%s failed: calls without Diagnostics must not be collected %s / %#v", name, err, diag.Attempts())
+	}
+	if rows, err := stmt.QueryContext(ctx); err != nil || len(diag.Attempts()) != 1 {
+		t.Fatalf("%s failed: context of the call must be used by prepared statements %s / %#v", name, err, diag.Attempts())
+	} else {
+		rows.Close()
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := stmt.QueryContext(canceled); err == nil {
+		t.Fatalf("%s failed: canceled call must fail", name)
+	}
+}
+
+func TestDiagnostics_SharedStmt(t *testing.T) {
+	name := "TestDiagnostics_SharedStmt"
+	var conn driver.Conn
+	var shared *Stmt
+	var mutated int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if shared.conn != conn {
+			// the cached statement has been rebound while a call is in progress
+			atomic.StoreInt32(&mutated, 1)
+		}
+		if r.Header.Get("X-Ms-Continuation") == "" {
+			w.Header().Set("X-Ms-Continuation", "page2")
+		}
+		w.Write([]byte(`{"Documents":[{"id":"1"}],"_count":1}`))
+	}))
+	defer server.Close()
+	var err error
+	if conn, err = (&Driver{}).Open("AccountEndpoint=" + server.URL + ";AccountKey=cHJpbWFyeQ=="); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	defer conn.Close()
+
+	// statements prepared from the same query share the cached statement, which calls must not modify
+	query := "SELECT * FROM c WITH db=mydb WITH collection=mycoll WITH TIMEOUT=10s"
+	stmt, _ := conn.(*Conn).PrepareContext(context.Background(), query)
+	shared = stmt.(*contextStmt).Stmt.(rebinder).baseStmt()
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stmt, err := conn.(*Conn).PrepareContext(context.Background(), query)
+			if err != nil {
+				errs <- err
+				return
+			}
+			diag := NewDiagnostics()
+			rows, err := stmt.(driver.StmtQueryContext).QueryContext(WithDiagnostics(context.Background(), diag), nil)
+			if err != nil {
+				errs <- err
+				return
+			}
+			rows.Close()
+			if attempts := diag.Attempts(); len(attempts) != 2 {
+				errs <- fmt.Errorf("expected 2 attempts but received %d", len(attempts))
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if atomic.LoadInt32(&mutated) != 0 {
+		t.Fatalf("%s failed: cached statement must stay bound to its connection", name)
+	}
+}
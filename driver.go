@@ -11,6 +11,13 @@ func init() {
 }
 
 var (
+	// ErrBadRequest is returned when the request is rejected as invalid, e.g. malformed query (available since v0.1.1).
+	ErrBadRequest = errors.New("StatusCode=400 Bad Request")
+
+	// ErrUnauthorized is returned when the request cannot be authenticated, e.g. invalid account key (available since
+	// v0.1.1).
+	ErrUnauthorized = errors.New("StatusCode=401 Unauthorized")
+
 	// ErrForbidden is returned when the operation is not allowed on the target resource.
 	ErrForbidden = errors.New("StatusCode=403 Forbidden")
 
@@ -19,10 +26,39 @@ var (
 
 	// ErrConflict is returned when the executing operation cause conflict (e.g. duplicated id).
 	ErrConflict = errors.New("StatusCode=409 Conflict")
+
+	// ErrPreconditionFailed is returned when the etag supplied via "_etag=<etag>" condition does not match the target
+	// document's current etag, i.e. the document has been modified since it was read (available since v0.1.1).
+	ErrPreconditionFailed = errors.New("StatusCode=412 Precondition Failed")
+
+	// ErrRequestEntityTooLarge is returned when the document or request exceeds the maximum allowed size (available
+	// since v0.1.1).
+	ErrRequestEntityTooLarge = errors.New("StatusCode=413 Request Entity Too Large")
+
+	// ErrTooManyRequests is returned when the request is throttled because the provisioned throughput is exceeded, see
+	// CosmosError.RetryAfter (available since v0.1.1).
+	ErrTooManyRequests = errors.New("StatusCode=429 Too Many Requests")
+
+	// ErrServiceUnavailable is returned when the service is temporarily unavailable (available since v0.1.1).
+	ErrServiceUnavailable = errors.New("StatusCode=503 Service Unavailable")
+
+	// ErrTimeout is returned when the executing operation does not complete within the timeout specified via
+	// "WITH TIMEOUT=<duration>", or when the server times out the request with StatusCode=408 (available since v0.1.1).
+	ErrTimeout = errors.New("operation timed out")
+
+	// ErrScanRequired is matched (via errors.Is) by the StatusCode=400 error returned when a query filters on paths
+	// excluded from indexing and scans are not enabled, see SELECT's "WITH enable_scan=true" (available since v0.1.1).
+	ErrScanRequired = errors.New("query requires a scan of paths excluded from indexing")
+
+	// ErrServerless is matched (via errors.Is) by the StatusCode=400 error returned when provisioning, changing or
+	// reading throughput (offers) on a serverless account (available since v0.1.1).
+	ErrServerless = errors.New("provisioned throughput is not supported on serverless accounts")
 )
 
 // Driver is Azure CosmosDB driver for database/sql.
 type Driver struct {
+	stats    statsCollector // (since v0.1.1) statistics of requests sent by connections opened by the driver
+	sessions sessionStores  // (since v0.1.1) session tokens shared by connections opened with SharedSessionTokens=true
 }
 
 // Open implements driver.Driver.Open.
@@ -33,14 +69,20 @@ type Driver struct {
 // If not supplied, default value for TimeoutMs is 10 seconds and Version is "2018-12-31".
 //
 // DefaultDb is added since v0.1.1
+//
+// (since v0.1.1) AuthMode=msi authenticates with Azure managed identity instead of AccountKey, see NewRestClient.
 func (d *Driver) Open(connStr string) (driver.Conn, error) {
 	restClient, err := NewRestClient(nil, connStr)
 	if err != nil {
 		return nil, err
 	}
-	defaultDb, ok := restClient.params["DEFAULTDB"]
-	if !ok {
-		defaultDb, _ = restClient.params["DB"]
+	restClient.stats = &d.stats
+	sharedSessions, err := _parseBoolOption(restClient.params, "SharedSessionTokens")
+	if err != nil {
+		return nil, err
+	}
+	if sharedSessions {
+		restClient.sessions = d.sessions.get(connStr)
 	}
-	return &Conn{restClient: restClient, defaultDb: defaultDb}, nil
+	return newConn(restClient)
 }
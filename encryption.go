@@ -0,0 +1,261 @@
+package gocosmos
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// encryptedPrefix marks values encrypted by FieldEncryptor, stored as "$enc:v1:<key id>:<base64 of nonce+ciphertext>".
+const encryptedPrefix = "$enc:v1:"
+
+// KeyProvider supplies the data encryption keys of a FieldEncryptor, e.g. by unwrapping keys stored alongside the
+// application's configuration with a key encryption key kept in Azure Key Vault.
+//
+// Available since v0.1.1
+type KeyProvider interface {
+	// Key returns the data encryption key identified by keyId, which must be 32 bytes long (AES-256).
+	Key(keyId string) ([]byte, error)
+}
+
+// StaticKeyProvider is a KeyProvider that serves keys from memory, keyed by key id.
+//
+// Available since v0.1.1
+type StaticKeyProvider map[string][]byte
+
+// Key implements KeyProvider.Key.
+func (p StaticKeyProvider) Key(keyId string) ([]byte, error) {
+	if key, ok := p[keyId]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("key <%s> not found", keyId)
+}
+
+// FieldEncryptionPolicy specifies the document paths of a collection to be encrypted, and the key to encrypt them with.
+//
+// Available since v0.1.1
+type FieldEncryptionPolicy struct {
+	DbName, CollName string
+	KeyId            string   // id of the data encryption key, passed to KeyProvider.Key
+	Paths            []string // paths of the fields to be encrypted, e.g. "/ssn" or "/address/street"
+}
+
+// FieldEncryptor encrypts configured fields of documents on the client side (AES-256-GCM) before they are sent to the
+// server, and decrypts them when documents are received, so that sensitive values (e.g. PII) are never stored in clear.
+//
+// Fields are encrypted by CreateDocument and ReplaceDocument (hence INSERT, UPSERT and UPDATE statements, and bulk
+// operations), and decrypted in documents returned by document operations, queries, read-feeds and change feed (hence
+// SELECT statements). Encrypted values are strings that embed the id of the key they are encrypted with, so that keys can
+// be rotated by changing FieldEncryptionPolicy.KeyId as long as the KeyProvider still serves the previous keys. Values
+// that are not encrypted (e.g. written before the policy was configured) are returned as-is.
+//
+// Limitations: encryption is randomized, hence encrypted fields can not be filtered, sorted nor aggregated by queries;
+// fields are decrypted only if the query result keeps their path (e.g. "SELECT c.ssn FROM c", not "SELECT c.ssn AS x
+// FROM c"); "/id" and the partition key path must not be encrypted.
+//
+// Available since v0.1.1
+type FieldEncryptor struct {
+	provider KeyProvider
+	policies map[string]FieldEncryptionPolicy // policies keyed by "<db>/<coll>"
+	lock     sync.RWMutex
+	aeads    map[string]cipher.AEAD // ciphers of keys fetched from provider, keyed by key id
+}
+
+// NewFieldEncryptor creates a new FieldEncryptor that fetches keys from provider. Keys are fetched once per key id and
+// cached.
+//
+// Available since v0.1.1
+func NewFieldEncryptor(provider KeyProvider, policies ...FieldEncryptionPolicy) (*FieldEncryptor, error) {
+	if provider == nil {
+		return nil, errors.New("key provider is required")
+	}
+	e := &FieldEncryptor{provider: provider, policies: make(map[string]FieldEncryptionPolicy), aeads: make(map[string]cipher.AEAD)}
+	for _, policy := range policies {
+		if policy.DbName == "" || policy.CollName == "" {
+			return nil, errors.New("database and collection names of encryption policy are required")
+		}
+		if policy.KeyId == "" || strings.Contains(policy.KeyId, ":") {
+			return nil, fmt.Errorf("invalid key id <%s> of encryption policy for %s/%s", policy.KeyId, policy.DbName, policy.CollName)
+		}
+		for _, path := range policy.Paths {
+			if !strings.HasPrefix(path, "/") || len(path) < 2 || path == "/id" || strings.HasPrefix(path, "/_") {
+				return nil, fmt.Errorf("invalid path <%s> of encryption policy for %s/%s", path, policy.DbName, policy.CollName)
+			}
+		}
+		e.policies[policy.DbName+"/"+policy.CollName] = policy
+	}
+	return e, nil
+}
+
+// aead returns the cipher of the key identified by keyId.
+func (e *FieldEncryptor) aead(keyId string) (cipher.AEAD, error) {
+	e.lock.RLock()
+	aead, ok := e.aeads[keyId]
+	e.lock.RUnlock()
+	if ok {
+		return aead, nil
+	}
+	key, err := e.provider.Key(keyId)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("key <%s> must be 32 bytes long, got %d", keyId, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if aead, err = cipher.NewGCM(block); err != nil {
+		return nil, err
+	}
+	e.lock.Lock()
+	e.aeads[keyId] = aead
+	e.lock.Unlock()
+	return aead, nil
+}
+
+// encryptValue encrypts the JSON encoding of value, authenticating the path the value is stored at.
+func (e *FieldEncryptor) encryptValue(keyId, path string, value interface{}, codec JsonCodec) (string, error) {
+	aead, err := e.aead(keyId)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := codec.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, []byte(path))
+	return encryptedPrefix + keyId + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptValue decrypts a value returned by encryptValue, ok is false if value is not encrypted.
+func (e *FieldEncryptor) decryptValue(path string, value interface{}, codec JsonCodec) (result interface{}, ok bool, err error) {
+	str, isStr := value.(string)
+	if !isStr || !strings.HasPrefix(str, encryptedPrefix) {
+		return value, false, nil
+	}
+	tokens := strings.SplitN(str[len(encryptedPrefix):], ":", 2)
+	if len(tokens) != 2 {
+		return nil, true, errors.New("malformed encrypted value")
+	}
+	aead, err := e.aead(tokens[0])
+	if err != nil {
+		return nil, true, err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(tokens[1])
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return nil, true, errors.New("malformed encrypted value")
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(path))
+	if err != nil {
+		return nil, true, err
+	}
+	err = codec.Unmarshal(plaintext, &result)
+	return result, true, err
+}
+
+// encryptDocument returns a copy of doc with fields encrypted according to the policy of the collection, or doc itself
+// if no policy applies. doc is not modified.
+func (e *FieldEncryptor) encryptDocument(dbName, collName string, doc map[string]interface{}, codec JsonCodec) (map[string]interface{}, error) {
+	if e == nil || doc == nil {
+		return doc, nil
+	}
+	policy, ok := e.policies[dbName+"/"+collName]
+	if !ok {
+		return doc, nil
+	}
+	result := _copyMap(doc)
+	for _, path := range policy.Paths {
+		parent, field := _encryptionTarget(result, path, true)
+		if value, ok := parent[field]; ok && value != nil {
+			encrypted, err := e.encryptValue(policy.KeyId, path, value, codec)
+			if err != nil {
+				return nil, fmt.Errorf("cannot encrypt path %s: %s", path, err)
+			}
+			parent[field] = encrypted
+		}
+	}
+	return result, nil
+}
+
+// hasPolicy returns true if fields of documents of the collection are encrypted.
+func (e *FieldEncryptor) hasPolicy(dbName, collName string) bool {
+	if e == nil {
+		return false
+	}
+	_, ok := e.policies[dbName+"/"+collName]
+	return ok
+}
+
+// decryptDocuments decrypts, in place, fields of docs according to the policy of the collection.
+func (e *FieldEncryptor) decryptDocuments(dbName, collName string, codec JsonCodec, docs ...DocInfo) error {
+	if e == nil {
+		return nil
+	}
+	policy, ok := e.policies[dbName+"/"+collName]
+	if !ok {
+		return nil
+	}
+	for _, doc := range docs {
+		for _, path := range policy.Paths {
+			parent, field := _encryptionTarget(doc, path, false)
+			if value, ok := parent[field]; ok {
+				decrypted, isEncrypted, err := e.decryptValue(path, value, codec)
+				if err != nil {
+					return fmt.Errorf("cannot decrypt path %s: %s", path, err)
+				}
+				if isEncrypted {
+					parent[field] = decrypted
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// _encryptionTarget walks doc along path and returns the map holding the field at the end of path and the field name.
+// If copyOnWrite is true, maps along the path are copied (doc itself must already be a copy). parent is nil if the path
+// does not exist in doc.
+func _encryptionTarget(doc map[string]interface{}, path string, copyOnWrite bool) (parent map[string]interface{}, field string) {
+	fields := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	parent = doc
+	for _, f := range fields[:len(fields)-1] {
+		child, ok := parent[f].(map[string]interface{})
+		if !ok {
+			return nil, ""
+		}
+		if copyOnWrite {
+			child = _copyMap(child)
+			parent[f] = child
+		}
+		parent = child
+	}
+	return parent, fields[len(fields)-1]
+}
+
+func _copyMap(m map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+	return result
+}
+
+// SetFieldEncryptor supplies the FieldEncryptor the client encrypts and decrypts fields of documents with. Passing nil
+// disables encryption.
+//
+// Available since v0.1.1
+func (c *RestClient) SetFieldEncryptor(encryptor *FieldEncryptor) *RestClient {
+	c.encryptor = encryptor
+	return c
+}
@@ -0,0 +1,112 @@
+package gocosmos
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFieldEncryptor(t *testing.T) {
+	name := "TestFieldEncryptor"
+	keys := StaticKeyProvider{"k1": bytes.Repeat([]byte{1}, 32), "k2": bytes.Repeat([]byte{2}, 32), "short": []byte("short")}
+	for _, policy := range []FieldEncryptionPolicy{
+		{DbName: "db", CollName: "coll", KeyId: "", Paths: []string{"/ssn"}},
+		{DbName: "db", CollName: "coll", KeyId: "k:1", Paths: []string{"/ssn"}},
+		{DbName: "db", CollName: "coll", KeyId: "k1", Paths: []string{"/id"}},
+		{DbName: "db", CollName: "coll", KeyId: "k1", Paths: []string{"ssn"}},
+		{DbName: "db", CollName: "coll", KeyId: "k1", Paths: []string{"/_ts"}},
+		{DbName: "", CollName: "coll", KeyId: "k1", Paths: []string{"/ssn"}},
+	} {
+		if _, err := NewFieldEncryptor(keys, policy); err == nil {
+			t.Fatalf("%s failed: invalid policy %#v must be rejected", name, policy)
+		}
+	}
+
+	encryptor, err := NewFieldEncryptor(keys, FieldEncryptionPolicy{DbName: "db", CollName: "coll", KeyId: "k1", Paths: []string{"/ssn", "/address/street", "/missing/field"}})
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	doc := map[string]interface{}{"id": "1", "ssn": "123-45-6789", "address": map[string]interface{}{"street": "1 Main St", "city": "Hanoi"}, "age": 30.0}
+	encrypted, err := encryptor.encryptDocument("db", "coll", doc, StdJsonCodec)
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if doc["ssn"] != "123-45-6789" || doc["address"].(map[string]interface{})["street"] != "1 Main St" {
+		t.Fatalf("%s failed: input document must not be modified: %#v", name, doc)
+	}
+	ssn, _ := encrypted["ssn"].(string)
+	street, _ := encrypted["address"].(map[string]interface{})["street"].(string)
+	if !strings.HasPrefix(ssn, "$enc:v1:k1:") || !strings.HasPrefix(street, "$enc:v1:k1:") || encrypted["age"] != 30.0 || encrypted["id"] != "1" {
+		t.Fatalf("%s failed: unexpected encrypted document %#v", name, encrypted)
+	}
+	if other, _ := encryptor.encryptDocument("db", "other", doc, StdJsonCodec); other["ssn"] != "123-45-6789" {
+		t.Fatalf("%s failed: documents of collections without policy must not be encrypted", name)
+	}
+
+	decrypted := DocInfo(encrypted)
+	if err := encryptor.decryptDocuments("db", "coll", StdJsonCodec, decrypted); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if decrypted["ssn"] != "123-45-6789" || decrypted["address"].(map[string]interface{})["street"] != "1 Main St" {
+		t.Fatalf("%s failed: unexpected decrypted document %#v", name, decrypted)
+	}
+
+	// values encrypted with a previous key are still decrypted, and ciphertext is bound to its path
+	rotated, _ := NewFieldEncryptor(keys, FieldEncryptionPolicy{DbName: "db", CollName: "coll", KeyId: "k2", Paths: []string{"/ssn", "/phone"}})
+	legacy, _ := encryptor.encryptDocument("db", "coll", map[string]interface{}{"ssn": 12345.0}, StdJsonCodec)
+	if err := rotated.decryptDocuments("db", "coll", StdJsonCodec, legacy); err != nil || legacy["ssn"] != 12345.0 {
+		t.Fatalf("%s failed: unexpected result %#v / %s", name, legacy, err)
+	}
+	swapped, _ := encryptor.encryptDocument("db", "coll", map[string]interface{}{"ssn": "123-45-6789"}, StdJsonCodec)
+	if err := rotated.decryptDocuments("db", "coll", StdJsonCodec, DocInfo{"phone": swapped["ssn"]}); err == nil {
+		t.Fatalf("%s failed: value moved to another path must not be decrypted", name)
+	}
+	invalidKey, _ := NewFieldEncryptor(keys, FieldEncryptionPolicy{DbName: "db", CollName: "coll", KeyId: "short", Paths: []string{"/ssn"}})
+	if _, err := invalidKey.encryptDocument("db", "coll", doc, StdJsonCodec); err == nil {
+		t.Fatalf("%s failed: keys that are not 32 bytes long must be rejected", name)
+	}
+}
+
+func TestConn_FieldEncryption(t *testing.T) {
+	name := "TestConn_FieldEncryption"
+	var stored string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		if r.Method == http.MethodPost && r.Header.Get("X-Ms-Documentdb-Isquery") == "" {
+			stored = string(data)
+			w.WriteHeader(http.StatusCreated)
+			w.Write(data)
+			return
+		}
+		// query result keeps the encrypted field only, as if projected by the server
+		var doc map[string]interface{}
+		json.Unmarshal([]byte(stored), &doc)
+		js, _ := json.Marshal(map[string]interface{}{"_count": 1, "Documents": []interface{}{map[string]interface{}{"ssn": doc["ssn"]}}})
+		w.Write(js)
+	}))
+	defer server.Close()
+
+	encryptor, _ := NewFieldEncryptor(StaticKeyProvider{"k1": bytes.Repeat([]byte{1}, 32)},
+		FieldEncryptionPolicy{DbName: "db", CollName: "coll", KeyId: "k1", Paths: []string{"/ssn"}})
+	connector, _ := NewConnectorFromConnStr("AccountEndpoint=" + server.URL + ";AccountKey=cHJpbWFyeQ==;DefaultDb=db")
+	db := sql.OpenDB(connector.SetFieldEncryptor(encryptor))
+	defer db.Close()
+
+	if _, err := db.Exec("INSERT INTO coll JSON :1", map[string]interface{}{"id": "1", "name": "user1", "ssn": "123-45-6789"}, "1"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if strings.Contains(stored, "123-45-6789") || !strings.Contains(stored, `"ssn":"$enc:v1:k1:`) || !strings.Contains(stored, `"name":"user1"`) {
+		t.Fatalf("%s failed: field must be encrypted before being sent, request body %s", name, stored)
+	}
+	var ssn string
+	if err := db.QueryRow("SELECT c.ssn FROM c WITH collection=coll").Scan(&ssn); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	} else if ssn != "123-45-6789" {
+		t.Fatalf("%s failed: field must be decrypted, received %#v", name, ssn)
+	}
+}
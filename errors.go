@@ -0,0 +1,113 @@
+package gocosmos
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// CosmosError is the error returned by the server for a request that failed with StatusCode>=400, see RestReponse.ApiErr.
+//
+// It wraps the sentinel error matching its status code (e.g. ErrNotFound for StatusCode=404), so it can be tested with
+// errors.Is, and can be extracted with errors.As to inspect its details:
+//
+//     var cosmosErr *gocosmos.CosmosError
+//     if errors.As(err, &cosmosErr) && cosmosErr.ResourceType == "Document" {
+//         // the document does not exist (but its database/collection do)
+//     }
+//
+// Available since v0.1.1
+type CosmosError struct {
+	StatusCode    int           // HTTP status code of the response
+	SubStatusCode int           // sub-status code of the response, from header x-ms-substatus (0 if not present)
+	Code          string        // error code from the response body, e.g. "NotFound"
+	Message       string        // error message from the response body
+	ActivityID    string        // activity id of the request, from header x-ms-activity-id
+	RetryAfter    time.Duration // time to wait before retrying the request, from header x-ms-retry-after-ms (0 if not present)
+	ResourceType  string        // type of the resource the error refers to as reported by the server, e.g. "Document" or "Collection"
+	Body          []byte        // raw response body
+}
+
+// Error implements error.Error.
+//
+// (since v0.1.1) The activity id of the request, if any, is appended so that the error can be correlated with the
+// server's logs (e.g. when contacting Azure support).
+func (e *CosmosError) Error() string {
+	msg := fmt.Sprintf("error executing Azure CosmosDB command; StatusCode=%d;Body=%s", e.StatusCode, e.Body)
+	if e.ActivityID != "" {
+		msg += ";ActivityId=" + e.ActivityID
+	}
+	return msg
+}
+
+// statusErrors maps status codes to sentinel errors.
+var statusErrors = map[int]error{
+	400: ErrBadRequest,
+	401: ErrUnauthorized,
+	403: ErrForbidden,
+	404: ErrNotFound,
+	408: ErrTimeout,
+	409: ErrConflict,
+	412: ErrPreconditionFailed,
+	413: ErrRequestEntityTooLarge,
+	429: ErrTooManyRequests,
+	503: ErrServiceUnavailable,
+}
+
+// Unwrap returns the sentinel error matching the error's status code, nil if none.
+func (e *CosmosError) Unwrap() error {
+	return statusErrors[e.StatusCode]
+}
+
+var (
+	reScanRequired = regexp.MustCompile(`(?i)excluded from indexing|allow scan|enable scan`)
+	reServerless   = regexp.MustCompile(`(?i)(offers|offer throughput or autopilot on \w+) is not supported for serverless accounts`)
+)
+
+// Is returns true if target is ErrScanRequired and the error reports a query filtering on paths excluded from indexing,
+// or if target is ErrServerless and the error message is the one returned for throughput requests on a serverless account
+// (e.g. "Reading or replacing offers is not supported for serverless accounts.").
+func (e *CosmosError) Is(target error) bool {
+	if e.StatusCode != 400 {
+		return false
+	}
+	switch target {
+	case ErrScanRequired:
+		return reScanRequired.Match(e.Body)
+	case ErrServerless:
+		return reServerless.MatchString(e.Message)
+	}
+	return false
+}
+
+var reResourceType = regexp.MustCompile(`ResourceType: (\w+)`)
+
+// newCosmosError builds a CosmosError from the response's status code, headers (keys in upper case) and body.
+func newCosmosError(statusCode int, header map[string]string, body []byte) *CosmosError {
+	err := &CosmosError{StatusCode: statusCode, ActivityID: header["X-MS-ACTIVITY-ID"], Body: body}
+	err.SubStatusCode, _ = strconv.Atoi(header["X-MS-SUBSTATUS"])
+	if retryAfterMs, e := strconv.ParseFloat(header["X-MS-RETRY-AFTER-MS"], 64); e == nil {
+		err.RetryAfter = time.Duration(retryAfterMs * float64(time.Millisecond))
+	}
+	var errBody struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &errBody) == nil {
+		err.Code, err.Message = errBody.Code, errBody.Message
+	}
+	if groups := reResourceType.FindSubmatch(body); groups != nil {
+		err.ResourceType = string(groups[1])
+	}
+	return err
+}
+
+// _isDocumentNotFound returns true if err reports that the target document does not exist, as opposed to its database
+// or collection.
+func _isDocumentNotFound(err error) bool {
+	var cosmosErr *CosmosError
+	return errors.As(err, &cosmosErr) && cosmosErr.StatusCode == 404 && cosmosErr.ResourceType == "Document"
+}
@@ -0,0 +1,107 @@
+package gocosmos
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCosmosError(t *testing.T) {
+	name := "TestCosmosError"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Ms-Activity-Id", "activity-1")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/docs/throttled"):
+			w.Header().Set("X-Ms-Retry-After-Ms", "1500")
+			w.Header().Set("X-Ms-Substatus", "3200")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"code":"TooManyRequests","message":"Request rate is large"}`))
+		case strings.Contains(r.URL.Path, "/colls/notexist"):
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":"NotFound","message":"Resource Not Found. ResourceType: Collection"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":"NotFound","message":"Entity with the specified id does not exist in the system. ResourceType: Document"}`))
+		}
+	}))
+	defer server.Close()
+	connStr := "AccountEndpoint=" + server.URL + ";AccountKey=cHJpbWFyeQ=="
+	client, _ := NewRestClient(nil, connStr)
+
+	result := client.GetDocument(DocReq{DbName: "mydb", CollName: "mycoll", DocId: "1", PartitionKeyValues: []interface{}{"1"}})
+	var cosmosErr *CosmosError
+	if !errors.As(result.Error(), &cosmosErr) || !errors.Is(result.Error(), ErrNotFound) {
+		t.Fatalf("%s failed: expected CosmosError wrapping ErrNotFound but received %#v", name, result.Error())
+	}
+	if cosmosErr.StatusCode != 404 || cosmosErr.Code != "NotFound" || cosmosErr.ResourceType != "Document" || cosmosErr.ActivityID != "activity-1" {
+		t.Fatalf("%s failed: unexpected error %#v", name, cosmosErr)
+	}
+	if !strings.HasPrefix(cosmosErr.Error(), "error executing Azure CosmosDB command; StatusCode=404;Body=") {
+		t.Fatalf("%s failed: unexpected error message %s", name, cosmosErr)
+	}
+
+	result = client.GetDocument(DocReq{DbName: "mydb", CollName: "mycoll", DocId: "throttled", PartitionKeyValues: []interface{}{"1"}})
+	if !errors.As(result.Error(), &cosmosErr) || cosmosErr.StatusCode != 429 || cosmosErr.SubStatusCode != 3200 ||
+		cosmosErr.RetryAfter != 1500*time.Millisecond || cosmosErr.Message != "Request rate is large" {
+		t.Fatalf("%s failed: unexpected error %#v", name, result.Error())
+	}
+
+	db, _ := sql.Open("gocosmos", connStr)
+	defer db.Close()
+	if result, err := db.Exec("DELETE FROM mydb.mycoll WHERE id=:1", "1", "1"); err != nil {
+		t.Fatalf("%s failed: deleting a non-existing document must succeed but received %s", name, err)
+	} else if affected, _ := result.RowsAffected(); affected != 0 {
+		t.Fatalf("%s failed: expected 0 rows affected but received %d", name, affected)
+	}
+	if _, err := db.Exec("DELETE FROM mydb.notexist WHERE id=:1", "1", "1"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("%s failed: expected ErrNotFound for non-existing collection but received %#v", name, err)
+	}
+}
+
+func TestCosmosError_Unwrap(t *testing.T) {
+	name := "TestCosmosError_Unwrap"
+	for statusCode, expected := range map[int]error{400: ErrBadRequest, 401: ErrUnauthorized, 403: ErrForbidden,
+		404: ErrNotFound, 408: ErrTimeout, 409: ErrConflict, 412: ErrPreconditionFailed, 413: ErrRequestEntityTooLarge,
+		429: ErrTooManyRequests, 503: ErrServiceUnavailable} {
+		if err := newCosmosError(statusCode, nil, nil); !errors.Is(err, expected) {
+			t.Fatalf("%s failed: <%d> expected %s but received %s", name, statusCode, expected, err.Unwrap())
+		}
+	}
+	if err := newCosmosError(500, nil, nil); err.Unwrap() != nil {
+		t.Fatalf("%s failed: expected no sentinel error for StatusCode=500 but received %s", name, err.Unwrap())
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Ms-Retry-After-Ms", "10")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"code":"TooManyRequests","message":"Request rate is large"}`))
+	}))
+	defer server.Close()
+	db, _ := sql.Open("gocosmos", "AccountEndpoint="+server.URL+";AccountKey=cHJpbWFyeQ==")
+	defer db.Close()
+	for _, query := range []string{
+		"CREATE DATABASE mydb",
+		"DROP COLLECTION mydb.mycoll",
+		"LIST DATABASES",
+		"INSERT INTO mydb.mycoll (id) VALUES (:1)",
+		"DELETE FROM mydb.mycoll WHERE id=:1",
+		"SELECT * FROM c WITH db=mydb WITH collection=mycoll",
+	} {
+		var err error
+		if strings.HasPrefix(query, "SELECT") || strings.HasPrefix(query, "LIST") {
+			_, err = db.Query(query)
+		} else if strings.Contains(query, ":1") {
+			_, err = db.Exec(query, "1", "1")
+		} else {
+			_, err = db.Exec(query)
+		}
+		var cosmosErr *CosmosError
+		if !errors.Is(err, ErrTooManyRequests) || !errors.As(err, &cosmosErr) || cosmosErr.RetryAfter != 10*time.Millisecond {
+			t.Fatalf("%s failed: <%s> expected ErrTooManyRequests but received %#v", name, query, err)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package gocosmos
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io"
+)
+
+// ExportRowsNdjson streams rows returned from a query (e.g. SELECT or SELECT CHANGES) executed by this driver to w as
+// newline-delimited JSON, one document per line.
+//
+// Column values are written as-is, so nested documents/arrays keep their structure instead of being flattened.
+// This function reads all remaining rows but does not close rows; the caller is responsible for closing it.
+// The number of exported rows is returned.
+//
+// Available since v0.1.1
+func ExportRowsNdjson(rows *sql.Rows, w io.Writer) (int, error) {
+	return _exportRows(rows, func(i int, doc map[string]interface{}) error {
+		js, err := json.Marshal(doc)
+		if err == nil {
+			_, err = w.Write(append(js, '\n'))
+		}
+		return err
+	})
+}
+
+// ExportRowsJsonArray streams rows returned from a query executed by this driver to w as a JSON array of documents.
+// See ExportRowsNdjson for details.
+//
+// Available since v0.1.1
+func ExportRowsJsonArray(rows *sql.Rows, w io.Writer) (int, error) {
+	if _, err := w.Write([]byte{'['}); err != nil {
+		return 0, err
+	}
+	n, err := _exportRows(rows, func(i int, doc map[string]interface{}) error {
+		js, err := json.Marshal(doc)
+		if err == nil && i > 0 {
+			_, err = w.Write([]byte{','})
+		}
+		if err == nil {
+			_, err = w.Write(js)
+		}
+		return err
+	})
+	if err != nil {
+		return n, err
+	}
+	_, err = w.Write([]byte{']'})
+	return n, err
+}
+
+func _exportRows(rows *sql.Rows, writeDoc func(i int, doc map[string]interface{}) error) (int, error) {
+	cols, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	vals := make([]interface{}, len(cols))
+	scanVals := make([]interface{}, len(cols))
+	for i := range vals {
+		scanVals[i] = &vals[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(scanVals...); err != nil {
+			return n, err
+		}
+		doc := make(map[string]interface{}, len(cols))
+		for i, col := range cols {
+			doc[col] = vals[i]
+		}
+		if err := writeDoc(n, doc); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, rows.Err()
+}
@@ -0,0 +1,64 @@
+package gocosmos
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+// _exportTestDriver is a minimal driver that returns a fixed set of documents, used to test export helpers without a server.
+type _exportTestDriver struct{ docs []DocInfo }
+
+func (d *_exportTestDriver) Open(_ string) (driver.Conn, error) { return d, nil }
+func (d *_exportTestDriver) Prepare(_ string) (driver.Stmt, error) {
+	return d, nil
+}
+func (d *_exportTestDriver) Close() error              { return nil }
+func (d *_exportTestDriver) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+func (d *_exportTestDriver) NumInput() int             { return 0 }
+func (d *_exportTestDriver) Exec(_ []driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+func (d *_exportTestDriver) Query(_ []driver.Value) (driver.Rows, error) {
+	return newResultSelect(d.docs), nil
+}
+
+func init() {
+	sql.Register("gocosmos-export-test", &_exportTestDriver{docs: []DocInfo{
+		{"id": "1", "name": map[string]interface{}{"first": "Thanh", "last": "Nguyen"}, "tags": []interface{}{"a", "b"}},
+		{"id": "2", "name": map[string]interface{}{"first": "Foo"}, "tags": nil},
+	}})
+}
+
+func TestExportRows(t *testing.T) {
+	name := "TestExportRows"
+	db, err := sql.Open("gocosmos-export-test", "")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	defer db.Close()
+
+	rows, _ := db.Query("SELECT")
+	buf := &bytes.Buffer{}
+	if n, err := ExportRowsNdjson(rows, buf); err != nil || n != 2 {
+		t.Fatalf("%s failed: %d/%s", name, n, err)
+	}
+	rows.Close()
+	expected := `{"id":"1","name":{"first":"Thanh","last":"Nguyen"},"tags":["a","b"]}` + "\n" + `{"id":"2","name":{"first":"Foo"},"tags":null}` + "\n"
+	if buf.String() != expected {
+		t.Fatalf("%s failed: expected %s but received %s", name, expected, buf.String())
+	}
+
+	rows, _ = db.Query("SELECT")
+	buf.Reset()
+	if n, err := ExportRowsJsonArray(rows, buf); err != nil || n != 2 {
+		t.Fatalf("%s failed: %d/%s", name, n, err)
+	}
+	rows.Close()
+	expected = `[{"id":"1","name":{"first":"Thanh","last":"Nguyen"},"tags":["a","b"]},{"id":"2","name":{"first":"Foo"},"tags":null}]`
+	if buf.String() != expected {
+		t.Fatalf("%s failed: expected %s but received %s", name, expected, buf.String())
+	}
+}
@@ -0,0 +1,44 @@
+package gocosmos
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/btnguyen2k/consu/gjrc"
+)
+
+// _isIntegratedCacheRead returns true if the request can be served by the integrated cache of a dedicated gateway:
+// point reads and queries of documents. Change feed requests are not cached.
+func _isIntegratedCacheRead(req *http.Request) bool {
+	operation, resType := _requestOperation(req)
+	return resType == "docs" && (operation == "Read" || operation == "Query")
+}
+
+// applyIntegratedCache sets header x-ms-dedicatedgateway-max-age of the request if it can be served by the integrated
+// cache (see MaxIntegratedCacheStalenessMs in NewRestClient), and returns true if the request is to be sent to the
+// dedicated gateway endpoint.
+func (c *RestClient) applyIntegratedCache(req *http.Request) bool {
+	if !_isIntegratedCacheRead(req) {
+		return false
+	}
+	if c.cacheStaleness > 0 {
+		req.Header.Set("X-Ms-Dedicatedgateway-Max-Age", strconv.FormatInt(c.cacheStaleness.Milliseconds(), 10))
+	}
+	return c.dedicatedGateway != ""
+}
+
+// doDedicated sends the request to the dedicated gateway endpoint. nil is returned if the gateway is not reachable
+// (or unavailable), so that the request is sent to the standard endpoint instead.
+func (c *RestClient) doDedicated(req *http.Request) *gjrc.GjrcResponse {
+	routedReq, err := _routeRequest(req, c.dedicatedGateway)
+	if err != nil {
+		return nil
+	}
+	resp := c.doWithKeys(routedReq)
+	if _isRegionalFailure(resp) && req.Context().Err() == nil {
+		c.log(LogLevelWarn, "dedicated gateway failed, falling back to the standard endpoint",
+			map[string]interface{}{"endpoint": c.dedicatedGateway, "path": req.URL.Path})
+		return nil
+	}
+	return resp
+}
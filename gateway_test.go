@@ -0,0 +1,107 @@
+package gocosmos
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDedicatedGateway(t *testing.T) {
+	name := "TestDedicatedGateway"
+	var lock sync.Mutex
+	var received []string
+	handler := func(server string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			received = append(received, server+" "+r.Method+" "+r.URL.Path+" max-age="+r.Header.Get("X-Ms-Dedicatedgateway-Max-Age"))
+			lock.Unlock()
+			if server == "gateway" {
+				w.Header().Set("X-Ms-Cosmos-Cachehit", "True")
+			}
+			switch {
+			case r.Header.Get("X-Ms-Documentdb-Isquery") == "true":
+				w.Write([]byte(`{"_count":1,"Documents":[{"id":"1"}]}`))
+			case r.Method == http.MethodPost:
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte(`{"id":"1"}`))
+			default:
+				w.Write([]byte(`{"id":"1"}`))
+			}
+		}
+	}
+	standard := httptest.NewServer(handler("standard"))
+	defer standard.Close()
+	gateway := httptest.NewServer(handler("gateway"))
+	defer gateway.Close()
+	lastRequest := func() string {
+		lock.Lock()
+		defer lock.Unlock()
+		last := received[len(received)-1]
+		received = nil
+		return last
+	}
+
+	client, err := NewRestClient(nil, "AccountEndpoint="+standard.URL+";AccountKey=cHJpbWFyeQ==;DedicatedGatewayEndpoint="+
+		gateway.URL+";MaxIntegratedCacheStalenessMs=60000")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	diag := NewDiagnostics()
+	client = client.WithContext(WithDiagnostics(context.Background(), diag))
+	if result := client.GetDocument(DocReq{DbName: "db", CollName: "coll", DocId: "1"}); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	}
+	if last := lastRequest(); last != "gateway GET /dbs/db/colls/coll/docs/1 max-age=60000" {
+		t.Fatalf("%s failed: point reads must be sent to the dedicated gateway, received %s", name, last)
+	}
+	if attempts := diag.Attempts(); len(attempts) != 1 || !attempts[0].CacheHit {
+		t.Fatalf("%s failed: cache hit must be recorded in diagnostics, received %#v", name, attempts)
+	}
+	if result := client.QueryDocuments(QueryReq{DbName: "db", CollName: "coll", Query: "SELECT * FROM c"}); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	}
+	if last := lastRequest(); last != "gateway POST /dbs/db/colls/coll/docs max-age=60000" {
+		t.Fatalf("%s failed: queries must be sent to the dedicated gateway, received %s", name, last)
+	}
+
+	diag.Reset()
+	spec := DocumentSpec{DbName: "db", CollName: "coll", PartitionKeyValues: []interface{}{"1"}, DocumentData: map[string]interface{}{"id": "1"}}
+	if result := client.CreateDocument(spec); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	}
+	if last := lastRequest(); last != "standard POST /dbs/db/colls/coll/docs max-age=" {
+		t.Fatalf("%s failed: writes must be sent to the standard endpoint, received %s", name, last)
+	}
+	if client.GetCollection("db", "coll"); lastRequest() != "standard GET /dbs/db/colls/coll max-age=" {
+		t.Fatalf("%s failed: metadata requests must be sent to the standard endpoint", name)
+	}
+	if attempts := diag.Attempts(); len(attempts) != 2 || attempts[0].CacheHit || attempts[1].CacheHit {
+		t.Fatalf("%s failed: unexpected cache hit %#v", name, attempts)
+	}
+
+	// reads fall back to the standard endpoint if the dedicated gateway is unavailable
+	gateway.Close()
+	if result := client.GetDocument(DocReq{DbName: "db", CollName: "coll", DocId: "1"}); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	}
+	if last := lastRequest(); last != "standard GET /dbs/db/colls/coll/docs/1 max-age=60000" {
+		t.Fatalf("%s failed: reads must fall back to the standard endpoint, received %s", name, last)
+	}
+
+	for _, connStr := range []string{
+		"AccountEndpoint=" + standard.URL + ";AccountKey=cHJpbWFyeQ==;DedicatedGatewayEndpoint=myaccount.sqlx.cosmos.azure.com",
+		"AccountEndpoint=" + standard.URL + ";AccountKey=cHJpbWFyeQ==;MaxIntegratedCacheStalenessMs=0",
+	} {
+		if _, err := NewRestClient(nil, connStr); err == nil {
+			t.Fatalf("%s failed: connection string must be rejected: %s", name, connStr)
+		}
+	}
+	cfg, err := ParseDSN("AccountEndpoint=https://localhost:8081/;AccountKey=cHJpbWFyeQ==;" +
+		"DedicatedGatewayEndpoint=https://localhost:8082/;MaxIntegratedCacheStalenessMs=5000")
+	if err != nil || cfg.DedicatedGatewayEndpoint != "https://localhost:8082/" || cfg.MaxIntegratedCacheStaleness != 5*time.Second {
+		t.Fatalf("%s failed: unexpected config %#v / %s", name, cfg, err)
+	}
+}
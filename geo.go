@@ -0,0 +1,103 @@
+package gocosmos
+
+import (
+	"fmt"
+)
+
+// GeoJson is a GeoJSON geometry (Point, LineString, Polygon or MultiPolygon), to be stored in documents and passed as
+// parameter of geospatial functions such as ST_DISTANCE, ST_WITHIN or ST_INTERSECTS:
+//
+//     rows, err := db.Query(`SELECT * FROM c WHERE ST_DISTANCE(c.location, :1) < 3000`, gocosmos.GeoPoint(106.70, 10.77))
+//     rows, err := db.Query(`SELECT * FROM c WHERE ST_WITHIN(c.location, :1)`, gocosmos.GeoPolygon([][2]float64{
+//         {106.6, 10.7}, {106.8, 10.7}, {106.8, 10.9}, {106.6, 10.9},
+//     }))
+//
+// Positions are [longitude, latitude] pairs. GeoJSON-shaped maps and structs (e.g. with fields tagged `json:"type"` and
+// `json:"coordinates"`) are accepted as parameters too. GeoJson implements sql.Scanner, so that geospatial values of
+// query results can be scanned into it.
+//
+// Available since v0.1.1
+type GeoJson struct {
+	Type        string      `json:"type"`        // geometry type, e.g. "Point" or "Polygon"
+	Coordinates interface{} `json:"coordinates"` // position(s) of the geometry, nested according to Type
+}
+
+// GeoPoint builds a GeoJSON Point from its longitude and latitude.
+//
+// Available since v0.1.1
+func GeoPoint(lon, lat float64) GeoJson {
+	return GeoJson{Type: "Point", Coordinates: []float64{lon, lat}}
+}
+
+// GeoLineString builds a GeoJSON LineString from its positions ([longitude, latitude] pairs).
+//
+// Available since v0.1.1
+func GeoLineString(positions ...[2]float64) GeoJson {
+	return GeoJson{Type: "LineString", Coordinates: _geoPositions(positions)}
+}
+
+// GeoPolygon builds a GeoJSON Polygon from its rings of positions ([longitude, latitude] pairs): the exterior ring first,
+// then the holes, if any. Rings are closed automatically if their last position differs from the first one.
+//
+// Note: Cosmos DB requires positions of the exterior ring to be specified in counter-clockwise order; a polygon in
+// clockwise order covers the rest of the globe instead.
+//
+// Available since v0.1.1
+func GeoPolygon(rings ...[][2]float64) GeoJson {
+	coordinates := make([][][]float64, len(rings))
+	for i, ring := range rings {
+		if len(ring) > 0 && ring[0] != ring[len(ring)-1] {
+			ring = append(ring[:len(ring):len(ring)], ring[0])
+		}
+		coordinates[i] = _geoPositions(ring)
+	}
+	return GeoJson{Type: "Polygon", Coordinates: coordinates}
+}
+
+// _geoPositions converts positions to the nested slices of GeoJSON coordinates.
+func _geoPositions(positions [][2]float64) [][]float64 {
+	coordinates := make([][]float64, len(positions))
+	for i, pos := range positions {
+		coordinates[i] = []float64{pos[0], pos[1]}
+	}
+	return coordinates
+}
+
+// Point returns the longitude and latitude of a Point geometry, ok is false if g is not a valid Point.
+//
+// Available since v0.1.1
+func (g GeoJson) Point() (lon, lat float64, ok bool) {
+	if g.Type != "Point" {
+		return 0, 0, false
+	}
+	switch c := g.Coordinates.(type) {
+	case []float64:
+		if len(c) >= 2 {
+			return c[0], c[1], true
+		}
+	case []interface{}:
+		if len(c) >= 2 {
+			lon, okLon := c[0].(float64)
+			lat, okLat := c[1].(float64)
+			return lon, lat, okLon && okLat
+		}
+	}
+	return 0, 0, false
+}
+
+// Scan implements sql.Scanner.Scan.
+func (g *GeoJson) Scan(src interface{}) error {
+	if src == nil {
+		*g = GeoJson{}
+		return nil
+	}
+	var geo GeoJson
+	if err := ScanJson(src, &geo); err != nil {
+		return fmt.Errorf("cannot scan %T into GeoJson: %s", src, err)
+	}
+	if geo.Type == "" {
+		return fmt.Errorf("cannot scan %T into GeoJson: missing geometry type", src)
+	}
+	*g = geo
+	return nil
+}
@@ -0,0 +1,86 @@
+package gocosmos
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGeoJson(t *testing.T) {
+	name := "TestGeoJson"
+	testData := map[string]GeoJson{
+		`{"type":"Point","coordinates":[106.7,10.77]}`:                 GeoPoint(106.7, 10.77),
+		`{"type":"LineString","coordinates":[[1,2],[3,4]]}`:            GeoLineString([2]float64{1, 2}, [2]float64{3, 4}),
+		`{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,0]]]}`: GeoPolygon([][2]float64{{0, 0}, {1, 0}, {1, 1}}),
+		`{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,0]],[[0.2,0.2],[0.5,0.2],[0.5,0.5],[0.2,0.2]]]}`: GeoPolygon(
+			[][2]float64{{0, 0}, {1, 0}, {1, 1}, {0, 0}}, [][2]float64{{0.2, 0.2}, {0.5, 0.2}, {0.5, 0.5}}),
+	}
+	for expected, geo := range testData {
+		if js, err := json.Marshal(geo); err != nil || string(js) != expected {
+			t.Fatalf("%s failed: expected %s but received %s / %s", name, expected, js, err)
+		}
+	}
+
+	ring := [][2]float64{{0, 0}, {1, 0}, {1, 1}}
+	GeoPolygon(ring)
+	if len(ring) != 3 {
+		t.Fatalf("%s failed: the supplied ring must not be modified", name)
+	}
+
+	var geo GeoJson
+	if err := geo.Scan(map[string]interface{}{"type": "Point", "coordinates": []interface{}{106.7, 10.77}}); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	} else if lon, lat, ok := geo.Point(); !ok || lon != 106.7 || lat != 10.77 {
+		t.Fatalf("%s failed: unexpected point %v, %v (%v)", name, lon, lat, ok)
+	}
+	if _, _, ok := GeoPoint(1, 2).Point(); !ok {
+		t.Fatalf("%s failed: GeoPoint must be a valid point", name)
+	}
+	if _, _, ok := GeoLineString().Point(); ok {
+		t.Fatalf("%s failed: LineString is not a point", name)
+	}
+	if err := geo.Scan(`{"coordinates":[1,2]}`); err == nil {
+		t.Fatalf("%s failed: value without geometry type must be rejected", name)
+	}
+	if err := geo.Scan(nil); err != nil || geo.Type != "" {
+		t.Fatalf("%s failed: nil must reset the value, received %#v / %s", name, geo, err)
+	}
+}
+
+func TestGeoJson_Params(t *testing.T) {
+	name := "TestGeoJson_Params"
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		body = string(data)
+		w.Write([]byte(`{"_count":1,"Documents":[{"location":{"type":"Point","coordinates":[106.7,10.77]}}]}`))
+	}))
+	defer server.Close()
+	db, _ := sql.Open("gocosmos", "AccountEndpoint="+server.URL+";AccountKey=cHJpbWFyeQ==;DefaultDb=db")
+	defer db.Close()
+
+	type point struct {
+		Type        string    `json:"type"`
+		Coordinates []float64 `json:"coordinates"`
+	}
+	for _, param := range []interface{}{
+		GeoPoint(106.7, 10.77),
+		map[string]interface{}{"type": "Point", "coordinates": []float64{106.7, 10.77}},
+		point{Type: "Point", Coordinates: []float64{106.7, 10.77}},
+	} {
+		var location GeoJson
+		if err := db.QueryRow("SELECT c.location FROM c WHERE ST_DISTANCE(c.location, :1) < 3000", param).Scan(&location); err != nil {
+			t.Fatalf("%s failed: %s", name, err)
+		}
+		if !strings.Contains(body, `"value":{"coordinates":[106.7,10.77],"type":"Point"}`) && !strings.Contains(body, `"value":{"type":"Point","coordinates":[106.7,10.77]}`) {
+			t.Fatalf("%s failed: <%T> parameter must be sent as GeoJSON, request body %s", name, param, body)
+		}
+		if lon, lat, ok := location.Point(); !ok || lon != 106.7 || lat != 10.77 {
+			t.Fatalf("%s failed: unexpected location %#v", name, location)
+		}
+	}
+}
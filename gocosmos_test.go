@@ -3,7 +3,13 @@ package gocosmos
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"regexp"
 	"strconv"
@@ -48,6 +54,25 @@ func TestDriver_invalidConnectionString(t *testing.T) {
 	}
 }
 
+func TestDriver_invalidDriverOptions(t *testing.T) {
+	name := "TestDriver_invalidDriverOptions"
+	dsn := "AccountEndpoint=https://localhost:8081/;AccountKey=cHJpbWFyeQ=="
+	for _, opt := range []string{"AutoPartitionKey=abc", "ObjectsAsJson=abc", "ParseTime=abc", "Flatten=maybe",
+		"SharedSessionTokens=abc", "TimeFormat=unix", "StmtCacheSize=-1", "MaxParallelism=0", "PrefetchPages=x"} {
+		// options of the database/sql driver are validated when a connection is opened, not by the REST client
+		if _, err := NewRestClient(nil, dsn+";"+opt); err != nil {
+			t.Fatalf("%s failed: <%s> must be ignored by the REST client, received %s", name, opt, err)
+		}
+		if _, err := (&Driver{}).Open(dsn + ";" + opt); err == nil {
+			t.Fatalf("%s failed: <%s> must be rejected by the driver", name, opt)
+		}
+		connector, _ := NewConnectorFromConnStr(dsn + ";" + opt)
+		if _, err := connector.Connect(context.Background()); err == nil {
+			t.Fatalf("%s failed: <%s> must be rejected by the connector", name, opt)
+		}
+	}
+}
+
 func TestDriver_missingEndpoint(t *testing.T) {
 	name := "TestDriver_missingEndpoint"
 	driver := "gocosmos"
@@ -162,7 +187,7 @@ func Test_Exec_CreateDatabase(t *testing.T) {
 
 	// second creation should return ErrConflict
 	_, err = db.Exec("CREATE DATABASE dbtemp WITH ru=400")
-	if err != ErrConflict {
+	if !errors.Is(err, ErrConflict) {
 		t.Fatalf("%s failed: expected ErrConflict but received %#v", name, err)
 	}
 
@@ -190,6 +215,33 @@ func Test_Exec_CreateDatabase(t *testing.T) {
 	}
 }
 
+func Test_Exec_CreateDatabaseAutoscale(t *testing.T) {
+	name := "Test_Exec_CreateDatabaseAutoscale"
+	db := _openDb(t, name)
+	client := _newRestClient(t, name)
+
+	db.Exec("DROP DATABASE IF EXISTS dbtemp")
+	if _, err := db.Exec("CREATE DATABASE dbtemp WITH maxru=4000"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	dbInfo := client.GetDatabase("dbtemp")
+	if offer := client.GetOfferForResource(dbInfo.Rid); offer.Error() != nil {
+		t.Fatalf("%s failed: %s", name, offer.Error())
+	} else if !offer.IsAutopilot() || offer.AutopilotMaxThroughput() != 4000 {
+		t.Fatalf("%s failed: <maxru> expected %#v but received %#v", name, 4000, offer.AutopilotMaxThroughput())
+	}
+
+	if _, err := db.Exec("CREATE COLLECTION dbtemp.tbltemp WITH pk=/id WITH maxru=5000"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	collInfo := client.GetCollection("dbtemp", "tbltemp")
+	if offer := client.GetOfferForResource(collInfo.Rid); offer.Error() != nil {
+		t.Fatalf("%s failed: %s", name, offer.Error())
+	} else if !offer.IsAutopilot() || offer.AutopilotMaxThroughput() != 5000 {
+		t.Fatalf("%s failed: <maxru> expected %#v but received %#v", name, 5000, offer.AutopilotMaxThroughput())
+	}
+}
+
 func Test_Query_DropDatabase(t *testing.T) {
 	name := "Test_Query_DropDatabase"
 	db := _openDb(t, name)
@@ -213,7 +265,7 @@ func Test_Exec_DropDatabase(t *testing.T) {
 
 	// second drop should return ErrNotFound
 	_, err = db.Exec("DROP DATABASE dbtemp")
-	if err != ErrNotFound {
+	if !errors.Is(err, ErrNotFound) {
 		t.Fatalf("%s failed: expected ErrNotFound but received %#v", name, err)
 	}
 
@@ -317,7 +369,7 @@ func Test_Exec_CreateCollection(t *testing.T) {
 
 	// second creation should return ErrConflict
 	_, err = db.Exec("CREATE COLLECTION dbtemp.tbltemp WITH pk=/id WITH ru=400")
-	if err != ErrConflict {
+	if !errors.Is(err, ErrConflict) {
 		t.Fatalf("%s failed: expected ErrConflict but received %#v", name, err)
 	}
 
@@ -345,7 +397,7 @@ func Test_Exec_CreateCollection(t *testing.T) {
 	}
 
 	_, err = db.Exec(`CREATE COLLECTION db_not_exists.table WITH pk=/a`)
-	if err != ErrNotFound {
+	if !errors.Is(err, ErrNotFound) {
 		t.Fatalf("%s failed: expected ErrNotFound but received %#v", name, err)
 	}
 }
@@ -374,7 +426,7 @@ func Test_Exec_CreateCollectionDefaultDb(t *testing.T) {
 
 	// second creation should return ErrConflict
 	_, err = db.Exec("CREATE COLLECTION tbltemp WITH pk=/id WITH ru=400")
-	if err != ErrConflict {
+	if !errors.Is(err, ErrConflict) {
 		t.Fatalf("%s failed: expected ErrConflict but received %#v", name, err)
 	}
 
@@ -391,6 +443,162 @@ func Test_Exec_CreateCollectionDefaultDb(t *testing.T) {
 	}
 }
 
+func Test_Exec_CreateCollectionIndexingPolicy(t *testing.T) {
+	name := "Test_Exec_CreateCollectionIndexingPolicy"
+	db := _openDb(t, name)
+	client := _newRestClient(t, name)
+
+	db.Exec("DROP DATABASE IF EXISTS dbtemp")
+	db.Exec("CREATE DATABASE IF NOT EXISTS dbtemp")
+
+	_, err := db.Exec("CREATE COLLECTION dbtemp.tbltemp WITH pk=/id WITH indexing_mode=consistent WITH include=/name/? WITH exclude=/*")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if result := client.GetCollection("dbtemp", "tbltemp"); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	} else if mode := result.IndexingPolicy["indexingMode"]; mode != "consistent" {
+		t.Fatalf("%s failed: <indexingMode> expected %#v but received %#v", name, "consistent", mode)
+	} else if paths, _ := result.IndexingPolicy["excludedPaths"].([]interface{}); len(paths) < 1 {
+		t.Fatalf("%s failed: <excludedPaths> expected non-empty but received %#v", name, result.IndexingPolicy["excludedPaths"])
+	}
+}
+
+func Test_Exec_CreateCollectionSpatial(t *testing.T) {
+	name := "Test_Exec_CreateCollectionSpatial"
+	db := _openDb(t, name)
+	client := _newRestClient(t, name)
+
+	db.Exec("DROP DATABASE IF EXISTS dbtemp")
+	db.Exec("CREATE DATABASE IF NOT EXISTS dbtemp")
+
+	_, err := db.Exec("CREATE COLLECTION dbtemp.tbltemp WITH pk=/id WITH spatial=/location/*:point,polygon WITH geospatial=geography")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if result := client.GetCollection("dbtemp", "tbltemp"); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	} else if indexes, _ := result.IndexingPolicy["spatialIndexes"].([]interface{}); len(indexes) != 1 {
+		t.Fatalf("%s failed: <spatialIndexes> expected 1 spatial index but received %#v", name, result.IndexingPolicy["spatialIndexes"])
+	} else if typ := result.GeospatialConfig["type"]; typ != "Geography" {
+		t.Fatalf("%s failed: <geospatialConfig> expected %#v but received %#v", name, "Geography", typ)
+	}
+}
+
+func Test_Query_SelectGeospatial(t *testing.T) {
+	name := "Test_Query_SelectGeospatial"
+	db := _openDb(t, name)
+
+	db.Exec("DROP DATABASE IF EXISTS dbtemp")
+	db.Exec("CREATE DATABASE IF NOT EXISTS dbtemp")
+	if _, err := db.Exec("CREATE COLLECTION dbtemp.tbltemp WITH pk=/id WITH spatial=/location/*:point,polygon"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	locations := map[string]GeoJson{
+		"hcmc":  GeoPoint(106.70, 10.77),
+		"hanoi": GeoPoint(105.85, 21.03),
+	}
+	for id, location := range locations {
+		doc := map[string]interface{}{"id": id, "location": location}
+		if _, err := db.Exec("INSERT INTO dbtemp.tbltemp JSON :1", doc, id); err != nil {
+			t.Fatalf("%s failed: %s", name, err)
+		}
+	}
+
+	queryIds := func(query string, args ...interface{}) []string {
+		dbRows, err := db.Query(query, args...)
+		if err != nil {
+			t.Fatalf("%s failed: %s", name, err)
+		}
+		defer dbRows.Close()
+		ids := make([]string, 0)
+		for dbRows.Next() {
+			var id string
+			var location GeoJson
+			if err := dbRows.Scan(&id, &location); err != nil {
+				t.Fatalf("%s failed: %s", name, err)
+			}
+			lon, lat, ok := location.Point()
+			if expectedLon, expectedLat, _ := locations[id].Point(); !ok || lon != expectedLon || lat != expectedLat {
+				t.Fatalf("%s failed: unexpected location %#v of <%s>", name, location, id)
+			}
+			ids = append(ids, id)
+		}
+		return ids
+	}
+
+	// ~2km from hcmc, ~1100km from hanoi
+	near := GeoPoint(106.69, 10.78)
+	if ids := queryIds("SELECT CROSS PARTITION c.id, c.location FROM tbltemp c WHERE ST_DISTANCE(c.location, :1) < 5000 WITH database=dbtemp", near); len(ids) != 1 || ids[0] != "hcmc" {
+		t.Fatalf("%s failed: expected [hcmc] but received %#v", name, ids)
+	}
+	south := GeoPolygon([][2]float64{{100, 5}, {110, 5}, {110, 15}, {100, 15}})
+	if ids := queryIds("SELECT CROSS PARTITION c.id, c.location FROM tbltemp c WHERE ST_WITHIN(c.location, :1) WITH database=dbtemp", south); len(ids) != 1 || ids[0] != "hcmc" {
+		t.Fatalf("%s failed: expected [hcmc] but received %#v", name, ids)
+	}
+}
+
+func Test_Exec_AlterCollection(t *testing.T) {
+	name := "Test_Exec_AlterCollection"
+	db := _openDb(t, name)
+	client := _newRestClient(t, name)
+
+	db.Exec("DROP DATABASE IF EXISTS dbtemp")
+	db.Exec("CREATE DATABASE IF NOT EXISTS dbtemp")
+	db.Exec("CREATE COLLECTION dbtemp.tbltemp WITH pk=/id WITH ru=400")
+
+	if result, err := db.Exec("ALTER COLLECTION dbtemp.tbltemp WITH ru=500"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	} else if numRows, err := result.RowsAffected(); numRows != 1 || err != nil {
+		t.Fatalf("%s failed: expected RowsAffected=1/err=nil but received RowsAffected=%d/err=%s", name, numRows, err)
+	}
+	collInfo := client.GetCollection("dbtemp", "tbltemp")
+	if offer := client.GetOfferForResource(collInfo.Rid); offer.Error() != nil {
+		t.Fatalf("%s failed: %s", name, offer.Error())
+	} else if ru := offer.OfferThroughput(); ru != 500 {
+		t.Fatalf("%s failed: <ru> expected %#v but received %#v", name, 500, ru)
+	}
+
+	if _, err := db.Exec("ALTER TABLE dbtemp.tbltemp WITH maxru=4000"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if offer := client.GetOfferForResource(collInfo.Rid); offer.Error() != nil {
+		t.Fatalf("%s failed: %s", name, offer.Error())
+	} else if maxru := offer.AutopilotMaxThroughput(); maxru != 4000 {
+		t.Fatalf("%s failed: <maxru> expected %#v but received %#v", name, 4000, maxru)
+	}
+
+	if _, err := db.Exec("ALTER TABLE dbtemp.tbltemp WITH composite=/name,/age:desc"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if result := client.GetCollection("dbtemp", "tbltemp"); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	} else if indexes, _ := result.IndexingPolicy["compositeIndexes"].([]interface{}); len(indexes) != 1 {
+		t.Fatalf("%s failed: <compositeIndexes> expected 1 composite index but received %#v", name, result.IndexingPolicy["compositeIndexes"])
+	}
+
+	if _, err := db.Exec("ALTER TABLE dbtemp.tbltemp WITH ttl=3600"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if result := client.GetCollection("dbtemp", "tbltemp"); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	} else if result.DefaultTtl != 3600 {
+		t.Fatalf("%s failed: <defaultTtl> expected %#v but received %#v", name, 3600, result.DefaultTtl)
+	}
+	if _, err := db.Exec("ALTER TABLE dbtemp.tbltemp WITH ttl=off"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if result := client.GetCollection("dbtemp", "tbltemp"); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	} else if result.DefaultTtl != 0 {
+		t.Fatalf("%s failed: <defaultTtl> expected %#v but received %#v", name, 0, result.DefaultTtl)
+	}
+
+	if _, err := db.Exec("ALTER COLLECTION dbtemp.tbl_not_found WITH ru=400"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("%s failed: expected ErrNotFound but received %#v", name, err)
+	}
+}
+
 func Test_Query_DropCollection(t *testing.T) {
 	name := "Test_Query_DropCollection"
 	db := _openDb(t, name)
@@ -415,7 +623,7 @@ func Test_Exec_DropCollection(t *testing.T) {
 
 	// second drop should return ErrNotFound
 	_, err = db.Exec("DROP COLLECTION dbtemp.tbltemp")
-	if err != ErrNotFound {
+	if !errors.Is(err, ErrNotFound) {
 		t.Fatalf("%s failed: expected ErrNotFound but received %#v", name, err)
 	}
 
@@ -485,7 +693,7 @@ func Test_Query_ListCollections(t *testing.T) {
 	}
 
 	_, err = db.Query("LIST COLLECTIONS FROM db_not_found")
-	if err != ErrNotFound {
+	if !errors.Is(err, ErrNotFound) {
 		t.Fatalf("%s failed: expected ErrNotFound but received %#v", name, err)
 	}
 }
@@ -526,26 +734,145 @@ func Test_Exec_Insert(t *testing.T) {
 		t.Fatalf("%s failed: expected RowsAffected=1/err=nil but received RowsAffected=%d/err=%s", name, numRows, err)
 	}
 
-	if _, err := db.Exec(`INSERT INTO dbtemp.tbltemp (id,username,email,grade,actived) VALUES ("\"1\"", "\"user\"", "\"user@domain2.com\"", 8, false)`, "user"); err != ErrConflict {
+	if _, err := db.Exec(`INSERT INTO dbtemp.tbltemp (id,username,email,grade,actived) VALUES ("\"1\"", "\"user\"", "\"user@domain2.com\"", 8, false)`, "user"); !errors.Is(err, ErrConflict) {
 		// duplicated id (in logical partition scope)
 		t.Fatalf("%s failed: expected ErrConflict but received %#v", name, err)
 	}
 
-	if _, err := db.Exec(`INSERT INTO dbtemp.tbltemp (id,username,email,grade,actived) VALUES ("\"2\"", "\"user\"", "\"user@domain1.com\"", 9, false)`, "user"); err != ErrConflict {
+	if _, err := db.Exec(`INSERT INTO dbtemp.tbltemp (id,username,email,grade,actived) VALUES ("\"2\"", "\"user\"", "\"user@domain1.com\"", 9, false)`, "user"); !errors.Is(err, ErrConflict) {
 		// duplicated unique index (in logical partition scope)
 		t.Fatalf("%s failed: expected ErrConflict but received %#v", name, err)
 	}
 
-	if _, err := db.Exec(`INSERT INTO db_not_exists.table (id,username,email) VALUES ("\"x\"", "\"y\"", "\"x\"")`, "y"); err != ErrNotFound {
+	if _, err := db.Exec(`INSERT INTO db_not_exists.table (id,username,email) VALUES ("\"x\"", "\"y\"", "\"x\"")`, "y"); !errors.Is(err, ErrNotFound) {
 		// database/table not found
 		t.Fatalf("%s failed: expected ErrNotFound but received %#v", name, err)
 	}
-	if _, err := db.Exec(`INSERT INTO dbtemp.tbl_not_found (id,username,email) VALUES ("\"x\"", "\"y\"", "\"x\"")`, "y"); err != ErrNotFound {
+	if _, err := db.Exec(`INSERT INTO dbtemp.tbl_not_found (id,username,email) VALUES ("\"x\"", "\"y\"", "\"x\"")`, "y"); !errors.Is(err, ErrNotFound) {
 		// database/table not found
 		t.Fatalf("%s failed: expected ErrNotFound but received %#v", name, err)
 	}
 }
 
+func Test_Exec_InsertGeneratedId(t *testing.T) {
+	name := "Test_Exec_InsertGeneratedId"
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			w.Write([]byte(`{"id":"coll","partitionKey":{"paths":["/username"],"kind":"Hash"}}`))
+			return
+		}
+		body = nil
+		json.NewDecoder(r.Body).Decode(&body)
+		body["_rid"] = "rid"
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(body)
+	}))
+	defer server.Close()
+	connStr := "AccountEndpoint=" + server.URL + ";AccountKey=C2y6yDjf5/R+ob0N8A7Cgv30VRDJIWEHLM+4QDU5DE2nQ9nDuVTqobD4b8mGGyPMbIZnqyMsEcaGQy67XIw/Jw=="
+	restClient, _ := NewRestClient(nil, connStr)
+	conn, _ := newConn(restClient)
+
+	reUuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	stmt, _ := conn.Prepare(`INSERT INTO db.coll (username,grade) VALUES (:1,:2)`)
+	if result, err := stmt.Exec([]driver.Value{"user", 1, "user"}); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	} else if id := result.(*ResultInsert).Id; !reUuid.MatchString(id) || body["id"] != id {
+		t.Fatalf("%s failed: expected generated UUID but received %#v (document id %#v)", name, id, body["id"])
+	}
+	stmt, _ = conn.Prepare(`INSERT INTO db.coll (id,username) VALUES (:1,:2)`)
+	if result, err := stmt.Exec([]driver.Value{"myid", "user", "user"}); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	} else if id := result.(*ResultInsert).Id; id != "myid" {
+		t.Fatalf("%s failed: <id> expected %#v but received %#v", name, "myid", id)
+	}
+
+	connector, _ := NewConnectorFromConnStr(connStr)
+	connector.SetIdGenerator(func() string { return "generated-id" })
+	db := sql.OpenDB(connector)
+	defer db.Close()
+	if _, err := db.Exec(`INSERT INTO db.coll (username) VALUES (:1)`, "user", "user"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	} else if body["id"] != "generated-id" {
+		t.Fatalf("%s failed: <id> expected %#v but received %#v", name, "generated-id", body["id"])
+	}
+}
+
+func Test_Exec_AutoPartitionKey(t *testing.T) {
+	name := "Test_Exec_AutoPartitionKey"
+	docs := make(map[string]map[string]interface{})
+	pkHeader := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pkHeader = r.Header.Get("X-Ms-Documentdb-PartitionKey")
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/dbs/db/colls/coll":
+			w.Write([]byte(`{"id":"coll","partitionKey":{"paths":["/username"],"kind":"Hash"}}`))
+		case !strings.HasPrefix(r.URL.Path, "/dbs/db/colls/coll"):
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == "POST" && r.Header.Get("X-Ms-Documentdb-Isquery") == "true":
+			var query map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&query)
+			id := query["parameters"].([]interface{})[0].(map[string]interface{})["value"].(string)
+			result := map[string]interface{}{"Documents": []interface{}{}}
+			if doc, ok := docs[id]; ok {
+				result["Documents"] = []interface{}{doc}
+			}
+			json.NewEncoder(w).Encode(result)
+		case r.Method == "POST":
+			var doc map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&doc)
+			doc["_etag"] = `"etag"`
+			docs[doc["id"].(string)] = doc
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(doc)
+		case r.Method == "PUT":
+			var doc map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&doc)
+			docs[doc["id"].(string)] = doc
+			json.NewEncoder(w).Encode(doc)
+		case r.Method == "DELETE":
+			delete(docs, strings.TrimPrefix(r.URL.Path, "/dbs/db/colls/coll/docs/"))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	connStr := "AccountEndpoint=" + server.URL + ";AccountKey=C2y6yDjf5/R+ob0N8A7Cgv30VRDJIWEHLM+4QDU5DE2nQ9nDuVTqobD4b8mGGyPMbIZnqyMsEcaGQy67XIw/Jw=="
+	if _, err := (&Driver{}).Open(connStr + ";AutoPartitionKey=abc"); err == nil {
+		t.Fatalf("%s failed: expected error for invalid AutoPartitionKey", name)
+	}
+	db, _ := sql.Open("gocosmos", connStr+";AutoPartitionKey=true")
+	defer db.Close()
+
+	if _, err := db.Exec(`INSERT INTO db.coll (id,username,grade) VALUES (:1,:2,:3)`, "1", "user", 1); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	} else if pkHeader != `["user"]` {
+		t.Fatalf("%s failed: <partition-key> expected %#v but received %#v", name, `["user"]`, pkHeader)
+	}
+	if _, err := db.Exec(`INSERT INTO db.coll (id,grade) VALUES (:1,:2)`, "2", 1); err == nil {
+		t.Fatalf("%s failed: expected error when partition key is missing from document", name)
+	}
+	if result, err := db.Exec(`UPDATE db.coll SET grade=:1 WHERE id=:2`, 2, "1"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	} else if numRows, _ := result.RowsAffected(); numRows != 1 || pkHeader != `["user"]` || docs["1"]["grade"] != 2.0 {
+		t.Fatalf("%s failed: expected document updated but received %#v / %#v", name, numRows, docs["1"])
+	}
+	if result, err := db.Exec(`UPDATE db.coll SET grade=:1 WHERE id=:2`, 2, "3"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	} else if numRows, _ := result.RowsAffected(); numRows != 0 {
+		t.Fatalf("%s failed: expected RowsAffected=0 but received %#v", name, numRows)
+	}
+	if result, err := db.Exec(`DELETE FROM db.coll WHERE id=:1`, "1"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	} else if numRows, _ := result.RowsAffected(); numRows != 1 || pkHeader != `["user"]` || docs["1"] != nil {
+		t.Fatalf("%s failed: expected document deleted but received %#v / %#v", name, numRows, docs["1"])
+	}
+	if _, err := db.Exec(`DELETE FROM db.notfound WHERE id=:1`, "1"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("%s failed: expected ErrNotFound but received %#v", name, err)
+	}
+}
+
 func Test_Exec_InsertPlaceholder(t *testing.T) {
 	name := "Test_Exec_InsertPlaceholder"
 	db := _openDb(t, name)
@@ -575,13 +902,13 @@ func Test_Exec_InsertPlaceholder(t *testing.T) {
 	}
 
 	if _, err := db.Exec(`INSERT INTO dbtemp.tbltemp (id, username, email, grade, actived, data) VALUES (:1, $2, @3, @4, $5, :6)`,
-		"1", "user", "user@domain2.com", 2, false, nil, "user"); err != ErrConflict {
+		"1", "user", "user@domain2.com", 2, false, nil, "user"); !errors.Is(err, ErrConflict) {
 		// duplicated id (in logical partition scope)
 		t.Fatalf("%s failed: expected ErrConflict but received %#v", name, err)
 	}
 
 	if _, err := db.Exec(`INSERT INTO dbtemp.tbltemp (id, username, email, grade, actived, data) VALUES (:1, $2, @3, @4, $5, :6)`,
-		"2", "user", "user@domain1.com", 3, false, nil, "user"); err != ErrConflict {
+		"2", "user", "user@domain1.com", 3, false, nil, "user"); !errors.Is(err, ErrConflict) {
 		// duplicated unique index (in logical partition scope)
 		t.Fatalf("%s failed: expected ErrConflict but received %#v", name, err)
 	}
@@ -648,16 +975,16 @@ func Test_Exec_Upsert(t *testing.T) {
 		t.Fatalf("%s failed: expected RowsAffected=1/err=nil but received RowsAffected=%d/err=%s", name, numRows, err)
 	}
 
-	if _, err := db.Exec(`UPSERT INTO dbtemp.tbltemp (id,username,email,grade,actived) VALUES ("\"1\"", "\"user2\"", "\"user2@domain.com\"", 9, true)`, "user2"); err != ErrConflict {
+	if _, err := db.Exec(`UPSERT INTO dbtemp.tbltemp (id,username,email,grade,actived) VALUES ("\"1\"", "\"user2\"", "\"user2@domain.com\"", 9, true)`, "user2"); !errors.Is(err, ErrConflict) {
 		// duplicated unique index (in logical partition scope)
 		t.Fatalf("%s failed: expected ErrConflict but received %#v", name, err)
 	}
 
-	if _, err := db.Exec(`UPSERT INTO db_not_exists.table (id,username,email) VALUES ("\"x\"", "\"y\"", "\"x\"")`, "y"); err != ErrNotFound {
+	if _, err := db.Exec(`UPSERT INTO db_not_exists.table (id,username,email) VALUES ("\"x\"", "\"y\"", "\"x\"")`, "y"); !errors.Is(err, ErrNotFound) {
 		// database/table not found
 		t.Fatalf("%s failed: expected ErrNotFound but received %#v", name, err)
 	}
-	if _, err := db.Exec(`UPSERT INTO dbtemp.tbl_not_found (id,username,email) VALUES ("\"x\"", "\"y\"", "\"x\"")`, "y"); err != ErrNotFound {
+	if _, err := db.Exec(`UPSERT INTO dbtemp.tbl_not_found (id,username,email) VALUES ("\"x\"", "\"y\"", "\"x\"")`, "y"); !errors.Is(err, ErrNotFound) {
 		// database/table not found
 		t.Fatalf("%s failed: expected ErrNotFound but received %#v", name, err)
 	}
@@ -714,7 +1041,7 @@ func Test_Exec_UpsertPlaceholder(t *testing.T) {
 	}
 
 	if _, err := db.Exec(`UPSERT INTO dbtemp.tbltemp (id, username, email, grade, actived, data) VALUES (:1, $2, @3, @4, $5, :6)`,
-		"2", "user1", "user2@domain.com", 3, false, nil, "user1"); err != ErrConflict {
+		"2", "user1", "user2@domain.com", 3, false, nil, "user1"); !errors.Is(err, ErrConflict) {
 		// duplicated unique index (in logical partition scope)
 		t.Fatalf("%s failed: expected ErrConflict but received %#v", name, err)
 	}
@@ -789,11 +1116,11 @@ func Test_Exec_Delete(t *testing.T) {
 		t.Fatalf("%s failed: expected RowsAffected=0/err=nil but received RowsAffected=%d/err=%s", name, numRows, err)
 	}
 
-	if _, err := db.Exec(`DELETE FROM dbtemp.table_not_exists WHERE id=1`, "user"); err != ErrNotFound {
+	if _, err := db.Exec(`DELETE FROM dbtemp.table_not_exists WHERE id=1`, "user"); !errors.Is(err, ErrNotFound) {
 		t.Fatalf("%s failed: expected ErrNotFound but received %#v", name, err)
 	}
 
-	if _, err := db.Exec(`DELETE FROM db_not_exists.table WHERE id=1`, "user"); err != ErrNotFound {
+	if _, err := db.Exec(`DELETE FROM db_not_exists.table WHERE id=1`, "user"); !errors.Is(err, ErrNotFound) {
 		t.Fatalf("%s failed: expected ErrNotFound but received %#v", name, err)
 	}
 
@@ -802,6 +1129,36 @@ func Test_Exec_Delete(t *testing.T) {
 	}
 }
 
+func Test_Exec_DeleteEtag(t *testing.T) {
+	name := "Test_Exec_DeleteEtag"
+	currentEtag, ifMatch := `"etag-2"`, ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ifMatch = r.Header.Get("If-Match"); ifMatch != "" && ifMatch != currentEtag {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			w.Write([]byte(`{"code":"PreconditionFailed","message":"Operation cannot be performed because one of the specified precondition is not met."}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	db, _ := sql.Open("gocosmos", "AccountEndpoint="+server.URL+";AccountKey=C2y6yDjf5/R+ob0N8A7Cgv30VRDJIWEHLM+4QDU5DE2nQ9nDuVTqobD4b8mGGyPMbIZnqyMsEcaGQy67XIw/Jw==")
+	defer db.Close()
+
+	if _, err := db.Exec(`DELETE FROM dbtemp.tbltemp WHERE id=:1 AND _etag=:2`, "1", `"etag-1"`, "user"); !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("%s failed: expected ErrPreconditionFailed but received %#v", name, err)
+	} else if ifMatch != `"etag-1"` {
+		t.Fatalf("%s failed: <If-Match> expected %#v but received %#v", name, `"etag-1"`, ifMatch)
+	}
+	if result, err := db.Exec(`DELETE FROM dbtemp.tbltemp WHERE id=1 AND _etag="\"etag-2\""`, "user"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	} else if numRows, err := result.RowsAffected(); numRows != 1 || err != nil {
+		t.Fatalf("%s failed: expected RowsAffected=1/err=nil but received RowsAffected=%d/err=%s", name, numRows, err)
+	}
+	if _, err := db.Exec(`DELETE FROM dbtemp.tbltemp WHERE id=1`, "user"); err != nil || ifMatch != "" {
+		t.Fatalf("%s failed: expected no If-Match header but received %#v (%s)", name, ifMatch, err)
+	}
+}
+
 func Test_Exec_Select(t *testing.T) {
 	name := "Test_Exec_Select"
 	db := _openDb(t, name)
@@ -898,11 +1255,11 @@ func Test_Query_Select(t *testing.T) {
 		}
 	}
 
-	if _, err := db.Query(`SELECT * FROM c WITH db=dbtemp WITH collection=tbl_not_found`); err != ErrNotFound {
+	if _, err := db.Query(`SELECT * FROM c WITH db=dbtemp WITH collection=tbl_not_found`); !errors.Is(err, ErrNotFound) {
 		t.Fatalf("%s failed: expected ErrNotFound but received %#v", name, err)
 	}
 
-	if _, err := db.Query(`SELECT * FROM c WITH db=db_not_found WITH collection=tbltemp`); err != ErrNotFound {
+	if _, err := db.Query(`SELECT * FROM c WITH db=db_not_found WITH collection=tbltemp`); !errors.Is(err, ErrNotFound) {
 		t.Fatalf("%s failed: expected ErrNotFound but received %#v", name, err)
 	}
 }
@@ -1102,15 +1459,15 @@ func Test_Exec_Update(t *testing.T) {
 		t.Fatalf("%s failed: expected RowsAffected=0/err=nil but received RowsAffected=%d/err=%s", name, numRows, err)
 	}
 
-	if _, err := db.Exec(`UPDATE dbtemp.tbltemp SET email="\"user2@domain.com\"" WHERE id=1`, "user"); err != ErrConflict {
+	if _, err := db.Exec(`UPDATE dbtemp.tbltemp SET email="\"user2@domain.com\"" WHERE id=1`, "user"); !errors.Is(err, ErrConflict) {
 		t.Fatalf("%s failed: %s", name, err)
 	}
 
-	if _, err := db.Exec(`UPDATE dbtemp.tbl_not_found SET email="\"user2@domain.com\"" WHERE id=1`, "user"); err != ErrNotFound {
+	if _, err := db.Exec(`UPDATE dbtemp.tbl_not_found SET email="\"user2@domain.com\"" WHERE id=1`, "user"); !errors.Is(err, ErrNotFound) {
 		t.Fatalf("%s failed: %s", name, err)
 	}
 
-	if _, err := db.Exec(`UPDATE db_not_exists.tbltemp SET email="\"user2@domain.com\"" WHERE id=1`, "user"); err != ErrNotFound {
+	if _, err := db.Exec(`UPDATE db_not_exists.tbltemp SET email="\"user2@domain.com\"" WHERE id=1`, "user"); !errors.Is(err, ErrNotFound) {
 		t.Fatalf("%s failed: %s", name, err)
 	}
 
@@ -1124,6 +1481,71 @@ func Test_Exec_Update(t *testing.T) {
 	}
 }
 
+func Test_Exec_UpdateEtag(t *testing.T) {
+	name := "Test_Exec_UpdateEtag"
+	currentEtag, ifMatch, patchBody := `"etag-2"`, "", ""
+	var docRequests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/dbs/dbtemp/colls/tbltemp/docs/1" {
+			docRequests = append(docRequests, r.Method)
+		}
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/dbs/dbtemp/colls/tbltemp":
+			w.Write([]byte(`{"id":"tbltemp","partitionKey":{"paths":["/username"],"kind":"Hash"}}`))
+		case r.Method == "GET":
+			w.Write([]byte(`{"id":"1","username":"user","grade":1,"_etag":` + strconv.Quote(currentEtag) + `}`))
+		case r.Method == "PUT" || r.Method == "PATCH":
+			if r.Method == "PATCH" {
+				body, _ := ioutil.ReadAll(r.Body)
+				patchBody = string(body)
+			}
+			if ifMatch = r.Header.Get("If-Match"); ifMatch != currentEtag {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				w.Write([]byte(`{"code":"PreconditionFailed","message":"Operation cannot be performed because one of the specified precondition is not met."}`))
+				return
+			}
+			w.Write([]byte(`{"id":"1","username":"user","grade":2,"_etag":"\"etag-3\""}`))
+		}
+	}))
+	defer server.Close()
+	db, _ := sql.Open("gocosmos", "AccountEndpoint="+server.URL+";AccountKey=C2y6yDjf5/R+ob0N8A7Cgv30VRDJIWEHLM+4QDU5DE2nQ9nDuVTqobD4b8mGGyPMbIZnqyMsEcaGQy67XIw/Jw==")
+	defer db.Close()
+
+	if result, err := db.Exec(`UPDATE dbtemp.tbltemp SET grade=2 WHERE id=1 AND _etag=:1`, `"etag-1"`, "user"); !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("%s failed: expected ErrPreconditionFailed but received %#v", name, err)
+	} else if ifMatch != `"etag-1"` {
+		t.Fatalf("%s failed: <If-Match> expected %#v but received %#v", name, `"etag-1"`, ifMatch)
+	} else if result != nil {
+		t.Fatalf("%s failed: expected nil result but received %#v", name, result)
+	}
+	if result, err := db.Exec(`UPDATE dbtemp.tbltemp SET grade=2 WHERE id=1 AND _etag=:1`, `"etag-2"`, "user"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	} else if numRows, err := result.RowsAffected(); numRows != 1 || err != nil {
+		t.Fatalf("%s failed: expected RowsAffected=1/err=nil but received RowsAffected=%d/err=%s", name, numRows, err)
+	}
+	// with etag condition, the document is patched without being read first
+	if strings.Join(docRequests, ",") != "PATCH,PATCH" || patchBody != `{"operations":[{"op":"set","path":"/grade","value":2}]}` {
+		t.Fatalf("%s failed: unexpected requests %v (body %s)", name, docRequests, patchBody)
+	}
+
+	// without etag condition, the etag of the fetched document is used
+	docRequests = nil
+	if _, err := db.Exec(`UPDATE dbtemp.tbltemp SET grade=2 WHERE id=1`, "user"); err != nil || ifMatch != currentEtag {
+		t.Fatalf("%s failed: <If-Match> expected %#v but received %#v (%s)", name, currentEtag, ifMatch, err)
+	} else if strings.Join(docRequests, ",") != "GET,PUT" {
+		t.Fatalf("%s failed: unexpected requests %v", name, docRequests)
+	}
+
+	// more fields than a partial document update supports: the document is read, then replaced
+	docRequests = nil
+	if _, err := db.Exec(`UPDATE dbtemp.tbltemp SET f1=1,f2=2,f3=3,f4=4,f5=5,f6=6,f7=7,f8=8,f9=9,f10=10,f11=11 WHERE id=1 AND _etag=:1`,
+		currentEtag, "user"); err != nil || ifMatch != currentEtag {
+		t.Fatalf("%s failed: <If-Match> expected %#v but received %#v (%s)", name, currentEtag, ifMatch, err)
+	} else if strings.Join(docRequests, ",") != "GET,PUT" {
+		t.Fatalf("%s failed: unexpected requests %v", name, docRequests)
+	}
+}
+
 func Test_Exec_UpdatePlaceholder(t *testing.T) {
 	name := "Test_Exec_UpdatePlaceholder"
 	db := _openDb(t, name)
@@ -1168,15 +1590,15 @@ func Test_Exec_UpdatePlaceholder(t *testing.T) {
 		t.Fatalf("%s failed: expected RowsAffected=0/err=nil but received RowsAffected=%d/err=%s", name, numRows, err)
 	}
 
-	if _, err := db.Exec(`UPDATE dbtemp.tbltemp SET email=:1 WHERE id=@2`, "user2@domain.com", "1", "user"); err != ErrConflict {
+	if _, err := db.Exec(`UPDATE dbtemp.tbltemp SET email=:1 WHERE id=@2`, "user2@domain.com", "1", "user"); !errors.Is(err, ErrConflict) {
 		t.Fatalf("%s failed: %s", name, err)
 	}
 
-	if _, err := db.Exec(`UPDATE dbtemp.tbl_not_found SET email=@1 WHERE id=1`, "user2@domain.com", "user"); err != ErrNotFound {
+	if _, err := db.Exec(`UPDATE dbtemp.tbl_not_found SET email=@1 WHERE id=1`, "user2@domain.com", "user"); !errors.Is(err, ErrNotFound) {
 		t.Fatalf("%s failed: %s", name, err)
 	}
 
-	if _, err := db.Exec(`UPDATE db_not_exists.tbltemp SET email=$1 WHERE id=1`, "user2@domain.com", "user"); err != ErrNotFound {
+	if _, err := db.Exec(`UPDATE db_not_exists.tbltemp SET email=$1 WHERE id=1`, "user2@domain.com", "user"); !errors.Is(err, ErrNotFound) {
 		t.Fatalf("%s failed: %s", name, err)
 	}
 
@@ -1197,3 +1619,218 @@ func Test_Exec_UpdatePlaceholder(t *testing.T) {
 		t.Fatalf("%s failed: expected 'invalid value index' but received '%s'", name, err)
 	}
 }
+
+func Test_Exec_Procedure(t *testing.T) {
+	name := "Test_Exec_Procedure"
+	db := _openDb(t, name)
+	client := _newRestClient(t, name)
+
+	db.Exec("DROP DATABASE IF EXISTS dbtemp")
+	db.Exec("CREATE DATABASE IF NOT EXISTS dbtemp")
+	db.Exec("CREATE COLLECTION dbtemp.tbltemp WITH pk=/id")
+
+	body := `function hello() { getContext().getResponse().setBody("hello"); }`
+	if result, err := db.Exec("CREATE PROCEDURE dbtemp.tbltemp.sp1 AS :1", body); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	} else if numRows, err := result.RowsAffected(); numRows != 1 || err != nil {
+		t.Fatalf("%s failed: expected RowsAffected=1/err=nil but received RowsAffected=%d/err=%s", name, numRows, err)
+	}
+	if _, err := db.Exec("CREATE PROCEDURE dbtemp.tbltemp.sp1 AS :1", body); !errors.Is(err, ErrConflict) {
+		t.Fatalf("%s failed: expected ErrConflict but received %#v", name, err)
+	}
+	if _, err := db.Exec("CREATE PROCEDURE IF NOT EXISTS dbtemp.tbltemp.sp1 AS :1", body); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if result := client.ExecuteStoredProcedure(ExecuteStoredProcedureReq{DbName: "dbtemp", CollName: "tbltemp", SprocId: "sp1",
+		PartitionKeyValues: []interface{}{"any"}}); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	} else if result.Result != "hello" {
+		t.Fatalf("%s failed: <result> expected %#v but received %#v", name, "hello", result.Result)
+	}
+
+	if _, err := db.Exec(`ALTER PROCEDURE dbtemp.tbltemp.sp1 AS "function hello() { getContext().getResponse().setBody(\"hi\"); }"`); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if result := client.ExecuteStoredProcedure(ExecuteStoredProcedureReq{DbName: "dbtemp", CollName: "tbltemp", SprocId: "sp1",
+		PartitionKeyValues: []interface{}{"any"}}); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	} else if result.Result != "hi" {
+		t.Fatalf("%s failed: <result> expected %#v but received %#v", name, "hi", result.Result)
+	}
+	if _, err := db.Exec("ALTER PROCEDURE dbtemp.tbltemp.not_exists AS :1", body); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("%s failed: expected ErrNotFound but received %#v", name, err)
+	}
+
+	if _, err := db.Exec("DROP PROCEDURE dbtemp.tbltemp.sp1"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if _, err := db.Exec("DROP PROCEDURE dbtemp.tbltemp.sp1"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("%s failed: expected ErrNotFound but received %#v", name, err)
+	}
+	if _, err := db.Exec("DROP PROCEDURE IF EXISTS dbtemp.tbltemp.sp1"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+}
+
+func Test_Exec_Function(t *testing.T) {
+	name := "Test_Exec_Function"
+	db := _openDb(t, name)
+
+	db.Exec("DROP DATABASE IF EXISTS dbtemp")
+	db.Exec("CREATE DATABASE IF NOT EXISTS dbtemp")
+	db.Exec("CREATE COLLECTION dbtemp.tbltemp WITH pk=/username")
+	db.Exec(`INSERT INTO dbtemp.tbltemp (id, username, age) VALUES ("1", "user1", 20)`, "user1")
+
+	if result, err := db.Exec(`CREATE FUNCTION dbtemp.tbltemp.double AS "function(a) { return a * 2; }"`); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	} else if numRows, err := result.RowsAffected(); numRows != 1 || err != nil {
+		t.Fatalf("%s failed: expected RowsAffected=1/err=nil but received RowsAffected=%d/err=%s", name, numRows, err)
+	}
+	if _, err := db.Exec(`CREATE FUNCTION dbtemp.tbltemp.double AS "function(a) { return a * 2; }"`); !errors.Is(err, ErrConflict) {
+		t.Fatalf("%s failed: expected ErrConflict but received %#v", name, err)
+	}
+	if _, err := db.Exec(`CREATE FUNCTION IF NOT EXISTS dbtemp.tbltemp.double AS "function(a) { return a * 2; }"`); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+
+	dbRows, err := db.Query("SELECT CROSS PARTITION udf.double(c.age) AS age2 FROM c WITH db=dbtemp WITH collection=tbltemp")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	var age2 interface{}
+	if !dbRows.Next() {
+		t.Fatalf("%s failed: expected 1 row returned", name)
+	} else if err := dbRows.Scan(&age2); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	} else if age2 != 40.0 {
+		t.Fatalf("%s failed: <age2> expected %#v but received %#v", name, 40.0, age2)
+	}
+	dbRows.Close()
+
+	if _, err := db.Exec("DROP FUNCTION dbtemp.tbltemp.double"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if _, err := db.Exec("DROP FUNCTION dbtemp.tbltemp.double"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("%s failed: expected ErrNotFound but received %#v", name, err)
+	}
+	if _, err := db.Exec("DROP FUNCTION IF EXISTS dbtemp.tbltemp.double"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+}
+
+func Test_Exec_Trigger(t *testing.T) {
+	name := "Test_Exec_Trigger"
+	db := _openDb(t, name)
+	client := _newRestClient(t, name)
+
+	db.Exec("DROP DATABASE IF EXISTS dbtemp")
+	db.Exec("CREATE DATABASE IF NOT EXISTS dbtemp")
+	db.Exec("CREATE COLLECTION dbtemp.tbltemp WITH pk=/username")
+
+	body := `function addTimestamp() {
+	var req = getContext().getRequest();
+	var doc = req.getBody();
+	doc.stamped = true;
+	req.setBody(doc);
+}`
+	if result, err := db.Exec("CREATE TRIGGER dbtemp.tbltemp.stamp WITH type=pre WITH operation=create AS :1", body); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	} else if numRows, err := result.RowsAffected(); numRows != 1 || err != nil {
+		t.Fatalf("%s failed: expected RowsAffected=1/err=nil but received RowsAffected=%d/err=%s", name, numRows, err)
+	}
+	if _, err := db.Exec("CREATE TRIGGER dbtemp.tbltemp.stamp WITH type=pre AS :1", body); !errors.Is(err, ErrConflict) {
+		t.Fatalf("%s failed: expected ErrConflict but received %#v", name, err)
+	}
+	if _, err := db.Exec("CREATE TRIGGER IF NOT EXISTS dbtemp.tbltemp.stamp WITH type=pre AS :1", body); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+
+	if _, err := db.Exec(`INSERT INTO dbtemp.tbltemp (id, username) VALUES ("1", "user1") WITH pretrigger=stamp`, "user1"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if _, err := db.Exec(`INSERT INTO dbtemp.tbltemp (id, username) VALUES ("2", "user2")`, "user2"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if result := client.GetDocument(DocReq{DbName: "dbtemp", CollName: "tbltemp", DocId: "1", PartitionKeyValues: []interface{}{"user1"}}); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	} else if result.DocInfo["stamped"] != true {
+		t.Fatalf("%s failed: document must have been modified by the pre-trigger %#v", name, result.DocInfo)
+	}
+	if result := client.GetDocument(DocReq{DbName: "dbtemp", CollName: "tbltemp", DocId: "2", PartitionKeyValues: []interface{}{"user2"}}); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	} else if _, ok := result.DocInfo["stamped"]; ok {
+		t.Fatalf("%s failed: document must not have been modified by the pre-trigger %#v", name, result.DocInfo)
+	}
+
+	if _, err := db.Exec("DROP TRIGGER dbtemp.tbltemp.stamp"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if _, err := db.Exec("DROP TRIGGER dbtemp.tbltemp.stamp"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("%s failed: expected ErrNotFound but received %#v", name, err)
+	}
+	if _, err := db.Exec("DROP TRIGGER IF EXISTS dbtemp.tbltemp.stamp"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+}
+
+func Test_Query_SelectChanges(t *testing.T) {
+	name := "Test_Query_SelectChanges"
+	db := _openDb(t, name)
+
+	db.Exec("DROP DATABASE IF EXISTS dbtemp")
+	db.Exec("CREATE DATABASE IF NOT EXISTS dbtemp")
+	db.Exec("CREATE COLLECTION dbtemp.tbltemp WITH pk=/username")
+
+	readChanges := func(continuation string) (map[string]bool, string) {
+		dbRows, err := db.Query("SELECT CHANGES FROM dbtemp.tbltemp WITH continuation=:1", continuation)
+		if err != nil {
+			t.Fatalf("%s failed: %s", name, err)
+		}
+		defer dbRows.Close()
+		cols, _ := dbRows.Columns()
+		ids := make(map[string]bool)
+		for dbRows.Next() {
+			vals := make([]interface{}, len(cols))
+			scanVals := make([]interface{}, len(cols))
+			for i := range vals {
+				scanVals[i] = &vals[i]
+			}
+			if err := dbRows.Scan(scanVals...); err != nil {
+				t.Fatalf("%s failed: %s", name, err)
+			}
+			for i, col := range cols {
+				switch col {
+				case "id":
+					ids[fmt.Sprintf("%s", vals[i])] = true
+				case "_continuation":
+					continuation = fmt.Sprintf("%s", vals[i])
+				}
+			}
+		}
+		return ids, continuation
+	}
+
+	// read from "now", expect no changes
+	ids, continuation := readChanges("*")
+	if len(ids) != 0 {
+		t.Fatalf("%s failed: expected no changes but received %#v", name, ids)
+	}
+
+	for i := 0; i < 5; i++ {
+		username := "user" + strconv.Itoa(i)
+		db.Exec("INSERT INTO dbtemp.tbltemp (id, username) VALUES (:1, :2)", strconv.Itoa(i), username, username)
+	}
+	ids, continuation = readChanges(continuation)
+	if len(ids) != 5 {
+		t.Fatalf("%s failed: expected 5 changes but received %#v", name, ids)
+	}
+
+	db.Exec(`UPDATE dbtemp.tbltemp SET grade=1 WHERE id="1"`, "user1")
+	ids, continuation = readChanges(continuation)
+	if len(ids) != 1 || !ids["1"] {
+		t.Fatalf("%s failed: expected changes of document #1 but received %#v", name, ids)
+	}
+	if ids, _ = readChanges(continuation); len(ids) != 0 {
+		t.Fatalf("%s failed: expected no changes but received %#v", name, ids)
+	}
+}
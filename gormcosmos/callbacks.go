@@ -0,0 +1,333 @@
+package gormcosmos
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// clauseBuilders render gorm's query clauses in Cosmos DB SQL dialect.
+var clauseBuilders = map[string]clause.ClauseBuilder{
+	"SELECT": func(c clause.Clause, builder clause.Builder) {
+		// documents are queried across partitions
+		c.Name = "SELECT CROSS PARTITION"
+		c.Builder = nil
+		c.Build(builder)
+	},
+	"WHERE": func(c clause.Clause, builder clause.Builder) {
+		if where, ok := c.Expression.(clause.Where); ok {
+			c.Expression = clause.Where{Exprs: _qualifyExprs(where.Exprs)}
+		}
+		c.Builder = nil
+		c.Build(builder)
+	},
+	"ORDER BY": func(c clause.Clause, builder clause.Builder) {
+		if orderBy, ok := c.Expression.(clause.OrderBy); ok {
+			columns := make([]clause.OrderByColumn, len(orderBy.Columns))
+			for i, column := range orderBy.Columns {
+				column.Column = _qualifyColumn(column.Column)
+				columns[i] = column
+			}
+			c.Expression = clause.OrderBy{Columns: columns, Expression: orderBy.Expression}
+		}
+		c.Builder = nil
+		c.Build(builder)
+	},
+	"LIMIT": func(c clause.Clause, builder clause.Builder) {
+		// Cosmos DB requires both OFFSET and LIMIT, in that order
+		if limit, ok := c.Expression.(clause.Limit); ok && (limit.Limit != nil || limit.Offset > 0) {
+			builder.WriteString("OFFSET ")
+			builder.WriteString(strconv.Itoa(limit.Offset))
+			builder.WriteString(" LIMIT ")
+			if limit.Limit != nil && *limit.Limit >= 0 {
+				builder.WriteString(strconv.Itoa(*limit.Limit))
+			} else {
+				builder.WriteString(strconv.Itoa(1<<31 - 1))
+			}
+		}
+	},
+}
+
+// _qualifyColumn prefixes an unqualified column with the collection name, as Cosmos DB SQL requires document properties
+// to be referenced via the collection alias (e.g. users.name).
+func _qualifyColumn(column clause.Column) clause.Column {
+	if column.Table == "" && !column.Raw && !strings.Contains(column.Name, ".") {
+		column.Table = clause.CurrentTable
+	}
+	return column
+}
+
+func _qualify(column interface{}) interface{} {
+	switch c := column.(type) {
+	case string:
+		return _qualifyColumn(clause.Column{Name: c})
+	case clause.Column:
+		return _qualifyColumn(c)
+	}
+	return column
+}
+
+func _qualifyExprs(exprs []clause.Expression) []clause.Expression {
+	result := make([]clause.Expression, len(exprs))
+	for i, expr := range exprs {
+		switch e := expr.(type) {
+		case clause.Eq:
+			e.Column = _qualify(e.Column)
+			expr = e
+		case clause.Neq:
+			e.Column = _qualify(e.Column)
+			expr = e
+		case clause.Gt:
+			e.Column = _qualify(e.Column)
+			expr = e
+		case clause.Gte:
+			e.Column = _qualify(e.Column)
+			expr = e
+		case clause.Lt:
+			e.Column = _qualify(e.Column)
+			expr = e
+		case clause.Lte:
+			e.Column = _qualify(e.Column)
+			expr = e
+		case clause.Like:
+			e.Column = _qualify(e.Column)
+			expr = e
+		case clause.IN:
+			e.Column = _qualify(e.Column)
+			expr = e
+		case clause.AndConditions:
+			expr = clause.AndConditions{Exprs: _qualifyExprs(e.Exprs)}
+		case clause.OrConditions:
+			expr = clause.OrConditions{Exprs: _qualifyExprs(e.Exprs)}
+		case clause.NotConditions:
+			expr = clause.NotConditions{Exprs: _qualifyExprs(e.Exprs)}
+		}
+		result[i] = expr
+	}
+	return result
+}
+
+// partitionKeyField returns the field tagged as partition key of the model, nil if none.
+func partitionKeyField(sch *schema.Schema) *schema.Field {
+	for _, field := range sch.Fields {
+		if _, ok := field.TagSettings[TagPartitionKey]; ok {
+			return field
+		}
+	}
+	return nil
+}
+
+// _reflectValues returns the model values of the statement, one per document.
+func _reflectValues(stmt *gorm.Statement) []reflect.Value {
+	switch stmt.ReflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		values := make([]reflect.Value, 0, stmt.ReflectValue.Len())
+		for i := 0; i < stmt.ReflectValue.Len(); i++ {
+			if rv := reflect.Indirect(stmt.ReflectValue.Index(i)); rv.IsValid() {
+				values = append(values, rv)
+			}
+		}
+		return values
+	case reflect.Struct:
+		return []reflect.Value{stmt.ReflectValue}
+	}
+	return nil
+}
+
+// documentKey returns the id and the partition key value (if any) of a document.
+func documentKey(stmt *gorm.Statement, rv reflect.Value) (interface{}, []interface{}, error) {
+	idField := stmt.Schema.PrioritizedPrimaryField
+	if idField == nil {
+		return nil, nil, ErrMissingId
+	}
+	id, isZero := idField.ValueOf(stmt.Context, rv)
+	if isZero {
+		return nil, nil, ErrMissingId
+	}
+	if pkField := partitionKeyField(stmt.Schema); pkField != nil {
+		pkValue, _ := pkField.ValueOf(stmt.Context, rv)
+		return id, []interface{}{pkValue}, nil
+	}
+	return id, nil, nil
+}
+
+// addVar adds a variable to the statement. Unlike gorm.Statement.AddVar, slices are bound as a single (JSON array) value.
+func addVar(stmt *gorm.Statement, v interface{}) {
+	if expr, ok := v.(clause.Expression); ok {
+		expr.Build(stmt)
+		return
+	}
+	stmt.Vars = append(stmt.Vars, v)
+	stmt.DB.Dialector.BindVarTo(stmt, stmt, v)
+}
+
+func exec(db *gorm.DB, pkValues []interface{}) {
+	stmt := db.Statement
+	if db.DryRun {
+		return
+	}
+	args := stmt.Vars
+	if len(pkValues) > 0 {
+		args = append(append(make([]interface{}, 0, len(args)+1), args...), pkValues[0])
+	}
+	result, err := stmt.ConnPool.ExecContext(stmt.Context, stmt.SQL.String(), args...)
+	if err != nil {
+		db.AddError(err)
+		return
+	}
+	if rowsAffected, err := result.RowsAffected(); err == nil {
+		db.RowsAffected += rowsAffected
+	}
+}
+
+// createCallback replaces gorm's create callback: documents are inserted one by one, with the partition key value injected
+// as the last argument. If clause ON CONFLICT is present (e.g. gorm.DB.Save), documents are upserted instead.
+func createCallback(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+	stmt := db.Statement
+	if idField := stmt.Schema.PrioritizedPrimaryField; idField != nil && idField.FieldType.Kind() == reflect.String {
+		for _, rv := range _reflectValues(stmt) {
+			if _, isZero := idField.ValueOf(stmt.Context, rv); isZero {
+				db.AddError(idField.Set(stmt.Context, rv, IdGenerator()))
+			}
+		}
+	}
+	values := callbacks.ConvertToCreateValues(stmt)
+	if db.Error != nil {
+		return
+	}
+	verb := "INSERT"
+	if _, ok := stmt.Clauses["ON CONFLICT"]; ok {
+		verb = "UPSERT"
+	}
+	pkIndex := -1
+	if pkField := partitionKeyField(stmt.Schema); pkField != nil {
+		for i, column := range values.Columns {
+			if column.Name == pkField.DBName {
+				pkIndex = i
+			}
+		}
+	}
+	for _, row := range values.Values {
+		stmt.SQL.Reset()
+		stmt.Vars = nil
+		stmt.WriteString(verb + " INTO ")
+		stmt.WriteQuoted(stmt.Table)
+		stmt.WriteString(" (")
+		for i, column := range values.Columns {
+			if i > 0 {
+				stmt.WriteByte(',')
+			}
+			stmt.WriteQuoted(column.Name)
+		}
+		stmt.WriteString(") VALUES (")
+		for i, v := range row {
+			if i > 0 {
+				stmt.WriteByte(',')
+			}
+			addVar(stmt, v)
+		}
+		stmt.WriteByte(')')
+		var pkValues []interface{}
+		if pkIndex >= 0 {
+			pkValues = []interface{}{row[pkIndex]}
+		}
+		if exec(db, pkValues); db.Error != nil {
+			return
+		}
+	}
+}
+
+// updateCallback replaces gorm's update callback: each document is updated by id, with the partition key value injected
+// as the last argument.
+func updateCallback(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+	stmt := db.Statement
+	set, ok := stmt.Clauses["SET"].Expression.(clause.Set)
+	if !ok {
+		set = callbacks.ConvertToAssignments(stmt)
+	}
+	if len(set) == 0 {
+		return
+	}
+	for _, assignment := range set {
+		if _, ok := assignment.Value.(clause.Expression); ok {
+			db.AddError(ErrUnsupportedExpr)
+			return
+		}
+	}
+	rvs := _reflectValues(stmt)
+	if len(rvs) == 0 {
+		db.AddError(ErrMissingId)
+		return
+	}
+	for _, rv := range rvs {
+		id, pkValues, err := documentKey(stmt, rv)
+		if err != nil {
+			db.AddError(err)
+			return
+		}
+		stmt.SQL.Reset()
+		stmt.Vars = nil
+		stmt.WriteString("UPDATE ")
+		stmt.WriteQuoted(stmt.Table)
+		stmt.WriteString(" SET ")
+		first := true
+		for _, assignment := range set {
+			if assignment.Column.Name == stmt.Schema.PrioritizedPrimaryField.DBName {
+				continue
+			}
+			if !first {
+				stmt.WriteByte(',')
+			}
+			first = false
+			stmt.WriteQuoted(assignment.Column.Name)
+			stmt.WriteByte('=')
+			addVar(stmt, assignment.Value)
+		}
+		stmt.WriteString(" WHERE id=")
+		addVar(stmt, id)
+		if exec(db, pkValues); db.Error != nil {
+			return
+		}
+	}
+}
+
+// deleteCallback replaces gorm's delete callback: each document is deleted by id, with the partition key value injected
+// as the last argument.
+func deleteCallback(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+	stmt := db.Statement
+	rvs := _reflectValues(stmt)
+	if len(rvs) == 0 {
+		db.AddError(ErrMissingId)
+		return
+	}
+	for _, rv := range rvs {
+		id, pkValues, err := documentKey(stmt, rv)
+		if err != nil {
+			db.AddError(err)
+			return
+		}
+		stmt.SQL.Reset()
+		stmt.Vars = nil
+		stmt.WriteString("DELETE FROM ")
+		stmt.WriteQuoted(stmt.Table)
+		stmt.WriteString(" WHERE id=")
+		addVar(stmt, id)
+		if exec(db, pkValues); db.Error != nil {
+			return
+		}
+	}
+}
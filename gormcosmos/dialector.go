@@ -0,0 +1,155 @@
+/*
+Package gormcosmos provides a GORM dialector for Azure Cosmos DB SQL API, built on top of the gocosmos database/sql driver.
+
+Models are mapped onto collections: the table name of a model is used as the collection name, which is resolved against
+the default database specified via DSN option DefaultDb (or can be qualified as "db.collection" via TableName()). The
+document id is the model's primary key, which must be a string field (mapped to column "id"); if empty, a UUID is
+generated when the document is created.
+
+The partition key is the field tagged with `gorm:"partitionKey"`. Its value is injected as the partition key value of
+INSERT, UPDATE and DELETE statements. Models without such a field are expected to be used with DSN option
+AutoPartitionKey=true or non-partitioned collections.
+
+Example:
+
+	type User struct {
+		ID       string `gorm:"primaryKey"`
+		Tenant   string `gorm:"partitionKey"`
+		Username string
+	}
+
+	db, err := gorm.Open(gormcosmos.Open("AccountEndpoint=...;AccountKey=...;DefaultDb=mydb"), &gorm.Config{})
+	db.AutoMigrate(&User{})
+	db.Create(&User{Tenant: "t1", Username: "btnguyen2k"})
+
+Available since v0.1.1
+*/
+package gormcosmos
+
+import (
+	"database/sql"
+	"errors"
+	"regexp"
+	"strconv"
+
+	"github.com/btnguyen2k/gocosmos"
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/migrator"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	// DriverName is the name of the database/sql driver used by the dialector.
+	DriverName = "gocosmos"
+
+	// TagPartitionKey is the (upper-cased) gorm tag setting that marks the partition key field of a model.
+	TagPartitionKey = "PARTITIONKEY"
+)
+
+var (
+	// ErrMissingId is returned when updating or deleting a document whose id is not known.
+	ErrMissingId = errors.New("document id is required, supply the model with primary key populated")
+
+	// ErrUnsupportedExpr is returned when an update assignment is an SQL expression, which Cosmos DB does not support.
+	ErrUnsupportedExpr = errors.New("SQL expression is not supported as update value")
+
+	// IdGenerator generates ids of documents created with empty primary key.
+	IdGenerator gocosmos.IdGenerator = gocosmos.UuidGenerator
+
+	rePlaceholder = regexp.MustCompile(`@(\d+)`)
+)
+
+// Dialector implements gorm.Dialector for Azure Cosmos DB.
+type Dialector struct {
+	// DSN is the gocosmos connection string, used to open the connection pool if Conn is nil.
+	DSN string
+
+	// Conn is an existing connection pool, e.g. a *sql.DB opened via sql.OpenDB with a gocosmos.Connector.
+	Conn gorm.ConnPool
+}
+
+// Open returns a Dialector that connects to Azure Cosmos DB using the supplied gocosmos connection string.
+func Open(dsn string) gorm.Dialector {
+	return &Dialector{DSN: dsn}
+}
+
+// New returns a Dialector with the supplied configurations.
+func New(config Dialector) gorm.Dialector {
+	return &config
+}
+
+// Name implements gorm.Dialector.Name.
+func (d Dialector) Name() string {
+	return "gocosmos"
+}
+
+// Initialize implements gorm.Dialector.Initialize.
+func (d Dialector) Initialize(db *gorm.DB) error {
+	// Cosmos DB does not support transactions spanning multiple statements
+	db.SkipDefaultTransaction = true
+
+	callbacks.RegisterDefaultCallbacks(db, &callbacks.Config{})
+	if err := db.Callback().Create().Replace("gorm:create", createCallback); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Replace("gorm:update", updateCallback); err != nil {
+		return err
+	}
+	if err := db.Callback().Delete().Replace("gorm:delete", deleteCallback); err != nil {
+		return err
+	}
+	for name, builder := range clauseBuilders {
+		db.ClauseBuilders[name] = builder
+	}
+
+	if d.Conn != nil {
+		db.ConnPool = d.Conn
+		return nil
+	}
+	sqlDB, err := sql.Open(DriverName, d.DSN)
+	if err != nil {
+		return err
+	}
+	db.ConnPool = sqlDB
+	return nil
+}
+
+// Migrator implements gorm.Dialector.Migrator.
+func (d Dialector) Migrator(db *gorm.DB) gorm.Migrator {
+	return Migrator{migrator.Migrator{Config: migrator.Config{DB: db, Dialector: d}}}
+}
+
+// DataTypeOf implements gorm.Dialector.DataTypeOf.
+//
+// Cosmos DB is schemaless, hence an empty string is returned.
+func (d Dialector) DataTypeOf(*schema.Field) string {
+	return ""
+}
+
+// DefaultValueOf implements gorm.Dialector.DefaultValueOf.
+func (d Dialector) DefaultValueOf(*schema.Field) clause.Expression {
+	return clause.Expr{SQL: "null"}
+}
+
+// BindVarTo implements gorm.Dialector.BindVarTo.
+//
+// Placeholders are written as @i, where i is the position of the variable (the first variable is 1).
+func (d Dialector) BindVarTo(writer clause.Writer, stmt *gorm.Statement, _ interface{}) {
+	writer.WriteByte('@')
+	writer.WriteString(strconv.Itoa(len(stmt.Vars)))
+}
+
+// QuoteTo implements gorm.Dialector.QuoteTo.
+//
+// Cosmos DB identifiers are written as-is.
+func (d Dialector) QuoteTo(writer clause.Writer, str string) {
+	writer.WriteString(str)
+}
+
+// Explain implements gorm.Dialector.Explain.
+func (d Dialector) Explain(sql string, vars ...interface{}) string {
+	return logger.ExplainSQL(sql, rePlaceholder, `"`, vars...)
+}
@@ -0,0 +1,154 @@
+package gormcosmos
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type User struct {
+	ID       string `gorm:"primaryKey"`
+	Tenant   string `gorm:"partitionKey"`
+	Username string
+	Grade    int
+}
+
+type _fakeCosmos struct {
+	lock        sync.Mutex
+	docs        map[string]map[string]interface{}
+	collSpec    map[string]interface{}
+	lastQuery   string
+	lastPkValue string
+}
+
+func (f *_fakeCosmos) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.lastPkValue = r.Header.Get("X-Ms-Documentdb-PartitionKey")
+	var body map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&body)
+	switch {
+	case r.Method == "POST" && r.URL.Path == "/dbs/mydb/colls":
+		f.collSpec = body
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"users"}`))
+	case r.Method == "GET" && r.URL.Path == "/dbs/mydb/colls/users":
+		w.Write([]byte(`{"id":"users","partitionKey":{"paths":["/tenant"],"kind":"Hash"}}`))
+	case r.Method == "POST" && r.URL.Path == "/dbs/mydb/colls/users/docs" && r.Header.Get("X-Ms-Documentdb-Isquery") == "true":
+		f.lastQuery, _ = body["query"].(string)
+		docs := make([]map[string]interface{}, 0)
+		for _, doc := range f.docs {
+			docs = append(docs, doc)
+		}
+		js, _ := json.Marshal(map[string]interface{}{"Documents": docs, "_count": len(docs)})
+		w.Write(js)
+	case r.Method == "POST" && r.URL.Path == "/dbs/mydb/colls/users/docs":
+		id, _ := body["id"].(string)
+		if _, ok := f.docs[id]; ok && r.Header.Get("X-Ms-Documentdb-Is-Upsert") != "true" {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		f.docs[id] = body
+		w.WriteHeader(http.StatusCreated)
+		js, _ := json.Marshal(body)
+		w.Write(js)
+	case strings.HasPrefix(r.URL.Path, "/dbs/mydb/colls/users/docs/"):
+		id := strings.TrimPrefix(r.URL.Path, "/dbs/mydb/colls/users/docs/")
+		doc, ok := f.docs[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.Method {
+		case "GET":
+			js, _ := json.Marshal(doc)
+			w.Write(js)
+		case "PUT":
+			f.docs[id] = body
+			js, _ := json.Marshal(body)
+			w.Write(js)
+		case "DELETE":
+			delete(f.docs, id)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func _openFake(t *testing.T, name string) (*gorm.DB, *_fakeCosmos) {
+	fake := &_fakeCosmos{docs: make(map[string]map[string]interface{})}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	dsn := "AccountEndpoint=" + server.URL + ";AccountKey=C2y6yDjf5/R+ob0N8A7Cgv30VRDJIWEHLM+4QDU5DE2nQ9nDuVTqobD4b8mGGyPMbIZnqyMsEcaGQy67XIw/Jw==;DefaultDb=mydb"
+	db, err := gorm.Open(Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	return db, fake
+}
+
+func TestDialector_AutoMigrate(t *testing.T) {
+	name := "TestDialector_AutoMigrate"
+	db, fake := _openFake(t, name)
+	if err := db.AutoMigrate(&User{}); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if fake.collSpec["id"] != "users" {
+		t.Fatalf("%s failed: expected collection %#v but received %#v", name, "users", fake.collSpec["id"])
+	}
+	if pk, _ := fake.collSpec["partitionKey"].(map[string]interface{}); pk == nil || pk["paths"].([]interface{})[0] != "/tenant" {
+		t.Fatalf("%s failed: unexpected partition key %#v", name, fake.collSpec["partitionKey"])
+	}
+}
+
+func TestDialector_CRUD(t *testing.T) {
+	name := "TestDialector_CRUD"
+	db, fake := _openFake(t, name)
+
+	user := User{Tenant: "t1", Username: "btnguyen2k", Grade: 1}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if user.ID == "" || fake.docs[user.ID] == nil {
+		t.Fatalf("%s failed: document id must be generated", name)
+	}
+	if fake.lastPkValue != `["t1"]` {
+		t.Fatalf("%s failed: <partition-key> expected %#v but received %#v", name, `["t1"]`, fake.lastPkValue)
+	}
+
+	var found User
+	if err := db.Where(&User{Username: "btnguyen2k"}).First(&found).Error; err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if found.ID != user.ID || found.Grade != 1 {
+		t.Fatalf("%s failed: unexpected document %#v", name, found)
+	}
+	expectedQuery := "SELECT * FROM users WHERE users.username = @_1 ORDER BY users.id OFFSET 0 LIMIT 1"
+	if fake.lastQuery != expectedQuery {
+		t.Fatalf("%s failed: expected query %#v but received %#v", name, expectedQuery, fake.lastQuery)
+	}
+
+	if err := db.Model(&user).Updates(map[string]interface{}{"grade": 2}).Error; err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if grade, _ := fake.docs[user.ID]["grade"].(float64); grade != 2 {
+		t.Fatalf("%s failed: expected grade %#v but received %#v", name, 2, fake.docs[user.ID]["grade"])
+	}
+
+	if err := db.Delete(&user).Error; err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if len(fake.docs) != 0 {
+		t.Fatalf("%s failed: document must be deleted", name)
+	}
+	if err := db.Delete(&User{}).Error; err != ErrMissingId {
+		t.Fatalf("%s failed: expected ErrMissingId but received %#v", name, err)
+	}
+}
@@ -0,0 +1,18 @@
+module github.com/btnguyen2k/gocosmos/gormcosmos
+
+go 1.18
+
+require (
+	github.com/btnguyen2k/gocosmos v0.1.1
+	gorm.io/gorm v1.25.5
+)
+
+require (
+	github.com/btnguyen2k/consu/gjrc v0.1.1 // indirect
+	github.com/btnguyen2k/consu/reddo v0.1.4 // indirect
+	github.com/btnguyen2k/consu/semita v0.1.4 // indirect
+	github.com/jinzhu/inflection v1.0.0 // indirect
+	github.com/jinzhu/now v1.1.5 // indirect
+)
+
+replace github.com/btnguyen2k/gocosmos => ../
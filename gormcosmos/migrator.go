@@ -0,0 +1,65 @@
+package gormcosmos
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/migrator"
+)
+
+// Migrator implements gorm.Migrator for Azure Cosmos DB.
+//
+// Cosmos DB is schemaless: migrating a model creates its collection (if not exists), partitioned by the model's partition
+// key field (or by "/id" if the model has no partition key field). Column and index migrations are no-op.
+type Migrator struct {
+	migrator.Migrator
+}
+
+// AutoMigrate creates collections of the supplied models if not exist.
+func (m Migrator) AutoMigrate(values ...interface{}) error {
+	return m.CreateTable(values...)
+}
+
+// CreateTable creates collections of the supplied models if not exist.
+func (m Migrator) CreateTable(values ...interface{}) error {
+	for _, value := range m.ReorderModels(values, false) {
+		if err := m.RunWithValue(value, func(stmt *gorm.Statement) error {
+			pkPath := "/id"
+			if pkField := partitionKeyField(stmt.Schema); pkField != nil {
+				pkPath = "/" + pkField.DBName
+			}
+			return m.DB.Exec("CREATE COLLECTION IF NOT EXISTS " + stmt.Table + " WITH pk=" + pkPath).Error
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DropTable drops collections of the supplied models if exist.
+func (m Migrator) DropTable(values ...interface{}) error {
+	for _, value := range values {
+		if err := m.RunWithValue(value, func(stmt *gorm.Statement) error {
+			return m.DB.Exec("DROP COLLECTION IF EXISTS " + stmt.Table).Error
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HasTable checks if the collection of the supplied model exists.
+func (m Migrator) HasTable(value interface{}) bool {
+	found := false
+	m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		var collections []map[string]interface{}
+		if err := m.DB.Raw("LIST COLLECTIONS").Scan(&collections).Error; err != nil {
+			return err
+		}
+		for _, coll := range collections {
+			if coll["id"] == stmt.Table {
+				found = true
+			}
+		}
+		return nil
+	})
+	return found
+}
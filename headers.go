@@ -0,0 +1,129 @@
+package gocosmos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type ctxKeyHeadersType int
+
+const ctxKeyHeaders ctxKeyHeadersType = 0
+
+// WithHeaders returns a copy of ctx to which custom request headers are attached. Requests sent on behalf of calls made
+// with the returned context (e.g. sql.DB.ExecContext/QueryContext, or methods of a RestClient obtained via
+// RestClient.WithContext) carry the headers, e.g. for priority-based execution or preview features:
+//
+//     ctx = gocosmos.WithHeaders(ctx, map[string]string{"x-ms-cosmos-priority-level": "Low"})
+//     _, err := db.ExecContext(ctx, "INSERT INTO mydb.mycoll (id, name) VALUES (:1, :2)", "1", "user1", "1")
+//
+// Only headers whose name starts with "x-ms-" are sent, except x-ms-date which is part of the request signature. They
+// override the headers set by the client (e.g. x-ms-consistency-level). Headers attached to ctx are merged with the
+// ones of the returned context, the latter taking precedence.
+//
+// Available since v0.1.1
+func WithHeaders(ctx context.Context, headers map[string]string) context.Context {
+	merged := make(map[string]string)
+	for name, value := range HeadersFromContext(ctx) {
+		merged[http.CanonicalHeaderKey(name)] = value
+	}
+	for name, value := range headers {
+		merged[http.CanonicalHeaderKey(name)] = value
+	}
+	return context.WithValue(ctx, ctxKeyHeaders, merged)
+}
+
+// HeadersFromContext returns the custom request headers attached to ctx, nil if none.
+//
+// Available since v0.1.1
+func HeadersFromContext(ctx context.Context) map[string]string {
+	headers, _ := ctx.Value(ctxKeyHeaders).(map[string]string)
+	return headers
+}
+
+// WithActivityId returns a copy of ctx to which an activity id (a GUID) is attached. Requests sent on behalf of calls made
+// with the returned context carry it in header x-ms-activity-id, so that they can be correlated with the caller's own
+// traces and with the server's logs. See WithHeaders.
+//
+// Available since v0.1.1
+func WithActivityId(ctx context.Context, activityId string) context.Context {
+	return WithHeaders(ctx, map[string]string{"X-Ms-Activity-Id": activityId})
+}
+
+// ActivityIdFromContext returns the activity id attached to ctx, empty if none.
+//
+// Available since v0.1.1
+func ActivityIdFromContext(ctx context.Context) string {
+	return HeadersFromContext(ctx)["X-Ms-Activity-Id"]
+}
+
+// WithSessionToken returns a copy of ctx to which a session token is attached. Requests sent on behalf of calls made
+// with the returned context carry it in header x-ms-session-token instead of the session token tracked by the client,
+// so that a stateless tier can serve reads that observe the writes of its clients (read-your-writes), carrying the
+// tokens returned to them (see Diagnostics.SessionToken and RestReponse.SessionToken). See WithHeaders.
+//
+// Available since v0.1.1
+func WithSessionToken(ctx context.Context, sessionToken string) context.Context {
+	return WithHeaders(ctx, map[string]string{"X-Ms-Session-Token": sessionToken})
+}
+
+// SessionTokenFromContext returns the session token attached to ctx, empty if none.
+//
+// Available since v0.1.1
+func SessionTokenFromContext(ctx context.Context) string {
+	return HeadersFromContext(ctx)["X-Ms-Session-Token"]
+}
+
+// _isCustomHeader returns true if name is a header that can be set via WithHeaders or "WITH header.<name>=<value>".
+func _isCustomHeader(name string) bool {
+	name = strings.ToLower(name)
+	return strings.HasPrefix(name, "x-ms-") && name != "x-ms-date"
+}
+
+// _applyCustomHeaders sets the custom headers attached to the request's context (see WithHeaders).
+func _applyCustomHeaders(req *http.Request) {
+	for name, value := range HeadersFromContext(req.Context()) {
+		if _isCustomHeader(name) {
+			req.Header.Set(name, value)
+		}
+	}
+}
+
+// headerOptPrefix is the prefix of "WITH header.<name>=<value>" options.
+const headerOptPrefix = "HEADER."
+
+// _unquoteOptValue removes the quotes of a quoted "WITH <key>=<value>" option value.
+func _unquoteOptValue(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// _parseHeaderOpts extracts the custom request headers of "WITH header.<name>=<value>" options, and the session token
+// of the "WITH session_token=<token>" option, nil if none.
+func _parseHeaderOpts(withOpts map[string]string) (map[string]string, error) {
+	var headers map[string]string
+	for key, value := range withOpts {
+		name := ""
+		switch {
+		case strings.HasPrefix(key, headerOptPrefix):
+			if name = key[len(headerOptPrefix):]; !_isCustomHeader(name) {
+				return nil, fmt.Errorf("invalid header <%s>: only x-ms-* headers other than x-ms-date can be set", strings.ToLower(name))
+			}
+		case key == "SESSION_TOKEN":
+			if name = "X-Ms-Session-Token"; _unquoteOptValue(value) == "" {
+				return nil, errors.New("cannot parse query (session_token is empty)")
+			}
+		default:
+			continue
+		}
+		if headers == nil {
+			headers = make(map[string]string)
+		}
+		headers[http.CanonicalHeaderKey(name)] = _unquoteOptValue(value)
+	}
+	return headers, nil
+}
@@ -0,0 +1,166 @@
+package gocosmos
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWithHeaders(t *testing.T) {
+	name := "TestWithHeaders"
+	var lock sync.Mutex
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		received = r.Header.Clone()
+		lock.Unlock()
+		if r.Method == http.MethodPost && r.Header.Get("X-Ms-Documentdb-Isquery") == "" {
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"1"}`))
+			return
+		}
+		w.Write([]byte(`{"_count":1,"Documents":[{"id":"1"}]}`))
+	}))
+	defer server.Close()
+	db, _ := sql.Open("gocosmos", "AccountEndpoint="+server.URL+";AccountKey=cHJpbWFyeQ==;DefaultDb=db")
+	defer db.Close()
+	header := func(name string) string {
+		lock.Lock()
+		defer lock.Unlock()
+		return received.Get(name)
+	}
+
+	ctx := WithHeaders(context.Background(), map[string]string{"x-ms-cosmos-priority-level": "Low", "X-Ms-Date": "now", "Authorization": "forged"})
+	ctx = WithHeaders(ctx, map[string]string{"x-ms-consistency-level": "Eventual"})
+	if _, err := db.ExecContext(ctx, "INSERT INTO coll (id) VALUES (:1)", "1", "1"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if header("X-Ms-Cosmos-Priority-Level") != "Low" || header("X-Ms-Consistency-Level") != "Eventual" ||
+		header("X-Ms-Date") == "now" || header("Authorization") == "forged" {
+		t.Fatalf("%s failed: unexpected request headers %v", name, received)
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT * FROM c WITH collection=coll WITH header.x-ms-cosmos-priority-level="High" WITH header.x-ms-test=1`)
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	rows.Close()
+	if header("X-Ms-Cosmos-Priority-Level") != "High" || header("X-Ms-Test") != "1" || header("X-Ms-Consistency-Level") != "Eventual" {
+		t.Fatalf("%s failed: statement headers must be sent and take precedence, received %v", name, received)
+	}
+	if _, err := db.Exec("INSERT INTO coll (id) VALUES (:1)", "1", "1"); err != nil || header("X-Ms-Cosmos-Priority-Level") != "" {
+		t.Fatalf("%s failed: headers must not leak to other calls, received %v / %s", name, received, err)
+	}
+
+	for _, query := range []string{
+		"SELECT * FROM c WITH collection=coll WITH header.authorization=forged",
+		"SELECT * FROM c WITH collection=coll WITH header.x-ms-date=now",
+	} {
+		if _, err := db.Query(query); err == nil {
+			t.Fatalf("%s failed: query must not be parsed successfully: %s", name, query)
+		}
+	}
+	if HeadersFromContext(context.Background()) != nil {
+		t.Fatalf("%s failed: context without headers must return nil", name)
+	}
+}
+
+func TestWithActivityId(t *testing.T) {
+	name := "TestWithActivityId"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		activityId := r.Header.Get("X-Ms-Activity-Id")
+		if activityId == "" {
+			activityId = "server-generated"
+		}
+		w.Header().Set("X-Ms-Activity-Id", activityId)
+		if strings.HasSuffix(r.URL.Path, "/missing") {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":"NotFound","message":"Resource Not Found"}`))
+			return
+		}
+		w.Write([]byte(`{"id":"mydb"}`))
+	}))
+	defer server.Close()
+	client, _ := NewRestClient(nil, "AccountEndpoint="+server.URL+";AccountKey=cHJpbWFyeQ==")
+
+	if result := client.GetDatabase("mydb"); result.Error() != nil || result.ActivityId != "server-generated" {
+		t.Fatalf("%s failed: unexpected activity id %#v / %s", name, result.ActivityId, result.Error())
+	}
+
+	diag := NewDiagnostics()
+	ctx := WithActivityId(WithDiagnostics(context.Background(), diag), "3f0c7d4e-0000-4000-8000-000000000001")
+	if ActivityIdFromContext(ctx) != "3f0c7d4e-0000-4000-8000-000000000001" {
+		t.Fatalf("%s failed: unexpected activity id of context %#v", name, ActivityIdFromContext(ctx))
+	}
+	result := client.WithContext(ctx).GetDatabase("missing")
+	var cosmosErr *CosmosError
+	if !errors.As(result.Error(), &cosmosErr) || cosmosErr.ActivityID != "3f0c7d4e-0000-4000-8000-000000000001" ||
+		!strings.HasSuffix(result.Error().Error(), ";ActivityId=3f0c7d4e-0000-4000-8000-000000000001") {
+		t.Fatalf("%s failed: activity id must be surfaced in error, received %s", name, result.Error())
+	}
+	if attempts := diag.Attempts(); len(attempts) != 1 || attempts[0].ActivityId != "3f0c7d4e-0000-4000-8000-000000000001" {
+		t.Fatalf("%s failed: activity id must be recorded in diagnostics, received %#v", name, attempts)
+	}
+}
+
+func TestWithSessionToken(t *testing.T) {
+	name := "TestWithSessionToken"
+	var lock sync.Mutex
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		received = r.Header.Get("X-Ms-Session-Token")
+		lock.Unlock()
+		if r.Method == http.MethodPost && r.Header.Get("X-Ms-Documentdb-Isquery") == "" {
+			w.Header().Set("X-Ms-Session-Token", "0:1#5")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"1"}`))
+			return
+		}
+		w.Write([]byte(`{"_count":1,"Documents":[{"id":"1"}]}`))
+	}))
+	defer server.Close()
+	db, _ := sql.Open("gocosmos", "AccountEndpoint="+server.URL+";AccountKey=cHJpbWFyeQ==;DefaultDb=db")
+	defer db.Close()
+	token := func() string {
+		lock.Lock()
+		defer lock.Unlock()
+		return received
+	}
+
+	// the token returned by a write is handed to the client...
+	diag := NewDiagnostics()
+	if _, err := db.ExecContext(WithDiagnostics(context.Background(), diag), "INSERT INTO coll (id) VALUES (:1)", "1", "1"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if diag.SessionToken() != "0:1#5" {
+		t.Fatalf("%s failed: unexpected session token %q", name, diag.SessionToken())
+	}
+
+	// ...which supplies it to later reads, possibly served by other connections or processes
+	ctx := WithSessionToken(context.Background(), diag.SessionToken())
+	if SessionTokenFromContext(ctx) != "0:1#5" {
+		t.Fatalf("%s failed: unexpected session token of context %q", name, SessionTokenFromContext(ctx))
+	}
+	for query, expected := range map[string]string{
+		"SELECT * FROM c WITH collection=coll":                            "0:1#5",
+		`SELECT * FROM c WITH collection=coll WITH session_token="0:1#7"`: "0:1#7",
+	} {
+		rows, err := db.QueryContext(ctx, query)
+		if err != nil {
+			t.Fatalf("%s failed: <%s> %s", name, query, err)
+		}
+		rows.Close()
+		if token() != expected {
+			t.Fatalf("%s failed: <%s> expected session token %q but received %q", name, query, expected, token())
+		}
+	}
+	if _, err := db.Query(`SELECT * FROM c WITH collection=coll WITH session_token=""`); err == nil {
+		t.Fatalf("%s failed: empty session token must be rejected", name)
+	}
+}
@@ -0,0 +1,126 @@
+package gocosmos
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Interceptor intercepts the requests sent by a RestClient to Azure Cosmos DB, e.g. to inject custom headers, record
+// requests and responses, implement custom authentication or inject faults in tests, without forking the client.
+// Implementations must be safe for concurrent use.
+//
+// Interceptors are invoked for every attempt of a request (retries, regional failovers and account key fallbacks
+// included), after the request has been signed and before the circuit breaker sees the response: Before in the order
+// interceptors are supplied, After in reverse order.
+//
+// Example:
+//     client.SetInterceptors(gocosmos.InterceptorFuncs{
+//         BeforeFunc: func(req *http.Request) error {
+//             req.Header.Set("X-Tenant", tenant)
+//             return nil
+//         },
+//     })
+//
+// Available since v0.1.1
+type Interceptor interface {
+	// Before is called before the request is sent and may modify its headers. If it returns an error, the request is not
+	// sent and fails with the error; After of the interceptors supplied before this one are still called.
+	Before(req *http.Request) error
+
+	// After is called when the response is received, or the request failed with err (resp is nil). It returns the
+	// response and error to pass on: resp and err as-is, a synthetic response (e.g. StatusCode=429 to test throttling) or
+	// an error. If resp is replaced, the interceptor must close its body.
+	After(req *http.Request, resp *http.Response, err error) (*http.Response, error)
+}
+
+// InterceptorFuncs is an Interceptor built from functions, nil functions are no-ops.
+//
+// Available since v0.1.1
+type InterceptorFuncs struct {
+	BeforeFunc func(req *http.Request) error
+	AfterFunc  func(req *http.Request, resp *http.Response, err error) (*http.Response, error)
+}
+
+// Before implements Interceptor.Before.
+func (f InterceptorFuncs) Before(req *http.Request) error {
+	if f.BeforeFunc == nil {
+		return nil
+	}
+	return f.BeforeFunc(req)
+}
+
+// After implements Interceptor.After.
+func (f InterceptorFuncs) After(req *http.Request, resp *http.Response, err error) (*http.Response, error) {
+	if f.AfterFunc == nil {
+		return resp, err
+	}
+	return f.AfterFunc(req, resp, err)
+}
+
+// SetInterceptors replaces the chain of interceptors of the client's requests. Passing no interceptor removes the chain.
+// It must not be called while requests are being sent.
+//
+// Clients derived from the client (e.g. via WithContext) keep the chain the client had when they were derived, and
+// calling SetInterceptors on a derived client does not affect the client it was derived from.
+//
+// Available since v0.1.1
+func (c *RestClient) SetInterceptors(interceptors ...Interceptor) *RestClient {
+	c.interceptors = interceptors
+	return c
+}
+
+const ctxKeyInterceptors = ctxKey("interceptors")
+
+// withInterceptors returns a copy of req to which the client's interceptors are attached, see interceptorTransport.
+func (c *RestClient) withInterceptors(req *http.Request) *http.Request {
+	if len(c.interceptors) == 0 {
+		return req
+	}
+	return req.WithContext(context.WithValue(req.Context(), ctxKeyInterceptors, c.interceptors))
+}
+
+// interceptorTransport is a http.RoundTripper that passes requests through the interceptors attached to their context
+// by the RestClient that sends them (see RestClient.withInterceptors), so that clients sharing the transport (e.g. derived
+// via RestClient.WithContext) each invoke their own interceptors.
+type interceptorTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.RoundTrip.
+func (t *interceptorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	interceptors, _ := req.Context().Value(ctxKeyInterceptors).([]Interceptor)
+	if len(interceptors) == 0 {
+		return t.base.RoundTrip(req)
+	}
+	var resp *http.Response
+	var err error
+	n := 0
+	for ; n < len(interceptors); n++ {
+		if err = interceptors[n].Before(req); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		resp, err = t.base.RoundTrip(req)
+	}
+	for i := n - 1; i >= 0; i-- {
+		resp, err = interceptors[i].After(req, resp, err)
+	}
+	return resp, err
+}
+
+// _withInterceptors returns a copy of httpClient whose transport passes requests through their interceptors.
+func _withInterceptors(httpClient *http.Client, timeout time.Duration) *http.Client {
+	intercepted := &http.Client{Timeout: timeout}
+	if httpClient != nil {
+		copied := *httpClient
+		intercepted = &copied
+	}
+	base := intercepted.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	intercepted.Transport = &interceptorTransport{base: base}
+	return intercepted
+}
@@ -0,0 +1,126 @@
+package gocosmos
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRestClient_Interceptors(t *testing.T) {
+	name := "TestRestClient_Interceptors"
+	var tenants []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenants = append(tenants, r.Header.Get("X-Tenant"))
+		w.Write([]byte(`{"id":"mydb"}`))
+	}))
+	defer server.Close()
+	client, _ := NewRestClient(nil, "AccountEndpoint="+server.URL+";AccountKey=cHJpbWFyeQ==;TransientRetries=0")
+
+	var lock sync.Mutex
+	calls := make([]string, 0)
+	record := func(call string) {
+		lock.Lock()
+		defer lock.Unlock()
+		calls = append(calls, call)
+	}
+	client.SetInterceptors(
+		InterceptorFuncs{
+			BeforeFunc: func(req *http.Request) error {
+				record("before1")
+				req.Header.Set("X-Tenant", "tenant1")
+				return nil
+			},
+			AfterFunc: func(req *http.Request, resp *http.Response, err error) (*http.Response, error) {
+				record("after1")
+				return resp, err
+			},
+		},
+		InterceptorFuncs{
+			BeforeFunc: func(req *http.Request) error {
+				record("before2")
+				if req.Header.Get("Authorization") == "" {
+					return errors.New("request must be signed before being intercepted")
+				}
+				return nil
+			},
+			AfterFunc: func(req *http.Request, resp *http.Response, err error) (*http.Response, error) {
+				record("after2")
+				return resp, err
+			},
+		},
+	)
+	if result := client.GetDatabase("mydb"); result.Error() != nil || result.Id != "mydb" {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	}
+	if strings.Join(calls, ",") != "before1,before2,after2,after1" || strings.Join(tenants, ",") != "tenant1" {
+		t.Fatalf("%s failed: unexpected calls %v / headers %v", name, calls, tenants)
+	}
+
+	// fault injection: synthetic response and error
+	client.SetInterceptors(InterceptorFuncs{AfterFunc: func(req *http.Request, resp *http.Response, err error) (*http.Response, error) {
+		resp.Body.Close()
+		return &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"X-Ms-Retry-After-Ms": []string{"10"}},
+			Body: ioutil.NopCloser(strings.NewReader(`{"code":"TooManyRequests"}`)), Request: req}, nil
+	}})
+	if result := client.GetDatabase("mydb"); !errors.Is(result.Error(), ErrTooManyRequests) {
+		t.Fatalf("%s failed: expected injected StatusCode=429 but received %d / %s", name, result.StatusCode, result.Error())
+	}
+	injected := errors.New("injected")
+	calls = calls[:0]
+	client.SetInterceptors(
+		InterceptorFuncs{AfterFunc: func(req *http.Request, resp *http.Response, err error) (*http.Response, error) {
+			record("after1")
+			return resp, err
+		}},
+		InterceptorFuncs{BeforeFunc: func(req *http.Request) error { return injected }},
+		InterceptorFuncs{BeforeFunc: func(req *http.Request) error {
+			record("before3")
+			return nil
+		}},
+	)
+	numRequests := len(tenants)
+	if result := client.GetDatabase("mydb"); !errors.Is(result.CallErr, injected) {
+		t.Fatalf("%s failed: expected injected error but received %s", name, result.Error())
+	}
+	if strings.Join(calls, ",") != "after1" || len(tenants) != numRequests {
+		t.Fatalf("%s failed: request must not be sent after Before fails, calls %v", name, calls)
+	}
+
+	client.SetInterceptors()
+	if result := client.GetDatabase("mydb"); result.Error() != nil || tenants[len(tenants)-1] != "" {
+		t.Fatalf("%s failed: interceptors must be removed, received %s", name, result.Error())
+	}
+
+	// derived clients invoke their own interceptors
+	tenant := func(id string) Interceptor {
+		return InterceptorFuncs{BeforeFunc: func(req *http.Request) error {
+			req.Header.Set("X-Tenant", id)
+			return nil
+		}}
+	}
+	client.SetInterceptors(tenant("parent"))
+	derived := client.WithContext(context.Background()).SetInterceptors(tenant("derived"))
+	withDeadline := client.withDeadline(time.Now().Add(time.Minute))
+	client.SetInterceptors(tenant("parent2"))
+	for _, data := range []struct {
+		client   *RestClient
+		expected string
+	}{{derived, "derived"}, {client, "parent2"}, {withDeadline, "parent"}} {
+		if result := data.client.GetDatabase("mydb"); result.Error() != nil || tenants[len(tenants)-1] != data.expected {
+			t.Fatalf("%s failed: expected tenant %s but received %s / %s", name, data.expected, tenants[len(tenants)-1], result.Error())
+		}
+	}
+	derived.SetInterceptors()
+	if result := derived.GetDatabase("mydb"); result.Error() != nil || tenants[len(tenants)-1] != "" {
+		t.Fatalf("%s failed: interceptors of derived client must be removed, received %s", name, result.Error())
+	}
+	if result := client.GetDatabase("mydb"); result.Error() != nil || tenants[len(tenants)-1] != "parent2" {
+		t.Fatalf("%s failed: interceptors of parent client must be kept, received %s", name, result.Error())
+	}
+}
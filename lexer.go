@@ -0,0 +1,284 @@
+package gocosmos
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ParseError is returned when a statement cannot be parsed, pointing to the position of the offending token.
+//
+// Available since v0.1.1
+type ParseError struct {
+	Pos    int    // byte offset of the offending token in the statement (0-based)
+	Line   int    // line of the offending token (1-based)
+	Column int    // column of the offending token (1-based, in characters)
+	Msg    string // description of the error
+}
+
+// Error implements error.Error.
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("cannot parse query at line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+// newParseError builds a ParseError for the token at byte offset pos of the input.
+func newParseError(input string, pos int, format string, args ...interface{}) *ParseError {
+	if pos > len(input) {
+		pos = len(input)
+	}
+	before := input[:pos]
+	line := strings.Count(before, "\n") + 1
+	column := len([]rune(before[strings.LastIndex(before, "\n")+1:])) + 1
+	return &ParseError{Pos: pos, Line: line, Column: column, Msg: fmt.Sprintf(format, args...)}
+}
+
+type tokenKind int
+
+const (
+	tokEOF         tokenKind = iota
+	tokIdent                 // identifier or keyword, e.g. mydb, table-1 or WHERE
+	tokNumber                // number literal, e.g. 1, -2.5 or 1e3
+	tokString                // quoted string literal including the quotes, e.g. "abc" or 'abc'
+	tokPlaceholder           // placeholder, e.g. :1, @2 or $3
+	tokPunct                 // single punctuation character, e.g. ( ) , . =
+)
+
+// token is a lexical token of a statement.
+type token struct {
+	kind tokenKind
+	text string // text of the token as written in the statement
+	pos  int    // byte offset of the token in the statement
+}
+
+// is returns true if the token is the keyword kw (case-insensitive) or the punctuation kw.
+func (t token) is(kw string) bool {
+	return (t.kind == tokIdent || t.kind == tokPunct) && strings.EqualFold(t.text, kw)
+}
+
+// describe returns a human-readable description of the token, used in error messages.
+func (t token) describe() string {
+	if t.kind == tokEOF {
+		return "end of statement"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// lexer splits a statement into tokens on demand.
+type lexer struct {
+	input string
+	pos   int
+}
+
+func _isIdentStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func _isIdentPart(r rune) bool {
+	return r == '_' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func _isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+// skipSpaces moves the lexer past whitespaces.
+func (l *lexer) skipSpaces() {
+	for l.pos < len(l.input) {
+		r, size := utf8.DecodeRuneInString(l.input[l.pos:])
+		if !unicode.IsSpace(r) {
+			return
+		}
+		l.pos += size
+	}
+}
+
+// next returns the next token of the statement.
+func (l *lexer) next() (token, error) {
+	l.skipSpaces()
+	start := l.pos
+	if start >= len(l.input) {
+		return token{kind: tokEOF, pos: start}, nil
+	}
+	c := l.input[start]
+	r, size := utf8.DecodeRuneInString(l.input[start:])
+	switch {
+	case c == '"' || c == '\'':
+		for i := start + 1; i < len(l.input); i++ {
+			switch l.input[i] {
+			case '\\':
+				i++
+			case c:
+				l.pos = i + 1
+				return token{kind: tokString, text: l.input[start:l.pos], pos: start}, nil
+			}
+		}
+		return token{}, newParseError(l.input, start, "unterminated string literal")
+	case (c == '$' || c == '@' || c == ':') && start+1 < len(l.input) && _isDigit(l.input[start+1]):
+		l.pos = start + 1
+		for l.pos < len(l.input) && _isDigit(l.input[l.pos]) {
+			l.pos++
+		}
+		return token{kind: tokPlaceholder, text: l.input[start:l.pos], pos: start}, nil
+	case _isDigit(c) || ((c == '-' || c == '+' || c == '.') && start+1 < len(l.input) && (_isDigit(l.input[start+1]) || l.input[start+1] == '.')):
+		l.pos = start + 1
+		for l.pos < len(l.input) {
+			b := l.input[l.pos]
+			isExponentSign := (b == '+' || b == '-') && (l.input[l.pos-1] == 'e' || l.input[l.pos-1] == 'E')
+			if !(_isDigit(b) || b == '.' || _isIdentStart(rune(b)) || isExponentSign) {
+				break
+			}
+			l.pos++
+		}
+		return token{kind: tokNumber, text: l.input[start:l.pos], pos: start}, nil
+	case _isIdentStart(r):
+		l.pos = start + size
+		for l.pos < len(l.input) {
+			r, size := utf8.DecodeRuneInString(l.input[l.pos:])
+			if !_isIdentPart(r) {
+				break
+			}
+			l.pos += size
+		}
+		return token{kind: tokIdent, text: l.input[start:l.pos], pos: start}, nil
+	}
+	l.pos = start + size
+	return token{kind: tokPunct, text: l.input[start:l.pos], pos: start}, nil
+}
+
+// _skipLiteral returns the offset of the quote closing the string literal that starts at offset start of the query, or
+// len(query) if the literal is not terminated. Quotes escaped by a backslash do not close the literal.
+func _skipLiteral(query string, start int) int {
+	i := start + 1
+	for ; i < len(query) && query[i] != query[start]; i++ {
+		if query[i] == '\\' {
+			i++
+		}
+	}
+	if i > len(query) {
+		i = len(query)
+	}
+	return i
+}
+
+// _inLiteral returns true if offset pos of the query is inside a "...", '...' or `...` string literal.
+func _inLiteral(query string, pos int) bool {
+	for i := 0; i < pos && i < len(query); i++ {
+		if c := query[i]; c == '"' || c == '\'' || c == '`' {
+			if i = _skipLiteral(query, i); i >= pos {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var reWithClause = regexp.MustCompile(`(?is)\sWITH\s+[\w-]+\s*=`)
+
+// _splitWithClause splits the query into the statement and its trailing "WITH..." clause. The clause starts at the
+// first "WITH <key>=" outside of string literals, so that values such as "a WITH b=c" are kept in the statement.
+func _splitWithClause(query string) (stmt, withOptsStr string) {
+	for _, loc := range reWithClause.FindAllStringIndex(query, -1) {
+		if !_inLiteral(query, loc[0]) {
+			return strings.TrimSpace(query[:loc[0]]), strings.TrimSpace(query[loc[0]:])
+		}
+	}
+	return query, ""
+}
+
+var reScriptStmt = regexp.MustCompile(`(?is)^(CREATE|ALTER)\s+(PROCEDURE|FUNCTION|TRIGGER)\s`)
+
+// _stripComments replaces "-- line comments" and "/* block comments */" of the query with whitespaces, so that
+// positions of the remaining tokens are kept. Comment markers inside string literals are left intact.
+//
+// A comment must start the query or be preceded by a whitespace, so that values such as the path /* or /a--b in
+// "WITH exclude=/*" are not mistaken for comments. The JavaScript body of CREATE/ALTER PROCEDURE|FUNCTION|TRIGGER
+// (after the AS keyword) is kept verbatim.
+func _stripComments(query string) string {
+	isScript := reScriptStmt.MatchString(strings.TrimSpace(query))
+	buf := []byte(query)
+	blank := func(from, to int) {
+		for i := from; i < to; i++ {
+			if buf[i] != '\n' {
+				buf[i] = ' '
+			}
+		}
+	}
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		afterSpace := i == 0 || unicode.IsSpace(rune(query[i-1]))
+		switch {
+		case c == '"' || c == '\'' || c == '`':
+			i = _skipLiteral(query, i)
+		case afterSpace && strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			blank(i, i+end)
+			i += end
+		case afterSpace && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				blank(i, len(query))
+				return string(buf)
+			}
+			blank(i, i+2+end+2)
+			i += 2 + end + 1
+		case isScript && afterSpace && (c == 'A' || c == 'a') && len(query) > i+2 && (query[i+1] == 'S' || query[i+1] == 's') &&
+			unicode.IsSpace(rune(query[i+2])):
+			return string(buf)
+		}
+	}
+	return string(buf)
+}
+
+// keywords after which a "?" is a placeholder rather than the conditional (ternary) operator.
+var placeholderKeywords = map[string]bool{
+	"SELECT": true, "WHERE": true, "AND": true, "OR": true, "NOT": true, "IN": true, "LIKE": true, "BETWEEN": true,
+	"TOP": true, "OFFSET": true, "LIMIT": true, "SET": true, "VALUES": true, "THEN": true, "ELSE": true, "AS": true,
+	"DATA": true, "ESCAPE": true, "JSON": true, "DOCUMENT": true,
+}
+
+// _numberPlaceholders rewrites positional "?" placeholders of the query into numbered ones ($1, $2...), from left to
+// right. A "?" is a placeholder if it starts the query or follows a punctuation (other than a closing bracket, "/" or
+// ".") or one of placeholderKeywords; otherwise, as well as "??", it is an operator of the query (or part of an index
+// path such as /name/?) and kept as-is. String literals and the JavaScript body of CREATE/ALTER
+// PROCEDURE|FUNCTION|TRIGGER are not touched.
+//
+// "?" placeholders cannot be mixed with numbered ones in the same query.
+func _numberPlaceholders(query string) (string, error) {
+	if !strings.Contains(query, "?") {
+		return query, nil
+	}
+	isScript := reScriptStmt.MatchString(query)
+	l := &lexer{input: query}
+	buf := strings.Builder{}
+	last, prev, count := 0, token{kind: tokEOF}, 0
+	var numbered *token
+	for {
+		tok, err := l.next()
+		if err != nil || tok.kind == tokEOF || (isScript && tok.is("AS")) {
+			break
+		}
+		if tok.kind == tokPlaceholder && numbered == nil {
+			numbered = &tok
+		}
+		if tok.is("?") && !strings.HasPrefix(query[tok.pos+1:], "?") && !prev.is("?") &&
+			((prev.kind == tokPunct && !strings.ContainsAny(prev.text, ")]}/.")) || prev.kind == tokEOF ||
+				(prev.kind == tokIdent && placeholderKeywords[strings.ToUpper(prev.text)])) {
+			count++
+			buf.WriteString(query[last:tok.pos])
+			buf.WriteString("$" + strconv.Itoa(count))
+			last = tok.pos + 1
+		}
+		prev = tok
+	}
+	if count > 0 && numbered != nil {
+		return query, newParseError(query, numbered.pos, "cannot mix ? placeholders with numbered placeholder %s", numbered.text)
+	}
+	buf.WriteString(query[last:])
+	return buf.String(), nil
+}
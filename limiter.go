@@ -0,0 +1,124 @@
+package gocosmos
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RuLimiter keeps request unit (RU) consumption within a budget of RU/s, so that a hot code path does not consume the
+// throughput provisioned for the container and starve others.
+//
+// The request charge is only known once the response is received: before a request is sent, the limiter reserves the
+// average charge of previous requests, and adjusts the budget with the actual charge reported by the response
+// (header x-ms-request-charge). Requests are delayed while the budget is exhausted, and are sent once it has been
+// replenished, or immediately fail if the request's context is done.
+//
+// Available since v0.1.1
+type RuLimiter struct {
+	lock     sync.Mutex
+	rate     float64   // RU replenished per second
+	burst    float64   // maximum RU that can be accumulated
+	tokens   float64   // available RU, negative if in debt
+	last     time.Time // last time tokens were replenished
+	estimate float64   // moving average of request charges, reserved before a request is sent
+}
+
+// NewRuLimiter creates a new RuLimiter with a budget of ruPerSecond RU/s. Up to 1 second of unused budget can be
+// accumulated to serve bursts.
+func NewRuLimiter(ruPerSecond float64) *RuLimiter {
+	return &RuLimiter{rate: ruPerSecond, burst: ruPerSecond, tokens: ruPerSecond, last: time.Now(), estimate: 1}
+}
+
+// Budget returns the limiter's budget in RU/s.
+func (l *RuLimiter) Budget() float64 {
+	return l.rate
+}
+
+// Available returns the RU currently available, negative if consumption has exceeded the budget.
+func (l *RuLimiter) Available() float64 {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.replenish(time.Now())
+	return l.tokens
+}
+
+func (l *RuLimiter) replenish(now time.Time) {
+	if elapsed := now.Sub(l.last).Seconds(); elapsed > 0 {
+		l.tokens += elapsed * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+		l.last = now
+	}
+}
+
+// reserve waits until budget is available and reserves the estimated charge of a request, returning the reserved
+// amount and the time spent waiting.
+func (l *RuLimiter) reserve(ctx context.Context) (float64, time.Duration, error) {
+	start := time.Now()
+	for {
+		l.lock.Lock()
+		l.replenish(time.Now())
+		if l.tokens > 0 {
+			reserved := l.estimate
+			l.tokens -= reserved
+			l.lock.Unlock()
+			return reserved, time.Since(start), nil
+		}
+		wait := time.Duration(-l.tokens / l.rate * float64(time.Second))
+		l.lock.Unlock()
+		if wait < time.Millisecond {
+			wait = time.Millisecond
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return 0, time.Since(start), ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// settle adjusts the budget with the actual charge of a request for which reserved RU were reserved. A negative charge
+// means the charge is unknown (e.g. the request failed without response) and the reservation is released.
+func (l *RuLimiter) settle(reserved, charge float64) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if charge < 0 {
+		l.tokens += reserved
+		return
+	}
+	l.tokens += reserved - charge
+	l.estimate = 0.8*l.estimate + 0.2*charge
+}
+
+var (
+	sharedRuLimitersLock sync.Mutex
+	sharedRuLimiters     = make(map[string]*RuLimiter)
+)
+
+// _sharedRuLimiter returns the RuLimiter shared by all clients of the endpoint configured with the same budget, so that
+// the budget applies to all connections of a sql.DB.
+func _sharedRuLimiter(endpoint string, ruPerSecond float64) *RuLimiter {
+	key := endpoint + "|" + strconv.FormatFloat(ruPerSecond, 'f', -1, 64)
+	sharedRuLimitersLock.Lock()
+	defer sharedRuLimitersLock.Unlock()
+	limiter, ok := sharedRuLimiters[key]
+	if !ok {
+		limiter = NewRuLimiter(ruPerSecond)
+		sharedRuLimiters[key] = limiter
+	}
+	return limiter
+}
+
+// SetRuLimiter supplies the RuLimiter that keeps the client's RU consumption within a budget, replacing the one
+// configured via connection string option RuBudget. A limiter can be shared among clients. Passing nil disables limiting.
+//
+// Available since v0.1.1
+func (c *RestClient) SetRuLimiter(limiter *RuLimiter) *RestClient {
+	c.ruLimiter = limiter
+	return c
+}
@@ -0,0 +1,114 @@
+package gocosmos
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRuLimiter(t *testing.T) {
+	name := "TestRuLimiter"
+	limiter := NewRuLimiter(1000)
+	if limiter.Budget() != 1000 || limiter.Available() != 1000 {
+		t.Fatalf("%s failed: expected full budget but received %f", name, limiter.Available())
+	}
+	reserved, waited, err := limiter.reserve(context.Background())
+	if err != nil || reserved != 1 || waited >= time.Millisecond {
+		t.Fatalf("%s failed: unexpected reservation %f / %s / %s", name, reserved, waited, err)
+	}
+	limiter.settle(reserved, 1100)
+	if available := limiter.Available(); available > -90 {
+		t.Fatalf("%s failed: expected budget in debt but received %f", name, available)
+	}
+
+	// budget is in debt of ~100 RU, i.e. ~100ms at 1000 RU/s
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, _, err := limiter.reserve(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("%s failed: expected DeadlineExceeded but received %#v", name, err)
+	}
+	reserved, waited, err = limiter.reserve(context.Background())
+	if err != nil || waited < 50*time.Millisecond {
+		t.Fatalf("%s failed: expected request to be delayed but waited %s / %s", name, waited, err)
+	}
+	if reserved <= 1 {
+		t.Fatalf("%s failed: reservation must follow observed charges, received %f", name, reserved)
+	}
+
+	before := limiter.Available()
+	limiter.settle(reserved, -1)
+	if after := limiter.Available(); after < before+reserved-1 {
+		t.Fatalf("%s failed: reservation must be released if charge is unknown, %f -> %f", name, before, after)
+	}
+}
+
+func TestRestClient_RuBudget(t *testing.T) {
+	name := "TestRestClient_RuBudget"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Ms-Request-Charge", "1100")
+		w.Write([]byte(`{"id":"mydb"}`))
+	}))
+	defer server.Close()
+	connStr := "AccountEndpoint=" + server.URL + ";AccountKey=cHJpbWFyeQ==;RuBudget=1000"
+	client1, err := NewRestClient(nil, connStr)
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	client2, _ := NewRestClient(nil, connStr)
+	if client1.ruLimiter == nil || client1.ruLimiter != client2.ruLimiter {
+		t.Fatalf("%s failed: clients of the same endpoint and budget must share the limiter", name)
+	}
+	if client3, _ := NewRestClient(nil, connStr+"0"); client3.ruLimiter == client1.ruLimiter {
+		t.Fatalf("%s failed: clients with different budgets must not share the limiter", name)
+	}
+
+	start := time.Now()
+	client1.GetDatabase("mydb")
+	client2.GetDatabase("mydb")
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("%s failed: second request must be delayed, elapsed %s", name, elapsed)
+	}
+	client1.SetRuLimiter(nil)
+	start = time.Now()
+	client1.GetDatabase("mydb")
+	if elapsed := time.Since(start); elapsed >= 50*time.Millisecond {
+		t.Fatalf("%s failed: request must not be delayed once limiter is removed, elapsed %s", name, elapsed)
+	}
+
+	// requests waiting for the budget fail, without being sent, once their context is done
+	var count int32
+	countingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		w.Header().Set("X-Ms-Request-Charge", "1000")
+		w.Write([]byte(`{"id":"mydb"}`))
+	}))
+	defer countingServer.Close()
+	client4, _ := NewRestClient(nil, "AccountEndpoint="+countingServer.URL+";AccountKey=cHJpbWFyeQ==")
+	client4.SetRuLimiter(NewRuLimiter(10))
+	if result := client4.GetDatabase("mydb"); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if result := client4.WithContext(ctx).GetDatabase("mydb"); !errors.Is(result.CallErr, context.Canceled) {
+		t.Fatalf("%s failed: expected context.Canceled but received %#v", name, result.CallErr)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if result := client4.WithContext(ctx).GetDatabase("mydb"); !errors.Is(result.CallErr, context.DeadlineExceeded) {
+		t.Fatalf("%s failed: expected context.DeadlineExceeded but received %#v", name, result.CallErr)
+	}
+	if n := atomic.LoadInt32(&count); n != 1 {
+		t.Fatalf("%s failed: expected 1 request sent but received %d", name, n)
+	}
+
+	for _, invalid := range []string{"0", "-1", "abc"} {
+		if _, err := NewRestClient(nil, "AccountEndpoint="+server.URL+";AccountKey=cHJpbWFyeQ==;RuBudget="+invalid); err == nil {
+			t.Fatalf("%s failed: expected error for RuBudget=%s", name, invalid)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package gocosmos
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// LoadFormatNdjson specifies that input data is newline-delimited JSON, one document per line.
+	//
+	// Available since v0.1.1
+	LoadFormatNdjson = "ndjson"
+
+	// LoadFormatCsv specifies that input data is CSV, the first line is the header containing field names.
+	//
+	// Available since v0.1.1
+	LoadFormatCsv = "csv"
+)
+
+// LoadProgress reports the progress of a LoadDocuments call.
+//
+// Available since v0.1.1
+type LoadProgress struct {
+	Lines         int     // number of input lines (documents) processed so far
+	NumSucceeded  int     // number of documents written successfully so far
+	NumFailed     int     // number of documents failed so far
+	RequestCharge float64 // number of request units consumed so far
+}
+
+// LoadDocumentsReq specifies a request to load documents from a NDJSON/CSV stream into a collection.
+//
+// Available since v0.1.1
+type LoadDocumentsReq struct {
+	DbName, CollName string
+	// PartitionKeyPath is the partition key path (or comma-separated paths) of the collection. If empty, the paths are
+	// fetched from the collection's metadata.
+	PartitionKeyPath string
+	// Reader is the input data stream.
+	Reader io.Reader
+	// Format of input data, either LoadFormatNdjson (default) or LoadFormatCsv.
+	Format string
+	// IsUpsert specifies if documents are upserted (true) or inserted (false).
+	IsUpsert bool
+	// BatchSize is the number of documents sent to the bulk API at a time, default value is 100.
+	BatchSize int
+	// Concurrency and MaxRetries are passed to the bulk API, see BulkDocumentsReq.
+	Concurrency, MaxRetries int
+	// OnProgress (optional) is called after each batch has been written.
+	OnProgress func(progress LoadProgress)
+	// OnError (optional) is called for each input line that could not be parsed or written (line number is 1-based).
+	OnError func(line int, err error)
+}
+
+// RespLoadDocuments captures the response from LoadDocuments call.
+//
+// Available since v0.1.1
+type RespLoadDocuments struct {
+	LoadProgress
+	// CallErr holds error that stopped the load operation (e.g. the input stream could not be read).
+	CallErr error
+	// LineErrors holds error of each failed line, keyed by line number (1-based).
+	LineErrors map[int]error
+}
+
+// LoadDocuments streams documents from a NDJSON or CSV input into a collection using the bulk API.
+//
+// - NDJSON: each non-empty line is a JSON object.
+//
+// - CSV: the first line is the header containing field names. Each cell is parsed as a JSON value if possible (e.g. numbers,
+// booleans, null, objects or arrays), otherwise it is kept as a string. Field "id" is always a string.
+//
+// Available since v0.1.1
+func (c *RestClient) LoadDocuments(r LoadDocumentsReq) *RespLoadDocuments {
+	result := &RespLoadDocuments{LineErrors: make(map[int]error)}
+	if r.Reader == nil {
+		result.CallErr = errors.New("input reader is nil")
+		return result
+	}
+	pkPaths, err := c.fetchPkPaths(r.DbName, r.CollName, r.PartitionKeyPath)
+	if err != nil {
+		result.CallErr = err
+		return result
+	}
+	pkPath := strings.Join(pkPaths, ",")
+	batchSize := r.BatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	var nextDoc func() (map[string]interface{}, int, error)
+	switch strings.ToLower(r.Format) {
+	case "", LoadFormatNdjson:
+		nextDoc = _ndjsonDocReader(r.Reader)
+	case LoadFormatCsv:
+		nextDoc = _csvDocReader(r.Reader)
+	default:
+		result.CallErr = fmt.Errorf("unsupported format <%s>", r.Format)
+		return result
+	}
+
+	lineError := func(line int, err error) {
+		result.NumFailed++
+		result.LineErrors[line] = err
+		if r.OnError != nil {
+			r.OnError(line, err)
+		}
+	}
+	docs, lines := make([]map[string]interface{}, 0, batchSize), make([]int, 0, batchSize)
+	flush := func() {
+		if len(docs) > 0 {
+			bulkResult := c.bulkWriteDocuments(BulkDocumentsReq{DbName: r.DbName, CollName: r.CollName, PartitionKeyPath: pkPath,
+				Documents: docs, Concurrency: r.Concurrency, MaxRetries: r.MaxRetries}, r.IsUpsert)
+			for i, docResult := range bulkResult.Results {
+				if docResult.Error != nil {
+					lineError(lines[i], docResult.Error)
+				} else {
+					result.NumSucceeded++
+				}
+			}
+			result.RequestCharge += bulkResult.RequestCharge
+			docs, lines = docs[:0], lines[:0]
+		}
+		if r.OnProgress != nil {
+			r.OnProgress(result.LoadProgress)
+		}
+	}
+	for {
+		doc, line, err := nextDoc()
+		if err == io.EOF {
+			break
+		}
+		if line > result.Lines {
+			result.Lines = line
+		}
+		if err != nil {
+			if line <= 0 {
+				result.CallErr = err
+				break
+			}
+			lineError(line, err)
+			continue
+		}
+		if doc == nil {
+			continue
+		}
+		docs, lines = append(docs, doc), append(lines, line)
+		if len(docs) >= batchSize {
+			flush()
+		}
+	}
+	flush()
+	return result
+}
+
+// _ndjsonDocReader returns a function that reads the next document from a NDJSON stream.
+// Empty lines are skipped (nil document is returned). Line number <= 0 means a non-recoverable error.
+func _ndjsonDocReader(reader io.Reader) func() (map[string]interface{}, int, error) {
+	r := bufio.NewReader(reader)
+	lineNum := 0
+	return func() (map[string]interface{}, int, error) {
+		line, err := r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, 0, err
+		}
+		if err == io.EOF && line == "" {
+			return nil, lineNum, io.EOF
+		}
+		lineNum++
+		if line = strings.TrimSpace(line); line == "" {
+			return nil, lineNum, nil
+		}
+		var doc map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &doc); err != nil {
+			return nil, lineNum, err
+		}
+		return doc, lineNum, nil
+	}
+}
+
+// _csvDocReader returns a function that reads the next document from a CSV stream.
+// Line number is the record number counting the header as line 1. Line number <= 0 means a non-recoverable error.
+func _csvDocReader(reader io.Reader) func() (map[string]interface{}, int, error) {
+	r := csv.NewReader(reader)
+	r.FieldsPerRecord = -1
+	var header []string
+	lineNum := 1
+	return func() (map[string]interface{}, int, error) {
+		if header == nil {
+			var err error
+			if header, err = r.Read(); err != nil {
+				if err == io.EOF {
+					return nil, 0, err
+				}
+				return nil, 0, fmt.Errorf("cannot read CSV header: %s", err)
+			}
+		}
+		record, err := r.Read()
+		if err == io.EOF {
+			return nil, lineNum, err
+		}
+		if err != nil {
+			if perr, ok := err.(*csv.ParseError); ok {
+				lineNum = perr.Line
+				return nil, perr.Line, err
+			}
+			return nil, 0, err
+		}
+		lineNum++
+		line := lineNum
+		if len(record) != len(header) {
+			return nil, line, fmt.Errorf("number of fields (%d) does not match header (%d)", len(record), len(header))
+		}
+		doc := make(map[string]interface{})
+		for i, field := range header {
+			var value interface{}
+			if field == "id" || json.Unmarshal([]byte(record[i]), &value) != nil {
+				value = record[i]
+			}
+			doc[field] = value
+		}
+		return doc, line, nil
+	}
+}
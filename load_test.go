@@ -0,0 +1,138 @@
+package gocosmos
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func Test_ndjsonDocReader(t *testing.T) {
+	name := "Test_ndjsonDocReader"
+	nextDoc := _ndjsonDocReader(strings.NewReader("{\"id\":\"1\",\"v\":1}\n\n{invalid}\n{\"id\":\"2\"}"))
+	expected := []struct {
+		doc     map[string]interface{}
+		line    int
+		isError bool
+	}{
+		{doc: map[string]interface{}{"id": "1", "v": 1.0}, line: 1},
+		{doc: nil, line: 2},
+		{doc: nil, line: 3, isError: true},
+		{doc: map[string]interface{}{"id": "2"}, line: 4},
+	}
+	for _, e := range expected {
+		doc, line, err := nextDoc()
+		if (err != nil) != e.isError || line != e.line || !reflect.DeepEqual(doc, e.doc) {
+			t.Fatalf("%s failed: expected %#v/%d/%v but received %#v/%d/%s", name, e.doc, e.line, e.isError, doc, line, err)
+		}
+	}
+	if _, _, err := nextDoc(); err != io.EOF {
+		t.Fatalf("%s failed: expected EOF but received %#v", name, err)
+	}
+}
+
+func Test_csvDocReader(t *testing.T) {
+	name := "Test_csvDocReader"
+	nextDoc := _csvDocReader(strings.NewReader("id,username,age,active,tags\n1,user1,20,true,\"[\"\"a\"\"]\"\n2,user2\n3,\"user,3\",null,false,x\n"))
+	expected := []struct {
+		doc     map[string]interface{}
+		line    int
+		isError bool
+	}{
+		{doc: map[string]interface{}{"id": "1", "username": "user1", "age": 20.0, "active": true, "tags": []interface{}{"a"}}, line: 2},
+		{doc: nil, line: 3, isError: true},
+		{doc: map[string]interface{}{"id": "3", "username": "user,3", "age": nil, "active": false, "tags": "x"}, line: 4},
+	}
+	for _, e := range expected {
+		doc, line, err := nextDoc()
+		if (err != nil) != e.isError || line != e.line || !reflect.DeepEqual(doc, e.doc) {
+			t.Fatalf("%s failed: expected %#v/%d/%v but received %#v/%d/%s", name, e.doc, e.line, e.isError, doc, line, err)
+		}
+	}
+	if _, _, err := nextDoc(); err != io.EOF {
+		t.Fatalf("%s failed: expected EOF but received %#v", name, err)
+	}
+}
+
+func TestRestClient_LoadDocuments(t *testing.T) {
+	name := "TestRestClient_LoadDocuments"
+	client := _newRestClient(t, name)
+
+	dbname := "mydb"
+	collname := "mytable"
+	client.DeleteDatabase(dbname)
+	client.CreateDatabase(DatabaseSpec{Id: dbname})
+	client.CreateCollection(CollectionSpec{DbName: dbname, CollName: collname,
+		PartitionKeyInfo: map[string]interface{}{"paths": []string{"/username"}, "kind": "Hash"}})
+
+	numProgress := 0
+	errLines := make([]int, 0)
+	input := "{\"id\":\"1\",\"username\":\"user1\"}\n{\"id\":\"2\",\"username\":\"user2\"}\n{invalid}\n{\"id\":\"1\",\"username\":\"user1\"}\n{\"id\":\"3\",\"username\":\"user3\"}\n"
+	result := client.LoadDocuments(LoadDocumentsReq{DbName: dbname, CollName: collname, Reader: strings.NewReader(input), BatchSize: 2,
+		OnProgress: func(_ LoadProgress) { numProgress++ },
+		OnError:    func(line int, _ error) { errLines = append(errLines, line) }})
+	if result.CallErr != nil {
+		t.Fatalf("%s failed: %s", name, result.CallErr)
+	}
+	if result.Lines != 5 || result.NumSucceeded != 3 || result.NumFailed != 2 {
+		t.Fatalf("%s failed: expected 5 lines/3 succeeded/2 failed but received %#v", name, result.LoadProgress)
+	}
+	if _, ok := result.LineErrors[3]; !ok || len(errLines) != 2 || numProgress < 2 {
+		t.Fatalf("%s failed: unexpected line errors %#v / progress %d", name, result.LineErrors, numProgress)
+	}
+
+	input = "id,username,age\n1,user1,10\n4,user4,40\n"
+	result = client.LoadDocuments(LoadDocumentsReq{DbName: dbname, CollName: collname, Reader: strings.NewReader(input), Format: LoadFormatCsv, IsUpsert: true})
+	if result.CallErr != nil || result.NumSucceeded != 2 || result.NumFailed != 0 {
+		t.Fatalf("%s failed: %#v", name, result)
+	}
+	docResult := client.GetDocument(DocReq{DbName: dbname, CollName: collname, DocId: "1", PartitionKeyValues: []interface{}{"user1"}})
+	if docResult.Error() != nil || docResult.DocInfo["age"] != 10.0 {
+		t.Fatalf("%s failed: %#v", name, docResult.DocInfo)
+	}
+}
+
+func TestStmtLoad_AllowLoadLocalFiles(t *testing.T) {
+	name := "TestStmtLoad_AllowLoadLocalFiles"
+	var numRequests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&numRequests, 1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "load")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "data.ndjson")
+	ioutil.WriteFile(path, []byte(`{"id":"1"}`), 0644)
+	dsn := "AccountEndpoint=" + server.URL + ";AccountKey=cHJpbWFyeQ==;DefaultDb=db"
+	db, _ := sql.Open("gocosmos", dsn)
+	defer db.Close()
+	for query, args := range map[string][]interface{}{"LOAD '" + path + "' INTO coll": nil, "LOAD :1 INTO coll": {path}} {
+		if _, err := db.Exec(query, args...); err == nil || !strings.Contains(err.Error(), "AllowLoadLocalFiles") {
+			t.Fatalf("%s failed: <%s> must be rejected without AllowLoadLocalFiles=true, received %v", name, query, err)
+		}
+	}
+	if n := atomic.LoadInt32(&numRequests); n != 0 {
+		t.Fatalf("%s failed: rejected statement must not send requests, received %d", name, n)
+	}
+
+	dbAllowed, _ := sql.Open("gocosmos", dsn+";AllowLoadLocalFiles=true")
+	defer dbAllowed.Close()
+	if _, err := dbAllowed.Exec("LOAD :1 INTO coll", path+".notfound"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("%s failed: local file must be read with AllowLoadLocalFiles=true, received %v", name, err)
+	}
+	if cfg, err := ParseDSN(dsn + ";AllowLoadLocalFiles=true"); err != nil || !cfg.AllowLoadLocalFiles {
+		t.Fatalf("%s failed: unexpected config %#v / %s", name, cfg, err)
+	}
+}
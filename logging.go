@@ -0,0 +1,226 @@
+package gocosmos
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LogLevel is the severity of a log entry.
+//
+// Available since v0.1.1
+type LogLevel int
+
+const (
+	// LogLevelDebug is used for request/response summaries and executed statements.
+	LogLevelDebug LogLevel = iota
+	// LogLevelInfo is used for retry decisions that are part of normal operation, e.g. retrying throttled requests.
+	LogLevelInfo
+	// LogLevelWarn is used for failover decisions, throttled or failed requests and statements that cannot be parsed.
+	LogLevelWarn
+	// LogLevelError is used for requests that receive no response, e.g. network errors or timeouts.
+	LogLevelError
+)
+
+// String implements fmt.Stringer.String.
+func (l LogLevel) String() string {
+	switch l {
+	case LogLevelDebug:
+		return "DEBUG"
+	case LogLevelInfo:
+		return "INFO"
+	case LogLevelWarn:
+		return "WARN"
+	case LogLevelError:
+		return "ERROR"
+	}
+	return fmt.Sprintf("LogLevel(%d)", int(l))
+}
+
+// _parseLogLevel parses a log level (case-insensitive), default level is LogLevelInfo.
+func _parseLogLevel(level string) (LogLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return LogLevelDebug, nil
+	case "", "info":
+		return LogLevelInfo, nil
+	case "warn", "warning":
+		return LogLevelWarn, nil
+	case "error":
+		return LogLevelError, nil
+	}
+	return LogLevelInfo, fmt.Errorf("invalid LogLevel <%s>, supported values are: debug, info, warn, error", level)
+}
+
+// Logger receives structured log entries from the driver and the REST client. Implementations must be safe for
+// concurrent use.
+//
+// Entries below the minimum level (connection string option LogLevel, default "info") are not passed to the logger.
+// Statement parameter values are replaced by RedactedValue unless connection string option LogParams=true is specified;
+// account keys, tokens and document contents are never logged.
+//
+// Available since v0.1.1
+type Logger interface {
+	Log(level LogLevel, msg string, fields map[string]interface{})
+}
+
+// LoggerFunc is an adapter to allow the use of ordinary functions as Logger.
+//
+// Available since v0.1.1
+type LoggerFunc func(level LogLevel, msg string, fields map[string]interface{})
+
+// Log implements Logger.Log.
+func (f LoggerFunc) Log(level LogLevel, msg string, fields map[string]interface{}) {
+	f(level, msg, fields)
+}
+
+// RedactedValue replaces statement parameter values in log entries, see Logger.
+//
+// Available since v0.1.1
+const RedactedValue = "[REDACTED]"
+
+// NewStdLogger creates a Logger that writes entries to a standard log.Logger (log.Default() if nil), in the format
+// "[LEVEL] message key1=value1 key2=value2" with keys sorted.
+//
+// Available since v0.1.1
+func NewStdLogger(logger *log.Logger) Logger {
+	return LoggerFunc(func(level LogLevel, msg string, fields map[string]interface{}) {
+		keys := make([]string, 0, len(fields))
+		for k := range fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		sb := strings.Builder{}
+		sb.WriteString("[" + level.String() + "] " + msg)
+		for _, k := range keys {
+			sb.WriteString(fmt.Sprintf(" %s=%v", k, fields[k]))
+		}
+		if logger == nil {
+			log.Println(sb.String())
+		} else {
+			logger.Println(sb.String())
+		}
+	})
+}
+
+// SetLogger supplies the Logger the client writes log entries to. Passing nil disables logging.
+//
+// Available since v0.1.1
+func (c *RestClient) SetLogger(logger Logger) *RestClient {
+	c.logger = logger
+	return c
+}
+
+// log writes an entry to the client's logger, if any and level is at least the configured minimum level.
+func (c *RestClient) log(level LogLevel, msg string, fields map[string]interface{}) {
+	if c.logger != nil && level >= c.logLevel {
+		c.logger.Log(level, msg, fields)
+	}
+}
+
+// logEnabled returns true if entries of the level would be written to the client's logger.
+func (c *RestClient) logEnabled(level LogLevel) bool {
+	return c.logger != nil && level >= c.logLevel
+}
+
+// logRequest logs the summary of a request and its response.
+func (c *RestClient) logRequest(m RequestMetrics, path string, activityId string) {
+	level := LogLevelDebug
+	msg := "request completed"
+	switch {
+	case m.Err != nil:
+		level, msg = LogLevelError, "request failed"
+	case m.StatusCode == 429 || m.StatusCode >= 500:
+		level = LogLevelWarn
+	}
+	if !c.logEnabled(level) {
+		return
+	}
+	fields := map[string]interface{}{
+		"operation":      m.Operation,
+		"resource_type":  m.ResourceType,
+		"path":           path,
+		"status_code":    m.StatusCode,
+		"latency_ms":     m.Latency.Milliseconds(),
+		"request_charge": m.RequestCharge,
+	}
+	if activityId != "" {
+		fields["activity_id"] = activityId
+	}
+	if m.Err != nil {
+		fields["error"] = m.Err.Error()
+	}
+	c.log(level, msg, fields)
+}
+
+// _redactArgs returns statement arguments to be logged, values are replaced by RedactedValue unless logParams is true.
+func _redactArgs(args []driver.Value, logParams bool) []interface{} {
+	result := make([]interface{}, len(args))
+	for i, arg := range args {
+		if logParams {
+			result[i] = arg
+		} else {
+			result[i] = RedactedValue
+		}
+	}
+	return result
+}
+
+/*----------------------------------------------------------------------*/
+
+// loggedStmt wraps a statement to log its executions.
+type loggedStmt struct {
+	driver.Stmt
+	restClient *RestClient
+	query      string
+}
+
+func (s *loggedStmt) logExecution(args []driver.Value, start time.Time, err error) {
+	level := LogLevelDebug
+	if err != nil {
+		level = LogLevelWarn
+	}
+	if !s.restClient.logEnabled(level) {
+		return
+	}
+	fields := map[string]interface{}{
+		"query":       s.query,
+		"args":        _redactArgs(args, s.restClient.logParams),
+		"duration_ms": time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		fields["error"] = err.Error()
+		s.restClient.log(level, "statement failed", fields)
+	} else {
+		s.restClient.log(level, "statement executed", fields)
+	}
+}
+
+// baseStmt returns the base statement of the wrapped statement.
+func (s *loggedStmt) baseStmt() *Stmt {
+	return s.Stmt.(rebinder).baseStmt()
+}
+
+// rebind returns a copy of the statement whose wrapped statement is bound to conn, see _rebindStmt.
+func (s *loggedStmt) rebind(conn *Conn) driver.Stmt {
+	return &loggedStmt{Stmt: s.Stmt.(rebinder).rebind(conn), restClient: s.restClient, query: s.query}
+}
+
+// Exec implements driver.Stmt.Exec.
+func (s *loggedStmt) Exec(args []driver.Value) (driver.Result, error) {
+	start := time.Now()
+	result, err := s.Stmt.Exec(args)
+	s.logExecution(args, start, err)
+	return result, err
+}
+
+// Query implements driver.Stmt.Query.
+func (s *loggedStmt) Query(args []driver.Value) (driver.Rows, error) {
+	start := time.Now()
+	rows, err := s.Stmt.Query(args)
+	s.logExecution(args, start, err)
+	return rows, err
+}
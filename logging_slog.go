@@ -0,0 +1,39 @@
+//go:build go1.21
+// +build go1.21
+
+package gocosmos
+
+import (
+	"context"
+	"log/slog"
+)
+
+// NewSlogLogger creates a Logger that writes entries to a log/slog Logger (slog.Default() if nil), fields are added as
+// attributes. Requires Go 1.21+.
+//
+// Note: entries are filtered by the connection string option LogLevel before reaching the slog handler, which may apply
+// its own level.
+//
+// Available since v0.1.1
+func NewSlogLogger(logger *slog.Logger) Logger {
+	return LoggerFunc(func(level LogLevel, msg string, fields map[string]interface{}) {
+		l := logger
+		if l == nil {
+			l = slog.Default()
+		}
+		slogLevel := slog.LevelInfo
+		switch level {
+		case LogLevelDebug:
+			slogLevel = slog.LevelDebug
+		case LogLevelWarn:
+			slogLevel = slog.LevelWarn
+		case LogLevelError:
+			slogLevel = slog.LevelError
+		}
+		attrs := make([]slog.Attr, 0, len(fields))
+		for k, v := range fields {
+			attrs = append(attrs, slog.Any(k, v))
+		}
+		l.LogAttrs(context.Background(), slogLevel, msg, attrs...)
+	})
+}
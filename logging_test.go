@@ -0,0 +1,144 @@
+package gocosmos
+
+import (
+	"bytes"
+	"database/sql"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+type _logEntry struct {
+	level  LogLevel
+	msg    string
+	fields map[string]interface{}
+}
+
+type _recordingLogger struct {
+	lock    sync.Mutex
+	entries []_logEntry
+}
+
+func (l *_recordingLogger) Log(level LogLevel, msg string, fields map[string]interface{}) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.entries = append(l.entries, _logEntry{level, msg, fields})
+}
+
+func (l *_recordingLogger) find(level LogLevel, msg string) *_logEntry {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	for i := range l.entries {
+		if l.entries[i].level == level && l.entries[i].msg == msg {
+			return &l.entries[i]
+		}
+	}
+	return nil
+}
+
+func (l *_recordingLogger) count(level LogLevel, msg string) int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	n := 0
+	for _, entry := range l.entries {
+		if entry.level == level && entry.msg == msg {
+			n++
+		}
+	}
+	return n
+}
+
+func Test_parseLogLevel(t *testing.T) {
+	name := "Test_parseLogLevel"
+	for input, expected := range map[string]LogLevel{"": LogLevelInfo, "DEBUG": LogLevelDebug, "warn": LogLevelWarn, " error ": LogLevelError} {
+		if level, err := _parseLogLevel(input); err != nil || level != expected {
+			t.Fatalf("%s failed: <%s> expected %s but received %s / %s", name, input, expected, level, err)
+		}
+	}
+	if _, err := _parseLogLevel("verbose"); err == nil {
+		t.Fatalf("%s failed: expected error for invalid level", name)
+	}
+	if _, err := NewRestClient(nil, "AccountEndpoint=https://localhost:8081;AccountKey=cHJpbWFyeQ==;LogLevel=verbose"); err == nil {
+		t.Fatalf("%s failed: expected error for invalid LogLevel", name)
+	}
+	if _, err := NewRestClient(nil, "AccountEndpoint=https://localhost:8081;AccountKey=cHJpbWFyeQ==;LogParams=maybe"); err == nil {
+		t.Fatalf("%s failed: expected error for invalid LogParams", name)
+	}
+}
+
+func TestNewStdLogger(t *testing.T) {
+	name := "TestNewStdLogger"
+	buf := &bytes.Buffer{}
+	logger := NewStdLogger(log.New(buf, "", 0))
+	logger.Log(LogLevelWarn, "request failed", map[string]interface{}{"status_code": 503, "path": "/dbs"})
+	if expected := "[WARN] request failed path=/dbs status_code=503\n"; buf.String() != expected {
+		t.Fatalf("%s failed: expected %q but received %q", name, expected, buf.String())
+	}
+}
+
+func TestConnector_SetLogger(t *testing.T) {
+	name := "TestConnector_SetLogger"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Ms-Activity-Id", "activity-1")
+		if r.Method == "POST" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"id":"mydb"}`))
+	}))
+	defer server.Close()
+	for _, testCase := range []struct {
+		connStr   string
+		debug     bool
+		logParams bool
+	}{
+		{"", false, false},
+		{";LogLevel=debug", true, false},
+		{";LogLevel=debug;LogParams=true", true, true},
+	} {
+		connector, err := NewConnectorFromConnStr("AccountEndpoint=" + server.URL + ";AccountKey=cHJpbWFyeQ==" + testCase.connStr)
+		if err != nil {
+			t.Fatalf("%s failed: %s", name, err)
+		}
+		logger := &_recordingLogger{}
+		db := sql.OpenDB(connector.SetLogger(logger))
+
+		db.Exec("DROP DATABASE mydb")
+		db.Exec("CREATE DATABASE mydb")
+		db.Exec("INVALID STATEMENT")
+		db.Exec("DELETE FROM mydb.mycoll WHERE id=:1", "secret-id", "secret-pk")
+		db.Close()
+
+		if entry := logger.find(LogLevelWarn, "cannot parse statement"); entry == nil || entry.fields["query"] != "INVALID STATEMENT" {
+			t.Fatalf("%s failed: <%s> parse failure must be logged %#v", name, testCase.connStr, entry)
+		}
+		if entry := logger.find(LogLevelWarn, "request completed"); entry == nil || entry.fields["status_code"] != 503 ||
+			entry.fields["activity_id"] != "activity-1" || entry.fields["operation"] != "Create" {
+			t.Fatalf("%s failed: <%s> failed request must be logged %#v", name, testCase.connStr, entry)
+		}
+		entry := logger.find(LogLevelDebug, "statement executed")
+		if !testCase.debug {
+			if entry != nil || logger.find(LogLevelWarn, "statement failed") == nil {
+				t.Fatalf("%s failed: <%s> debug entries must not be logged", name, testCase.connStr)
+			}
+			continue
+		}
+		if entry == nil || entry.fields["query"] != "DROP DATABASE mydb" {
+			t.Fatalf("%s failed: <%s> executed statement must be logged %#v", name, testCase.connStr, entry)
+		}
+		for _, e := range logger.entries {
+			args, _ := e.fields["args"].([]interface{})
+			if len(args) != 2 {
+				continue
+			}
+			if redacted := args[0] == RedactedValue && args[1] == RedactedValue; redacted == testCase.logParams {
+				t.Fatalf("%s failed: <%s> unexpected args %#v", name, testCase.connStr, args)
+			}
+		}
+		if logger.find(LogLevelDebug, "request completed") == nil {
+			t.Fatalf("%s failed: <%s> requests must be logged at debug level", name, testCase.connStr)
+		}
+	}
+}
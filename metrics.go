@@ -0,0 +1,273 @@
+package gocosmos
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/btnguyen2k/consu/gjrc"
+)
+
+// RequestMetrics captures the metrics of a request sent to Azure Cosmos DB.
+//
+// Available since v0.1.1
+type RequestMetrics struct {
+	Operation     string        // operation of the request: Create, Upsert, Read, Replace, Delete, List, Query, ChangeFeed or Execute
+	ResourceType  string        // type of the target resource, e.g. "dbs", "colls", "docs", "offers", "sprocs"
+	StatusCode    int           // HTTP status code of the response, 0 if no response was received
+	Latency       time.Duration // total time of the request, including regional failover and account key fallback
+	RequestCharge float64       // request units consumed by the request, 0 if not reported
+	Err           error         // error if no response was received, e.g. timeout
+}
+
+// IsThrottled returns true if the request was rejected with StatusCode=429 (Too Many Requests).
+func (m RequestMetrics) IsThrottled() bool {
+	return m.StatusCode == http.StatusTooManyRequests
+}
+
+// MetricsReporter receives the metrics of every request sent by a RestClient. Implementations must be safe for
+// concurrent use and should return quickly, as ReportRequest is called synchronously after each request.
+//
+// See PrometheusReporter for a ready-made implementation.
+//
+// Available since v0.1.1
+type MetricsReporter interface {
+	ReportRequest(m RequestMetrics)
+}
+
+// SetMetricsReporter supplies the MetricsReporter the client reports metrics of requests to. Passing nil disables
+// reporting.
+//
+// Available since v0.1.1
+func (c *RestClient) SetMetricsReporter(reporter MetricsReporter) *RestClient {
+	c.metrics = reporter
+	return c
+}
+
+// _requestOperation determines the operation and resource type of a request.
+func _requestOperation(req *http.Request) (string, string) {
+	segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	isFeed := len(segments)%2 == 1 // e.g. dbs/mydb/colls is a feed, dbs/mydb/colls/mycoll is an item
+	resType := ""
+	if isFeed {
+		resType = segments[len(segments)-1]
+	} else if len(segments) >= 2 {
+		resType = segments[len(segments)-2]
+	}
+	switch req.Method {
+	case "POST":
+		switch {
+		case req.Header.Get("X-Ms-Documentdb-Isquery") == "true":
+			return "Query", resType
+		case req.Header.Get("X-Ms-Documentdb-Is-Upsert") == "true":
+			return "Upsert", resType
+		case !isFeed:
+			return "Execute", resType
+		}
+		return "Create", resType
+	case "GET":
+		switch {
+		case req.Header.Get("A-Im") != "":
+			return "ChangeFeed", resType
+		case isFeed && resType != "":
+			return "List", resType
+		}
+		return "Read", resType
+	case "PUT":
+		return "Replace", resType
+	case "DELETE":
+		return "Delete", resType
+	}
+	return req.Method, resType
+}
+
+// observe accumulates the metrics of a request into the client's statistics, reports them to the client's
+// MetricsReporter and logs the request's summary, if configured.
+func (c *RestClient) observe(req *http.Request, resp *gjrc.GjrcResponse, start time.Time) {
+	m := RequestMetrics{Latency: time.Since(start), Err: resp.Error()}
+	m.Operation, m.ResourceType = _requestOperation(req)
+	bytesReceived := 0
+	if m.Err == nil {
+		m.StatusCode = resp.StatusCode()
+		m.RequestCharge, _ = strconv.ParseFloat(resp.HttpResponse().Header.Get("X-Ms-Request-Charge"), 64)
+		body, _ := resp.Body()
+		bytesReceived = len(body)
+	}
+	c.stats.record(m, req.ContentLength, int64(bytesReceived))
+	if c.metrics != nil {
+		c.metrics.ReportRequest(m)
+	}
+	if c.logger != nil {
+		activityId := ""
+		if m.Err == nil {
+			activityId = resp.HttpResponse().Header.Get("X-Ms-Activity-Id")
+		}
+		c.logRequest(m, req.URL.Path, activityId)
+	}
+}
+
+/*----------------------------------------------------------------------*/
+
+// DefaultLatencyBuckets are the default upper bounds (in seconds) of PrometheusReporter's latency histogram buckets.
+//
+// Available since v0.1.1
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// PrometheusReporter is a MetricsReporter that aggregates metrics in-memory and exposes them in the Prometheus text
+// exposition format, without depending on the Prometheus client library. It serves the metrics as a http.Handler:
+//     reporter := gocosmos.NewPrometheusReporter("gocosmos")
+//     connector.SetMetricsReporter(reporter)
+//     http.Handle("/metrics", reporter)
+//
+// The following metrics are exposed (prefixed by the namespace):
+//     - requests_total{operation,resource_type,status_code}: number of requests.
+//     - throttled_requests_total{operation,resource_type}: number of requests rejected with StatusCode=429.
+//     - request_charge_total{operation,resource_type}: request units consumed.
+//     - request_duration_seconds{operation,resource_type}: histogram of request latency.
+//
+// Available since v0.1.1
+type PrometheusReporter struct {
+	namespace string
+	buckets   []float64
+	lock      sync.Mutex
+	requests  map[[3]string]float64 // operation, resource_type, status_code
+	throttled map[[2]string]float64 // operation, resource_type
+	charges   map[[2]string]float64
+	latencies map[[2]string]*latencyHistogram
+}
+
+type latencyHistogram struct {
+	counts []float64 // cumulative count per bucket
+	sum    float64
+	count  float64
+}
+
+// NewPrometheusReporter creates a new PrometheusReporter whose metric names are prefixed by namespace (e.g. "gocosmos"),
+// with DefaultLatencyBuckets as latency histogram buckets.
+func NewPrometheusReporter(namespace string) *PrometheusReporter {
+	return NewPrometheusReporterWithBuckets(namespace, DefaultLatencyBuckets)
+}
+
+// NewPrometheusReporterWithBuckets creates a new PrometheusReporter with custom latency histogram buckets (upper bounds
+// in seconds).
+func NewPrometheusReporterWithBuckets(namespace string, buckets []float64) *PrometheusReporter {
+	sortedBuckets := append([]float64(nil), buckets...)
+	sort.Float64s(sortedBuckets)
+	if namespace != "" && !strings.HasSuffix(namespace, "_") {
+		namespace += "_"
+	}
+	return &PrometheusReporter{
+		namespace: namespace,
+		buckets:   sortedBuckets,
+		requests:  make(map[[3]string]float64),
+		throttled: make(map[[2]string]float64),
+		charges:   make(map[[2]string]float64),
+		latencies: make(map[[2]string]*latencyHistogram),
+	}
+}
+
+// ReportRequest implements MetricsReporter.ReportRequest.
+func (r *PrometheusReporter) ReportRequest(m RequestMetrics) {
+	key := [2]string{m.Operation, m.ResourceType}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.requests[[3]string{m.Operation, m.ResourceType, strconv.Itoa(m.StatusCode)}]++
+	if m.IsThrottled() {
+		r.throttled[key]++
+	}
+	if m.RequestCharge > 0 {
+		r.charges[key] += m.RequestCharge
+	}
+	h, ok := r.latencies[key]
+	if !ok {
+		h = &latencyHistogram{counts: make([]float64, len(r.buckets))}
+		r.latencies[key] = h
+	}
+	seconds := m.Latency.Seconds()
+	for i, bound := range r.buckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += seconds
+	h.count++
+}
+
+func _formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func _sortedKeys2(m interface{}) [][2]string {
+	keys := make([][2]string, 0)
+	switch m := m.(type) {
+	case map[[2]string]float64:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[[2]string]*latencyHistogram:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i][0] < keys[j][0] || (keys[i][0] == keys[j][0] && keys[i][1] < keys[j][1])
+	})
+	return keys
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format.
+func (r *PrometheusReporter) WriteTo(w io.Writer) (int64, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	sb := &strings.Builder{}
+	labels := func(key [2]string) string {
+		return fmt.Sprintf(`operation=%q,resource_type=%q`, key[0], key[1])
+	}
+
+	fmt.Fprintf(sb, "# HELP %srequests_total Number of requests sent to Azure Cosmos DB.\n# TYPE %srequests_total counter\n", r.namespace, r.namespace)
+	requestKeys := make([][3]string, 0, len(r.requests))
+	for k := range r.requests {
+		requestKeys = append(requestKeys, k)
+	}
+	sort.Slice(requestKeys, func(i, j int) bool {
+		a, b := requestKeys[i], requestKeys[j]
+		return a[0] < b[0] || (a[0] == b[0] && (a[1] < b[1] || (a[1] == b[1] && a[2] < b[2])))
+	})
+	for _, k := range requestKeys {
+		fmt.Fprintf(sb, "%srequests_total{%s,status_code=%q} %s\n", r.namespace, labels([2]string{k[0], k[1]}), k[2], _formatFloat(r.requests[k]))
+	}
+
+	fmt.Fprintf(sb, "# HELP %sthrottled_requests_total Number of requests rejected with StatusCode=429.\n# TYPE %sthrottled_requests_total counter\n", r.namespace, r.namespace)
+	for _, k := range _sortedKeys2(r.throttled) {
+		fmt.Fprintf(sb, "%sthrottled_requests_total{%s} %s\n", r.namespace, labels(k), _formatFloat(r.throttled[k]))
+	}
+
+	fmt.Fprintf(sb, "# HELP %srequest_charge_total Request units consumed.\n# TYPE %srequest_charge_total counter\n", r.namespace, r.namespace)
+	for _, k := range _sortedKeys2(r.charges) {
+		fmt.Fprintf(sb, "%srequest_charge_total{%s} %s\n", r.namespace, labels(k), _formatFloat(r.charges[k]))
+	}
+
+	fmt.Fprintf(sb, "# HELP %srequest_duration_seconds Latency of requests.\n# TYPE %srequest_duration_seconds histogram\n", r.namespace, r.namespace)
+	for _, k := range _sortedKeys2(r.latencies) {
+		h := r.latencies[k]
+		for i, bound := range r.buckets {
+			fmt.Fprintf(sb, "%srequest_duration_seconds_bucket{%s,le=%q} %s\n", r.namespace, labels(k), _formatFloat(bound), _formatFloat(h.counts[i]))
+		}
+		fmt.Fprintf(sb, "%srequest_duration_seconds_bucket{%s,le=\"+Inf\"} %s\n", r.namespace, labels(k), _formatFloat(h.count))
+		fmt.Fprintf(sb, "%srequest_duration_seconds_sum{%s} %s\n", r.namespace, labels(k), _formatFloat(h.sum))
+		fmt.Fprintf(sb, "%srequest_duration_seconds_count{%s} %s\n", r.namespace, labels(k), _formatFloat(h.count))
+	}
+	n, err := io.WriteString(w, sb.String())
+	return int64(n), err
+}
+
+// ServeHTTP implements http.Handler.ServeHTTP, serving the metrics in the Prometheus text exposition format.
+func (r *PrometheusReporter) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WriteTo(w)
+}
@@ -0,0 +1,121 @@
+package gocosmos
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_requestOperation(t *testing.T) {
+	name := "Test_requestOperation"
+	testCases := []struct {
+		method, path string
+		headers      map[string]string
+		op, resType  string
+	}{
+		{"GET", "/", nil, "Read", ""},
+		{"POST", "/dbs", nil, "Create", "dbs"},
+		{"GET", "/dbs", nil, "List", "dbs"},
+		{"GET", "/dbs/mydb", nil, "Read", "dbs"},
+		{"DELETE", "/dbs/mydb/colls/mycoll", nil, "Delete", "colls"},
+		{"POST", "/dbs/mydb/colls/mycoll/docs", map[string]string{"X-Ms-Documentdb-Isquery": "true"}, "Query", "docs"},
+		{"POST", "/dbs/mydb/colls/mycoll/docs", map[string]string{"X-Ms-Documentdb-Is-Upsert": "true"}, "Upsert", "docs"},
+		{"GET", "/dbs/mydb/colls/mycoll/docs", map[string]string{"A-IM": "Incremental feed"}, "ChangeFeed", "docs"},
+		{"PUT", "/dbs/mydb/colls/mycoll/docs/1", nil, "Replace", "docs"},
+		{"POST", "/dbs/mydb/colls/mycoll/sprocs/hello", nil, "Execute", "sprocs"},
+		{"PUT", "/offers/abc", nil, "Replace", "offers"},
+	}
+	for _, testCase := range testCases {
+		req, _ := http.NewRequest(testCase.method, "http://localhost"+testCase.path, nil)
+		for k, v := range testCase.headers {
+			req.Header.Set(k, v)
+		}
+		if op, resType := _requestOperation(req); op != testCase.op || resType != testCase.resType {
+			t.Fatalf("%s failed: %s %s expected %s/%s but received %s/%s", name, testCase.method, testCase.path, testCase.op, testCase.resType, op, resType)
+		}
+	}
+}
+
+type _recordingReporter struct {
+	lock    sync.Mutex
+	metrics []RequestMetrics
+}
+
+func (r *_recordingReporter) ReportRequest(m RequestMetrics) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.metrics = append(r.metrics, m)
+}
+
+func TestRestClient_SetMetricsReporter(t *testing.T) {
+	name := "TestRestClient_SetMetricsReporter"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Ms-Request-Charge", "2.5")
+		if r.Method == "GET" {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"mydb"}`))
+	}))
+	defer server.Close()
+	client, err := NewRestClient(nil, "AccountEndpoint="+server.URL+";AccountKey=cHJpbWFyeQ==")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	reporter := &_recordingReporter{}
+	client.SetMetricsReporter(reporter)
+	client.CreateDatabase(DatabaseSpec{Id: "mydb"})
+	client.GetDatabase("mydb")
+	if len(reporter.metrics) != 2 {
+		t.Fatalf("%s failed: expected 2 reported requests but received %d", name, len(reporter.metrics))
+	}
+	m := reporter.metrics[0]
+	if m.Operation != "Create" || m.ResourceType != "dbs" || m.StatusCode != 201 || m.RequestCharge != 2.5 || m.Latency <= 0 || m.IsThrottled() {
+		t.Fatalf("%s failed: unexpected metrics %#v", name, m)
+	}
+	if m = reporter.metrics[1]; m.Operation != "Read" || !m.IsThrottled() {
+		t.Fatalf("%s failed: unexpected metrics %#v", name, m)
+	}
+
+	client.SetMetricsReporter(nil)
+	client.GetDatabase("mydb")
+	if len(reporter.metrics) != 2 {
+		t.Fatalf("%s failed: metrics must not be reported after reporter is removed", name)
+	}
+}
+
+func TestPrometheusReporter(t *testing.T) {
+	name := "TestPrometheusReporter"
+	reporter := NewPrometheusReporterWithBuckets("test", []float64{1, 0.1})
+	reporter.ReportRequest(RequestMetrics{Operation: "Query", ResourceType: "docs", StatusCode: 200, Latency: 50 * time.Millisecond, RequestCharge: 3})
+	reporter.ReportRequest(RequestMetrics{Operation: "Query", ResourceType: "docs", StatusCode: 200, Latency: 500 * time.Millisecond, RequestCharge: 2.5})
+	reporter.ReportRequest(RequestMetrics{Operation: "Query", ResourceType: "docs", StatusCode: 429, Latency: 5 * time.Second})
+
+	recorder := httptest.NewRecorder()
+	reporter.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	output := recorder.Body.String()
+	for _, expected := range []string{
+		"# TYPE test_requests_total counter\n",
+		`test_requests_total{operation="Query",resource_type="docs",status_code="200"} 2` + "\n",
+		`test_requests_total{operation="Query",resource_type="docs",status_code="429"} 1` + "\n",
+		`test_throttled_requests_total{operation="Query",resource_type="docs"} 1` + "\n",
+		`test_request_charge_total{operation="Query",resource_type="docs"} 5.5` + "\n",
+		"# TYPE test_request_duration_seconds histogram\n",
+		`test_request_duration_seconds_bucket{operation="Query",resource_type="docs",le="0.1"} 1` + "\n",
+		`test_request_duration_seconds_bucket{operation="Query",resource_type="docs",le="1"} 2` + "\n",
+		`test_request_duration_seconds_bucket{operation="Query",resource_type="docs",le="+Inf"} 3` + "\n",
+		`test_request_duration_seconds_sum{operation="Query",resource_type="docs"} 5.55` + "\n",
+		`test_request_duration_seconds_count{operation="Query",resource_type="docs"} 3` + "\n",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Fatalf("%s failed: expected %q in output\n%s", name, expected, output)
+		}
+	}
+	if contentType := recorder.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain") {
+		t.Fatalf("%s failed: unexpected content type %s", name, contentType)
+	}
+}
@@ -0,0 +1,268 @@
+/*
+Package migrations applies ordered SQL scripts (see SQL.md for supported statements) to an Azure Cosmos DB account via
+the gocosmos driver, and records applied versions in a dedicated collection so that each script is applied only once.
+
+Scripts are usually stored in a directory, one file per migration, named "<version>_<name>.sql" where version is a
+positive integer, e.g.:
+
+	0001_create_users.sql
+	0002_seed_admin.sql
+
+A script contains statements terminated by ";" at the end of a line (semicolons inside string literals or brackets, e.g.
+bodies of stored procedures, do not terminate statements). Lines starting with "--" are comments:
+
+	-- 0001_create_users.sql
+	CREATE COLLECTION IF NOT EXISTS mydb.users WITH pk=/tenant WITH uk=/email;
+	CREATE PROCEDURE IF NOT EXISTS mydb.users.hello AS function() { getContext().getResponse().setBody("hello"); };
+
+Usage:
+
+	migs, err := migrations.LoadDir("./migrations")
+	runner := migrations.NewRunner(db, "mydb")
+	applied, err := runner.Up(context.Background(), migs)
+
+Cosmos DB does not support transactional DDL: if a statement fails, the migration is not recorded and Up stops, leaving
+statements that were executed before the failure in place. Writing idempotent statements (e.g. IF NOT EXISTS, UPSERT)
+allows the migration to be simply re-run once the failure is fixed.
+
+Available since v0.1.1
+*/
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultCollName is the default name of the collection that records applied migrations.
+const DefaultCollName = "schema_migrations"
+
+// Migration is a versioned list of statements.
+type Migration struct {
+	Version    int64
+	Name       string
+	Statements []string
+}
+
+var (
+	// ErrUnterminatedString is returned by ParseScript if a script contains an unterminated string literal.
+	ErrUnterminatedString = errors.New("unterminated string literal")
+
+	// ErrDuplicatedVersion is returned by LoadDir and Runner.Up if migrations have the same version.
+	ErrDuplicatedVersion = errors.New("duplicated migration version")
+)
+
+// ParseScript splits a script into statements. Statements are terminated by ";" at the end of a line, outside of string
+// literals and brackets (so that e.g. "WITH composite=/a,/b;/c" is not split); lines starting with "--" are comments. A
+// final statement without terminating ";" is accepted.
+func ParseScript(script string) ([]string, error) {
+	statements := make([]string, 0)
+	current := strings.Builder{}
+	var quote rune // quote character of the string literal being scanned, 0 if none
+	escaped, depth, lineStart := false, 0, true
+	runes := []rune(script)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if lineStart && quote == 0 && depth == 0 {
+			// skip leading whitespace to detect comment lines
+			for i < len(runes) && (runes[i] == ' ' || runes[i] == '\t') {
+				i++
+			}
+			if i+1 < len(runes) && runes[i] == '-' && runes[i+1] == '-' {
+				for i < len(runes) && runes[i] != '\n' {
+					i++
+				}
+			}
+			if i >= len(runes) {
+				break
+			}
+			r = runes[i]
+		}
+		lineStart = r == '\n'
+		switch {
+		case quote != 0:
+			if escaped {
+				escaped = false
+			} else if r == '\\' {
+				escaped = true
+			} else if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '{' || r == '[' || r == '(':
+			depth++
+		case (r == '}' || r == ']' || r == ')') && depth > 0:
+			depth--
+		case r == ';' && depth == 0 && _isEndOfLine(runes[i+1:]):
+			if stmt := strings.TrimSpace(current.String()); stmt != "" {
+				statements = append(statements, stmt)
+			}
+			current.Reset()
+			continue
+		}
+		current.WriteRune(r)
+	}
+	if quote != 0 {
+		return nil, ErrUnterminatedString
+	}
+	if stmt := strings.TrimSpace(current.String()); stmt != "" {
+		statements = append(statements, stmt)
+	}
+	return statements, nil
+}
+
+// _isEndOfLine checks if the remaining of the current line contains only whitespaces.
+func _isEndOfLine(runes []rune) bool {
+	for _, r := range runes {
+		if r == '\n' {
+			return true
+		}
+		if r != ' ' && r != '\t' && r != '\r' {
+			return false
+		}
+	}
+	return true
+}
+
+var reScriptFile = regexp.MustCompile(`^(\d+)_(.+)\.sql$`)
+
+// LoadDir loads migrations from script files "<version>_<name>.sql" in a directory, sorted by version. Other files are
+// ignored.
+func LoadDir(dir string) ([]Migration, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	migrations := make([]Migration, 0)
+	for _, file := range files {
+		groups := reScriptFile.FindStringSubmatch(file.Name())
+		if file.IsDir() || groups == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(groups[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid version of migration script %s", file.Name())
+		}
+		script, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		statements, err := ParseScript(string(script))
+		if err != nil {
+			return nil, fmt.Errorf("migration script %s: %s", file.Name(), err)
+		}
+		migrations = append(migrations, Migration{Version: version, Name: groups[2], Statements: statements})
+	}
+	return migrations, _sortMigrations(migrations)
+}
+
+// _sortMigrations sorts migrations by version, returning ErrDuplicatedVersion if versions are not unique.
+func _sortMigrations(migrations []Migration) error {
+	sort.SliceStable(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return fmt.Errorf("%w: %d", ErrDuplicatedVersion, migrations[i].Version)
+		}
+	}
+	return nil
+}
+
+// Runner applies migrations to a database opened with the gocosmos driver.
+type Runner struct {
+	DB       *sql.DB
+	DbName   string // database of the collection that records applied migrations
+	CollName string // name of the collection that records applied migrations, default is DefaultCollName
+}
+
+// NewRunner creates a new Runner that records applied migrations in collection DefaultCollName of database dbName.
+func NewRunner(db *sql.DB, dbName string) *Runner {
+	return &Runner{DB: db, DbName: dbName, CollName: DefaultCollName}
+}
+
+// init creates the database and collection that record applied migrations if not exist.
+func (r *Runner) init(ctx context.Context) error {
+	if _, err := r.DB.ExecContext(ctx, "CREATE DATABASE IF NOT EXISTS "+r.DbName); err != nil {
+		return err
+	}
+	_, err := r.DB.ExecContext(ctx, "CREATE COLLECTION IF NOT EXISTS "+r.DbName+"."+r.CollName+" WITH pk=/id")
+	return err
+}
+
+// Applied returns versions of applied migrations.
+func (r *Runner) Applied(ctx context.Context) (map[int64]bool, error) {
+	if err := r.init(ctx); err != nil {
+		return nil, err
+	}
+	rows, err := r.DB.QueryContext(ctx, "SELECT CROSS PARTITION c.id FROM c WITH db="+r.DbName+" WITH collection="+r.CollName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	versions := make(map[int64]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		if version, err := strconv.ParseInt(id, 10, 64); err == nil {
+			versions[version] = true
+		}
+	}
+	return versions, rows.Err()
+}
+
+// Up applies, in order of version, migrations that have not been applied yet and returns them. If a migration fails, Up
+// stops and returns the migrations applied so far together with the error.
+func (r *Runner) Up(ctx context.Context, migrations []Migration) ([]Migration, error) {
+	migrations = append([]Migration(nil), migrations...)
+	if err := _sortMigrations(migrations); err != nil {
+		return nil, err
+	}
+	applied, err := r.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Migration, 0)
+	for _, m := range migrations {
+		if applied[m.Version] {
+			continue
+		}
+		for _, stmt := range m.Statements {
+			if err := r.exec(ctx, stmt); err != nil {
+				return result, fmt.Errorf("migration %d_%s: %w", m.Version, m.Name, err)
+			}
+		}
+		id := strconv.FormatInt(m.Version, 10)
+		_, err := r.DB.ExecContext(ctx, "INSERT INTO "+r.DbName+"."+r.CollName+" (id, name, appliedAt) VALUES (:1, :2, :3)",
+			id, m.Name, time.Now().UTC().Format(time.RFC3339), id)
+		if err != nil {
+			return result, fmt.Errorf("migration %d_%s: %w", m.Version, m.Name, err)
+		}
+		result = append(result, m)
+	}
+	return result, nil
+}
+
+// exec executes a statement, using sql.DB.Query for statements that return rows.
+func (r *Runner) exec(ctx context.Context, stmt string) error {
+	keyword := strings.ToUpper(strings.Fields(stmt)[0])
+	if keyword != "SELECT" && keyword != "LIST" {
+		_, err := r.DB.ExecContext(ctx, stmt)
+		return err
+	}
+	rows, err := r.DB.QueryContext(ctx, stmt)
+	if err != nil {
+		return err
+	}
+	return rows.Close()
+}
@@ -0,0 +1,167 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	_ "github.com/btnguyen2k/gocosmos"
+)
+
+func TestParseScript(t *testing.T) {
+	name := "TestParseScript"
+	script := `-- create collection
+CREATE COLLECTION IF NOT EXISTS mydb.users WITH pk=/tenant WITH composite=/a,/b;/c ;
+  -- stored procedure
+CREATE PROCEDURE mydb.users.hello AS function() {
+  var msg = "hello; world";
+  getContext().getResponse().setBody(msg);
+};
+INSERT INTO mydb.users (id, note) VALUES ("1", "\"a;\nb\"")`
+	statements, err := ParseScript(script)
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	expected := []string{
+		"CREATE COLLECTION IF NOT EXISTS mydb.users WITH pk=/tenant WITH composite=/a,/b;/c",
+		"CREATE PROCEDURE mydb.users.hello AS function() {\n  var msg = \"hello; world\";\n  getContext().getResponse().setBody(msg);\n}",
+		`INSERT INTO mydb.users (id, note) VALUES ("1", "\"a;\nb\"")`,
+	}
+	if !reflect.DeepEqual(statements, expected) {
+		t.Fatalf("%s failed: expected %#v but received %#v", name, expected, statements)
+	}
+
+	if _, err := ParseScript(`INSERT INTO mydb.users (id) VALUES ("1);`); err != ErrUnterminatedString {
+		t.Fatalf("%s failed: expected ErrUnterminatedString but received %#v", name, err)
+	}
+	if statements, _ := ParseScript("-- nothing\n  \n"); len(statements) != 0 {
+		t.Fatalf("%s failed: expected no statement but received %#v", name, statements)
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	name := "TestLoadDir"
+	dir, err := ioutil.TempDir("", "migrations")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "0010_seed.sql"), []byte("INSERT INTO mydb.users (id) VALUES (\"1\");\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "2_create_users.sql"), []byte("CREATE DATABASE mydb;\nCREATE COLLECTION mydb.users WITH pk=/id;\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte("not a migration"), 0644)
+	migrations, err := LoadDir(dir)
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if len(migrations) != 2 || migrations[0].Version != 2 || migrations[0].Name != "create_users" || len(migrations[0].Statements) != 2 ||
+		migrations[1].Version != 10 || migrations[1].Name != "seed" {
+		t.Fatalf("%s failed: unexpected migrations %#v", name, migrations)
+	}
+
+	ioutil.WriteFile(filepath.Join(dir, "02_duplicated.sql"), []byte("CREATE DATABASE mydb;"), 0644)
+	if _, err := LoadDir(dir); !errors.Is(err, ErrDuplicatedVersion) {
+		t.Fatalf("%s failed: expected ErrDuplicatedVersion but received %#v", name, err)
+	}
+	if _, err := LoadDir(filepath.Join(dir, "notfound")); err == nil {
+		t.Fatalf("%s failed: expected error for non-existing directory", name)
+	}
+}
+
+type _fakeCosmos struct {
+	lock       sync.Mutex
+	colls      map[string]bool
+	migrations map[string]map[string]interface{}
+}
+
+func (f *_fakeCosmos) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	var body map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&body)
+	switch {
+	case r.Method == "POST" && r.URL.Path == "/dbs":
+		w.WriteHeader(http.StatusConflict)
+	case r.Method == "POST" && r.URL.Path == "/dbs/mydb/colls":
+		id, _ := body["id"].(string)
+		if f.colls[id] {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		f.colls[id] = true
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"` + id + `"}`))
+	case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/dbs/mydb/colls/") && !strings.Contains(r.URL.Path, "/docs"):
+		w.Write([]byte(`{"id":"coll","partitionKey":{"paths":["/id"],"kind":"Hash"}}`))
+	case r.Method == "POST" && r.URL.Path == "/dbs/mydb/colls/schema_migrations/docs" && r.Header.Get("X-Ms-Documentdb-Isquery") == "true":
+		docs := make([]map[string]interface{}, 0)
+		for id := range f.migrations {
+			docs = append(docs, map[string]interface{}{"id": id})
+		}
+		js, _ := json.Marshal(map[string]interface{}{"Documents": docs, "_count": len(docs)})
+		w.Write(js)
+	case r.Method == "POST" && r.URL.Path == "/dbs/mydb/colls/schema_migrations/docs":
+		id, _ := body["id"].(string)
+		f.migrations[id] = body
+		w.WriteHeader(http.StatusCreated)
+		js, _ := json.Marshal(body)
+		w.Write(js)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestRunner_Up(t *testing.T) {
+	name := "TestRunner_Up"
+	fake := &_fakeCosmos{colls: make(map[string]bool), migrations: make(map[string]map[string]interface{})}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	db, err := sql.Open("gocosmos", "AccountEndpoint="+server.URL+";AccountKey=C2y6yDjf5/R+ob0N8A7Cgv30VRDJIWEHLM+4QDU5DE2nQ9nDuVTqobD4b8mGGyPMbIZnqyMsEcaGQy67XIw/Jw==")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	defer db.Close()
+	runner := NewRunner(db, "mydb")
+	migrations := []Migration{
+		{Version: 2, Name: "create_orders", Statements: []string{"CREATE COLLECTION mydb.orders WITH pk=/id"}},
+		{Version: 1, Name: "create_users", Statements: []string{"CREATE COLLECTION mydb.users WITH pk=/id"}},
+	}
+	applied, err := runner.Up(context.Background(), migrations)
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if len(applied) != 2 || applied[0].Version != 1 || applied[1].Version != 2 {
+		t.Fatalf("%s failed: unexpected applied migrations %#v", name, applied)
+	}
+	if !fake.colls["users"] || !fake.colls["orders"] || fake.migrations["1"]["name"] != "create_users" || fake.migrations["2"] == nil {
+		t.Fatalf("%s failed: migrations must be applied and recorded", name)
+	}
+
+	// applied migrations are skipped; a failed migration stops the run
+	migrations = append(migrations, Migration{Version: 3, Name: "fail", Statements: []string{"CREATE COLLECTION mydb.users WITH pk=/id"}},
+		Migration{Version: 4, Name: "create_items", Statements: []string{"CREATE COLLECTION mydb.items WITH pk=/id"}})
+	applied, err = runner.Up(context.Background(), migrations)
+	if err == nil || !strings.Contains(err.Error(), "3_fail") || len(applied) != 0 {
+		t.Fatalf("%s failed: expected error of migration 3 but received %#v / %#v", name, err, applied)
+	}
+	if fake.migrations["3"] != nil || fake.colls["items"] {
+		t.Fatalf("%s failed: failed migration must not be recorded", name)
+	}
+
+	versions, err := runner.Applied(context.Background())
+	if err != nil || !reflect.DeepEqual(versions, map[int64]bool{1: true, 2: true}) {
+		t.Fatalf("%s failed: unexpected applied versions %#v / %s", name, versions, err)
+	}
+	if _, err := runner.Up(context.Background(), []Migration{{Version: 1}, {Version: 1}}); !errors.Is(err, ErrDuplicatedVersion) {
+		t.Fatalf("%s failed: expected ErrDuplicatedVersion but received %#v", name, err)
+	}
+}
@@ -0,0 +1,405 @@
+package gocosmos
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// astDml is the syntax tree of a data-manipulation statement (INSERT, UPSERT, UPDATE or DELETE).
+type astDml struct {
+	verb        string        // INSERT, UPSERT, UPDATE or DELETE (upper case)
+	dbName      string        // database name, "" if not specified
+	collName    string        // collection name
+	fields      []string      // INSERT/UPSERT: field list; UPDATE: fields of the SET clause
+	values      []interface{} // values matching fields, see parser.parseValue
+	idStr       string        // UPDATE/DELETE: id value as written in the WHERE clause
+	etag        interface{}   // UPDATE/DELETE: etag condition, nil if not specified
+	withOptsStr string        // the trailing "WITH..." clause
+	selectStr   string        // INSERT/UPSERT...SELECT: the SELECT statement, including its WITH clause
+	ignore      bool          // INSERT IGNORE: true if conflicts are not reported as errors
+	orInsert    bool          // UPDATE OR INSERT: true if the document is created when it does not exist
+	returning   []string      // fields of the RETURNING clause ("*" for all fields), nil if not specified
+	isDocument  bool          // INSERT/UPSERT...JSON: true if the whole document is supplied as a single value
+	document    interface{}   // INSERT/UPSERT...JSON: the document, see parser.parseValue
+	deleteAll   bool          // DELETE...WHERE pk=<value> ALL: true if all documents of the partition are deleted
+	pkValue     interface{}   // DELETE...WHERE pk=<value> ALL: the partition key value, see parser.parseValue
+}
+
+// parser is a recursive-descent parser on top of the lexer.
+type parser struct {
+	lexer  *lexer
+	tok    token // current token
+	peeked bool  // true if tok has been read but not consumed
+}
+
+func newParser(input string) *parser {
+	return &parser{lexer: &lexer{input: input}}
+}
+
+// peek returns the current token without consuming it.
+func (p *parser) peek() (token, error) {
+	if !p.peeked {
+		tok, err := p.lexer.next()
+		if err != nil {
+			return tok, err
+		}
+		p.tok, p.peeked = tok, true
+	}
+	return p.tok, nil
+}
+
+// next consumes and returns the current token.
+func (p *parser) next() (token, error) {
+	tok, err := p.peek()
+	p.peeked = false
+	return tok, err
+}
+
+func (p *parser) errorAt(tok token, format string, args ...interface{}) error {
+	return newParseError(p.lexer.input, tok.pos, format, args...)
+}
+
+// expect consumes the current token, which must be one of the keywords/punctuations.
+func (p *parser) expect(kws ...string) (token, error) {
+	tok, err := p.next()
+	if err != nil {
+		return tok, err
+	}
+	for _, kw := range kws {
+		if tok.is(kw) {
+			return tok, nil
+		}
+	}
+	return tok, p.errorAt(tok, "expected %s but found %s", strings.Join(kws, " or "), tok.describe())
+}
+
+// accept consumes the current token if it is the keyword/punctuation kw.
+func (p *parser) accept(kw string) (bool, error) {
+	tok, err := p.peek()
+	if err != nil || !tok.is(kw) {
+		return false, err
+	}
+	p.peeked = false
+	return true, nil
+}
+
+// parseName parses an identifier, e.g. a database, collection or field name.
+func (p *parser) parseName(what string) (string, error) {
+	tok, err := p.next()
+	if err != nil {
+		return "", err
+	}
+	if tok.kind != tokIdent {
+		return "", p.errorAt(tok, "expected %s but found %s", what, tok.describe())
+	}
+	return tok.text, nil
+}
+
+// parseCollection parses "[<db-name>.]<collection-name>".
+func (p *parser) parseCollection() (dbName, collName string, err error) {
+	if collName, err = p.parseName("collection name"); err != nil {
+		return
+	}
+	if ok, err := p.accept("."); err != nil || !ok {
+		return "", collName, err
+	}
+	dbName = collName
+	collName, err = p.parseName("collection name")
+	return
+}
+
+// parseValue parses a value: a placeholder (returned as placeholder), null, a number, a boolean, or a double-quoted
+// string that must contain a valid JSON (returned decoded).
+func (p *parser) parseValue() (interface{}, error) {
+	tok, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case tok.kind == tokPlaceholder:
+		index, _ := strconv.Atoi(tok.text[1:])
+		return placeholder{index}, nil
+	case tok.is("null"):
+		return nil, nil
+	case tok.is("true") || tok.is("false"):
+		return strings.EqualFold(tok.text, "true"), nil
+	case tok.kind == tokNumber:
+		var data interface{}
+		if err := json.Unmarshal([]byte(tok.text), &data); err != nil {
+			return nil, p.errorAt(tok, "invalid number %s", tok.text)
+		}
+		return data, nil
+	case tok.kind == tokString && tok.text[0] == '"':
+		var data interface{}
+		unquoted, err := strconv.Unquote(tok.text)
+		if err != nil {
+			return nil, p.errorAt(tok, "invalid string literal %s", tok.text)
+		}
+		if err := json.Unmarshal([]byte(unquoted), &data); err != nil {
+			return nil, p.errorAt(tok, "string literal %s is not a valid JSON", tok.text)
+		}
+		return data, nil
+	case tok.kind == tokString:
+		return nil, p.errorAt(tok, "string literal must be double-quoted: %s", tok.text)
+	}
+	return nil, p.errorAt(tok, "expected a value but found %s", tok.describe())
+}
+
+// parseWhereId parses "id=<id-value> [AND _etag=<etag-value>]", the condition of the WHERE clause.
+func (p *parser) parseWhereId(ast *astDml) error {
+	for _, kw := range []string{"id", "="} {
+		if _, err := p.expect(kw); err != nil {
+			return err
+		}
+	}
+	tok, err := p.next()
+	if err != nil {
+		return err
+	}
+	if tok.kind == tokEOF || tok.kind == tokPunct {
+		return p.errorAt(tok, "expected id value but found %s", tok.describe())
+	}
+	ast.idStr = tok.text
+	if ok, err := p.accept("AND"); err != nil || !ok {
+		return err
+	}
+	for _, kw := range []string{"_etag", "="} {
+		if _, err := p.expect(kw); err != nil {
+			return err
+		}
+	}
+	if tok, err = p.next(); err != nil {
+		return err
+	}
+	switch {
+	case tok.kind == tokPlaceholder:
+		index, _ := strconv.Atoi(tok.text[1:])
+		ast.etag = placeholder{index}
+	case tok.kind == tokString && tok.text[0] == '"':
+		if etag, err := strconv.Unquote(tok.text); err == nil && etag != "" {
+			ast.etag = etag
+			return nil
+		}
+		return p.errorAt(tok, "invalid _etag literal %s", tok.text)
+	default:
+		return p.errorAt(tok, "expected _etag value but found %s", tok.describe())
+	}
+	return nil
+}
+
+// parseReturning parses the optional "RETURNING *|<field>[,<field>]*" clause.
+func (p *parser) parseReturning(ast *astDml) error {
+	if ok, err := p.accept("RETURNING"); err != nil || !ok {
+		return err
+	}
+	if ok, err := p.accept("*"); err != nil || ok {
+		ast.returning = []string{"*"}
+		return err
+	}
+	for {
+		field, err := p.parseName("field name")
+		if err != nil {
+			return err
+		}
+		ast.returning = append(ast.returning, field)
+		if ok, err := p.accept(","); err != nil || !ok {
+			return err
+		}
+	}
+}
+
+// parseWithClause parses the trailing "WITH..." clause, which must end the statement. Options are kept as text and
+// parsed by the statement (see Stmt.parseWithOpts).
+func (p *parser) parseWithClause(ast *astDml) error {
+	tok, err := p.peek()
+	if err != nil || tok.kind == tokEOF {
+		return err
+	}
+	if !tok.is("WITH") {
+		return p.errorAt(tok, "unexpected %s", tok.describe())
+	}
+	ast.withOptsStr = strings.TrimSpace(p.lexer.input[tok.pos:])
+	return nil
+}
+
+// parseSelect consumes the rest of the statement if it is a SELECT statement (INSERT|UPSERT...SELECT).
+func (p *parser) parseSelect(ast *astDml) (bool, error) {
+	tok, err := p.peek()
+	if err != nil || !tok.is("SELECT") {
+		return false, err
+	}
+	ast.selectStr = strings.TrimSpace(p.lexer.input[tok.pos:])
+	p.lexer.pos, p.peeked = len(p.lexer.input), false
+	return true, nil
+}
+
+// parseInsert parses "INSERT|UPSERT INTO [<db-name>.]<collection-name> (<field-list>) VALUES (<value-list>)",
+// "INSERT|UPSERT INTO [<db-name>.]<collection-name> [(<field-list>)] SELECT..." or
+// "INSERT|UPSERT INTO [<db-name>.]<collection-name> JSON|DOCUMENT <value>". INSERT may be followed by IGNORE.
+func (p *parser) parseInsert(ast *astDml) error {
+	var err error
+	if ast.verb == "INSERT" {
+		if ast.ignore, err = p.accept("IGNORE"); err != nil {
+			return err
+		}
+	}
+	if _, err := p.expect("INTO"); err != nil {
+		return err
+	}
+	if ast.dbName, ast.collName, err = p.parseCollection(); err != nil {
+		return err
+	}
+	if ok, err := p.parseSelect(ast); err != nil || ok {
+		return err
+	}
+	if tok, err := p.peek(); err != nil {
+		return err
+	} else if tok.is("JSON") || tok.is("DOCUMENT") {
+		p.peeked = false
+		ast.isDocument = true
+		ast.document, err = p.parseValue()
+		return err
+	}
+	if _, err := p.expect("("); err != nil {
+		return err
+	}
+	for {
+		field, err := p.parseName("field name")
+		if err != nil {
+			return err
+		}
+		ast.fields = append(ast.fields, field)
+		if tok, err := p.expect(",", ")"); err != nil {
+			return err
+		} else if tok.is(")") {
+			break
+		}
+	}
+	if ok, err := p.parseSelect(ast); err != nil || ok {
+		return err
+	}
+	if _, err := p.expect("VALUES"); err != nil {
+		return err
+	}
+	if _, err := p.expect("("); err != nil {
+		return err
+	}
+	for {
+		value, err := p.parseValue()
+		if err != nil {
+			return err
+		}
+		ast.values = append(ast.values, value)
+		if tok, err := p.expect(",", ")"); err != nil {
+			return err
+		} else if tok.is(")") {
+			break
+		}
+	}
+	return nil
+}
+
+// parseUpdate parses "UPDATE [OR INSERT] [<db-name>.]<collection-name> SET <field>=<value>[,<field>=<value>]* WHERE...".
+func (p *parser) parseUpdate(ast *astDml) error {
+	var err error
+	if ast.orInsert, err = p.accept("OR"); err != nil {
+		return err
+	} else if ast.orInsert {
+		if _, err := p.expect("INSERT"); err != nil {
+			return err
+		}
+	}
+	if ast.dbName, ast.collName, err = p.parseCollection(); err != nil {
+		return err
+	}
+	if _, err := p.expect("SET"); err != nil {
+		return err
+	}
+	for {
+		field, err := p.parseName("field name")
+		if err != nil {
+			return err
+		}
+		if _, err := p.expect("="); err != nil {
+			return err
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return err
+		}
+		ast.fields = append(ast.fields, field)
+		ast.values = append(ast.values, value)
+		if ok, err := p.accept(","); err != nil {
+			return err
+		} else if !ok {
+			break
+		}
+	}
+	if _, err := p.expect("WHERE"); err != nil {
+		return err
+	}
+	return p.parseWhereId(ast)
+}
+
+// parseDelete parses "DELETE FROM [<db-name>.]<collection-name> WHERE..." or
+// "DELETE FROM [<db-name>.]<collection-name> WHERE pk=<value> ALL".
+func (p *parser) parseDelete(ast *astDml) error {
+	if _, err := p.expect("FROM"); err != nil {
+		return err
+	}
+	var err error
+	if ast.dbName, ast.collName, err = p.parseCollection(); err != nil {
+		return err
+	}
+	if _, err := p.expect("WHERE"); err != nil {
+		return err
+	}
+	if ast.deleteAll, err = p.accept("pk"); err != nil {
+		return err
+	} else if !ast.deleteAll {
+		return p.parseWhereId(ast)
+	}
+	if _, err := p.expect("="); err != nil {
+		return err
+	}
+	if ast.pkValue, err = p.parseValue(); err != nil {
+		return err
+	}
+	_, err = p.expect("ALL")
+	return err
+}
+
+// _isDml returns true if the query starts with INSERT, UPSERT, UPDATE or DELETE.
+func _isDml(query string) bool {
+	tok, err := (&lexer{input: query}).next()
+	return err == nil && (tok.is("INSERT") || tok.is("UPSERT") || tok.is("UPDATE") || tok.is("DELETE"))
+}
+
+// parseDml parses a data-manipulation statement (INSERT, UPSERT, UPDATE or DELETE) into its syntax tree.
+func parseDml(query string) (*astDml, error) {
+	p := newParser(query)
+	tok, err := p.expect("INSERT", "UPSERT", "UPDATE", "DELETE")
+	if err != nil {
+		return nil, err
+	}
+	ast := &astDml{verb: strings.ToUpper(tok.text)}
+	switch ast.verb {
+	case "INSERT", "UPSERT":
+		if err = p.parseInsert(ast); err == nil && ast.selectStr == "" {
+			err = p.parseReturning(ast)
+		}
+	case "UPDATE":
+		if err = p.parseUpdate(ast); err == nil {
+			err = p.parseReturning(ast)
+		}
+	case "DELETE":
+		if err = p.parseDelete(ast); err == nil && !ast.deleteAll {
+			err = p.parseReturning(ast)
+		}
+	}
+	if err == nil {
+		err = p.parseWithClause(ast)
+	}
+	return ast, err
+}
@@ -0,0 +1,141 @@
+package gocosmos
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func Test_lexer(t *testing.T) {
+	name := "Test_lexer"
+	input := "INSERT INTO db-1.coll_1 (a,b)\nVALUES (:1, -1.5e+3, \"a \\\"b\\\" c\", 'd')"
+	expected := []token{
+		{tokIdent, "INSERT", 0}, {tokIdent, "INTO", 7}, {tokIdent, "db-1", 12}, {tokPunct, ".", 16},
+		{tokIdent, "coll_1", 17}, {tokPunct, "(", 24}, {tokIdent, "a", 25}, {tokPunct, ",", 26}, {tokIdent, "b", 27},
+		{tokPunct, ")", 28}, {tokIdent, "VALUES", 30}, {tokPunct, "(", 37}, {tokPlaceholder, ":1", 38}, {tokPunct, ",", 40},
+		{tokNumber, "-1.5e+3", 42}, {tokPunct, ",", 49}, {tokString, `"a \"b\" c"`, 51}, {tokPunct, ",", 62},
+		{tokString, "'d'", 64}, {tokPunct, ")", 67}, {tokEOF, "", 68},
+	}
+	l := &lexer{input: input}
+	for i, exp := range expected {
+		if tok, err := l.next(); err != nil || tok != exp {
+			t.Fatalf("%s failed: token #%d expected %#v but received %#v / %s", name, i, exp, tok, err)
+		}
+	}
+
+	_, err := (&lexer{input: "\"abc"}).next()
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || parseErr.Pos != 0 || parseErr.Msg != "unterminated string literal" {
+		t.Fatalf("%s failed: unexpected error %#v", name, err)
+	}
+}
+
+func Test_parseDml(t *testing.T) {
+	name := "Test_parseDml"
+	testData := map[string]astDml{
+		`INSERT INTO db.coll (a, b, c) VALUES ("\"a) b, c\"", "{\"k\":[1,2]}", "\"WITH x=1\"") WITH indexing=exclude`: {
+			verb: "INSERT", dbName: "db", collName: "coll", fields: []string{"a", "b", "c"},
+			values:      []interface{}{"a) b, c", map[string]interface{}{"k": []interface{}{1.0, 2.0}}, "WITH x=1"},
+			withOptsStr: "WITH indexing=exclude"},
+		"INSERT IGNORE INTO coll (a) VALUES (1)": {
+			verb: "INSERT", collName: "coll", fields: []string{"a"}, values: []interface{}{1.0}, ignore: true},
+		"upsert into coll(a)values(@1)": {
+			verb: "UPSERT", collName: "coll", fields: []string{"a"}, values: []interface{}{placeholder{1}}},
+		`UPDATE coll SET a="\"WHERE id=1\"",b=2 WHERE id="x" AND _etag=$3`: {
+			verb: "UPDATE", collName: "coll", fields: []string{"a", "b"}, values: []interface{}{"WHERE id=1", 2.0},
+			idStr: `"x"`, etag: placeholder{3}},
+		"UPDATE OR INSERT db.coll SET a=:1 WHERE id=:2": {
+			verb: "UPDATE", dbName: "db", collName: "coll", fields: []string{"a"}, values: []interface{}{placeholder{1}}, idStr: ":2", orInsert: true},
+		"DELETE FROM db.coll WHERE id=:1 RETURNING * WITH pretrigger=t1": {
+			verb: "DELETE", dbName: "db", collName: "coll", idStr: ":1", returning: []string{"*"}, withOptsStr: "WITH pretrigger=t1"},
+		"DELETE FROM db.coll WHERE id=:1 WITH pretrigger=t1": {
+			verb: "DELETE", dbName: "db", collName: "coll", idStr: ":1", withOptsStr: "WITH pretrigger=t1"},
+		"INSERT INTO coll JSON :1 RETURNING id, _etag WITH indexing=exclude": {
+			verb: "INSERT", collName: "coll", isDocument: true, document: placeholder{1}, returning: []string{"id", "_etag"},
+			withOptsStr: "WITH indexing=exclude"},
+		"upsert into coll document :1 WITH indexing=exclude": {
+			verb: "UPSERT", collName: "coll", isDocument: true, document: placeholder{1}, withOptsStr: "WITH indexing=exclude"},
+		"INSERT INTO db.coll (a) SELECT c.x AS a FROM c WITH db=db0": {
+			verb: "INSERT", dbName: "db", collName: "coll", fields: []string{"a"}, selectStr: "SELECT c.x AS a FROM c WITH db=db0"},
+	}
+	for query, expected := range testData {
+		ast, err := parseDml(query)
+		if err != nil {
+			t.Fatalf("%s failed: <%s> %s", name, query, err)
+		}
+		if !reflect.DeepEqual(*ast, expected) {
+			t.Fatalf("%s failed: <%s> expected %#v but received %#v", name, query, expected, *ast)
+		}
+	}
+
+	errorData := map[string]ParseError{
+		"INSERT INTO db.coll (a, b)\n  VALUES (1, 'x')": {Pos: 40, Line: 2, Column: 14, Msg: "string literal must be double-quoted: 'x'"},
+		"UPDATE db.coll SET a=1 WHERE idx=1":            {Pos: 29, Line: 1, Column: 30, Msg: `expected id but found "idx"`},
+		"INSERT INTO db.coll (a) VALUES (1) RETURNING":  {Pos: 44, Line: 1, Column: 45, Msg: "expected field name but found end of statement"},
+		"DELETE FROM db.coll WHERE id=1 LIMIT 1":        {Pos: 31, Line: 1, Column: 32, Msg: `unexpected "LIMIT"`},
+		"INSERT INTO db.coll (a) VALUES (0x1qa)":        {Pos: 32, Line: 1, Column: 33, Msg: "invalid number 0x1qa"},
+	}
+	for query, expected := range errorData {
+		_, err := parseDml(query)
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) || *parseErr != expected {
+			t.Fatalf("%s failed: <%s> expected error %#v but received %#v", name, query, expected, err)
+		}
+	}
+}
+
+func Test_stripComments(t *testing.T) {
+	name := "Test_stripComments"
+	testData := map[string]string{
+		"-- leading\nSELECT * FROM c -- trailing":                                 "          \nSELECT * FROM c            ",
+		"SELECT /* a\nb */ * FROM c WHERE c.a='-- x /*'":                          "SELECT     \n     * FROM c WHERE c.a='-- x /*'",
+		`INSERT INTO c (a) VALUES ("\"/* x */\"")`:                                `INSERT INTO c (a) VALUES ("\"/* x */\"")`,
+		"CREATE COLLECTION db.c WITH pk=/a--b WITH exclude=/* /* comment */":      "CREATE COLLECTION db.c WITH pk=/a--b WITH exclude=/*              ",
+		"CREATE PROCEDURE db.c.sp /* c */ AS function() { /* body */ return 1; }": "CREATE PROCEDURE db.c.sp         AS function() { /* body */ return 1; }",
+		"SELECT * FROM c /* unterminated":                                         "SELECT * FROM c                ",
+	}
+	for query, expected := range testData {
+		if stripped := _stripComments(query); stripped != expected {
+			t.Fatalf("%s failed: <%s> expected %q but received %q", name, query, expected, stripped)
+		}
+	}
+
+	query := `-- insert a document
+INSERT INTO db.coll (a, b) /* fields */
+VALUES (:1, "\"x\"") -- values`
+	if stmt, err := parseQuery(nil, query); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	} else if insert := stmt.(*StmtInsert); !reflect.DeepEqual(insert.values, []interface{}{placeholder{1}, "x"}) {
+		t.Fatalf("%s failed: unexpected values %#v", name, insert.values)
+	}
+}
+
+func Test_numberPlaceholders(t *testing.T) {
+	name := "Test_numberPlaceholders"
+	testData := map[string]string{
+		"INSERT INTO db.coll (a, b, c) VALUES (?, ?,?)":                       "INSERT INTO db.coll (a, b, c) VALUES ($1, $2,$3)",
+		`UPDATE db.coll SET a=?, b="?" WHERE id=? AND _etag=?`:                `UPDATE db.coll SET a=$1, b="?" WHERE id=$2 AND _etag=$3`,
+		"SELECT c.a ?? c.b, (c.x > 1 ? 'y' : 'n') FROM c WHERE c.id IN (?,?)": "SELECT c.a ?? c.b, (c.x > 1 ? 'y' : 'n') FROM c WHERE c.id IN ($1,$2)",
+		"SELECT TOP ? * FROM c WHERE c.a LIKE ? WITH db=mydb":                 "SELECT TOP $1 * FROM c WHERE c.a LIKE $2 WITH db=mydb",
+		"CREATE PROCEDURE db.c.sp AS function(a) { return a ? 1 : ?; }":       "CREATE PROCEDURE db.c.sp AS function(a) { return a ? 1 : ?; }",
+		"DELETE FROM db.coll WHERE id=:1":                                     "DELETE FROM db.coll WHERE id=:1",
+		"CREATE TABLE db.coll WITH pk=/id WITH include=/name/?,/age/?":        "CREATE TABLE db.coll WITH pk=/id WITH include=/name/?,/age/?",
+	}
+	for query, expected := range testData {
+		if output, err := _numberPlaceholders(query); err != nil || output != expected {
+			t.Fatalf("%s failed: <%s> expected %q but received %q / %s", name, query, expected, output, err)
+		}
+	}
+
+	_, err := _numberPlaceholders("UPDATE db.coll SET a=? WHERE id=@1")
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || parseErr.Pos != 32 {
+		t.Fatalf("%s failed: mixing placeholders must be rejected, received %#v", name, err)
+	}
+
+	if stmt, err := parseQuery(nil, "INSERT INTO db.coll (id, name) VALUES (?, ?)"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	} else if stmt.NumInput() != 3 {
+		t.Fatalf("%s failed: expected 3 inputs (2 values and the partition key) but received %d", name, stmt.NumInput())
+	}
+}
@@ -0,0 +1,214 @@
+package gocosmos
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// pkPathsTtl is how long a collection's partition key paths are cached.
+const pkPathsTtl = 5 * time.Minute
+
+type pkPathsEntry struct {
+	paths     []string
+	fetchedAt time.Time
+}
+
+// pkPathsCache caches partition key paths of collections, shared among all clients and keyed by endpoint/db/collection.
+var pkPathsCache = struct {
+	lock    sync.RWMutex
+	entries map[string]pkPathsEntry
+}{entries: make(map[string]pkPathsEntry)}
+
+func (c *RestClient) pkPathsCacheKey(dbName, collName string) string {
+	return c.endpoint + "/dbs/" + dbName + "/colls/" + collName
+}
+
+// getPkPaths returns the partition key paths of a collection, fetched from the collection's metadata and cached.
+// Paths are empty if the collection is non-partitioned.
+func (c *RestClient) getPkPaths(dbName, collName string) ([]string, error) {
+	key := c.pkPathsCacheKey(dbName, collName)
+	pkPathsCache.lock.RLock()
+	entry, ok := pkPathsCache.entries[key]
+	pkPathsCache.lock.RUnlock()
+	if ok && time.Since(entry.fetchedAt) < pkPathsTtl {
+		return entry.paths, nil
+	}
+
+	collResult := c.GetCollection(dbName, collName)
+	if err := collResult.Error(); err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0)
+	if systemKey, _ := collResult.PartitionKey["systemKey"].(bool); systemKey {
+		// legacy non-partitioned collection, the system-defined partition key is not part of documents
+	} else if rawPaths, ok := collResult.PartitionKey["paths"].([]interface{}); ok {
+		for _, rawPath := range rawPaths {
+			if path, _ := rawPath.(string); path != "" {
+				paths = append(paths, path)
+			}
+		}
+	}
+	pkPathsCache.lock.Lock()
+	pkPathsCache.entries[key] = pkPathsEntry{paths: paths, fetchedAt: time.Now()}
+	pkPathsCache.lock.Unlock()
+	return paths, nil
+}
+
+// invalidatePkPaths removes cached partition key paths of a collection, or of all collections in the database if
+// collName is empty.
+func (c *RestClient) invalidatePkPaths(dbName, collName string) {
+	key := c.pkPathsCacheKey(dbName, collName)
+	pkPathsCache.lock.Lock()
+	defer pkPathsCache.lock.Unlock()
+	for k := range pkPathsCache.entries {
+		if k == key || (collName == "" && strings.HasPrefix(k, key)) {
+			delete(pkPathsCache.entries, k)
+		}
+	}
+}
+
+// fetchPkPaths returns the partition key paths of a collection, either the comma-separated list supplied by the caller or
+// fetched from the collection's metadata (empty if the collection is non-partitioned).
+func (c *RestClient) fetchPkPaths(dbName, collName, pkPaths string) ([]string, error) {
+	if pkPaths != "" {
+		paths := make([]string, 0)
+		for _, path := range strings.Split(pkPaths, ",") {
+			if path = strings.TrimSpace(path); path != "" {
+				paths = append(paths, path)
+			}
+		}
+		return paths, nil
+	}
+	return c.getPkPaths(dbName, collName)
+}
+
+// _parsePkPath splits a partition key path (e.g. "/address/city") into property names. A property name containing special
+// characters can be enclosed in double quotes, e.g. /"user-info"/city.
+func _parsePkPath(pkPath string) ([]string, error) {
+	if !strings.HasPrefix(pkPath, "/") {
+		return nil, errors.New("invalid partition key path: " + pkPath)
+	}
+	parts := make([]string, 0)
+	for rest := pkPath; rest != ""; {
+		if rest[0] != '/' {
+			return nil, errors.New("invalid partition key path: " + pkPath)
+		}
+		rest = rest[1:]
+		var part string
+		if strings.HasPrefix(rest, "\"") {
+			end := strings.Index(rest[1:], "\"")
+			if end < 0 {
+				return nil, errors.New("invalid partition key path: " + pkPath)
+			}
+			part, rest = rest[1:end+1], rest[end+2:]
+		} else if end := strings.Index(rest, "/"); end >= 0 {
+			part, rest = rest[:end], rest[end:]
+		} else {
+			part, rest = rest, ""
+		}
+		if part == "" {
+			return nil, errors.New("invalid partition key path: " + pkPath)
+		}
+		parts = append(parts, part)
+	}
+	return parts, nil
+}
+
+// _validatePkValue checks if the value extracted at a partition key path can be used as partition key value, which must
+// be a string, number, boolean or null.
+func _validatePkValue(pkPath string, pkValue interface{}) error {
+	switch pkValue.(type) {
+	case map[string]interface{}, []interface{}:
+		return errors.New("value of partition key " + pkPath + " must be a string, number, boolean or null")
+	}
+	return nil
+}
+
+// _nonePkValues returns the partition key values of documents in a non-partitioned collection.
+func _nonePkValues() []interface{} {
+	return []interface{}{map[string]interface{}{}}
+}
+
+// _extractPkValues extracts values at the partition key paths from a document, one value per path.
+// If pkPaths is empty (non-partitioned collection), _nonePkValues is returned.
+func _extractPkValues(doc map[string]interface{}, pkPaths []string) ([]interface{}, error) {
+	if len(pkPaths) == 0 {
+		return _nonePkValues(), nil
+	}
+	pkValues := make([]interface{}, len(pkPaths))
+	for i, pkPath := range pkPaths {
+		if _, err := _parsePkPath(pkPath); err != nil {
+			return nil, err
+		}
+		pkValue, ok := _extractPkValue(doc, pkPath)
+		if !ok {
+			return nil, errors.New("partition key " + pkPath + " not found in document")
+		}
+		if err := _validatePkValue(pkPath, pkValue); err != nil {
+			return nil, err
+		}
+		pkValues[i] = pkValue
+	}
+	return pkValues, nil
+}
+
+// docPkValues extracts the partition key values from document data, using the collection's partition key paths.
+func (c *RestClient) docPkValues(dbName, collName string, doc map[string]interface{}) ([]interface{}, error) {
+	pkPaths, err := c.fetchPkPaths(dbName, collName, "")
+	if err != nil {
+		return nil, err
+	}
+	return _extractPkValues(doc, pkPaths)
+}
+
+// _pkValues converts the partition key argument to the list of partition key values: a slice (other than []byte) supplies
+// values of a hierarchical partition key, any other value is the value of a single partition key.
+func _pkValues(arg interface{}) []interface{} {
+	switch v := arg.(type) {
+	case []interface{}:
+		return v
+	case []byte:
+		return []interface{}{v}
+	}
+	if rv := reflect.ValueOf(arg); rv.Kind() == reflect.Slice {
+		pkValues := make([]interface{}, rv.Len())
+		for i := range pkValues {
+			pkValues[i] = rv.Index(i).Interface()
+		}
+		return pkValues
+	}
+	return []interface{}{arg}
+}
+
+// lookupDocument finds a document by id across all partitions, returns nil if not found.
+func (c *RestClient) lookupDocument(dbName, collName, id string) (DocInfo, error) {
+	query := QueryReq{
+		DbName:                dbName,
+		CollName:              collName,
+		Query:                 "SELECT * FROM c WHERE c.id=@id",
+		Params:                []interface{}{map[string]interface{}{"name": "@id", "value": id}},
+		CrossPartitionEnabled: true,
+	}
+	documents := make([]DocInfo, 0)
+	for {
+		restResult := c.QueryDocuments(query)
+		if err := restResult.Error(); err != nil {
+			return nil, err
+		}
+		documents = append(documents, restResult.Documents...)
+		if restResult.ContinuationToken == "" {
+			break
+		}
+		query.ContinuationToken = restResult.ContinuationToken
+	}
+	switch len(documents) {
+	case 0:
+		return nil, nil
+	case 1:
+		return documents[0], nil
+	}
+	return nil, errors.New("ambiguous document id " + id + ", found in multiple partitions")
+}
@@ -0,0 +1,231 @@
+package gocosmos
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func Test_pkValues(t *testing.T) {
+	name := "Test_pkValues"
+	testData := []struct {
+		arg      interface{}
+		expected []interface{}
+	}{
+		{"user", []interface{}{"user"}},
+		{int64(1), []interface{}{int64(1)}},
+		{nil, []interface{}{nil}},
+		{[]byte("user"), []interface{}{[]byte("user")}},
+		{[]interface{}{"tenant", 1}, []interface{}{"tenant", 1}},
+		{[]string{"tenant", "user"}, []interface{}{"tenant", "user"}},
+	}
+	for _, data := range testData {
+		if v := _pkValues(data.arg); !reflect.DeepEqual(v, data.expected) {
+			t.Fatalf("%s failed: expected %#v but received %#v", name, data.expected, v)
+		}
+	}
+}
+
+func Test_parsePkPath(t *testing.T) {
+	name := "Test_parsePkPath"
+	testData := map[string][]string{
+		"/id":                 {"id"},
+		"/address/city":       {"address", "city"},
+		`/"user-info"/city`:   {"user-info", "city"},
+		`/address/"zip/code"`: {"address", "zip/code"},
+		"/a/b/c":              {"a", "b", "c"},
+	}
+	for path, expected := range testData {
+		if parts, err := _parsePkPath(path); err != nil || !reflect.DeepEqual(parts, expected) {
+			t.Fatalf("%s failed: <%s> expected %#v but received %#v (%s)", name, path, expected, parts, err)
+		}
+	}
+	for _, path := range []string{"", "id", "/", "/address/", "/address//city", `/"user-info`, `/"user"info`} {
+		if parts, err := _parsePkPath(path); err == nil {
+			t.Fatalf("%s failed: <%s> expected error but received %#v", name, path, parts)
+		}
+	}
+}
+
+func Test_extractPkValues(t *testing.T) {
+	name := "Test_extractPkValues"
+	doc := map[string]interface{}{"tenant": "t1", "user": map[string]interface{}{"id": "u1"}}
+	if v, err := _extractPkValues(doc, []string{"/tenant", "/user/id"}); err != nil || !reflect.DeepEqual(v, []interface{}{"t1", "u1"}) {
+		t.Fatalf("%s failed: expected %#v but received %#v (%s)", name, []interface{}{"t1", "u1"}, v, err)
+	}
+	if _, err := _extractPkValues(doc, []string{"/tenant", "/session"}); err == nil {
+		t.Fatalf("%s failed: expected error for missing partition key", name)
+	}
+	if _, err := _extractPkValues(doc, []string{"/user"}); err == nil {
+		t.Fatalf("%s failed: expected error for partition key value of object type", name)
+	}
+	if _, err := _extractPkValues(doc, []string{"/user//id"}); err == nil {
+		t.Fatalf("%s failed: expected error for invalid partition key path", name)
+	}
+	if v, err := _extractPkValues(doc, nil); err != nil || !reflect.DeepEqual(v, _nonePkValues()) {
+		t.Fatalf("%s failed: expected %#v but received %#v (%s)", name, _nonePkValues(), v, err)
+	}
+}
+
+func TestStmt_HierarchicalPk(t *testing.T) {
+	name := "TestStmt_HierarchicalPk"
+	var pkHeader string
+	var collSpec map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pkHeader = r.Header.Get("X-Ms-Documentdb-PartitionKey")
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/dbs/db/colls":
+			json.NewDecoder(r.Body).Decode(&collSpec)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"coll","_rid":"rid"}`))
+		case r.Method == "GET" && r.URL.Path == "/dbs/db/colls/coll":
+			w.Write([]byte(`{"id":"coll","partitionKey":{"paths":["/tenant","/user"],"kind":"MultiHash","version":2}}`))
+		case r.Method == "POST":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"1","_rid":"rid"}`))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+	connStr := "AccountEndpoint=" + server.URL + ";AccountKey=C2y6yDjf5/R+ob0N8A7Cgv30VRDJIWEHLM+4QDU5DE2nQ9nDuVTqobD4b8mGGyPMbIZnqyMsEcaGQy67XIw/Jw=="
+	db, _ := sql.Open("gocosmos", connStr)
+	defer db.Close()
+
+	if _, err := db.Exec("CREATE COLLECTION db.coll WITH pk=/tenant,/user"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	} else if pk, _ := collSpec["partitionKey"].(map[string]interface{}); pk["kind"] != "MultiHash" || !reflect.DeepEqual(pk["paths"], []interface{}{"/tenant", "/user"}) {
+		t.Fatalf("%s failed: unexpected partition key spec %#v", name, collSpec["partitionKey"])
+	}
+	if _, err := db.Exec("INSERT INTO db.coll (id,tenant,user) VALUES (:1,:2,:3)", "1", "t1", "u1", []interface{}{"t1", "u1"}); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	} else if pkHeader != `["t1","u1"]` {
+		t.Fatalf("%s failed: <partition-key> expected %#v but received %#v", name, `["t1","u1"]`, pkHeader)
+	}
+	if _, err := db.Exec("DELETE FROM db.coll WHERE id=:1", "1", []string{"t1", "u1"}); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	} else if pkHeader != `["t1","u1"]` {
+		t.Fatalf("%s failed: <partition-key> expected %#v but received %#v", name, `["t1","u1"]`, pkHeader)
+	}
+
+	dbAuto, _ := sql.Open("gocosmos", connStr+";AutoPartitionKey=true")
+	defer dbAuto.Close()
+	if _, err := dbAuto.Exec("INSERT INTO db.coll (id,tenant,user) VALUES (:1,:2,:3)", "1", "t2", "u2"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	} else if pkHeader != `["t2","u2"]` {
+		t.Fatalf("%s failed: <partition-key> expected %#v but received %#v", name, `["t2","u2"]`, pkHeader)
+	}
+}
+
+func TestStmt_NonPartitioned(t *testing.T) {
+	name := "TestStmt_NonPartitioned"
+	var pkHeader string
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		pkHeader = r.Header.Get("X-Ms-Documentdb-PartitionKey")
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/dbs/db/colls/coll":
+			w.Write([]byte(`{"id":"coll","partitionKey":{"paths":["/grade"],"kind":"Hash"}}`))
+		case r.Method == "GET" && r.URL.Path == "/dbs/db/colls/legacy":
+			w.Write([]byte(`{"id":"legacy","partitionKey":{"paths":["/_partitionKey"],"kind":"Hash","systemKey":true}}`))
+		case r.Method == "GET" && r.URL.Path == "/dbs/db/colls/nopk":
+			w.Write([]byte(`{"id":"nopk"}`))
+		case r.Method == "GET":
+			w.Write([]byte(`{"id":"1","grade":1,"_etag":"\"etag\""}`))
+		case r.Method == "POST":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"1","_rid":"rid"}`))
+		case r.Method == "PUT":
+			w.Write([]byte(`{"id":"1","grade":2}`))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+	db, _ := sql.Open("gocosmos", "AccountEndpoint="+server.URL+";AccountKey=C2y6yDjf5/R+ob0N8A7Cgv30VRDJIWEHLM+4QDU5DE2nQ9nDuVTqobD4b8mGGyPMbIZnqyMsEcaGQy67XIw/Jw==")
+	defer db.Close()
+
+	for _, coll := range []string{"legacy", "nopk"} {
+		queries := []struct {
+			query string
+			args  []interface{}
+		}{
+			{"INSERT INTO db." + coll + " (id,grade) VALUES (:1,:2)", []interface{}{"1", 1, nil}},
+			{"UPDATE db." + coll + " SET grade=:1 WHERE id=:2", []interface{}{2, "1", nil}},
+			{"DELETE FROM db." + coll + " WHERE id=:1", []interface{}{"1", nil}},
+		}
+		for _, q := range queries {
+			if result, err := db.Exec(q.query, q.args...); err != nil {
+				t.Fatalf("%s failed: <%s> %s", name, q.query, err)
+			} else if numRows, _ := result.RowsAffected(); numRows != 1 {
+				t.Fatalf("%s failed: <%s> expected RowsAffected=1 but received %#v", name, q.query, numRows)
+			} else if pkHeader != `[{}]` {
+				t.Fatalf("%s failed: <%s> <partition-key> expected %#v but received %#v", name, q.query, `[{}]`, pkHeader)
+			}
+		}
+	}
+
+	// the partition key value may also be omitted if the statement is executed directly
+	dconn, _ := (&Driver{}).Open("AccountEndpoint=" + server.URL + ";AccountKey=C2y6yDjf5/R+ob0N8A7Cgv30VRDJIWEHLM+4QDU5DE2nQ9nDuVTqobD4b8mGGyPMbIZnqyMsEcaGQy67XIw/Jw==")
+	defer dconn.Close()
+	dstmt, _ := dconn.Prepare("INSERT INTO db.legacy (id,grade) VALUES (:1,:2)")
+	if _, err := dstmt.Exec([]driver.Value{"1", 1}); err != nil || pkHeader != `[{}]` {
+		t.Fatalf("%s failed: <partition-key> expected %#v but received %#v / %s", name, `[{}]`, pkHeader, err)
+	}
+
+	// partitioning is determined when the statement is executed, not when it is prepared
+	atomic.StoreInt32(&requests, 0)
+	stmt, err := db.Prepare("INSERT INTO db.coll (id,grade) VALUES (:1,:2)")
+	if err != nil || atomic.LoadInt32(&requests) != 0 {
+		t.Fatalf("%s failed: prepare must not send requests, received %s / %d requests", name, err, requests)
+	}
+	defer stmt.Close()
+	if _, err := stmt.Exec("1", 1); err == nil || atomic.LoadInt32(&requests) != 0 {
+		t.Fatalf("%s failed: missing partition key value must be rejected without sending requests, received %v", name, err)
+	}
+	if _, err := stmt.Exec("1", 1, nil); err != nil || pkHeader != `[null]` {
+		t.Fatalf("%s failed: <partition-key> expected %#v but received %#v / %s", name, `[null]`, pkHeader, err)
+	}
+	if _, err := stmt.Exec("1", 1, 1); err != nil || pkHeader != `[1]` {
+		t.Fatalf("%s failed: <partition-key> expected %#v but received %#v / %s", name, `[1]`, pkHeader, err)
+	}
+	if _, _, err := dstmt.(*StmtInsert).pkArgs("db", "coll", []driver.Value{"1", 1}); err == nil || !strings.Contains(err.Error(), "partition key") {
+		t.Fatalf("%s failed: missing partition key value of a partitioned collection must be rejected, received %v", name, err)
+	}
+}
+
+func TestStmt_NestedPk(t *testing.T) {
+	name := "TestStmt_NestedPk"
+	var pkHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pkHeader = r.Header.Get("X-Ms-Documentdb-PartitionKey")
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/dbs/db/colls/coll":
+			w.Write([]byte(`{"id":"coll","partitionKey":{"paths":["/address/city"],"kind":"Hash"}}`))
+		case r.Method == "POST":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"1","_rid":"rid"}`))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+	db, _ := sql.Open("gocosmos", "AccountEndpoint="+server.URL+";AccountKey=C2y6yDjf5/R+ob0N8A7Cgv30VRDJIWEHLM+4QDU5DE2nQ9nDuVTqobD4b8mGGyPMbIZnqyMsEcaGQy67XIw/Jw==;AutoPartitionKey=true")
+	defer db.Close()
+
+	if _, err := db.Exec("INSERT INTO db.coll (id,address) VALUES (:1,:2)", "1", map[string]interface{}{"city": "HCM"}); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	} else if pkHeader != `["HCM"]` {
+		t.Fatalf("%s failed: <partition-key> expected %#v but received %#v", name, `["HCM"]`, pkHeader)
+	}
+	if _, err := db.Exec("INSERT INTO db.coll (id,address) VALUES (:1,:2)", "2", "HCM"); err == nil {
+		t.Fatalf("%s failed: expected error for missing nested partition key", name)
+	}
+}
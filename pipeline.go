@@ -0,0 +1,183 @@
+package gocosmos
+
+import (
+	"database/sql/driver"
+	"io"
+	"sync"
+)
+
+// queryPage is a page of documents read by a pageStream.
+type queryPage struct {
+	docs []DocInfo
+	err  error
+}
+
+// pageStream reads the pages of a query, either synchronously (one request per call to next) or in background, ahead of
+// their consumption.
+type pageStream struct {
+	client *RestClient
+	query  *QueryReq // synchronous mode: the next request to send, nil when all pages have been read
+
+	pages     chan queryPage // background mode: pages read ahead, closed when all pages have been read
+	done      chan struct{}  // background mode: closed to stop reading pages
+	closeOnce sync.Once
+}
+
+// newPageStream starts reading the pages of the query.
+//
+// If pkRangeIds is not empty, the query is executed on each of the partition key ranges, with at most parallelism ranges
+// read concurrently (pages of different ranges are interleaved). Otherwise, pages are read following continuation
+// tokens. If prefetch is 0 and the query is not executed in parallel, pages are read synchronously; otherwise they are
+// read in background, and up to prefetch pages (at least parallelism) are buffered ahead of their consumption.
+func newPageStream(client *RestClient, query QueryReq, pkRangeIds []string, parallelism, prefetch int) *pageStream {
+	if len(pkRangeIds) <= 1 && prefetch <= 0 {
+		if len(pkRangeIds) == 1 {
+			query.PartitionKeyRangeId = pkRangeIds[0]
+		}
+		return &pageStream{client: client, query: &query}
+	}
+	if len(pkRangeIds) == 0 {
+		pkRangeIds = []string{query.PartitionKeyRangeId}
+	}
+	if parallelism > len(pkRangeIds) {
+		parallelism = len(pkRangeIds)
+	}
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	if prefetch < parallelism {
+		prefetch = parallelism
+	}
+	s := &pageStream{client: client, pages: make(chan queryPage, prefetch), done: make(chan struct{})}
+	ranges := make(chan string, len(pkRangeIds))
+	for _, id := range pkRangeIds {
+		ranges <- id
+	}
+	close(ranges)
+	var wg sync.WaitGroup
+	for i := 0; i < parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range ranges {
+				req := query
+				req.PartitionKeyRangeId = id
+				if !s.readAll(req) {
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(s.pages)
+	}()
+	return s
+}
+
+// readAll reads all pages of the query in background mode, returns false if an error occurred or the stream is closed.
+func (s *pageStream) readAll(query QueryReq) bool {
+	for {
+		select {
+		case <-s.done:
+			return false
+		default:
+		}
+		result := s.client.QueryDocuments(query)
+		page := queryPage{docs: result.Documents, err: result.Error()}
+		select {
+		case s.pages <- page:
+		case <-s.done:
+			return false
+		}
+		if page.err != nil || result.ContinuationToken == "" {
+			return page.err == nil
+		}
+		query.ContinuationToken = result.ContinuationToken
+	}
+}
+
+// next returns the documents of the next page, io.EOF if all pages have been read.
+func (s *pageStream) next() ([]DocInfo, error) {
+	if s.pages == nil {
+		if s.query == nil {
+			return nil, io.EOF
+		}
+		result := s.client.QueryDocuments(*s.query)
+		if err := result.Error(); err != nil || result.ContinuationToken == "" {
+			s.query = nil
+			return result.Documents, err
+		}
+		s.query.ContinuationToken = result.ContinuationToken
+		return result.Documents, nil
+	}
+	page, ok := <-s.pages
+	if !ok {
+		return nil, io.EOF
+	}
+	return page.docs, page.err
+}
+
+// close stops reading pages, pages read ahead are discarded.
+func (s *pageStream) close() {
+	if s.pages == nil {
+		s.query = nil
+		return
+	}
+	s.closeOnce.Do(func() { close(s.done) })
+}
+
+// resultSelectStream is a ResultSelect whose documents are read page by page while rows are consumed.
+type resultSelectStream struct {
+	*ResultSelect
+	stream   *pageStream
+	window   *rowWindow
+	distinct *distinctFilter
+	flatten  bool // (since v0.1.1) flatten nested objects of the documents into parent.child columns
+	eof      bool
+}
+
+// fetch returns the rows of the next page that has rows, io.EOF if the query has no more rows.
+func (r *resultSelectStream) fetch() ([]DocInfo, error) {
+	for !r.eof {
+		docs, err := r.stream.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			r.Close()
+			return nil, _explainScanRequired(err)
+		}
+		docs, done := r.window.take(r.distinct.filter(docs))
+		if done {
+			r.Close()
+		}
+		if len(docs) > 0 {
+			if r.flatten {
+				docs = _flattenDocuments(docs)
+			}
+			return docs, nil
+		}
+	}
+	r.eof = true
+	return nil, io.EOF
+}
+
+// Next implements driver.Rows.Next.
+func (r *resultSelectStream) Next(dest []driver.Value) error {
+	for r.cursorCount >= r.count {
+		docs, err := r.fetch()
+		if err != nil {
+			return err
+		}
+		r.documents, r.count, r.cursorCount = docs, len(docs), 0
+	}
+	return r.ResultSelect.Next(dest)
+}
+
+// Close implements driver.Rows.Close.
+func (r *resultSelectStream) Close() error {
+	r.eof = true
+	r.stream.close()
+	return nil
+}
@@ -0,0 +1,227 @@
+package gocosmos
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStmtSelect_Parallelism(t *testing.T) {
+	name := "TestStmtSelect_Parallelism"
+	var lock sync.Mutex
+	var inFlight, maxInFlight, pkrangesCalls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		if strings.HasSuffix(r.URL.Path, "/pkranges") {
+			pkrangesCalls++
+			lock.Unlock()
+			w.Write([]byte(`{"_count":3,"PartitionKeyRanges":[{"id":"0"},{"id":"1"},{"id":"2"}]}`))
+			return
+		}
+		if inFlight++; inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		lock.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		lock.Lock()
+		inFlight--
+		lock.Unlock()
+		pkrangeId, continuation := r.Header.Get("X-Ms-Documentdb-PartitionKeyRangeId"), r.Header.Get("X-Ms-Continuation")
+		if pkrangeId == "1" && continuation == "" {
+			w.Header().Set("X-Ms-Continuation", "next")
+		}
+		w.Write([]byte(fmt.Sprintf(`{"_count":1,"Documents":[{"id":"%s%s"}]}`, pkrangeId, continuation)))
+	}))
+	defer server.Close()
+	db, _ := sql.Open("gocosmos", "AccountEndpoint="+server.URL+";AccountKey=cHJpbWFyeQ==;DefaultDb=db;MaxParallelism=2")
+	defer db.Close()
+
+	rows, err := db.Query("SELECT CROSS PARTITION * FROM c WITH collection=coll")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	ids := make([]string, 0)
+	for rows.Next() {
+		var id string
+		rows.Scan(&id)
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	if strings.Join(ids, ",") != "0,1,1next,2" {
+		t.Fatalf("%s failed: unexpected rows %v", name, ids)
+	}
+	if maxInFlight != 2 || pkrangesCalls != 1 {
+		t.Fatalf("%s failed: expected 2 concurrent readers, received %d (%d pkranges calls)", name, maxInFlight, pkrangesCalls)
+	}
+
+	// ORDER BY queries and queries scoped to a partition key range are read sequentially
+	maxInFlight = 0
+	for _, query := range []string{
+		"SELECT CROSS PARTITION * FROM c ORDER BY c.id WITH collection=coll WITH max_parallelism=3",
+		"SELECT * FROM c WITH collection=coll WITH pkrangeid=1 WITH max_parallelism=3",
+	} {
+		if rows, err := db.Query(query); err != nil {
+			t.Fatalf("%s failed: %s", name, err)
+		} else {
+			rows.Close()
+		}
+	}
+	if maxInFlight != 1 || pkrangesCalls != 1 {
+		t.Fatalf("%s failed: expected sequential reads, received %d concurrent readers (%d pkranges calls)", name, maxInFlight, pkrangesCalls)
+	}
+
+	for _, query := range []string{
+		"SELECT * FROM c WITH collection=coll WITH max_parallelism=0",
+		"SELECT * FROM c WITH collection=coll WITH prefetch=-1",
+	} {
+		if _, err := db.Query(query); err == nil {
+			t.Fatalf("%s failed: query must not be parsed successfully: %s", name, query)
+		}
+	}
+	if _, err := (&Driver{}).Open("AccountEndpoint=" + server.URL + ";AccountKey=cHJpbWFyeQ==;PrefetchPages=x"); err == nil {
+		t.Fatalf("%s failed: invalid PrefetchPages must be rejected", name)
+	}
+}
+
+func TestStmtSelect_Prefetch(t *testing.T) {
+	name := "TestStmtSelect_Prefetch"
+	var lock sync.Mutex
+	requested := make([]string, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := 0
+		fmt.Sscanf(r.Header.Get("X-Ms-Continuation"), "%d", &page)
+		lock.Lock()
+		requested = append(requested, fmt.Sprint(page))
+		lock.Unlock()
+		if page < 9 {
+			w.Header().Set("X-Ms-Continuation", fmt.Sprint(page+1))
+		}
+		if page == 0 {
+			w.Write([]byte(`{"_count":1,"Documents":[{"id":"0","page":0}]}`))
+			return
+		}
+		w.Write([]byte(fmt.Sprintf(`{"_count":1,"Documents":[{"id":"%d","page":%d,"extra":true}]}`, page, page)))
+	}))
+	defer server.Close()
+	db, _ := sql.Open("gocosmos", "AccountEndpoint="+server.URL+";AccountKey=cHJpbWFyeQ==;DefaultDb=db")
+	defer db.Close()
+	numRequested := func() int {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(requested)
+	}
+
+	rows, err := db.Query("SELECT * FROM c WITH collection=coll WITH prefetch=2")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if cols, _ := rows.Columns(); strings.Join(cols, ",") != "id,page" {
+		t.Fatalf("%s failed: columns must be the fields of the first page, received %v", name, cols)
+	}
+	time.Sleep(50 * time.Millisecond)
+	// first page, 2 buffered pages and 1 page waiting to be buffered
+	if n := numRequested(); n > 4 {
+		t.Fatalf("%s failed: at most 4 pages must be read ahead, %d pages read", name, n)
+	}
+	for i := 0; i < 3 && rows.Next(); i++ {
+		var id string
+		var page float64
+		if err := rows.Scan(&id, &page); err != nil || id != fmt.Sprint(i) || page != float64(i) {
+			t.Fatalf("%s failed: unexpected row %s/%v/%s", name, id, page, err)
+		}
+	}
+	rows.Close()
+	time.Sleep(50 * time.Millisecond)
+	if n := numRequested(); n >= 10 {
+		t.Fatalf("%s failed: pages must not be read after rows are closed, %d pages read", name, n)
+	}
+
+	// without prefetch, all pages are read before Query returns
+	lock.Lock()
+	requested = requested[:0]
+	lock.Unlock()
+	rows, err = db.Query("SELECT * FROM c WITH collection=coll")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	defer rows.Close()
+	if cols, _ := rows.Columns(); strings.Join(cols, ",") != "extra,id,page" || numRequested() != 10 {
+		t.Fatalf("%s failed: unexpected columns %v (%d pages read)", name, cols, numRequested())
+	}
+}
+
+func TestStmtSelect_CrossPartitionCount(t *testing.T) {
+	name := "TestStmtSelect_CrossPartitionCount"
+	var lock sync.Mutex
+	queries := make(map[string]bool)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/pkranges") {
+			w.Write([]byte(`{"_count":3,"PartitionKeyRanges":[{"id":"0"},{"id":"1"},{"id":"2"}]}`))
+			return
+		}
+		var body struct {
+			Query string `json:"query"`
+		}
+		data, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(data, &body)
+		lock.Lock()
+		queries[body.Query] = true
+		lock.Unlock()
+		pkrangeId, continuation := r.Header.Get("X-Ms-Documentdb-PartitionKeyRangeId"), r.Header.Get("X-Ms-Continuation")
+		if pkrangeId == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":"BadRequest","message":"The provided cross partition query can not be directly served by the gateway."}`))
+			return
+		}
+		column := "$1"
+		if strings.Contains(body.Query, " total ") {
+			column = "total"
+		}
+		switch {
+		case pkrangeId == "1" && continuation == "":
+			// partial result of the first page, more to come
+			w.Header().Set("X-Ms-Continuation", "next")
+			w.Write([]byte(`{"_count":1,"Documents":[{"` + column + `":2}]}`))
+		case pkrangeId == "2":
+			// no document in the range
+			w.Write([]byte(`{"_count":1,"Documents":[{}]}`))
+		default:
+			w.Write([]byte(`{"_count":1,"Documents":[{"` + column + `":10}]}`))
+		}
+	}))
+	defer server.Close()
+	db, _ := sql.Open("gocosmos", "AccountEndpoint="+server.URL+";AccountKey=cHJpbWFyeQ==;DefaultDb=db;MaxParallelism=2")
+	defer db.Close()
+
+	for query, expected := range map[string][]string{
+		"SELECT CROSS PARTITION COUNT(1) FROM c WHERE c.grade>1 WITH collection=coll": {"$1", "SELECT COUNT(1) FROM c WHERE c.grade>1"},
+		"SELECT VALUE COUNT(1) FROM c WITH collection=coll WITH cross_partition=true": {"$1", "SELECT COUNT(1) FROM c"},
+		"SELECT CROSS PARTITION COUNT(c.id) AS total FROM c WITH collection=coll":     {"total", "SELECT COUNT(c.id) AS total FROM c"},
+		"SELECT CROSS PARTITION COUNT(c.id) total FROM c WITH collection=coll":        {"total", "SELECT COUNT(c.id) total FROM c"},
+	} {
+		rows, err := db.Query(query)
+		if err != nil {
+			t.Fatalf("%s failed: <%s> %s", name, query, err)
+		}
+		cols, _ := rows.Columns()
+		var count int64
+		numRows := 0
+		for rows.Next() {
+			rows.Scan(&count)
+			numRows++
+		}
+		rows.Close()
+		if numRows != 1 || count != 22 || !reflect.DeepEqual(cols, expected[:1]) || !queries[expected[1]] {
+			t.Fatalf("%s failed: <%s> expected a single row %s=22 but received %d rows, %v=%d (queries sent: %v)", name, query, expected[0], numRows, cols, count, queries)
+		}
+	}
+}
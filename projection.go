@@ -0,0 +1,197 @@
+package gocosmos
+
+import (
+	"strconv"
+	"strings"
+)
+
+// _unquoteName returns the property name of a "..." or '...' string literal, e.g. in c["name"].
+func _unquoteName(literal string) string {
+	if literal[0] == '"' {
+		if name, err := strconv.Unquote(literal); err == nil {
+			return name
+		}
+	}
+	return literal[1 : len(literal)-1]
+}
+
+// _splitProjection splits the select list of a SELECT query into its items, each a list of tokens. nil is returned if
+// the query does not have an explicit projection, i.e. SELECT * or SELECT VALUE.
+func _splitProjection(query string) [][]token {
+	l := &lexer{input: query}
+	tok, err := l.next()
+	if err != nil || !tok.is("SELECT") {
+		return nil
+	}
+	if tok, err = l.next(); err == nil && tok.is("DISTINCT") {
+		tok, err = l.next()
+	}
+	if err == nil && tok.is("TOP") {
+		// TOP <n>, where n may be a placeholder (@_1)
+		if tok, err = l.next(); err == nil && tok.is("@") {
+			tok, err = l.next()
+		}
+		if tok, err = l.next(); err == nil && tok.is("DISTINCT") {
+			tok, err = l.next()
+		}
+	}
+	if err != nil || tok.is("*") || tok.is("VALUE") {
+		return nil
+	}
+	var items [][]token
+	var item []token
+	depth := 0
+	for ; err == nil && tok.kind != tokEOF; tok, err = l.next() {
+		switch {
+		case tok.is("(") || tok.is("[") || tok.is("{"):
+			depth++
+		case tok.is(")") || tok.is("]") || tok.is("}"):
+			depth--
+		case depth == 0 && tok.is(","):
+			items, item = append(items, item), nil
+			continue
+		case depth == 0 && tok.is("FROM"):
+			return append(items, item)
+		}
+		item = append(item, tok)
+	}
+	if err != nil {
+		return nil
+	}
+	return append(items, item)
+}
+
+// _isLiteralKeyword returns true if the token is one of the literals true, false, null or undefined.
+func _isLiteralKeyword(tok token) bool {
+	return tok.is("true") || tok.is("false") || tok.is("null") || tok.is("undefined")
+}
+
+// projectionOperators lists the keywords that are followed by an operand in expressions of a select list.
+var projectionOperators = []string{"AND", "OR", "NOT", "IN", "BETWEEN", "LIKE", "ESCAPE", "EXISTS", "ARRAY"}
+
+// _endsExpression returns true if the token can be the last token of an expression, so that an identifier following
+// it is an alias (e.g. "UPPER(c.name) uname" or "c.name n") rather than a part of the expression.
+func _endsExpression(tok token) bool {
+	switch tok.kind {
+	case tokNumber, tokString:
+		return true
+	case tokIdent:
+		for _, kw := range projectionOperators {
+			if tok.is(kw) {
+				return false
+			}
+		}
+		return true
+	}
+	return tok.is(")") || tok.is("]") || tok.is("}")
+}
+
+// _projectionAlias returns the alias of an item of a select list, specified as "<expression> AS <alias>" or
+// "<expression> <alias>", "" if the item has no alias.
+func _projectionAlias(item []token) string {
+	n := len(item)
+	if n < 2 || item[n-1].kind != tokIdent || _isLiteralKeyword(item[n-1]) {
+		return ""
+	}
+	if item[n-2].is("AS") {
+		if n >= 3 {
+			return item[n-1].text
+		}
+		return ""
+	}
+	if _endsExpression(item[n-2]) {
+		return item[n-1].text
+	}
+	return ""
+}
+
+// _projectionColumn returns the column name of an item of a select list: its alias (see _projectionAlias), otherwise
+// the name of the last property of a property path such as c.address.city or c["address"]["city"]. "" is returned if
+// the item is another expression without alias, e.g. UPPER(c.name) or c.tags[0].
+func _projectionColumn(item []token) string {
+	if alias := _projectionAlias(item); alias != "" {
+		return alias
+	}
+	if len(item) == 0 || item[0].kind != tokIdent || _isLiteralKeyword(item[0]) {
+		return ""
+	}
+	name := item[0].text
+	for i := 1; i < len(item); i++ {
+		switch {
+		case item[i].is(".") && i+1 < len(item) && item[i+1].kind == tokIdent:
+			name = item[i+1].text
+			i++
+		case item[i].is("[") && i+2 < len(item) && item[i+1].kind == tokString && item[i+2].is("]"):
+			name = _unquoteName(item[i+1].text)
+			i += 2
+		default:
+			return ""
+		}
+	}
+	return name
+}
+
+// _selectColumns returns the column names of the explicit projection of a SELECT query, in the order of the select
+// list, e.g. ["name", "town", "$1"] for "SELECT c.name, c.address.city AS town, UPPER(c.id) FROM c". Like the server,
+// items without alias that are not property paths are named $1, $2... in order. nil is returned if the query does not
+// have an explicit projection.
+func _selectColumns(query string) []string {
+	items := _splitProjection(strings.TrimSpace(query))
+	if len(items) == 0 {
+		return nil
+	}
+	columns := make([]string, 0, len(items))
+	unnamed := 0
+	for _, item := range items {
+		if len(item) == 0 {
+			return nil
+		}
+		column := _projectionColumn(item)
+		if column == "" {
+			unnamed++
+			column = "$" + strconv.Itoa(unnamed)
+		}
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+// _flattenInto stores the fields of obj into flat, with nested objects flattened into "parent.child" keys.
+func _flattenInto(flat DocInfo, prefix string, obj map[string]interface{}) {
+	for key, value := range obj {
+		if nested, ok := value.(map[string]interface{}); ok && len(nested) > 0 {
+			_flattenInto(flat, prefix+key+".", nested)
+		} else {
+			flat[prefix+key] = value
+		}
+	}
+}
+
+// _flattenDocuments flattens nested objects of the documents into "parent.child" fields, e.g.
+// {"address":{"city":"HCM"}} into {"address.city":"HCM"}. Arrays and empty objects are kept as values.
+func _flattenDocuments(documents []DocInfo) []DocInfo {
+	flattened := make([]DocInfo, len(documents))
+	for i, doc := range documents {
+		flattened[i] = make(DocInfo, len(doc))
+		_flattenInto(flattened[i], "", doc)
+	}
+	return flattened
+}
+
+// _flattenColumns expands the columns of an explicit projection into the flattened fields of the documents (fields,
+// sorted): a column whose values are objects is replaced in place by its "<column>.<child>" fields.
+func _flattenColumns(columns, fields []string) []string {
+	expanded := make([]string, 0, len(fields))
+	for _, column := range columns {
+		found := false
+		for _, field := range fields {
+			if field == column || strings.HasPrefix(field, column+".") {
+				expanded, found = append(expanded, field), true
+			}
+		}
+		if !found {
+			expanded = append(expanded, column)
+		}
+	}
+	return expanded
+}
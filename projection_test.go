@@ -0,0 +1,165 @@
+package gocosmos
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func Test_selectColumns(t *testing.T) {
+	name := "Test_selectColumns"
+	testData := []struct {
+		query    string
+		expected []string
+	}{
+		{"SELECT c.b, c.a FROM c", []string{"b", "a"}},
+		{"SELECT c.b AS x, c.a FROM c WHERE c.id=@_1", []string{"x", "a"}},
+		{"select distinct c.address.city, c[\"zip code\"] from c", []string{"city", "zip code"}},
+		{"SELECT TOP 10 c.name, c.grade FROM c ORDER BY c.grade", []string{"name", "grade"}},
+		{"SELECT TOP @_1 c.name FROM c", []string{"name"}},
+		{"SELECT COUNT(1) AS total, c.grade FROM c GROUP BY c.grade", []string{"total", "grade"}},
+		{"SELECT COUNT(1), c.grade FROM c GROUP BY c.grade", []string{"$1", "grade"}},
+		{"SELECT c.id, (SELECT VALUE COUNT(1) FROM t IN c.tags) AS tags FROM c", []string{"id", "tags"}},
+		{"SELECT * FROM c", nil},
+		{"SELECT VALUE c.name FROM c", nil},
+		{"SELECT c.id, UPPER(c.name), c.tags[0], c.a AS x, c.a + 1 FROM c", []string{"id", "$1", "$2", "x", "$3"}},
+		{"SELECT UPPER(c.name) uname, c.address.city town, c.a ?? 'n/a' b FROM c", []string{"uname", "town", "b"}},
+		{"SELECT c.a AND c.b, NOT c, c.x IN ('a', 'b') isX, true, null AS n FROM c", []string{"$1", "$2", "isX", "$3", "n"}},
+		{"SELECT 1 AS one, 'a' two", []string{"one", "two"}},
+	}
+	for _, data := range testData {
+		if columns := _selectColumns(data.query); !reflect.DeepEqual(columns, data.expected) {
+			t.Fatalf("%s failed: <%s> expected %#v but received %#v", name, data.query, data.expected, columns)
+		}
+	}
+}
+
+func TestStmtSelect_ProjectionColumns(t *testing.T) {
+	name := "TestStmtSelect_ProjectionColumns"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// undefined values are omitted from the returned documents
+		w.Write([]byte(`{"_count":2,"Documents":[{"a":1,"x":"b1"},{"a":2}]}`))
+	}))
+	defer server.Close()
+	db, _ := sql.Open("gocosmos", "AccountEndpoint="+server.URL+";AccountKey=cHJpbWFyeQ==;DefaultDb=db")
+	defer db.Close()
+
+	for _, query := range []string{
+		"SELECT c.x, c.a FROM c WITH collection=coll",
+		"SELECT c.x, c.a FROM c WITH collection=coll WITH prefetch=1",
+	} {
+		rows, err := db.Query(query)
+		if err != nil {
+			t.Fatalf("%s failed: <%s> %s", name, query, err)
+		}
+		if columns, _ := rows.Columns(); !reflect.DeepEqual(columns, []string{"x", "a"}) {
+			t.Fatalf("%s failed: <%s> columns must follow the projection, received %#v", name, query, columns)
+		}
+		var values [][2]interface{}
+		for rows.Next() {
+			var x, a interface{}
+			if err := rows.Scan(&x, &a); err != nil {
+				t.Fatalf("%s failed: <%s> %s", name, query, err)
+			}
+			values = append(values, [2]interface{}{x, a})
+		}
+		rows.Close()
+		if expected := [][2]interface{}{{"b1", 1.0}, {nil, 2.0}}; !reflect.DeepEqual(values, expected) {
+			t.Fatalf("%s failed: <%s> expected %#v but received %#v", name, query, expected, values)
+		}
+	}
+}
+
+func TestStmtSelect_ProjectionAliases(t *testing.T) {
+	name := "TestStmtSelect_ProjectionAliases"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"_count":1,"Documents":[{"$1":"USER1","uname":"user1","$2":3}]}`))
+	}))
+	defer server.Close()
+	db, _ := sql.Open("gocosmos", "AccountEndpoint="+server.URL+";AccountKey=cHJpbWFyeQ==;DefaultDb=db")
+	defer db.Close()
+
+	rows, err := db.Query("SELECT c.name uname, UPPER(c.name), ARRAY_LENGTH(c.tags) FROM c WITH collection=coll")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	defer rows.Close()
+	if columns, _ := rows.Columns(); !reflect.DeepEqual(columns, []string{"uname", "$1", "$2"}) {
+		t.Fatalf("%s failed: unexpected columns %#v", name, columns)
+	}
+	var uname, upper string
+	var numTags int
+	if !rows.Next() {
+		t.Fatalf("%s failed: expected a row", name)
+	}
+	if err := rows.Scan(&uname, &upper, &numTags); err != nil || uname != "user1" || upper != "USER1" || numTags != 3 {
+		t.Fatalf("%s failed: unexpected row %s / %s / %d / %s", name, uname, upper, numTags, err)
+	}
+}
+
+func TestStmtSelect_Flatten(t *testing.T) {
+	name := "TestStmtSelect_Flatten"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"_count":2,"Documents":[{"id":"1","address":{"city":"HCM","geo":{"lat":10.7}},"tags":["a"],"meta":{}},` +
+			`{"id":"2","address":{"city":"HN"}}]}`))
+	}))
+	defer server.Close()
+	dsn := "AccountEndpoint=" + server.URL + ";AccountKey=cHJpbWFyeQ==;DefaultDb=db"
+	db, _ := sql.Open("gocosmos", dsn)
+	defer db.Close()
+	dbFlatten, _ := sql.Open("gocosmos", dsn+";Flatten=true")
+	defer dbFlatten.Close()
+
+	allColumns := []string{"address.city", "address.geo.lat", "id", "meta", "tags"}
+	testData := []struct {
+		db       *sql.DB
+		query    string
+		expected []string
+	}{
+		{db, "SELECT * FROM c WITH collection=coll WITH flatten=true", allColumns},
+		{db, "SELECT * FROM c WITH collection=coll WITH flatten=true WITH prefetch=1", allColumns},
+		{dbFlatten, "SELECT * FROM c WITH collection=coll", allColumns},
+		{dbFlatten, "SELECT * FROM c WITH collection=coll WITH flatten=false", []string{"address", "id", "meta", "tags"}},
+		{dbFlatten, "SELECT c.tags, c.address, c.id FROM c WITH collection=coll", []string{"tags", "address.city", "address.geo.lat", "id"}},
+	}
+	for _, data := range testData {
+		rows, err := data.db.Query(data.query)
+		if err != nil {
+			t.Fatalf("%s failed: <%s> %s", name, data.query, err)
+		}
+		columns, _ := rows.Columns()
+		if !reflect.DeepEqual(columns, data.expected) {
+			t.Fatalf("%s failed: <%s> expected columns %#v but received %#v", name, data.query, data.expected, columns)
+		}
+		values := make([]interface{}, len(columns))
+		var rowValues []map[string]interface{}
+		for rows.Next() {
+			ptrs := make([]interface{}, len(columns))
+			for i := range values {
+				ptrs[i] = &values[i]
+			}
+			if err := rows.Scan(ptrs...); err != nil {
+				t.Fatalf("%s failed: <%s> %s", name, data.query, err)
+			}
+			row := make(map[string]interface{})
+			for i, column := range columns {
+				row[column] = values[i]
+			}
+			rowValues = append(rowValues, row)
+		}
+		rows.Close()
+		if _, flattened := rowValues[0]["address.city"]; len(rowValues) != 2 || (flattened && (rowValues[0]["address.city"] != "HCM" ||
+			rowValues[0]["address.geo.lat"] != 10.7 || rowValues[1]["address.city"] != "HN" || rowValues[1]["address.geo.lat"] != nil)) {
+			t.Fatalf("%s failed: <%s> unexpected rows %#v", name, data.query, rowValues)
+		}
+	}
+
+	if _, err := db.Query("SELECT * FROM c WITH collection=coll WITH flatten=maybe"); err == nil {
+		t.Fatalf("%s failed: invalid flatten value must be rejected", name)
+	}
+	if _, err := ParseDSN(dsn + ";Flatten=maybe"); err == nil {
+		t.Fatalf("%s failed: invalid Flatten option must be rejected", name)
+	}
+}
@@ -0,0 +1,229 @@
+package gocosmos
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/btnguyen2k/consu/gjrc"
+)
+
+const (
+	// regionResolveRetryInterval is the minimum interval between attempts to resolve regional endpoints after a failure.
+	regionResolveRetryInterval = time.Minute
+
+	// regionRefreshInterval is the interval to refresh regional endpoints in background.
+	regionRefreshInterval = 5 * time.Minute
+)
+
+// regionRouter routes requests to regional endpoints of the database account according to the preferred regions.
+type regionRouter struct {
+	preferred      []string // preferred regions in order, normalized
+	lock           sync.RWMutex
+	resolveLock    sync.Mutex
+	resolvedAt     time.Time // last time regional endpoints were resolved successfully
+	attemptedAt    time.Time // last time regional endpoints were attempted to resolve
+	refreshing     bool      // true if a background refresh is in progress
+	readEndpoints  []string  // endpoints to route read requests to, in order
+	writeEndpoints []string  // endpoints to route write requests to, in order
+}
+
+// _normalizeRegion normalizes region name for comparison, e.g. "West US" and "westus" are the same region.
+func _normalizeRegion(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, " ", ""))
+}
+
+// newRegionRouter creates a new regionRouter from a comma-separated list of preferred regions.
+// nil is returned if no preferred region is specified and endpoint discovery is not enabled.
+func newRegionRouter(preferredRegions string, endpointDiscovery bool) *regionRouter {
+	preferred := make([]string, 0)
+	for _, region := range strings.Split(preferredRegions, ",") {
+		if region = _normalizeRegion(region); region != "" {
+			preferred = append(preferred, region)
+		}
+	}
+	if len(preferred) == 0 && !endpointDiscovery {
+		return nil
+	}
+	return &regionRouter{preferred: preferred}
+}
+
+// endpoints returns the endpoints to route read or write requests to.
+func (r *regionRouter) endpoints(isRead bool) []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	if isRead {
+		return r.readEndpoints
+	}
+	return r.writeEndpoints
+}
+
+// update computes the regional endpoints from the database account's metadata.
+// Endpoints of preferred regions come first (in order of preference), the default endpoint is the last resort.
+func (r *regionRouter) update(account DatabaseAccountInfo, defaultEndpoint string) {
+	build := func(locations []AccountLocation, candidates []AccountLocation) []string {
+		endpoints := make([]string, 0)
+		added := make(map[string]bool)
+		add := func(endpoint string) {
+			if endpoint = strings.TrimSuffix(endpoint, "/"); endpoint != "" && !added[endpoint] {
+				added[endpoint] = true
+				endpoints = append(endpoints, endpoint)
+			}
+		}
+		for _, region := range r.preferred {
+			for _, loc := range locations {
+				if _normalizeRegion(loc.Name) == region {
+					add(loc.Endpoint)
+				}
+			}
+		}
+		for _, loc := range candidates {
+			add(loc.Endpoint)
+		}
+		add(defaultEndpoint)
+		return endpoints
+	}
+	var writeEndpoints []string
+	if account.EnableMultipleWriteLocations {
+		writeEndpoints = build(account.WritableLocations, nil)
+	} else if len(account.WritableLocations) > 0 {
+		// single-write account: writes must go to the current write region
+		writeEndpoints = build(nil, account.WritableLocations[:1])
+	} else {
+		writeEndpoints = build(nil, nil)
+	}
+	readEndpoints := build(account.ReadableLocations, nil)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.readEndpoints, r.writeEndpoints = readEndpoints, writeEndpoints
+	r.resolvedAt = time.Now()
+}
+
+// resolveRegions fetches the database account's metadata and updates regional endpoints.
+func (c *RestClient) resolveRegions() error {
+	result := c.GetDatabaseAccount()
+	if err := result.Error(); err != nil {
+		return err
+	}
+	c.regions.update(result.DatabaseAccountInfo, c.endpoint)
+	return nil
+}
+
+// _isReadRequest returns true if the request only reads data.
+func _isReadRequest(req *http.Request) bool {
+	return req.Method == "GET" || req.Method == "HEAD" || strings.ToLower(req.Header.Get("X-Ms-Documentdb-Isquery")) == "true"
+}
+
+// routeEndpoints returns the endpoints to send the request to, in order. nil is returned if the request is not routed.
+//
+// Regional endpoints are resolved upon the first request, and refreshed in background periodically.
+func (c *RestClient) routeEndpoints(req *http.Request) []string {
+	r := c.regions
+	if r == nil || req.URL.Path == "/" || req.URL.Path == "" {
+		// account-level requests always go to the default endpoint
+		return nil
+	}
+	r.lock.RLock()
+	resolvedAt := r.resolvedAt
+	r.lock.RUnlock()
+	if resolvedAt.IsZero() {
+		r.resolveLock.Lock()
+		if r.resolvedAt.IsZero() && time.Since(r.attemptedAt) >= regionResolveRetryInterval {
+			r.attemptedAt = time.Now()
+			c.resolveRegions()
+		}
+		r.resolveLock.Unlock()
+	} else if time.Since(resolvedAt) >= regionRefreshInterval {
+		c.refreshRegionsInBackground()
+	}
+	return r.endpoints(_isReadRequest(req))
+}
+
+// refreshRegionsInBackground refreshes regional endpoints in a background goroutine, unless a refresh is in progress.
+func (c *RestClient) refreshRegionsInBackground() {
+	r := c.regions
+	r.resolveLock.Lock()
+	defer r.resolveLock.Unlock()
+	if r.refreshing {
+		return
+	}
+	r.refreshing = true
+	go func() {
+		c.resolveRegions()
+		r.resolveLock.Lock()
+		r.refreshing = false
+		r.resolveLock.Unlock()
+	}()
+}
+
+// _routeRequest clones the request to be sent to the specified endpoint.
+func _routeRequest(req *http.Request, endpoint string) (*http.Request, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	routedReq := req.Clone(req.Context())
+	routedReq.URL.Scheme, routedReq.URL.Host, routedReq.Host = u.Scheme, u.Host, ""
+	if req.GetBody != nil {
+		if routedReq.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	return routedReq, nil
+}
+
+// _isRegionalFailure returns true if the request should be retried against another region.
+func _isRegionalFailure(resp *gjrc.GjrcResponse) bool {
+	return resp.Error() != nil || resp.StatusCode() == http.StatusServiceUnavailable
+}
+
+// _isWriteForbidden returns true if the request was rejected because the region no longer accepts writes
+// (StatusCode=403, sub-status 3), which happens after the account's write region has been changed.
+func _isWriteForbidden(resp *gjrc.GjrcResponse) bool {
+	return resp.Error() == nil && resp.StatusCode() == http.StatusForbidden && resp.HttpResponse().Header.Get("X-Ms-Substatus") == "3"
+}
+
+// tryEndpoints sends the request to the regional endpoints in order, failing over to the next endpoint on regional failures.
+func (c *RestClient) tryEndpoints(req *http.Request, endpoints []string) *gjrc.GjrcResponse {
+	var resp *gjrc.GjrcResponse
+	for _, endpoint := range endpoints {
+		routedReq, err := _routeRequest(req, endpoint)
+		if err != nil {
+			continue
+		}
+		if resp = c.doWithKeys(routedReq); !_isRegionalFailure(resp) {
+			return resp
+		}
+		c.log(LogLevelWarn, "regional endpoint failed, failing over to the next endpoint",
+			map[string]interface{}{"endpoint": endpoint, "path": req.URL.Path})
+	}
+	if resp == nil {
+		resp = c.doWithKeys(req)
+	}
+	return resp
+}
+
+// doRouted sends the request to the regional endpoints.
+//
+// If a write request is rejected because the region no longer accepts writes, or all endpoints fail, regional endpoints
+// are refreshed immediately and the request is retried once against the new endpoints (e.g. the new write region).
+func (c *RestClient) doRouted(req *http.Request, endpoints []string) *gjrc.GjrcResponse {
+	resp := c.tryEndpoints(req, endpoints)
+	if _isWriteForbidden(resp) || _isRegionalFailure(resp) {
+		r := c.regions
+		c.log(LogLevelInfo, "refreshing regional endpoints and retrying request", map[string]interface{}{"path": req.URL.Path})
+		r.resolveLock.Lock()
+		err := c.resolveRegions()
+		r.resolveLock.Unlock()
+		if err == nil {
+			if newEndpoints := r.endpoints(_isReadRequest(req)); !reflect.DeepEqual(newEndpoints, endpoints) {
+				resp = c.tryEndpoints(req, newEndpoints)
+			}
+		}
+	}
+	return resp
+}
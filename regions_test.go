@@ -0,0 +1,158 @@
+package gocosmos
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func Test_newRegionRouter(t *testing.T) {
+	name := "Test_newRegionRouter"
+	if r := newRegionRouter(" , ", false); r != nil {
+		t.Fatalf("%s failed: expected nil router if no preferred region", name)
+	}
+	r := newRegionRouter("West US, East Asia,southeastasia", false)
+	if expected := []string{"westus", "eastasia", "southeastasia"}; !reflect.DeepEqual(r.preferred, expected) {
+		t.Fatalf("%s failed: expected %#v but received %#v", name, expected, r.preferred)
+	}
+
+	account := DatabaseAccountInfo{
+		WritableLocations: []AccountLocation{{Name: "East US", Endpoint: "https://acc-eastus:443/"}},
+		ReadableLocations: []AccountLocation{
+			{Name: "East US", Endpoint: "https://acc-eastus:443/"},
+			{Name: "East Asia", Endpoint: "https://acc-eastasia:443/"},
+			{Name: "West US", Endpoint: "https://acc-westus:443/"},
+		},
+	}
+	r.update(account, "https://acc:443")
+	if expected := []string{"https://acc-westus:443", "https://acc-eastasia:443", "https://acc:443"}; !reflect.DeepEqual(r.readEndpoints, expected) {
+		t.Fatalf("%s failed: <read> expected %#v but received %#v", name, expected, r.readEndpoints)
+	}
+	if expected := []string{"https://acc-eastus:443", "https://acc:443"}; !reflect.DeepEqual(r.writeEndpoints, expected) {
+		t.Fatalf("%s failed: <write> expected %#v but received %#v", name, expected, r.writeEndpoints)
+	}
+
+	account.EnableMultipleWriteLocations = true
+	account.WritableLocations = account.ReadableLocations
+	r.update(account, "https://acc:443")
+	if expected := []string{"https://acc-westus:443", "https://acc-eastasia:443", "https://acc:443"}; !reflect.DeepEqual(r.writeEndpoints, expected) {
+		t.Fatalf("%s failed: <multi-write> expected %#v but received %#v", name, expected, r.writeEndpoints)
+	}
+}
+
+func TestRestClient_PreferredRegions(t *testing.T) {
+	name := "TestRestClient_PreferredRegions"
+	lock := sync.Mutex{}
+	hits := make(map[string]int)
+	newServer := func(region string, statusCode int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			hits[region+" "+r.Method]++
+			lock.Unlock()
+			w.WriteHeader(statusCode)
+			w.Write([]byte(`{"id":"mydb"}`))
+		}))
+	}
+	regionA := newServer("A", http.StatusOK)
+	defer regionA.Close()
+	regionB := newServer("B", http.StatusServiceUnavailable)
+	defer regionB.Close()
+	global := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"id":"myaccount",
+			"writableLocations":[{"name":"Region A","databaseAccountEndpoint":"` + regionA.URL + `/"}],
+			"readableLocations":[{"name":"Region A","databaseAccountEndpoint":"` + regionA.URL + `/"},{"name":"Region B","databaseAccountEndpoint":"` + regionB.URL + `/"}]}`))
+	}))
+	defer global.Close()
+
+	client, err := NewRestClient(nil, "AccountEndpoint="+global.URL+";AccountKey=cHJpbWFyeQ==;PreferredRegions=Region B,Region A")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	account := client.GetDatabaseAccount()
+	if account.Error() != nil || account.Id != "myaccount" || len(account.ReadableLocations) != 2 {
+		t.Fatalf("%s failed: %s / %#v", name, account.Error(), account.DatabaseAccountInfo)
+	}
+
+	// region B is preferred for reads but unavailable, fail over to region A
+	if result := client.GetDatabase("mydb"); result.Error() != nil || result.Id != "mydb" {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	}
+	if hits["B GET"] != 1 || hits["A GET"] != 1 {
+		t.Fatalf("%s failed: unexpected hits %#v", name, hits)
+	}
+	// writes go to the write region
+	if result := client.CreateDatabase(DatabaseSpec{Id: "mydb"}); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	}
+	if hits["A POST"] != 1 || hits["B POST"] != 0 {
+		t.Fatalf("%s failed: unexpected hits %#v", name, hits)
+	}
+}
+
+func TestRestClient_WriteRegionFailover(t *testing.T) {
+	name := "TestRestClient_WriteRegionFailover"
+	lock := sync.Mutex{}
+	failedOver := false
+	hits := make(map[string]int)
+	newServer := func(region string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			defer lock.Unlock()
+			hits[region]++
+			if region == "A" && failedOver {
+				w.Header().Set("X-Ms-Substatus", "3")
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Write([]byte(`{"id":"mydb"}`))
+		}))
+	}
+	regionA := newServer("A")
+	defer regionA.Close()
+	regionB := newServer("B")
+	defer regionB.Close()
+	global := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		hits["global"]++
+		writeRegion := regionA.URL
+		if failedOver {
+			writeRegion = regionB.URL
+		}
+		w.Write([]byte(`{"id":"myaccount","writableLocations":[{"name":"Region","databaseAccountEndpoint":"` + writeRegion + `"}],"readableLocations":[]}`))
+	}))
+	defer global.Close()
+
+	if client, _ := NewRestClient(nil, "AccountEndpoint="+global.URL+";AccountKey=cHJpbWFyeQ=="); client.regions != nil {
+		t.Fatalf("%s failed: endpoint discovery must be disabled by default", name)
+	}
+	if _, err := NewRestClient(nil, "AccountEndpoint="+global.URL+";AccountKey=cHJpbWFyeQ==;EndpointDiscovery=abc"); err == nil {
+		t.Fatalf("%s failed: expected error for invalid EndpointDiscovery", name)
+	}
+	client, err := NewRestClient(nil, "AccountEndpoint="+global.URL+";AccountKey=cHJpbWFyeQ==;EndpointDiscovery=true")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if result := client.CreateDatabase(DatabaseSpec{Id: "mydb"}); result.Error() != nil || hits["A"] != 1 {
+		t.Fatalf("%s failed: expected write to region A %#v / %s", name, hits, result.Error())
+	}
+
+	lock.Lock()
+	failedOver = true
+	lock.Unlock()
+	if result := client.CreateDatabase(DatabaseSpec{Id: "mydb"}); result.Error() != nil {
+		t.Fatalf("%s failed: expected write to be retried against the new write region: %s", name, result.Error())
+	}
+	if hits["A"] != 2 || hits["B"] != 1 || hits["global"] != 2 {
+		t.Fatalf("%s failed: unexpected hits %#v", name, hits)
+	}
+	if result := client.CreateDatabase(DatabaseSpec{Id: "mydb"}); result.Error() != nil || hits["B"] != 2 || hits["A"] != 2 {
+		t.Fatalf("%s failed: expected subsequent writes to go to region B %#v / %s", name, hits, result.Error())
+	}
+}
@@ -2,12 +2,18 @@ package gocosmos
 
 import (
 	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"reflect"
@@ -26,28 +32,98 @@ import (
 // connStr is expected to be in the following format:
 //     AccountEndpoint=<cosmosdb-restapi-endpoint>;AccountKey=<account-key>[;TimeoutMs=<timeout-in-ms>][;Version=<cosmosdb-api-version>]
 // If not supplied, default value for TimeoutMs is 10 seconds and Version is "2018-12-31".
+//
+// (since v0.1.1) The other supported options are listed in the "Connection string options" section of README.md. If
+// connStr is empty or DsnFromEnv, the connection settings are read from environment variables, see ConfigFromEnv.
 func NewRestClient(httpClient *http.Client, connStr string) (*RestClient, error) {
+	return newRestClient(httpClient, connStr, nil)
+}
+
+// NewRestClientWithTokenCredential constructs a new RestClient instance that authenticates requests with Azure AD
+// (Entra ID) tokens obtained from cred instead of the account key.
+//
+// connStr has the same format as NewRestClient's, except that AccountKey is not required.
+//
+// Available since v0.1.1
+func NewRestClientWithTokenCredential(httpClient *http.Client, connStr string, cred TokenCredential) (*RestClient, error) {
+	connStr, err := _resolveConnStr(connStr)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := strings.TrimSuffix(_parseConnStr(connStr)["ACCOUNTENDPOINT"], "/")
+	if endpoint == "" {
+		return nil, errors.New("AccountEndpoint not found in connection string")
+	}
+	tokenProvider, err := newAadTokenProvider(cred, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return newRestClient(httpClient, connStr, tokenProvider)
+}
+
+// _trimConnStr removes the whitespaces and quotes surrounding a connection string, e.g. copy-pasted from the Azure portal.
+func _trimConnStr(connStr string) string {
+	connStr = strings.TrimSpace(connStr)
+	if len(connStr) > 1 && (connStr[0] == '"' || connStr[0] == '\'') && connStr[len(connStr)-1] == connStr[0] {
+		connStr = strings.TrimSpace(connStr[1 : len(connStr)-1])
+	}
+	return connStr
+}
+
+// _parseConnStr parses a connection string into a map of options, keys in upper case.
+//
+// Besides the driver's own options, it accepts the connection string format of the Azure portal
+// ("AccountEndpoint=...;AccountKey=...;"): option names are case-insensitive, whitespaces around names and values as
+// well as empty parts (e.g. the trailing semicolon) are ignored.
+func _parseConnStr(connStr string) map[string]string {
 	params := make(map[string]string)
-	parts := strings.Split(connStr, ";")
-	for _, part := range parts {
+	for _, part := range strings.Split(_trimConnStr(connStr), ";") {
 		tokens := strings.SplitN(part, "=", 2)
+		key := strings.ToUpper(strings.TrimSpace(tokens[0]))
+		if key == "" {
+			continue
+		}
 		if len(tokens) == 2 {
-			params[strings.ToUpper(tokens[0])] = strings.TrimSpace(tokens[1])
+			params[key] = strings.TrimSpace(tokens[1])
 		} else {
-			params[strings.ToUpper(tokens[0])] = ""
+			params[key] = ""
 		}
 	}
+	return params
+}
+
+func newRestClient(httpClient *http.Client, connStr string, tokenProvider *aadTokenProvider) (*RestClient, error) {
+	connStr, err := _resolveConnStr(connStr)
+	if err != nil {
+		return nil, err
+	}
+	params := _parseConnStr(connStr)
 	endpoint := strings.TrimSuffix(params["ACCOUNTENDPOINT"], "/")
 	if endpoint == "" {
 		return nil, errors.New("AccountEndpoint not found in connection string")
 	}
-	accountKey := params["ACCOUNTKEY"]
-	if accountKey == "" {
-		return nil, errors.New("AccountKey not found in connection string")
+	switch authMode := strings.ToLower(params["AUTHMODE"]); authMode {
+	case "", "key":
+	case "msi":
+		if tokenProvider == nil {
+			var err error
+			if tokenProvider, err = getMsiTokenProvider(endpoint, params["MSICLIENTID"]); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("invalid AuthMode <%s>, supported values are: key, msi", params["AUTHMODE"])
 	}
-	key, err := base64.StdEncoding.DecodeString(accountKey)
-	if err != nil {
-		return nil, fmt.Errorf("cannot base64 decode account key: %s", err)
+	var keys *accountKeys
+	if tokenProvider == nil {
+		accountKey := params["ACCOUNTKEY"]
+		if accountKey == "" {
+			return nil, errors.New("AccountKey not found in connection string")
+		}
+		var err error
+		if keys, err = newAccountKeys(accountKey, params["SECONDARYACCOUNTKEY"]); err != nil {
+			return nil, err
+		}
 	}
 	timeoutMs, err := strconv.Atoi(params["TIMEOUTMS"])
 	if err != nil || timeoutMs < 0 {
@@ -57,42 +133,236 @@ func NewRestClient(httpClient *http.Client, connStr string) (*RestClient, error)
 	if apiVersion == "" {
 		apiVersion = "2018-12-31"
 	}
-	return &RestClient{
-		client:     gjrc.NewGjrc(httpClient, time.Duration(timeoutMs)*time.Millisecond),
-		endpoint:   endpoint,
-		authKey:    key,
-		apiVersion: apiVersion,
-		params:     params,
-	}, nil
+	if tunedClient, err := _buildHttpClient(params, time.Duration(timeoutMs)*time.Millisecond); err != nil {
+		return nil, err
+	} else if httpClient == nil {
+		httpClient = tunedClient
+	}
+	disableCompression := false
+	if v, ok := params["DISABLECOMPRESSION"]; ok {
+		if disableCompression, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("invalid DisableCompression <%s>", v)
+		}
+	}
+	defaultConsistency, err := _normalizeConsistencyLevel(params["DEFAULTCONSISTENCY"])
+	if err != nil {
+		return nil, err
+	}
+	logLevel, err := _parseLogLevel(params["LOGLEVEL"])
+	if err != nil {
+		return nil, err
+	}
+	logParams := false
+	if v, ok := params["LOGPARAMS"]; ok {
+		if logParams, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("invalid LogParams <%s>", v)
+		}
+	}
+	var ruLimiter *RuLimiter
+	if v, ok := params["RUBUDGET"]; ok {
+		ruBudget, err := strconv.ParseFloat(v, 64)
+		if err != nil || ruBudget <= 0 {
+			return nil, fmt.Errorf("invalid RuBudget <%s>", v)
+		}
+		ruLimiter = _sharedRuLimiter(endpoint, ruBudget)
+	}
+	retryPolicy, err := _parseRetryPolicy(params)
+	if err != nil {
+		return nil, err
+	}
+	breaker, err := _parseCircuitBreaker(params, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	endpointDiscovery := false
+	if v, ok := params["ENDPOINTDISCOVERY"]; ok {
+		if endpointDiscovery, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("invalid EndpointDiscovery <%s>", v)
+		}
+	}
+	dedicatedGateway := strings.TrimSuffix(params["DEDICATEDGATEWAYENDPOINT"], "/")
+	if dedicatedGateway != "" {
+		if u, err := url.Parse(dedicatedGateway); err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid DedicatedGatewayEndpoint <%s>", dedicatedGateway)
+		}
+	}
+	cacheStalenessMs := 0
+	if v, ok := params["MAXINTEGRATEDCACHESTALENESSMS"]; ok {
+		if cacheStalenessMs, err = strconv.Atoi(v); err != nil || cacheStalenessMs <= 0 {
+			return nil, fmt.Errorf("invalid MaxIntegratedCacheStalenessMs <%s>", v)
+		}
+	}
+	continuationLimitKb := 0
+	if v, ok := params["CONTINUATIONLIMITKB"]; ok {
+		if continuationLimitKb, err = strconv.Atoi(v); err != nil || continuationLimitKb <= 0 {
+			return nil, fmt.Errorf("invalid ContinuationLimitKb <%s>", v)
+		}
+	}
+	c := &RestClient{
+		client:        gjrc.NewGjrc(httpClient, time.Duration(timeoutMs)*time.Millisecond),
+		timeout:       time.Duration(timeoutMs) * time.Millisecond,
+		endpoint:      endpoint,
+		keys:          keys,
+		tokenProvider: tokenProvider,
+		regions:       newRegionRouter(params["PREFERREDREGIONS"], endpointDiscovery),
+		apiVersion:    apiVersion,
+		params:        params,
+
+		disableCompression: disableCompression,
+		defaultConsistency: defaultConsistency,
+		logLevel:           logLevel,
+		logParams:          logParams,
+		ruLimiter:          ruLimiter,
+		retryPolicy:        retryPolicy,
+		stats:              &statsCollector{},
+
+		continuationLimitKb: continuationLimitKb,
+
+		dedicatedGateway: dedicatedGateway,
+		cacheStaleness:   time.Duration(cacheStalenessMs) * time.Millisecond,
+	}
+	httpClient = _withInterceptors(httpClient, c.timeout)
+	if breaker != nil {
+		httpClient = _withCircuitBreaker(httpClient, c.timeout, breaker, c)
+	}
+	c.client = gjrc.NewGjrc(httpClient, c.timeout)
+	return c, nil
 }
 
 // RestClient is REST-based client for Azure CosmosDB
 type RestClient struct {
-	client     *gjrc.Gjrc
-	endpoint   string            // Azure CosmosDB endpoint
-	authKey    []byte            // Account key to authenticate
-	apiVersion string            // Azure CosmosDB API version
-	params     map[string]string // parsed parameters
+	client        *gjrc.Gjrc
+	timeout       time.Duration     // (since v0.1.1) request timeout
+	endpoint      string            // Azure CosmosDB endpoint
+	keys          *accountKeys      // (since v0.1.1) Account keys to authenticate, primary key first
+	tokenProvider *aadTokenProvider // (since v0.1.1) Azure AD token provider to authenticate, used instead of keys if not nil
+	regions       *regionRouter     // (since v0.1.1) routes requests to regional endpoints, nil if no preferred region is configured
+	apiVersion    string            // Azure CosmosDB API version
+	params        map[string]string // parsed parameters
+
+	disableCompression bool            // (since v0.1.1) if true, responses are not requested to be compressed
+	deadline           time.Time       // (since v0.1.1) if not zero, requests are aborted when the deadline is exceeded
+	defaultConsistency string          // (since v0.1.1) consistency level of read requests that do not specify one
+	metrics            MetricsReporter // (since v0.1.1) receives metrics of requests, nil if not configured
+	logger             Logger          // (since v0.1.1) receives log entries, nil if not configured
+	logLevel           LogLevel        // (since v0.1.1) minimum level of entries written to logger
+	logParams          bool            // (since v0.1.1) if true, statement parameter values are logged instead of RedactedValue
+	ruLimiter          *RuLimiter      // (since v0.1.1) keeps RU consumption within a budget, nil if not configured
+	retryPolicy        retryPolicy     // (since v0.1.1) retries of idempotent requests on transient network errors
+	ctx                context.Context // (since v0.1.1) context of requests, nil if not bound to a context (see WithContext)
+	sessions           *sessionTokens  // (since v0.1.1) session tokens of collections, nil if not tracked (see Conn)
+	codec              JsonCodec       // (since v0.1.1) converts documents and parameters to JSON and back, nil means StdJsonCodec
+	encryptor          *FieldEncryptor // (since v0.1.1) encrypts/decrypts fields of documents, nil if not configured
+	stats              *statsCollector // (since v0.1.1) statistics of requests, shared by clients of a Connector or Driver
+	interceptors       []Interceptor   // (since v0.1.1) intercept requests before they are sent and responses when received
+
+	continuationLimitKb int // (since v0.1.1) size limit (in KB) of continuation tokens of queries that do not specify one, 0 if not limited
+
+	dedicatedGateway string        // (since v0.1.1) dedicated gateway endpoint to send document reads and queries to, "" if not configured
+	cacheStaleness   time.Duration // (since v0.1.1) max staleness of integrated cache entries served to reads, 0 if not specified
+}
+
+// _normalizeConsistencyLevel validates a consistency level (case-insensitive) and returns its canonical form.
+func _normalizeConsistencyLevel(level string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "":
+		return "", nil
+	case "strong":
+		return "Strong", nil
+	case "bounded":
+		return "Bounded", nil
+	case "session":
+		return "Session", nil
+	case "eventual":
+		return "Eventual", nil
+	}
+	return "", fmt.Errorf("invalid consistency level <%s>, supported values are: strong, bounded, session, eventual", level)
+}
+
+// consistencyLevel returns the consistency level to be sent with a read request, falling back to the client's default.
+func (c *RestClient) consistencyLevel(level string) string {
+	if level != "" {
+		return level
+	}
+	return c.defaultConsistency
+}
+
+// WithContext returns a copy of the client that sends requests with ctx: requests are aborted when ctx is done, and are
+// collected into the Diagnostics attached to ctx (see WithDiagnostics), if any.
+//
+// Available since v0.1.1
+func (c *RestClient) WithContext(ctx context.Context) *RestClient {
+	clone := *c
+	clone.ctx = ctx
+	return &clone
+}
+
+// withDeadline returns a copy of the client whose requests are aborted when the deadline is exceeded.
+func (c *RestClient) withDeadline(deadline time.Time) *RestClient {
+	clone := *c
+	clone.deadline = deadline
+	return &clone
 }
 
 func (c *RestClient) buildJsonRequest(method, url string, params interface{}) *http.Request {
 	var r *bytes.Reader
 	if params != nil {
-		js, _ := json.Marshal(params)
+		js, _ := c.jsonCodec().Marshal(params)
 		r = bytes.NewReader(js)
 	} else {
 		r = bytes.NewReader([]byte{})
 	}
-	req, _ := http.NewRequest(method, url, r)
+	ctx := c.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	req, _ := http.NewRequestWithContext(ctx, method, url, r)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("X-Ms-Version", c.apiVersion)
+	if c.disableCompression {
+		req.Header.Set("Accept-Encoding", "identity")
+	} else {
+		req.Header.Set("Accept-Encoding", "gzip, deflate")
+	}
 	return req
 }
 
+// _decompressBody decompresses the response body according to the Content-Encoding header.
+func _decompressBody(body []byte, contentEncoding string) ([]byte, error) {
+	var reader io.ReadCloser
+	var err error
+	switch strings.ToLower(strings.TrimSpace(contentEncoding)) {
+	case "gzip":
+		reader, err = gzip.NewReader(bytes.NewReader(body))
+	case "deflate":
+		// "deflate" is zlib-wrapped per RFC 7230, but some servers send raw deflate data
+		if reader, err = zlib.NewReader(bytes.NewReader(body)); err != nil {
+			reader, err = flate.NewReader(bytes.NewReader(body)), nil
+		}
+	default:
+		return body, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return ioutil.ReadAll(reader)
+}
+
 func (c *RestClient) addAuthHeader(req *http.Request, method, resType, resId string) *http.Request {
 	now := time.Now().In(locGmt)
+	if c.tokenProvider != nil {
+		// if token cannot be obtained, the request is sent without Authorization header and fails with StatusCode=401
+		if token, err := c.tokenProvider.getToken(); err == nil {
+			req.Header.Set("Authorization", url.QueryEscape("type=aad&ver=1.0&sig="+token))
+		}
+		req.Header.Set("X-Ms-Date", now.Format(time.RFC1123))
+		return req
+	}
+	key, keyIndex := c.keys.get()
+	req = req.WithContext(context.WithValue(req.Context(), ctxKeyAuthSignInfo, authSignInfo{method, resType, resId, keyIndex}))
 	stringToSign := strings.ToLower(fmt.Sprintf("%s\n%s\n%s\n%s\n%s\n", method, resType, resId, now.Format(time.RFC1123), ""))
-	h := hmac.New(sha256.New, c.authKey)
+	h := hmac.New(sha256.New, key)
 	h.Write([]byte(stringToSign))
 	signature := base64.StdEncoding.EncodeToString(h.Sum(nil))
 	authHeader := "type=master&ver=1.0&sig=" + signature
@@ -102,6 +372,113 @@ func (c *RestClient) addAuthHeader(req *http.Request, method, resType, resId str
 	return req
 }
 
+// do sends the request. If preferred regions are configured, the request is routed to regional endpoints (see
+// PreferredRegions in NewRestClient). If a dedicated gateway is configured, document reads and queries are sent to it
+// first (see DedicatedGatewayEndpoint in NewRestClient).
+func (c *RestClient) do(req *http.Request) *gjrc.GjrcResponse {
+	if !c.deadline.IsZero() {
+		ctx, cancel := context.WithDeadline(req.Context(), c.deadline)
+		defer cancel()
+		req = req.WithContext(ctx)
+	}
+	req = c.withInterceptors(req)
+	if c.sessions != nil {
+		c.sessions.apply(req)
+	}
+	dedicated := c.applyIntegratedCache(req)
+	_applyCustomHeaders(req)
+	reserved := 0.0
+	if c.ruLimiter != nil {
+		var waited time.Duration
+		var err error
+		if reserved, waited, err = c.ruLimiter.reserve(req.Context()); err != nil {
+			// the request's context is done while waiting for the RU budget: fail without sending the request
+			return _failedResponse(req, err)
+		}
+		if waited >= time.Millisecond {
+			c.log(LogLevelDebug, "request delayed to stay within RU budget",
+				map[string]interface{}{"path": req.URL.Path, "wait_ms": waited.Milliseconds()})
+		}
+	}
+	start := time.Now()
+	var resp *gjrc.GjrcResponse
+	if dedicated {
+		resp = c.doDedicated(req)
+	}
+	if resp == nil {
+		if endpoints := c.routeEndpoints(req); len(endpoints) > 0 {
+			resp = c.doRouted(req, endpoints)
+		} else {
+			resp = c.doWithKeys(req)
+		}
+	}
+	if c.sessions != nil {
+		c.sessions.update(req, resp)
+	}
+	if c.ruLimiter != nil {
+		charge := -1.0
+		if resp.Error() == nil {
+			if charge, _ = strconv.ParseFloat(resp.HttpResponse().Header.Get("X-Ms-Request-Charge"), 64); charge < 0 {
+				charge = 0
+			}
+		}
+		c.ruLimiter.settle(reserved, charge)
+	}
+	c.observe(req, resp, start)
+	return resp
+}
+
+// _failingTransport is a http.RoundTripper that fails all requests with err, without sending them.
+type _failingTransport struct {
+	err error
+}
+
+// RoundTrip implements http.RoundTripper.RoundTrip.
+func (t _failingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	return nil, t.err
+}
+
+// _failedResponse returns a response that failed with err, without sending the request.
+func _failedResponse(req *http.Request, err error) *gjrc.GjrcResponse {
+	return gjrc.NewGjrc(&http.Client{Transport: _failingTransport{err: err}}, 0).Do(req)
+}
+
+// doWithKeys sends the request. If the request was signed with an account key and is rejected with StatusCode=401, the
+// client falls back to the next configured account key (if any) and resends the request once.
+func (c *RestClient) doWithKeys(req *http.Request) *gjrc.GjrcResponse {
+	resp := c.send(req)
+	if resp.Error() != nil || resp.StatusCode() != http.StatusUnauthorized || c.tokenProvider != nil {
+		return resp
+	}
+	info, ok := req.Context().Value(ctxKeyAuthSignInfo).(authSignInfo)
+	if !ok || !c.keys.fallback(info.keyIndex) || req.GetBody == nil {
+		return resp
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return resp
+	}
+	c.log(LogLevelWarn, "request rejected with StatusCode=401, retrying with the next account key",
+		map[string]interface{}{"path": req.URL.Path})
+	retryReq := req.Clone(req.Context())
+	retryReq.Body = body
+	return c.send(c.addAuthHeader(retryReq, info.method, info.resType, info.resId))
+}
+
+// SetAccountKeys replaces the account keys used to sign requests at runtime, e.g. when keys are rotated.
+// If secondaryKey is not empty, it is used as fallback when a request signed with primaryKey is rejected with StatusCode=401.
+//
+// Available since v0.1.1
+func (c *RestClient) SetAccountKeys(primaryKey, secondaryKey string) error {
+	if c.keys == nil {
+		return errors.New("client is not authenticated with account key")
+	}
+	return c.keys.set(primaryKey, secondaryKey)
+}
+
 func (c *RestClient) buildRestReponse(resp *gjrc.GjrcResponse) RestReponse {
 	result := RestReponse{CallErr: resp.Error()}
 	if result.CallErr == nil {
@@ -114,19 +491,45 @@ func (c *RestClient) buildRestReponse(resp *gjrc.GjrcResponse) RestReponse {
 				result.RespHeader[strings.ToUpper(k)] = v[0]
 			}
 		}
+		if contentEncoding := result.RespHeader["CONTENT-ENCODING"]; contentEncoding != "" && len(result.RespBody) > 0 {
+			if result.RespBody, result.CallErr = _decompressBody(result.RespBody, contentEncoding); result.CallErr != nil {
+				result.CallErr = fmt.Errorf("cannot decompress response body (%s): %s", contentEncoding, result.CallErr)
+				return result
+			}
+		}
 		if v, err := strconv.ParseFloat(result.RespHeader["X-MS-REQUEST-CHARGE"], 64); err == nil {
 			result.RequestCharge = v
 		} else {
 			result.RequestCharge = -1
 		}
 		result.SessionToken = result.RespHeader["X-MS-SESSION-TOKEN"]
+		result.ActivityId = result.RespHeader["X-MS-ACTIVITY-ID"]
 		if result.StatusCode >= 400 {
-			result.ApiErr = fmt.Errorf("error executing Azure CosmosDB command; StatusCode=%d;Body=%s", result.StatusCode, result.RespBody)
+			result.ApiErr = newCosmosError(result.StatusCode, result.RespHeader, result.RespBody)
 		}
 	}
 	return result
 }
 
+// GetDatabaseAccount invokes CosmosDB API to get the database account's metadata, including readable and writable locations.
+//
+// See: https://docs.microsoft.com/en-us/rest/api/cosmos-db/cosmosdb-resource-uri-syntax-for-rest.
+//
+// Available since v0.1.1
+func (c *RestClient) GetDatabaseAccount() *RespGetDatabaseAccount {
+	method := "GET"
+	url := c.endpoint + "/"
+	req := c.buildJsonRequest(method, url, nil)
+	req = c.addAuthHeader(req, method, "", "")
+
+	resp := c.do(req)
+	result := &RespGetDatabaseAccount{RestReponse: c.buildRestReponse(resp)}
+	if result.CallErr == nil && result.ApiErr == nil {
+		result.CallErr = json.Unmarshal(result.RespBody, &(result.DatabaseAccountInfo))
+	}
+	return result
+}
+
 // DatabaseSpec specifies a CosmosDB database specifications for creation.
 type DatabaseSpec struct {
 	Id        string
@@ -150,9 +553,9 @@ func (c *RestClient) CreateDatabase(spec DatabaseSpec) *RespCreateDb {
 		req.Header.Set("X-Ms-Cosmos-Offer-Autopilot-Settings", fmt.Sprintf(`{"maxThroughput":%d}`, spec.MaxRu))
 	}
 
-	resp := c.client.Do(req)
+	resp := c.do(req)
 	result := &RespCreateDb{RestReponse: c.buildRestReponse(resp), DbInfo: DbInfo{Id: spec.Id}}
-	if result.CallErr == nil {
+	if result.CallErr == nil && result.ApiErr == nil {
 		result.CallErr = json.Unmarshal(result.RespBody, &(result.DbInfo))
 	}
 	return result
@@ -167,9 +570,9 @@ func (c *RestClient) GetDatabase(dbName string) *RespGetDb {
 	req := c.buildJsonRequest(method, url, nil)
 	req = c.addAuthHeader(req, method, "dbs", "dbs/"+dbName)
 
-	resp := c.client.Do(req)
+	resp := c.do(req)
 	result := &RespGetDb{RestReponse: c.buildRestReponse(resp)}
-	if result.CallErr == nil {
+	if result.CallErr == nil && result.ApiErr == nil {
 		result.CallErr = json.Unmarshal(result.RespBody, &(result.DbInfo))
 	}
 	return result
@@ -184,8 +587,9 @@ func (c *RestClient) DeleteDatabase(dbName string) *RespDeleteDb {
 	req := c.buildJsonRequest(method, url, nil)
 	req = c.addAuthHeader(req, method, "dbs", "dbs/"+dbName)
 
-	resp := c.client.Do(req)
+	resp := c.do(req)
 	result := &RespDeleteDb{RestReponse: c.buildRestReponse(resp)}
+	c.invalidatePkPaths(dbName, "")
 	return result
 }
 
@@ -198,9 +602,9 @@ func (c *RestClient) ListDatabases() *RespListDb {
 	req := c.buildJsonRequest(method, url, nil)
 	req = c.addAuthHeader(req, method, "dbs", "")
 
-	resp := c.client.Do(req)
+	resp := c.do(req)
 	result := &RespListDb{RestReponse: c.buildRestReponse(resp)}
-	if result.CallErr == nil {
+	if result.CallErr == nil && result.ApiErr == nil {
 		result.CallErr = json.Unmarshal(result.RespBody, &result)
 		if result.CallErr == nil {
 			sort.Slice(result.Databases, func(i, j int) bool {
@@ -222,6 +626,15 @@ type CollectionSpec struct {
 	PartitionKeyInfo map[string]interface{}
 	IndexingPolicy   map[string]interface{}
 	UniqueKeyPolicy  map[string]interface{}
+	GeospatialConfig map[string]interface{} // e.g. {"type":"Geography"} or {"type":"Geometry"}
+	// DefaultTtl specifies the collection's default time-to-live (in seconds) of documents.
+	// 0: not set (documents never expire), -1: documents do not expire by default, n>0: documents expire n seconds after their last modified time.
+	DefaultTtl int
+	// (since v0.1.1) ConflictResolutionPolicy specifies how conflicting writes of multi-region write accounts are resolved,
+	// e.g. {"mode":"LastWriterWins","conflictResolutionPath":"/_ts"} or {"mode":"Custom"}. With mode "Custom" and no
+	// resolution stored procedure, conflicts are recorded in the conflicts feed (see ListConflicts). It can only be set
+	// when the collection is created.
+	ConflictResolutionPolicy map[string]interface{}
 }
 
 // CreateCollection invokes CosmosDB API to create a new collection.
@@ -239,6 +652,15 @@ func (c *RestClient) CreateCollection(spec CollectionSpec) *RespCreateColl {
 	if spec.UniqueKeyPolicy != nil {
 		params["uniqueKeyPolicy"] = spec.UniqueKeyPolicy
 	}
+	if spec.GeospatialConfig != nil {
+		params["geospatialConfig"] = spec.GeospatialConfig
+	}
+	if spec.DefaultTtl != 0 {
+		params["defaultTtl"] = spec.DefaultTtl
+	}
+	if spec.ConflictResolutionPolicy != nil {
+		params["conflictResolutionPolicy"] = spec.ConflictResolutionPolicy
+	}
 	req := c.buildJsonRequest(method, url, params)
 	req = c.addAuthHeader(req, method, "colls", "dbs/"+spec.DbName)
 	if spec.Ru > 0 {
@@ -248,9 +670,9 @@ func (c *RestClient) CreateCollection(spec CollectionSpec) *RespCreateColl {
 		req.Header.Set("X-Ms-Cosmos-Offer-Autopilot-Settings", fmt.Sprintf(`{"maxThroughput":%d}`, spec.MaxRu))
 	}
 
-	resp := c.client.Do(req)
+	resp := c.do(req)
 	result := &RespCreateColl{RestReponse: c.buildRestReponse(resp), CollInfo: CollInfo{Id: spec.CollName}}
-	if result.CallErr == nil {
+	if result.CallErr == nil && result.ApiErr == nil {
 		result.CallErr = json.Unmarshal(result.RespBody, &(result.CollInfo))
 	}
 	return result
@@ -275,6 +697,12 @@ func (c *RestClient) ReplaceCollection(spec CollectionSpec) *RespReplaceColl {
 	// if spec.UniqueKeyPolicy != nil {
 	// 	params["uniqueKeyPolicy"] = spec.UniqueKeyPolicy
 	// }
+	if spec.GeospatialConfig != nil {
+		params["geospatialConfig"] = spec.GeospatialConfig
+	}
+	if spec.DefaultTtl != 0 {
+		params["defaultTtl"] = spec.DefaultTtl
+	}
 	req := c.buildJsonRequest(method, url, params)
 	req = c.addAuthHeader(req, method, "colls", "dbs/"+spec.DbName+"/colls/"+spec.CollName)
 	if spec.Ru > 0 {
@@ -284,9 +712,9 @@ func (c *RestClient) ReplaceCollection(spec CollectionSpec) *RespReplaceColl {
 		req.Header.Set("X-Ms-Cosmos-Offer-Autopilot-Settings", fmt.Sprintf(`{"maxThroughput":%d}`, spec.MaxRu))
 	}
 
-	resp := c.client.Do(req)
+	resp := c.do(req)
 	result := &RespReplaceColl{RestReponse: c.buildRestReponse(resp), CollInfo: CollInfo{Id: spec.CollName}}
-	if result.CallErr == nil {
+	if result.CallErr == nil && result.ApiErr == nil {
 		result.CallErr = json.Unmarshal(result.RespBody, &(result.CollInfo))
 	}
 	return result
@@ -301,9 +729,9 @@ func (c *RestClient) GetCollection(dbName, collName string) *RespGetColl {
 	req := c.buildJsonRequest(method, url, nil)
 	req = c.addAuthHeader(req, method, "colls", "dbs/"+dbName+"/colls/"+collName)
 
-	resp := c.client.Do(req)
+	resp := c.do(req)
 	result := &RespGetColl{RestReponse: c.buildRestReponse(resp)}
-	if result.CallErr == nil {
+	if result.CallErr == nil && result.ApiErr == nil {
 		result.CallErr = json.Unmarshal(result.RespBody, &(result.CollInfo))
 	}
 	return result
@@ -318,8 +746,9 @@ func (c *RestClient) DeleteCollection(dbName, collName string) *RespDeleteColl {
 	req := c.buildJsonRequest(method, url, nil)
 	req = c.addAuthHeader(req, method, "colls", "dbs/"+dbName+"/colls/"+collName)
 
-	resp := c.client.Do(req)
+	resp := c.do(req)
 	result := &RespDeleteColl{RestReponse: c.buildRestReponse(resp)}
+	c.invalidatePkPaths(dbName, collName)
 	return result
 }
 
@@ -332,9 +761,9 @@ func (c *RestClient) ListCollections(dbName string) *RespListColl {
 	req := c.buildJsonRequest(method, url, nil)
 	req = c.addAuthHeader(req, method, "colls", "dbs/"+dbName)
 
-	resp := c.client.Do(req)
+	resp := c.do(req)
 	result := &RespListColl{RestReponse: c.buildRestReponse(resp)}
-	if result.CallErr == nil {
+	if result.CallErr == nil && result.ApiErr == nil {
 		result.CallErr = json.Unmarshal(result.RespBody, &result)
 		if result.CallErr == nil {
 			sort.Slice(result.Collections, func(i, j int) bool {
@@ -346,6 +775,727 @@ func (c *RestClient) ListCollections(dbName string) *RespListColl {
 	return result
 }
 
+// QueryOffers invokes CosmosDB API to query existing offers.
+//
+// See: https://docs.microsoft.com/en-us/rest/api/cosmos-db/querying-offers.
+func (c *RestClient) QueryOffers(query string) *RespQueryOffers {
+	method := "POST"
+	url := c.endpoint + "/offers"
+	req := c.buildJsonRequest(method, url, map[string]interface{}{"query": query})
+	req = c.addAuthHeader(req, method, "offers", "")
+	req.Header.Set("Content-Type", "application/query+json")
+	req.Header.Set("X-Ms-Documentdb-Isquery", "true")
+
+	resp := c.do(req)
+	result := &RespQueryOffers{RestReponse: c.buildRestReponse(resp)}
+	if result.CallErr == nil && result.ApiErr == nil {
+		result.ContinuationToken = result.RespHeader["X-MS-CONTINUATION"]
+		result.CallErr = json.Unmarshal(result.RespBody, &result)
+	}
+	return result
+}
+
+// GetOfferForResource is convenient function to fetch the offer associated with a database or collection.
+//
+// rid is the "_rid" of the database/collection. If no offer is associated with the resource, StatusCode of the
+// returned response is 404.
+func (c *RestClient) GetOfferForResource(rid string) *RespGetOffer {
+	queryResult := c.QueryOffers(fmt.Sprintf(`SELECT * FROM root WHERE root.offerResourceId="%s"`, rid))
+	result := &RespGetOffer{RestReponse: queryResult.RestReponse}
+	if result.Error() == nil {
+		if len(queryResult.Offers) == 0 {
+			result.StatusCode = 404
+			result.ApiErr = &CosmosError{StatusCode: result.StatusCode, Code: "NotFound", ResourceType: "Offer",
+				Message: "no offer found for resource " + rid, Body: []byte("no offer found for resource " + rid)}
+		} else {
+			result.OfferInfo = queryResult.Offers[0]
+		}
+	}
+	return result
+}
+
+// GetOfferForDatabase is convenient function to fetch the offer (shared throughput) associated with a database.
+//
+// If the database does not exist or has no provisioned throughput, StatusCode of the returned response is 404.
+//
+// Available since v0.1.1
+func (c *RestClient) GetOfferForDatabase(dbName string) *RespGetOffer {
+	getResult := c.GetDatabase(dbName)
+	if getResult.Error() != nil {
+		return &RespGetOffer{RestReponse: getResult.RestReponse}
+	}
+	return c.GetOfferForResource(getResult.Rid)
+}
+
+// GetOfferForCollection is convenient function to fetch the offer (dedicated throughput) associated with a collection.
+//
+// If the collection does not exist or has no dedicated throughput (e.g. it shares the throughput of its database),
+// StatusCode of the returned response is 404.
+//
+// Available since v0.1.1
+func (c *RestClient) GetOfferForCollection(dbName, collName string) *RespGetOffer {
+	getResult := c.GetCollection(dbName, collName)
+	if getResult.Error() != nil {
+		return &RespGetOffer{RestReponse: getResult.RestReponse}
+	}
+	return c.GetOfferForResource(getResult.Rid)
+}
+
+// ReplaceOfferForResource invokes CosmosDB API to replace the offer (throughput) associated with a database or collection.
+//
+// rid is the "_rid" of the database/collection. If ru > 0, the offer is changed to manual throughput; if maxru > 0,
+// the offer is changed to autoscale throughput. If the offer is switched between manual and autoscale, it is migrated
+// first.
+//
+// See: https://docs.microsoft.com/en-us/rest/api/cosmos-db/replace-an-offer.
+//
+// Note: ru and maxru must not be supplied together!
+func (c *RestClient) ReplaceOfferForResource(rid string, ru, maxru int) *RespReplaceOffer {
+	getResult := c.GetOfferForResource(rid)
+	if getResult.Error() != nil {
+		return &RespReplaceOffer{RestReponse: getResult.RestReponse}
+	}
+	offerInfo := getResult.OfferInfo
+	if ru > 0 && offerInfo.IsAutopilot() {
+		result := c._replaceOffer(offerInfo, "X-Ms-Cosmos-Migrate-Offer-To-Manual-Throughput")
+		if result.Error() != nil {
+			return result
+		}
+		offerInfo = result.OfferInfo
+	}
+	if maxru > 0 && !offerInfo.IsAutopilot() {
+		result := c._replaceOffer(offerInfo, "X-Ms-Cosmos-Migrate-Offer-To-Autopilot")
+		if result.Error() != nil {
+			return result
+		}
+		offerInfo = result.OfferInfo
+	}
+	if maxru > 0 {
+		offerInfo.Content = map[string]interface{}{"offerAutopilotSettings": map[string]interface{}{"maxThroughput": maxru}}
+	} else {
+		offerInfo.Content = map[string]interface{}{"offerThroughput": ru}
+	}
+	return c._replaceOffer(offerInfo, "")
+}
+
+func (c *RestClient) _replaceOffer(offerInfo OfferInfo, migrateHeader string) *RespReplaceOffer {
+	method := "PUT"
+	url := c.endpoint + "/offers/" + offerInfo.Rid
+	req := c.buildJsonRequest(method, url, offerInfo)
+	req = c.addAuthHeader(req, method, "offers", strings.ToLower(offerInfo.Rid))
+	if migrateHeader != "" {
+		req.Header.Set(migrateHeader, "true")
+	}
+
+	resp := c.do(req)
+	result := &RespReplaceOffer{RestReponse: c.buildRestReponse(resp)}
+	if result.CallErr == nil && result.ApiErr == nil {
+		result.CallErr = json.Unmarshal(result.RespBody, &(result.OfferInfo))
+	}
+	return result
+}
+
+// StoredProcedureSpec specifies a CosmosDB stored procedure specifications for creation/replacement.
+type StoredProcedureSpec struct {
+	DbName, CollName, SprocId string
+	Body                      string // JavaScript body of the stored procedure
+}
+
+// CreateStoredProcedure invokes CosmosDB API to create a new stored procedure.
+//
+// See: https://docs.microsoft.com/en-us/rest/api/cosmos-db/create-a-stored-procedure.
+func (c *RestClient) CreateStoredProcedure(spec StoredProcedureSpec) *RespCreateSproc {
+	method := "POST"
+	url := c.endpoint + "/dbs/" + spec.DbName + "/colls/" + spec.CollName + "/sprocs"
+	req := c.buildJsonRequest(method, url, map[string]interface{}{"id": spec.SprocId, "body": spec.Body})
+	req = c.addAuthHeader(req, method, "sprocs", "dbs/"+spec.DbName+"/colls/"+spec.CollName)
+
+	resp := c.do(req)
+	result := &RespCreateSproc{RestReponse: c.buildRestReponse(resp)}
+	if result.CallErr == nil && result.ApiErr == nil {
+		result.CallErr = json.Unmarshal(result.RespBody, &(result.SprocInfo))
+	}
+	return result
+}
+
+// ReplaceStoredProcedure invokes CosmosDB API to replace an existing stored procedure.
+//
+// See: https://docs.microsoft.com/en-us/rest/api/cosmos-db/replace-a-stored-procedure.
+func (c *RestClient) ReplaceStoredProcedure(spec StoredProcedureSpec) *RespReplaceSproc {
+	method := "PUT"
+	url := c.endpoint + "/dbs/" + spec.DbName + "/colls/" + spec.CollName + "/sprocs/" + spec.SprocId
+	req := c.buildJsonRequest(method, url, map[string]interface{}{"id": spec.SprocId, "body": spec.Body})
+	req = c.addAuthHeader(req, method, "sprocs", "dbs/"+spec.DbName+"/colls/"+spec.CollName+"/sprocs/"+spec.SprocId)
+
+	resp := c.do(req)
+	result := &RespReplaceSproc{RestReponse: c.buildRestReponse(resp)}
+	if result.CallErr == nil && result.ApiErr == nil {
+		result.CallErr = json.Unmarshal(result.RespBody, &(result.SprocInfo))
+	}
+	return result
+}
+
+// DeleteStoredProcedure invokes CosmosDB API to delete an existing stored procedure.
+//
+// See: https://docs.microsoft.com/en-us/rest/api/cosmos-db/delete-a-stored-procedure.
+func (c *RestClient) DeleteStoredProcedure(dbName, collName, sprocId string) *RespDeleteSproc {
+	method := "DELETE"
+	url := c.endpoint + "/dbs/" + dbName + "/colls/" + collName + "/sprocs/" + sprocId
+	req := c.buildJsonRequest(method, url, nil)
+	req = c.addAuthHeader(req, method, "sprocs", "dbs/"+dbName+"/colls/"+collName+"/sprocs/"+sprocId)
+
+	resp := c.do(req)
+	result := &RespDeleteSproc{RestReponse: c.buildRestReponse(resp)}
+	return result
+}
+
+// ListStoredProcedures invokes CosmosDB API to list all stored procedures of a collection.
+//
+// See: https://docs.microsoft.com/en-us/rest/api/cosmos-db/list-stored-procedures.
+func (c *RestClient) ListStoredProcedures(dbName, collName string) *RespListSprocs {
+	method := "GET"
+	url := c.endpoint + "/dbs/" + dbName + "/colls/" + collName + "/sprocs"
+	req := c.buildJsonRequest(method, url, nil)
+	req = c.addAuthHeader(req, method, "sprocs", "dbs/"+dbName+"/colls/"+collName)
+
+	resp := c.do(req)
+	result := &RespListSprocs{RestReponse: c.buildRestReponse(resp)}
+	if result.CallErr == nil && result.ApiErr == nil {
+		result.CallErr = json.Unmarshal(result.RespBody, &result)
+		if result.CallErr == nil {
+			sort.Slice(result.StoredProcedures, func(i, j int) bool {
+				// sort stored procedures by id
+				return result.StoredProcedures[i].Id < result.StoredProcedures[j].Id
+			})
+		}
+	}
+	return result
+}
+
+// ExecuteStoredProcedureReq specifies a request to execute a stored procedure.
+type ExecuteStoredProcedureReq struct {
+	DbName, CollName, SprocId string
+	PartitionKeyValues        []interface{}
+	Params                    []interface{} // input parameters of the stored procedure
+}
+
+// ExecuteStoredProcedure invokes CosmosDB API to execute a stored procedure.
+//
+// See: https://docs.microsoft.com/en-us/rest/api/cosmos-db/execute-a-stored-procedure.
+func (c *RestClient) ExecuteStoredProcedure(r ExecuteStoredProcedureReq) *RespExecuteSproc {
+	method := "POST"
+	url := c.endpoint + "/dbs/" + r.DbName + "/colls/" + r.CollName + "/sprocs/" + r.SprocId
+	params := r.Params
+	if params == nil {
+		params = make([]interface{}, 0)
+	}
+	req := c.buildJsonRequest(method, url, params)
+	req = c.addAuthHeader(req, method, "sprocs", "dbs/"+r.DbName+"/colls/"+r.CollName+"/sprocs/"+r.SprocId)
+	jsPkValues, _ := json.Marshal(r.PartitionKeyValues)
+	req.Header.Set("X-Ms-Documentdb-PartitionKey", string(jsPkValues))
+
+	resp := c.do(req)
+	result := &RespExecuteSproc{RestReponse: c.buildRestReponse(resp)}
+	if result.CallErr == nil && result.StatusCode < 400 && len(result.RespBody) > 0 {
+		result.CallErr = c.jsonCodec().Unmarshal(result.RespBody, &(result.Result))
+	}
+	return result
+}
+
+// UserDefinedFunctionSpec specifies a CosmosDB user-defined function specifications for creation/replacement.
+type UserDefinedFunctionSpec struct {
+	DbName, CollName, UdfId string
+	Body                    string // JavaScript body of the user-defined function
+}
+
+// CreateUserDefinedFunction invokes CosmosDB API to create a new user-defined function.
+//
+// See: https://docs.microsoft.com/en-us/rest/api/cosmos-db/create-a-user-defined-function.
+func (c *RestClient) CreateUserDefinedFunction(spec UserDefinedFunctionSpec) *RespCreateUdf {
+	method := "POST"
+	url := c.endpoint + "/dbs/" + spec.DbName + "/colls/" + spec.CollName + "/udfs"
+	req := c.buildJsonRequest(method, url, map[string]interface{}{"id": spec.UdfId, "body": spec.Body})
+	req = c.addAuthHeader(req, method, "udfs", "dbs/"+spec.DbName+"/colls/"+spec.CollName)
+
+	resp := c.do(req)
+	result := &RespCreateUdf{RestReponse: c.buildRestReponse(resp)}
+	if result.CallErr == nil && result.ApiErr == nil {
+		result.CallErr = json.Unmarshal(result.RespBody, &(result.UdfInfo))
+	}
+	return result
+}
+
+// ReplaceUserDefinedFunction invokes CosmosDB API to replace an existing user-defined function.
+//
+// See: https://docs.microsoft.com/en-us/rest/api/cosmos-db/replace-a-user-defined-function.
+func (c *RestClient) ReplaceUserDefinedFunction(spec UserDefinedFunctionSpec) *RespReplaceUdf {
+	method := "PUT"
+	url := c.endpoint + "/dbs/" + spec.DbName + "/colls/" + spec.CollName + "/udfs/" + spec.UdfId
+	req := c.buildJsonRequest(method, url, map[string]interface{}{"id": spec.UdfId, "body": spec.Body})
+	req = c.addAuthHeader(req, method, "udfs", "dbs/"+spec.DbName+"/colls/"+spec.CollName+"/udfs/"+spec.UdfId)
+
+	resp := c.do(req)
+	result := &RespReplaceUdf{RestReponse: c.buildRestReponse(resp)}
+	if result.CallErr == nil && result.ApiErr == nil {
+		result.CallErr = json.Unmarshal(result.RespBody, &(result.UdfInfo))
+	}
+	return result
+}
+
+// DeleteUserDefinedFunction invokes CosmosDB API to delete an existing user-defined function.
+//
+// See: https://docs.microsoft.com/en-us/rest/api/cosmos-db/delete-a-user-defined-function.
+func (c *RestClient) DeleteUserDefinedFunction(dbName, collName, udfId string) *RespDeleteUdf {
+	method := "DELETE"
+	url := c.endpoint + "/dbs/" + dbName + "/colls/" + collName + "/udfs/" + udfId
+	req := c.buildJsonRequest(method, url, nil)
+	req = c.addAuthHeader(req, method, "udfs", "dbs/"+dbName+"/colls/"+collName+"/udfs/"+udfId)
+
+	resp := c.do(req)
+	result := &RespDeleteUdf{RestReponse: c.buildRestReponse(resp)}
+	return result
+}
+
+// ListUserDefinedFunctions invokes CosmosDB API to list all user-defined functions of a collection.
+//
+// See: https://docs.microsoft.com/en-us/rest/api/cosmos-db/list-user-defined-functions.
+func (c *RestClient) ListUserDefinedFunctions(dbName, collName string) *RespListUdfs {
+	method := "GET"
+	url := c.endpoint + "/dbs/" + dbName + "/colls/" + collName + "/udfs"
+	req := c.buildJsonRequest(method, url, nil)
+	req = c.addAuthHeader(req, method, "udfs", "dbs/"+dbName+"/colls/"+collName)
+
+	resp := c.do(req)
+	result := &RespListUdfs{RestReponse: c.buildRestReponse(resp)}
+	if result.CallErr == nil && result.ApiErr == nil {
+		result.CallErr = json.Unmarshal(result.RespBody, &result)
+		if result.CallErr == nil {
+			sort.Slice(result.UserDefinedFunctions, func(i, j int) bool {
+				// sort user-defined functions by id
+				return result.UserDefinedFunctions[i].Id < result.UserDefinedFunctions[j].Id
+			})
+		}
+	}
+	return result
+}
+
+// TriggerSpec specifies a CosmosDB trigger specifications for creation/replacement.
+type TriggerSpec struct {
+	DbName, CollName, TriggerId string
+	Body                        string // JavaScript body of the trigger
+	TriggerType                 string // accepted values: "Pre" or "Post"
+	TriggerOperation            string // accepted values: "All", "Create", "Replace" or "Delete"
+}
+
+func (spec TriggerSpec) toBody() map[string]interface{} {
+	return map[string]interface{}{"id": spec.TriggerId, "body": spec.Body, "triggerType": spec.TriggerType, "triggerOperation": spec.TriggerOperation}
+}
+
+// CreateTrigger invokes CosmosDB API to create a new trigger.
+//
+// See: https://docs.microsoft.com/en-us/rest/api/cosmos-db/create-a-trigger.
+func (c *RestClient) CreateTrigger(spec TriggerSpec) *RespCreateTrigger {
+	method := "POST"
+	url := c.endpoint + "/dbs/" + spec.DbName + "/colls/" + spec.CollName + "/triggers"
+	req := c.buildJsonRequest(method, url, spec.toBody())
+	req = c.addAuthHeader(req, method, "triggers", "dbs/"+spec.DbName+"/colls/"+spec.CollName)
+
+	resp := c.do(req)
+	result := &RespCreateTrigger{RestReponse: c.buildRestReponse(resp)}
+	if result.CallErr == nil && result.ApiErr == nil {
+		result.CallErr = json.Unmarshal(result.RespBody, &(result.TriggerInfo))
+	}
+	return result
+}
+
+// ReplaceTrigger invokes CosmosDB API to replace an existing trigger.
+//
+// See: https://docs.microsoft.com/en-us/rest/api/cosmos-db/replace-a-trigger.
+func (c *RestClient) ReplaceTrigger(spec TriggerSpec) *RespReplaceTrigger {
+	method := "PUT"
+	url := c.endpoint + "/dbs/" + spec.DbName + "/colls/" + spec.CollName + "/triggers/" + spec.TriggerId
+	req := c.buildJsonRequest(method, url, spec.toBody())
+	req = c.addAuthHeader(req, method, "triggers", "dbs/"+spec.DbName+"/colls/"+spec.CollName+"/triggers/"+spec.TriggerId)
+
+	resp := c.do(req)
+	result := &RespReplaceTrigger{RestReponse: c.buildRestReponse(resp)}
+	if result.CallErr == nil && result.ApiErr == nil {
+		result.CallErr = json.Unmarshal(result.RespBody, &(result.TriggerInfo))
+	}
+	return result
+}
+
+// DeleteTrigger invokes CosmosDB API to delete an existing trigger.
+//
+// See: https://docs.microsoft.com/en-us/rest/api/cosmos-db/delete-a-trigger.
+func (c *RestClient) DeleteTrigger(dbName, collName, triggerId string) *RespDeleteTrigger {
+	method := "DELETE"
+	url := c.endpoint + "/dbs/" + dbName + "/colls/" + collName + "/triggers/" + triggerId
+	req := c.buildJsonRequest(method, url, nil)
+	req = c.addAuthHeader(req, method, "triggers", "dbs/"+dbName+"/colls/"+collName+"/triggers/"+triggerId)
+
+	resp := c.do(req)
+	result := &RespDeleteTrigger{RestReponse: c.buildRestReponse(resp)}
+	return result
+}
+
+// ListTriggers invokes CosmosDB API to list all triggers of a collection.
+//
+// See: https://docs.microsoft.com/en-us/rest/api/cosmos-db/list-triggers.
+func (c *RestClient) ListTriggers(dbName, collName string) *RespListTriggers {
+	method := "GET"
+	url := c.endpoint + "/dbs/" + dbName + "/colls/" + collName + "/triggers"
+	req := c.buildJsonRequest(method, url, nil)
+	req = c.addAuthHeader(req, method, "triggers", "dbs/"+dbName+"/colls/"+collName)
+
+	resp := c.do(req)
+	result := &RespListTriggers{RestReponse: c.buildRestReponse(resp)}
+	if result.CallErr == nil && result.ApiErr == nil {
+		result.CallErr = json.Unmarshal(result.RespBody, &result)
+		if result.CallErr == nil {
+			sort.Slice(result.Triggers, func(i, j int) bool {
+				// sort triggers by id
+				return result.Triggers[i].Id < result.Triggers[j].Id
+			})
+		}
+	}
+	return result
+}
+
+// CreateUser invokes CosmosDB API to create a new user in a database.
+//
+// See: https://docs.microsoft.com/en-us/rest/api/cosmos-db/create-a-user.
+//
+// Available since v0.1.1
+func (c *RestClient) CreateUser(dbName, userId string) *RespCreateUser {
+	method := "POST"
+	url := c.endpoint + "/dbs/" + dbName + "/users"
+	req := c.buildJsonRequest(method, url, map[string]interface{}{"id": userId})
+	req = c.addAuthHeader(req, method, "users", "dbs/"+dbName)
+
+	resp := c.do(req)
+	result := &RespCreateUser{RestReponse: c.buildRestReponse(resp)}
+	if result.CallErr == nil && result.ApiErr == nil {
+		result.CallErr = json.Unmarshal(result.RespBody, &(result.UserInfo))
+	}
+	return result
+}
+
+// ReplaceUser invokes CosmosDB API to replace an existing user, i.e. to rename it to newUserId.
+//
+// See: https://docs.microsoft.com/en-us/rest/api/cosmos-db/replace-a-user.
+//
+// Available since v0.1.1
+func (c *RestClient) ReplaceUser(dbName, userId, newUserId string) *RespReplaceUser {
+	method := "PUT"
+	url := c.endpoint + "/dbs/" + dbName + "/users/" + userId
+	req := c.buildJsonRequest(method, url, map[string]interface{}{"id": newUserId})
+	req = c.addAuthHeader(req, method, "users", "dbs/"+dbName+"/users/"+userId)
+
+	resp := c.do(req)
+	result := &RespReplaceUser{RestReponse: c.buildRestReponse(resp)}
+	if result.CallErr == nil && result.ApiErr == nil {
+		result.CallErr = json.Unmarshal(result.RespBody, &(result.UserInfo))
+	}
+	return result
+}
+
+// GetUser invokes CosmosDB API to get an existing user.
+//
+// See: https://docs.microsoft.com/en-us/rest/api/cosmos-db/get-a-user.
+//
+// Available since v0.1.1
+func (c *RestClient) GetUser(dbName, userId string) *RespGetUser {
+	method := "GET"
+	url := c.endpoint + "/dbs/" + dbName + "/users/" + userId
+	req := c.buildJsonRequest(method, url, nil)
+	req = c.addAuthHeader(req, method, "users", "dbs/"+dbName+"/users/"+userId)
+
+	resp := c.do(req)
+	result := &RespGetUser{RestReponse: c.buildRestReponse(resp)}
+	if result.CallErr == nil && result.ApiErr == nil {
+		result.CallErr = json.Unmarshal(result.RespBody, &(result.UserInfo))
+	}
+	return result
+}
+
+// DeleteUser invokes CosmosDB API to delete an existing user, together with its permissions.
+//
+// See: https://docs.microsoft.com/en-us/rest/api/cosmos-db/delete-a-user.
+//
+// Available since v0.1.1
+func (c *RestClient) DeleteUser(dbName, userId string) *RespDeleteUser {
+	method := "DELETE"
+	url := c.endpoint + "/dbs/" + dbName + "/users/" + userId
+	req := c.buildJsonRequest(method, url, nil)
+	req = c.addAuthHeader(req, method, "users", "dbs/"+dbName+"/users/"+userId)
+
+	resp := c.do(req)
+	result := &RespDeleteUser{RestReponse: c.buildRestReponse(resp)}
+	return result
+}
+
+// ListUsers invokes CosmosDB API to list all users of a database.
+//
+// See: https://docs.microsoft.com/en-us/rest/api/cosmos-db/list-users.
+//
+// Available since v0.1.1
+func (c *RestClient) ListUsers(dbName string) *RespListUsers {
+	method := "GET"
+	url := c.endpoint + "/dbs/" + dbName + "/users"
+	req := c.buildJsonRequest(method, url, nil)
+	req = c.addAuthHeader(req, method, "users", "dbs/"+dbName)
+
+	resp := c.do(req)
+	result := &RespListUsers{RestReponse: c.buildRestReponse(resp)}
+	if result.CallErr == nil && result.ApiErr == nil {
+		result.CallErr = json.Unmarshal(result.RespBody, &result)
+		if result.CallErr == nil {
+			sort.Slice(result.Users, func(i, j int) bool {
+				// sort users by id
+				return result.Users[i].Id < result.Users[j].Id
+			})
+		}
+	}
+	return result
+}
+
+// PermissionSpec specifies a CosmosDB permission specifications for creation/replacement.
+//
+// Available since v0.1.1
+type PermissionSpec struct {
+	DbName, UserId, PermissionId string
+	PermissionMode               string        // accepted values: "All" or "Read"
+	Resource                     string        // link of the resource the permission applies to, e.g. "dbs/mydb/colls/mytable"
+	ResourcePartitionKey         []interface{} // (optional) scopes the permission to documents of a partition key value
+	TokenExpirySeconds           int           // (optional) validity of the resource token returned in the response, 1 hour by default, at most 5 hours
+}
+
+func (spec PermissionSpec) toBody() map[string]interface{} {
+	body := map[string]interface{}{"id": spec.PermissionId, "permissionMode": spec.PermissionMode, "resource": spec.Resource}
+	if len(spec.ResourcePartitionKey) > 0 {
+		body["resourcePartitionKey"] = spec.ResourcePartitionKey
+	}
+	return body
+}
+
+// addTokenExpiryHeader sets the validity of the resource token returned in the response of a permission operation.
+func addTokenExpiryHeader(req *http.Request, tokenExpirySeconds int) *http.Request {
+	if tokenExpirySeconds > 0 {
+		req.Header.Set("X-Ms-Documentdb-Expiry-Seconds", strconv.Itoa(tokenExpirySeconds))
+	}
+	return req
+}
+
+// CreatePermission invokes CosmosDB API to create a new permission for a user.
+//
+// The response carries a resource token (PermissionInfo.Token) that grants access to the resource of the permission,
+// to be handed out to end-user clients instead of the account key.
+//
+// See: https://docs.microsoft.com/en-us/rest/api/cosmos-db/create-a-permission.
+//
+// Available since v0.1.1
+func (c *RestClient) CreatePermission(spec PermissionSpec) *RespCreatePermission {
+	method := "POST"
+	url := c.endpoint + "/dbs/" + spec.DbName + "/users/" + spec.UserId + "/permissions"
+	req := c.buildJsonRequest(method, url, spec.toBody())
+	req = c.addAuthHeader(req, method, "permissions", "dbs/"+spec.DbName+"/users/"+spec.UserId)
+	req = addTokenExpiryHeader(req, spec.TokenExpirySeconds)
+
+	resp := c.do(req)
+	result := &RespCreatePermission{RestReponse: c.buildRestReponse(resp)}
+	if result.CallErr == nil && result.ApiErr == nil {
+		result.CallErr = json.Unmarshal(result.RespBody, &(result.PermissionInfo))
+	}
+	return result
+}
+
+// ReplacePermission invokes CosmosDB API to replace an existing permission of a user.
+//
+// See: https://docs.microsoft.com/en-us/rest/api/cosmos-db/replace-a-permission.
+//
+// Available since v0.1.1
+func (c *RestClient) ReplacePermission(spec PermissionSpec) *RespReplacePermission {
+	method := "PUT"
+	url := c.endpoint + "/dbs/" + spec.DbName + "/users/" + spec.UserId + "/permissions/" + spec.PermissionId
+	req := c.buildJsonRequest(method, url, spec.toBody())
+	req = c.addAuthHeader(req, method, "permissions", "dbs/"+spec.DbName+"/users/"+spec.UserId+"/permissions/"+spec.PermissionId)
+	req = addTokenExpiryHeader(req, spec.TokenExpirySeconds)
+
+	resp := c.do(req)
+	result := &RespReplacePermission{RestReponse: c.buildRestReponse(resp)}
+	if result.CallErr == nil && result.ApiErr == nil {
+		result.CallErr = json.Unmarshal(result.RespBody, &(result.PermissionInfo))
+	}
+	return result
+}
+
+// GetPermission invokes CosmosDB API to get an existing permission of a user.
+//
+// Each call mints a fresh resource token (PermissionInfo.Token), valid for tokenExpirySeconds (1 hour if 0, at most 5
+// hours).
+//
+// See: https://docs.microsoft.com/en-us/rest/api/cosmos-db/get-a-permission.
+//
+// Available since v0.1.1
+func (c *RestClient) GetPermission(dbName, userId, permissionId string, tokenExpirySeconds int) *RespGetPermission {
+	method := "GET"
+	url := c.endpoint + "/dbs/" + dbName + "/users/" + userId + "/permissions/" + permissionId
+	req := c.buildJsonRequest(method, url, nil)
+	req = c.addAuthHeader(req, method, "permissions", "dbs/"+dbName+"/users/"+userId+"/permissions/"+permissionId)
+	req = addTokenExpiryHeader(req, tokenExpirySeconds)
+
+	resp := c.do(req)
+	result := &RespGetPermission{RestReponse: c.buildRestReponse(resp)}
+	if result.CallErr == nil && result.ApiErr == nil {
+		result.CallErr = json.Unmarshal(result.RespBody, &(result.PermissionInfo))
+	}
+	return result
+}
+
+// DeletePermission invokes CosmosDB API to delete an existing permission of a user.
+//
+// See: https://docs.microsoft.com/en-us/rest/api/cosmos-db/delete-a-permission.
+//
+// Available since v0.1.1
+func (c *RestClient) DeletePermission(dbName, userId, permissionId string) *RespDeletePermission {
+	method := "DELETE"
+	url := c.endpoint + "/dbs/" + dbName + "/users/" + userId + "/permissions/" + permissionId
+	req := c.buildJsonRequest(method, url, nil)
+	req = c.addAuthHeader(req, method, "permissions", "dbs/"+dbName+"/users/"+userId+"/permissions/"+permissionId)
+
+	resp := c.do(req)
+	result := &RespDeletePermission{RestReponse: c.buildRestReponse(resp)}
+	return result
+}
+
+// ListPermissions invokes CosmosDB API to list all permissions of a user.
+//
+// See: https://docs.microsoft.com/en-us/rest/api/cosmos-db/list-permissions.
+//
+// Available since v0.1.1
+func (c *RestClient) ListPermissions(dbName, userId string) *RespListPermissions {
+	method := "GET"
+	url := c.endpoint + "/dbs/" + dbName + "/users/" + userId + "/permissions"
+	req := c.buildJsonRequest(method, url, nil)
+	req = c.addAuthHeader(req, method, "permissions", "dbs/"+dbName+"/users/"+userId)
+
+	resp := c.do(req)
+	result := &RespListPermissions{RestReponse: c.buildRestReponse(resp)}
+	if result.CallErr == nil && result.ApiErr == nil {
+		result.CallErr = json.Unmarshal(result.RespBody, &result)
+		if result.CallErr == nil {
+			sort.Slice(result.Permissions, func(i, j int) bool {
+				// sort permissions by id
+				return result.Permissions[i].Id < result.Permissions[j].Id
+			})
+		}
+	}
+	return result
+}
+
+// ListConflictsReq specifies a request to list conflicts of a collection.
+//
+// Available since v0.1.1
+type ListConflictsReq struct {
+	DbName, CollName  string
+	MaxItemCount      int    // (optional) maximum number of conflicts returned per page
+	ContinuationToken string // (optional) continuation token returned by the previous page
+}
+
+// ListConflicts invokes CosmosDB API to read a page of the conflicts feed of a collection.
+//
+// Conflicts are recorded on accounts with multi-region writes when concurrent writes to the same document can not be
+// resolved automatically, i.e. for collections with conflict resolution mode "Custom" without a resolution stored
+// procedure (or when the procedure fails). Applications resolve them by applying the winning version of the document,
+// then deleting the conflict with DeleteConflict. If the response's ContinuationToken is not empty, more conflicts are
+// available and can be read by supplying it in the next call.
+//
+// See: https://docs.microsoft.com/en-us/rest/api/cosmos-db/list-conflicts.
+//
+// Available since v0.1.1
+func (c *RestClient) ListConflicts(r ListConflictsReq) *RespListConflicts {
+	method := "GET"
+	url := c.endpoint + "/dbs/" + r.DbName + "/colls/" + r.CollName + "/conflicts"
+	req := c.buildJsonRequest(method, url, nil)
+	req = c.addAuthHeader(req, method, "conflicts", "dbs/"+r.DbName+"/colls/"+r.CollName)
+	if r.MaxItemCount > 0 {
+		req.Header.Set("X-Ms-Max-Item-Count", strconv.Itoa(r.MaxItemCount))
+	}
+	if r.ContinuationToken != "" {
+		req.Header.Set("X-Ms-Continuation", r.ContinuationToken)
+	}
+
+	resp := c.do(req)
+	result := &RespListConflicts{RestReponse: c.buildRestReponse(resp)}
+	if result.CallErr == nil && result.ApiErr == nil {
+		result.ContinuationToken = result.RespHeader["X-MS-CONTINUATION"]
+		result.CallErr = json.Unmarshal(result.RespBody, &result)
+		for i := 0; result.CallErr == nil && i < len(result.Conflicts); i++ {
+			result.CallErr = c.decodeConflictContent(&result.Conflicts[i])
+		}
+	}
+	return result
+}
+
+// ConflictReq specifies a request to get or delete a conflict.
+//
+// Available since v0.1.1
+type ConflictReq struct {
+	DbName, CollName, ConflictId string
+	PartitionKeyValues           []interface{} // partition key value of the conflicting document
+}
+
+// GetConflict invokes CosmosDB API to get an existing conflict.
+//
+// See: https://docs.microsoft.com/en-us/rest/api/cosmos-db/get-a-conflict.
+//
+// Available since v0.1.1
+func (c *RestClient) GetConflict(r ConflictReq) *RespGetConflict {
+	method := "GET"
+	url := c.endpoint + "/dbs/" + r.DbName + "/colls/" + r.CollName + "/conflicts/" + r.ConflictId
+	req := c.buildJsonRequest(method, url, nil)
+	req = c.addAuthHeader(req, method, "conflicts", "dbs/"+r.DbName+"/colls/"+r.CollName+"/conflicts/"+r.ConflictId)
+	jsPkValues, _ := json.Marshal(r.PartitionKeyValues)
+	req.Header.Set("X-Ms-Documentdb-PartitionKey", string(jsPkValues))
+
+	resp := c.do(req)
+	result := &RespGetConflict{RestReponse: c.buildRestReponse(resp)}
+	if result.CallErr == nil && result.ApiErr == nil {
+		result.CallErr = json.Unmarshal(result.RespBody, &(result.ConflictInfo))
+		if result.CallErr == nil {
+			result.CallErr = c.decodeConflictContent(&result.ConflictInfo)
+		}
+	}
+	return result
+}
+
+// DeleteConflict invokes CosmosDB API to delete an existing conflict, e.g. once it has been resolved.
+//
+// See: https://docs.microsoft.com/en-us/rest/api/cosmos-db/delete-a-conflict.
+//
+// Available since v0.1.1
+func (c *RestClient) DeleteConflict(r ConflictReq) *RespDeleteConflict {
+	method := "DELETE"
+	url := c.endpoint + "/dbs/" + r.DbName + "/colls/" + r.CollName + "/conflicts/" + r.ConflictId
+	req := c.buildJsonRequest(method, url, nil)
+	req = c.addAuthHeader(req, method, "conflicts", "dbs/"+r.DbName+"/colls/"+r.CollName+"/conflicts/"+r.ConflictId)
+	jsPkValues, _ := json.Marshal(r.PartitionKeyValues)
+	req.Header.Set("X-Ms-Documentdb-PartitionKey", string(jsPkValues))
+
+	resp := c.do(req)
+	result := &RespDeleteConflict{RestReponse: c.buildRestReponse(resp)}
+	return result
+}
+
+// decodeConflictContent decodes the conflicting version of the resource, which the server returns as a JSON string.
+func (c *RestClient) decodeConflictContent(conflict *ConflictInfo) error {
+	if conflict.Content == "" {
+		return nil
+	}
+	return c.jsonCodec().Unmarshal([]byte(conflict.Content), &conflict.Document)
+}
+
 // DocumentSpec specifies a CosmosDB document specifications for creation.
 type DocumentSpec struct {
 	DbName, CollName   string
@@ -353,15 +1503,32 @@ type DocumentSpec struct {
 	IndexingDirective  string // accepted value "", "Include" or "Exclude"
 	PartitionKeyValues []interface{}
 	DocumentData       map[string]interface{}
+	PreTriggers        []string // (available since v0.1.1) pre-triggers to be invoked before the operation
+	PostTriggers       []string // (available since v0.1.1) post-triggers to be invoked after the operation
+}
+
+// addTriggerHeaders adds pre/post-trigger headers to the request.
+func addTriggerHeaders(req *http.Request, preTriggers, postTriggers []string) *http.Request {
+	if len(preTriggers) > 0 {
+		req.Header.Set("X-Ms-Documentdb-Pre-Trigger-Include", strings.Join(preTriggers, ","))
+	}
+	if len(postTriggers) > 0 {
+		req.Header.Set("X-Ms-Documentdb-Post-Trigger-Include", strings.Join(postTriggers, ","))
+	}
+	return req
 }
 
 // CreateDocument invokes CosmosDB API to create a new document.
 //
 // See: https://docs.microsoft.com/en-us/rest/api/cosmos-db/create-a-document.
 func (c *RestClient) CreateDocument(spec DocumentSpec) *RespCreateDoc {
+	data, err := c.encryptor.encryptDocument(spec.DbName, spec.CollName, spec.DocumentData, c.jsonCodec())
+	if err != nil {
+		return &RespCreateDoc{RestReponse: RestReponse{CallErr: err}}
+	}
 	method := "POST"
 	url := c.endpoint + "/dbs/" + spec.DbName + "/colls/" + spec.CollName + "/docs"
-	req := c.buildJsonRequest(method, url, spec.DocumentData)
+	req := c.buildJsonRequest(method, url, data)
 	req = c.addAuthHeader(req, method, "docs", "dbs/"+spec.DbName+"/colls/"+spec.CollName)
 	if spec.IsUpsert {
 		req.Header.Set("X-Ms-Documentdb-Is-Upsert", "true")
@@ -369,13 +1536,17 @@ func (c *RestClient) CreateDocument(spec DocumentSpec) *RespCreateDoc {
 	if spec.IndexingDirective != "" {
 		req.Header.Set("x-ms-indexing-directive", spec.IndexingDirective)
 	}
+	req = addTriggerHeaders(req, spec.PreTriggers, spec.PostTriggers)
 	jsPkValues, _ := json.Marshal(spec.PartitionKeyValues)
 	req.Header.Set("X-Ms-Documentdb-PartitionKey", string(jsPkValues))
 
-	resp := c.client.Do(req)
+	resp := c.do(req)
 	result := &RespCreateDoc{RestReponse: c.buildRestReponse(resp)}
-	if result.CallErr == nil {
-		result.CallErr = json.Unmarshal(result.RespBody, &(result.DocInfo))
+	if result.CallErr == nil && result.ApiErr == nil {
+		result.CallErr = c.jsonCodec().Unmarshal(result.RespBody, &(result.DocInfo))
+		if result.CallErr == nil {
+			result.CallErr = c.encryptor.decryptDocuments(spec.DbName, spec.CollName, c.jsonCodec(), result.DocInfo)
+		}
 	}
 	return result
 }
@@ -385,20 +1556,71 @@ func (c *RestClient) CreateDocument(spec DocumentSpec) *RespCreateDoc {
 // See: https://docs.microsoft.com/en-us/rest/api/cosmos-db/replace-a-document.
 func (c *RestClient) ReplaceDocument(matchEtag string, spec DocumentSpec) *RespReplaceDoc {
 	id, _ := spec.DocumentData["id"].(string)
+	data, err := c.encryptor.encryptDocument(spec.DbName, spec.CollName, spec.DocumentData, c.jsonCodec())
+	if err != nil {
+		return &RespReplaceDoc{RestReponse: RestReponse{CallErr: err}}
+	}
 	method := "PUT"
 	url := c.endpoint + "/dbs/" + spec.DbName + "/colls/" + spec.CollName + "/docs/" + id
-	req := c.buildJsonRequest(method, url, spec.DocumentData)
+	req := c.buildJsonRequest(method, url, data)
 	req = c.addAuthHeader(req, method, "docs", "dbs/"+spec.DbName+"/colls/"+spec.CollName+"/docs/"+id)
 	if matchEtag != "" {
 		req.Header.Set("If-Match", matchEtag)
 	}
-	jsPkValues, _ := json.Marshal(spec.PartitionKeyValues)
+	req = addTriggerHeaders(req, spec.PreTriggers, spec.PostTriggers)
+	jsPkValues, _ := json.Marshal(spec.PartitionKeyValues)
+	req.Header.Set("X-Ms-Documentdb-PartitionKey", string(jsPkValues))
+
+	resp := c.do(req)
+	result := &RespReplaceDoc{RestReponse: c.buildRestReponse(resp)}
+	if result.CallErr == nil && result.ApiErr == nil {
+		result.CallErr = c.jsonCodec().Unmarshal(result.RespBody, &(result.DocInfo))
+		if result.CallErr == nil {
+			result.CallErr = c.encryptor.decryptDocuments(spec.DbName, spec.CollName, c.jsonCodec(), result.DocInfo)
+		}
+	}
+	return result
+}
+
+// maxPatchOperations is the maximum number of operations of a partial document update.
+const maxPatchOperations = 10
+
+// patchApiVersion is the minimum API version that supports partial document update.
+const patchApiVersion = "2020-07-15"
+
+// setDocumentFields invokes CosmosDB API to set top-level fields of an existing document (partial document update), without
+// reading it first. r.MatchEtag, r.PreTriggers and r.PostTriggers are honored. The values must not be encrypted, see
+// FieldEncryptor.
+//
+// See: https://learn.microsoft.com/en-us/rest/api/cosmos-db/patch-a-document.
+func (c *RestClient) setDocumentFields(r DocReq, fields []string, values []interface{}) *RespReplaceDoc {
+	operations := make([]map[string]interface{}, len(fields))
+	for i, field := range fields {
+		path := "/" + strings.ReplaceAll(strings.ReplaceAll(field, "~", "~0"), "/", "~1")
+		operations[i] = map[string]interface{}{"op": "set", "path": path, "value": values[i]}
+	}
+	method := "PATCH"
+	url := c.endpoint + "/dbs/" + r.DbName + "/colls/" + r.CollName + "/docs/" + r.DocId
+	req := c.buildJsonRequest(method, url, map[string]interface{}{"operations": operations})
+	req = c.addAuthHeader(req, method, "docs", "dbs/"+r.DbName+"/colls/"+r.CollName+"/docs/"+r.DocId)
+	req.Header.Set("Content-Type", "application/json_patch+json")
+	if c.apiVersion < patchApiVersion {
+		req.Header.Set("X-Ms-Version", patchApiVersion)
+	}
+	if r.MatchEtag != "" {
+		req.Header.Set("If-Match", r.MatchEtag)
+	}
+	req = addTriggerHeaders(req, r.PreTriggers, r.PostTriggers)
+	jsPkValues, _ := json.Marshal(r.PartitionKeyValues)
 	req.Header.Set("X-Ms-Documentdb-PartitionKey", string(jsPkValues))
 
-	resp := c.client.Do(req)
+	resp := c.do(req)
 	result := &RespReplaceDoc{RestReponse: c.buildRestReponse(resp)}
-	if result.CallErr == nil {
-		result.CallErr = json.Unmarshal(result.RespBody, &(result.DocInfo))
+	if result.CallErr == nil && result.ApiErr == nil {
+		result.CallErr = c.jsonCodec().Unmarshal(result.RespBody, &(result.DocInfo))
+		if result.CallErr == nil {
+			result.CallErr = c.encryptor.decryptDocuments(r.DbName, r.CollName, c.jsonCodec(), result.DocInfo)
+		}
 	}
 	return result
 }
@@ -407,10 +1629,12 @@ func (c *RestClient) ReplaceDocument(matchEtag string, spec DocumentSpec) *RespR
 type DocReq struct {
 	DbName, CollName, DocId string
 	PartitionKeyValues      []interface{}
-	MatchEtag               string // if not empty, add "If-Match" header to request
-	NotMatchEtag            string // if not empty, add "If-None-Match" header to request
-	ConsistencyLevel        string // accepted values: "", "Strong", "Bounded", "Session" or "Eventual"
-	SessionToken            string // string token used with session level consistency
+	MatchEtag               string   // if not empty, add "If-Match" header to request
+	NotMatchEtag            string   // if not empty, add "If-None-Match" header to request
+	ConsistencyLevel        string   // accepted values: "", "Strong", "Bounded", "Session" or "Eventual"
+	SessionToken            string   // string token used with session level consistency
+	PreTriggers             []string // (available since v0.1.1) pre-triggers to be invoked before the operation, used by DeleteDocument
+	PostTriggers            []string // (available since v0.1.1) post-triggers to be invoked after the operation, used by DeleteDocument
 }
 
 // GetDocument invokes CosmosDB API to get an existing document.
@@ -426,17 +1650,20 @@ func (c *RestClient) GetDocument(r DocReq) *RespGetDoc {
 	if r.NotMatchEtag != "" {
 		req.Header.Set("If-None-Match", r.NotMatchEtag)
 	}
-	if r.ConsistencyLevel != "" {
-		req.Header.Set("X-Ms-Consistency-Level", r.ConsistencyLevel)
+	if level := c.consistencyLevel(r.ConsistencyLevel); level != "" {
+		req.Header.Set("X-Ms-Consistency-Level", level)
 	}
 	if r.SessionToken != "" {
 		req.Header.Set("X-Ms-Session-Token", r.SessionToken)
 	}
 
-	resp := c.client.Do(req)
+	resp := c.do(req)
 	result := &RespGetDoc{RestReponse: c.buildRestReponse(resp)}
-	if result.CallErr == nil && result.StatusCode != 304 {
-		result.CallErr = json.Unmarshal(result.RespBody, &(result.DocInfo))
+	if result.CallErr == nil && result.ApiErr == nil && result.StatusCode != 304 {
+		result.CallErr = c.jsonCodec().Unmarshal(result.RespBody, &(result.DocInfo))
+		if result.CallErr == nil {
+			result.CallErr = c.encryptor.decryptDocuments(r.DbName, r.CollName, c.jsonCodec(), result.DocInfo)
+		}
 	}
 	return result
 }
@@ -454,12 +1681,42 @@ func (c *RestClient) DeleteDocument(r DocReq) *RespDeleteDoc {
 	if r.MatchEtag != "" {
 		req.Header.Set("If-Match", r.MatchEtag)
 	}
+	req = addTriggerHeaders(req, r.PreTriggers, r.PostTriggers)
 
-	resp := c.client.Do(req)
+	resp := c.do(req)
 	result := &RespDeleteDoc{RestReponse: c.buildRestReponse(resp)}
 	return result
 }
 
+// DeleteByPkReq specifies a request to delete all documents of a logical partition.
+//
+// Available since v0.1.1
+type DeleteByPkReq struct {
+	DbName, CollName   string
+	PartitionKeyValues []interface{} // partition key value of the documents to delete
+}
+
+// DeleteDocumentsByPartitionKey invokes CosmosDB API to delete all documents that share a partition key value, e.g. to
+// wipe the data of a tenant.
+//
+// The server accepts the request and deletes documents in background, consuming at most 10% of the collection's
+// throughput; documents that are not deleted yet may still be returned by reads and queries. The feature must be
+// enabled on the account (capability "DeleteAllItemsByPartitionKey").
+//
+// Available since v0.1.1
+func (c *RestClient) DeleteDocumentsByPartitionKey(r DeleteByPkReq) *RespDeleteDocsByPk {
+	method := "POST"
+	url := c.endpoint + "/dbs/" + r.DbName + "/colls/" + r.CollName + "/operations/partitionkeydelete"
+	req := c.buildJsonRequest(method, url, nil)
+	req = c.addAuthHeader(req, method, "partitionkey", "dbs/"+r.DbName+"/colls/"+r.CollName)
+	jsPkValues, _ := json.Marshal(r.PartitionKeyValues)
+	req.Header.Set("X-Ms-Documentdb-PartitionKey", string(jsPkValues))
+
+	resp := c.do(req)
+	result := &RespDeleteDocsByPk{RestReponse: c.buildRestReponse(resp)}
+	return result
+}
+
 // QueryReq specifies a query request to query for documents.
 type QueryReq struct {
 	DbName, CollName      string
@@ -470,12 +1727,29 @@ type QueryReq struct {
 	CrossPartitionEnabled bool
 	ConsistencyLevel      string // accepted values: "", "Strong", "Bounded", "Session" or "Eventual"
 	SessionToken          string // string token used with session level consistency
+	ContinuationLimitKb   int    // (since v0.1.1) size limit (in KB) of the returned continuation token, 0 to use the client's default
+	EnableScan            bool   // (since v0.1.1) allow the query to scan paths excluded from indexing
+	PartitionKeyRangeId   string // (since v0.1.1) if not empty, scope the query to the specified partition key range (see ListPartitionKeyRanges)
 }
 
 // QueryDocuments invokes CosmosDB API to query a collection for documents.
 //
 // See: https://docs.microsoft.com/en-us/rest/api/cosmos-db/query-documents.
 func (c *RestClient) QueryDocuments(query QueryReq) *RespQueryDocs {
+	resp := c.do(c.buildQueryRequest(query))
+	result := &RespQueryDocs{RestReponse: c.buildRestReponse(resp)}
+	if result.CallErr == nil && result.ApiErr == nil {
+		result.ContinuationToken = result.RespHeader["X-MS-CONTINUATION"]
+		result.CallErr = c.jsonCodec().Unmarshal(result.RespBody, &result)
+		if result.CallErr == nil {
+			result.CallErr = c.encryptor.decryptDocuments(query.DbName, query.CollName, c.jsonCodec(), result.Documents...)
+		}
+	}
+	return result
+}
+
+// buildQueryRequest builds the request of a query (see QueryDocuments).
+func (c *RestClient) buildQueryRequest(query QueryReq) *http.Request {
 	method := "POST"
 	url := c.endpoint + "/dbs/" + query.DbName + "/colls/" + query.CollName + "/docs"
 	req := c.buildJsonRequest(method, url, map[string]interface{}{"query": query.Query, "parameters": query.Params})
@@ -491,20 +1765,25 @@ func (c *RestClient) QueryDocuments(query QueryReq) *RespQueryDocs {
 	if query.CrossPartitionEnabled {
 		req.Header.Set("X-Ms-Documentdb-Query-EnableCrossPartition", "true")
 	}
-	if query.ConsistencyLevel != "" {
-		req.Header.Set("X-Ms-Consistency-Level", query.ConsistencyLevel)
+	if limitKb := query.ContinuationLimitKb; limitKb > 0 || c.continuationLimitKb > 0 {
+		if limitKb <= 0 {
+			limitKb = c.continuationLimitKb
+		}
+		req.Header.Set("X-Ms-Documentdb-Responsecontinuationtokenlimitinkb", strconv.Itoa(limitKb))
+	}
+	if query.EnableScan {
+		req.Header.Set("X-Ms-Documentdb-Query-Enable-Scan", "true")
+	}
+	if query.PartitionKeyRangeId != "" {
+		req.Header.Set("X-Ms-Documentdb-PartitionKeyRangeId", query.PartitionKeyRangeId)
+	}
+	if level := c.consistencyLevel(query.ConsistencyLevel); level != "" {
+		req.Header.Set("X-Ms-Consistency-Level", level)
 	}
 	if query.SessionToken != "" {
 		req.Header.Set("X-Ms-Session-Token", query.SessionToken)
 	}
-
-	resp := c.client.Do(req)
-	result := &RespQueryDocs{RestReponse: c.buildRestReponse(resp)}
-	if result.CallErr == nil {
-		result.ContinuationToken = result.RespHeader["X-MS-CONTINUATION"]
-		result.CallErr = json.Unmarshal(result.RespBody, &result)
-	}
-	return result
+	return req
 }
 
 // ListDocsReq specifies a list documents request.
@@ -532,8 +1811,8 @@ func (c *RestClient) ListDocuments(r ListDocsReq) *RespListDocs {
 	if r.ContinuationToken != "" {
 		req.Header.Set("X-Ms-Continuation", r.ContinuationToken)
 	}
-	if r.ConsistencyLevel != "" {
-		req.Header.Set("X-Ms-Consistency-Level", r.ConsistencyLevel)
+	if level := c.consistencyLevel(r.ConsistencyLevel); level != "" {
+		req.Header.Set("X-Ms-Consistency-Level", level)
 	}
 	if r.SessionToken != "" {
 		req.Header.Set("X-Ms-Session-Token", r.SessionToken)
@@ -545,23 +1824,126 @@ func (c *RestClient) ListDocuments(r ListDocsReq) *RespListDocs {
 		req.Header.Set("X-Ms-Documentdb-PartitionKeyRangeId", r.PartitionKeyRangeId)
 	}
 
-	resp := c.client.Do(req)
+	resp := c.do(req)
 	result := &RespListDocs{RestReponse: c.buildRestReponse(resp)}
-	if result.CallErr == nil {
+	if result.CallErr == nil && result.ApiErr == nil {
 		result.ContinuationToken = result.RespHeader["X-MS-CONTINUATION"]
 		result.Etag = result.RespHeader["ETAG"]
-		result.CallErr = json.Unmarshal(result.RespBody, &result)
+		result.CallErr = c.jsonCodec().Unmarshal(result.RespBody, &result)
+		if result.CallErr == nil {
+			result.CallErr = c.encryptor.decryptDocuments(r.DbName, r.CollName, c.jsonCodec(), result.Documents...)
+		}
+	}
+	return result
+}
+
+// ChangeFeedReq specifies a request to read the change feed of a collection.
+type ChangeFeedReq struct {
+	DbName, CollName    string
+	MaxItemCount        int
+	Continuation        string // "" to read changes from the beginning, "*" to read only new changes, or the continuation returned from a previous call
+	PartitionKeyRangeId string // if not empty, read changes of the specified partition key range
+	PartitionKeyValues  []interface{}
+	ConsistencyLevel    string // accepted values: "", "Strong", "Bounded", "Session" or "Eventual"
+	SessionToken        string // string token used with session level consistency
+}
+
+// ReadChangeFeed invokes CosmosDB API to read the incremental change feed of a collection.
+//
+// Documents are returned in the order of modification. Pass the returned Continuation to the next call to read
+// subsequent changes. StatusCode 304 (Not Modified) means there are no new changes since the supplied continuation.
+//
+// See: https://docs.microsoft.com/en-us/rest/api/cosmos-db/list-documents.
+//
+// Available since v0.1.1
+func (c *RestClient) ReadChangeFeed(r ChangeFeedReq) *RespChangeFeed {
+	method := "GET"
+	url := c.endpoint + "/dbs/" + r.DbName + "/colls/" + r.CollName + "/docs"
+	req := c.buildJsonRequest(method, url, nil)
+	req = c.addAuthHeader(req, method, "docs", "dbs/"+r.DbName+"/colls/"+r.CollName)
+	req.Header.Set("A-IM", "Incremental feed")
+	if r.MaxItemCount > 0 {
+		req.Header.Set("X-Ms-Max-Item-Count", strconv.Itoa(r.MaxItemCount))
+	}
+	if r.Continuation != "" {
+		req.Header.Set("If-None-Match", r.Continuation)
+	}
+	if r.PartitionKeyRangeId != "" {
+		req.Header.Set("X-Ms-Documentdb-PartitionKeyRangeId", r.PartitionKeyRangeId)
+	}
+	if len(r.PartitionKeyValues) > 0 {
+		jsPkValues, _ := json.Marshal(r.PartitionKeyValues)
+		req.Header.Set("X-Ms-Documentdb-PartitionKey", string(jsPkValues))
+	}
+	if level := c.consistencyLevel(r.ConsistencyLevel); level != "" {
+		req.Header.Set("X-Ms-Consistency-Level", level)
+	}
+	if r.SessionToken != "" {
+		req.Header.Set("X-Ms-Session-Token", r.SessionToken)
+	}
+
+	resp := c.do(req)
+	result := &RespChangeFeed{RestReponse: c.buildRestReponse(resp), Documents: make([]DocInfo, 0)}
+	if result.CallErr == nil && result.ApiErr == nil {
+		result.Continuation = result.RespHeader["ETAG"]
+		if result.Continuation == "" {
+			result.Continuation = r.Continuation
+		}
+		if result.StatusCode != 304 && result.StatusCode < 400 {
+			result.CallErr = c.jsonCodec().Unmarshal(result.RespBody, &result)
+			if result.CallErr == nil {
+				result.CallErr = c.encryptor.decryptDocuments(r.DbName, r.CollName, c.jsonCodec(), result.Documents...)
+			}
+		}
 	}
 	return result
 }
 
+// ListPartitionKeyRanges invokes CosmosDB API to list all partition key ranges (physical partitions) of a collection.
+// All pages are fetched, so the result contains every range of the collection.
+//
+// See: https://docs.microsoft.com/en-us/rest/api/cosmos-db/get-partition-key-ranges.
+//
+// Available since v0.1.1
+func (c *RestClient) ListPartitionKeyRanges(dbName, collName string) *RespListPkranges {
+	result := &RespListPkranges{PartitionKeyRanges: make([]PkrangeInfo, 0)}
+	continuation := ""
+	for {
+		method := "GET"
+		url := c.endpoint + "/dbs/" + dbName + "/colls/" + collName + "/pkranges"
+		req := c.buildJsonRequest(method, url, nil)
+		req = c.addAuthHeader(req, method, "pkranges", "dbs/"+dbName+"/colls/"+collName)
+		if continuation != "" {
+			req.Header.Set("X-Ms-Continuation", continuation)
+		}
+
+		resp := c.do(req)
+		requestCharge := result.RequestCharge
+		result.RestReponse = c.buildRestReponse(resp)
+		result.RequestCharge += requestCharge
+		if result.CallErr != nil || result.ApiErr != nil {
+			return result
+		}
+		var page RespListPkranges
+		if result.CallErr = json.Unmarshal(result.RespBody, &page); result.CallErr != nil {
+			return result
+		}
+		result.PartitionKeyRanges = append(result.PartitionKeyRanges, page.PartitionKeyRanges...)
+		result.Count = int64(len(result.PartitionKeyRanges))
+		if continuation = result.RespHeader["X-MS-CONTINUATION"]; continuation == "" {
+			return result
+		}
+	}
+}
+
 /*----------------------------------------------------------------------*/
 
 // RestReponse captures the response from REST API call.
 type RestReponse struct {
 	// CallErr holds any error occurred during the REST call.
 	CallErr error
-	// ApiErr holds any error occurred during the API call (only available when StatusCode >= 400).
+	// ApiErr holds any error occurred during the API call (only available when StatusCode >= 400). Since v0.1.1, it is
+	// a *CosmosError.
 	ApiErr error
 	// StatusCode captures the HTTP status code from the REST call.
 	StatusCode int
@@ -573,6 +1955,9 @@ type RestReponse struct {
 	RequestCharge float64
 	// SessionToken is used with session level consistency. Clients must save this value and set it for subsequent read requests for session consistency.
 	SessionToken string
+	// ActivityId is the activity id of the request returned by the server, to correlate the request with the server's
+	// logs (available since v0.1.1).
+	ActivityId string
 }
 
 // Error returns CallErr if not nil, ApiErr otherwise.
@@ -583,6 +1968,33 @@ func (r RestReponse) Error() error {
 	return r.ApiErr
 }
 
+// AccountLocation captures info of a region of a CosmosDB database account.
+//
+// Available since v0.1.1
+type AccountLocation struct {
+	Name     string `json:"name"`                    // name of the region, e.g. "West US"
+	Endpoint string `json:"databaseAccountEndpoint"` // regional endpoint, e.g. "https://myaccount-westus.documents.azure.com:443/"
+}
+
+// DatabaseAccountInfo captures info of a CosmosDB database account.
+//
+// Available since v0.1.1
+type DatabaseAccountInfo struct {
+	Id                           string            `json:"id"`                           // name of the database account
+	Rid                          string            `json:"_rid"`                         // (system generated property) _rid attribute of the database account
+	WritableLocations            []AccountLocation `json:"writableLocations"`            // regions that accept writes, the first one is the current write region
+	ReadableLocations            []AccountLocation `json:"readableLocations"`            // regions that accept reads
+	EnableMultipleWriteLocations bool              `json:"enableMultipleWriteLocations"` // true if the account accepts writes in multiple regions
+}
+
+// RespGetDatabaseAccount captures the response from GetDatabaseAccount call.
+//
+// Available since v0.1.1
+type RespGetDatabaseAccount struct {
+	RestReponse
+	DatabaseAccountInfo
+}
+
 // DbInfo captures info of a CosmosDB database.
 type DbInfo struct {
 	Id    string `json:"id"`     // user-generated unique name for the database
@@ -634,6 +2046,7 @@ type CollInfo struct {
 	PartitionKey             map[string]interface{} `json:"partitionKey"`             // partitioning configuration settings for collection
 	ConflictResolutionPolicy map[string]interface{} `json:"conflictResolutionPolicy"` // conflict resolution policy settings for collection
 	GeospatialConfig         map[string]interface{} `json:"geospatialConfig"`         // Geo-spatial configuration settings for collection
+	DefaultTtl               int                    `json:"defaultTtl"`               // default time-to-live (in seconds) of documents, 0 if not set
 }
 
 // RespCreateColl captures the response from CreateCollection call.
@@ -666,6 +2079,369 @@ type RespListColl struct {
 	Collections []CollInfo `json:"DocumentCollections"`
 }
 
+// PkrangeInfo captures info of a partition key range (physical partition) of a collection.
+//
+// See: https://docs.microsoft.com/en-us/rest/api/cosmos-db/get-partition-key-ranges.
+//
+// Available since v0.1.1
+type PkrangeInfo struct {
+	Id                 string   `json:"id"`                 // user-defined unique identifier of the partition key range
+	MinInclusive       string   `json:"minInclusive"`       // minimum effective partition key hash (inclusive) of the range
+	MaxExclusive       string   `json:"maxExclusive"`       // maximum effective partition key hash (exclusive) of the range
+	ThroughputFraction float64  `json:"throughputFraction"` // fraction of the collection's throughput allocated to the range
+	Status             string   `json:"status"`             // status of the range, e.g. "online"
+	Parents            []string `json:"parents"`            // ids of the ranges this range was split from
+	Rid                string   `json:"_rid"`               // (system-generated property) _rid attribute of the partition key range
+	Ts                 int64    `json:"_ts"`                // (system-generated property) _ts attribute of the partition key range
+	Self               string   `json:"_self"`              // (system-generated property) _self attribute of the partition key range
+	Etag               string   `json:"_etag"`              // (system-generated property) _etag attribute of the partition key range
+}
+
+// RespListPkranges captures the response from ListPartitionKeyRanges call.
+//
+// Available since v0.1.1
+type RespListPkranges struct {
+	RestReponse        `json:"-"`
+	Count              int64         `json:"_count"` // number of partition key ranges returned from the list operation
+	PartitionKeyRanges []PkrangeInfo `json:"PartitionKeyRanges"`
+}
+
+// OfferInfo captures info of a CosmosDB offer.
+//
+// See: https://docs.microsoft.com/en-us/rest/api/cosmos-db/offers.
+type OfferInfo struct {
+	OfferVersion    string                 `json:"offerVersion"`    // V2 is the current version for request unit-based throughput.
+	OfferType       string                 `json:"offerType"`       // performance level for V1 offer version, "Invalid" for V2 offer version
+	Content         map[string]interface{} `json:"content"`         // information about the offer, e.g. throughput settings
+	Resource        string                 `json:"resource"`        // self-link of the database/collection associated with the offer
+	OfferResourceId string                 `json:"offerResourceId"` // _rid of the database/collection associated with the offer
+	Id              string                 `json:"id"`              // (system-generated property) id of the offer, same value as _rid
+	Rid             string                 `json:"_rid"`            // (system-generated property) _rid attribute of the offer
+	Ts              int64                  `json:"_ts"`             // (system-generated property) _ts attribute of the offer
+	Self            string                 `json:"_self"`           // (system-generated property) _self attribute of the offer
+	Etag            string                 `json:"_etag"`           // (system-generated property) _etag attribute of the offer
+}
+
+// OfferThroughput returns value of field "content.offerThroughput" (manual throughput).
+func (o OfferInfo) OfferThroughput() int {
+	v, err := reddo.ToInt(o.Content["offerThroughput"])
+	if err == nil {
+		return int(v)
+	}
+	return 0
+}
+
+// MaxThroughputEverProvisioned returns value of field "content.offerMinimumThroughputParameters.maxThroughputEverProvisioned".
+func (o OfferInfo) MaxThroughputEverProvisioned() int {
+	params, ok := o.Content["offerMinimumThroughputParameters"].(map[string]interface{})
+	if !ok {
+		return 0
+	}
+	v, err := reddo.ToInt(params["maxThroughputEverProvisioned"])
+	if err == nil {
+		return int(v)
+	}
+	return 0
+}
+
+// IsAutopilot returns true if autoscale (autopilot) throughput is enabled, false otherwise.
+func (o OfferInfo) IsAutopilot() bool {
+	_, ok := o.Content["offerAutopilotSettings"]
+	return ok
+}
+
+// AutopilotMaxThroughput returns value of field "content.offerAutopilotSettings.maxThroughput" (autoscale throughput).
+func (o OfferInfo) AutopilotMaxThroughput() int {
+	settings, ok := o.Content["offerAutopilotSettings"].(map[string]interface{})
+	if !ok {
+		return 0
+	}
+	v, err := reddo.ToInt(settings["maxThroughput"])
+	if err == nil {
+		return int(v)
+	}
+	return 0
+}
+
+// RespGetOffer captures the response from GetOfferForResource call.
+type RespGetOffer struct {
+	RestReponse
+	OfferInfo
+}
+
+// RespReplaceOffer captures the response from ReplaceOfferForResource call.
+type RespReplaceOffer struct {
+	RestReponse
+	OfferInfo
+}
+
+// RespQueryOffers captures the response from QueryOffers call.
+type RespQueryOffers struct {
+	RestReponse       `json:"-"`
+	Count             int64       `json:"_count"` // number of records returned from the operation
+	Offers            []OfferInfo `json:"Offers"`
+	ContinuationToken string      `json:"-"`
+}
+
+// SprocInfo captures info of a CosmosDB stored procedure.
+type SprocInfo struct {
+	Id   string `json:"id"`    // user-generated unique name for the stored procedure
+	Body string `json:"body"`  // JavaScript body of the stored procedure
+	Rid  string `json:"_rid"`  // (system generated property) _rid attribute of the stored procedure
+	Ts   int64  `json:"_ts"`   // (system-generated property) _ts attribute of the stored procedure
+	Self string `json:"_self"` // (system-generated property) _self attribute of the stored procedure
+	Etag string `json:"_etag"` // (system-generated property) _etag attribute of the stored procedure
+}
+
+// RespCreateSproc captures the response from CreateStoredProcedure call.
+type RespCreateSproc struct {
+	RestReponse
+	SprocInfo
+}
+
+// RespReplaceSproc captures the response from ReplaceStoredProcedure call.
+type RespReplaceSproc struct {
+	RestReponse
+	SprocInfo
+}
+
+// RespDeleteSproc captures the response from DeleteStoredProcedure call.
+type RespDeleteSproc struct {
+	RestReponse
+}
+
+// RespListSprocs captures the response from ListStoredProcedures call.
+type RespListSprocs struct {
+	RestReponse      `json:"-"`
+	Count            int64       `json:"_count"` // number of stored procedures returned from the list operation
+	StoredProcedures []SprocInfo `json:"StoredProcedures"`
+}
+
+// RespExecuteSproc captures the response from ExecuteStoredProcedure call.
+type RespExecuteSproc struct {
+	RestReponse
+	Result interface{} // value returned by the stored procedure
+}
+
+// UdfInfo captures info of a CosmosDB user-defined function.
+type UdfInfo struct {
+	Id   string `json:"id"`    // user-generated unique name for the user-defined function
+	Body string `json:"body"`  // JavaScript body of the user-defined function
+	Rid  string `json:"_rid"`  // (system generated property) _rid attribute of the user-defined function
+	Ts   int64  `json:"_ts"`   // (system-generated property) _ts attribute of the user-defined function
+	Self string `json:"_self"` // (system-generated property) _self attribute of the user-defined function
+	Etag string `json:"_etag"` // (system-generated property) _etag attribute of the user-defined function
+}
+
+// RespCreateUdf captures the response from CreateUserDefinedFunction call.
+type RespCreateUdf struct {
+	RestReponse
+	UdfInfo
+}
+
+// RespReplaceUdf captures the response from ReplaceUserDefinedFunction call.
+type RespReplaceUdf struct {
+	RestReponse
+	UdfInfo
+}
+
+// RespDeleteUdf captures the response from DeleteUserDefinedFunction call.
+type RespDeleteUdf struct {
+	RestReponse
+}
+
+// RespListUdfs captures the response from ListUserDefinedFunctions call.
+type RespListUdfs struct {
+	RestReponse          `json:"-"`
+	Count                int64     `json:"_count"` // number of user-defined functions returned from the list operation
+	UserDefinedFunctions []UdfInfo `json:"UserDefinedFunctions"`
+}
+
+// TriggerInfo captures info of a CosmosDB trigger.
+type TriggerInfo struct {
+	Id               string `json:"id"`               // user-generated unique name for the trigger
+	Body             string `json:"body"`             // JavaScript body of the trigger
+	TriggerType      string `json:"triggerType"`      // type of the trigger, "Pre" or "Post"
+	TriggerOperation string `json:"triggerOperation"` // operation that the trigger is associated with
+	Rid              string `json:"_rid"`             // (system generated property) _rid attribute of the trigger
+	Ts               int64  `json:"_ts"`              // (system-generated property) _ts attribute of the trigger
+	Self             string `json:"_self"`            // (system-generated property) _self attribute of the trigger
+	Etag             string `json:"_etag"`            // (system-generated property) _etag attribute of the trigger
+}
+
+// RespCreateTrigger captures the response from CreateTrigger call.
+type RespCreateTrigger struct {
+	RestReponse
+	TriggerInfo
+}
+
+// RespReplaceTrigger captures the response from ReplaceTrigger call.
+type RespReplaceTrigger struct {
+	RestReponse
+	TriggerInfo
+}
+
+// RespDeleteTrigger captures the response from DeleteTrigger call.
+type RespDeleteTrigger struct {
+	RestReponse
+}
+
+// RespListTriggers captures the response from ListTriggers call.
+type RespListTriggers struct {
+	RestReponse `json:"-"`
+	Count       int64         `json:"_count"` // number of triggers returned from the list operation
+	Triggers    []TriggerInfo `json:"Triggers"`
+}
+
+// UserInfo captures info of a CosmosDB user.
+//
+// Available since v0.1.1
+type UserInfo struct {
+	Id          string `json:"id"`           // user-generated unique name for the user
+	Rid         string `json:"_rid"`         // (system generated property) _rid attribute of the user
+	Ts          int64  `json:"_ts"`          // (system-generated property) _ts attribute of the user
+	Self        string `json:"_self"`        // (system-generated property) _self attribute of the user
+	Etag        string `json:"_etag"`        // (system-generated property) _etag attribute of the user
+	Permissions string `json:"_permissions"` // (system-generated property) _permissions attribute of the user
+}
+
+// RespCreateUser captures the response from CreateUser call.
+//
+// Available since v0.1.1
+type RespCreateUser struct {
+	RestReponse
+	UserInfo
+}
+
+// RespReplaceUser captures the response from ReplaceUser call.
+//
+// Available since v0.1.1
+type RespReplaceUser struct {
+	RestReponse
+	UserInfo
+}
+
+// RespGetUser captures the response from GetUser call.
+//
+// Available since v0.1.1
+type RespGetUser struct {
+	RestReponse
+	UserInfo
+}
+
+// RespDeleteUser captures the response from DeleteUser call.
+//
+// Available since v0.1.1
+type RespDeleteUser struct {
+	RestReponse
+}
+
+// RespListUsers captures the response from ListUsers call.
+//
+// Available since v0.1.1
+type RespListUsers struct {
+	RestReponse `json:"-"`
+	Count       int64      `json:"_count"` // number of users returned from the list operation
+	Users       []UserInfo `json:"Users"`
+}
+
+// PermissionInfo captures info of a CosmosDB permission.
+//
+// Available since v0.1.1
+type PermissionInfo struct {
+	Id                   string        `json:"id"`                             // user-generated unique name for the permission
+	PermissionMode       string        `json:"permissionMode"`                 // access mode of the permission, "All" or "Read"
+	Resource             string        `json:"resource"`                       // link of the resource the permission applies to
+	ResourcePartitionKey []interface{} `json:"resourcePartitionKey,omitempty"` // partition key value the permission is scoped to, if any
+	Token                string        `json:"_token"`                         // (system-generated property) resource token granting access to the resource
+	Rid                  string        `json:"_rid"`                           // (system generated property) _rid attribute of the permission
+	Ts                   int64         `json:"_ts"`                            // (system-generated property) _ts attribute of the permission
+	Self                 string        `json:"_self"`                          // (system-generated property) _self attribute of the permission
+	Etag                 string        `json:"_etag"`                          // (system-generated property) _etag attribute of the permission
+}
+
+// RespCreatePermission captures the response from CreatePermission call.
+//
+// Available since v0.1.1
+type RespCreatePermission struct {
+	RestReponse
+	PermissionInfo
+}
+
+// RespReplacePermission captures the response from ReplacePermission call.
+//
+// Available since v0.1.1
+type RespReplacePermission struct {
+	RestReponse
+	PermissionInfo
+}
+
+// RespGetPermission captures the response from GetPermission call.
+//
+// Available since v0.1.1
+type RespGetPermission struct {
+	RestReponse
+	PermissionInfo
+}
+
+// RespDeletePermission captures the response from DeletePermission call.
+//
+// Available since v0.1.1
+type RespDeletePermission struct {
+	RestReponse
+}
+
+// RespListPermissions captures the response from ListPermissions call.
+//
+// Available since v0.1.1
+type RespListPermissions struct {
+	RestReponse `json:"-"`
+	Count       int64            `json:"_count"` // number of permissions returned from the list operation
+	Permissions []PermissionInfo `json:"Permissions"`
+}
+
+// ConflictInfo captures info of a CosmosDB conflict.
+//
+// Available since v0.1.1
+type ConflictInfo struct {
+	Id            string  `json:"id"`            // id of the conflict
+	ResourceId    string  `json:"resourceId"`    // _rid of the conflicting resource
+	ResourceType  string  `json:"resourceType"`  // type of the conflicting resource, e.g. "document"
+	OperationType string  `json:"operationType"` // operation that caused the conflict: "create", "replace" or "delete"
+	Content       string  `json:"content"`       // conflicting version of the resource, as a JSON string
+	Document      DocInfo `json:"-"`             // conflicting version of the resource, decoded from Content
+	ConflictLsn   int64   `json:"conflict_lsn"`  // logical sequence number of the conflicting write
+	Rid           string  `json:"_rid"`          // (system generated property) _rid attribute of the conflict
+	Ts            int64   `json:"_ts"`           // (system-generated property) _ts attribute of the conflict
+	Self          string  `json:"_self"`         // (system-generated property) _self attribute of the conflict
+	Etag          string  `json:"_etag"`         // (system-generated property) _etag attribute of the conflict
+}
+
+// RespListConflicts captures the response from ListConflicts call.
+//
+// Available since v0.1.1
+type RespListConflicts struct {
+	RestReponse       `json:"-"`
+	Count             int64          `json:"_count"` // number of conflicts returned from the list operation
+	Conflicts         []ConflictInfo `json:"Conflicts"`
+	ContinuationToken string         `json:"-"`
+}
+
+// RespGetConflict captures the response from GetConflict call.
+//
+// Available since v0.1.1
+type RespGetConflict struct {
+	RestReponse
+	ConflictInfo
+}
+
+// RespDeleteConflict captures the response from DeleteConflict call.
+//
+// Available since v0.1.1
+type RespDeleteConflict struct {
+	RestReponse
+}
+
 // DocInfo captures info of a CosmosDB document.
 type DocInfo map[string]interface{}
 
@@ -779,6 +2555,13 @@ type RespDeleteDoc struct {
 	RestReponse
 }
 
+// RespDeleteDocsByPk captures the response from DeleteDocumentsByPartitionKey call.
+//
+// Available since v0.1.1
+type RespDeleteDocsByPk struct {
+	RestReponse
+}
+
 // RespQueryDocs captures the response from QueryDocuments call.
 type RespQueryDocs struct {
 	RestReponse       `json:"-"`
@@ -787,6 +2570,14 @@ type RespQueryDocs struct {
 	ContinuationToken string    `json:"-"`
 }
 
+// RespChangeFeed captures the response from ReadChangeFeed call.
+type RespChangeFeed struct {
+	RestReponse  `json:"-"`
+	Count        int64     `json:"_count"` // number of documents returned from the operation
+	Documents    []DocInfo `json:"Documents"`
+	Continuation string    `json:"-"` // continuation to read subsequent changes
+}
+
 // RespListDocs captures the response from ListDocuments call.
 type RespListDocs struct {
 	RestReponse       `json:"-"`
@@ -1,9 +1,18 @@
 package gocosmos
 
 import (
+	"compress/gzip"
+	"compress/zlib"
+	"database/sql"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"math/rand"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"reflect"
 	"strconv"
 	"strings"
 	"testing"
@@ -35,6 +44,199 @@ func TestNewRestClient(t *testing.T) {
 	}
 }
 
+func TestNewRestClient_PortalConnStr(t *testing.T) {
+	name := "TestNewRestClient_PortalConnStr"
+	accountKey := "C2y6yDjf5/R+ob0N8A7Cgv30VRDJIWEHLM+4QDU5DE2nQ9nDuVTqobD4b8mGGyPMbIZnqyMsEcaGQy67XIw/Jw=="
+	for _, connStr := range []string{
+		"AccountEndpoint=https://myaccount.documents.azure.com:443/;AccountKey=" + accountKey + ";",
+		" \"AccountEndpoint=https://myaccount.documents.azure.com:443/;AccountKey=" + accountKey + ";\"\n",
+		"accountendpoint = https://myaccount.documents.azure.com:443/;\n  accountkey = " + accountKey + " ;;",
+	} {
+		client, err := NewRestClient(nil, connStr)
+		if err != nil {
+			t.Fatalf("%s failed: <%s> %s", name, connStr, err)
+		}
+		if key, _ := client.keys.get(); client.endpoint != "https://myaccount.documents.azure.com:443" || base64.StdEncoding.EncodeToString(key) != accountKey {
+			t.Fatalf("%s failed: <%s> unexpected endpoint %s", name, connStr, client.endpoint)
+		}
+		if cfg, err := ParseDSN(connStr); err != nil || cfg.AccountKey != accountKey {
+			t.Fatalf("%s failed: <%s> unexpected config %#v / %s", name, connStr, cfg, err)
+		}
+	}
+}
+
+func TestNewRestClient_InsecureSkipVerify(t *testing.T) {
+	name := "TestNewRestClient_InsecureSkipVerify"
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"myaccount"}`))
+	}))
+	defer server.Close()
+	accountKey := "C2y6yDjf5/R+ob0N8A7Cgv30VRDJIWEHLM+4QDU5DE2nQ9nDuVTqobD4b8mGGyPMbIZnqyMsEcaGQy67XIw/Jw=="
+
+	if _, err := NewRestClient(nil, "AccountEndpoint="+server.URL+";AccountKey="+accountKey+";InsecureSkipVerify=abc"); err == nil {
+		t.Fatalf("%s failed: expected error for invalid InsecureSkipVerify", name)
+	}
+	client, _ := NewRestClient(nil, "AccountEndpoint="+server.URL+";AccountKey="+accountKey)
+	if result := client.GetDatabaseAccount(); result.CallErr == nil {
+		t.Fatalf("%s failed: self-signed certificate must be rejected by default", name)
+	}
+	client, _ = NewRestClient(nil, "AccountEndpoint="+server.URL+";AccountKey="+accountKey+";InsecureSkipVerify=true")
+	if result := client.GetDatabaseAccount(); result.Error() != nil || result.Id != "myaccount" {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	}
+}
+
+func TestRestClient_Compression(t *testing.T) {
+	name := "TestRestClient_Compression"
+	acceptEncoding, contentEncoding := "", ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		acceptEncoding = r.Header.Get("Accept-Encoding")
+		body := []byte(`{"id":"myaccount"}`)
+		switch contentEncoding {
+		case "gzip":
+			w.Header().Set("Content-Encoding", "gzip")
+			gw := gzip.NewWriter(w)
+			gw.Write(body)
+			gw.Close()
+		case "deflate":
+			w.Header().Set("Content-Encoding", "deflate")
+			zw := zlib.NewWriter(w)
+			zw.Write(body)
+			zw.Close()
+		default:
+			w.Write(body)
+		}
+	}))
+	defer server.Close()
+	accountKey := "C2y6yDjf5/R+ob0N8A7Cgv30VRDJIWEHLM+4QDU5DE2nQ9nDuVTqobD4b8mGGyPMbIZnqyMsEcaGQy67XIw/Jw=="
+
+	client, _ := NewRestClient(nil, "AccountEndpoint="+server.URL+";AccountKey="+accountKey)
+	for _, contentEncoding = range []string{"gzip", "deflate", ""} {
+		if result := client.GetDatabaseAccount(); result.Error() != nil || result.Id != "myaccount" {
+			t.Fatalf("%s failed: <%s> %s", name, contentEncoding, result.Error())
+		}
+		if !strings.Contains(acceptEncoding, "gzip") || !strings.Contains(acceptEncoding, "deflate") {
+			t.Fatalf("%s failed: unexpected Accept-Encoding %#v", name, acceptEncoding)
+		}
+	}
+
+	if _, err := NewRestClient(nil, "AccountEndpoint="+server.URL+";AccountKey="+accountKey+";DisableCompression=abc"); err == nil {
+		t.Fatalf("%s failed: expected error for invalid DisableCompression", name)
+	}
+	client, _ = NewRestClient(nil, "AccountEndpoint="+server.URL+";AccountKey="+accountKey+";DisableCompression=true")
+	if result := client.GetDatabaseAccount(); result.Error() != nil || acceptEncoding != "identity" {
+		t.Fatalf("%s failed: expected uncompressed response (%#v): %s", name, acceptEncoding, result.Error())
+	}
+}
+
+func TestRestClient_DefaultConsistency(t *testing.T) {
+	name := "TestRestClient_DefaultConsistency"
+	consistencyLevel := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		consistencyLevel = r.Header.Get("X-Ms-Consistency-Level")
+		w.Write([]byte(`{"Documents":[{"id":"1"}],"_count":1}`))
+	}))
+	defer server.Close()
+	connStr := "AccountEndpoint=" + server.URL + ";AccountKey=C2y6yDjf5/R+ob0N8A7Cgv30VRDJIWEHLM+4QDU5DE2nQ9nDuVTqobD4b8mGGyPMbIZnqyMsEcaGQy67XIw/Jw=="
+
+	if _, err := NewRestClient(nil, connStr+";DefaultConsistency=weak"); err == nil {
+		t.Fatalf("%s failed: expected error for invalid DefaultConsistency", name)
+	}
+	client, _ := NewRestClient(nil, connStr+";DefaultConsistency=eventual")
+	if result := client.QueryDocuments(QueryReq{DbName: "db", CollName: "coll", Query: "SELECT * FROM c"}); result.Error() != nil || consistencyLevel != "Eventual" {
+		t.Fatalf("%s failed: expected consistency level %#v but received %#v (%s)", name, "Eventual", consistencyLevel, result.Error())
+	}
+	if result := client.QueryDocuments(QueryReq{DbName: "db", CollName: "coll", Query: "SELECT * FROM c", ConsistencyLevel: "Session"}); result.Error() != nil || consistencyLevel != "Session" {
+		t.Fatalf("%s failed: expected consistency level %#v but received %#v (%s)", name, "Session", consistencyLevel, result.Error())
+	}
+
+	db, _ := sql.Open("gocosmos", connStr+";DefaultConsistency=session")
+	defer db.Close()
+	for query, expected := range map[string]string{
+		"SELECT * FROM c WITH db=db":                         "Session",
+		"SELECT * FROM c WITH db=db WITH consistency=strong": "Strong",
+	} {
+		if rows, err := db.Query(query); err != nil || consistencyLevel != expected {
+			t.Fatalf("%s failed: <%s> expected consistency level %#v but received %#v (%s)", name, query, expected, consistencyLevel, err)
+		} else {
+			rows.Close()
+		}
+	}
+}
+
+func TestRestClient_ContinuationLimitKb(t *testing.T) {
+	name := "TestRestClient_ContinuationLimitKb"
+	limitKb := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limitKb = r.Header.Get("X-Ms-Documentdb-Responsecontinuationtokenlimitinkb")
+		w.Write([]byte(`{"Documents":[{"id":"1"}],"_count":1}`))
+	}))
+	defer server.Close()
+	connStr := "AccountEndpoint=" + server.URL + ";AccountKey=C2y6yDjf5/R+ob0N8A7Cgv30VRDJIWEHLM+4QDU5DE2nQ9nDuVTqobD4b8mGGyPMbIZnqyMsEcaGQy67XIw/Jw=="
+
+	if _, err := NewRestClient(nil, connStr+";ContinuationLimitKb=0"); err == nil {
+		t.Fatalf("%s failed: expected error for invalid ContinuationLimitKb", name)
+	}
+	client, _ := NewRestClient(nil, connStr)
+	if result := client.QueryDocuments(QueryReq{DbName: "db", CollName: "coll", Query: "SELECT * FROM c"}); result.Error() != nil || limitKb != "" {
+		t.Fatalf("%s failed: expected no limit but received %#v (%s)", name, limitKb, result.Error())
+	}
+	client, _ = NewRestClient(nil, connStr+";ContinuationLimitKb=2")
+	if result := client.QueryDocuments(QueryReq{DbName: "db", CollName: "coll", Query: "SELECT * FROM c"}); result.Error() != nil || limitKb != "2" {
+		t.Fatalf("%s failed: expected limit %#v but received %#v (%s)", name, "2", limitKb, result.Error())
+	}
+
+	db, _ := sql.Open("gocosmos", connStr+";ContinuationLimitKb=2")
+	defer db.Close()
+	for query, expected := range map[string]string{
+		"SELECT * FROM c WITH db=db":                              "2",
+		"SELECT * FROM c WITH db=db WITH continuation_limit_kb=1": "1",
+	} {
+		if rows, err := db.Query(query); err != nil || limitKb != expected {
+			t.Fatalf("%s failed: <%s> expected limit %#v but received %#v (%s)", name, query, expected, limitKb, err)
+		} else {
+			rows.Close()
+		}
+	}
+	if _, err := db.Query("SELECT * FROM c WITH db=db WITH continuation_limit_kb=x"); err == nil {
+		t.Fatalf("%s failed: expected error for invalid continuation_limit_kb", name)
+	}
+}
+
+func TestRestClient_ListPartitionKeyRanges(t *testing.T) {
+	name := "TestRestClient_ListPartitionKeyRanges"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/dbs/db/colls/coll/pkranges" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("X-Ms-Request-Charge", "1.5")
+		if r.Header.Get("X-Ms-Continuation") == "" {
+			w.Header().Set("X-Ms-Continuation", "page2")
+			w.Write([]byte(`{"_count":1,"PartitionKeyRanges":[{"id":"1","minInclusive":"","maxExclusive":"7F","throughputFraction":0.5,"status":"online","parents":["0"]}]}`))
+			return
+		}
+		w.Write([]byte(`{"_count":1,"PartitionKeyRanges":[{"id":"2","minInclusive":"7F","maxExclusive":"FF","throughputFraction":0.5,"status":"online","parents":["0"]}]}`))
+	}))
+	defer server.Close()
+	client, _ := NewRestClient(nil, "AccountEndpoint="+server.URL+";AccountKey=cHJpbWFyeQ==")
+
+	result := client.ListPartitionKeyRanges("db", "coll")
+	if err := result.Error(); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	expected := []PkrangeInfo{
+		{Id: "1", MinInclusive: "", MaxExclusive: "7F", ThroughputFraction: 0.5, Status: "online", Parents: []string{"0"}},
+		{Id: "2", MinInclusive: "7F", MaxExclusive: "FF", ThroughputFraction: 0.5, Status: "online", Parents: []string{"0"}},
+	}
+	if result.Count != 2 || !reflect.DeepEqual(result.PartitionKeyRanges, expected) || result.RequestCharge != 3 {
+		t.Fatalf("%s failed: unexpected result %#v", name, result)
+	}
+	if result := client.ListPartitionKeyRanges("db", "notfound"); !errors.Is(result.Error(), ErrNotFound) {
+		t.Fatalf("%s failed: expected ErrNotFound but received %#v", name, result.Error())
+	}
+}
+
 /*----------------------------------------------------------------------*/
 
 func _newRestClient(t *testing.T, testName string) *RestClient {
@@ -358,6 +560,330 @@ func TestRestClient_ListCollection(t *testing.T) {
 
 /*----------------------------------------------------------------------*/
 
+func TestRestClient_ReplaceOfferForResource(t *testing.T) {
+	name := "TestRestClient_ReplaceOfferForResource"
+	client := _newRestClient(t, name)
+
+	dbname := "mydb"
+	collname := "mytable"
+	client.DeleteDatabase(dbname)
+	client.CreateDatabase(DatabaseSpec{Id: dbname})
+	collInfo := client.CreateCollection(CollectionSpec{DbName: dbname, CollName: collname, Ru: 400,
+		PartitionKeyInfo: map[string]interface{}{"paths": []string{"/id"}, "kind": "Hash"}})
+	if collInfo.Error() != nil {
+		t.Fatalf("%s failed: %s", name, collInfo.Error())
+	}
+
+	if result := client.GetOfferForResource(collInfo.Rid); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	} else if result.OfferResourceId != collInfo.Rid || result.IsAutopilot() || result.OfferThroughput() != 400 {
+		t.Fatalf("%s failed: invalid offer info returned %#v", name, result.OfferInfo)
+	}
+	if result := client.ReplaceOfferForResource(collInfo.Rid, 600, 0); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	} else if result.OfferThroughput() != 600 {
+		t.Fatalf("%s failed: <ru> expected %#v but received %#v", name, 600, result.OfferThroughput())
+	}
+	if result := client.ReplaceOfferForResource(collInfo.Rid, 0, 6000); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	} else if !result.IsAutopilot() || result.AutopilotMaxThroughput() != 6000 {
+		t.Fatalf("%s failed: <maxru> expected %#v but received %#v", name, 6000, result.AutopilotMaxThroughput())
+	}
+	if result := client.GetOfferForResource("not_exists"); result.StatusCode != 404 {
+		t.Fatalf("%s failed: <status-code> expected %#v but received %#v", name, 404, result.StatusCode)
+	}
+}
+
+func TestRestClient_UserPermission(t *testing.T) {
+	name := "TestRestClient_UserPermission"
+	client := _newRestClient(t, name)
+
+	dbname := "mydb"
+	collname := "mytable"
+	client.DeleteDatabase(dbname)
+	client.CreateDatabase(DatabaseSpec{Id: dbname})
+	client.CreateCollection(CollectionSpec{DbName: dbname, CollName: collname,
+		PartitionKeyInfo: map[string]interface{}{"paths": []string{"/username"}, "kind": "Hash"}})
+
+	if result := client.CreateUser(dbname, "user1"); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	} else if result.Id != "user1" || result.Rid == "" {
+		t.Fatalf("%s failed: invalid user info returned %#v", name, result.UserInfo)
+	}
+	if result := client.CreateUser(dbname, "user1"); result.StatusCode != 409 {
+		t.Fatalf("%s failed: <status-code> expected %#v but received %#v", name, 409, result.StatusCode)
+	}
+	if result := client.ReplaceUser(dbname, "user1", "user2"); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	} else if result.Id != "user2" {
+		t.Fatalf("%s failed: <id> expected %#v but received %#v", name, "user2", result.Id)
+	}
+	if result := client.GetUser(dbname, "user1"); result.StatusCode != 404 {
+		t.Fatalf("%s failed: <status-code> expected %#v but received %#v", name, 404, result.StatusCode)
+	}
+	if result := client.ListUsers(dbname); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	} else if result.Count != 1 || len(result.Users) != 1 || result.Users[0].Id != "user2" {
+		t.Fatalf("%s failed: invalid list of users returned %#v", name, result.Users)
+	}
+
+	spec := PermissionSpec{DbName: dbname, UserId: "user2", PermissionId: "perm1", PermissionMode: "Read",
+		Resource: "dbs/" + dbname + "/colls/" + collname, ResourcePartitionKey: []interface{}{"user2"}, TokenExpirySeconds: 600}
+	if result := client.CreatePermission(spec); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	} else if result.Id != spec.PermissionId || result.PermissionMode != "Read" || result.Token == "" {
+		t.Fatalf("%s failed: invalid permission info returned %#v", name, result.PermissionInfo)
+	}
+	spec.PermissionMode = "All"
+	if result := client.ReplacePermission(spec); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	} else if result.PermissionMode != "All" {
+		t.Fatalf("%s failed: <permissionMode> expected %#v but received %#v", name, "All", result.PermissionMode)
+	}
+	if result := client.GetPermission(dbname, "user2", "perm1", 3600); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	} else if result.Token == "" || !reflect.DeepEqual(result.ResourcePartitionKey, []interface{}{"user2"}) {
+		t.Fatalf("%s failed: invalid permission info returned %#v", name, result.PermissionInfo)
+	}
+	if result := client.ListPermissions(dbname, "user2"); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	} else if result.Count != 1 || len(result.Permissions) != 1 || result.Permissions[0].Id != "perm1" {
+		t.Fatalf("%s failed: invalid list of permissions returned %#v", name, result.Permissions)
+	}
+	if result := client.DeletePermission(dbname, "user2", "perm1"); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	}
+	if result := client.DeleteUser(dbname, "user2"); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	}
+	if result := client.DeleteUser(dbname, "user2"); result.StatusCode != 404 {
+		t.Fatalf("%s failed: <status-code> expected %#v but received %#v", name, 404, result.StatusCode)
+	}
+}
+
+func TestRestClient_PermissionTokenExpiry(t *testing.T) {
+	name := "TestRestClient_PermissionTokenExpiry"
+	var method, path, expiry, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		method, path, expiry, body = r.Method, r.URL.Path, r.Header.Get("X-Ms-Documentdb-Expiry-Seconds"), string(data)
+		w.Write([]byte(`{"id":"perm1","permissionMode":"Read","resource":"dbs/db/colls/coll","_token":"type=resource&ver=1&sig=abc"}`))
+	}))
+	defer server.Close()
+	client, _ := NewRestClient(nil, "AccountEndpoint="+server.URL+";AccountKey=cHJpbWFyeQ==")
+
+	spec := PermissionSpec{DbName: "db", UserId: "user1", PermissionId: "perm1", PermissionMode: "Read",
+		Resource: "dbs/db/colls/coll", ResourcePartitionKey: []interface{}{"tenant1"}, TokenExpirySeconds: 900}
+	if result := client.CreatePermission(spec); result.Error() != nil || result.Token != "type=resource&ver=1&sig=abc" {
+		t.Fatalf("%s failed: unexpected result %#v / %s", name, result.PermissionInfo, result.Error())
+	}
+	if method != "POST" || path != "/dbs/db/users/user1/permissions" || expiry != "900" || !strings.Contains(body, `"resourcePartitionKey":["tenant1"]`) {
+		t.Fatalf("%s failed: unexpected request %s %s (expiry %#v): %s", name, method, path, expiry, body)
+	}
+	if result := client.GetPermission("db", "user1", "perm1", 0); result.Error() != nil || result.Token == "" {
+		t.Fatalf("%s failed: unexpected result %#v / %s", name, result.PermissionInfo, result.Error())
+	}
+	if method != "GET" || path != "/dbs/db/users/user1/permissions/perm1" || expiry != "" {
+		t.Fatalf("%s failed: unexpected request %s %s (expiry %#v)", name, method, path, expiry)
+	}
+}
+
+func TestRestClient_Conflicts(t *testing.T) {
+	name := "TestRestClient_Conflicts"
+	var method, path, pk, continuation, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		method, path, pk, continuation, body = r.Method, r.URL.Path, r.Header.Get("X-Ms-Documentdb-PartitionKey"), r.Header.Get("X-Ms-Continuation"), string(data)
+		switch {
+		case r.Method == "POST":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"coll"}`))
+		case r.Method == "DELETE":
+			w.WriteHeader(http.StatusNoContent)
+		case strings.HasSuffix(r.URL.Path, "/conflicts") && continuation == "":
+			w.Header().Set("X-Ms-Continuation", "page2")
+			w.Write([]byte(`{"_count":1,"Conflicts":[{"id":"c1","resourceType":"document","operationType":"replace","content":"{\"id\":\"1\",\"value\":1}","conflict_lsn":5}]}`))
+		case strings.HasSuffix(r.URL.Path, "/conflicts"):
+			w.Write([]byte(`{"_count":0,"Conflicts":[]}`))
+		default:
+			w.Write([]byte(`{"id":"c1","resourceType":"document","operationType":"delete","content":""}`))
+		}
+	}))
+	defer server.Close()
+	client, _ := NewRestClient(nil, "AccountEndpoint="+server.URL+";AccountKey=cHJpbWFyeQ==")
+
+	if result := client.CreateCollection(CollectionSpec{DbName: "db", CollName: "coll",
+		ConflictResolutionPolicy: map[string]interface{}{"mode": "Custom"}}); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	}
+	if !strings.Contains(body, `"conflictResolutionPolicy":{"mode":"Custom"}`) {
+		t.Fatalf("%s failed: conflict resolution policy must be sent, request body %s", name, body)
+	}
+
+	result := client.ListConflicts(ListConflictsReq{DbName: "db", CollName: "coll", MaxItemCount: 10})
+	if result.Error() != nil || result.Count != 1 || len(result.Conflicts) != 1 || result.ContinuationToken != "page2" {
+		t.Fatalf("%s failed: unexpected result %#v / %s", name, result.Conflicts, result.Error())
+	}
+	if conflict := result.Conflicts[0]; conflict.Id != "c1" || conflict.OperationType != "replace" || conflict.ConflictLsn != 5 ||
+		conflict.Document.Id() != "1" || conflict.Document["value"] != 1.0 {
+		t.Fatalf("%s failed: unexpected conflict %#v", name, conflict)
+	}
+	if method != "GET" || path != "/dbs/db/colls/coll/conflicts" {
+		t.Fatalf("%s failed: unexpected request %s %s", name, method, path)
+	}
+	result = client.ListConflicts(ListConflictsReq{DbName: "db", CollName: "coll", ContinuationToken: result.ContinuationToken})
+	if result.Error() != nil || len(result.Conflicts) != 0 || result.ContinuationToken != "" || continuation != "page2" {
+		t.Fatalf("%s failed: unexpected result %#v / %s", name, result.Conflicts, result.Error())
+	}
+
+	if result := client.GetConflict(ConflictReq{DbName: "db", CollName: "coll", ConflictId: "c1", PartitionKeyValues: []interface{}{"1"}}); result.Error() != nil || result.Id != "c1" || result.Document != nil {
+		t.Fatalf("%s failed: unexpected result %#v / %s", name, result.ConflictInfo, result.Error())
+	}
+	if method != "GET" || path != "/dbs/db/colls/coll/conflicts/c1" || pk != `["1"]` {
+		t.Fatalf("%s failed: unexpected request %s %s (pk %s)", name, method, path, pk)
+	}
+	if result := client.DeleteConflict(ConflictReq{DbName: "db", CollName: "coll", ConflictId: "c1", PartitionKeyValues: []interface{}{"1"}}); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	}
+	if method != "DELETE" || path != "/dbs/db/colls/coll/conflicts/c1" || pk != `["1"]` {
+		t.Fatalf("%s failed: unexpected request %s %s (pk %s)", name, method, path, pk)
+	}
+}
+
+func TestRestClient_StoredProcedure(t *testing.T) {
+	name := "TestRestClient_StoredProcedure"
+	client := _newRestClient(t, name)
+
+	dbname := "mydb"
+	collname := "mytable"
+	client.DeleteDatabase(dbname)
+	client.CreateDatabase(DatabaseSpec{Id: dbname})
+	client.CreateCollection(CollectionSpec{DbName: dbname, CollName: collname,
+		PartitionKeyInfo: map[string]interface{}{"paths": []string{"/id"}, "kind": "Hash"}})
+
+	spec := StoredProcedureSpec{DbName: dbname, CollName: collname, SprocId: "sp1",
+		Body: "function sum(a, b) { getContext().getResponse().setBody(a + b); }"}
+	if result := client.CreateStoredProcedure(spec); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	} else if result.Id != spec.SprocId || result.Body != spec.Body || result.Rid == "" {
+		t.Fatalf("%s failed: invalid stored procedure info returned %#v", name, result.SprocInfo)
+	}
+	if result := client.CreateStoredProcedure(spec); result.StatusCode != 409 {
+		t.Fatalf("%s failed: <status-code> expected %#v but received %#v", name, 409, result.StatusCode)
+	}
+	if result := client.ExecuteStoredProcedure(ExecuteStoredProcedureReq{DbName: dbname, CollName: collname, SprocId: "sp1",
+		PartitionKeyValues: []interface{}{"any"}, Params: []interface{}{1, 2}}); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	} else if result.Result != 3.0 {
+		t.Fatalf("%s failed: <result> expected %#v but received %#v", name, 3.0, result.Result)
+	}
+
+	spec.Body = "function sum(a, b) { getContext().getResponse().setBody(a * b); }"
+	if result := client.ReplaceStoredProcedure(spec); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	} else if result.Body != spec.Body {
+		t.Fatalf("%s failed: <body> expected %#v but received %#v", name, spec.Body, result.Body)
+	}
+	if result := client.ListStoredProcedures(dbname, collname); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	} else if result.Count != 1 || len(result.StoredProcedures) != 1 || result.StoredProcedures[0].Id != spec.SprocId {
+		t.Fatalf("%s failed: invalid list of stored procedures returned %#v", name, result.StoredProcedures)
+	}
+	if result := client.DeleteStoredProcedure(dbname, collname, spec.SprocId); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	}
+	if result := client.DeleteStoredProcedure(dbname, collname, spec.SprocId); result.StatusCode != 404 {
+		t.Fatalf("%s failed: <status-code> expected %#v but received %#v", name, 404, result.StatusCode)
+	}
+}
+
+func TestRestClient_UserDefinedFunction(t *testing.T) {
+	name := "TestRestClient_UserDefinedFunction"
+	client := _newRestClient(t, name)
+
+	dbname := "mydb"
+	collname := "mytable"
+	client.DeleteDatabase(dbname)
+	client.CreateDatabase(DatabaseSpec{Id: dbname})
+	client.CreateCollection(CollectionSpec{DbName: dbname, CollName: collname,
+		PartitionKeyInfo: map[string]interface{}{"paths": []string{"/id"}, "kind": "Hash"}})
+
+	spec := UserDefinedFunctionSpec{DbName: dbname, CollName: collname, UdfId: "double", Body: "function(a) { return a * 2; }"}
+	if result := client.CreateUserDefinedFunction(spec); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	} else if result.Id != spec.UdfId || result.Body != spec.Body || result.Rid == "" {
+		t.Fatalf("%s failed: invalid user-defined function info returned %#v", name, result.UdfInfo)
+	}
+	if result := client.CreateUserDefinedFunction(spec); result.StatusCode != 409 {
+		t.Fatalf("%s failed: <status-code> expected %#v but received %#v", name, 409, result.StatusCode)
+	}
+	spec.Body = "function(a) { return a * 3; }"
+	if result := client.ReplaceUserDefinedFunction(spec); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	} else if result.Body != spec.Body {
+		t.Fatalf("%s failed: <body> expected %#v but received %#v", name, spec.Body, result.Body)
+	}
+	if result := client.ListUserDefinedFunctions(dbname, collname); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	} else if result.Count != 1 || len(result.UserDefinedFunctions) != 1 || result.UserDefinedFunctions[0].Id != spec.UdfId {
+		t.Fatalf("%s failed: invalid list of user-defined functions returned %#v", name, result.UserDefinedFunctions)
+	}
+	if result := client.DeleteUserDefinedFunction(dbname, collname, spec.UdfId); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	}
+	if result := client.DeleteUserDefinedFunction(dbname, collname, spec.UdfId); result.StatusCode != 404 {
+		t.Fatalf("%s failed: <status-code> expected %#v but received %#v", name, 404, result.StatusCode)
+	}
+}
+
+func TestRestClient_Trigger(t *testing.T) {
+	name := "TestRestClient_Trigger"
+	client := _newRestClient(t, name)
+
+	dbname := "mydb"
+	collname := "mytable"
+	client.DeleteDatabase(dbname)
+	client.CreateDatabase(DatabaseSpec{Id: dbname})
+	client.CreateCollection(CollectionSpec{DbName: dbname, CollName: collname,
+		PartitionKeyInfo: map[string]interface{}{"paths": []string{"/id"}, "kind": "Hash"}})
+
+	spec := TriggerSpec{DbName: dbname, CollName: collname, TriggerId: "stamp", TriggerType: "Pre", TriggerOperation: "All",
+		Body: "function() { var req = getContext().getRequest(); var doc = req.getBody(); doc.stamped = true; req.setBody(doc); }"}
+	if result := client.CreateTrigger(spec); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	} else if result.Id != spec.TriggerId || result.TriggerType != spec.TriggerType || result.TriggerOperation != spec.TriggerOperation || result.Rid == "" {
+		t.Fatalf("%s failed: invalid trigger info returned %#v", name, result.TriggerInfo)
+	}
+	if result := client.CreateTrigger(spec); result.StatusCode != 409 {
+		t.Fatalf("%s failed: <status-code> expected %#v but received %#v", name, 409, result.StatusCode)
+	}
+	docSpec := DocumentSpec{DbName: dbname, CollName: collname, PartitionKeyValues: []interface{}{"1"},
+		DocumentData: map[string]interface{}{"id": "1"}, PreTriggers: []string{"stamp"}}
+	if result := client.CreateDocument(docSpec); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	} else if result.DocInfo["stamped"] != true {
+		t.Fatalf("%s failed: document must have been modified by the pre-trigger %#v", name, result.DocInfo)
+	}
+
+	spec.TriggerOperation = "Create"
+	if result := client.ReplaceTrigger(spec); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	} else if result.TriggerOperation != spec.TriggerOperation {
+		t.Fatalf("%s failed: <trigger-operation> expected %#v but received %#v", name, spec.TriggerOperation, result.TriggerOperation)
+	}
+	if result := client.ListTriggers(dbname, collname); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	} else if result.Count != 1 || len(result.Triggers) != 1 || result.Triggers[0].Id != spec.TriggerId {
+		t.Fatalf("%s failed: invalid list of triggers returned %#v", name, result.Triggers)
+	}
+	if result := client.DeleteTrigger(dbname, collname, spec.TriggerId); result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	}
+	if result := client.DeleteTrigger(dbname, collname, spec.TriggerId); result.StatusCode != 404 {
+		t.Fatalf("%s failed: <status-code> expected %#v but received %#v", name, 404, result.StatusCode)
+	}
+}
+
 func TestRestClient_CreateDocument(t *testing.T) {
 	name := "TestRestClient_CreateDocument"
 	client := _newRestClient(t, name)
@@ -1004,7 +1530,7 @@ func TestRestClient_ListDocuments(t *testing.T) {
 				DocumentData:       map[string]interface{}{"id": fmt.Sprintf("%02d", id), "username": "user", "email": "user" + strconv.Itoa(id) + "@domain.com", "grade": id, "active": i%10 == 0, "extra": time.Now()},
 			}
 			result := client.ReplaceDocument("", doc)
-			if result.Error() != nil && result.Error() != ErrNotFound {
+			if result.Error() != nil && !errors.Is(result.Error(), ErrNotFound) {
 				t.Fatalf("%s failed: %s", name, result.Error())
 			} else {
 				sessionToken = result.SessionToken
@@ -1144,3 +1670,45 @@ func TestRestClient_ListDocumentsCrossPartition(t *testing.T) {
 		t.Fatalf("%s failed: <status-code> expected %#v but received %#v", name, 404, result.StatusCode)
 	}
 }
+
+func TestRestClient_ReadChangeFeed(t *testing.T) {
+	name := "TestRestClient_ReadChangeFeed"
+	client := _newRestClient(t, name)
+
+	dbname := "mydb"
+	collname := "mytable"
+	client.DeleteDatabase(dbname)
+	client.CreateDatabase(DatabaseSpec{Id: dbname})
+	client.CreateCollection(CollectionSpec{DbName: dbname, CollName: collname,
+		PartitionKeyInfo: map[string]interface{}{"paths": []string{"/id"}, "kind": "Hash"}})
+
+	result := client.ReadChangeFeed(ChangeFeedReq{DbName: dbname, CollName: collname, Continuation: "*"})
+	if result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	} else if len(result.Documents) != 0 {
+		t.Fatalf("%s failed: expected no changes but received %#v", name, result.Documents)
+	}
+	continuation := result.Continuation
+	for i := 0; i < 3; i++ {
+		id := strconv.Itoa(i)
+		client.CreateDocument(DocumentSpec{DbName: dbname, CollName: collname, PartitionKeyValues: []interface{}{id}, DocumentData: map[string]interface{}{"id": id}})
+	}
+	result = client.ReadChangeFeed(ChangeFeedReq{DbName: dbname, CollName: collname, Continuation: continuation})
+	if result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	} else if len(result.Documents) != 3 {
+		t.Fatalf("%s failed: expected 3 changes but received %#v", name, result.Documents)
+	}
+	result = client.ReadChangeFeed(ChangeFeedReq{DbName: dbname, CollName: collname, Continuation: result.Continuation})
+	if result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	} else if result.StatusCode != 304 || len(result.Documents) != 0 {
+		t.Fatalf("%s failed: expected no changes but received %d/%#v", name, result.StatusCode, result.Documents)
+	}
+	result = client.ReadChangeFeed(ChangeFeedReq{DbName: dbname, CollName: collname})
+	if result.Error() != nil {
+		t.Fatalf("%s failed: %s", name, result.Error())
+	} else if len(result.Documents) != 3 {
+		t.Fatalf("%s failed: expected 3 changes from the beginning but received %#v", name, result.Documents)
+	}
+}
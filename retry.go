@@ -0,0 +1,183 @@
+package gocosmos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/btnguyen2k/consu/gjrc"
+)
+
+// defaultTransientRetries is the default number of times an idempotent request is retried on transient network errors.
+const defaultTransientRetries = 2
+
+const (
+	defaultRetryBackoff   = "exponential"
+	defaultRetryBaseDelay = 100 * time.Millisecond
+	defaultRetryMaxWait   = 30 * time.Second
+)
+
+// retryPolicy specifies how many times, and after which delays, failed requests are retried.
+type retryPolicy struct {
+	maxRetries int           // maximum number of retries of a request, 0 disables retries
+	backoff    string        // "fixed", "exponential" or "jitter"
+	baseDelay  time.Duration // delay before the first retry
+	maxWait    time.Duration // maximum total time spent waiting between retries of a request
+}
+
+// _parseRetryPolicy builds the retry policy from connection string options TransientRetries, RetryBackoff,
+// RetryBaseDelayMs and RetryMaxWaitMs.
+func _parseRetryPolicy(params map[string]string) (retryPolicy, error) {
+	policy := retryPolicy{maxRetries: defaultTransientRetries, backoff: defaultRetryBackoff,
+		baseDelay: defaultRetryBaseDelay, maxWait: defaultRetryMaxWait}
+	var err error
+	if v, ok := params["TRANSIENTRETRIES"]; ok {
+		if policy.maxRetries, err = strconv.Atoi(v); err != nil || policy.maxRetries < 0 {
+			return policy, fmt.Errorf("invalid TransientRetries <%s>", v)
+		}
+	}
+	if v, ok := params["RETRYBACKOFF"]; ok {
+		if policy.backoff, err = _normalizeRetryBackoff(v); err != nil {
+			return policy, err
+		}
+	}
+	if v, ok := params["RETRYBASEDELAYMS"]; ok {
+		ms, err := strconv.Atoi(v)
+		if err != nil || ms <= 0 {
+			return policy, fmt.Errorf("invalid RetryBaseDelayMs <%s>", v)
+		}
+		policy.baseDelay = time.Duration(ms) * time.Millisecond
+	}
+	if v, ok := params["RETRYMAXWAITMS"]; ok {
+		ms, err := strconv.Atoi(v)
+		if err != nil || ms <= 0 {
+			return policy, fmt.Errorf("invalid RetryMaxWaitMs <%s>", v)
+		}
+		policy.maxWait = time.Duration(ms) * time.Millisecond
+	}
+	return policy, nil
+}
+
+// _normalizeRetryBackoff validates a backoff strategy (case-insensitive) and returns its canonical form.
+func _normalizeRetryBackoff(backoff string) (string, error) {
+	switch v := strings.ToLower(strings.TrimSpace(backoff)); v {
+	case "fixed", "exponential", "jitter":
+		return v, nil
+	}
+	return "", fmt.Errorf("invalid RetryBackoff <%s>, expected fixed, exponential or jitter", backoff)
+}
+
+// delay returns the delay before the retry-th retry (0-based): baseDelay for "fixed", baseDelay doubled after each retry
+// for "exponential", and a random delay between 0 and the exponential one for "jitter".
+func (p retryPolicy) delay(retry int) time.Duration {
+	if p.backoff == "fixed" {
+		return p.baseDelay
+	}
+	if retry > 20 {
+		retry = 20
+	}
+	delay := p.baseDelay << uint(retry)
+	if delay <= 0 || delay > p.maxWait {
+		delay = p.maxWait
+	}
+	if p.backoff == "jitter" && delay > 0 {
+		delay = time.Duration(rand.Int63n(int64(delay) + 1))
+	}
+	return delay
+}
+
+// _isIdempotentRequest returns true if the request can be safely resent: reads (GET/HEAD), queries and conditional
+// replaces and partial updates (PUT/PATCH with If-Match header).
+func _isIdempotentRequest(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		return true
+	case http.MethodPost:
+		return req.Header.Get("X-Ms-Documentdb-Isquery") == "true"
+	case http.MethodPut, http.MethodPatch:
+		return req.Header.Get("If-Match") != ""
+	}
+	return false
+}
+
+// _isTransientNetworkError returns true if err is a network error that may not happen again if the request is resent:
+// connection resets, DNS failures and timeouts.
+func _isTransientNetworkError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNABORTED) || errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// send sends the request. Idempotent requests (see _isIdempotentRequest) that fail with transient network errors are
+// resent according to the retry policy (by default up to 2 times, after 100ms then 200ms), unless the request's context
+// is done or the total delay would exceed the policy's maximum wait.
+//
+// Note: requests rejected by the server (e.g. StatusCode=429 or 503) are not retried here.
+func (c *RestClient) send(req *http.Request) *gjrc.GjrcResponse {
+	resp := c.sendOnce(req)
+	waited := time.Duration(0)
+	for retry := 0; retry < c.retryPolicy.maxRetries && _isTransientNetworkError(resp.Error()) && _isIdempotentRequest(req); retry++ {
+		if req.Context().Err() != nil || (req.Body != nil && req.GetBody == nil) {
+			return resp
+		}
+		retryReq := req.Clone(req.Context())
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return resp
+			}
+			retryReq.Body = body
+		}
+		delay := c.retryPolicy.delay(retry)
+		if waited += delay; waited > c.retryPolicy.maxWait {
+			return resp
+		}
+		c.log(LogLevelInfo, "transient network error, retrying request", map[string]interface{}{
+			"path": req.URL.Path, "retry": retry + 1, "delay_ms": delay.Milliseconds(), "error": resp.Error().Error()})
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return resp
+		case <-timer.C:
+		}
+		c.stats.retried()
+		resp = c.sendOnce(retryReq)
+	}
+	return resp
+}
+
+// sendOnce sends the request, recording it into the Diagnostics attached to the request's context (if any).
+//
+// The response body is read before returning (gjrc otherwise reads it in background), so that a connection dropped in
+// the middle of the response is reported by resp.Error() and can be retried.
+func (c *RestClient) sendOnce(req *http.Request) *gjrc.GjrcResponse {
+	diag := DiagnosticsFromContext(req.Context())
+	start := time.Now()
+	resp := c.client.Do(req)
+	if resp.Error() == nil {
+		resp.Body()
+	}
+	if diag != nil {
+		diag.record(req, resp, start)
+	}
+	return resp
+}
@@ -0,0 +1,190 @@
+package gocosmos
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func Test_isIdempotentRequest(t *testing.T) {
+	name := "Test_isIdempotentRequest"
+	client, _ := NewRestClient(nil, "AccountEndpoint=https://localhost:8081;AccountKey=cHJpbWFyeQ==")
+	query := client.buildJsonRequest("POST", "https://localhost:8081/dbs/mydb/colls/mycoll/docs", nil)
+	query.Header.Set("X-Ms-Documentdb-Isquery", "true")
+	replace := client.buildJsonRequest("PUT", "https://localhost:8081/dbs/mydb/colls/mycoll/docs/1", nil)
+	conditionalReplace := client.buildJsonRequest("PUT", "https://localhost:8081/dbs/mydb/colls/mycoll/docs/1", nil)
+	conditionalReplace.Header.Set("If-Match", `"etag"`)
+	conditionalPatch := client.buildJsonRequest("PATCH", "https://localhost:8081/dbs/mydb/colls/mycoll/docs/1", nil)
+	conditionalPatch.Header.Set("If-Match", `"etag"`)
+	for i, testCase := range []struct {
+		req      *http.Request
+		expected bool
+	}{
+		{client.buildJsonRequest("GET", "https://localhost:8081/dbs/mydb", nil), true},
+		{query, true},
+		{conditionalReplace, true},
+		{conditionalPatch, true},
+		{client.buildJsonRequest("PATCH", "https://localhost:8081/dbs/mydb/colls/mycoll/docs/1", nil), false},
+		{client.buildJsonRequest("POST", "https://localhost:8081/dbs/mydb/colls/mycoll/docs", nil), false},
+		{replace, false},
+		{client.buildJsonRequest("DELETE", "https://localhost:8081/dbs/mydb", nil), false},
+	} {
+		if actual := _isIdempotentRequest(testCase.req); actual != testCase.expected {
+			t.Fatalf("%s failed: <%d> expected %#v but received %#v", name, i, testCase.expected, actual)
+		}
+	}
+}
+
+func Test_isTransientNetworkError(t *testing.T) {
+	name := "Test_isTransientNetworkError"
+	for i, testCase := range []struct {
+		err      error
+		expected bool
+	}{
+		{nil, false},
+		{&url.Error{Op: "Get", URL: "https://localhost", Err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}}, true},
+		{&url.Error{Op: "Get", URL: "https://localhost", Err: &net.DNSError{Err: "no such host", Name: "localhost"}}, true},
+		{&url.Error{Op: "Get", URL: "https://localhost", Err: io.EOF}, true},
+		{&url.Error{Op: "Get", URL: "https://localhost", Err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}}, false},
+		{&url.Error{Op: "Get", URL: "https://localhost", Err: context.Canceled}, false},
+		{&url.Error{Op: "Get", URL: "https://localhost", Err: &CircuitOpenError{Endpoint: "localhost"}}, false},
+		{errors.New("error"), false},
+	} {
+		if actual := _isTransientNetworkError(testCase.err); actual != testCase.expected {
+			t.Fatalf("%s failed: <%d> expected %#v but received %#v", name, i, testCase.expected, actual)
+		}
+	}
+}
+
+func TestRestClient_TransientRetries(t *testing.T) {
+	name := "TestRestClient_TransientRetries"
+	var requests, failures int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if atomic.AddInt32(&failures, -1) >= 0 {
+			// drop the connection in the middle of the response
+			w.Header().Set("Content-Length", "100")
+			w.Write([]byte(`{"id":`))
+			return
+		}
+		w.Write([]byte(`{"id":"mydb"}`))
+	}))
+	defer server.Close()
+	connStr := "AccountEndpoint=" + server.URL + ";AccountKey=cHJpbWFyeQ=="
+	client, _ := NewRestClient(nil, connStr)
+	logger := &_recordingLogger{}
+	client.SetLogger(logger)
+
+	atomic.StoreInt32(&requests, 0)
+	atomic.StoreInt32(&failures, 2)
+	if result := client.GetDatabase("mydb"); result.Error() != nil || result.Id != "mydb" || atomic.LoadInt32(&requests) != 3 {
+		t.Fatalf("%s failed: expected read to be retried but received %s / %d requests", name, result.Error(), requests)
+	}
+	if logger.find(LogLevelInfo, "transient network error, retrying request") == nil {
+		t.Fatalf("%s failed: retries must be logged", name)
+	}
+
+	atomic.StoreInt32(&requests, 0)
+	atomic.StoreInt32(&failures, 3)
+	if result := client.GetDatabase("mydb"); result.CallErr == nil || atomic.LoadInt32(&requests) != 3 {
+		t.Fatalf("%s failed: retries must be bounded, received %s / %d requests", name, result.Error(), requests)
+	}
+
+	atomic.StoreInt32(&requests, 0)
+	atomic.StoreInt32(&failures, 1)
+	if result := client.CreateDatabase(DatabaseSpec{Id: "mydb"}); result.CallErr == nil || atomic.LoadInt32(&requests) != 1 {
+		t.Fatalf("%s failed: non-idempotent request must not be retried, received %s / %d requests", name, result.Error(), requests)
+	}
+
+	client, _ = NewRestClient(nil, connStr+";TransientRetries=0")
+	atomic.StoreInt32(&requests, 0)
+	atomic.StoreInt32(&failures, 1)
+	if result := client.GetDatabase("mydb"); result.CallErr == nil || atomic.LoadInt32(&requests) != 1 {
+		t.Fatalf("%s failed: TransientRetries=0 must disable retries, received %s / %d requests", name, result.Error(), requests)
+	}
+	if _, err := NewRestClient(nil, connStr+";TransientRetries=-1"); err == nil {
+		t.Fatalf("%s failed: expected error for invalid TransientRetries", name)
+	}
+}
+
+func TestRestClient_RetryPolicy(t *testing.T) {
+	name := "TestRestClient_RetryPolicy"
+	connStr := "AccountEndpoint=https://localhost:8081;AccountKey=cHJpbWFyeQ=="
+	client, _ := NewRestClient(nil, connStr)
+	for retry, expected := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond} {
+		if delay := client.retryPolicy.delay(retry); delay != expected {
+			t.Fatalf("%s failed: <%d> expected default delay %s but received %s", name, retry, expected, delay)
+		}
+	}
+
+	client, _ = NewRestClient(nil, connStr+";RetryBackoff=Fixed;RetryBaseDelayMs=50")
+	if delay := client.retryPolicy.delay(5); delay != 50*time.Millisecond {
+		t.Fatalf("%s failed: expected fixed delay 50ms but received %s", name, delay)
+	}
+	client, _ = NewRestClient(nil, connStr+";RetryBackoff=exponential;RetryBaseDelayMs=1000;RetryMaxWaitMs=3000")
+	if delay := client.retryPolicy.delay(4); delay != 3*time.Second {
+		t.Fatalf("%s failed: delay must be capped by RetryMaxWaitMs, received %s", name, delay)
+	}
+	client, _ = NewRestClient(nil, connStr+";RetryBackoff=jitter")
+	for retry := 0; retry < 100; retry++ {
+		if delay := client.retryPolicy.delay(retry % 5); delay < 0 || delay > 100*time.Millisecond<<uint(retry%5) {
+			t.Fatalf("%s failed: <%d> jitter delay out of range: %s", name, retry, delay)
+		}
+	}
+
+	for _, opt := range []string{"RetryBackoff=linear", "RetryBaseDelayMs=0", "RetryMaxWaitMs=x"} {
+		if _, err := NewRestClient(nil, connStr+";"+opt); err == nil {
+			t.Fatalf("%s failed: expected error for invalid option %s", name, opt)
+		}
+	}
+	cfg, err := ParseDSN(connStr + ";RetryBackoff=jitter;RetryBaseDelayMs=50;RetryMaxWaitMs=2000")
+	if err != nil || cfg.RetryBackoff != "jitter" || cfg.RetryBaseDelay != 50*time.Millisecond || cfg.RetryMaxWait != 2*time.Second {
+		t.Fatalf("%s failed: unexpected config %#v / %s", name, cfg, err)
+	}
+	if connStr := cfg.String(); !strings.HasSuffix(connStr, ";RetryBackoff=jitter;RetryBaseDelayMs=50;RetryMaxWaitMs=2000") {
+		t.Fatalf("%s failed: unexpected connection string %s", name, connStr)
+	}
+}
+
+func TestRestClient_RetryMaxWait(t *testing.T) {
+	name := "TestRestClient_RetryMaxWait"
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Length", "100")
+		w.Write([]byte(`{"id":`))
+	}))
+	defer server.Close()
+	connStr := "AccountEndpoint=" + server.URL + ";AccountKey=cHJpbWFyeQ==;TransientRetries=5;RetryBackoff=fixed;RetryBaseDelayMs=20"
+
+	// delays of 20ms: the 3rd retry would bring the total wait to 60ms > 50ms
+	client, _ := NewRestClient(nil, connStr+";RetryMaxWaitMs=50")
+	logger := &_recordingLogger{}
+	client.SetLogger(logger)
+	start := time.Now()
+	if result := client.GetDatabase("mydb"); result.CallErr == nil || atomic.LoadInt32(&requests) != 3 {
+		t.Fatalf("%s failed: retries must stop when RetryMaxWaitMs is exceeded, received %s / %d requests", name, result.Error(), requests)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond || elapsed > time.Second {
+		t.Fatalf("%s failed: unexpected time spent retrying %s", name, elapsed)
+	}
+	if retries := logger.count(LogLevelInfo, "transient network error, retrying request"); retries != 2 {
+		t.Fatalf("%s failed: expected 2 retries but received %d", name, retries)
+	}
+
+	// without the cap, all TransientRetries are spent
+	client, _ = NewRestClient(nil, connStr+";RetryMaxWaitMs=1000")
+	atomic.StoreInt32(&requests, 0)
+	if result := client.GetDatabase("mydb"); result.CallErr == nil || atomic.LoadInt32(&requests) != 6 {
+		t.Fatalf("%s failed: expected 5 retries, received %s / %d requests", name, result.Error(), requests)
+	}
+}
@@ -0,0 +1,159 @@
+package gocosmos
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// ScanJson assigns a nested document/array value returned from a query to dest, which should be a pointer to a struct,
+// map or slice. src can be either the decoded value (map[string]interface{} or []interface{}) or its JSON text (string
+// or []byte, see SELECT's WITH objects_as_json option); nil src leaves dest untouched.
+//
+// ScanJson is meant to be used to implement sql.Scanner for types of nested documents, so that they can be scanned with
+// sql.Rows.Scan or sqlx's StructScan:
+//
+//     type Address struct {
+//         City   string `json:"city"`
+//         Street string `json:"street"`
+//     }
+//
+//     func (a *Address) Scan(src interface{}) error {
+//         return gocosmos.ScanJson(src, a)
+//     }
+//
+// (since v0.1.1) If dest is a pointer to struct, its string field tagged with `cosmos:"id"` (see StructTagName) receives
+// the document id.
+//
+// Available since v0.1.1
+func ScanJson(src, dest interface{}) error {
+	var js []byte
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		js = v
+	case string:
+		js = []byte(v)
+	default:
+		var err error
+		if js, err = json.Marshal(v); err != nil {
+			return err
+		}
+	}
+	return _unmarshalDocument(js, dest)
+}
+
+// _unmarshalDocument unmarshals the JSON document into dest, then populates the field tagged with `cosmos:"id"` if any.
+func _unmarshalDocument(js []byte, dest interface{}) error {
+	if err := json.Unmarshal(js, dest); err != nil {
+		return err
+	}
+	if id, _, _ := DocumentKeys(dest); id != "" {
+		// the tagged id field has been populated from the document via its json name
+		return nil
+	}
+	var doc struct {
+		Id *string `json:"id"`
+	}
+	if json.Unmarshal(js, &doc) != nil || doc.Id == nil {
+		return nil
+	}
+	return _setTaggedId(dest, *doc.Id)
+}
+
+// ToStruct converts the document into dest, which should be a pointer to a struct, via a JSON round trip: fields are
+// matched against json tags and nested documents are unmarshaled into nested struct fields. See ScanJson.
+//
+// Available since v0.1.1
+func (d DocInfo) ToStruct(dest interface{}) error {
+	if d == nil {
+		return nil
+	}
+	return ScanJson(map[string]interface{}(d), dest)
+}
+
+// ScanRow converts the current row of rows into dest, which should be a pointer to a struct, via a JSON round trip: the
+// row is treated as a document whose fields are the columns (e.g. rows of "SELECT * FROM c" or of a RETURNING clause),
+// so that nested documents are unmarshaled into nested struct fields instead of being handled as interface{} maps:
+//
+//     rows, err := db.Query("SELECT * FROM c WITH collection=users")
+//     for rows.Next() {
+//         var user User // e.g. with a nested struct field Address
+//         err := gocosmos.ScanRow(rows, &user)
+//     }
+//
+// Columns returned as JSON text (see SELECT's WITH objects_as_json option) are embedded as-is. See ScanJson for tag
+// support.
+//
+// Available since v0.1.1
+func ScanRow(rows *sql.Rows, dest interface{}) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	values := make([]interface{}, len(columns))
+	ptrs := make([]interface{}, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	if err := rows.Scan(ptrs...); err != nil {
+		return err
+	}
+	doc := make(map[string]interface{}, len(columns))
+	for i, column := range columns {
+		if js, ok := values[i].([]byte); ok && json.Valid(js) {
+			values[i] = json.RawMessage(js)
+		}
+		doc[column] = values[i]
+	}
+	js, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return _unmarshalDocument(js, dest)
+}
+
+// JsonObject is a nested document returned from a query. It implements sql.Scanner, so that it can be used as type of
+// struct fields to be scanned with sqlx's StructScan.
+//
+// Available since v0.1.1
+type JsonObject map[string]interface{}
+
+// Scan implements sql.Scanner.Scan.
+func (o *JsonObject) Scan(src interface{}) error {
+	if src == nil {
+		*o = nil
+		return nil
+	}
+	if m, ok := src.(map[string]interface{}); ok {
+		*o = m
+		return nil
+	}
+	if err := ScanJson(src, (*map[string]interface{})(o)); err != nil {
+		return fmt.Errorf("cannot scan %T into JsonObject: %s", src, err)
+	}
+	return nil
+}
+
+// JsonArray is a nested array returned from a query. It implements sql.Scanner, so that it can be used as type of
+// struct fields to be scanned with sqlx's StructScan.
+//
+// Available since v0.1.1
+type JsonArray []interface{}
+
+// Scan implements sql.Scanner.Scan.
+func (a *JsonArray) Scan(src interface{}) error {
+	if src == nil {
+		*a = nil
+		return nil
+	}
+	if arr, ok := src.([]interface{}); ok {
+		*a = arr
+		return nil
+	}
+	if err := ScanJson(src, (*[]interface{})(a)); err != nil {
+		return fmt.Errorf("cannot scan %T into JsonArray: %s", src, err)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package gocosmos
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type _address struct {
+	City   string `json:"city"`
+	Street string `json:"street"`
+}
+
+func (a *_address) Scan(src interface{}) error {
+	return ScanJson(src, a)
+}
+
+func TestScanJson(t *testing.T) {
+	name := "TestScanJson"
+	expected := _address{City: "HCM", Street: "Le Loi"}
+	for _, src := range []interface{}{
+		map[string]interface{}{"city": "HCM", "street": "Le Loi"},
+		`{"city":"HCM","street":"Le Loi"}`,
+		[]byte(`{"city":"HCM","street":"Le Loi"}`),
+	} {
+		var addr _address
+		if err := ScanJson(src, &addr); err != nil || addr != expected {
+			t.Fatalf("%s failed: <%#v> expected %#v but received %#v (%s)", name, src, expected, addr, err)
+		}
+	}
+	if err := ScanJson("not a json", &_address{}); err == nil {
+		t.Fatalf("%s failed: expected error for invalid JSON", name)
+	}
+}
+
+func TestJsonObject_JsonArray_Scan(t *testing.T) {
+	name := "TestJsonObject_JsonArray_Scan"
+	var obj JsonObject
+	if err := obj.Scan([]byte(`{"a":1}`)); err != nil || !reflect.DeepEqual(obj, JsonObject{"a": 1.0}) {
+		t.Fatalf("%s failed: unexpected JsonObject %#v (%s)", name, obj, err)
+	}
+	if err := obj.Scan(nil); err != nil || obj != nil {
+		t.Fatalf("%s failed: expected nil JsonObject but received %#v (%s)", name, obj, err)
+	}
+	var arr JsonArray
+	if err := arr.Scan([]interface{}{"a", true}); err != nil || !reflect.DeepEqual(arr, JsonArray{"a", true}) {
+		t.Fatalf("%s failed: unexpected JsonArray %#v (%s)", name, arr, err)
+	}
+	if err := arr.Scan(`{"a":1}`); err == nil {
+		t.Fatalf("%s failed: expected error scanning object into JsonArray", name)
+	}
+}
+
+func TestStmtSelect_StructScan(t *testing.T) {
+	name := "TestStmtSelect_StructScan"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"_count":2,"Documents":[` +
+			`{"id":"1","address":{"city":"HCM","street":"Le Loi"}},` +
+			`{"id":"2","tags":["a","b"],"address":{"city":"HN"}}]}`))
+	}))
+	defer server.Close()
+	db, _ := sql.Open("gocosmos", "AccountEndpoint="+server.URL+";AccountKey=C2y6yDjf5/R+ob0N8A7Cgv30VRDJIWEHLM+4QDU5DE2nQ9nDuVTqobD4b8mGGyPMbIZnqyMsEcaGQy67XIw/Jw==;DefaultDb=db")
+	defer db.Close()
+
+	// columns are consistent across rows, even if documents have different fields
+	rows, err := db.Query("SELECT * FROM c WITH collection=coll")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if cols, _ := rows.Columns(); !reflect.DeepEqual(cols, []string{"address", "id", "tags"}) {
+		t.Fatalf("%s failed: unexpected columns %#v", name, cols)
+	}
+	var addrs []_address
+	var tagsList []JsonArray
+	for rows.Next() {
+		var addr _address
+		var id string
+		var tags JsonArray
+		if err := rows.Scan(&addr, &id, &tags); err != nil {
+			t.Fatalf("%s failed: %s", name, err)
+		}
+		addrs = append(addrs, addr)
+		tagsList = append(tagsList, tags)
+	}
+	rows.Close()
+	if !reflect.DeepEqual(addrs, []_address{{City: "HCM", Street: "Le Loi"}, {City: "HN"}}) || tagsList[0] != nil || len(tagsList[1]) != 2 {
+		t.Fatalf("%s failed: unexpected result %#v / %#v", name, addrs, tagsList)
+	}
+
+	// nested objects returned as JSON text
+	rows, err = db.Query("SELECT * FROM c WITH collection=coll WITH objects_as_json=true")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	defer rows.Close()
+	rows.Next()
+	var addrJson, id string
+	var tags sql.NullString
+	if err := rows.Scan(&addrJson, &id, &tags); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	} else if addrJson != `{"city":"HCM","street":"Le Loi"}` {
+		t.Fatalf("%s failed: unexpected JSON text %#v", name, addrJson)
+	}
+}
+
+type _person struct {
+	Key     string   `json:"key" cosmos:"id"`
+	Name    string   `json:"name"`
+	Address _address `json:"address"`
+	Tags    []string `json:"tags"`
+}
+
+func TestScanRow_ToStruct(t *testing.T) {
+	name := "TestScanRow_ToStruct"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"_count":2,"Documents":[` +
+			`{"id":"1","name":"user1","address":{"city":"HCM","street":"Le Loi"},"tags":["a","b"]},` +
+			`{"id":"2","name":"user2"}]}`))
+	}))
+	defer server.Close()
+	db, _ := sql.Open("gocosmos", "AccountEndpoint="+server.URL+";AccountKey=cHJpbWFyeQ==;DefaultDb=db")
+	defer db.Close()
+
+	expected := []_person{
+		{Key: "1", Name: "user1", Address: _address{City: "HCM", Street: "Le Loi"}, Tags: []string{"a", "b"}},
+		{Key: "2", Name: "user2"},
+	}
+	for _, query := range []string{"SELECT * FROM c WITH collection=coll", "SELECT * FROM c WITH collection=coll WITH objects_as_json=true"} {
+		rows, err := db.Query(query)
+		if err != nil {
+			t.Fatalf("%s failed: %s", name, err)
+		}
+		var persons []_person
+		for rows.Next() {
+			var p _person
+			if err := ScanRow(rows, &p); err != nil {
+				t.Fatalf("%s failed: %s", name, err)
+			}
+			persons = append(persons, p)
+		}
+		rows.Close()
+		if !reflect.DeepEqual(persons, expected) {
+			t.Fatalf("%s failed: <%s> expected %#v but received %#v", name, query, expected, persons)
+		}
+	}
+
+	var p _person
+	doc := DocInfo{"id": "1", "name": "user1", "address": map[string]interface{}{"city": "HCM"}}
+	if err := doc.ToStruct(&p); err != nil || p.Key != "1" || p.Address.City != "HCM" {
+		t.Fatalf("%s failed: unexpected struct %#v (%s)", name, p, err)
+	}
+	if err := (DocInfo{"name": 1}).ToStruct(&p); err == nil {
+		t.Fatalf("%s failed: expected error for mismatched type", name)
+	}
+}
@@ -0,0 +1,145 @@
+package gocosmos
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/btnguyen2k/consu/gjrc"
+)
+
+// sessionTokens keeps the latest session token of each collection, so that requests observe the writes made before
+// them (session consistency).
+type sessionTokens struct {
+	lock   sync.RWMutex
+	tokens map[string]string // collection path (dbs/<db>/colls/<coll>) -> session token
+}
+
+func newSessionTokens() *sessionTokens {
+	return &sessionTokens{tokens: make(map[string]string)}
+}
+
+var reDocsPath = regexp.MustCompile(`^/?(dbs/[^/]+/colls/[^/]+)/docs(/|$)`)
+
+// _sessionCollPath returns the path of the collection targeted by a document request, "" if the request does not
+// target documents.
+func _sessionCollPath(req *http.Request) string {
+	if groups := reDocsPath.FindStringSubmatch(req.URL.Path); groups != nil {
+		return groups[1]
+	}
+	return ""
+}
+
+// apply sets the session token of the targeted collection to the request, unless the request already specifies one.
+func (s *sessionTokens) apply(req *http.Request) {
+	collPath := _sessionCollPath(req)
+	if collPath == "" || req.Header.Get("X-Ms-Session-Token") != "" {
+		return
+	}
+	s.lock.RLock()
+	token := s.tokens[collPath]
+	s.lock.RUnlock()
+	if token != "" {
+		req.Header.Set("X-Ms-Session-Token", token)
+	}
+}
+
+// update records the session token returned by the server for the targeted collection.
+func (s *sessionTokens) update(req *http.Request, resp *gjrc.GjrcResponse) {
+	if resp.Error() != nil {
+		return
+	}
+	collPath := _sessionCollPath(req)
+	token := resp.HttpResponse().Header.Get("X-Ms-Session-Token")
+	if collPath == "" || token == "" {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.tokens[collPath] = _mergeSessionTokens(s.tokens[collPath], token)
+}
+
+// _mergeSessionTokens merges the session token returned by the server into the known session token of a collection.
+// Session tokens are comma-separated lists of <pkrange-id>:<version>#<lsn>[#...] entries and a response only carries the
+// entries of the partition key ranges it touched, so entries are merged per partition key range, keeping the one with
+// the highest LSN. Tokens that cannot be parsed replace the known token.
+func _mergeSessionTokens(token, update string) string {
+	if token == "" || token == update {
+		return update
+	}
+	entries := make(map[string]string)
+	rangeIds := make([]string, 0)
+	for _, entry := range strings.Split(token+","+update, ",") {
+		colon := strings.Index(entry, ":")
+		if colon <= 0 || _sessionLsn(entry) < 0 {
+			return update
+		}
+		rangeId := entry[:colon]
+		if known, ok := entries[rangeId]; !ok {
+			rangeIds = append(rangeIds, rangeId)
+		} else if _sessionLsn(entry) < _sessionLsn(known) {
+			continue
+		}
+		entries[rangeId] = entry
+	}
+	merged := make([]string, len(rangeIds))
+	for i, rangeId := range rangeIds {
+		merged[i] = entries[rangeId]
+	}
+	return strings.Join(merged, ",")
+}
+
+// _sessionLsn returns the global LSN of a session token entry (<pkrange-id>:<version>#<lsn>[#...]), -1 if invalid.
+func _sessionLsn(entry string) int64 {
+	hash := strings.Index(entry, "#")
+	if hash < 0 {
+		return -1
+	}
+	lsn := entry[hash+1:]
+	if end := strings.IndexAny(lsn, "#="); end >= 0 {
+		lsn = lsn[:end]
+	}
+	n, err := strconv.ParseInt(lsn, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// sessionStores holds session token stores shared by connections, keyed by connection string (see SharedSessionTokens
+// connection string option).
+type sessionStores struct {
+	lock   sync.Mutex
+	stores map[string]*sessionTokens
+}
+
+// get returns the store shared by connections opened with the connection string, creating it if needed.
+func (s *sessionStores) get(connStr string) *sessionTokens {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.stores == nil {
+		s.stores = make(map[string]*sessionTokens)
+	}
+	store, ok := s.stores[connStr]
+	if !ok {
+		store = newSessionTokens()
+		s.stores[connStr] = store
+	}
+	return store
+}
+
+// get returns the session token of the collection, "" if not known.
+func (s *sessionTokens) get(dbName, collName string) string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.tokens["dbs/"+dbName+"/colls/"+collName]
+}
+
+// reset forgets all session tokens.
+func (s *sessionTokens) reset() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.tokens = make(map[string]string)
+}
@@ -0,0 +1,192 @@
+package gocosmos
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestConn_SessionTokens(t *testing.T) {
+	name := "TestConn_SessionTokens"
+	var lock sync.Mutex
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		received = append(received, r.URL.Path+"="+r.Header.Get("X-Ms-Session-Token"))
+		lock.Unlock()
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/dbs/mydb/colls/mycoll/docs"):
+			w.Header().Set("X-Ms-Session-Token", "0:1#"+r.Header.Get("X-Ms-Documentdb-Partitionkey"))
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"1"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":"NotFound","message":"Resource Not Found. ResourceType: Database"}`))
+		}
+	}))
+	defer server.Close()
+	dconn, err := (&Driver{}).Open("AccountEndpoint=" + server.URL + ";AccountKey=cHJpbWFyeQ==;DefaultDb=mydb")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	defer dconn.Close()
+	conn := dconn.(*Conn)
+	exec := func(query string, args ...driver.NamedValue) error {
+		stmt, err := conn.PrepareContext(context.Background(), query)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		_, err = stmt.(driver.StmtExecContext).ExecContext(context.Background(), args)
+		return err
+	}
+	args := []driver.NamedValue{{Ordinal: 1, Value: "1"}, {Ordinal: 2, Value: "1"}}
+
+	for i := 0; i < 2; i++ {
+		if err := exec("INSERT INTO mycoll (id) VALUES (:1)", args...); err != nil {
+			t.Fatalf("%s failed: %s", name, err)
+		}
+	}
+	if token := conn.restClient.sessions.get("mydb", "mycoll"); token != `0:1#["1"]` {
+		t.Fatalf("%s failed: unexpected session token %q", name, token)
+	}
+	if expected := `/dbs/mydb/colls/mycoll/docs=0:1#["1"]`; received[len(received)-1] != expected {
+		t.Fatalf("%s failed: expected request %s but received %s", name, expected, received[len(received)-1])
+	}
+
+	if !conn.IsValid() {
+		t.Fatalf("%s failed: connection must be valid", name)
+	}
+	if err := conn.ResetSession(context.Background()); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if token := conn.restClient.sessions.get("mydb", "mycoll"); token != "" {
+		t.Fatalf("%s failed: session token must be reset but received %q", name, token)
+	}
+	if err := exec("INSERT INTO mycoll (id) VALUES (:1)", args...); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if expected := "/dbs/mydb/colls/mycoll/docs="; received[len(received)-1] != expected {
+		t.Fatalf("%s failed: expected request %s but received %s", name, expected, received[len(received)-1])
+	}
+}
+
+func TestConn_IsValid(t *testing.T) {
+	name := "TestConn_IsValid"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/dbs/unauthorized":
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	for _, testCase := range []struct {
+		query string
+		valid bool
+	}{
+		{"DROP DATABASE notexist", true},
+		{"DROP DATABASE unauthorized", false},
+	} {
+		dconn, _ := (&Driver{}).Open("AccountEndpoint=" + server.URL + ";AccountKey=cHJpbWFyeQ==")
+		conn := dconn.(*Conn)
+		stmt, _ := conn.PrepareContext(context.Background(), testCase.query)
+		if _, err := stmt.(driver.StmtExecContext).ExecContext(context.Background(), nil); err == nil {
+			t.Fatalf("%s failed: <%s> expected error", name, testCase.query)
+		}
+		if conn.IsValid() != testCase.valid {
+			t.Fatalf("%s failed: <%s> expected IsValid=%v", name, testCase.query, testCase.valid)
+		}
+		if err := conn.ResetSession(context.Background()); (err == driver.ErrBadConn) == testCase.valid {
+			t.Fatalf("%s failed: <%s> unexpected ResetSession result %v", name, testCase.query, err)
+		}
+	}
+
+	dconn, _ := (&Driver{}).Open("AccountEndpoint=http://127.0.0.1:1;AccountKey=cHJpbWFyeQ==;TransientRetries=0")
+	conn := dconn.(*Conn)
+	stmt, _ := conn.PrepareContext(context.Background(), "DROP DATABASE mydb")
+	if _, err := stmt.(driver.StmtExecContext).ExecContext(context.Background(), nil); err == nil || conn.IsValid() {
+		t.Fatalf("%s failed: connection must be bad after transport error %s", name, err)
+	}
+}
+
+func Test_mergeSessionTokens(t *testing.T) {
+	name := "Test_mergeSessionTokens"
+	for _, testCase := range []struct{ token, update, expected string }{
+		{"", "0:1#10", "0:1#10"},
+		{"0:1#10", "0:1#12", "0:1#12"},
+		{"0:1#12", "0:1#10", "0:1#12"},
+		{"0:1#10", "1:1#5", "0:1#10,1:1#5"},
+		{"0:1#10,1:1#5", "1:-1#7#3=2", "0:1#10,1:-1#7#3=2"},
+		{"0:1#10", "invalid", "invalid"},
+	} {
+		if merged := _mergeSessionTokens(testCase.token, testCase.update); merged != testCase.expected {
+			t.Fatalf("%s failed: merging %q into %q expected %q but received %q", name, testCase.update, testCase.token, testCase.expected, merged)
+		}
+	}
+}
+
+func TestConn_SharedSessionTokens(t *testing.T) {
+	name := "TestConn_SharedSessionTokens"
+	var lock sync.Mutex
+	var lsn int
+	var readToken string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		if r.Method == http.MethodPost && r.Header.Get("X-Ms-Documentdb-Isquery") == "" {
+			lsn++
+			w.Header().Set("X-Ms-Session-Token", "0:1#"+strconv.Itoa(lsn))
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"1"}`))
+			return
+		}
+		readToken = r.Header.Get("X-Ms-Session-Token")
+		w.Write([]byte(`{"_count":1,"Documents":[{"id":"1"}]}`))
+	}))
+	defer server.Close()
+	token := func() (string, string) {
+		lock.Lock()
+		defer lock.Unlock()
+		return readToken, "0:1#" + strconv.Itoa(lsn)
+	}
+	ctx := context.Background()
+
+	dsn := "AccountEndpoint=" + server.URL + ";AccountKey=cHJpbWFyeQ==;DefaultDb=db"
+	connector, _ := NewConnectorFromConnStr(dsn + ";SharedSessionTokens=true")
+	for _, testCase := range []struct {
+		db     *sql.DB
+		shared bool
+	}{
+		{sql.OpenDB(connector), true},
+		{func() *sql.DB { db, _ := sql.Open("gocosmos", dsn+";SharedSessionTokens=true"); return db }(), true},
+		{func() *sql.DB { db, _ := sql.Open("gocosmos", dsn); return db }(), false},
+	} {
+		db := testCase.db
+		conn1, _ := db.Conn(ctx)
+		conn2, _ := db.Conn(ctx)
+		if _, err := conn1.ExecContext(ctx, "INSERT INTO coll (id) VALUES (:1)", "1", "1"); err != nil {
+			t.Fatalf("%s failed: %s", name, err)
+		}
+		rows, err := conn2.QueryContext(ctx, "SELECT * FROM c WITH collection=coll")
+		if err != nil {
+			t.Fatalf("%s failed: %s", name, err)
+		}
+		rows.Close()
+		if received, expected := token(); testCase.shared && received != expected {
+			t.Fatalf("%s failed: expected session token %q written by another connection but received %q", name, expected, received)
+		} else if !testCase.shared && received != "" {
+			t.Fatalf("%s failed: session tokens must not be shared by default, received %q", name, received)
+		}
+		conn1.Close()
+		conn2.Close()
+		db.Close()
+	}
+}
@@ -0,0 +1,114 @@
+package gocosmos
+
+import (
+	"sync"
+	"time"
+)
+
+// ClientStats holds cumulative statistics of the requests sent to Azure Cosmos DB, similar to sql.DBStats but specific
+// to Cosmos DB. See RestClient.Stats, Connector.Stats and Driver.Stats.
+//
+// Available since v0.1.1
+type ClientStats struct {
+	Requests            int64            // number of requests, retries of a request are not counted separately
+	RequestsByOperation map[string]int64 // number of requests per operation (see RequestMetrics.Operation)
+	Throttled           int64            // number of requests rejected with StatusCode=429
+	Failed              int64            // number of requests that received no response, e.g. timeouts
+	Retries             int64            // number of requests resent on transient network errors or after being throttled
+	RequestCharge       float64          // request units consumed
+	BytesSent           int64            // size of request bodies
+	BytesReceived       int64            // size of response bodies (after decompression)
+	TotalLatency        time.Duration    // total time of requests, including retries
+}
+
+// AverageLatency returns the average time of requests, 0 if no request has been sent.
+//
+// Available since v0.1.1
+func (s ClientStats) AverageLatency() time.Duration {
+	if s.Requests == 0 {
+		return 0
+	}
+	return s.TotalLatency / time.Duration(s.Requests)
+}
+
+// statsCollector accumulates ClientStats, shared by all clients of a Connector or Driver. The zero value is ready to use,
+// a nil collector discards stats.
+type statsCollector struct {
+	lock  sync.Mutex
+	stats ClientStats
+}
+
+// record accumulates the metrics of a request.
+func (s *statsCollector) record(m RequestMetrics, bytesSent, bytesReceived int64) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.stats.RequestsByOperation == nil {
+		s.stats.RequestsByOperation = make(map[string]int64)
+	}
+	s.stats.Requests++
+	s.stats.RequestsByOperation[m.Operation]++
+	if m.IsThrottled() {
+		s.stats.Throttled++
+	}
+	if m.Err != nil {
+		s.stats.Failed++
+	}
+	s.stats.RequestCharge += m.RequestCharge
+	if bytesSent > 0 {
+		s.stats.BytesSent += bytesSent
+	}
+	s.stats.BytesReceived += bytesReceived
+	s.stats.TotalLatency += m.Latency
+}
+
+// retried counts a resent request.
+func (s *statsCollector) retried() {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	s.stats.Retries++
+	s.lock.Unlock()
+}
+
+// snapshot returns a copy of the accumulated stats.
+func (s *statsCollector) snapshot() ClientStats {
+	if s == nil {
+		return ClientStats{RequestsByOperation: map[string]int64{}}
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	result := s.stats
+	result.RequestsByOperation = make(map[string]int64, len(s.stats.RequestsByOperation))
+	for op, n := range s.stats.RequestsByOperation {
+		result.RequestsByOperation[op] = n
+	}
+	return result
+}
+
+// Stats returns the statistics of the requests sent by the client. Clients of connections created by a Connector or the
+// Driver share the statistics of their Connector/Driver.
+//
+// Available since v0.1.1
+func (c *RestClient) Stats() ClientStats {
+	return c.stats.snapshot()
+}
+
+// Stats returns the statistics of the requests sent by all connections created by the connector.
+//
+// Available since v0.1.1
+func (c *Connector) Stats() ClientStats {
+	return c.stats.snapshot()
+}
+
+// Stats returns the statistics of the requests sent by all connections opened by the driver, i.e. by all sql.DB opened
+// with sql.Open("gocosmos", ...):
+//     stats := db.Driver().(*gocosmos.Driver).Stats()
+//
+// Available since v0.1.1
+func (d *Driver) Stats() ClientStats {
+	return d.stats.snapshot()
+}
@@ -0,0 +1,71 @@
+package gocosmos
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestConnector_Stats(t *testing.T) {
+	name := "TestConnector_Stats"
+	var failures, written int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Ms-Request-Charge", "2.5")
+		if r.Method == http.MethodPost && r.Header.Get("X-Ms-Documentdb-Isquery") == "" {
+			w.WriteHeader(http.StatusTooManyRequests)
+			n, _ := w.Write([]byte(`{"code":"TooManyRequests"}`))
+			atomic.AddInt32(&written, int32(n))
+			return
+		}
+		if atomic.AddInt32(&failures, -1) >= 0 {
+			// drop the connection in the middle of the response
+			w.Header().Set("Content-Length", "100")
+			w.Write([]byte(`{"id":`))
+			return
+		}
+		n, _ := w.Write([]byte(`{"_count":1,"Documents":[{"id":"1"}]}`))
+		atomic.AddInt32(&written, int32(n))
+	}))
+	defer server.Close()
+	connector, _ := NewConnectorFromConnStr("AccountEndpoint=" + server.URL + ";AccountKey=cHJpbWFyeQ==;DefaultDb=db")
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	atomic.StoreInt32(&failures, 1)
+	if rows, err := db.Query("SELECT * FROM c WITH collection=coll"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	} else {
+		rows.Close()
+	}
+	if _, err := db.Exec("INSERT INTO coll JSON :1", map[string]interface{}{"id": "1"}, "1"); err == nil {
+		t.Fatalf("%s failed: throttled insert must fail", name)
+	}
+	stats := connector.Stats()
+	if stats.Requests != 2 || stats.RequestsByOperation["Query"] != 1 || stats.RequestsByOperation["Create"] != 1 ||
+		stats.Throttled != 1 || stats.Retries != 1 || stats.Failed != 0 || stats.RequestCharge != 5 {
+		t.Fatalf("%s failed: unexpected stats %#v", name, stats)
+	}
+	if stats.BytesSent <= 0 || stats.BytesReceived != int64(atomic.LoadInt32(&written)) {
+		t.Fatalf("%s failed: unexpected bytes sent/received %d/%d", name, stats.BytesSent, stats.BytesReceived)
+	}
+	if stats.AverageLatency() <= 0 || stats.AverageLatency() != stats.TotalLatency/2 {
+		t.Fatalf("%s failed: unexpected average latency %s", name, stats.AverageLatency())
+	}
+	stats.RequestsByOperation["Query"] = 100
+	if connector.Stats().RequestsByOperation["Query"] != 1 {
+		t.Fatalf("%s failed: stats must be a snapshot", name)
+	}
+	if (ClientStats{}).AverageLatency() != 0 {
+		t.Fatalf("%s failed: average latency of no request must be 0", name)
+	}
+
+	drv := &Driver{}
+	conn, _ := drv.Open("AccountEndpoint=" + server.URL + ";AccountKey=cHJpbWFyeQ==")
+	defer conn.Close()
+	conn.(*Conn).restClient.ListDatabases()
+	if stats := drv.Stats(); stats.Requests != 1 || stats.RequestsByOperation["List"] != 1 {
+		t.Fatalf("%s failed: unexpected driver stats %#v", name, stats)
+	}
+}
@@ -1,32 +1,55 @@
 package gocosmos
 
 import (
+	"context"
 	"database/sql/driver"
+	"errors"
 	"fmt"
+	"net"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
 )
 
 const (
 	field       = `([\w\-]+)`
 	ifNotExists = `(\s+IF\s+NOT\s+EXISTS)?`
 	ifExists    = `(\s+IF\s+EXISTS)?`
-	with        = `((\s+WITH\s+([\w-]+)\s*=\s*([\w/\.,;:'"-]+))*)`
+	with        = `((\s+WITH\s+[\w-]+\s*=.*)?)`
 )
 
 var (
 	reCreateDb = regexp.MustCompile(`(?is)^CREATE\s+DATABASE` + ifNotExists + `\s+` + field + with + `$`)
+	reAlterDb  = regexp.MustCompile(`(?is)^ALTER\s+DATABASE\s+` + field + with + `$`)
 	reDropDb   = regexp.MustCompile(`(?is)^DROP\s+DATABASE` + ifExists + `\s+` + field + `$`)
 	reListDbs  = regexp.MustCompile(`(?is)^LIST\s+DATABASES?$`)
 
 	reCreateColl = regexp.MustCompile(`(?is)^CREATE\s+(COLLECTION|TABLE)` + ifNotExists + `\s+(` + field + `\.)?` + field + with + `$`)
+	reAlterColl  = regexp.MustCompile(`(?is)^ALTER\s+(COLLECTION|TABLE)\s+(` + field + `\.)?` + field + with + `$`)
 	reDropColl   = regexp.MustCompile(`(?is)^DROP\s+(COLLECTION|TABLE)` + ifExists + `\s+(` + field + `\.)?` + field + `$`)
 	reListColls  = regexp.MustCompile(`(?is)^LIST\s+(COLLECTIONS?|TABLES?)(\s+FROM\s+` + field + `)?$`)
 
-	reInsert = regexp.MustCompile(`(?is)^(INSERT|UPSERT)\s+INTO\s+(` + field + `\.)?` + field + `\s*\(([^)]*?)\)\s*VALUES\s*\(([^)]*?)\)$`)
-	reSelect = regexp.MustCompile(`(?is)^SELECT\s+(CROSS\s+PARTITION\s+)?.*?\s+FROM\s+` + field + `.*?` + with + `$`)
-	reUpdate = regexp.MustCompile(`(?is)^UPDATE\s+(` + field + `\.)?` + field + `\s+SET\s+(.*)\s+WHERE\s+id\s*=\s*(.*)$`)
-	reDelete = regexp.MustCompile(`(?is)^DELETE\s+FROM\s+(` + field + `\.)?` + field + `\s+WHERE\s+id\s*=\s*(.*)$`)
+	reShowThroughput = regexp.MustCompile(`(?is)^SHOW\s+(THROUGHPUT|OFFER)\s+(DATABASE|COLLECTION|TABLE)(\s+(` + field + `\.)?` + field + `)?$`)
+
+	reCreateUser = regexp.MustCompile(`(?is)^CREATE\s+USER` + ifNotExists + `\s+(` + field + `\.)?` + field + `$`)
+	reDropUser   = regexp.MustCompile(`(?is)^DROP\s+USER` + ifExists + `\s+(` + field + `\.)?` + field + `$`)
+	reListUsers  = regexp.MustCompile(`(?is)^LIST\s+USERS?(\s+FROM\s+` + field + `)?$`)
+	reGrant      = regexp.MustCompile(`(?is)^GRANT\s+(ALL|READ)\s+ON\s+(` + field + `\.)?` + field + `\s+TO\s+` + field + with + `$`)
+	reRevoke     = regexp.MustCompile(`(?is)^REVOKE(\s+(ALL|READ))?\s+ON\s+(` + field + `\.)?` + field + `\s+FROM\s+` + field + with + `$`)
+
+	reCreateSproc = regexp.MustCompile(`(?is)^CREATE\s+PROCEDURE` + ifNotExists + `\s+(` + field + `\.)?` + field + `\.` + field + `\s+AS\s+(.*)$`)
+	reAlterSproc  = regexp.MustCompile(`(?is)^ALTER\s+PROCEDURE\s+(` + field + `\.)?` + field + `\.` + field + `\s+AS\s+(.*)$`)
+	reDropSproc   = regexp.MustCompile(`(?is)^DROP\s+PROCEDURE` + ifExists + `\s+(` + field + `\.)?` + field + `\.` + field + `$`)
+	reCreateUdf   = regexp.MustCompile(`(?is)^CREATE\s+FUNCTION` + ifNotExists + `\s+(` + field + `\.)?` + field + `\.` + field + `\s+AS\s+(.*)$`)
+	reDropUdf     = regexp.MustCompile(`(?is)^DROP\s+FUNCTION` + ifExists + `\s+(` + field + `\.)?` + field + `\.` + field + `$`)
+	reCreateTrg   = regexp.MustCompile(`(?is)^CREATE\s+TRIGGER` + ifNotExists + `\s+(` + field + `\.)?` + field + `\.` + field + `((\s+WITH\s+[\w-]+\s*=\s*\S+)*)\s+AS\s+(.*)$`)
+	reDropTrg     = regexp.MustCompile(`(?is)^DROP\s+TRIGGER` + ifExists + `\s+(` + field + `\.)?` + field + `\.` + field + `$`)
+
+	reSelectChanges = regexp.MustCompile(`(?is)^SELECT\s+CHANGES\s+FROM\s+(` + field + `\.)?` + field + with + `$`)
+	reSelect        = regexp.MustCompile(`(?is)^SELECT\s+(CROSS\s+PARTITION\s+)?.*?\s+FROM\s+` + field + `.*?` + with + `$`)
+	reLoad          = regexp.MustCompile(`(?is)^LOAD\s+(DATA\s+)?("(\\"|[^"])*"|'[^']*'|[$@:]\d+)\s+INTO\s+(` + field + `\.)?` + field + with + `$`)
 )
 
 func parseQuery(c *Conn, query string) (driver.Stmt, error) {
@@ -34,7 +57,99 @@ func parseQuery(c *Conn, query string) (driver.Stmt, error) {
 }
 
 func parseQueryWithDefaultDb(c *Conn, defaultDb, query string) (driver.Stmt, error) {
-	query = strings.TrimSpace(query)
+	query, err := _numberPlaceholders(strings.TrimSpace(_stripComments(query)))
+	if err != nil {
+		return nil, err
+	}
+	query, timeout, err := _extractTimeout(query)
+	if err != nil {
+		return nil, err
+	}
+	stmt, err := _parseQuery(c, defaultDb, query)
+	if err != nil || timeout <= 0 {
+		return stmt, err
+	}
+	return &StmtWithTimeout{stmt: stmt, timeout: timeout}, nil
+}
+
+// _parseDml builds the statement of a data-manipulation query (INSERT, UPSERT, UPDATE or DELETE).
+func _parseDml(c *Conn, defaultDb, query string) (driver.Stmt, error) {
+	ast, err := parseDml(query)
+	if err != nil {
+		return nil, err
+	}
+	if ast.dbName == "" {
+		ast.dbName = defaultDb
+	}
+	var stmt interface {
+		driver.Stmt
+		parse() error
+		validate() error
+	}
+	switch {
+	case ast.selectStr != "":
+		stmt = &StmtInsertSelect{
+			Stmt:      &Stmt{query: query, conn: c, numInput: 0},
+			isUpsert:  ast.verb == "UPSERT",
+			dbName:    ast.dbName,
+			collName:  ast.collName,
+			fields:    ast.fields,
+			selectStr: ast.selectStr,
+			ignore:    ast.ignore,
+		}
+	case ast.verb == "INSERT" || ast.verb == "UPSERT":
+		stmt = &StmtInsert{
+			Stmt:        &Stmt{query: query, conn: c, numInput: 0},
+			isUpsert:    ast.verb == "UPSERT",
+			dbName:      ast.dbName,
+			collName:    ast.collName,
+			fields:      ast.fields,
+			values:      ast.values,
+			withOptsStr: ast.withOptsStr,
+			isDocument:  ast.isDocument,
+			document:    ast.document,
+			returning:   ast.returning,
+			ignore:      ast.ignore,
+		}
+	case ast.verb == "UPDATE":
+		stmt = &StmtUpdate{
+			Stmt:        &Stmt{query: query, conn: c, numInput: 0},
+			dbName:      ast.dbName,
+			collName:    ast.collName,
+			fields:      ast.fields,
+			values:      ast.values,
+			idStr:       ast.idStr,
+			etag:        ast.etag,
+			withOptsStr: ast.withOptsStr,
+			orInsert:    ast.orInsert,
+			returning:   ast.returning,
+		}
+	case ast.deleteAll:
+		stmt = &StmtDeleteByPk{
+			Stmt:        &Stmt{query: query, conn: c, numInput: 0},
+			dbName:      ast.dbName,
+			collName:    ast.collName,
+			pkValue:     ast.pkValue,
+			withOptsStr: ast.withOptsStr,
+		}
+	default:
+		stmt = &StmtDelete{
+			Stmt:        &Stmt{query: query, conn: c, numInput: 0},
+			dbName:      ast.dbName,
+			collName:    ast.collName,
+			idStr:       ast.idStr,
+			etag:        ast.etag,
+			withOptsStr: ast.withOptsStr,
+			returning:   ast.returning,
+		}
+	}
+	if err := stmt.parse(); err != nil {
+		return nil, err
+	}
+	return stmt, stmt.validate()
+}
+
+func _parseQuery(c *Conn, defaultDb, query string) (driver.Stmt, error) {
 	if re := reCreateDb; re.MatchString(query) {
 		groups := re.FindAllStringSubmatch(query, -1)
 		stmt := &StmtCreateDatabase{
@@ -48,6 +163,18 @@ func parseQueryWithDefaultDb(c *Conn, defaultDb, query string) (driver.Stmt, err
 		}
 		return stmt, stmt.validate()
 	}
+	if re := reAlterDb; re.MatchString(query) {
+		groups := re.FindAllStringSubmatch(query, -1)
+		stmt := &StmtAlterDatabase{
+			Stmt:        &Stmt{query: query, conn: c, numInput: 0},
+			dbName:      strings.TrimSpace(groups[0][1]),
+			withOptsStr: strings.TrimSpace(groups[0][2]),
+		}
+		if err := stmt.parse(); err != nil {
+			return nil, err
+		}
+		return stmt, stmt.validate()
+	}
 	if re := reDropDb; re.MatchString(query) {
 		groups := re.FindAllStringSubmatch(query, -1)
 		stmt := &StmtDropDatabase{
@@ -81,6 +208,22 @@ func parseQueryWithDefaultDb(c *Conn, defaultDb, query string) (driver.Stmt, err
 		}
 		return stmt, stmt.validate()
 	}
+	if re := reAlterColl; re.MatchString(query) {
+		groups := re.FindAllStringSubmatch(query, -1)
+		stmt := &StmtAlterCollection{
+			Stmt:        &Stmt{query: query, conn: c, numInput: 0},
+			dbName:      strings.TrimSpace(groups[0][3]),
+			collName:    strings.TrimSpace(groups[0][4]),
+			withOptsStr: strings.TrimSpace(groups[0][5]),
+		}
+		if stmt.dbName == "" {
+			stmt.dbName = defaultDb
+		}
+		if err := stmt.parse(); err != nil {
+			return nil, err
+		}
+		return stmt, stmt.validate()
+	}
 	if re := reDropColl; re.MatchString(query) {
 		groups := re.FindAllStringSubmatch(query, -1)
 		stmt := &StmtDropCollection{
@@ -106,15 +249,78 @@ func parseQueryWithDefaultDb(c *Conn, defaultDb, query string) (driver.Stmt, err
 		return stmt, stmt.validate()
 	}
 
-	if re := reInsert; re.MatchString(query) {
+	if re := reShowThroughput; re.MatchString(query) {
 		groups := re.FindAllStringSubmatch(query, -1)
-		stmt := &StmtInsert{
-			Stmt:      &Stmt{query: query, conn: c, numInput: 0},
-			isUpsert:  strings.ToUpper(strings.TrimSpace(groups[0][1])) == "UPSERT",
-			dbName:    strings.TrimSpace(groups[0][3]),
-			collName:  strings.TrimSpace(groups[0][4]),
-			fieldsStr: strings.TrimSpace(groups[0][5]),
-			valuesStr: strings.TrimSpace(groups[0][6]),
+		stmt := &StmtShowThroughput{
+			Stmt:   &Stmt{query: query, conn: c, numInput: 0},
+			dbName: strings.TrimSpace(groups[0][5]),
+		}
+		if strings.EqualFold(groups[0][2], "DATABASE") {
+			if stmt.dbName != "" {
+				return nil, fmt.Errorf("invalid database name: %s", strings.TrimSpace(groups[0][3]))
+			}
+			stmt.dbName = strings.TrimSpace(groups[0][6])
+		} else {
+			stmt.collName = strings.TrimSpace(groups[0][6])
+			if stmt.collName == "" {
+				return nil, errors.New("collection is missing")
+			}
+		}
+		if stmt.dbName == "" {
+			stmt.dbName = defaultDb
+		}
+		return stmt, stmt.validate()
+	}
+
+	if re := reCreateUser; re.MatchString(query) {
+		groups := re.FindAllStringSubmatch(query, -1)
+		stmt := &StmtCreateUser{
+			Stmt:        &Stmt{query: query, conn: c, numInput: 0},
+			ifNotExists: strings.TrimSpace(groups[0][1]) != "",
+			dbName:      strings.TrimSpace(groups[0][3]),
+			userId:      strings.TrimSpace(groups[0][4]),
+		}
+		if stmt.dbName == "" {
+			stmt.dbName = defaultDb
+		}
+		return stmt, stmt.validate()
+	}
+	if re := reDropUser; re.MatchString(query) {
+		groups := re.FindAllStringSubmatch(query, -1)
+		stmt := &StmtDropUser{
+			Stmt:     &Stmt{query: query, conn: c, numInput: 0},
+			ifExists: strings.TrimSpace(groups[0][1]) != "",
+			dbName:   strings.TrimSpace(groups[0][3]),
+			userId:   strings.TrimSpace(groups[0][4]),
+		}
+		if stmt.dbName == "" {
+			stmt.dbName = defaultDb
+		}
+		return stmt, stmt.validate()
+	}
+	if re := reListUsers; re.MatchString(query) {
+		groups := re.FindAllStringSubmatch(query, -1)
+		stmt := &StmtListUsers{
+			Stmt:   &Stmt{query: query, conn: c, numInput: 0},
+			dbName: strings.TrimSpace(groups[0][2]),
+		}
+		if stmt.dbName == "" {
+			stmt.dbName = defaultDb
+		}
+		return stmt, stmt.validate()
+	}
+	if re := reGrant; re.MatchString(query) {
+		groups := re.FindAllStringSubmatch(query, -1)
+		stmt := &StmtGrant{
+			Stmt:        &Stmt{query: query, conn: c, numInput: 0},
+			mode:        "All",
+			dbName:      strings.TrimSpace(groups[0][3]),
+			collName:    strings.TrimSpace(groups[0][4]),
+			userId:      strings.TrimSpace(groups[0][5]),
+			withOptsStr: strings.TrimSpace(groups[0][6]),
+		}
+		if strings.EqualFold(groups[0][1], "READ") {
+			stmt.mode = "Read"
 		}
 		if stmt.dbName == "" {
 			stmt.dbName = defaultDb
@@ -124,28 +330,33 @@ func parseQueryWithDefaultDb(c *Conn, defaultDb, query string) (driver.Stmt, err
 		}
 		return stmt, stmt.validate()
 	}
-	if re := reSelect; re.MatchString(query) {
+	if re := reRevoke; re.MatchString(query) {
 		groups := re.FindAllStringSubmatch(query, -1)
-		stmt := &StmtSelect{
-			Stmt:             &Stmt{query: query, conn: c, numInput: 0},
-			isCrossPartition: strings.TrimSpace(groups[0][1]) != "",
-			collName:         strings.TrimSpace(groups[0][2]),
-			dbName:           defaultDb,
-			selectQuery:      strings.ReplaceAll(strings.ReplaceAll(query, groups[0][1], ""), groups[0][3], ""),
+		stmt := &StmtRevoke{
+			Stmt:        &Stmt{query: query, conn: c, numInput: 0},
+			dbName:      strings.TrimSpace(groups[0][4]),
+			collName:    strings.TrimSpace(groups[0][5]),
+			userId:      strings.TrimSpace(groups[0][6]),
+			withOptsStr: strings.TrimSpace(groups[0][7]),
+		}
+		if stmt.dbName == "" {
+			stmt.dbName = defaultDb
 		}
-		if err := stmt.parse(groups[0][3]); err != nil {
+		if err := stmt.parse(); err != nil {
 			return nil, err
 		}
 		return stmt, stmt.validate()
 	}
-	if re := reUpdate; re.MatchString(query) {
+
+	if re := reCreateSproc; re.MatchString(query) {
 		groups := re.FindAllStringSubmatch(query, -1)
-		stmt := &StmtUpdate{
-			Stmt:      &Stmt{query: query, conn: c, numInput: 0},
-			dbName:    strings.TrimSpace(groups[0][2]),
-			collName:  strings.TrimSpace(groups[0][3]),
-			updateStr: strings.TrimSpace(groups[0][4]),
-			idStr:     strings.TrimSpace(groups[0][5]),
+		stmt := &StmtCreateProcedure{
+			Stmt:        &Stmt{query: query, conn: c, numInput: 0},
+			ifNotExists: strings.TrimSpace(groups[0][1]) != "",
+			dbName:      strings.TrimSpace(groups[0][3]),
+			collName:    strings.TrimSpace(groups[0][4]),
+			sprocId:     strings.TrimSpace(groups[0][5]),
+			bodyStr:     strings.TrimSpace(groups[0][6]),
 		}
 		if stmt.dbName == "" {
 			stmt.dbName = defaultDb
@@ -155,13 +366,147 @@ func parseQueryWithDefaultDb(c *Conn, defaultDb, query string) (driver.Stmt, err
 		}
 		return stmt, stmt.validate()
 	}
-	if re := reDelete; re.MatchString(query) {
+	if re := reAlterSproc; re.MatchString(query) {
 		groups := re.FindAllStringSubmatch(query, -1)
-		stmt := &StmtDelete{
+		stmt := &StmtAlterProcedure{
 			Stmt:     &Stmt{query: query, conn: c, numInput: 0},
 			dbName:   strings.TrimSpace(groups[0][2]),
 			collName: strings.TrimSpace(groups[0][3]),
-			idStr:    strings.TrimSpace(groups[0][4]),
+			sprocId:  strings.TrimSpace(groups[0][4]),
+			bodyStr:  strings.TrimSpace(groups[0][5]),
+		}
+		if stmt.dbName == "" {
+			stmt.dbName = defaultDb
+		}
+		if err := stmt.parse(); err != nil {
+			return nil, err
+		}
+		return stmt, stmt.validate()
+	}
+	if re := reDropSproc; re.MatchString(query) {
+		groups := re.FindAllStringSubmatch(query, -1)
+		stmt := &StmtDropProcedure{
+			Stmt:     &Stmt{query: query, conn: c, numInput: 0},
+			ifExists: strings.TrimSpace(groups[0][1]) != "",
+			dbName:   strings.TrimSpace(groups[0][3]),
+			collName: strings.TrimSpace(groups[0][4]),
+			sprocId:  strings.TrimSpace(groups[0][5]),
+		}
+		if stmt.dbName == "" {
+			stmt.dbName = defaultDb
+		}
+		return stmt, stmt.validate()
+	}
+
+	if re := reCreateUdf; re.MatchString(query) {
+		groups := re.FindAllStringSubmatch(query, -1)
+		stmt := &StmtCreateFunction{
+			Stmt:        &Stmt{query: query, conn: c, numInput: 0},
+			ifNotExists: strings.TrimSpace(groups[0][1]) != "",
+			dbName:      strings.TrimSpace(groups[0][3]),
+			collName:    strings.TrimSpace(groups[0][4]),
+			udfId:       strings.TrimSpace(groups[0][5]),
+			bodyStr:     strings.TrimSpace(groups[0][6]),
+		}
+		if stmt.dbName == "" {
+			stmt.dbName = defaultDb
+		}
+		if err := stmt.parse(); err != nil {
+			return nil, err
+		}
+		return stmt, stmt.validate()
+	}
+	if re := reDropUdf; re.MatchString(query) {
+		groups := re.FindAllStringSubmatch(query, -1)
+		stmt := &StmtDropFunction{
+			Stmt:     &Stmt{query: query, conn: c, numInput: 0},
+			ifExists: strings.TrimSpace(groups[0][1]) != "",
+			dbName:   strings.TrimSpace(groups[0][3]),
+			collName: strings.TrimSpace(groups[0][4]),
+			udfId:    strings.TrimSpace(groups[0][5]),
+		}
+		if stmt.dbName == "" {
+			stmt.dbName = defaultDb
+		}
+		return stmt, stmt.validate()
+	}
+
+	if re := reCreateTrg; re.MatchString(query) {
+		groups := re.FindAllStringSubmatch(query, -1)
+		stmt := &StmtCreateTrigger{
+			Stmt:        &Stmt{query: query, conn: c, numInput: 0},
+			ifNotExists: strings.TrimSpace(groups[0][1]) != "",
+			dbName:      strings.TrimSpace(groups[0][3]),
+			collName:    strings.TrimSpace(groups[0][4]),
+			triggerId:   strings.TrimSpace(groups[0][5]),
+			withOptsStr: strings.TrimSpace(groups[0][6]),
+			bodyStr:     strings.TrimSpace(groups[0][8]),
+		}
+		if stmt.dbName == "" {
+			stmt.dbName = defaultDb
+		}
+		if err := stmt.parse(); err != nil {
+			return nil, err
+		}
+		return stmt, stmt.validate()
+	}
+	if re := reDropTrg; re.MatchString(query) {
+		groups := re.FindAllStringSubmatch(query, -1)
+		stmt := &StmtDropTrigger{
+			Stmt:      &Stmt{query: query, conn: c, numInput: 0},
+			ifExists:  strings.TrimSpace(groups[0][1]) != "",
+			dbName:    strings.TrimSpace(groups[0][3]),
+			collName:  strings.TrimSpace(groups[0][4]),
+			triggerId: strings.TrimSpace(groups[0][5]),
+		}
+		if stmt.dbName == "" {
+			stmt.dbName = defaultDb
+		}
+		return stmt, stmt.validate()
+	}
+
+	if _isDml(query) {
+		return _parseDml(c, defaultDb, query)
+	}
+	if re := reSelectChanges; re.MatchString(query) {
+		groups := re.FindAllStringSubmatch(query, -1)
+		stmt := &StmtSelectChanges{
+			Stmt:        &Stmt{query: query, conn: c, numInput: 0},
+			dbName:      strings.TrimSpace(groups[0][2]),
+			collName:    strings.TrimSpace(groups[0][3]),
+			withOptsStr: strings.TrimSpace(groups[0][4]),
+		}
+		if stmt.dbName == "" {
+			stmt.dbName = defaultDb
+		}
+		if err := stmt.parse(); err != nil {
+			return nil, err
+		}
+		return stmt, stmt.validate()
+	}
+	if re := reSelect; re.MatchString(query) {
+		groups := re.FindAllStringSubmatch(query, -1)
+		selectQuery, withOptsStr := _splitWithClause(query)
+		stmt := &StmtSelect{
+			Stmt:             &Stmt{query: query, conn: c, numInput: 0},
+			isCrossPartition: strings.TrimSpace(groups[0][1]) != "",
+			collName:         strings.TrimSpace(groups[0][2]),
+			dbName:           defaultDb,
+			selectQuery:      strings.ReplaceAll(selectQuery, groups[0][1], ""),
+		}
+		if err := stmt.parse(withOptsStr); err != nil {
+			return nil, err
+		}
+		return stmt, stmt.validate()
+	}
+	if re := reLoad; re.MatchString(query) {
+		groups := re.FindAllStringSubmatch(query, -1)
+		stmt := &StmtLoad{
+			Stmt:        &Stmt{query: query, conn: c, numInput: 0},
+			pathStr:     strings.TrimSpace(groups[0][2]),
+			dbName:      strings.TrimSpace(groups[0][5]),
+			collName:    strings.TrimSpace(groups[0][6]),
+			withOptsStr: strings.TrimSpace(groups[0][7]),
 		}
 		if stmt.dbName == "" {
 			stmt.dbName = defaultDb
@@ -181,19 +526,121 @@ type Stmt struct {
 	conn     *Conn  // the connection that this prepared statement is bound to
 	numInput int    // number of placeholder parameters
 	withOpts map[string]string
+
+	headers map[string]string // (since v0.1.1) custom request headers specified via "WITH header.<name>=<value>", nil if none
 }
 
-var reWithOpt = regexp.MustCompile(`(?i)WITH\s+([\w-]+)\s*=\s*([\w/\.,;:'"-]+)`)
+// isAutoPk returns true if partition key value is derived from document data instead of the last argument (see DSN option
+// AutoPartitionKey).
+func (s *Stmt) isAutoPk() bool {
+	return s.conn != nil && s.conn.autoPk
+}
+
+// pkArgs splits the arguments of a statement whose partition key value is supplied as the last argument (numInput
+// includes it) into the arguments bound to value placeholders and the partition key values.
+//
+// Non-partitioned collections (e.g. legacy collections created without partition key) have no partition key value: it
+// is supplied as nil (database/sql checks the number of arguments against NumInput), or omitted if the statement is
+// executed directly. The collection's partition key paths are then looked up (and cached) to tell a non-partitioned
+// collection from a null partition key value.
+func (s *Stmt) pkArgs(dbName, collName string, args []driver.Value) ([]driver.Value, []interface{}, error) {
+	if len(args) == s.numInput && args[len(args)-1] != nil {
+		return args[:len(args)-1], _pkValues(args[len(args)-1]), nil
+	}
+	if len(args) == s.numInput || len(args) == s.numInput-1 {
+		pkPaths, err := s.conn.restClient.getPkPaths(dbName, collName)
+		if err != nil {
+			return nil, nil, err
+		}
+		valueArgs := args[:s.numInput-1]
+		if len(pkPaths) == 0 {
+			return valueArgs, _nonePkValues(), nil
+		}
+		if len(args) == s.numInput {
+			return valueArgs, _pkValues(nil), nil
+		}
+	}
+	return nil, nil, fmt.Errorf("expected %d arguments (the last one is the partition key value), got %d", s.numInput, len(args))
+}
+
+var reWithOpt = regexp.MustCompile(`(?is)^WITH\s+([\w.-]+)\s*=\s*`)
 
 // parseWithOpts parses "WITH..." clause and store result in withOpts map.
-// This function returns no error. Sub-implementations may override this behavior.
+//
+// Each option is in format "WITH <key>=<value>"; <value> is either a JSON object/array literal (e.g. {"key":"value"}),
+// a quoted string including the quotes (e.g. "a b" or 'a b') or a sequence of non-space characters.
+// Sub-implementations may override this behavior.
+//
+// (since v0.1.1) Options "WITH header.<name>=<value>" specify custom headers of the statement's requests, see WithHeaders.
+// Option "WITH session_token=<token>" specifies the session token of the statement's requests, see WithSessionToken.
 func (s *Stmt) parseWithOpts(withOptsStr string) error {
 	s.withOpts = make(map[string]string)
-	tokens := reWithOpt.FindAllStringSubmatch(withOptsStr, -1)
-	for _, token := range tokens {
-		s.withOpts[strings.TrimSpace(strings.ToUpper(token[1]))] = strings.TrimSpace(token[2])
+	for temp := strings.TrimSpace(withOptsStr); temp != ""; temp = strings.TrimSpace(temp) {
+		loc := reWithOpt.FindStringSubmatchIndex(temp)
+		if loc == nil {
+			return errors.New("cannot parse query, invalid token at: " + temp)
+		}
+		key := strings.ToUpper(temp[loc[2]:loc[3]])
+		value, leftOver, err := _parseWithOptValue(temp[loc[1]:])
+		if err != nil {
+			return err
+		}
+		s.withOpts[key] = value
+		temp = leftOver
 	}
-	return nil
+	var err error
+	s.headers, err = _parseHeaderOpts(s.withOpts)
+	return err
+}
+
+// _parseWithOptValue extracts the value part of a "WITH <key>=<value>" option.
+func _parseWithOptValue(input string) (value string, leftOver string, err error) {
+	if input == "" || _isSpace(rune(input[0])) {
+		return "", input, errors.New("cannot parse query, missing value at: " + input)
+	}
+	if input[0] == '"' || input[0] == '\'' {
+		end := _skipLiteral(input, 0)
+		if end >= len(input) {
+			return "", input, errors.New("cannot parse query, unterminated string literal at: " + input)
+		}
+		return input[:end+1], input[end+1:], nil
+	}
+	if input[0] == '{' || input[0] == '[' {
+		if end := _scanJsonLiteral(input); end > 0 {
+			return input[:end], input[end:], nil
+		}
+		return "", input, errors.New("cannot parse query, invalid JSON literal at: " + input)
+	}
+	end := strings.IndexFunc(input, unicode.IsSpace)
+	if end < 0 {
+		end = len(input)
+	}
+	return input[:end], input[end:], nil
+}
+
+// _scanJsonLiteral returns the length of the JSON object/array literal at the beginning of the input, or -1 if the
+// literal is not terminated.
+func _scanJsonLiteral(input string) int {
+	depth, inString, escaped := 0, false, false
+	for i, r := range input {
+		switch {
+		case escaped:
+			escaped = false
+		case inString && r == '\\':
+			escaped = true
+		case r == '"':
+			inString = !inString
+		case inString:
+		case r == '{' || r == '[':
+			depth++
+		case r == '}' || r == ']':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return -1
 }
 
 // // validateWithOpts is no-op in this struct. Sub-implementations may override this behavior.
@@ -201,6 +648,41 @@ func (s *Stmt) parseWithOpts(withOptsStr string) error {
 // 	return nil
 // }
 
+// baseStmt returns the base statement.
+func (s *Stmt) baseStmt() *Stmt {
+	return s
+}
+
+// withConn returns a copy of the base statement bound to conn.
+func (s *Stmt) withConn(conn *Conn) *Stmt {
+	base := *s
+	base.conn = conn
+	return &base
+}
+
+// rebinder is implemented by every statement type, including the ones that wrap another statement (e.g.
+// StmtWithTimeout), see _rebindStmt.
+type rebinder interface {
+	// baseStmt returns the base statement.
+	baseStmt() *Stmt
+	// rebind returns a copy of the statement bound to conn.
+	rebind(conn *Conn) driver.Stmt
+}
+
+// _rebindStmt returns a copy of the statement that is bound to the connection returned by bind, which is called with
+// the base statement. The statement itself is left untouched: it may be shared by concurrent calls (see stmtCache),
+// and the rows returned by a call keep using the connection the copy is bound to.
+func _rebindStmt(stmt driver.Stmt, bind func(base *Stmt) *Conn) driver.Stmt {
+	r, ok := stmt.(rebinder)
+	if !ok {
+		return stmt
+	}
+	if base := r.baseStmt(); base != nil && base.conn != nil {
+		return r.rebind(bind(base))
+	}
+	return stmt
+}
+
 // Close implements driver.Stmt.Close.
 func (s *Stmt) Close() error {
 	return nil
@@ -210,3 +692,117 @@ func (s *Stmt) Close() error {
 func (s *Stmt) NumInput() int {
 	return s.numInput
 }
+
+/*----------------------------------------------------------------------*/
+
+var reWithTimeout = regexp.MustCompile(`(?is)^(.*?)\s+WITH\s+TIMEOUT\s*=\s*(\S+)$`)
+
+// _extractTimeout extracts the trailing "WITH TIMEOUT=<duration>" option from the query.
+//
+// <duration> is a Go duration string (e.g. 500ms, 5s or 1m); a plain number is treated as number of seconds.
+func _extractTimeout(query string) (string, time.Duration, error) {
+	groups := reWithTimeout.FindStringSubmatch(query)
+	if groups == nil || _inLiteral(query, len(groups[1])) {
+		return query, 0, nil
+	}
+	timeout, err := time.ParseDuration(groups[2])
+	if err != nil {
+		if seconds, e := strconv.ParseFloat(groups[2], 64); e == nil {
+			timeout, err = time.Duration(seconds*float64(time.Second)), nil
+		}
+	}
+	if err != nil || timeout <= 0 {
+		return query, 0, fmt.Errorf("invalid TIMEOUT value: %s", groups[2])
+	}
+	return strings.TrimSpace(groups[1]), timeout, nil
+}
+
+// StmtWithTimeout wraps a statement that has "WITH TIMEOUT=<duration>" option.
+//
+// The timeout bounds the total time of the operation, including retries and pagination. An error matching ErrTimeout
+// (wrapping the underlying error) is returned if the operation does not complete in time.
+//
+// Syntax:
+//     <statement> WITH TIMEOUT=<duration>
+//
+// - TIMEOUT must be the last option of the statement.
+//
+// - <duration> is a Go duration string (e.g. 500ms, 5s or 1m); a plain number is treated as number of seconds.
+//
+// Available since v0.1.1
+type StmtWithTimeout struct {
+	stmt    driver.Stmt
+	timeout time.Duration
+}
+
+// Stmt returns the wrapped statement.
+func (s *StmtWithTimeout) Stmt() driver.Stmt {
+	return s.stmt
+}
+
+// Timeout returns the timeout of the statement.
+func (s *StmtWithTimeout) Timeout() time.Duration {
+	return s.timeout
+}
+
+// Close implements driver.Stmt.Close.
+func (s *StmtWithTimeout) Close() error {
+	return s.stmt.Close()
+}
+
+// NumInput implements driver.Stmt.NumInput.
+func (s *StmtWithTimeout) NumInput() int {
+	return s.stmt.NumInput()
+}
+
+// baseStmt returns the base statement of the wrapped statement.
+func (s *StmtWithTimeout) baseStmt() *Stmt {
+	return s.stmt.(rebinder).baseStmt()
+}
+
+// rebind returns a copy of the statement whose wrapped statement is bound to conn, see _rebindStmt.
+func (s *StmtWithTimeout) rebind(conn *Conn) driver.Stmt {
+	return &StmtWithTimeout{stmt: s.stmt.(rebinder).rebind(conn), timeout: s.timeout}
+}
+
+// withDeadline returns a copy of the wrapped statement bound to a connection whose REST client enforces the deadline.
+func (s *StmtWithTimeout) withDeadline(deadline time.Time) driver.Stmt {
+	return _rebindStmt(s.stmt, func(base *Stmt) *Conn {
+		boundConn := *base.conn
+		boundConn.restClient = base.conn.restClient.withDeadline(deadline)
+		return &boundConn
+	})
+}
+
+// _timeoutError returns ErrTimeout (wrapping err) if err is caused by the deadline being exceeded, err otherwise: errors
+// returned by the server (e.g. a conflict that happens to complete late) are kept as-is.
+func _timeoutError(err error, deadline time.Time) error {
+	var netErr net.Error
+	if err == nil || time.Now().Before(deadline) || errors.Is(err, ErrTimeout) {
+		return err
+	}
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return fmt.Errorf("%w: %s", ErrTimeout, err)
+	}
+	return err
+}
+
+// Exec implements driver.Stmt.Exec.
+func (s *StmtWithTimeout) Exec(args []driver.Value) (driver.Result, error) {
+	deadline := time.Now().Add(s.timeout)
+	result, err := s.withDeadline(deadline).Exec(args)
+	if err = _timeoutError(err, deadline); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Query implements driver.Stmt.Query.
+func (s *StmtWithTimeout) Query(args []driver.Value) (driver.Rows, error) {
+	deadline := time.Now().Add(s.timeout)
+	rows, err := s.withDeadline(deadline).Query(args)
+	if err = _timeoutError(err, deadline); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
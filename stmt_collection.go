@@ -2,35 +2,211 @@ package gocosmos
 
 import (
 	"database/sql/driver"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"regexp"
 	"strconv"
+	"strings"
 )
 
+// _parseIndexingPolicy builds the collection's indexing policy from the "WITH..." options.
+//
+// - INDEXING: the full indexing policy as a JSON literal, e.g. WITH indexing={"indexingMode":"consistent","automatic":true}
+//
+// - INDEXING_MODE: one of consistent, lazy or none. Mode "none" turns off automatic indexing.
+//
+// - INCLUDE/EXCLUDE: comma-separated list of included/excluded paths, e.g. WITH include=/name/?,/age/? WITH exclude=/*
+//
+// - COMPOSITE: composite indexes separated by semi-colons (;), each composite index is a comma-separated list of
+// paths with optional sort order (default "asc"), e.g. WITH composite=/name,/age:desc;/city:asc,/zip:desc
+//
+// - SPATIAL: spatial indexes separated by semi-colons (;), each spatial index is a path with optional comma-separated
+// list of spatial types (default: all types), e.g. WITH spatial=/location/*:point;/area/*:polygon,multipolygon
+//
+// Options INDEXING_MODE, INCLUDE, EXCLUDE, COMPOSITE and SPATIAL override the corresponding settings from INDEXING.
+// This function returns nil policy if none of the options are specified.
+func _parseIndexingPolicy(withOpts map[string]string) (map[string]interface{}, error) {
+	var policy map[string]interface{}
+	if v, ok := withOpts["INDEXING"]; ok {
+		if err := json.Unmarshal([]byte(v), &policy); err != nil || policy == nil {
+			return nil, fmt.Errorf("invalid INDEXING value: %s", v)
+		}
+	}
+	setPolicy := func(key string, value interface{}) {
+		if policy == nil {
+			policy = make(map[string]interface{})
+		}
+		policy[key] = value
+	}
+	if v, ok := withOpts["INDEXING_MODE"]; ok {
+		mode := strings.ToLower(v)
+		if mode != "consistent" && mode != "lazy" && mode != "none" {
+			return nil, fmt.Errorf("invalid INDEXING_MODE value: %s", v)
+		}
+		setPolicy("indexingMode", mode)
+		setPolicy("automatic", mode != "none")
+	}
+	for _, opt := range []struct{ key, policyKey string }{{"INCLUDE", "includedPaths"}, {"EXCLUDE", "excludedPaths"}} {
+		if v, ok := withOpts[opt.key]; ok {
+			paths := make([]interface{}, 0)
+			for _, path := range regexp.MustCompile(`[,\s]+`).Split(v, -1) {
+				if path != "" {
+					paths = append(paths, map[string]interface{}{"path": path})
+				}
+			}
+			if len(paths) == 0 {
+				return nil, fmt.Errorf("invalid %s value: %s", opt.key, v)
+			}
+			setPolicy(opt.policyKey, paths)
+		}
+	}
+	if v, ok := withOpts["COMPOSITE"]; ok {
+		compositeIndexes := make([]interface{}, 0)
+		for _, token := range strings.Split(v, ";") {
+			compositeIndex := make([]interface{}, 0)
+			for _, path := range strings.Split(token, ",") {
+				tokens := strings.SplitN(strings.TrimSpace(path), ":", 2)
+				if tokens[0] == "" {
+					continue
+				}
+				order := "ascending"
+				if len(tokens) > 1 {
+					switch strings.ToLower(tokens[1]) {
+					case "asc", "ascending":
+					case "desc", "descending":
+						order = "descending"
+					default:
+						return nil, fmt.Errorf("invalid COMPOSITE value: %s", v)
+					}
+				}
+				compositeIndex = append(compositeIndex, map[string]interface{}{"path": tokens[0], "order": order})
+			}
+			if len(compositeIndex) > 0 {
+				compositeIndexes = append(compositeIndexes, compositeIndex)
+			}
+		}
+		if len(compositeIndexes) == 0 {
+			return nil, fmt.Errorf("invalid COMPOSITE value: %s", v)
+		}
+		setPolicy("compositeIndexes", compositeIndexes)
+	}
+	if v, ok := withOpts["SPATIAL"]; ok {
+		spatialIndexes := make([]interface{}, 0)
+		for _, token := range strings.Split(v, ";") {
+			tokens := strings.SplitN(strings.TrimSpace(token), ":", 2)
+			if tokens[0] == "" {
+				continue
+			}
+			types := make([]interface{}, 0)
+			if len(tokens) > 1 {
+				for _, typ := range strings.Split(tokens[1], ",") {
+					spatialType, ok := spatialTypes[strings.ToLower(strings.TrimSpace(typ))]
+					if !ok {
+						return nil, fmt.Errorf("invalid SPATIAL value: %s", v)
+					}
+					types = append(types, spatialType)
+				}
+			} else {
+				types = append(types, "Point", "Polygon", "LineString", "MultiPolygon")
+			}
+			spatialIndexes = append(spatialIndexes, map[string]interface{}{"path": tokens[0], "types": types})
+		}
+		if len(spatialIndexes) == 0 {
+			return nil, fmt.Errorf("invalid SPATIAL value: %s", v)
+		}
+		setPolicy("spatialIndexes", spatialIndexes)
+	}
+	return policy, nil
+}
+
+// _parseDefaultTtl parses the collection's default time-to-live from the "WITH TTL=<seconds|-1|off>" option.
+// Value "off" is returned as 0 if allowOff is true. This function returns (0, false, nil) if the option is not specified.
+func _parseDefaultTtl(withOpts map[string]string, allowOff bool) (int, bool, error) {
+	v, ok := withOpts["TTL"]
+	if !ok {
+		return 0, false, nil
+	}
+	if allowOff && strings.ToLower(v) == "off" {
+		return 0, true, nil
+	}
+	ttl, err := strconv.ParseInt(v, 10, 32)
+	if err != nil || ttl < -1 || ttl == 0 {
+		return 0, false, fmt.Errorf("invalid TTL value: %s", v)
+	}
+	return int(ttl), true, nil
+}
+
+var spatialTypes = map[string]string{"point": "Point", "polygon": "Polygon", "linestring": "LineString", "multipolygon": "MultiPolygon"}
+
+// _parseGeospatialConfig builds the collection's geospatial configuration from the "WITH GEOSPATIAL=geography|geometry" option.
+// This function returns nil config if the option is not specified.
+func _parseGeospatialConfig(withOpts map[string]string) (map[string]interface{}, error) {
+	v, ok := withOpts["GEOSPATIAL"]
+	if !ok {
+		return nil, nil
+	}
+	switch strings.ToLower(v) {
+	case "geography":
+		return map[string]interface{}{"type": "Geography"}, nil
+	case "geometry":
+		return map[string]interface{}{"type": "Geometry"}, nil
+	}
+	return nil, fmt.Errorf("invalid GEOSPATIAL value: %s", v)
+}
+
 // StmtCreateCollection implements "CREATE COLLECTION" operation.
 //
 // Syntax:
 //     CREATE COLLECTION|TABLE [IF NOT EXISTS] [<db-name>.]<collection-name> <WITH [LARGE]PK=partitionKey> [WITH RU|MAXRU=ru] [WITH UK=/path1:/path2,/path3;/path4]
+//         [WITH INDEXING=<json>] [WITH INDEXING_MODE=consistent|lazy|none] [WITH INCLUDE=/path1,/path2] [WITH EXCLUDE=/path3,/path4]
+//         [WITH COMPOSITE=/path1,/path2:desc;/path3:asc,/path4:desc] [WITH SPATIAL=/path1:point,polygon;/path2] [WITH GEOSPATIAL=geography|geometry]
+//         [WITH TTL=seconds|-1]
 //
-// - ru: an integer specifying CosmosDB's database throughput expressed in RU/s. Supply either RU or MAXRU, not both!
+// - ru: an integer specifying CosmosDB's collection throughput expressed in RU/s. Supply either RU or MAXRU, not both!
+// RU provisions manual throughput; MAXRU provisions autoscale throughput that scales between 10% of MAXRU and MAXRU.
+// (since v0.1.1) On serverless accounts, RU and MAXRU are not supported; Exec returns an error matching ErrServerless.
 //
 // - If "IF NOT EXISTS" is specified, Exec will silently swallow the error "409 Conflict".
 //
 // - Use LARGEPK if partitionKey is larger than 100 bytes.
 //
+// - (available since v0.1.1) partitionKey can be a comma-separated list of up to 3 paths (e.g. /tenantId,/userId) to
+// create a collection with hierarchical partition key (subpartitioning).
+//
 // - Use UK to define unique keys. Each unique key consists a list of paths separated by comma (,). Unique keys are separated by colons (:) or semi-colons (;).
+//
+// - Use INDEXING, INDEXING_MODE, INCLUDE, EXCLUDE, COMPOSITE and SPATIAL to define the collection's indexing policy (available since v0.1.1).
+// INDEXING specifies the full policy as a JSON literal; the other options override the corresponding settings.
+//
+// - Use GEOSPATIAL to specify the collection's geospatial configuration (available since v0.1.1). Note: spatial indexes
+// of a "geometry" collection require a bounding box, which can be specified via INDEXING.
+//
+// - Use TTL to specify the collection's default time-to-live of documents (available since v0.1.1): -1 enables
+// time-to-live without a default expiry, a positive number specifies the number of seconds documents expire after their
+// last modified time.
 type StmtCreateCollection struct {
 	*Stmt
-	dbName      string
-	collName    string // collection name
-	ifNotExists bool
-	isLargePk   bool
-	ru, maxru   int
-	pk          string     // partition key
-	uk          [][]string // unique keys
-	withOptsStr string
+	dbName           string
+	collName         string // collection name
+	ifNotExists      bool
+	isLargePk        bool
+	ru, maxru        int
+	pk               string                 // partition key
+	pkPaths          []string               // (since v0.1.1) partition key paths, more than one for hierarchical partition key
+	uk               [][]string             // unique keys
+	indexingPolicy   map[string]interface{} // indexing policy
+	geospatialConfig map[string]interface{} // geospatial configuration
+	ttl              int                    // default time-to-live
+	withOptsStr      string
+}
+
+// rebind returns a copy of the statement bound to conn, see _rebindStmt.
+func (s *StmtCreateCollection) rebind(conn *Conn) driver.Stmt {
+	clone := *s
+	clone.Stmt = s.Stmt.withConn(conn)
+	return &clone
 }
 
 func (s *StmtCreateCollection) parse() error {
@@ -54,6 +230,18 @@ func (s *StmtCreateCollection) parse() error {
 		s.pk = largePk
 		s.isLargePk = true
 	}
+	for _, path := range strings.Split(s.pk, ",") {
+		if path = strings.TrimSpace(path); path == "" {
+			return fmt.Errorf("invalid PartitionKey value: %s", s.pk)
+		}
+		if _, err := _parsePkPath(path); err != nil {
+			return fmt.Errorf("invalid PartitionKey value: %s", s.pk)
+		}
+		s.pkPaths = append(s.pkPaths, path)
+	}
+	if len(s.pkPaths) > 3 {
+		return fmt.Errorf("hierarchical partition key supports up to 3 paths: %s", s.pk)
+	}
 
 	// request unit
 	if _, ok := s.withOpts["RU"]; ok {
@@ -80,6 +268,27 @@ func (s *StmtCreateCollection) parse() error {
 		}
 	}
 
+	// indexing policy
+	indexingPolicy, err := _parseIndexingPolicy(s.withOpts)
+	if err != nil {
+		return err
+	}
+	s.indexingPolicy = indexingPolicy
+
+	// geospatial configuration
+	geospatialConfig, err := _parseGeospatialConfig(s.withOpts)
+	if err != nil {
+		return err
+	}
+	s.geospatialConfig = geospatialConfig
+
+	// default time-to-live
+	ttl, _, err := _parseDefaultTtl(s.withOpts, false)
+	if err != nil {
+		return err
+	}
+	s.ttl = ttl
+
 	return nil
 }
 
@@ -102,14 +311,18 @@ func (s *StmtCreateCollection) Query(_ []driver.Value) (driver.Rows, error) {
 // Exec implements driver.Stmt.Exec.
 // Upon successful call, this function returns (*ResultCreateCollection, nil).
 func (s *StmtCreateCollection) Exec(_ []driver.Value) (driver.Result, error) {
-	spec := CollectionSpec{DbName: s.dbName, CollName: s.collName, Ru: s.ru, MaxRu: s.maxru,
+	spec := CollectionSpec{DbName: s.dbName, CollName: s.collName, Ru: s.ru, MaxRu: s.maxru, DefaultTtl: s.ttl,
 		PartitionKeyInfo: map[string]interface{}{
-			"paths": []string{s.pk},
+			"paths": s.pkPaths,
 			"kind":  "Hash",
 		}}
 	if s.isLargePk {
 		spec.PartitionKeyInfo["Version"] = 2
 	}
+	if len(s.pkPaths) > 1 {
+		spec.PartitionKeyInfo["kind"] = "MultiHash"
+		spec.PartitionKeyInfo["Version"] = 2
+	}
 	if len(s.uk) > 0 {
 		uniqueKeys := make([]interface{}, 0)
 		for _, uk := range s.uk {
@@ -117,21 +330,18 @@ func (s *StmtCreateCollection) Exec(_ []driver.Value) (driver.Result, error) {
 		}
 		spec.UniqueKeyPolicy = map[string]interface{}{"uniqueKeys": uniqueKeys}
 	}
+	if s.indexingPolicy != nil {
+		spec.IndexingPolicy = s.indexingPolicy
+	}
+	if s.geospatialConfig != nil {
+		spec.GeospatialConfig = s.geospatialConfig
+	}
 
 	restResult := s.conn.restClient.CreateCollection(spec)
 	result := &ResultCreateCollection{Successful: restResult.Error() == nil, InsertId: restResult.Rid}
-	err := restResult.Error()
-	switch restResult.StatusCode {
-	case 403:
-		err = ErrForbidden
-	case 404:
-		err = ErrNotFound
-	case 409:
-		if s.ifNotExists {
-			err = nil
-		} else {
-			err = ErrConflict
-		}
+	err := _explainServerless(restResult.Error())
+	if restResult.StatusCode == 409 && s.ifNotExists {
+		err = nil
 	}
 	return result, err
 }
@@ -166,12 +376,170 @@ func (r *ResultCreateCollection) RowsAffected() (int64, error) {
 
 /*----------------------------------------------------------------------*/
 
+// StmtAlterCollection implements "ALTER COLLECTION" operation.
+//
+// Syntax:
+//     ALTER COLLECTION|TABLE [<db-name>.]<collection-name> [WITH RU|MAXRU=ru]
+//         [WITH INDEXING=<json>] [WITH INDEXING_MODE=consistent|lazy|none] [WITH INCLUDE=/path1,/path2] [WITH EXCLUDE=/path3,/path4]
+//         [WITH COMPOSITE=/path1,/path2:desc;/path3:asc,/path4:desc] [WITH SPATIAL=/path1:point,polygon;/path2] [WITH GEOSPATIAL=geography|geometry]
+//         [WITH TTL=seconds|-1|off]
+//
+// - ru: an integer specifying CosmosDB's collection throughput expressed in RU/s. Supply either RU or MAXRU, not both!
+// Use RU to switch the collection to manual throughput, and MAXRU to switch it to autoscale throughput.
+// (since v0.1.1) On serverless accounts, RU and MAXRU are not supported; Exec returns an error matching ErrServerless.
+//
+// - Indexing, geospatial and TTL options have the same meaning as in CREATE COLLECTION. Use TTL=off to turn off
+// time-to-live.
+//
+// - If INDEXING is specified, it replaces the current indexing policy; otherwise the other indexing options are merged
+// into the current indexing policy.
+//
+// Available since v0.1.1
+type StmtAlterCollection struct {
+	*Stmt
+	dbName           string
+	collName         string // collection name
+	ru, maxru        int
+	indexingPolicy   map[string]interface{} // indexing policy
+	geospatialConfig map[string]interface{} // geospatial configuration
+	ttl              int                    // default time-to-live, 0 means "off"
+	hasTtl           bool                   // true if TTL option is specified
+	withOptsStr      string
+}
+
+// rebind returns a copy of the statement bound to conn, see _rebindStmt.
+func (s *StmtAlterCollection) rebind(conn *Conn) driver.Stmt {
+	clone := *s
+	clone.Stmt = s.Stmt.withConn(conn)
+	return &clone
+}
+
+func (s *StmtAlterCollection) parse() error {
+	if err := s.Stmt.parseWithOpts(s.withOptsStr); err != nil {
+		return err
+	}
+
+	// request unit
+	if _, ok := s.withOpts["RU"]; ok {
+		ru, err := strconv.ParseInt(s.withOpts["RU"], 10, 64)
+		if err != nil || ru < 0 {
+			return fmt.Errorf("invalid RU value: %s", s.withOpts["RU"])
+		}
+		s.ru = int(ru)
+	}
+	if _, ok := s.withOpts["MAXRU"]; ok {
+		maxru, err := strconv.ParseInt(s.withOpts["MAXRU"], 10, 64)
+		if err != nil || maxru < 0 {
+			return fmt.Errorf("invalid MAXRU value: %s", s.withOpts["MAXRU"])
+		}
+		s.maxru = int(maxru)
+	}
+
+	// indexing policy
+	indexingPolicy, err := _parseIndexingPolicy(s.withOpts)
+	if err != nil {
+		return err
+	}
+	s.indexingPolicy = indexingPolicy
+
+	// geospatial configuration
+	geospatialConfig, err := _parseGeospatialConfig(s.withOpts)
+	if err != nil {
+		return err
+	}
+	s.geospatialConfig = geospatialConfig
+
+	// default time-to-live
+	if s.ttl, s.hasTtl, err = _parseDefaultTtl(s.withOpts, true); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *StmtAlterCollection) validate() error {
+	if s.ru > 0 && s.maxru > 0 {
+		return errors.New("only one of RU or MAXRU must be specified")
+	}
+	if s.ru <= 0 && s.maxru <= 0 && s.indexingPolicy == nil && s.geospatialConfig == nil && !s.hasTtl {
+		return errors.New("nothing to alter, specify at least RU, MAXRU, indexing policy, geospatial configuration or TTL")
+	}
+	if s.dbName == "" || s.collName == "" {
+		return errors.New("database/collection is missing")
+	}
+	return nil
+}
+
+// Query implements driver.Stmt.Query.
+// This function is not implemented, use Exec instead.
+func (s *StmtAlterCollection) Query(_ []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("this operation is not supported, please use exec")
+}
+
+// Exec implements driver.Stmt.Exec.
+// Upon successful call, this function returns (*ResultAlterCollection, nil).
+func (s *StmtAlterCollection) Exec(_ []driver.Value) (driver.Result, error) {
+	getResult := s.conn.restClient.GetCollection(s.dbName, s.collName)
+	if err := getResult.Error(); err != nil {
+		return nil, err
+	}
+	var restResult RestReponse
+	if s.indexingPolicy != nil || s.geospatialConfig != nil || s.hasTtl {
+		spec := CollectionSpec{DbName: s.dbName, CollName: s.collName, PartitionKeyInfo: getResult.PartitionKey,
+			IndexingPolicy: getResult.IndexingPolicy, GeospatialConfig: getResult.GeospatialConfig, DefaultTtl: getResult.DefaultTtl}
+		if s.indexingPolicy != nil {
+			if _, ok := s.withOpts["INDEXING"]; ok || spec.IndexingPolicy == nil {
+				spec.IndexingPolicy = make(map[string]interface{})
+			}
+			for k, v := range s.indexingPolicy {
+				spec.IndexingPolicy[k] = v
+			}
+		}
+		if s.geospatialConfig != nil {
+			spec.GeospatialConfig = s.geospatialConfig
+		}
+		if s.hasTtl {
+			spec.DefaultTtl = s.ttl
+		}
+		restResult = s.conn.restClient.ReplaceCollection(spec).RestReponse
+	}
+	if restResult.Error() == nil && (s.ru > 0 || s.maxru > 0) {
+		restResult = s.conn.restClient.ReplaceOfferForResource(getResult.Rid, s.ru, s.maxru).RestReponse
+	}
+	result := &ResultAlterCollection{Successful: restResult.Error() == nil}
+	err := _explainServerless(restResult.Error())
+	return result, err
+}
+
+// ResultAlterCollection captures the result from ALTER COLLECTION operation.
+type ResultAlterCollection struct {
+	// Successful flags if the operation was successful or not.
+	Successful bool
+}
+
+// LastInsertId implements driver.Result.LastInsertId.
+func (r *ResultAlterCollection) LastInsertId() (int64, error) {
+	return 0, errors.New("this operation is not supported")
+}
+
+// RowsAffected implements driver.Result.RowsAffected.
+func (r *ResultAlterCollection) RowsAffected() (int64, error) {
+	if r.Successful {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+/*----------------------------------------------------------------------*/
+
 // StmtDropCollection implements "DROP COLLECTION" operation.
 //
 // Syntax:
 //     DROP COLLECTION|TABLE [IF EXISTS] [<db-name>.]<collection-name>
 //
 // If "IF EXISTS" is specified, Exec will silently swallow the error "404 Not Found".
+//
+// (since v0.1.1) Exec returns a *ResultDrop, reporting 0 rows affected if the collection did not exist.
 type StmtDropCollection struct {
 	*Stmt
 	dbName   string
@@ -179,6 +547,13 @@ type StmtDropCollection struct {
 	ifExists bool
 }
 
+// rebind returns a copy of the statement bound to conn, see _rebindStmt.
+func (s *StmtDropCollection) rebind(conn *Conn) driver.Stmt {
+	clone := *s
+	clone.Stmt = s.Stmt.withConn(conn)
+	return &clone
+}
+
 func (s *StmtDropCollection) validate() error {
 	if s.dbName == "" || s.collName == "" {
 		return errors.New("database/collection is missing")
@@ -193,21 +568,15 @@ func (s *StmtDropCollection) Query(_ []driver.Value) (driver.Rows, error) {
 }
 
 // Exec implements driver.Stmt.Exec.
-// This function always return a nil driver.Result.
+// Upon successful call, this function returns (*ResultDrop, nil).
 func (s *StmtDropCollection) Exec(_ []driver.Value) (driver.Result, error) {
 	restResult := s.conn.restClient.DeleteCollection(s.dbName, s.collName)
+	result := &ResultDrop{Successful: restResult.Error() == nil}
 	err := restResult.Error()
-	switch restResult.StatusCode {
-	case 403:
-		err = ErrForbidden
-	case 404:
-		if s.ifExists {
-			err = nil
-		} else {
-			err = ErrNotFound
-		}
+	if restResult.StatusCode == 404 && s.ifExists {
+		err = nil
 	}
-	return nil, err
+	return result, err
 }
 
 /*----------------------------------------------------------------------*/
@@ -221,6 +590,13 @@ type StmtListCollections struct {
 	dbName string
 }
 
+// rebind returns a copy of the statement bound to conn, see _rebindStmt.
+func (s *StmtListCollections) rebind(conn *Conn) driver.Stmt {
+	clone := *s
+	clone.Stmt = s.Stmt.withConn(conn)
+	return &clone
+}
+
 func (s *StmtListCollections) validate() error {
 	if s.dbName == "" {
 		return errors.New("database is missing")
@@ -246,12 +622,6 @@ func (s *StmtListCollections) Query(_ []driver.Value) (driver.Rows, error) {
 			cursorCount: 0,
 		}
 	}
-	switch restResult.StatusCode {
-	case 403:
-		err = ErrForbidden
-	case 404:
-		err = ErrNotFound
-	}
 	return rows, err
 }
 
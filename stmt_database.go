@@ -8,12 +8,83 @@ import (
 	"strconv"
 )
 
+const (
+	minDbRu    = 400  // minimum manual shared throughput of a database, in RU/s
+	minDbMaxRu = 1000 // minimum autoscale max shared throughput of a database, in RU/s
+)
+
+// _parseThroughputOpts parses options RU and MAXRU of a statement.
+func _parseThroughputOpts(withOpts map[string]string) (ru, maxru int, err error) {
+	if _, ok := withOpts["RU"]; ok {
+		v, err := strconv.ParseInt(withOpts["RU"], 10, 64)
+		if err != nil || v < 0 {
+			return 0, 0, fmt.Errorf("invalid RU value: %s", withOpts["RU"])
+		}
+		ru = int(v)
+	}
+	if _, ok := withOpts["MAXRU"]; ok {
+		v, err := strconv.ParseInt(withOpts["MAXRU"], 10, 64)
+		if err != nil || v < 0 {
+			return 0, 0, fmt.Errorf("invalid MAXRU value: %s", withOpts["MAXRU"])
+		}
+		maxru = int(v)
+	}
+	return ru, maxru, nil
+}
+
+// _validateDbThroughput checks the shared throughput of a database: manual throughput must be at least 400 RU/s in
+// increments of 100, autoscale max throughput at least 1000 RU/s in increments of 1000.
+func _validateDbThroughput(ru, maxru int) error {
+	if ru > 0 && maxru > 0 {
+		return errors.New("only one of RU or MAXRU must be specified")
+	}
+	if ru > 0 && (ru < minDbRu || ru%100 != 0) {
+		return fmt.Errorf("invalid RU value: %d, shared throughput must be at least %d RU/s in increments of 100", ru, minDbRu)
+	}
+	if maxru > 0 && (maxru < minDbMaxRu || maxru%1000 != 0) {
+		return fmt.Errorf("invalid MAXRU value: %d, autoscale max throughput must be at least %d RU/s in increments of 1000", maxru, minDbMaxRu)
+	}
+	return nil
+}
+
+// serverlessError wraps the error of a request provisioning or reading throughput on a serverless account, explaining
+// how to fix it.
+type serverlessError struct {
+	err error
+}
+
+// Error implements error.Error.
+func (e *serverlessError) Error() string {
+	return "the account is serverless, throughput can not be provisioned (omit WITH RU/MAXRU) nor inspected: " + e.err.Error()
+}
+
+// Unwrap returns the wrapped error (a *CosmosError).
+func (e *serverlessError) Unwrap() error {
+	return e.err
+}
+
+// _explainServerless wraps err into a serverlessError if it matches ErrServerless, err is returned as-is otherwise.
+func _explainServerless(err error) error {
+	if errors.Is(err, ErrServerless) {
+		return &serverlessError{err: err}
+	}
+	return err
+}
+
+/*----------------------------------------------------------------------*/
+
 // StmtCreateDatabase implements "CREATE DATABASE" operation.
 //
 // Syntax:
 //     CREATE DATABASE [IF NOT EXISTS] <db-name> [WITH RU|MAXRU=ru]
 //
 // - ru: an integer specifying CosmosDB's database throughput expressed in RU/s. Supply either RU or MAXRU, not both!
+// RU provisions manual throughput; MAXRU provisions autoscale throughput that scales between 10% of MAXRU and MAXRU.
+// (since v0.1.1) RU must be at least 400 in increments of 100, MAXRU at least 1000 in increments of 1000, checked by Exec
+// before the request is sent. Throughput provisioned at database level is shared by collections of the database that do
+// not have throughput of their own.
+//
+// - (since v0.1.1) On serverless accounts, RU and MAXRU are not supported; Exec returns an error matching ErrServerless.
 //
 // - If "IF NOT EXISTS" is specified, Exec will silently swallow the error "409 Conflict".
 type StmtCreateDatabase struct {
@@ -24,25 +95,20 @@ type StmtCreateDatabase struct {
 	withOptsStr string
 }
 
+// rebind returns a copy of the statement bound to conn, see _rebindStmt.
+func (s *StmtCreateDatabase) rebind(conn *Conn) driver.Stmt {
+	clone := *s
+	clone.Stmt = s.Stmt.withConn(conn)
+	return &clone
+}
+
 func (s *StmtCreateDatabase) parse() error {
 	if err := s.Stmt.parseWithOpts(s.withOptsStr); err != nil {
 		return err
 	}
-	if _, ok := s.withOpts["RU"]; ok {
-		ru, err := strconv.ParseInt(s.withOpts["RU"], 10, 64)
-		if err != nil || ru < 0 {
-			return fmt.Errorf("invalid RU value: %s", s.withOpts["RU"])
-		}
-		s.ru = int(ru)
-	}
-	if _, ok := s.withOpts["MAXRU"]; ok {
-		maxru, err := strconv.ParseInt(s.withOpts["MAXRU"], 10, 64)
-		if err != nil || maxru < 0 {
-			return fmt.Errorf("invalid MAXRU value: %s", s.withOpts["MAXRU"])
-		}
-		s.maxru = int(maxru)
-	}
-	return nil
+	var err error
+	s.ru, s.maxru, err = _parseThroughputOpts(s.withOpts)
+	return err
 }
 
 func (s *StmtCreateDatabase) validate() error {
@@ -61,18 +127,14 @@ func (s *StmtCreateDatabase) Query(_ []driver.Value) (driver.Rows, error) {
 // Exec implements driver.Stmt.Exec.
 // Upon successful call, this function return (*ResultCreateDatabase, nil).
 func (s *StmtCreateDatabase) Exec(_ []driver.Value) (driver.Result, error) {
+	if err := _validateDbThroughput(s.ru, s.maxru); err != nil {
+		return nil, err
+	}
 	restResult := s.conn.restClient.CreateDatabase(DatabaseSpec{Id: s.dbName, Ru: s.ru, MaxRu: s.maxru})
 	result := &ResultCreateDatabase{Successful: restResult.Error() == nil, InsertId: restResult.Rid}
-	err := restResult.Error()
-	switch restResult.StatusCode {
-	case 403:
-		err = ErrForbidden
-	case 409:
-		if s.ifNotExists {
-			err = nil
-		} else {
-			err = ErrConflict
-		}
+	err := _explainServerless(restResult.Error())
+	if restResult.StatusCode == 409 && s.ifNotExists {
+		err = nil
 	}
 	return result, err
 }
@@ -100,18 +162,117 @@ func (r *ResultCreateDatabase) RowsAffected() (int64, error) {
 
 /*----------------------------------------------------------------------*/
 
+// StmtAlterDatabase implements "ALTER DATABASE" operation.
+//
+// Syntax:
+//     ALTER DATABASE <db-name> WITH RU|MAXRU=ru
+//
+// - ru: an integer specifying CosmosDB's database shared throughput expressed in RU/s. Supply either RU or MAXRU, not
+// both! Use RU to switch the database to manual throughput (at least 400, in increments of 100), and MAXRU to switch it
+// to autoscale throughput (at least 1000, in increments of 1000).
+//
+// - Only databases created with shared throughput can be altered, shared throughput can not be added to an existing
+// database. On serverless accounts, Exec returns an error matching ErrServerless.
+//
+// Available since v0.1.1
+type StmtAlterDatabase struct {
+	*Stmt
+	dbName      string
+	ru, maxru   int
+	withOptsStr string
+}
+
+// rebind returns a copy of the statement bound to conn, see _rebindStmt.
+func (s *StmtAlterDatabase) rebind(conn *Conn) driver.Stmt {
+	clone := *s
+	clone.Stmt = s.Stmt.withConn(conn)
+	return &clone
+}
+
+func (s *StmtAlterDatabase) parse() error {
+	if err := s.Stmt.parseWithOpts(s.withOptsStr); err != nil {
+		return err
+	}
+	var err error
+	s.ru, s.maxru, err = _parseThroughputOpts(s.withOpts)
+	return err
+}
+
+func (s *StmtAlterDatabase) validate() error {
+	if s.ru <= 0 && s.maxru <= 0 {
+		return errors.New("RU or MAXRU must be specified")
+	}
+	return _validateDbThroughput(s.ru, s.maxru)
+}
+
+// Query implements driver.Stmt.Query.
+// This function is not implemented, use Exec instead.
+func (s *StmtAlterDatabase) Query(_ []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("this operation is not supported, please use exec")
+}
+
+// Exec implements driver.Stmt.Exec.
+// Upon successful call, this function return (*ResultAlterDatabase, nil).
+func (s *StmtAlterDatabase) Exec(_ []driver.Value) (driver.Result, error) {
+	getResult := s.conn.restClient.GetDatabase(s.dbName)
+	if err := getResult.Error(); err != nil {
+		return nil, err
+	}
+	restResult := s.conn.restClient.ReplaceOfferForResource(getResult.Rid, s.ru, s.maxru)
+	if err := restResult.Error(); err != nil {
+		var cosmosErr *CosmosError
+		if errors.As(err, &cosmosErr) && cosmosErr.StatusCode == 404 && cosmosErr.ResourceType == "Offer" {
+			return nil, fmt.Errorf("database %s has no shared throughput, which can only be provisioned when the database is created", s.dbName)
+		}
+		return nil, _explainServerless(err)
+	}
+	return &ResultAlterDatabase{Successful: true}, nil
+}
+
+// ResultAlterDatabase captures the result from ALTER DATABASE operation.
+//
+// Available since v0.1.1
+type ResultAlterDatabase struct {
+	// Successful flags if the operation was successful or not.
+	Successful bool
+}
+
+// LastInsertId implements driver.Result.LastInsertId.
+func (r *ResultAlterDatabase) LastInsertId() (int64, error) {
+	return 0, errors.New("this operation is not supported")
+}
+
+// RowsAffected implements driver.Result.RowsAffected.
+func (r *ResultAlterDatabase) RowsAffected() (int64, error) {
+	if r.Successful {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+/*----------------------------------------------------------------------*/
+
 // StmtDropDatabase implements "DROP DATABASE" operation.
 //
 // Syntax:
 //     DROP DATABASE [IF EXISTS] <db-name>
 //
 // - If "IF EXISTS" is specified, Exec will silently swallow the error "404 Not Found".
+//
+// - (since v0.1.1) Exec returns a *ResultDrop, reporting 0 rows affected if the database did not exist.
 type StmtDropDatabase struct {
 	*Stmt
 	dbName   string
 	ifExists bool
 }
 
+// rebind returns a copy of the statement bound to conn, see _rebindStmt.
+func (s *StmtDropDatabase) rebind(conn *Conn) driver.Stmt {
+	clone := *s
+	clone.Stmt = s.Stmt.withConn(conn)
+	return &clone
+}
+
 func (s *StmtDropDatabase) validate() error {
 	return nil
 }
@@ -123,21 +284,36 @@ func (s *StmtDropDatabase) Query(_ []driver.Value) (driver.Rows, error) {
 }
 
 // Exec implements driver.Stmt.Exec.
-// This function always return a nil driver.Result.
+// Upon successful call, this function returns (*ResultDrop, nil).
 func (s *StmtDropDatabase) Exec(_ []driver.Value) (driver.Result, error) {
 	restResult := s.conn.restClient.DeleteDatabase(s.dbName)
+	result := &ResultDrop{Successful: restResult.Error() == nil}
 	err := restResult.Error()
-	switch restResult.StatusCode {
-	case 403:
-		err = ErrForbidden
-	case 404:
-		if s.ifExists {
-			err = nil
-		} else {
-			err = ErrNotFound
-		}
+	if restResult.StatusCode == 404 && s.ifExists {
+		err = nil
 	}
-	return nil, err
+	return result, err
+}
+
+// ResultDrop captures the result from DROP DATABASE, DROP COLLECTION and DROP USER operations.
+//
+// Available since v0.1.1
+type ResultDrop struct {
+	// Successful flags if the operation was successful or not, i.e. false if the resource did not exist.
+	Successful bool
+}
+
+// LastInsertId implements driver.Result.LastInsertId.
+func (r *ResultDrop) LastInsertId() (int64, error) {
+	return 0, errors.New("this operation is not supported")
+}
+
+// RowsAffected implements driver.Result.RowsAffected.
+func (r *ResultDrop) RowsAffected() (int64, error) {
+	if r.Successful {
+		return 1, nil
+	}
+	return 0, nil
 }
 
 /*----------------------------------------------------------------------*/
@@ -150,6 +326,13 @@ type StmtListDatabases struct {
 	*Stmt
 }
 
+// rebind returns a copy of the statement bound to conn, see _rebindStmt.
+func (s *StmtListDatabases) rebind(conn *Conn) driver.Stmt {
+	clone := *s
+	clone.Stmt = s.Stmt.withConn(conn)
+	return &clone
+}
+
 func (s *StmtListDatabases) validate() error {
 	return nil
 }
@@ -172,10 +355,6 @@ func (s *StmtListDatabases) Query(_ []driver.Value) (driver.Rows, error) {
 			cursorCount: 0,
 		}
 	}
-	switch restResult.StatusCode {
-	case 403:
-		err = ErrForbidden
-	}
 	return rows, err
 }
 
@@ -1,76 +1,64 @@
 package gocosmos
 
 import (
+	"crypto/sha256"
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"os"
+	"reflect"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
 )
 
-var (
-	reValNull        = regexp.MustCompile(`(?i)(null)\s*,?`)
-	reValNumber      = regexp.MustCompile(`([\d\.xe+-]+)\s*,?`)
-	reValBoolean     = regexp.MustCompile(`(?i)(true|false)\s*,?`)
-	reValString      = regexp.MustCompile(`(?i)("(\\"|[^"])*?")\s*,?`)
-	reValPlaceholder = regexp.MustCompile(`(?i)[$@:](\d+)\s*,?`)
-)
+var reValPlaceholder = regexp.MustCompile(`(?i)[$@:](\d+)\s*,?`)
 
 type placeholder struct {
 	index int
 }
 
-func _parseValue(input string, separator rune) (value interface{}, leftOver string, err error) {
-	if loc := reValPlaceholder.FindStringIndex(input); loc != nil && loc[0] == 0 {
-		token := strings.TrimFunc(input[loc[0]+1:loc[1]], func(r rune) bool { return _isSpace(r) || r == separator })
-		index, err := strconv.Atoi(token)
-		return placeholder{index}, input[loc[1]:], err
-	}
-	if loc := reValNull.FindStringIndex(input); loc != nil && loc[0] == 0 {
-		return nil, input[loc[1]:], nil
-	}
-	if loc := reValNumber.FindStringIndex(input); loc != nil && loc[0] == 0 {
-		token := strings.TrimFunc(input[loc[0]:loc[1]], func(r rune) bool { return _isSpace(r) || r == separator })
-		var data interface{}
-		err := json.Unmarshal([]byte(token), &data)
-		if err != nil {
-			err = errors.New("(nul) cannot parse query, invalid token at: " + token)
+// _parseTriggerOpts extracts the comma-separated trigger lists from "WITH PRETRIGGER=..." and "WITH POSTTRIGGER=..." options.
+func _parseTriggerOpts(withOpts map[string]string) (preTriggers, postTriggers []string) {
+	split := func(v string) []string {
+		result := make([]string, 0)
+		for _, token := range strings.Split(v, ",") {
+			if token = strings.TrimSpace(token); token != "" {
+				result = append(result, token)
+			}
 		}
-		return data, input[loc[1]:], err
+		return result
 	}
-	if loc := reValBoolean.FindStringIndex(input); loc != nil && loc[0] == 0 {
-		token := strings.TrimFunc(input[loc[0]:loc[1]], func(r rune) bool { return _isSpace(r) || r == separator })
-		var data bool
-		err := json.Unmarshal([]byte(token), &data)
-		// if err != nil {
-		// 	err = errors.New("(bool) cannot parse query, invalid token at: " + token)
-		// }
-		return data, input[loc[1]:], err
+	if v, ok := withOpts["PRETRIGGER"]; ok {
+		preTriggers = split(v)
 	}
-	if loc := reValString.FindStringIndex(input); loc != nil && loc[0] == 0 {
-		var data interface{}
-		token, err := strconv.Unquote(strings.TrimFunc(input[loc[0]:loc[1]], func(r rune) bool { return _isSpace(r) || r == separator }))
-		if err == nil {
-			err = json.Unmarshal([]byte(token), &data)
-			if err != nil {
-				err = errors.New("(unmarshal) cannot parse query, invalid token at: " + token)
-			}
-		} else {
-			err = errors.New("(unquote) cannot parse query, invalid token at: " + token)
+	if v, ok := withOpts["POSTTRIGGER"]; ok {
+		postTriggers = split(v)
+	}
+	return
+}
+
+// _bindEtag returns the etag value of an etag condition, resolving placeholder against the supplied value arguments.
+func _bindEtag(etag interface{}, valueArgs []driver.Value) (string, error) {
+	switch v := etag.(type) {
+	case placeholder:
+		if v.index <= 0 || v.index > len(valueArgs) {
+			return "", fmt.Errorf("invalid value index %d", v.index)
 		}
-		return data, input[loc[1]:], err
+		return fmt.Sprintf("%s", valueArgs[v.index-1]), nil
+	case string:
+		return v, nil
 	}
-	return nil, input, errors.New("cannot parse query, invalid token at: " + input)
+	return "", nil
 }
 
 // StmtInsert implements "INSERT" operation.
 //
 // Syntax:
-//     INSERT|UPSERT INTO <db-name>.<collection-name> (<field-list>) VALUES (<value-list>)
+//     INSERT|UPSERT INTO <db-name>.<collection-name> (<field-list>) VALUES (<value-list>) [WITH PRETRIGGER=<triggers>] [WITH POSTTRIGGER=<triggers>] [WITH INDEXING=include|exclude]
 //
 //     - values are comma separated.
 //     - a value is either:
@@ -85,36 +73,89 @@ func _parseValue(input string, separator rune) (value interface{}, leftOver stri
 //         - a null value in JSON (include the double quotes): "null"
 //         - a map value in JSON (include the double quotes): "{\"key\":\"value\"}"
 //         - a list value in JSON (include the double quotes): "[1,true,null,\"string\"]"
+//     - (available since v0.1.1) PRETRIGGER/POSTTRIGGER: comma-separated list of pre/post-triggers to be invoked with the operation.
+//     - (available since v0.1.1) INDEXING: include or exclude the document from the collection's index (x-ms-indexing-directive),
+//       e.g. to skip indexing for bulk-loaded documents.
+//     - (available since v0.1.1) If the field list does not contain "id", the document id is generated client-side by the
+//       connection's IdGenerator (UUID by default, see Connector.SetIdGenerator) and returned via ResultInsert.Id.
+//     - (available since v0.1.1) With DSN option AutoPartitionKey=true, the partition key value is extracted from the
+//       document data (using the collection's partition key path) and must not be supplied as the last argument.
+//     - (available since v0.1.1) If the collection is non-partitioned, the partition key value must not be supplied.
+//
+// (available since v0.1.1) The whole document can also be supplied as a single value:
+//     INSERT|UPSERT INTO <db-name>.<collection-name> JSON|DOCUMENT <value> [WITH...]
+//
+//     - <value> is a placeholder or a double-quoted string that must contain a JSON object.
+//     - The argument bound to the placeholder is either a map[string]interface{}, a JSON object as string/[]byte, or any
+//       value that encoding/json marshals into a JSON object (e.g. a struct).
+//
+// (available since v0.1.1) With INSERT IGNORE, a conflict (StatusCode=409, i.e. the id or a unique key already exists)
+// is not reported as an error: the document is left untouched and zero rows affected are reported.
+//
+// (available since v0.1.1) "RETURNING *|<field>[,<field>]*" can be appended after the VALUES/JSON part (before the WITH
+// options); the statement is then executed with Query, which returns the written document (including system properties
+// such as _rid, _etag and _ts) as a single row.
 //
 // CosmosDB automatically creates a few extra fields for the insert document.
 // See https://docs.microsoft.com/en-us/azure/cosmos-db/account-databases-containers-items#properties-of-an-item.
 type StmtInsert struct {
 	*Stmt
-	dbName    string
-	collName  string
-	isUpsert  bool
-	fieldsStr string
-	valuesStr string
-	fields    []string
-	values    []interface{}
+	dbName       string
+	collName     string
+	isUpsert     bool
+	fields       []string
+	values       []interface{}
+	withOptsStr  string
+	preTriggers  []string
+	postTriggers []string
+
+	indexing   string      // (since v0.1.1) indexing directive, "", "Include" or "Exclude"
+	hasId      bool        // (since v0.1.1) true if the field list contains "id"
+	isDocument bool        // (since v0.1.1) true if the whole document is supplied as a single value (INSERT...JSON)
+	document   interface{} // (since v0.1.1) INSERT...JSON: the document, either a placeholder or a JSON object
+	returning  []string    // (since v0.1.1) fields of the RETURNING clause, nil if not specified
+	ignore     bool        // (since v0.1.1) INSERT IGNORE: conflicts are not reported as errors
+}
+
+// rebind returns a copy of the statement bound to conn, see _rebindStmt.
+func (s *StmtInsert) rebind(conn *Conn) driver.Stmt {
+	clone := *s
+	clone.Stmt = s.Stmt.withConn(conn)
+	return &clone
 }
 
 func (s *StmtInsert) parse() error {
-	s.fields = regexp.MustCompile(`[,\s]+`).Split(s.fieldsStr, -1)
-	s.values = make([]interface{}, 0)
+	if err := s.Stmt.parseWithOpts(s.withOptsStr); err != nil {
+		return err
+	}
+	s.preTriggers, s.postTriggers = _parseTriggerOpts(s.withOpts)
+	if v, ok := s.withOpts["INDEXING"]; ok {
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "include":
+			s.indexing = "Include"
+		case "exclude":
+			s.indexing = "Exclude"
+		default:
+			return errors.New("cannot parse query (accepted values for indexing are include or exclude), invalid token at: " + v)
+		}
+	}
+
+	for _, field := range s.fields {
+		if field == "id" {
+			s.hasId = true
+		}
+	}
 	s.numInput = 1
-	for temp := strings.TrimSpace(s.valuesStr); temp != ""; temp = strings.TrimSpace(temp) {
-		value, leftOver, err := _parseValue(temp, ',')
-		if err == nil {
-			s.values = append(s.values, value)
-			temp = leftOver
-			switch value.(type) {
-			case placeholder:
-				s.numInput++
-			}
-			continue
+	if s.isAutoPk() {
+		s.numInput = 0
+	}
+	for _, value := range s.values {
+		if _, ok := value.(placeholder); ok {
+			s.numInput++
 		}
-		return err
+	}
+	if _, ok := s.document.(placeholder); ok {
+		s.numInput++
 	}
 	return nil
 }
@@ -123,6 +164,11 @@ func (s *StmtInsert) validate() error {
 	if len(s.fields) != len(s.values) {
 		return fmt.Errorf("number of field (%d) does not match number of input value (%d)", len(s.fields), len(s.values))
 	}
+	if _, ok := s.document.(map[string]interface{}); s.isDocument && !ok {
+		if _, ok := s.document.(placeholder); !ok {
+			return errors.New("document must be a JSON object or a placeholder")
+		}
+	}
 	if s.dbName == "" || s.collName == "" {
 		return errors.New("database/collection is missing")
 	}
@@ -132,48 +178,138 @@ func (s *StmtInsert) validate() error {
 // Exec implements driver.Stmt.Exec.
 // Upon successful call, this function returns (*ResultInsert, nil).
 //
-// Note: this function expects the last argument is partition key value.
+// Note: this function expects the last argument is partition key value, unless DSN option AutoPartitionKey=true.
 func (s *StmtInsert) Exec(args []driver.Value) (driver.Result, error) {
+	result, _, err := s.insert(args)
+	if result == nil {
+		return nil, err
+	}
+	return result, err
+}
+
+// insert writes the document, returning the result and the written document.
+func (s *StmtInsert) insert(args []driver.Value) (*ResultInsert, DocInfo, error) {
+	autoPk := s.isAutoPk()
+	valueArgs, pkValues := args, []interface{}(nil)
+	if !autoPk {
+		var err error
+		if valueArgs, pkValues, err = s.pkArgs(s.dbName, s.collName, args); err != nil {
+			return nil, nil, err
+		}
+	}
 	spec := DocumentSpec{
-		DbName:             s.dbName,
-		CollName:           s.collName,
-		IsUpsert:           s.isUpsert,
-		PartitionKeyValues: []interface{}{args[s.numInput-1]}, // expect the last argument is partition key value
-		DocumentData:       make(map[string]interface{}),
+		DbName:            s.dbName,
+		CollName:          s.collName,
+		IsUpsert:          s.isUpsert,
+		DocumentData:      make(map[string]interface{}),
+		IndexingDirective: s.indexing,
+		PreTriggers:       s.preTriggers,
+		PostTriggers:      s.postTriggers,
 	}
 	for i := 0; i < len(s.fields); i++ {
 		switch s.values[i].(type) {
 		case placeholder:
 			ph := s.values[i].(placeholder)
-			if ph.index <= 0 || ph.index >= len(args) {
-				return nil, fmt.Errorf("invalid value index %d", ph.index)
+			if ph.index <= 0 || ph.index > len(valueArgs) {
+				return nil, nil, fmt.Errorf("invalid value index %d", ph.index)
 			}
-			spec.DocumentData[s.fields[i]] = args[ph.index-1]
+			spec.DocumentData[s.fields[i]] = valueArgs[ph.index-1]
 		default:
 			spec.DocumentData[s.fields[i]] = s.values[i]
 		}
 	}
+	hasId := s.hasId
+	var taggedPkValues []interface{}
+	if s.isDocument {
+		document := s.document
+		if ph, ok := document.(placeholder); ok {
+			if ph.index <= 0 || ph.index > len(valueArgs) {
+				return nil, nil, fmt.Errorf("invalid value index %d", ph.index)
+			}
+			document = valueArgs[ph.index-1]
+		}
+		doc, err := _toDocument(document, s.conn.restClient.jsonCodec())
+		if err != nil {
+			return nil, nil, err
+		}
+		if taggedPkValues, err = _applyDocumentKeys(document, doc); err != nil {
+			return nil, nil, err
+		}
+		spec.DocumentData = doc
+		_, hasId = doc["id"]
+	}
+	if !hasId {
+		idGenerator := s.conn.idGenerator
+		if idGenerator == nil {
+			idGenerator = UuidGenerator
+		}
+		spec.DocumentData["id"] = idGenerator()
+	}
+	if autoPk && taggedPkValues != nil {
+		pkValues = taggedPkValues
+	} else if autoPk {
+		var err error
+		if pkValues, err = s.conn.restClient.docPkValues(s.dbName, s.collName, spec.DocumentData); err != nil {
+			return nil, nil, err
+		}
+	}
+	spec.PartitionKeyValues = pkValues
 	restResult := s.conn.restClient.CreateDocument(spec)
 	result := &ResultInsert{Successful: restResult.Error() == nil}
+	result.Id, _ = spec.DocumentData["id"].(string)
 	if restResult.DocInfo != nil {
 		result.InsertId, _ = restResult.DocInfo["_rid"].(string)
+		if id, ok := restResult.DocInfo["id"].(string); ok {
+			result.Id = id
+		}
 	}
 	err := restResult.Error()
-	switch restResult.StatusCode {
-	case 403:
-		err = ErrForbidden
-	case 404:
-		err = ErrNotFound
-	case 409:
-		err = ErrConflict
+	if s.ignore && errors.Is(err, ErrConflict) {
+		return result, nil, nil
 	}
-	return result, err
+	return result, restResult.DocInfo, err
+}
+
+// _toDocument converts the argument of INSERT...JSON into a document: a map[string]interface{} is copied, a string or
+// []byte is decoded as JSON, other values are converted via codec (e.g. structs).
+func _toDocument(value interface{}, codec JsonCodec) (map[string]interface{}, error) {
+	var js []byte
+	switch v := value.(type) {
+	case map[string]interface{}:
+		doc := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			doc[k] = val
+		}
+		return doc, nil
+	case string:
+		js = []byte(v)
+	case []byte:
+		js = v
+	default:
+		var err error
+		if js, err = codec.Marshal(v); err != nil {
+			return nil, fmt.Errorf("cannot convert document to JSON: %s", err)
+		}
+	}
+	var doc map[string]interface{}
+	if err := codec.Unmarshal(js, &doc); err != nil || doc == nil {
+		return nil, errors.New("document must be a JSON object")
+	}
+	return doc, nil
 }
 
 // Query implements driver.Stmt.Query.
-// This function is not implemented, use Exec instead.
+// This function is available only if the statement has a RETURNING clause, it returns the written document as a single
+// row (*ResultSelect).
 func (s *StmtInsert) Query(args []driver.Value) (driver.Rows, error) {
-	return nil, errors.New("this operation is not supported, please use exec")
+	if len(s.returning) == 0 {
+		return nil, errors.New("this operation is not supported, please use exec")
+	}
+	_, doc, err := s.insert(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.returningRows(s.returning, doc), nil
 }
 
 // ResultInsert captures the result from INSERT operation.
@@ -182,6 +318,9 @@ type ResultInsert struct {
 	Successful bool
 	// InsertId holds the "_rid" if the operation was successful.
 	InsertId string
+	// Id holds the id of the inserted document, which is generated client-side if the field list does not contain "id"
+	// (available since v0.1.1).
+	Id string
 }
 
 // LastInsertId implements driver.Result.LastInsertId.
@@ -199,24 +338,243 @@ func (r *ResultInsert) RowsAffected() (int64, error) {
 
 /*----------------------------------------------------------------------*/
 
+// StmtInsertSelect implements "INSERT...SELECT" operation, which copies the result of a query into a collection.
+//
+// Syntax:
+//     INSERT [IGNORE]|UPSERT INTO [<db-name>.]<collection-name> [(<field-list>)] SELECT ... FROM <collection-name> ... [WITH database|db=<db-name>] [WITH collection|table=<collection-name>] [WITH cross_partition=true] [WITH BATCH_SIZE=<n>] [WITH CONCURRENCY=<n>]
+//
+//     - The SELECT part (including its WITH options) follows the syntax of StmtSelect. The source database defaults to
+//       the target database, use "WITH db=<db-name>" to copy documents across databases.
+//     - Each row of the query is written as a document (system properties _rid, _self, _etag, _attachments and _ts are
+//       removed). If the field list is specified, only the listed properties of the row are written; use aliases
+//       (e.g. SELECT c.name AS username ...) to rename properties.
+//     - If a row does not have "id", it is generated client-side by the connection's IdGenerator.
+//     - Query results are written page by page using the bulk API; BATCH_SIZE (maximum number of documents of a page,
+//       default 100) and CONCURRENCY are passed to the query and the bulk API.
+//     - With INSERT IGNORE, documents that already exist (StatusCode=409) are skipped and counted as
+//       ResultInsertSelect.NumIgnored instead of failures.
+//
+// Available since v0.1.1
+type StmtInsertSelect struct {
+	*Stmt
+	dbName      string
+	collName    string
+	isUpsert    bool
+	fields      []string
+	selectStr   string
+	selectStmt  *StmtSelect
+	batchSize   int
+	concurrency int
+	ignore      bool
+}
+
+// rebind returns a copy of the statement bound to conn, see _rebindStmt.
+func (s *StmtInsertSelect) rebind(conn *Conn) driver.Stmt {
+	clone := *s
+	clone.Stmt = s.Stmt.withConn(conn)
+	return &clone
+}
+
+func (s *StmtInsertSelect) parse() error {
+	stmt, err := _parseQuery(s.conn, s.dbName, s.selectStr)
+	if err != nil {
+		return err
+	}
+	selectStmt, ok := stmt.(*StmtSelect)
+	if !ok {
+		return errors.New("cannot parse query, expected SELECT statement at: " + s.selectStr)
+	}
+	s.selectStmt, s.withOpts, s.numInput = selectStmt, selectStmt.withOpts, selectStmt.numInput
+	for key, target := range map[string]*int{"BATCH_SIZE": &s.batchSize, "CONCURRENCY": &s.concurrency} {
+		if v, ok := s.withOpts[key]; ok {
+			value, err := strconv.ParseInt(v, 10, 32)
+			if err != nil || value <= 0 {
+				return fmt.Errorf("invalid %s value: %s", key, v)
+			}
+			*target = int(value)
+		}
+	}
+	return nil
+}
+
+func (s *StmtInsertSelect) validate() error {
+	if s.dbName == "" || s.collName == "" {
+		return errors.New("database/collection is missing")
+	}
+	return nil
+}
+
+// toDocument builds the document to be written from a row of the query.
+func (s *StmtInsertSelect) toDocument(row DocInfo) map[string]interface{} {
+	doc := make(map[string]interface{})
+	if len(s.fields) == 0 {
+		for k, v := range row.RemoveSystemAttrs() {
+			doc[k] = v
+		}
+	}
+	for _, field := range s.fields {
+		if v, ok := row[field]; ok {
+			doc[field] = v
+		}
+	}
+	if _, ok := doc["id"].(string); !ok {
+		idGenerator := s.conn.idGenerator
+		if idGenerator == nil {
+			idGenerator = UuidGenerator
+		}
+		doc["id"] = idGenerator()
+	}
+	return doc
+}
+
+// Exec implements driver.Stmt.Exec.
+// Upon successful call, this function returns (*ResultInsertSelect, nil).
+//
+// If some documents could not be written, this function returns the *ResultInsertSelect together with the error of the
+// first failed document.
+func (s *StmtInsertSelect) Exec(args []driver.Value) (driver.Result, error) {
+	query, err := s.selectStmt.queryReq(args)
+	if err != nil {
+		return nil, err
+	}
+	query.MaxItemCount = s.batchSize
+	if query.MaxItemCount <= 0 {
+		query.MaxItemCount = 100
+	}
+	pkPaths, err := s.conn.restClient.getPkPaths(s.dbName, s.collName)
+	if err != nil {
+		return nil, err
+	}
+	window, err := s.selectStmt.newRowWindow(args)
+	if err != nil {
+		return nil, err
+	}
+	result := &ResultInsertSelect{}
+	distinct := s.selectStmt.newDistinctFilter()
+	var firstErr error
+	for {
+		restResult := s.conn.restClient.QueryDocuments(query)
+		if err := restResult.Error(); err != nil {
+			return result, _explainScanRequired(err)
+		}
+		rows, done := window.take(distinct.filter(restResult.Documents))
+		docs := make([]map[string]interface{}, len(rows))
+		for i, row := range rows {
+			docs[i] = s.toDocument(row)
+		}
+		bulkResult := s.conn.restClient.bulkWriteDocuments(BulkDocumentsReq{DbName: s.dbName, CollName: s.collName,
+			PartitionKeyPath: strings.Join(pkPaths, ","), Documents: docs, Concurrency: s.concurrency}, s.isUpsert)
+		result.NumSucceeded += bulkResult.NumSucceeded
+		if bulkResult.CallErr != nil {
+			return result, bulkResult.CallErr
+		}
+		for _, docResult := range bulkResult.Results {
+			switch {
+			case docResult.Error == nil:
+			case s.ignore && errors.Is(docResult.Error, ErrConflict):
+				result.NumIgnored++
+			default:
+				result.NumFailed++
+				if firstErr == nil {
+					firstErr = docResult.Error
+				}
+			}
+		}
+		if done || restResult.ContinuationToken == "" {
+			break
+		}
+		query.ContinuationToken = restResult.ContinuationToken
+	}
+	if firstErr != nil {
+		return result, fmt.Errorf("%d document(s) failed to write, first error: %s", result.NumFailed, firstErr)
+	}
+	return result, nil
+}
+
+// Query implements driver.Stmt.Query.
+// This function is not implemented, use Exec instead.
+func (s *StmtInsertSelect) Query(_ []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("this operation is not supported, please use exec")
+}
+
+// ResultInsertSelect captures the result from INSERT...SELECT operation.
+//
+// Available since v0.1.1
+type ResultInsertSelect struct {
+	// NumSucceeded and NumFailed are number of documents written successfully and failed.
+	NumSucceeded, NumFailed int
+	// NumIgnored is the number of documents skipped by INSERT IGNORE because they already exist.
+	NumIgnored int
+}
+
+// LastInsertId implements driver.Result.LastInsertId.
+func (r *ResultInsertSelect) LastInsertId() (int64, error) {
+	return 0, errors.New("this operation is not supported")
+}
+
+// RowsAffected implements driver.Result.RowsAffected.
+func (r *ResultInsertSelect) RowsAffected() (int64, error) {
+	return int64(r.NumSucceeded), nil
+}
+
+/*----------------------------------------------------------------------*/
+
 // StmtDelete implements "DELETE" operation.
 //
 // Syntax:
-//     DELETE FROM <db-name>.<collection-name> WHERE id=<id-value>
+//     DELETE FROM <db-name>.<collection-name> WHERE id=<id-value> [AND _etag=<etag-value>] [WITH PRETRIGGER=<triggers>] [WITH POSTTRIGGER=<triggers>]
 //
 // - Currently DELETE only removes one document specified by id.
 //
 // - <id-value> is treated as string. `WHERE id=abc` has the same effect as `WHERE id="abc"`.
+//
+// - (available since v0.1.1) <etag-value> is either a placeholder or a double-quoted string literal. If specified, the
+// document is removed only if its current etag matches, otherwise ErrPreconditionFailed is returned.
+//
+// - (available since v0.1.1) With DSN option AutoPartitionKey=true, the document is looked up by id across all partitions
+// to determine its partition key value, which must not be supplied as the last argument.
+//
+// - (available since v0.1.1) If the collection is non-partitioned, the partition key value must not be supplied.
+//
+// - (available since v0.1.1) PRETRIGGER/POSTTRIGGER: comma-separated list of pre/post-triggers to be invoked with the operation.
+//
+// - (available since v0.1.1) "RETURNING *|<field>[,<field>]*" can be appended after the WHERE clause (before the WITH
+// options); the statement is then executed with Query, which fetches the document before removing it and returns it as
+// a row (no row if the document does not exist).
 type StmtDelete struct {
 	*Stmt
-	dbName   string
-	collName string
-	idStr    string
-	id       interface{}
+	dbName       string
+	collName     string
+	idStr        string
+	id           interface{}
+	withOptsStr  string
+	preTriggers  []string
+	postTriggers []string
+
+	etag      interface{} // (since v0.1.1) etag condition, nil if not specified
+	returning []string    // (since v0.1.1) fields of the RETURNING clause, nil if not specified
+}
+
+// rebind returns a copy of the statement bound to conn, see _rebindStmt.
+func (s *StmtDelete) rebind(conn *Conn) driver.Stmt {
+	clone := *s
+	clone.Stmt = s.Stmt.withConn(conn)
+	return &clone
 }
 
 func (s *StmtDelete) parse() error {
+	if err := s.Stmt.parseWithOpts(s.withOptsStr); err != nil {
+		return err
+	}
+	s.preTriggers, s.postTriggers = _parseTriggerOpts(s.withOpts)
+
 	s.numInput = 1
+	if s.isAutoPk() {
+		s.numInput = 0
+	}
+	if _, ok := s.etag.(placeholder); ok {
+		s.numInput++
+	}
 	hasPrefix := strings.HasPrefix(s.idStr, `"`)
 	hasSuffix := strings.HasSuffix(s.idStr, `"`)
 	if hasPrefix != hasSuffix {
@@ -250,42 +608,92 @@ func (s *StmtDelete) validate() error {
 }
 
 // Exec implements driver.Stmt.Exec.
-// This function always return nil driver.Result.
+// Upon successful call, this function returns (*ResultDelete, nil).
 //
-// Note: this function expects the last argument is partition key value.
+// Note: this function expects the last argument is partition key value, unless DSN option AutoPartitionKey=true.
 func (s *StmtDelete) Exec(args []driver.Value) (driver.Result, error) {
+	result, _, err := s.delete(args, false)
+	if result == nil {
+		return nil, err
+	}
+	return result, err
+}
+
+// Query implements driver.Stmt.Query.
+// This function is available only if the statement has a RETURNING clause, it returns the removed document as a single
+// row (*ResultSelect), or no row if the document does not exist.
+func (s *StmtDelete) Query(args []driver.Value) (driver.Rows, error) {
+	if len(s.returning) == 0 {
+		return nil, errors.New("this operation is not supported, please use exec")
+	}
+	result, doc, err := s.delete(args, true)
+	if err != nil {
+		return nil, err
+	}
+	if !result.Successful {
+		doc = nil
+	}
+	return s.returningRows(s.returning, doc), nil
+}
+
+// delete removes the document. If fetch is true, the document is fetched before being removed and returned.
+func (s *StmtDelete) delete(args []driver.Value, fetch bool) (*ResultDelete, DocInfo, error) {
+	autoPk := s.isAutoPk()
+	valueArgs, pkValues := args, []interface{}(nil)
+	if !autoPk {
+		var err error
+		if valueArgs, pkValues, err = s.pkArgs(s.dbName, s.collName, args); err != nil {
+			return nil, nil, err
+		}
+	}
 	id := s.idStr
 	if s.id != nil {
 		ph := s.id.(placeholder)
-		if ph.index <= 0 || ph.index >= len(args) {
-			return nil, fmt.Errorf("invalid value index %d", ph.index)
+		if ph.index <= 0 || ph.index > len(valueArgs) {
+			return nil, nil, fmt.Errorf("invalid value index %d", ph.index)
+		}
+		id = fmt.Sprintf("%s", valueArgs[ph.index-1])
+	}
+	etag, err := _bindEtag(s.etag, valueArgs)
+	if err != nil {
+		return nil, nil, err
+	}
+	var doc DocInfo
+	if autoPk {
+		if doc, err = s.conn.restClient.lookupDocument(s.dbName, s.collName, id); err != nil {
+			return nil, nil, err
+		}
+		if doc == nil {
+			// consider "document not found" as successful operation
+			return &ResultDelete{Successful: false, StatusCode: 404}, nil, nil
+		}
+		if pkValues, err = s.conn.restClient.docPkValues(s.dbName, s.collName, doc); err != nil {
+			return nil, nil, err
+		}
+	}
+	if fetch && doc == nil {
+		getDocResult := s.conn.restClient.GetDocument(DocReq{DbName: s.dbName, CollName: s.collName, DocId: id, PartitionKeyValues: pkValues})
+		if err := getDocResult.Error(); _isDocumentNotFound(err) {
+			return &ResultDelete{Successful: false, StatusCode: 404}, nil, nil
+		} else if err != nil {
+			return nil, nil, err
 		}
-		id = fmt.Sprintf("%s", args[ph.index-1])
+		doc = getDocResult.DocInfo
 	}
 	restClient := s.conn.restClient.DeleteDocument(DocReq{DbName: s.dbName, CollName: s.collName, DocId: id,
-		PartitionKeyValues: []interface{}{args[s.numInput-1]}, // expect the last argument is partition key value
+		PartitionKeyValues: pkValues,
+		MatchEtag:          etag,
+		PreTriggers:        s.preTriggers,
+		PostTriggers:       s.postTriggers,
 	})
-	err := restClient.Error()
+	err = restClient.Error()
 	result := &ResultDelete{Successful: err == nil, StatusCode: restClient.StatusCode}
-	switch restClient.StatusCode {
-	case 403:
-		err = ErrForbidden
-	case 404:
-		// consider "document not found" as successful operation
-		// but database/collection not found is not!
-		if strings.Index(fmt.Sprintf("%s", err), "ResourceType: Document") >= 0 {
-			err = nil
-		} else {
-			err = ErrNotFound
-		}
+	// consider "document not found" as successful operation
+	// but database/collection not found is not!
+	if _isDocumentNotFound(err) {
+		err = nil
 	}
-	return result, err
-}
-
-// Query implements driver.Stmt.Query.
-// This function is not implemented, use Exec instead.
-func (s *StmtDelete) Query(args []driver.Value) (driver.Rows, error) {
-	return nil, errors.New("this operation is not supported, please use exec")
+	return result, doc, err
 }
 
 // ResultDelete captures the result from DELETE operation.
@@ -311,11 +719,99 @@ func (r *ResultDelete) RowsAffected() (int64, error) {
 
 /*----------------------------------------------------------------------*/
 
+// StmtDeleteByPk implements "DELETE...ALL" operation, which removes all documents of a logical partition (e.g. to wipe
+// the data of a tenant).
+//
+// Syntax:
+//     DELETE FROM <db-name>.<collection-name> WHERE pk=<pk-value> ALL
+//
+// - <pk-value> is either a placeholder or a literal (e.g. "\"tenant1\"", 123); the value of a hierarchical partition key
+// is supplied as a slice (e.g. []string{"tenant1", "user1"}).
+//
+// - Documents are deleted by the server in background, see RestClient.DeleteDocumentsByPartitionKey.
+//
+// Available since v0.1.1
+type StmtDeleteByPk struct {
+	*Stmt
+	dbName      string
+	collName    string
+	pkValue     interface{}
+	withOptsStr string
+}
+
+// rebind returns a copy of the statement bound to conn, see _rebindStmt.
+func (s *StmtDeleteByPk) rebind(conn *Conn) driver.Stmt {
+	clone := *s
+	clone.Stmt = s.Stmt.withConn(conn)
+	return &clone
+}
+
+func (s *StmtDeleteByPk) parse() error {
+	if err := s.Stmt.parseWithOpts(s.withOptsStr); err != nil {
+		return err
+	}
+	if ph, ok := s.pkValue.(placeholder); ok {
+		s.numInput = ph.index
+	}
+	return nil
+}
+
+func (s *StmtDeleteByPk) validate() error {
+	if s.dbName == "" || s.collName == "" {
+		return errors.New("database/collection is missing")
+	}
+	return nil
+}
+
+// Query implements driver.Stmt.Query.
+// This function is not implemented, use Exec instead.
+func (s *StmtDeleteByPk) Query(_ []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("this operation is not supported, please use exec")
+}
+
+// Exec implements driver.Stmt.Exec.
+// Upon successful call, this function returns (*ResultDeleteByPk, nil).
+func (s *StmtDeleteByPk) Exec(args []driver.Value) (driver.Result, error) {
+	pkValue := s.pkValue
+	if ph, ok := pkValue.(placeholder); ok {
+		if ph.index <= 0 || ph.index > len(args) {
+			return nil, fmt.Errorf("invalid value index %d", ph.index)
+		}
+		pkValue = args[ph.index-1]
+	}
+	restResult := s.conn.restClient.DeleteDocumentsByPartitionKey(DeleteByPkReq{DbName: s.dbName, CollName: s.collName,
+		PartitionKeyValues: _pkValues(pkValue)})
+	err := restResult.Error()
+	return &ResultDeleteByPk{Successful: err == nil}, err
+}
+
+// ResultDeleteByPk captures the result from DELETE...ALL operation.
+//
+// Available since v0.1.1
+type ResultDeleteByPk struct {
+	// Successful flags if the operation was accepted by the server.
+	Successful bool
+}
+
+// LastInsertId implements driver.Result.LastInsertId.
+func (r *ResultDeleteByPk) LastInsertId() (int64, error) {
+	return 0, errors.New("this operation is not supported")
+}
+
+// RowsAffected implements driver.Result.RowsAffected.
+// The number of deleted documents is not reported, as they are deleted by the server in background: this function
+// always returns 0.
+func (r *ResultDeleteByPk) RowsAffected() (int64, error) {
+	return 0, nil
+}
+
+/*----------------------------------------------------------------------*/
+
 // StmtSelect implements "SELECT" operation.
 // The "SELECT" query follows CosmosDB's SQL grammar (https://docs.microsoft.com/en-us/azure/cosmos-db/sql-query-select) with a few extensions:
 //
 // Syntax:
-//     SELECT [CROSS PARTITION] ... FROM <collection/table-name> ... WITH database|db=<db-name> [WITH collection|table=<collection/table-name>] [WITH cross_partition=true]
+//     SELECT [CROSS PARTITION] ... FROM <collection/table-name> ... WITH database|db=<db-name> [WITH collection|table=<collection/table-name>] [WITH cross_partition=true] [WITH consistency=<level>] [WITH objects_as_json=true|false] [WITH parse_time=true|false] [WITH flatten=true|false] [WITH continuation_limit_kb=<n>] [WITH enable_scan=true|false] [WITH pkrangeid=<partition-key-range-id>] [WITH max_parallelism=<n>] [WITH prefetch=<n>]
 //
 //     - (extension) If the collection is partitioned, specify "CROSS PARTITION" to allow execution across multiple partitions.
 //       This clause is not required if query is to be executed on a single partition.
@@ -323,16 +819,90 @@ func (r *ResultDelete) RowsAffected() (int64, error) {
 //     - (extension) Use "WITH database=<db-name>" (or "WITH db=<db-name>") to specify the database on which the query is to be executed.
 //     - (extension) Use "WITH collection=<coll-name>" (or "WITH table=<coll-name>") to specify the collection/table on which the query is to be executed.
 //       If not specified, collection/table name is extracted from the "FROM <collection/table-name>" clause.
+//     - (extension) Use "WITH consistency=eventual|session|bounded|strong" to override the consistency level of the query
+//       (available since v0.1.1). If not specified, the connection's DefaultConsistency (if any) is used.
+//     - (extension) Use "WITH objects_as_json=true" to return nested objects and arrays as JSON text ([]byte) instead of
+//       map[string]interface{}/[]interface{}, e.g. to scan them into string or json.RawMessage fields with sqlx
+//       (available since v0.1.1). If not specified, the connection's ObjectsAsJson setting is used.
+//     - (extension) Use "WITH parse_time=true" to return the system property _ts and RFC3339 string columns as time.Time
+//       (available since v0.1.1). If not specified, the connection's ParseTime setting is used.
+//     - (extension) Use "WITH flatten=true" to flatten nested objects into "parent.child" columns, e.g. "address.city"
+//       instead of an "address" map (available since v0.1.1). Arrays and empty objects are kept as values. Columns of an
+//       explicit projection are expanded in place, e.g. "SELECT c.id, c.address" returns "id", "address.city"... If not
+//       specified, the connection's Flatten setting is used.
+//     - (extension) Use "WITH continuation_limit_kb=<n>" to cap the size of continuation tokens returned by the server to
+//       n KB (available since v0.1.1). If not specified, the connection's ContinuationLimitKb setting is used.
+//     - (extension) Use "WITH enable_scan=true" to allow the query to filter on paths excluded from indexing (available
+//       since v0.1.1). Without it, such queries fail with an error matching ErrScanRequired.
+//     - (extension) Use "WITH pkrangeid=<partition-key-range-id>" to scope the query to one partition key range (physical
+//       partition, see RestClient.ListPartitionKeyRanges); cross-partition execution is implied (available since v0.1.1).
+//     - (extension) Use "WITH max_parallelism=<n>" to read up to n partition key ranges concurrently in cross-partition
+//       mode (available since v0.1.1); rows of different ranges are then interleaved. Queries with ORDER BY or GROUP BY
+//       are always read sequentially. If not specified, the connection's MaxParallelism setting (default 1) is used.
+//     - (extension) Use "WITH prefetch=<n>" to stream rows (available since v0.1.1): pages are read in background, up to
+//       n pages ahead of Next(), instead of reading all pages before Query returns; without explicit projection, columns
+//       are then the fields of the first page. If not specified, the connection's PrefetchPages setting (default 0, i.e.
+//       no streaming) is used.
 //     - (extension) Use placeholder syntax @i, $i or :i (where i denotes the i-th parameter, the first parameter is 1)
+//     - (extension) A slice bound to the only placeholder of an IN (...) expression, e.g. "WHERE c.id IN (:1)", is expanded
+//       into one parameter per element (available since v0.1.1). An empty slice is rejected.
+//     - SELECT DISTINCT: the server only removes duplicates within a partition and a page, so in cross-partition mode the
+//       driver also removes them client-side across partitions and pages (available since v0.1.1).
+//     - Columns: the (sorted) fields of the returned documents. If the query has an explicit projection, columns follow
+//       the select list instead (available since v0.1.1), named after the alias of an item ("<expression> [AS] <alias>"),
+//       otherwise after the last property of a property path (e.g. "city" for c.address.city), otherwise "$1", "$2"...
+//     - SELECT [VALUE] COUNT(...) [[AS] <alias>] in cross-partition mode (without GROUP BY): the gateway does not aggregate
+//       results across partitions, so the driver counts per partition key range and returns the sum as a single row,
+//       whose column is <alias> or "$1" (available since v0.1.1).
+//     - TOP <n> and OFFSET <m> LIMIT <k> (numbers or placeholders): the server applies them per partition, so in
+//       cross-partition mode the driver enforces them client-side across partitions and pages; OFFSET...LIMIT is sent as
+//       OFFSET 0 LIMIT <m+k> and the first m rows are skipped (available since v0.1.1).
 type StmtSelect struct {
 	*Stmt
 	isCrossPartition bool
+	consistency      string // (since v0.1.1) consistency level of the query
 	dbName           string
 	collName         string
 	selectQuery      string
 	placeholders     map[int]string
+	inPlaceholders   map[string]bool // (since v0.1.1) placeholders (e.g. @_1) that are the only item of an IN (...) expression
+
+	objectsAsJson bool // (since v0.1.1) return nested objects and arrays as JSON text
+	parseTime     bool // (since v0.1.1) return _ts and RFC3339 string columns as time.Time
+	isDistinct    bool // (since v0.1.1) true if the query is a SELECT DISTINCT
+	flatten       bool // (since v0.1.1) flatten nested objects into parent.child columns
+
+	top, offset, limit string // (since v0.1.1) values of TOP and OFFSET...LIMIT (numbers or placeholders), "" if not specified
+
+	continuationLimitKb int    // (since v0.1.1) size limit (in KB) of continuation tokens, 0 to use the connection's setting
+	enableScan          bool   // (since v0.1.1) allow the query to scan paths excluded from indexing
+	pkRangeId           string // (since v0.1.1) partition key range the query is scoped to, "" if not specified
+
+	maxParallelism int // (since v0.1.1) number of partition key ranges read concurrently by cross-partition queries
+	prefetch       int // (since v0.1.1) if > 0, rows are streamed with up to prefetch pages read ahead of Next()
+
+	countColumn string // (since v0.1.1) cross-partition SELECT COUNT(...): name of the count column, "" for other queries
+
+	columns []string // (since v0.1.1) column names of the explicit projection, in order, nil if columns are the fields of the documents
+}
+
+// rebind returns a copy of the statement bound to conn, see _rebindStmt.
+func (s *StmtSelect) rebind(conn *Conn) driver.Stmt {
+	clone := *s
+	clone.Stmt = s.Stmt.withConn(conn)
+	return &clone
 }
 
+var (
+	reSelectDistinct = regexp.MustCompile(`(?is)^SELECT\s+(TOP\s+\S+\s+)?DISTINCT\s`)
+	reSelectTop      = regexp.MustCompile(`(?is)^SELECT\s+TOP\s+(\d+|@_\d+)\s`)
+	reOffsetLimit    = regexp.MustCompile(`(?is)\sOFFSET\s+(\d+|@_\d+)\s+LIMIT\s+(\d+|@_\d+)\s*$`)
+	reOrderGroupBy   = regexp.MustCompile(`(?is)\s(ORDER|GROUP)\s+BY\s`)
+	reInPlaceholder  = regexp.MustCompile(`(?is)\sIN\s*\(\s*(@_\d+)\s*\)`)
+	reSelectCount    = regexp.MustCompile(`(?is)^SELECT\s+(VALUE\s+)?COUNT\s*\([^()]*\)\s*(?:(?:AS\s+)?\w+\s+)?FROM\s`)
+	reGroupBy        = regexp.MustCompile(`(?is)\sGROUP\s+BY\s`)
+)
+
 func (s *StmtSelect) parse(withOptsStr string) error {
 	if err := s.Stmt.parseWithOpts(withOptsStr); err != nil {
 		return err
@@ -354,82 +924,404 @@ func (s *StmtSelect) parse(withOptsStr string) error {
 		}
 		s.isCrossPartition = true
 	}
-
-	matches := reValPlaceholder.FindAllStringSubmatch(s.selectQuery, -1)
-	s.numInput = len(matches)
-	s.placeholders = make(map[int]string)
-	for _, match := range matches {
-		v, _ := strconv.Atoi(match[1])
-		key := "@_" + match[1]
-		s.placeholders[v] = key
-		if strings.HasSuffix(match[0], " ") {
-			key += " "
+	if v, ok := s.withOpts["CONSISTENCY"]; ok {
+		var err error
+		if s.consistency, err = _normalizeConsistencyLevel(v); err != nil {
+			return err
+		}
+		if s.consistency == "" {
+			return errors.New("cannot parse query (consistency level is empty)")
 		}
-		s.selectQuery = strings.ReplaceAll(s.selectQuery, match[0], key)
 	}
-
-	return nil
-}
-
-func (s *StmtSelect) validate() error {
-	if s.dbName == "" || s.collName == "" {
-		return errors.New("database/collection is missing")
+	s.objectsAsJson = s.conn != nil && s.conn.objectsAsJson
+	if v, ok := s.withOpts["OBJECTS_AS_JSON"]; ok {
+		var err error
+		if s.objectsAsJson, err = strconv.ParseBool(v); err != nil {
+			return errors.New("cannot parse query (invalid objects_as_json value), invalid token at: " + v)
+		}
+	}
+	s.parseTime = s.conn != nil && s.conn.parseTime
+	s.isDistinct = reSelectDistinct.MatchString(strings.TrimSpace(s.selectQuery))
+	if v, ok := s.withOpts["CONTINUATION_LIMIT_KB"]; ok {
+		var err error
+		if s.continuationLimitKb, err = strconv.Atoi(v); err != nil || s.continuationLimitKb <= 0 {
+			return errors.New("cannot parse query (invalid continuation_limit_kb value), invalid token at: " + v)
+		}
+	}
+	if v, ok := s.withOpts["ENABLE_SCAN"]; ok {
+		var err error
+		if s.enableScan, err = strconv.ParseBool(v); err != nil {
+			return errors.New("cannot parse query (invalid enable_scan value), invalid token at: " + v)
+		}
+	}
+	if v, ok := s.withOpts["PKRANGEID"]; ok {
+		if s.pkRangeId = strings.TrimSpace(v); s.pkRangeId == "" {
+			return errors.New("cannot parse query (pkrangeid is empty)")
+		}
+		s.isCrossPartition = true
+	}
+	if v, ok := s.withOpts["PARSE_TIME"]; ok {
+		var err error
+		if s.parseTime, err = strconv.ParseBool(v); err != nil {
+			return errors.New("cannot parse query (invalid parse_time value), invalid token at: " + v)
+		}
+	}
+	s.flatten = s.conn != nil && s.conn.flatten
+	if v, ok := s.withOpts["FLATTEN"]; ok {
+		var err error
+		if s.flatten, err = strconv.ParseBool(v); err != nil {
+			return errors.New("cannot parse query (invalid flatten value), invalid token at: " + v)
+		}
+	}
+	if s.conn != nil {
+		s.maxParallelism, s.prefetch = s.conn.maxParallelism, s.conn.prefetchPages
+	}
+	if v, ok := s.withOpts["MAX_PARALLELISM"]; ok {
+		var err error
+		if s.maxParallelism, err = strconv.Atoi(v); err != nil || s.maxParallelism <= 0 {
+			return errors.New("cannot parse query (invalid max_parallelism value), invalid token at: " + v)
+		}
+	}
+	if v, ok := s.withOpts["PREFETCH"]; ok {
+		var err error
+		if s.prefetch, err = strconv.Atoi(v); err != nil || s.prefetch < 0 {
+			return errors.New("cannot parse query (invalid prefetch value), invalid token at: " + v)
+		}
+	}
+
+	matches := reValPlaceholder.FindAllStringSubmatch(s.selectQuery, -1)
+	s.numInput = len(matches)
+	s.placeholders = make(map[int]string)
+	for _, match := range matches {
+		v, _ := strconv.Atoi(match[1])
+		key := "@_" + match[1]
+		s.placeholders[v] = key
+		if strings.HasSuffix(match[0], ",") {
+			key += ","
+		} else if strings.HasSuffix(match[0], " ") {
+			key += " "
+		}
+		s.selectQuery = strings.ReplaceAll(s.selectQuery, match[0], key)
+	}
+	s.inPlaceholders = make(map[string]bool)
+	for _, match := range reInPlaceholder.FindAllStringSubmatch(s.selectQuery, -1) {
+		s.inPlaceholders[match[1]] = true
+	}
+	if groups := reSelectTop.FindStringSubmatch(strings.TrimSpace(s.selectQuery)); groups != nil {
+		s.top = groups[1]
+	}
+	s.columns = _selectColumns(s.selectQuery)
+	if groups := reOffsetLimit.FindStringSubmatch(s.selectQuery); groups != nil {
+		s.offset, s.limit = groups[1], groups[2]
+	}
+	if groups := reSelectCount.FindStringSubmatch(strings.TrimSpace(s.selectQuery)); groups != nil && s.isCrossPartition &&
+		s.pkRangeId == "" && !reGroupBy.MatchString(s.selectQuery) {
+		// documents are counted per partition key range, the VALUE form is sent as an object so that counts can be summed
+		s.countColumn = "$1"
+		if len(s.columns) == 1 {
+			s.countColumn = s.columns[0]
+		}
+		if groups[1] != "" {
+			s.selectQuery = strings.Replace(s.selectQuery, groups[1], "", 1)
+		}
 	}
+
 	return nil
 }
 
-// Query implements driver.Stmt.Query.
-// Upon successful call, this function returns (*ResultSelect, nil).
-func (s *StmtSelect) Query(args []driver.Value) (driver.Rows, error) {
+func (s *StmtSelect) validate() error {
+	if s.dbName == "" || s.collName == "" {
+		return errors.New("database/collection is missing")
+	}
+	return nil
+}
+
+// _inListValues returns the elements of arg if it is a slice or an array other than []byte, to be expanded in an
+// IN (...) expression.
+func _inListValues(arg interface{}) ([]interface{}, bool) {
+	if _, ok := arg.([]byte); ok || arg == nil {
+		return nil, false
+	}
+	rv := reflect.ValueOf(arg)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	values := make([]interface{}, rv.Len())
+	for i := range values {
+		values[i] = rv.Index(i).Interface()
+	}
+	return values, true
+}
+
+// queryReq builds the request to execute the query with the arguments bound to its placeholders.
+//
+// (since v0.1.1) A slice bound to the only placeholder of an IN (...) expression is expanded into one parameter per
+// element, e.g. "c.id IN (@_1)" with []string{"a", "b"} becomes "c.id IN (@_1_1, @_1_2)".
+func (s *StmtSelect) queryReq(args []driver.Value) (QueryReq, error) {
 	params := make([]interface{}, 0)
+	expanded := make(map[string][]string)
 	for i, arg := range args {
 		v, ok := s.placeholders[i+1]
 		if !ok {
-			return nil, fmt.Errorf("there is no placeholder #%d", i+1)
+			return QueryReq{}, fmt.Errorf("there is no placeholder #%d", i+1)
+		}
+		if values, ok := _inListValues(arg); ok && s.inPlaceholders[v] {
+			if len(values) == 0 {
+				return QueryReq{}, fmt.Errorf("empty list bound to placeholder #%d of IN (...) expression", i+1)
+			}
+			names := make([]string, len(values))
+			for j, value := range values {
+				names[j] = fmt.Sprintf("%s_%d", v, j+1)
+				params = append(params, map[string]interface{}{"name": names[j], "value": value})
+			}
+			expanded[v] = names
+			continue
 		}
 		params = append(params, map[string]interface{}{"name": fmt.Sprintf("%s", v), "value": arg})
 	}
-	query := QueryReq{
+	query := s.selectQuery
+	if len(expanded) > 0 {
+		query = reInPlaceholder.ReplaceAllStringFunc(query, func(in string) string {
+			match := reInPlaceholder.FindStringSubmatch(in)
+			if names, ok := expanded[match[1]]; ok {
+				return strings.Replace(in, match[1], strings.Join(names, ", "), 1)
+			}
+			return in
+		})
+	}
+	if s.isCrossPartition && s.offset != "" {
+		window, err := s.newRowWindow(args)
+		if err != nil {
+			return QueryReq{}, err
+		}
+		loc := reOffsetLimit.FindStringIndex(query)
+		query = query[:loc[0]] + fmt.Sprintf(" OFFSET 0 LIMIT %d", window.skip+window.remaining)
+	}
+	return QueryReq{
 		DbName:                s.dbName,
 		CollName:              s.collName,
-		Query:                 s.selectQuery,
+		Query:                 query,
 		Params:                params,
 		CrossPartitionEnabled: s.isCrossPartition,
+		ConsistencyLevel:      s.consistency,
+		ContinuationLimitKb:   s.continuationLimitKb,
+		EnableScan:            s.enableScan,
+		PartitionKeyRangeId:   s.pkRangeId,
+	}, nil
+}
+
+// scanRequiredError wraps the error of a query filtering on paths excluded from indexing, explaining how to fix it.
+type scanRequiredError struct {
+	err error
+}
+
+// Error implements error.Error.
+func (e *scanRequiredError) Error() string {
+	return "query filters on paths excluded from indexing, add WITH enable_scan=true to allow scanning them: " + e.err.Error()
+}
+
+// Unwrap returns the wrapped error (a *CosmosError).
+func (e *scanRequiredError) Unwrap() error {
+	return e.err
+}
+
+// _explainScanRequired wraps err into a scanRequiredError if it matches ErrScanRequired, err is returned as-is otherwise.
+func _explainScanRequired(err error) error {
+	if errors.Is(err, ErrScanRequired) {
+		return &scanRequiredError{err: err}
+	}
+	return err
+}
+
+// _intArg resolves v, either a number or a placeholder (e.g. @_1), into a non-negative integer.
+func _intArg(v string, args []driver.Value) (int, error) {
+	value := interface{}(v)
+	if strings.HasPrefix(v, "@_") {
+		index, _ := strconv.Atoi(v[2:])
+		if index <= 0 || index > len(args) {
+			return 0, fmt.Errorf("invalid value index %d", index)
+		}
+		value = args[index-1]
+	}
+	n, err := strconv.ParseFloat(fmt.Sprintf("%v", value), 64)
+	if err != nil || n < 0 || n != float64(int(n)) {
+		return 0, fmt.Errorf("invalid TOP/OFFSET/LIMIT value <%v>", value)
+	}
+	return int(n), nil
+}
+
+// newRowWindow returns the window that enforces TOP and OFFSET...LIMIT of a cross-partition query across partitions and
+// pages, nil if the query does not need one.
+func (s *StmtSelect) newRowWindow(args []driver.Value) (*rowWindow, error) {
+	if !s.isCrossPartition || (s.top == "" && s.offset == "") {
+		return nil, nil
+	}
+	window := &rowWindow{remaining: -1}
+	var err error
+	if s.offset != "" {
+		if window.skip, err = _intArg(s.offset, args); err != nil {
+			return nil, err
+		}
+		if window.remaining, err = _intArg(s.limit, args); err != nil {
+			return nil, err
+		}
+	}
+	if s.top != "" {
+		top, err := _intArg(s.top, args)
+		if err != nil {
+			return nil, err
+		}
+		if window.remaining < 0 || top < window.remaining {
+			window.remaining = top
+		}
+	}
+	return window, nil
+}
+
+// rowWindow skips the first rows of a query result and caps the number of returned rows.
+type rowWindow struct {
+	skip      int // number of rows still to be skipped
+	remaining int // number of rows still to be returned, negative if unlimited
+}
+
+// take returns the rows of the page that fall into the window, and true if the window is full (no more page is needed).
+// A nil window returns all rows.
+func (w *rowWindow) take(docs []DocInfo) ([]DocInfo, bool) {
+	if w == nil {
+		return docs, false
+	}
+	if w.skip >= len(docs) {
+		w.skip -= len(docs)
+		return docs[:0], false
+	}
+	docs, w.skip = docs[w.skip:], 0
+	if w.remaining >= 0 && len(docs) >= w.remaining {
+		docs, w.remaining = docs[:w.remaining], 0
+		return docs, true
+	}
+	if w.remaining > 0 {
+		w.remaining -= len(docs)
+	}
+	return docs, false
+}
+
+// Query implements driver.Stmt.Query.
+// Upon successful call, this function returns (*ResultSelect, nil).
+func (s *StmtSelect) Query(args []driver.Value) (driver.Rows, error) {
+	query, err := s.queryReq(args)
+	if err != nil {
+		return nil, err
+	}
+	if s.countColumn != "" {
+		return s.queryCount(query)
+	}
+	window, err := s.newRowWindow(args)
+	if err != nil {
+		return nil, err
+	}
+	var pkRangeIds []string
+	if s.isParallel() {
+		if pkRangeIds, err = s.pkRangeIds(); err != nil {
+			return nil, err
+		}
+	}
+	stream := &resultSelectStream{
+		stream:   newPageStream(s.conn.restClient, query, pkRangeIds, s.maxParallelism, s.prefetch),
+		window:   window,
+		distinct: s.newDistinctFilter(),
+		flatten:  s.flatten,
 	}
 	documents := make([]DocInfo, 0)
-	var restResult *RespQueryDocs
-	for restResult = s.conn.restClient.QueryDocuments(query); restResult.Error() == nil; restResult = s.conn.restClient.QueryDocuments(query) {
-		documents = append(documents, restResult.Documents...)
-		if restResult.ContinuationToken == "" {
+	for docs, err := stream.fetch(); err != io.EOF; docs, err = stream.fetch() {
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, docs...)
+		if s.prefetch > 0 {
+			// rows are streamed, without explicit projection columns are the fields of the first page
 			break
 		}
-		query.ContinuationToken = restResult.ContinuationToken
 	}
-	err := restResult.Error()
-	var rows driver.Rows
-	if err == nil {
-		rows = &ResultSelect{count: len(documents), documents: documents, cursorCount: 0, columnList: make([]string, 0)}
-		if len(documents) > 0 {
-			doc := documents[0]
-			columnList := make([]string, len(doc))
-			i := 0
-			for colName := range doc {
-				columnList[i] = colName
-				i++
+	result := newResultSelect(documents)
+	if s.columns != nil && s.flatten {
+		result.columnList = _flattenColumns(s.columns, result.columnList)
+	} else if s.columns != nil {
+		result.columnList = s.columns
+	}
+	result.objectsAsJson = s.objectsAsJson
+	result.parseTime = s.parseTime
+	if s.prefetch > 0 {
+		stream.ResultSelect = result
+		return stream, nil
+	}
+	return result, nil
+}
+
+// pkRangeIds returns the ids of the partition key ranges of the queried collection.
+func (s *StmtSelect) pkRangeIds() ([]string, error) {
+	restResult := s.conn.restClient.ListPartitionKeyRanges(s.dbName, s.collName)
+	if err := restResult.Error(); err != nil {
+		return nil, err
+	}
+	pkRangeIds := make([]string, 0, len(restResult.PartitionKeyRanges))
+	for _, pkrange := range restResult.PartitionKeyRanges {
+		pkRangeIds = append(pkRangeIds, pkrange.Id)
+	}
+	return pkRangeIds, nil
+}
+
+// queryCount executes a cross-partition SELECT COUNT(...) query: the gateway does not aggregate results across
+// partitions, so the query is executed on each partition key range and the partial counts of all pages are summed into
+// a single row.
+func (s *StmtSelect) queryCount(query QueryReq) (driver.Rows, error) {
+	pkRangeIds, err := s.pkRangeIds()
+	if err != nil {
+		return nil, err
+	}
+	stream := newPageStream(s.conn.restClient, query, pkRangeIds, s.maxParallelism, 0)
+	var total int64
+	for docs, err := stream.next(); err != io.EOF; docs, err = stream.next() {
+		if err != nil {
+			stream.close()
+			return nil, err
+		}
+		for _, doc := range docs {
+			count, err := _toInt64(doc[s.countColumn])
+			if err != nil {
+				stream.close()
+				return nil, fmt.Errorf("invalid partial count %#v: %s", doc[s.countColumn], err)
 			}
-			sort.Strings(columnList)
-			rows.(*ResultSelect).columnList = columnList
+			total += count
 		}
 	}
-	switch restResult.StatusCode {
-	case 403:
-		err = ErrForbidden
-	case 404:
-		err = ErrNotFound
-		// case 409:
-		// 	err = ErrConflict
+	return newResultSelect([]DocInfo{{s.countColumn: total}}), nil
+}
+
+// _toInt64 converts a number decoded from JSON (float64, json.Number or integer types) to int64, nil is 0.
+func _toInt64(v interface{}) (int64, error) {
+	switch n := v.(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		return int64(n), nil
+	case json.Number:
+		return n.Int64()
 	}
-	return rows, err
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint()), nil
+	}
+	return 0, fmt.Errorf("%T is not a number", v)
+}
+
+// isParallel returns true if the query is executed on partition key ranges concurrently: it must be a cross-partition
+// query that is not scoped to a partition key range, and whose result does not depend on the order pages are read in
+// (no ORDER BY nor GROUP BY).
+func (s *StmtSelect) isParallel() bool {
+	return s.maxParallelism > 1 && s.isCrossPartition && s.pkRangeId == "" && !reOrderGroupBy.MatchString(s.selectQuery)
 }
 
 // Exec implements driver.Stmt.Exec.
@@ -438,12 +1330,79 @@ func (s *StmtSelect) Exec(args []driver.Value) (driver.Result, error) {
 	return nil, errors.New("this operation is not supported, please use query")
 }
 
+// newDistinctFilter returns the filter that removes duplicated rows of a cross-partition SELECT DISTINCT query across
+// partitions and pages, nil if the query does not need one.
+func (s *StmtSelect) newDistinctFilter() *distinctFilter {
+	if !s.isDistinct || !s.isCrossPartition {
+		return nil
+	}
+	return &distinctFilter{seen: make(map[[sha256.Size]byte]bool)}
+}
+
+// distinctFilter removes rows that have been returned before, comparing hashes of their JSON encoding (keys of nested
+// objects are sorted by encoding/json, so that equal rows have equal encodings).
+type distinctFilter struct {
+	seen map[[sha256.Size]byte]bool
+}
+
+// filter returns the rows of the page that have not been seen before. A nil filter returns all rows.
+func (f *distinctFilter) filter(docs []DocInfo) []DocInfo {
+	if f == nil {
+		return docs
+	}
+	result := make([]DocInfo, 0, len(docs))
+	for _, doc := range docs {
+		js, _ := json.Marshal(doc)
+		if hash := sha256.Sum256(js); !f.seen[hash] {
+			f.seen[hash] = true
+			result = append(result, doc)
+		}
+	}
+	return result
+}
+
 // ResultSelect captures the result from SELECT operation.
 type ResultSelect struct {
 	count       int
 	documents   []DocInfo
 	cursorCount int
 	columnList  []string
+
+	objectsAsJson bool // (since v0.1.1) return nested objects and arrays as JSON text
+	parseTime     bool // (since v0.1.1) return _ts and RFC3339 string columns as time.Time
+}
+
+// newResultSelect builds a ResultSelect from the documents, column list is the sorted union of fields of all documents
+// so that every row has the same columns (fields missing from a document are returned as nil).
+func newResultSelect(documents []DocInfo) *ResultSelect {
+	result := &ResultSelect{count: len(documents), documents: documents, cursorCount: 0, columnList: make([]string, 0)}
+	colNames := make(map[string]bool)
+	for _, doc := range documents {
+		for colName := range doc {
+			if !colNames[colName] {
+				colNames[colName] = true
+				result.columnList = append(result.columnList, colName)
+			}
+		}
+	}
+	sort.Strings(result.columnList)
+	return result
+}
+
+// returningRows builds the rows of a RETURNING clause from the document: all fields of the document if fields is
+// ["*"], otherwise only the listed fields in the listed order. No row is returned if doc is nil.
+func (s *Stmt) returningRows(fields []string, doc DocInfo) *ResultSelect {
+	documents := make([]DocInfo, 0, 1)
+	if doc != nil {
+		documents = append(documents, doc)
+	}
+	result := newResultSelect(documents)
+	if len(fields) != 1 || fields[0] != "*" {
+		result.columnList = fields
+	}
+	result.objectsAsJson = s.conn != nil && s.conn.objectsAsJson
+	result.parseTime = s.conn != nil && s.conn.parseTime
+	return result
 }
 
 // Columns implements driver.Rows.Columns.
@@ -465,18 +1424,166 @@ func (r *ResultSelect) Next(dest []driver.Value) error {
 	r.cursorCount++
 	for i, colName := range r.columnList {
 		dest[i] = rowData[colName]
+		if r.parseTime {
+			dest[i] = _parseTimeValue(colName, dest[i])
+		}
+		if r.objectsAsJson {
+			switch dest[i].(type) {
+			case map[string]interface{}, []interface{}:
+				js, err := json.Marshal(dest[i])
+				if err != nil {
+					return err
+				}
+				dest[i] = js
+			}
+		}
 	}
 	return nil
 }
 
 /*----------------------------------------------------------------------*/
 
+// StmtSelectChanges implements "SELECT CHANGES" operation, which reads the change feed of a collection.
+//
+// Syntax:
+//     SELECT CHANGES FROM [<db-name>.]<collection-name> [WITH CONTINUATION=<continuation>] [WITH PKRANGEID=<partition-key-range-id>] [WITH MAX_ITEM_COUNT=<n>]
+//
+//     - CONTINUATION: continuation returned from the previous call, either a literal or a placeholder (e.g. :1, @2 or $3).
+//       If not specified, changes are read from the beginning. Use CONTINUATION=* to read only new changes.
+//     - PKRANGEID: read changes of the specified partition key range.
+//     - MAX_ITEM_COUNT: maximum number of changed documents returned per call.
+//
+// Each returned row is a changed document, with an extra column "_continuation" holding the continuation to be used for
+// the next call.
+//
+// Available since v0.1.1
+type StmtSelectChanges struct {
+	*Stmt
+	dbName       string
+	collName     string
+	withOptsStr  string
+	continuation interface{} // string or placeholder
+	pkRangeId    string
+	maxItemCount int
+}
+
+// rebind returns a copy of the statement bound to conn, see _rebindStmt.
+func (s *StmtSelectChanges) rebind(conn *Conn) driver.Stmt {
+	clone := *s
+	clone.Stmt = s.Stmt.withConn(conn)
+	return &clone
+}
+
+func (s *StmtSelectChanges) parse() error {
+	if err := s.Stmt.parseWithOpts(s.withOptsStr); err != nil {
+		return err
+	}
+	if v, ok := s.withOpts["CONTINUATION"]; ok {
+		if loc := reValPlaceholder.FindStringSubmatchIndex(v); loc != nil && loc[0] == 0 && loc[1] == len(v) {
+			index, err := strconv.Atoi(v[loc[2]:loc[3]])
+			if err != nil || index <= 0 {
+				return fmt.Errorf("invalid CONTINUATION value: %s", v)
+			}
+			s.continuation = placeholder{index}
+			s.numInput = index
+		} else {
+			s.continuation = v
+		}
+	}
+	s.pkRangeId = s.withOpts["PKRANGEID"]
+	if v, ok := s.withOpts["MAX_ITEM_COUNT"]; ok {
+		maxItemCount, err := strconv.ParseInt(v, 10, 32)
+		if err != nil || maxItemCount <= 0 {
+			return fmt.Errorf("invalid MAX_ITEM_COUNT value: %s", v)
+		}
+		s.maxItemCount = int(maxItemCount)
+	}
+	return nil
+}
+
+func (s *StmtSelectChanges) validate() error {
+	if s.dbName == "" || s.collName == "" {
+		return errors.New("database/collection is missing")
+	}
+	return nil
+}
+
+// Query implements driver.Stmt.Query.
+// Upon successful call, this function returns (*ResultChangeFeed, nil).
+func (s *StmtSelectChanges) Query(args []driver.Value) (driver.Rows, error) {
+	req := ChangeFeedReq{DbName: s.dbName, CollName: s.collName, PartitionKeyRangeId: s.pkRangeId, MaxItemCount: s.maxItemCount}
+	switch v := s.continuation.(type) {
+	case placeholder:
+		if v.index <= 0 || v.index > len(args) {
+			return nil, fmt.Errorf("invalid value index %d", v.index)
+		}
+		req.Continuation = fmt.Sprintf("%s", args[v.index-1])
+	case string:
+		req.Continuation = v
+	}
+	restResult := s.conn.restClient.ReadChangeFeed(req)
+	err := restResult.Error()
+	var rows driver.Rows
+	if err == nil {
+		result := newResultSelect(restResult.Documents)
+		result.parseTime = s.conn.parseTime
+		rows = &ResultChangeFeed{ResultSelect: result, Continuation: restResult.Continuation}
+	}
+	return rows, err
+}
+
+// Exec implements driver.Stmt.Exec.
+// This function is not implemented, use Query instead.
+func (s *StmtSelectChanges) Exec(_ []driver.Value) (driver.Result, error) {
+	return nil, errors.New("this operation is not supported, please use query")
+}
+
+// ResultChangeFeed captures the result from SELECT CHANGES operation.
+//
+// Available since v0.1.1
+type ResultChangeFeed struct {
+	*ResultSelect
+	// Continuation is to be used to read subsequent changes.
+	Continuation string
+}
+
+// Columns implements driver.Rows.Columns.
+func (r *ResultChangeFeed) Columns() []string {
+	columnList := make([]string, len(r.columnList), len(r.columnList)+1)
+	copy(columnList, r.columnList)
+	return append(columnList, "_continuation")
+}
+
+// Next implements driver.Rows.Next.
+func (r *ResultChangeFeed) Next(dest []driver.Value) error {
+	if err := r.ResultSelect.Next(dest[:len(dest)-1]); err != nil {
+		return err
+	}
+	dest[len(dest)-1] = r.Continuation
+	return nil
+}
+
+/*----------------------------------------------------------------------*/
+
 // StmtUpdate implements "UPDATE" operation.
 //
 // Syntax:
-//     UPDATE <db-name>.<collection-name> SET <field-name>=<value>[,<field-name>=<value>]*, WHERE id=<id-value>
+//     UPDATE [OR INSERT] <db-name>.<collection-name> SET <field-name>=<value>[,<field-name>=<value>]*, WHERE id=<id-value> [AND _etag=<etag-value>]
+//         [WITH PRETRIGGER=<triggers>] [WITH POSTTRIGGER=<triggers>]
 //
+//     - (available since v0.1.1) With OR INSERT, if the document does not exist, a new document is created from the id
+//       and the SET clause (ResultUpdate.Inserted is true). OR INSERT cannot be used together with an _etag condition.
+//     - (available since v0.1.1) "RETURNING *|<field>[,<field>]*" can be appended after the WHERE clause (before the WITH
+//       options); the statement is then executed with Query, which returns the updated (or inserted) document as a row.
 //     - <id-value> is treated as a string. `WHERE id=abc` has the same effect as `WHERE id="abc"`.
+//     - (available since v0.1.1) <etag-value> is either a placeholder or a double-quoted string literal. If specified, the
+//       document is updated only if its current etag matches, otherwise ErrPreconditionFailed is returned. The SET
+//       fields are then patched without reading the document first (partial document update), unless DSN option
+//       AutoPartitionKey=true, the SET clause has more than 10 fields or fields of the collection are encrypted.
+//     - Without <etag-value>, the document is read, then replaced with If-Match set to the etag read (2 requests).
+//     - (available since v0.1.1) With DSN option AutoPartitionKey=true, the document is looked up by id across all partitions
+//       to determine its partition key value, which must not be supplied as the last argument.
+//     - (available since v0.1.1) If the collection is non-partitioned, the partition key value must not be supplied.
 //     - <value> is either:
 //       - a placeholder (e.g. :1, @2 or $3)
 //       - a null
@@ -489,17 +1596,31 @@ func (r *ResultSelect) Next(dest []driver.Value) error {
 //         - a null value in JSON (include the double quotes): "null"
 //         - a map value in JSON (include the double quotes): "{\"key\":\"value\"}"
 //         - a list value in JSON (include the double quotes): "[1,true,null,\"string\"]"
+//     - (available since v0.1.1) PRETRIGGER/POSTTRIGGER: comma-separated list of pre/post-triggers to be invoked with the operation.
 //
 // Currently UPDATE only updates one document specified by id.
 type StmtUpdate struct {
 	*Stmt
-	dbName    string
-	collName  string
-	updateStr string
-	idStr     string
-	id        interface{}
-	fields    []string
-	values    []interface{}
+	dbName       string
+	collName     string
+	idStr        string
+	id           interface{}
+	fields       []string
+	values       []interface{}
+	withOptsStr  string
+	preTriggers  []string
+	postTriggers []string
+
+	etag      interface{} // (since v0.1.1) etag condition, nil if not specified
+	orInsert  bool        // (since v0.1.1) UPDATE OR INSERT: create the document if it does not exist
+	returning []string    // (since v0.1.1) fields of the RETURNING clause, nil if not specified
+}
+
+// rebind returns a copy of the statement bound to conn, see _rebindStmt.
+func (s *StmtUpdate) rebind(conn *Conn) driver.Stmt {
+	clone := *s
+	clone.Stmt = s.Stmt.withConn(conn)
+	return &clone
 }
 
 func (s *StmtUpdate) _parseId() error {
@@ -525,10 +1646,6 @@ func (s *StmtUpdate) _parseId() error {
 	return nil
 }
 
-var (
-	reFieldPart = regexp.MustCompile(`\s*([\w\-]+)\s*=`)
-)
-
 func _isSpace(r rune) bool {
 	switch r {
 	case '\t', '\n', '\v', '\f', '\r', ' ', 0x85, 0xA0, '=':
@@ -537,46 +1654,28 @@ func _isSpace(r rune) bool {
 	return false
 }
 
-func (s *StmtUpdate) _parseUpdateClause() error {
-	s.fields = make([]string, 0)
-	s.values = make([]interface{}, 0)
-	for temp := strings.TrimSpace(s.updateStr); temp != ""; temp = strings.TrimSpace(temp) {
-		// firstly, extract the field name
-		if loc := reFieldPart.FindStringIndex(temp); loc != nil && loc[0] == 0 {
-			field := strings.TrimFunc(temp[loc[0]:loc[1]], func(r rune) bool { return _isSpace(r) || r == '=' })
-			s.fields = append(s.fields, field)
-			temp = strings.TrimSpace(temp[loc[1]:])
-		} else {
-			return errors.New("(field) cannot parse query, invalid token at: " + temp)
-		}
-
-		// secondly, parse the value part
-		value, leftOver, err := _parseValue(temp, ',')
-		if err == nil {
-			s.values = append(s.values, value)
-			temp = leftOver
-			switch value.(type) {
-			case placeholder:
-				s.numInput++
-			}
-			continue
-		}
+func (s *StmtUpdate) parse() error {
+	if err := s.Stmt.parseWithOpts(s.withOptsStr); err != nil {
 		return err
 	}
-	return nil
-}
+	s.preTriggers, s.postTriggers = _parseTriggerOpts(s.withOpts)
 
-func (s *StmtUpdate) parse() error {
 	s.numInput = 1
+	if s.isAutoPk() {
+		s.numInput = 0
+	}
 
+	if _, ok := s.etag.(placeholder); ok {
+		s.numInput++
+	}
 	if err := s._parseId(); err != nil {
 		return err
 	}
-
-	if err := s._parseUpdateClause(); err != nil {
-		return err
+	for _, value := range s.values {
+		if _, ok := value.(placeholder); ok {
+			s.numInput++
+		}
 	}
-
 	return nil
 }
 
@@ -590,6 +1689,9 @@ func (s *StmtUpdate) validate() error {
 	if len(s.fields) == 0 {
 		return errors.New("invalid query: SET clause is empty")
 	}
+	if s.orInsert && s.etag != nil {
+		return errors.New("invalid query: _etag condition cannot be used with UPDATE OR INSERT")
+	}
 	// if len(s.fields) != len(s.values) {
 	// 	return fmt.Errorf("number of field (%d) does not match number of input value (%d)", len(s.fields), len(s.values))
 	// }
@@ -599,76 +1701,175 @@ func (s *StmtUpdate) validate() error {
 // Exec implements driver.Stmt.Exec.
 // Upon successful call, this function returns (*ResultUpdate, nil).
 //
-// Note: this function expects the last argument is partition key value.
+// Note: this function expects the last argument is partition key value, unless DSN option AutoPartitionKey=true.
 func (s *StmtUpdate) Exec(args []driver.Value) (driver.Result, error) {
+	result, _, err := s.update(args)
+	if result == nil {
+		return nil, err
+	}
+	return result, err
+}
+
+// Query implements driver.Stmt.Query.
+// This function is available only if the statement has a RETURNING clause, it returns the updated (or inserted)
+// document as a single row (*ResultSelect), or no row if the document does not exist.
+func (s *StmtUpdate) Query(args []driver.Value) (driver.Rows, error) {
+	if len(s.returning) == 0 {
+		return nil, errors.New("this operation is not supported, please use exec")
+	}
+	_, doc, err := s.update(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.returningRows(s.returning, doc), nil
+}
+
+// update replaces (or inserts) the document, returning the result and the written document.
+func (s *StmtUpdate) update(args []driver.Value) (*ResultUpdate, DocInfo, error) {
+	autoPk := s.isAutoPk()
+	valueArgs, pkValues := args, []interface{}(nil)
+	if !autoPk {
+		var err error
+		if valueArgs, pkValues, err = s.pkArgs(s.dbName, s.collName, args); err != nil {
+			return nil, nil, err
+		}
+	}
 	// firstly, fetch the document
 	id := s.idStr
 	if s.id != nil {
 		ph := s.id.(placeholder)
-		if ph.index <= 0 || ph.index >= len(args) {
-			return nil, fmt.Errorf("invalid value index %d", ph.index)
+		if ph.index <= 0 || ph.index > len(valueArgs) {
+			return nil, nil, fmt.Errorf("invalid value index %d", ph.index)
 		}
-		id = fmt.Sprintf("%s", args[ph.index-1])
+		id = fmt.Sprintf("%s", valueArgs[ph.index-1])
+	}
+	if s.etag != nil && !autoPk && len(s.fields) <= maxPatchOperations && !s.conn.restClient.encryptor.hasPolicy(s.dbName, s.collName) {
+		return s.patch(id, pkValues, valueArgs)
 	}
-	docReq := DocReq{DbName: s.dbName, CollName: s.collName, DocId: id, PartitionKeyValues: []interface{}{args[len(args)-1]}}
-	getDocResult := s.conn.restClient.GetDocument(docReq)
-	if err := getDocResult.Error(); err != nil {
-		if getDocResult.StatusCode == 404 {
+	var doc DocInfo
+	if autoPk {
+		var err error
+		if doc, err = s.conn.restClient.lookupDocument(s.dbName, s.collName, id); err != nil {
+			return nil, nil, err
+		}
+		if doc == nil && s.orInsert {
+			return s.insert(id, nil, valueArgs)
+		}
+		if doc == nil {
+			// consider "document not found" as successful operation
+			return &ResultUpdate{Successful: false}, nil, nil
+		}
+		if pkValues, err = s.conn.restClient.docPkValues(s.dbName, s.collName, doc); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		docReq := DocReq{DbName: s.dbName, CollName: s.collName, DocId: id, PartitionKeyValues: pkValues}
+		getDocResult := s.conn.restClient.GetDocument(docReq)
+		if err := getDocResult.Error(); err != nil {
 			// consider "document not found" as successful operation
 			// but database/collection not found is not!
-			if strings.Index(fmt.Sprintf("%s", err), "ResourceType: Document") >= 0 {
-				return &ResultUpdate{Successful: false}, nil
+			if _isDocumentNotFound(err) && s.orInsert {
+				return s.insert(id, pkValues, valueArgs)
+			}
+			if _isDocumentNotFound(err) {
+				return &ResultUpdate{Successful: false}, nil, nil
 			}
-			return nil, ErrNotFound
+			return nil, nil, err
+		}
+		doc = getDocResult.DocInfo
+	}
+	etag := doc.Etag()
+	if s.etag != nil {
+		// optimistic concurrency: the document is replaced only if it has not been modified since the caller read it
+		var err error
+		if etag, err = _bindEtag(s.etag, valueArgs); err != nil {
+			return nil, nil, err
 		}
-		return nil, getDocResult.Error()
 	}
-	etag := getDocResult.DocInfo.Etag()
-	spec := DocumentSpec{DbName: s.dbName, CollName: s.collName, PartitionKeyValues: []interface{}{args[len(args)-1]}, DocumentData: getDocResult.DocInfo.RemoveSystemAttrs()}
+	spec := DocumentSpec{DbName: s.dbName, CollName: s.collName, PartitionKeyValues: pkValues, DocumentData: doc.RemoveSystemAttrs(),
+		PreTriggers: s.preTriggers, PostTriggers: s.postTriggers}
+	if err := s.applySet(spec.DocumentData, valueArgs); err != nil {
+		return nil, nil, err
+	}
+	replaceDocResult := s.conn.restClient.ReplaceDocument(etag, spec)
+	result := &ResultUpdate{Successful: replaceDocResult.Error() == nil}
+	err := replaceDocResult.Error()
+	// race case, but possible: consider "document not found" as successful operation
+	// but database/collection not found is not!
+	if _isDocumentNotFound(err) {
+		return result, nil, nil
+	}
+	return result, replaceDocResult.DocInfo, err
+}
+
+// patch sets the fields of the SET clause to the document, if its current etag matches, without reading it first.
+func (s *StmtUpdate) patch(id string, pkValues []interface{}, valueArgs []driver.Value) (*ResultUpdate, DocInfo, error) {
+	etag, err := _bindEtag(s.etag, valueArgs)
+	if err != nil {
+		return nil, nil, err
+	}
+	values := make(map[string]interface{}, len(s.fields))
+	if err := s.applySet(values, valueArgs); err != nil {
+		return nil, nil, err
+	}
+	fieldValues := make([]interface{}, len(s.fields))
+	for i, field := range s.fields {
+		fieldValues[i] = values[field]
+	}
+	docReq := DocReq{DbName: s.dbName, CollName: s.collName, DocId: id, PartitionKeyValues: pkValues, MatchEtag: etag,
+		PreTriggers: s.preTriggers, PostTriggers: s.postTriggers}
+	patchDocResult := s.conn.restClient.setDocumentFields(docReq, s.fields, fieldValues)
+	err = patchDocResult.Error()
+	// consider "document not found" as successful operation
+	// but database/collection not found is not!
+	if _isDocumentNotFound(err) {
+		return &ResultUpdate{Successful: false}, nil, nil
+	}
+	return &ResultUpdate{Successful: err == nil}, patchDocResult.DocInfo, err
+}
+
+// applySet sets the fields of the SET clause to the document.
+func (s *StmtUpdate) applySet(doc map[string]interface{}, valueArgs []driver.Value) error {
 	for i := 0; i < len(s.fields); i++ {
 		switch s.values[i].(type) {
 		case placeholder:
 			ph := s.values[i].(placeholder)
-			if ph.index <= 0 || ph.index >= len(args) {
-				return nil, fmt.Errorf("invalid value index %d", ph.index)
+			if ph.index <= 0 || ph.index > len(valueArgs) {
+				return fmt.Errorf("invalid value index %d", ph.index)
 			}
-			spec.DocumentData[s.fields[i]] = args[ph.index-1]
+			doc[s.fields[i]] = valueArgs[ph.index-1]
 		default:
-			spec.DocumentData[s.fields[i]] = s.values[i]
+			doc[s.fields[i]] = s.values[i]
 		}
 	}
-	replaceDocResult := s.conn.restClient.ReplaceDocument(etag, spec)
-	result := &ResultUpdate{Successful: replaceDocResult.Error() == nil}
-	err := replaceDocResult.Error()
-	switch replaceDocResult.StatusCode {
-	case 403:
-		err = ErrForbidden
-	case 404: // race case, but possible
-		// consider "document not found" as successful operation
-		// but database/collection not found is not!
-		if strings.Index(fmt.Sprintf("%s", err), "ResourceType: Document") >= 0 {
-			err = nil
-		} else {
-			err = ErrNotFound
-		}
-	case 409:
-		err = ErrConflict
-	case 412:
-		err = nil
-	}
-	return result, err
+	return nil
 }
 
-// Query implements driver.Stmt.Query.
-// This function is not implemented, use Exec instead.
-func (s *StmtUpdate) Query(args []driver.Value) (driver.Rows, error) {
-	return nil, errors.New("this operation is not supported, please use exec")
+// insert creates the document from the id and the SET clause (UPDATE OR INSERT), pkValues is nil if the partition key
+// value is to be extracted from the document.
+func (s *StmtUpdate) insert(id string, pkValues []interface{}, valueArgs []driver.Value) (*ResultUpdate, DocInfo, error) {
+	spec := DocumentSpec{DbName: s.dbName, CollName: s.collName, PartitionKeyValues: pkValues,
+		DocumentData: map[string]interface{}{"id": id}, PreTriggers: s.preTriggers, PostTriggers: s.postTriggers}
+	if err := s.applySet(spec.DocumentData, valueArgs); err != nil {
+		return nil, nil, err
+	}
+	if pkValues == nil {
+		var err error
+		if spec.PartitionKeyValues, err = s.conn.restClient.docPkValues(s.dbName, s.collName, spec.DocumentData); err != nil {
+			return nil, nil, err
+		}
+	}
+	restResult := s.conn.restClient.CreateDocument(spec)
+	err := restResult.Error()
+	return &ResultUpdate{Successful: err == nil, Inserted: err == nil}, restResult.DocInfo, err
 }
 
 // ResultUpdate captures the result from UPDATE operation.
 type ResultUpdate struct {
 	// Successful flags if the operation was successful or not.
 	Successful bool
+	// Inserted flags if the document did not exist and was created by UPDATE OR INSERT (available since v0.1.1).
+	Inserted bool
 }
 
 // LastInsertId implements driver.Result.LastInsertId.
@@ -683,3 +1884,168 @@ func (r *ResultUpdate) RowsAffected() (int64, error) {
 	}
 	return 0, nil
 }
+
+/*----------------------------------------------------------------------*/
+
+// StmtLoad implements "LOAD" operation: streams documents from a local NDJSON/CSV file into a collection using the bulk API.
+//
+// Syntax:
+//     LOAD [DATA] '<file-path>' INTO <db-name>.<collection-name> [WITH FORMAT=ndjson|csv] [WITH UPSERT=true] [WITH BATCH_SIZE=<n>] [WITH CONCURRENCY=<n>]
+//
+// - <file-path> is a single-quoted or double-quoted string literal, or a placeholder.
+//
+// - FORMAT is detected from file extension if not specified: ".csv" files are treated as CSV, other files are treated as NDJSON.
+//
+// - If UPSERT=true, existing documents are replaced, otherwise loading an existing document fails.
+//
+// - As the file path may come from the query text, reading local files must be enabled with connection string option
+// AllowLoadLocalFiles=true; otherwise the statement is rejected. Use RestClient.LoadDocuments to load from an io.Reader.
+//
+// Available since v0.1.1
+type StmtLoad struct {
+	*Stmt
+	dbName      string
+	collName    string
+	pathStr     string
+	path        interface{} // string or placeholder
+	withOptsStr string
+	format      string
+	isUpsert    bool
+	batchSize   int
+	concurrency int
+}
+
+// rebind returns a copy of the statement bound to conn, see _rebindStmt.
+func (s *StmtLoad) rebind(conn *Conn) driver.Stmt {
+	clone := *s
+	clone.Stmt = s.Stmt.withConn(conn)
+	return &clone
+}
+
+func (s *StmtLoad) parse() error {
+	if err := s.Stmt.parseWithOpts(s.withOptsStr); err != nil {
+		return err
+	}
+	switch {
+	case strings.HasPrefix(s.pathStr, "'"):
+		s.path = s.pathStr[1 : len(s.pathStr)-1]
+	case strings.HasPrefix(s.pathStr, `"`):
+		path, err := strconv.Unquote(s.pathStr)
+		if err != nil {
+			return fmt.Errorf("invalid file path %s: %s", s.pathStr, err)
+		}
+		s.path = path
+	default:
+		index, err := strconv.Atoi(s.pathStr[1:])
+		if err != nil || index <= 0 {
+			return fmt.Errorf("invalid file path placeholder: %s", s.pathStr)
+		}
+		s.path = placeholder{index}
+		s.numInput = index
+	}
+	s.format = strings.ToLower(s.withOpts["FORMAT"])
+	if v, ok := s.withOpts["UPSERT"]; ok {
+		isUpsert, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid UPSERT value: %s", v)
+		}
+		s.isUpsert = isUpsert
+	}
+	for key, target := range map[string]*int{"BATCH_SIZE": &s.batchSize, "CONCURRENCY": &s.concurrency} {
+		if v, ok := s.withOpts[key]; ok {
+			value, err := strconv.ParseInt(v, 10, 32)
+			if err != nil || value <= 0 {
+				return fmt.Errorf("invalid %s value: %s", key, v)
+			}
+			*target = int(value)
+		}
+	}
+	return nil
+}
+
+func (s *StmtLoad) validate() error {
+	if s.dbName == "" || s.collName == "" {
+		return errors.New("database/collection is missing")
+	}
+	if s.format != "" && s.format != LoadFormatNdjson && s.format != LoadFormatCsv {
+		return fmt.Errorf("invalid FORMAT value: %s", s.format)
+	}
+	if path, ok := s.path.(string); ok && path == "" {
+		return errors.New("file path is missing")
+	}
+	return nil
+}
+
+// Exec implements driver.Stmt.Exec.
+// Upon successful call, this function returns (*ResultLoad, nil).
+//
+// If some documents could not be loaded, this function returns the *ResultLoad together with an error describing the first failed line.
+func (s *StmtLoad) Exec(args []driver.Value) (driver.Result, error) {
+	if !s.conn.allowLoadLocalFiles {
+		return nil, errors.New("LOAD is disabled, set AllowLoadLocalFiles=true in the connection string to allow reading local files")
+	}
+	var path string
+	switch v := s.path.(type) {
+	case placeholder:
+		if v.index <= 0 || v.index > len(args) {
+			return nil, fmt.Errorf("invalid value index %d", v.index)
+		}
+		path = fmt.Sprintf("%s", args[v.index-1])
+	case string:
+		path = v
+	}
+	format := s.format
+	if format == "" {
+		format = LoadFormatNdjson
+		if strings.HasSuffix(strings.ToLower(path), ".csv") {
+			format = LoadFormatCsv
+		}
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	restResult := s.conn.restClient.LoadDocuments(LoadDocumentsReq{DbName: s.dbName, CollName: s.collName, Reader: f,
+		Format: format, IsUpsert: s.isUpsert, BatchSize: s.batchSize, Concurrency: s.concurrency})
+	if restResult.CallErr != nil {
+		return nil, restResult.CallErr
+	}
+	result := &ResultLoad{NumSucceeded: restResult.NumSucceeded, NumFailed: restResult.NumFailed, LineErrors: restResult.LineErrors}
+	if restResult.NumFailed > 0 {
+		firstLine := -1
+		for line := range restResult.LineErrors {
+			if firstLine < 0 || line < firstLine {
+				firstLine = line
+			}
+		}
+		return result, fmt.Errorf("%d document(s) failed to load, first error at line %d: %s", restResult.NumFailed, firstLine, restResult.LineErrors[firstLine])
+	}
+	return result, nil
+}
+
+// Query implements driver.Stmt.Query.
+// This function is not implemented, use Exec instead.
+func (s *StmtLoad) Query(_ []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("this operation is not supported, please use exec")
+}
+
+// ResultLoad captures the result from LOAD operation.
+//
+// Available since v0.1.1
+type ResultLoad struct {
+	// NumSucceeded and NumFailed are number of documents loaded successfully and failed.
+	NumSucceeded, NumFailed int
+	// LineErrors holds error of each failed line, keyed by line number (1-based).
+	LineErrors map[int]error
+}
+
+// LastInsertId implements driver.Result.LastInsertId.
+func (r *ResultLoad) LastInsertId() (int64, error) {
+	return 0, errors.New("this operation is not supported")
+}
+
+// RowsAffected implements driver.Result.RowsAffected.
+func (r *ResultLoad) RowsAffected() (int64, error) {
+	return int64(r.NumSucceeded), nil
+}
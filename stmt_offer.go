@@ -0,0 +1,110 @@
+package gocosmos
+
+import (
+	"database/sql/driver"
+	"errors"
+	"io"
+)
+
+// StmtShowThroughput implements "SHOW THROUGHPUT" operation.
+//
+// Syntax:
+//     SHOW THROUGHPUT|OFFER DATABASE [<db-name>]
+//     SHOW THROUGHPUT|OFFER COLLECTION|TABLE [<db-name>.]<collection-name>
+//
+// - Query returns the offer (provisioned throughput) associated with the database or collection as a single row with
+// columns "ru" (manual throughput in RU/s), "maxru" (autoscale max throughput in RU/s, 0 if autoscale is not enabled),
+// "offerVersion" and the offer's system properties.
+//
+// - If the database/collection has no provisioned throughput of its own (e.g. a collection sharing the throughput of its
+// database), Query returns no rows.
+//
+// Available since v0.1.1
+type StmtShowThroughput struct {
+	*Stmt
+	dbName   string
+	collName string // collection name, empty for databases
+}
+
+// rebind returns a copy of the statement bound to conn, see _rebindStmt.
+func (s *StmtShowThroughput) rebind(conn *Conn) driver.Stmt {
+	clone := *s
+	clone.Stmt = s.Stmt.withConn(conn)
+	return &clone
+}
+
+func (s *StmtShowThroughput) validate() error {
+	if s.dbName == "" {
+		return errors.New("database is missing")
+	}
+	return nil
+}
+
+// Exec implements driver.Stmt.Exec.
+// This function is not implemented, use Query instead.
+func (s *StmtShowThroughput) Exec(_ []driver.Value) (driver.Result, error) {
+	return nil, errors.New("this operation is not supported, please use query")
+}
+
+// Query implements driver.Stmt.Query.
+func (s *StmtShowThroughput) Query(_ []driver.Value) (driver.Rows, error) {
+	var rid string
+	if s.collName == "" {
+		getResult := s.conn.restClient.GetDatabase(s.dbName)
+		if err := getResult.Error(); err != nil {
+			return nil, err
+		}
+		rid = getResult.Rid
+	} else {
+		getResult := s.conn.restClient.GetCollection(s.dbName, s.collName)
+		if err := getResult.Error(); err != nil {
+			return nil, err
+		}
+		rid = getResult.Rid
+	}
+	restResult := s.conn.restClient.GetOfferForResource(rid)
+	if restResult.StatusCode == 404 {
+		return &RowsShowThroughput{}, nil
+	}
+	if err := restResult.Error(); err != nil {
+		return nil, _explainServerless(err)
+	}
+	return &RowsShowThroughput{offers: []OfferInfo{restResult.OfferInfo}}, nil
+}
+
+// RowsShowThroughput captures the result from SHOW THROUGHPUT operation.
+//
+// Available since v0.1.1
+type RowsShowThroughput struct {
+	offers      []OfferInfo
+	cursorCount int
+}
+
+// Columns implements driver.Rows.Columns.
+func (r *RowsShowThroughput) Columns() []string {
+	return []string{"ru", "maxru", "offerVersion", "offerResourceId", "id", "_rid", "_ts", "_self", "_etag"}
+}
+
+// Close implements driver.Rows.Close.
+func (r *RowsShowThroughput) Close() error {
+	return nil
+}
+
+// Next implements driver.Rows.Next.
+func (r *RowsShowThroughput) Next(dest []driver.Value) error {
+	if r.cursorCount >= len(r.offers) {
+		return io.EOF
+	}
+	rowData := r.offers[r.cursorCount]
+	r.cursorCount++
+	dest[0] = int64(rowData.OfferThroughput())
+	dest[1] = int64(rowData.AutopilotMaxThroughput())
+	dest[2] = rowData.OfferVersion
+	dest[3] = rowData.OfferResourceId
+	dest[4] = rowData.Id
+	dest[5] = rowData.Rid
+	dest[6] = rowData.Ts
+	dest[7] = rowData.Self
+	dest[8] = rowData.Etag
+	return nil
+}
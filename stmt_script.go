@@ -0,0 +1,533 @@
+package gocosmos
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var reScriptBodyPlaceholder = regexp.MustCompile(`^[$@:](\d+)$`)
+
+// _parseScriptBody parses the body of a server-side script, which is either a placeholder (e.g. :1, @2 or $3) or a
+// double-quoted string literal (e.g. "function() {...}").
+func _parseScriptBody(input string) (interface{}, error) {
+	input = strings.TrimSpace(input)
+	if groups := reScriptBodyPlaceholder.FindStringSubmatch(input); groups != nil {
+		index, err := strconv.Atoi(groups[1])
+		if err != nil || index <= 0 {
+			return nil, fmt.Errorf("invalid placeholder: %s", input)
+		}
+		return placeholder{index}, nil
+	}
+	if len(input) > 1 && input[0] == '"' && input[len(input)-1] == '"' {
+		body, err := strconv.Unquote(input)
+		if err != nil {
+			return nil, fmt.Errorf("invalid script body: %s", err)
+		}
+		return body, nil
+	}
+	return nil, fmt.Errorf("invalid script body, expect a placeholder or a string literal: %s", input)
+}
+
+// _scriptBody extracts the script body, resolving placeholder from the supplied arguments.
+func _scriptBody(body interface{}, args []driver.Value) (string, error) {
+	switch v := body.(type) {
+	case placeholder:
+		if v.index <= 0 || v.index > len(args) {
+			return "", fmt.Errorf("invalid value index %d", v.index)
+		}
+		if str, ok := args[v.index-1].(string); ok {
+			return str, nil
+		}
+		return "", fmt.Errorf("script body must be a string, but received %T", args[v.index-1])
+	case string:
+		return v, nil
+	}
+	return "", fmt.Errorf("invalid script body %#v", body)
+}
+
+// _scriptNumInput returns number of placeholder parameters of a script body.
+func _scriptNumInput(body interface{}) int {
+	if ph, ok := body.(placeholder); ok {
+		return ph.index
+	}
+	return 0
+}
+
+// ResultScript captures the result from CREATE/ALTER/DROP operations on server-side scripts.
+//
+// Available since v0.1.1
+type ResultScript struct {
+	// Successful flags if the operation was successful or not.
+	Successful bool
+	// InsertId holds the "_rid" if the operation was successful (not available for DROP operations).
+	InsertId string
+}
+
+// LastInsertId implements driver.Result.LastInsertId.
+func (r *ResultScript) LastInsertId() (int64, error) {
+	return 0, fmt.Errorf("this operation is not supported. {LastInsertId:%s}", r.InsertId)
+}
+
+// RowsAffected implements driver.Result.RowsAffected.
+func (r *ResultScript) RowsAffected() (int64, error) {
+	if r.Successful {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+/*----------------------------------------------------------------------*/
+
+// StmtCreateProcedure implements "CREATE PROCEDURE" operation.
+//
+// Syntax:
+//     CREATE PROCEDURE [IF NOT EXISTS] [<db-name>.]<collection-name>.<procedure-id> AS <body>
+//
+// - body: JavaScript body of the stored procedure, either a double-quoted string literal or a placeholder.
+//
+// If "IF NOT EXISTS" is specified, Exec will silently swallow the error "409 Conflict".
+//
+// Available since v0.1.1
+type StmtCreateProcedure struct {
+	*Stmt
+	dbName      string
+	collName    string
+	sprocId     string
+	ifNotExists bool
+	bodyStr     string
+	body        interface{} // string or placeholder
+}
+
+// rebind returns a copy of the statement bound to conn, see _rebindStmt.
+func (s *StmtCreateProcedure) rebind(conn *Conn) driver.Stmt {
+	clone := *s
+	clone.Stmt = s.Stmt.withConn(conn)
+	return &clone
+}
+
+func (s *StmtCreateProcedure) parse() error {
+	body, err := _parseScriptBody(s.bodyStr)
+	if err != nil {
+		return err
+	}
+	s.body = body
+	s.numInput = _scriptNumInput(body)
+	return nil
+}
+
+func (s *StmtCreateProcedure) validate() error {
+	if s.dbName == "" || s.collName == "" || s.sprocId == "" {
+		return errors.New("database/collection/procedure is missing")
+	}
+	return nil
+}
+
+// Query implements driver.Stmt.Query.
+// This function is not implemented, use Exec instead.
+func (s *StmtCreateProcedure) Query(_ []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("this operation is not supported, please use exec")
+}
+
+// Exec implements driver.Stmt.Exec.
+// Upon successful call, this function returns (*ResultScript, nil).
+func (s *StmtCreateProcedure) Exec(args []driver.Value) (driver.Result, error) {
+	body, err := _scriptBody(s.body, args)
+	if err != nil {
+		return nil, err
+	}
+	restResult := s.conn.restClient.CreateStoredProcedure(StoredProcedureSpec{DbName: s.dbName, CollName: s.collName, SprocId: s.sprocId, Body: body})
+	result := &ResultScript{Successful: restResult.Error() == nil, InsertId: restResult.Rid}
+	err = restResult.Error()
+	if restResult.StatusCode == 409 && s.ifNotExists {
+		err = nil
+	}
+	return result, err
+}
+
+/*----------------------------------------------------------------------*/
+
+// StmtAlterProcedure implements "ALTER PROCEDURE" operation.
+//
+// Syntax:
+//     ALTER PROCEDURE [<db-name>.]<collection-name>.<procedure-id> AS <body>
+//
+// - body: new JavaScript body of the stored procedure, either a double-quoted string literal or a placeholder.
+//
+// Available since v0.1.1
+type StmtAlterProcedure struct {
+	*Stmt
+	dbName   string
+	collName string
+	sprocId  string
+	bodyStr  string
+	body     interface{} // string or placeholder
+}
+
+// rebind returns a copy of the statement bound to conn, see _rebindStmt.
+func (s *StmtAlterProcedure) rebind(conn *Conn) driver.Stmt {
+	clone := *s
+	clone.Stmt = s.Stmt.withConn(conn)
+	return &clone
+}
+
+func (s *StmtAlterProcedure) parse() error {
+	body, err := _parseScriptBody(s.bodyStr)
+	if err != nil {
+		return err
+	}
+	s.body = body
+	s.numInput = _scriptNumInput(body)
+	return nil
+}
+
+func (s *StmtAlterProcedure) validate() error {
+	if s.dbName == "" || s.collName == "" || s.sprocId == "" {
+		return errors.New("database/collection/procedure is missing")
+	}
+	return nil
+}
+
+// Query implements driver.Stmt.Query.
+// This function is not implemented, use Exec instead.
+func (s *StmtAlterProcedure) Query(_ []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("this operation is not supported, please use exec")
+}
+
+// Exec implements driver.Stmt.Exec.
+// Upon successful call, this function returns (*ResultScript, nil).
+func (s *StmtAlterProcedure) Exec(args []driver.Value) (driver.Result, error) {
+	body, err := _scriptBody(s.body, args)
+	if err != nil {
+		return nil, err
+	}
+	restResult := s.conn.restClient.ReplaceStoredProcedure(StoredProcedureSpec{DbName: s.dbName, CollName: s.collName, SprocId: s.sprocId, Body: body})
+	result := &ResultScript{Successful: restResult.Error() == nil, InsertId: restResult.Rid}
+	err = restResult.Error()
+	return result, err
+}
+
+/*----------------------------------------------------------------------*/
+
+// StmtDropProcedure implements "DROP PROCEDURE" operation.
+//
+// Syntax:
+//     DROP PROCEDURE [IF EXISTS] [<db-name>.]<collection-name>.<procedure-id>
+//
+// If "IF EXISTS" is specified, Exec will silently swallow the error "404 Not Found".
+//
+// Available since v0.1.1
+type StmtDropProcedure struct {
+	*Stmt
+	dbName   string
+	collName string
+	sprocId  string
+	ifExists bool
+}
+
+// rebind returns a copy of the statement bound to conn, see _rebindStmt.
+func (s *StmtDropProcedure) rebind(conn *Conn) driver.Stmt {
+	clone := *s
+	clone.Stmt = s.Stmt.withConn(conn)
+	return &clone
+}
+
+func (s *StmtDropProcedure) validate() error {
+	if s.dbName == "" || s.collName == "" || s.sprocId == "" {
+		return errors.New("database/collection/procedure is missing")
+	}
+	return nil
+}
+
+// Query implements driver.Stmt.Query.
+// This function is not implemented, use Exec instead.
+func (s *StmtDropProcedure) Query(_ []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("this operation is not supported, please use exec")
+}
+
+// Exec implements driver.Stmt.Exec.
+// Upon successful call, this function returns (*ResultScript, nil).
+func (s *StmtDropProcedure) Exec(_ []driver.Value) (driver.Result, error) {
+	restResult := s.conn.restClient.DeleteStoredProcedure(s.dbName, s.collName, s.sprocId)
+	result := &ResultScript{Successful: restResult.Error() == nil}
+	err := restResult.Error()
+	if restResult.StatusCode == 404 && s.ifExists {
+		err = nil
+	}
+	return result, err
+}
+
+/*----------------------------------------------------------------------*/
+
+// StmtCreateFunction implements "CREATE FUNCTION" operation.
+//
+// Syntax:
+//     CREATE FUNCTION [IF NOT EXISTS] [<db-name>.]<collection-name>.<function-id> AS <body>
+//
+// - body: JavaScript body of the user-defined function, either a double-quoted string literal or a placeholder.
+//
+// Once registered, the function can be used in queries as udf.<function-id>(...).
+//
+// If "IF NOT EXISTS" is specified, Exec will silently swallow the error "409 Conflict".
+//
+// Available since v0.1.1
+type StmtCreateFunction struct {
+	*Stmt
+	dbName      string
+	collName    string
+	udfId       string
+	ifNotExists bool
+	bodyStr     string
+	body        interface{} // string or placeholder
+}
+
+// rebind returns a copy of the statement bound to conn, see _rebindStmt.
+func (s *StmtCreateFunction) rebind(conn *Conn) driver.Stmt {
+	clone := *s
+	clone.Stmt = s.Stmt.withConn(conn)
+	return &clone
+}
+
+func (s *StmtCreateFunction) parse() error {
+	body, err := _parseScriptBody(s.bodyStr)
+	if err != nil {
+		return err
+	}
+	s.body = body
+	s.numInput = _scriptNumInput(body)
+	return nil
+}
+
+func (s *StmtCreateFunction) validate() error {
+	if s.dbName == "" || s.collName == "" || s.udfId == "" {
+		return errors.New("database/collection/function is missing")
+	}
+	return nil
+}
+
+// Query implements driver.Stmt.Query.
+// This function is not implemented, use Exec instead.
+func (s *StmtCreateFunction) Query(_ []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("this operation is not supported, please use exec")
+}
+
+// Exec implements driver.Stmt.Exec.
+// Upon successful call, this function returns (*ResultScript, nil).
+func (s *StmtCreateFunction) Exec(args []driver.Value) (driver.Result, error) {
+	body, err := _scriptBody(s.body, args)
+	if err != nil {
+		return nil, err
+	}
+	restResult := s.conn.restClient.CreateUserDefinedFunction(UserDefinedFunctionSpec{DbName: s.dbName, CollName: s.collName, UdfId: s.udfId, Body: body})
+	result := &ResultScript{Successful: restResult.Error() == nil, InsertId: restResult.Rid}
+	err = restResult.Error()
+	if restResult.StatusCode == 409 && s.ifNotExists {
+		err = nil
+	}
+	return result, err
+}
+
+/*----------------------------------------------------------------------*/
+
+// StmtDropFunction implements "DROP FUNCTION" operation.
+//
+// Syntax:
+//     DROP FUNCTION [IF EXISTS] [<db-name>.]<collection-name>.<function-id>
+//
+// If "IF EXISTS" is specified, Exec will silently swallow the error "404 Not Found".
+//
+// Available since v0.1.1
+type StmtDropFunction struct {
+	*Stmt
+	dbName   string
+	collName string
+	udfId    string
+	ifExists bool
+}
+
+// rebind returns a copy of the statement bound to conn, see _rebindStmt.
+func (s *StmtDropFunction) rebind(conn *Conn) driver.Stmt {
+	clone := *s
+	clone.Stmt = s.Stmt.withConn(conn)
+	return &clone
+}
+
+func (s *StmtDropFunction) validate() error {
+	if s.dbName == "" || s.collName == "" || s.udfId == "" {
+		return errors.New("database/collection/function is missing")
+	}
+	return nil
+}
+
+// Query implements driver.Stmt.Query.
+// This function is not implemented, use Exec instead.
+func (s *StmtDropFunction) Query(_ []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("this operation is not supported, please use exec")
+}
+
+// Exec implements driver.Stmt.Exec.
+// Upon successful call, this function returns (*ResultScript, nil).
+func (s *StmtDropFunction) Exec(_ []driver.Value) (driver.Result, error) {
+	restResult := s.conn.restClient.DeleteUserDefinedFunction(s.dbName, s.collName, s.udfId)
+	result := &ResultScript{Successful: restResult.Error() == nil}
+	err := restResult.Error()
+	if restResult.StatusCode == 404 && s.ifExists {
+		err = nil
+	}
+	return result, err
+}
+
+/*----------------------------------------------------------------------*/
+
+// StmtCreateTrigger implements "CREATE TRIGGER" operation.
+//
+// Syntax:
+//     CREATE TRIGGER [IF NOT EXISTS] [<db-name>.]<collection-name>.<trigger-id> WITH TYPE=pre|post
+//         [WITH OPERATION=all|create|replace|delete] AS <body>
+//
+// - TYPE: specifies if the trigger is invoked before (pre) or after (post) the operation.
+//
+// - OPERATION: the operation the trigger is associated with (default "all").
+//
+// - body: JavaScript body of the trigger, either a double-quoted string literal or a placeholder.
+//
+// Triggers are not fired automatically, they must be specified per operation via WITH PRETRIGGER=... or
+// WITH POSTTRIGGER=... option of INSERT/UPSERT/UPDATE/DELETE statements.
+//
+// If "IF NOT EXISTS" is specified, Exec will silently swallow the error "409 Conflict".
+//
+// Available since v0.1.1
+type StmtCreateTrigger struct {
+	*Stmt
+	dbName           string
+	collName         string
+	triggerId        string
+	ifNotExists      bool
+	triggerType      string // "Pre" or "Post"
+	triggerOperation string // "All", "Create", "Replace" or "Delete"
+	withOptsStr      string
+	bodyStr          string
+	body             interface{} // string or placeholder
+}
+
+// rebind returns a copy of the statement bound to conn, see _rebindStmt.
+func (s *StmtCreateTrigger) rebind(conn *Conn) driver.Stmt {
+	clone := *s
+	clone.Stmt = s.Stmt.withConn(conn)
+	return &clone
+}
+
+var (
+	triggerTypes      = map[string]string{"PRE": "Pre", "POST": "Post"}
+	triggerOperations = map[string]string{"ALL": "All", "CREATE": "Create", "REPLACE": "Replace", "DELETE": "Delete"}
+)
+
+func (s *StmtCreateTrigger) parse() error {
+	if err := s.Stmt.parseWithOpts(s.withOptsStr); err != nil {
+		return err
+	}
+	if v, ok := s.withOpts["TYPE"]; ok {
+		if s.triggerType, ok = triggerTypes[strings.ToUpper(v)]; !ok {
+			return fmt.Errorf("invalid TYPE value: %s", v)
+		}
+	}
+	s.triggerOperation = "All"
+	if v, ok := s.withOpts["OPERATION"]; ok {
+		if s.triggerOperation, ok = triggerOperations[strings.ToUpper(v)]; !ok {
+			return fmt.Errorf("invalid OPERATION value: %s", v)
+		}
+	}
+	body, err := _parseScriptBody(s.bodyStr)
+	if err != nil {
+		return err
+	}
+	s.body = body
+	s.numInput = _scriptNumInput(body)
+	return nil
+}
+
+func (s *StmtCreateTrigger) validate() error {
+	if s.dbName == "" || s.collName == "" || s.triggerId == "" {
+		return errors.New("database/collection/trigger is missing")
+	}
+	if s.triggerType == "" {
+		return errors.New("trigger type is missing, specify it via WITH TYPE=pre|post")
+	}
+	return nil
+}
+
+// Query implements driver.Stmt.Query.
+// This function is not implemented, use Exec instead.
+func (s *StmtCreateTrigger) Query(_ []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("this operation is not supported, please use exec")
+}
+
+// Exec implements driver.Stmt.Exec.
+// Upon successful call, this function returns (*ResultScript, nil).
+func (s *StmtCreateTrigger) Exec(args []driver.Value) (driver.Result, error) {
+	body, err := _scriptBody(s.body, args)
+	if err != nil {
+		return nil, err
+	}
+	restResult := s.conn.restClient.CreateTrigger(TriggerSpec{DbName: s.dbName, CollName: s.collName, TriggerId: s.triggerId,
+		Body: body, TriggerType: s.triggerType, TriggerOperation: s.triggerOperation})
+	result := &ResultScript{Successful: restResult.Error() == nil, InsertId: restResult.Rid}
+	err = restResult.Error()
+	if restResult.StatusCode == 409 && s.ifNotExists {
+		err = nil
+	}
+	return result, err
+}
+
+/*----------------------------------------------------------------------*/
+
+// StmtDropTrigger implements "DROP TRIGGER" operation.
+//
+// Syntax:
+//     DROP TRIGGER [IF EXISTS] [<db-name>.]<collection-name>.<trigger-id>
+//
+// If "IF EXISTS" is specified, Exec will silently swallow the error "404 Not Found".
+//
+// Available since v0.1.1
+type StmtDropTrigger struct {
+	*Stmt
+	dbName    string
+	collName  string
+	triggerId string
+	ifExists  bool
+}
+
+// rebind returns a copy of the statement bound to conn, see _rebindStmt.
+func (s *StmtDropTrigger) rebind(conn *Conn) driver.Stmt {
+	clone := *s
+	clone.Stmt = s.Stmt.withConn(conn)
+	return &clone
+}
+
+func (s *StmtDropTrigger) validate() error {
+	if s.dbName == "" || s.collName == "" || s.triggerId == "" {
+		return errors.New("database/collection/trigger is missing")
+	}
+	return nil
+}
+
+// Query implements driver.Stmt.Query.
+// This function is not implemented, use Exec instead.
+func (s *StmtDropTrigger) Query(_ []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("this operation is not supported, please use exec")
+}
+
+// Exec implements driver.Stmt.Exec.
+// Upon successful call, this function returns (*ResultScript, nil).
+func (s *StmtDropTrigger) Exec(_ []driver.Value) (driver.Result, error) {
+	restResult := s.conn.restClient.DeleteTrigger(s.dbName, s.collName, s.triggerId)
+	result := &ResultScript{Successful: restResult.Error() == nil}
+	err := restResult.Error()
+	if restResult.StatusCode == 404 && s.ifExists {
+		err = nil
+	}
+	return result, err
+}
@@ -1,8 +1,25 @@
 package gocosmos
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	goparser "go/parser"
+	gotoken "go/token"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"reflect"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestStmt_NumInput(t *testing.T) {
@@ -79,6 +96,178 @@ func Test_parseQuery_CreateDatabase(t *testing.T) {
 			t.Fatalf("%s failed: query must not be parsed/validated successfully", name+"/"+query)
 		}
 	}
+
+	// (since v0.1.1) throughput below the minimum is rejected before the request is sent
+	for _, query := range []string{
+		"CREATE DATABASE dbtemp WITH ru=100",
+		"CREATE DATABASE dbtemp WITH ru=450",
+		"CREATE DATABASE dbtemp WITH maxru=100",
+		"CREATE DATABASE dbtemp WITH maxru=1500",
+	} {
+		if stmt, err := parseQuery(nil, query); err != nil {
+			t.Fatalf("%s failed: %s", name+"/"+query, err)
+		} else if _, err := stmt.Exec(nil); err == nil || !strings.Contains(err.Error(), "at least") {
+			t.Fatalf("%s failed: throughput must be rejected by Exec, received %v", name+"/"+query, err)
+		}
+	}
+}
+
+func Test_parseQuery_AlterDatabase(t *testing.T) {
+	name := "Test_parseQuery_AlterDatabase"
+	type testStruct struct {
+		dbName    string
+		ru, maxru int
+	}
+	testData := map[string]testStruct{
+		"ALTER DATABASE db1 WITH ru=400":               {dbName: "db1", ru: 400},
+		"alter\ndatabase\r\ndb-2\nwith\r\nMAXRU=10000": {dbName: "db-2", maxru: 10000},
+	}
+	for query, data := range testData {
+		if stmt, err := parseQuery(nil, query); err != nil {
+			t.Fatalf("%s failed: %s", name+"/"+query, err)
+		} else if dbstmt, ok := stmt.(*StmtAlterDatabase); !ok {
+			t.Fatalf("%s failed: the parsed stmt must be of type *StmtAlterDatabase", name+"/"+query)
+		} else if dbstmt.dbName != data.dbName || dbstmt.ru != data.ru || dbstmt.maxru != data.maxru {
+			t.Fatalf("%s failed: expected %#v but received %#v", name+"/"+query, data, dbstmt)
+		}
+	}
+
+	invalidQueries := []string{
+		"ALTER DATABASE db1",
+		"ALTER DATABASE db1 WITH ru=400 WITH maxru=4000",
+		"ALTER DATABASE db1 WITH ru=300",
+		"ALTER DATABASE db1 WITH maxru=500",
+		"ALTER DATABASE db1 WITH maxru=abc",
+	}
+	for _, query := range invalidQueries {
+		if _, err := parseQuery(nil, query); err == nil {
+			t.Fatalf("%s failed: query must not be parsed/validated successfully", name+"/"+query)
+		}
+	}
+}
+
+func TestStmtAlterDatabase_Exec(t *testing.T) {
+	name := "TestStmtAlterDatabase_Exec"
+	var offerBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		switch {
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/dbs/"):
+			w.Write([]byte(`{"id":"db","_rid":"` + strings.TrimPrefix(r.URL.Path, "/dbs/") + `Rid"}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/dbs":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":"BadRequest","message":"Setting offer throughput or autopilot on container is not supported for serverless accounts."}`))
+		case r.URL.Path == "/offers" && strings.Contains(string(data), "sharedRid"):
+			w.Write([]byte(`{"_count":1,"Offers":[{"offerVersion":"V2","content":{"offerThroughput":400},"offerResourceId":"sharedRid","id":"o1","_rid":"o1"}]}`))
+		case r.URL.Path == "/offers" && strings.Contains(string(data), "serverlessRid"):
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":"BadRequest","message":"Reading or replacing offers is not supported for serverless accounts."}`))
+		case r.URL.Path == "/offers":
+			w.Write([]byte(`{"_count":0,"Offers":[]}`))
+		case r.Method == http.MethodPut && r.URL.Path == "/offers/o1":
+			offerBody = string(data)
+			w.Write(data)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":"NotFound","message":"not found"}`))
+		}
+	}))
+	defer server.Close()
+	db, _ := sql.Open("gocosmos", "AccountEndpoint="+server.URL+";AccountKey=cHJpbWFyeQ==")
+	defer db.Close()
+
+	if result, err := db.Exec("ALTER DATABASE shared WITH ru=800"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	} else if rowsAffected, _ := result.RowsAffected(); rowsAffected != 1 || !strings.Contains(offerBody, `"offerThroughput":800`) {
+		t.Fatalf("%s failed: unexpected result %d, offer %s", name, rowsAffected, offerBody)
+	}
+	if _, err := db.Exec("ALTER DATABASE dedicated WITH ru=800"); err == nil || !strings.Contains(err.Error(), "no shared throughput") {
+		t.Fatalf("%s failed: expected error for database without shared throughput, received %v", name, err)
+	}
+	if _, err := db.Exec("ALTER DATABASE serverless WITH maxru=4000"); !errors.Is(err, ErrServerless) || !errors.Is(err, ErrBadRequest) || !strings.Contains(err.Error(), "the account is serverless") {
+		t.Fatalf("%s failed: expected ErrServerless but received %v", name, err)
+	}
+	if _, err := db.Exec("CREATE DATABASE serverless WITH ru=400"); !errors.Is(err, ErrServerless) || !strings.Contains(err.Error(), "omit WITH RU/MAXRU") {
+		t.Fatalf("%s failed: expected ErrServerless but received %v", name, err)
+	}
+	if _, err := db.Query("SHOW THROUGHPUT DATABASE serverless"); !errors.Is(err, ErrServerless) {
+		t.Fatalf("%s failed: expected ErrServerless but received %v", name, err)
+	}
+	for _, body := range []string{
+		`{"message":"Syntax error"}`,
+		`{"message":"The input name 'serverless/db' is invalid."}`,
+		`{"message":"Partition key path /serverless is not supported for serverless accounts"}`,
+	} {
+		if err := newCosmosError(400, nil, []byte(body)); errors.Is(err, ErrServerless) {
+			t.Fatalf("%s failed: other bad requests must not match ErrServerless: %s", name, body)
+		}
+	}
+	if (&CosmosError{StatusCode: 400, Body: []byte(`{"message":"Syntax error"}`)}).Is(ErrServerless) {
+		t.Fatalf("%s failed: other bad requests must not match ErrServerless", name)
+	}
+}
+
+func TestStmt_IfExistsIdempotent(t *testing.T) {
+	name := "TestStmt_IfExistsIdempotent"
+	exists := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && exists:
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"code":"Conflict","message":"Entity with the specified id already exists in the system."}`))
+		case r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"x","_rid":"rid"}`))
+		case r.Method == http.MethodDelete && exists:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":"NotFound","message":"Entity with the specified id does not exist in the system."}`))
+		}
+	}))
+	defer server.Close()
+	db, _ := sql.Open("gocosmos", "AccountEndpoint="+server.URL+";AccountKey=cHJpbWFyeQ==;DefaultDb=db")
+	defer db.Close()
+
+	testData := []struct {
+		query, noopQuery string
+		exists           bool // whether the resource exists before the statement is executed in the no-op case
+	}{
+		{"CREATE DATABASE db1", "CREATE DATABASE IF NOT EXISTS db1", true},
+		{"CREATE COLLECTION db1.coll1 WITH pk=/id", "CREATE COLLECTION IF NOT EXISTS db1.coll1 WITH pk=/id", true},
+		{`CREATE PROCEDURE coll1.sp1 AS "function f() {}"`, `CREATE PROCEDURE IF NOT EXISTS coll1.sp1 AS "function f() {}"`, true},
+		{`CREATE FUNCTION coll1.udf1 AS "function f() {}"`, `CREATE FUNCTION IF NOT EXISTS coll1.udf1 AS "function f() {}"`, true},
+		{`CREATE TRIGGER coll1.trg1 WITH type=pre AS "function f() {}"`, `CREATE TRIGGER IF NOT EXISTS coll1.trg1 WITH type=pre AS "function f() {}"`, true},
+		{"CREATE USER user1", "CREATE USER IF NOT EXISTS user1", true},
+		{"DROP DATABASE db1", "DROP DATABASE IF EXISTS db1", false},
+		{"DROP COLLECTION db1.coll1", "DROP COLLECTION IF EXISTS db1.coll1", false},
+		{"DROP PROCEDURE coll1.sp1", "DROP PROCEDURE IF EXISTS coll1.sp1", false},
+		{"DROP FUNCTION coll1.udf1", "DROP FUNCTION IF EXISTS coll1.udf1", false},
+		{"DROP TRIGGER coll1.trg1", "DROP TRIGGER IF EXISTS coll1.trg1", false},
+		{"DROP USER user1", "DROP USER IF EXISTS user1", false},
+	}
+	for _, data := range testData {
+		// the resource is created/dropped: 1 row affected
+		exists = !data.exists
+		if result, err := db.Exec(data.noopQuery); err != nil {
+			t.Fatalf("%s failed: <%s> %s", name, data.noopQuery, err)
+		} else if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected != 1 {
+			t.Fatalf("%s failed: <%s> expected 1 row affected but received %d/%v", name, data.noopQuery, rowsAffected, err)
+		}
+
+		// no-op: no error, 0 rows affected
+		exists = data.exists
+		if result, err := db.Exec(data.noopQuery); err != nil {
+			t.Fatalf("%s failed: <%s> %s", name, data.noopQuery, err)
+		} else if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected != 0 {
+			t.Fatalf("%s failed: <%s> expected 0 rows affected but received %d/%v", name, data.noopQuery, rowsAffected, err)
+		}
+
+		// without modifier: error
+		if _, err := db.Exec(data.query); !errors.Is(err, ErrConflict) && !errors.Is(err, ErrNotFound) {
+			t.Fatalf("%s failed: <%s> expected ErrConflict/ErrNotFound but received %#v", name, data.query, err)
+		}
+	}
 }
 
 func Test_parseQuery_DropDatabase(t *testing.T) {
@@ -180,6 +369,38 @@ func Test_parseQuery_CreateCollection(t *testing.T) {
 	}
 }
 
+func Test_parseQuery_CreateCollectionHierarchicalPk(t *testing.T) {
+	name := "Test_parseQuery_CreateCollectionHierarchicalPk"
+	testData := map[string][]string{
+		"CREATE COLLECTION db.coll WITH pk=/id":                         {"/id"},
+		"CREATE COLLECTION db.coll WITH pk=/tenantId,/userId":           {"/tenantId", "/userId"},
+		"CREATE COLLECTION db.coll WITH largepk=/a/b,/c,/d WITH ru=400": {"/a/b", "/c", "/d"},
+		"CREATE COLLECTION db.coll WITH pk=/address/city":               {"/address/city"},
+	}
+	for query, pkPaths := range testData {
+		if stmt, err := parseQuery(nil, query); err != nil {
+			t.Fatalf("%s failed: %s", name+"/"+query, err)
+		} else if dbstmt, ok := stmt.(*StmtCreateCollection); !ok {
+			t.Fatalf("%s failed: the parsed stmt must be of type *StmtCreateCollection", name+"/"+query)
+		} else if !reflect.DeepEqual(dbstmt.pkPaths, pkPaths) {
+			t.Fatalf("%s failed: <pk-paths> expected %#v but received %#v", name+"/"+query, pkPaths, dbstmt.pkPaths)
+		}
+	}
+
+	invalidQueries := []string{
+		"CREATE COLLECTION db.coll WITH pk=/a,/b,/c,/d", // at most 3 paths
+		"CREATE COLLECTION db.coll WITH pk=/a,,/b",      // empty path
+		"CREATE COLLECTION db.coll WITH pk=address",     // path must start with /
+		"CREATE COLLECTION db.coll WITH pk=/address//city",
+		"CREATE COLLECTION db.coll WITH pk=/address/",
+	}
+	for _, query := range invalidQueries {
+		if _, err := parseQuery(nil, query); err == nil {
+			t.Fatalf("%s failed: query must not be parsed/validated successfully", name+"/"+query)
+		}
+	}
+}
+
 func Test_parseQuery_CreateCollectionDefaultDb(t *testing.T) {
 	name := "Test_parseQuery_CreateCollectionDefaultDb"
 	dbName := "mydb"
@@ -230,6 +451,190 @@ func Test_parseQuery_CreateCollectionDefaultDb(t *testing.T) {
 	}
 }
 
+func Test_parseQuery_CreateCollectionIndexingPolicy(t *testing.T) {
+	name := "Test_parseQuery_CreateCollectionIndexingPolicy"
+	testData := map[string]map[string]interface{}{
+		"CREATE COLLECTION db.table WITH pk=/id": nil,
+		`CREATE COLLECTION db.table WITH pk=/id WITH indexing={"indexingMode":"consistent", "automatic":true, "excludedPaths":[{"path":"/\"_etag\"/?"}]} WITH ru=400`: {
+			"indexingMode": "consistent", "automatic": true, "excludedPaths": []interface{}{map[string]interface{}{"path": `/"_etag"/?`}},
+		},
+		"CREATE TABLE db.table WITH pk=/id WITH indexing_mode=LAZY WITH include=/name/?,/age/? WITH exclude=/*": {
+			"indexingMode": "lazy", "automatic": true,
+			"includedPaths": []interface{}{map[string]interface{}{"path": "/name/?"}, map[string]interface{}{"path": "/age/?"}},
+			"excludedPaths": []interface{}{map[string]interface{}{"path": "/*"}},
+		},
+		`CREATE TABLE db.table WITH pk=/id WITH indexing={"indexingMode":"consistent","automatic":true} WITH indexing_mode=none`: {
+			"indexingMode": "none", "automatic": false,
+		},
+		"CREATE TABLE db.table WITH pk=/id WITH composite=/name,/age:desc;/city:ASC,/zip:descending": {
+			"compositeIndexes": []interface{}{
+				[]interface{}{map[string]interface{}{"path": "/name", "order": "ascending"}, map[string]interface{}{"path": "/age", "order": "descending"}},
+				[]interface{}{map[string]interface{}{"path": "/city", "order": "ascending"}, map[string]interface{}{"path": "/zip", "order": "descending"}},
+			},
+		},
+	}
+	for query, data := range testData {
+		if stmt, err := parseQuery(nil, query); err != nil {
+			t.Fatalf("%s failed: %s", name+"/"+query, err)
+		} else if dbstmt, ok := stmt.(*StmtCreateCollection); !ok {
+			t.Fatalf("%s failed: the parsed stmt must be of type *StmtCreateCollection", name+"/"+query)
+		} else if !reflect.DeepEqual(dbstmt.indexingPolicy, data) {
+			t.Fatalf("%s failed: <indexing-policy> expected %#v but received %#v", name+"/"+query, data, dbstmt.indexingPolicy)
+		}
+	}
+
+	invalidQueries := []string{
+		"CREATE COLLECTION db.table WITH pk=/id WITH indexing={",
+		"CREATE COLLECTION db.table WITH pk=/id WITH indexing=[1,2]",
+		"CREATE COLLECTION db.table WITH pk=/id WITH indexing_mode=invalid",
+		"CREATE COLLECTION db.table WITH pk=/id WITH include=,",
+		"CREATE COLLECTION db.table WITH pk=/id WITH composite=;",
+		"CREATE COLLECTION db.table WITH pk=/id WITH composite=/a:up,/b",
+		"CREATE COLLECTION db.table WITH pk=/id WITH spatial=/location/*:circle",
+	}
+	for _, query := range invalidQueries {
+		if _, err := parseQuery(nil, query); err == nil {
+			t.Fatalf("%s failed: query must not be parsed/validated successfully", name+"/"+query)
+		}
+	}
+}
+
+func Test_parseQuery_CreateCollectionSpatial(t *testing.T) {
+	name := "Test_parseQuery_CreateCollectionSpatial"
+	type testStruct struct {
+		indexingPolicy   map[string]interface{}
+		geospatialConfig map[string]interface{}
+	}
+	testData := map[string]testStruct{
+		"CREATE COLLECTION db.table WITH pk=/id WITH spatial=/location/*": {
+			indexingPolicy: map[string]interface{}{"spatialIndexes": []interface{}{
+				map[string]interface{}{"path": "/location/*", "types": []interface{}{"Point", "Polygon", "LineString", "MultiPolygon"}},
+			}},
+		},
+		"CREATE COLLECTION db.table WITH pk=/id WITH spatial=/location/*:point;/area/*:Polygon,multipolygon WITH geospatial=Geography": {
+			indexingPolicy: map[string]interface{}{"spatialIndexes": []interface{}{
+				map[string]interface{}{"path": "/location/*", "types": []interface{}{"Point"}},
+				map[string]interface{}{"path": "/area/*", "types": []interface{}{"Polygon", "MultiPolygon"}},
+			}},
+			geospatialConfig: map[string]interface{}{"type": "Geography"},
+		},
+		"CREATE COLLECTION db.table WITH pk=/id WITH geospatial=geometry": {
+			geospatialConfig: map[string]interface{}{"type": "Geometry"},
+		},
+	}
+	for query, data := range testData {
+		if stmt, err := parseQuery(nil, query); err != nil {
+			t.Fatalf("%s failed: %s", name+"/"+query, err)
+		} else if dbstmt, ok := stmt.(*StmtCreateCollection); !ok {
+			t.Fatalf("%s failed: the parsed stmt must be of type *StmtCreateCollection", name+"/"+query)
+		} else if !reflect.DeepEqual(dbstmt.indexingPolicy, data.indexingPolicy) {
+			t.Fatalf("%s failed: <indexing-policy> expected %#v but received %#v", name+"/"+query, data.indexingPolicy, dbstmt.indexingPolicy)
+		} else if !reflect.DeepEqual(dbstmt.geospatialConfig, data.geospatialConfig) {
+			t.Fatalf("%s failed: <geospatial-config> expected %#v but received %#v", name+"/"+query, data.geospatialConfig, dbstmt.geospatialConfig)
+		}
+	}
+
+	invalidQueries := []string{
+		"CREATE COLLECTION db.table WITH pk=/id WITH geospatial=sphere",
+		"ALTER COLLECTION db.table WITH geospatial=sphere",
+	}
+	for _, query := range invalidQueries {
+		if _, err := parseQuery(nil, query); err == nil {
+			t.Fatalf("%s failed: query must not be parsed/validated successfully", name+"/"+query)
+		}
+	}
+}
+
+func Test_parseQuery_CollectionTtl(t *testing.T) {
+	name := "Test_parseQuery_CollectionTtl"
+	testData := map[string]int{
+		"CREATE COLLECTION db.table WITH pk=/id":             0,
+		"CREATE COLLECTION db.table WITH pk=/id WITH ttl=-1": -1,
+		"CREATE TABLE db.table WITH pk=/id WITH TTL=3600":    3600,
+	}
+	for query, data := range testData {
+		if stmt, err := parseQuery(nil, query); err != nil {
+			t.Fatalf("%s failed: %s", name+"/"+query, err)
+		} else if dbstmt, ok := stmt.(*StmtCreateCollection); !ok {
+			t.Fatalf("%s failed: the parsed stmt must be of type *StmtCreateCollection", name+"/"+query)
+		} else if dbstmt.ttl != data {
+			t.Fatalf("%s failed: <ttl> expected %#v but received %#v", name+"/"+query, data, dbstmt.ttl)
+		}
+	}
+
+	testData = map[string]int{
+		"ALTER COLLECTION db.table WITH ttl=-1":  -1,
+		"ALTER COLLECTION db.table WITH ttl=60":  60,
+		"ALTER COLLECTION db.table WITH ttl=OFF": 0,
+	}
+	for query, data := range testData {
+		if stmt, err := parseQuery(nil, query); err != nil {
+			t.Fatalf("%s failed: %s", name+"/"+query, err)
+		} else if dbstmt, ok := stmt.(*StmtAlterCollection); !ok {
+			t.Fatalf("%s failed: the parsed stmt must be of type *StmtAlterCollection", name+"/"+query)
+		} else if !dbstmt.hasTtl || dbstmt.ttl != data {
+			t.Fatalf("%s failed: <ttl> expected %#v but received %#v", name+"/"+query, data, dbstmt.ttl)
+		}
+	}
+
+	invalidQueries := []string{
+		"CREATE COLLECTION db.table WITH pk=/id WITH ttl=0",
+		"CREATE COLLECTION db.table WITH pk=/id WITH ttl=-2",
+		"CREATE COLLECTION db.table WITH pk=/id WITH ttl=off",
+		"CREATE COLLECTION db.table WITH pk=/id WITH ttl=abc",
+		"ALTER COLLECTION db.table WITH ttl=0",
+		"ALTER COLLECTION db.table WITH ttl=-2",
+	}
+	for _, query := range invalidQueries {
+		if _, err := parseQuery(nil, query); err == nil {
+			t.Fatalf("%s failed: query must not be parsed/validated successfully", name+"/"+query)
+		}
+	}
+}
+
+func Test_parseQuery_AlterCollection(t *testing.T) {
+	name := "Test_parseQuery_AlterCollection"
+	type testStruct struct {
+		dbName    string
+		collName  string
+		ru, maxru int
+	}
+	testData := map[string]testStruct{
+		"ALTER COLLECTION db1.table1 WITH ru=400":          {dbName: "db1", collName: "table1", ru: 400, maxru: 0},
+		"alter\ntable\r\ndb-2.table_2 WITH\r\nmaxRU=4000":  {dbName: "db-2", collName: "table_2", ru: 0, maxru: 4000},
+		"ALTER TABLE db3.table3 WITH composite=/a,/b:desc": {dbName: "db3", collName: "table3", ru: 0, maxru: 0},
+		"ALTER TABLE db4.table4 WITH geospatial=geography": {dbName: "db4", collName: "table4", ru: 0, maxru: 0},
+	}
+	for query, data := range testData {
+		if stmt, err := parseQuery(nil, query); err != nil {
+			t.Fatalf("%s failed: %s", name+"/"+query, err)
+		} else if dbstmt, ok := stmt.(*StmtAlterCollection); !ok {
+			t.Fatalf("%s failed: the parsed stmt must be of type *StmtAlterCollection", name+"/"+query)
+		} else if dbstmt.dbName != data.dbName {
+			t.Fatalf("%s failed: <db-name> expected %#v but received %#v", name+"/"+query, data.dbName, dbstmt.dbName)
+		} else if dbstmt.collName != data.collName {
+			t.Fatalf("%s failed: <collection-name> expected %#v but received %#v", name+"/"+query, data.collName, dbstmt.collName)
+		} else if dbstmt.ru != data.ru {
+			t.Fatalf("%s failed: <ru> expected %#v but received %#v", name+"/"+query, data.ru, dbstmt.ru)
+		} else if dbstmt.maxru != data.maxru {
+			t.Fatalf("%s failed: <maxru> expected %#v but received %#v", name+"/"+query, data.maxru, dbstmt.maxru)
+		}
+	}
+
+	invalidQueries := []string{
+		"ALTER COLLECTION db.coll",
+		"ALTER COLLECTION db.coll WITH ru=400 WITH maxru=4000",
+		"ALTER COLLECTION db.coll WITH ru=-1",
+		"ALTER COLLECTION db.coll WITH maxru=-1",
+		"ALTER TABLE db WITH ru=400", // no collection name
+	}
+	for _, query := range invalidQueries {
+		if _, err := parseQuery(nil, query); err == nil {
+			t.Fatalf("%s failed: query must not be parsed/validated successfully", name+"/"+query)
+		}
+	}
+}
+
 func Test_parseQuery_DropCollection(t *testing.T) {
 	name := "Test_parseQuery_DropCollection"
 	type testStruct struct {
@@ -365,6 +770,266 @@ func Test_parseQuery_ListCollectionsDefaultDb(t *testing.T) {
 	}
 }
 
+func Test_parseQuery_ShowThroughput(t *testing.T) {
+	name := "Test_parseQuery_ShowThroughput"
+	type testStruct struct {
+		dbName   string
+		collName string
+	}
+	testData := map[string]testStruct{
+		"SHOW THROUGHPUT DATABASE db1":                 {dbName: "db1"},
+		"show\n\tthroughput database":                  {dbName: "mydb"},
+		"SHOW OFFER COLLECTION db-2.table-2":           {dbName: "db-2", collName: "table-2"},
+		"show throughput\r\n\tTABLE\tcoll_3":           {dbName: "mydb", collName: "coll_3"},
+		"SHOW THROUGHPUT collection db_4.collection_4": {dbName: "db_4", collName: "collection_4"},
+	}
+	for query, data := range testData {
+		if stmt, err := parseQueryWithDefaultDb(nil, "mydb", query); err != nil {
+			t.Fatalf("%s failed: %s", name+"/"+query, err)
+		} else if dbstmt, ok := stmt.(*StmtShowThroughput); !ok {
+			t.Fatalf("%s failed: the parsed stmt must be of type *StmtShowThroughput", name+"/"+query)
+		} else if dbstmt.dbName != data.dbName || dbstmt.collName != data.collName {
+			t.Fatalf("%s failed: expected %#v but received %#v/%#v", name+"/"+query, data, dbstmt.dbName, dbstmt.collName)
+		}
+	}
+
+	invalidQueries := []string{
+		"SHOW THROUGHPUT",
+		"SHOW THROUGHPUT DATABASE",
+		"SHOW THROUGHPUT DATABASE db.coll",
+		"SHOW THROUGHPUT COLLECTION",
+		"SHOW THROUGHPUT TABLE coll",
+	}
+	for _, query := range invalidQueries {
+		if _, err := parseQuery(nil, query); err == nil {
+			t.Fatalf("%s failed: query must not be parsed/validated successfully", name+"/"+query)
+		}
+	}
+}
+
+func TestStmtShowThroughput_Query(t *testing.T) {
+	name := "TestStmtShowThroughput_Query"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		switch {
+		case r.URL.Path == "/dbs/db":
+			w.Write([]byte(`{"id":"db","_rid":"dbRid"}`))
+		case r.URL.Path == "/dbs/db/colls/shared":
+			w.Write([]byte(`{"id":"shared","_rid":"sharedRid"}`))
+		case r.URL.Path == "/dbs/db/colls/coll":
+			w.Write([]byte(`{"id":"coll","_rid":"collRid"}`))
+		case r.URL.Path == "/offers" && strings.Contains(string(data), "dbRid"):
+			w.Write([]byte(`{"_count":1,"Offers":[{"offerVersion":"V2","content":{"offerThroughput":400},"offerResourceId":"dbRid","id":"o1","_rid":"o1"}]}`))
+		case r.URL.Path == "/offers" && strings.Contains(string(data), "collRid"):
+			w.Write([]byte(`{"_count":1,"Offers":[{"offerVersion":"V2","content":{"offerThroughput":1000,"offerAutopilotSettings":{"maxThroughput":10000}},"offerResourceId":"collRid","id":"o2","_rid":"o2"}]}`))
+		case r.URL.Path == "/offers":
+			w.Write([]byte(`{"_count":0,"Offers":[]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":"NotFound","message":"not found"}`))
+		}
+	}))
+	defer server.Close()
+	db, _ := sql.Open("gocosmos", "AccountEndpoint="+server.URL+";AccountKey=cHJpbWFyeQ==;DefaultDb=db")
+	defer db.Close()
+
+	testData := map[string][]int64{
+		"SHOW THROUGHPUT DATABASE":        {400, 0},
+		"SHOW THROUGHPUT COLLECTION coll": {1000, 10000},
+		"SHOW THROUGHPUT TABLE db.shared": nil,
+	}
+	for query, expected := range testData {
+		rows, err := db.Query(query)
+		if err != nil {
+			t.Fatalf("%s failed: <%s> %s", name, query, err)
+		}
+		var ru, maxru int64
+		var offerVersion, resourceId, id, rid, self, etag string
+		var ts int64
+		found := rows.Next()
+		if found {
+			if err := rows.Scan(&ru, &maxru, &offerVersion, &resourceId, &id, &rid, &ts, &self, &etag); err != nil {
+				t.Fatalf("%s failed: <%s> %s", name, query, err)
+			}
+		}
+		rows.Close()
+		if expected == nil && found {
+			t.Fatalf("%s failed: <%s> expected no rows", name, query)
+		} else if expected != nil && (!found || ru != expected[0] || maxru != expected[1] || offerVersion != "V2") {
+			t.Fatalf("%s failed: <%s> expected %v but received %v/%v/%v", name, query, expected, ru, maxru, offerVersion)
+		}
+	}
+	if _, err := db.Query("SHOW THROUGHPUT COLLECTION not_exists"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("%s failed: expected ErrNotFound but received %#v", name, err)
+	}
+
+	client, _ := NewRestClient(nil, "AccountEndpoint="+server.URL+";AccountKey=cHJpbWFyeQ==")
+	if result := client.GetOfferForCollection("db", "coll"); result.Error() != nil || result.AutopilotMaxThroughput() != 10000 {
+		t.Fatalf("%s failed: unexpected offer %#v / %s", name, result.OfferInfo, result.Error())
+	}
+	if result := client.GetOfferForDatabase("db"); result.Error() != nil || result.OfferThroughput() != 400 {
+		t.Fatalf("%s failed: unexpected offer %#v / %s", name, result.OfferInfo, result.Error())
+	}
+	if result := client.GetOfferForCollection("db", "shared"); result.StatusCode != 404 {
+		t.Fatalf("%s failed: <status-code> expected %#v but received %#v", name, 404, result.StatusCode)
+	}
+}
+
+func Test_parseQuery_User(t *testing.T) {
+	name := "Test_parseQuery_User"
+	type testStruct struct {
+		dbName   string
+		userId   string
+		ifExists bool
+	}
+	createData := map[string]testStruct{
+		"CREATE USER db1.user1":                   {dbName: "db1", userId: "user1"},
+		"create\nuser\r\n\tIF not\nexists user-2": {dbName: "mydb", userId: "user-2", ifExists: true},
+	}
+	for query, data := range createData {
+		if stmt, err := parseQueryWithDefaultDb(nil, "mydb", query); err != nil {
+			t.Fatalf("%s failed: %s", name+"/"+query, err)
+		} else if dbstmt, ok := stmt.(*StmtCreateUser); !ok {
+			t.Fatalf("%s failed: the parsed stmt must be of type *StmtCreateUser", name+"/"+query)
+		} else if dbstmt.dbName != data.dbName || dbstmt.userId != data.userId || dbstmt.ifNotExists != data.ifExists {
+			t.Fatalf("%s failed: expected %#v but received %#v", name+"/"+query, data, dbstmt)
+		}
+	}
+	dropData := map[string]testStruct{
+		"DROP USER db1.user1":           {dbName: "db1", userId: "user1"},
+		"drop user\n\tif EXISTS user_2": {dbName: "mydb", userId: "user_2", ifExists: true},
+	}
+	for query, data := range dropData {
+		if stmt, err := parseQueryWithDefaultDb(nil, "mydb", query); err != nil {
+			t.Fatalf("%s failed: %s", name+"/"+query, err)
+		} else if dbstmt, ok := stmt.(*StmtDropUser); !ok {
+			t.Fatalf("%s failed: the parsed stmt must be of type *StmtDropUser", name+"/"+query)
+		} else if dbstmt.dbName != data.dbName || dbstmt.userId != data.userId || dbstmt.ifExists != data.ifExists {
+			t.Fatalf("%s failed: expected %#v but received %#v", name+"/"+query, data, dbstmt)
+		}
+	}
+	listData := map[string]string{"LIST USERS FROM db1": "db1", "list\n\tuser": "mydb"}
+	for query, data := range listData {
+		if stmt, err := parseQueryWithDefaultDb(nil, "mydb", query); err != nil {
+			t.Fatalf("%s failed: %s", name+"/"+query, err)
+		} else if dbstmt, ok := stmt.(*StmtListUsers); !ok {
+			t.Fatalf("%s failed: the parsed stmt must be of type *StmtListUsers", name+"/"+query)
+		} else if dbstmt.dbName != data {
+			t.Fatalf("%s failed: <db-name> expected %#v but received %#v", name+"/"+query, data, dbstmt.dbName)
+		}
+	}
+
+	invalidQueries := []string{
+		"CREATE USER user1",
+		"CREATE USER",
+		"DROP USER user1",
+		"LIST USERS",
+		"LIST USERS FROM",
+	}
+	for _, query := range invalidQueries {
+		if _, err := parseQuery(nil, query); err == nil {
+			t.Fatalf("%s failed: query must not be parsed/validated successfully", name+"/"+query)
+		}
+	}
+}
+
+func Test_parseQuery_GrantRevoke(t *testing.T) {
+	name := "Test_parseQuery_GrantRevoke"
+	type testStruct struct {
+		dbName, collName, userId, mode, permissionId string
+	}
+	testData := map[string]testStruct{
+		"GRANT ALL ON db1.coll1 TO user1":                  {"db1", "coll1", "user1", "All", "coll1"},
+		"grant\nread on\r\n\tcoll2 to user2 WITH id=perm2": {"mydb", "coll2", "user2", "Read", "perm2"},
+		"REVOKE ON db3.coll3 FROM user3":                   {"db3", "coll3", "user3", "", "coll3"},
+		"revoke read ON coll4 from user4 with ID=perm4":    {"mydb", "coll4", "user4", "", "perm4"},
+	}
+	for query, data := range testData {
+		stmt, err := parseQueryWithDefaultDb(nil, "mydb", query)
+		if err != nil {
+			t.Fatalf("%s failed: %s", name+"/"+query, err)
+		}
+		var parsed testStruct
+		switch dbstmt := stmt.(type) {
+		case *StmtGrant:
+			parsed = testStruct{dbstmt.dbName, dbstmt.collName, dbstmt.userId, dbstmt.mode, dbstmt.permissionId}
+		case *StmtRevoke:
+			parsed = testStruct{dbstmt.dbName, dbstmt.collName, dbstmt.userId, "", dbstmt.permissionId}
+		default:
+			t.Fatalf("%s failed: the parsed stmt must be of type *StmtGrant or *StmtRevoke", name+"/"+query)
+		}
+		if parsed != data {
+			t.Fatalf("%s failed: expected %#v but received %#v", name+"/"+query, data, parsed)
+		}
+	}
+
+	invalidQueries := []string{
+		"GRANT ALL ON coll1 TO user1",
+		"GRANT WRITE ON db1.coll1 TO user1",
+		"GRANT ALL ON db1.coll1 TO user1 WITH id=",
+		"REVOKE ON coll1 FROM user1",
+		"REVOKE ALL ON db1.coll1 TO user1",
+	}
+	for _, query := range invalidQueries {
+		if _, err := parseQuery(nil, query); err == nil {
+			t.Fatalf("%s failed: query must not be parsed/validated successfully", name+"/"+query)
+		}
+	}
+}
+
+func TestStmtGrant_Exec(t *testing.T) {
+	name := "TestStmtGrant_Exec"
+	requests := make([]string, 0)
+	permissions := make(map[string]bool)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/dbs/db/users/user1/permissions":
+			permissions["coll"] = true
+			w.WriteHeader(http.StatusCreated)
+			w.Write(data)
+		case r.URL.Path == "/dbs/db/users/user1/permissions/coll" && permissions["coll"]:
+			if r.Method == http.MethodDelete {
+				delete(permissions, "coll")
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			w.Write(data)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":"NotFound","message":"not found"}`))
+		}
+	}))
+	defer server.Close()
+	db, _ := sql.Open("gocosmos", "AccountEndpoint="+server.URL+";AccountKey=cHJpbWFyeQ==;DefaultDb=db")
+	defer db.Close()
+
+	testData := []struct {
+		query        string
+		rowsAffected int64
+		requests     []string
+	}{
+		{"GRANT READ ON coll TO user1", 1, []string{"PUT /dbs/db/users/user1/permissions/coll", "POST /dbs/db/users/user1/permissions"}},
+		{"GRANT ALL ON coll TO user1", 1, []string{"PUT /dbs/db/users/user1/permissions/coll"}},
+		{"REVOKE ON coll FROM user1", 1, []string{"DELETE /dbs/db/users/user1/permissions/coll"}},
+		{"REVOKE ON coll FROM user1", 0, []string{"DELETE /dbs/db/users/user1/permissions/coll"}},
+	}
+	for _, data := range testData {
+		requests = requests[:0]
+		result, err := db.Exec(data.query)
+		if err != nil {
+			t.Fatalf("%s failed: <%s> %s", name, data.query, err)
+		}
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected != data.rowsAffected || !reflect.DeepEqual(requests, data.requests) {
+			t.Fatalf("%s failed: <%s> expected %d/%v but received %d/%v", name, data.query, data.rowsAffected, data.requests, rowsAffected, requests)
+		}
+	}
+	if _, err := db.Exec("GRANT ALL ON coll TO user_not_exists"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("%s failed: expected ErrNotFound but received %#v", name, err)
+	}
+}
+
 func Test_parseQuery_Insert(t *testing.T) {
 	name := "Test_parseQuery_Insert"
 	type testStruct struct {
@@ -428,18 +1093,339 @@ $1, :3, @2)`: {
 	}
 }
 
-func Test_parseQuery_InsertDefaultDb(t *testing.T) {
-	name := "Test_parseQuery_InsertDefaultDb"
-	dbName := "mydb"
-	type testStruct struct {
-		dbName   string
-		collName string
-		fields   []string
-		values   []interface{}
+func Test_parseQuery_InsertJson(t *testing.T) {
+	name := "Test_parseQuery_InsertJson"
+	testData := map[string]struct {
+		document interface{}
+		numInput int
+	}{
+		"INSERT INTO db.coll JSON :1":                   {document: placeholder{1}, numInput: 2},
+		`UPSERT INTO db.coll DOCUMENT "{\"id\":\"1\"}"`: {document: map[string]interface{}{"id": "1"}, numInput: 1},
 	}
-	testData := map[string]testStruct{
-		`INSERT INTO
-table1 (a, b, c, d, e, 
+	for query, data := range testData {
+		if stmt, err := parseQuery(nil, query); err != nil {
+			t.Fatalf("%s failed: %s", name+"/"+query, err)
+		} else if dbstmt, ok := stmt.(*StmtInsert); !ok || !dbstmt.isDocument {
+			t.Fatalf("%s failed: the parsed stmt must be a *StmtInsert with document", name+"/"+query)
+		} else if !reflect.DeepEqual(dbstmt.document, data.document) || dbstmt.NumInput() != data.numInput {
+			t.Fatalf("%s failed: unexpected document %#v / num input %d", name+"/"+query, dbstmt.document, dbstmt.NumInput())
+		}
+	}
+	if _, err := parseQuery(nil, `INSERT INTO db.coll JSON "[1,2]"`); err == nil {
+		t.Fatalf("%s failed: document must be a JSON object", name)
+	}
+
+	type user struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	}
+	for _, arg := range []interface{}{map[string]interface{}{"id": "1", "name": "a"}, `{"id":"1","name":"a"}`, []byte(`{"id":"1","name":"a"}`), user{"1", "a"}} {
+		if doc, err := _toDocument(arg, StdJsonCodec); err != nil || !reflect.DeepEqual(doc, map[string]interface{}{"id": "1", "name": "a"}) {
+			t.Fatalf("%s failed: <%#v> unexpected document %#v / %s", name, arg, doc, err)
+		}
+	}
+	if _, err := _toDocument("[1,2]", StdJsonCodec); err == nil {
+		t.Fatalf("%s failed: document must be a JSON object", name)
+	}
+
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+	}))
+	defer server.Close()
+	dconn, err := (&Driver{}).Open("AccountEndpoint=" + server.URL + ";AccountKey=cHJpbWFyeQ==")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	defer dconn.Close()
+	stmt, err := dconn.(*Conn).PrepareContext(context.Background(), "INSERT INTO db.coll JSON ?")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	args := []driver.NamedValue{{Ordinal: 1, Value: user{"1", "a"}}, {Ordinal: 2, Value: "1"}}
+	if _, err := stmt.(driver.StmtExecContext).ExecContext(context.Background(), args); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if string(body) != `{"id":"1","name":"a"}` {
+		t.Fatalf("%s failed: unexpected document %s", name, body)
+	}
+}
+
+func TestStmtInsert_Returning(t *testing.T) {
+	name := "TestStmtInsert_Returning"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"1","name":"a","tags":["x"],"_rid":"r1","_etag":"e1","_ts":10}`))
+	}))
+	defer server.Close()
+	dconn, err := (&Driver{}).Open("AccountEndpoint=" + server.URL + ";AccountKey=cHJpbWFyeQ==")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	defer dconn.Close()
+	args := []driver.NamedValue{{Ordinal: 1, Value: "a"}, {Ordinal: 2, Value: "1"}}
+	testData := map[string][]string{
+		`INSERT INTO db.coll (id, name) VALUES ("\"1\"", ?) RETURNING *`:              {"_etag", "_rid", "_ts", "id", "name", "tags"},
+		`UPSERT INTO db.coll (id, name) VALUES ("\"1\"", ?) RETURNING _etag, name, x`: {"_etag", "name", "x"},
+	}
+	for query, columns := range testData {
+		stmt, err := dconn.(*Conn).PrepareContext(context.Background(), query)
+		if err != nil {
+			t.Fatalf("%s failed: <%s> %s", name, query, err)
+		}
+		rows, err := stmt.(driver.StmtQueryContext).QueryContext(context.Background(), args)
+		if err != nil {
+			t.Fatalf("%s failed: <%s> %s", name, query, err)
+		}
+		if !reflect.DeepEqual(rows.Columns(), columns) {
+			t.Fatalf("%s failed: <%s> expected columns %#v but received %#v", name, query, columns, rows.Columns())
+		}
+		dest := make([]driver.Value, len(columns))
+		if err := rows.Next(dest); err != nil || dest[0] != "e1" {
+			t.Fatalf("%s failed: <%s> unexpected row %#v / %s", name, query, dest, err)
+		}
+		if err := rows.Next(dest); err == nil {
+			t.Fatalf("%s failed: <%s> only one row must be returned", name, query)
+		}
+	}
+	stmt, _ := dconn.(*Conn).PrepareContext(context.Background(), "INSERT INTO db.coll (id) VALUES (1)")
+	if _, err := stmt.(driver.StmtQueryContext).QueryContext(context.Background(), args[1:]); err == nil {
+		t.Fatalf("%s failed: Query without RETURNING must not be supported", name)
+	}
+}
+
+func TestStmtUpdateDelete_Returning(t *testing.T) {
+	name := "TestStmtUpdateDelete_Returning"
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch {
+		case r.URL.Path != "/dbs/db/colls/coll/docs/1":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":"NotFound","message":"ResourceType: Document"}`))
+		case r.Method == "GET":
+			w.Write([]byte(`{"id":"1","a":1,"_etag":"e1"}`))
+		case r.Method == "PUT":
+			body, _ := ioutil.ReadAll(r.Body)
+			w.Write([]byte(strings.Replace(string(body), "}", `,"_etag":"e2"}`, 1)))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+	dconn, err := (&Driver{}).Open("AccountEndpoint=" + server.URL + ";AccountKey=cHJpbWFyeQ==")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	defer dconn.Close()
+	query := func(query string, args ...interface{}) []driver.Value {
+		stmt, err := dconn.(*Conn).PrepareContext(context.Background(), query)
+		if err != nil {
+			t.Fatalf("%s failed: <%s> %s", name, query, err)
+		}
+		namedArgs := make([]driver.NamedValue, len(args))
+		for i, arg := range args {
+			namedArgs[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+		}
+		rows, err := stmt.(driver.StmtQueryContext).QueryContext(context.Background(), namedArgs)
+		if err != nil {
+			t.Fatalf("%s failed: <%s> %s", name, query, err)
+		}
+		dest := make([]driver.Value, len(rows.Columns()))
+		if err := rows.Next(dest); err != nil {
+			return nil
+		}
+		return dest
+	}
+
+	if row := query("UPDATE db.coll SET a=2 WHERE id=? RETURNING a, _etag", "1", "pk"); !reflect.DeepEqual(row, []driver.Value{2.0, "e2"}) {
+		t.Fatalf("%s failed: expected the updated document but received %#v", name, row)
+	}
+	if row := query("DELETE FROM db.coll WHERE id=? RETURNING a", "1", "pk"); !reflect.DeepEqual(row, []driver.Value{1.0}) {
+		t.Fatalf("%s failed: expected the removed document but received %#v", name, row)
+	} else if expected := []string{"GET /dbs/db/colls/coll/docs/1", "DELETE /dbs/db/colls/coll/docs/1"}; !reflect.DeepEqual(requests[len(requests)-2:], expected) {
+		t.Fatalf("%s failed: expected requests %#v but received %#v", name, expected, requests)
+	}
+	for _, q := range []string{"UPDATE db.coll SET a=2 WHERE id=? RETURNING *", "DELETE FROM db.coll WHERE id=? RETURNING *"} {
+		if row := query(q, "2", "pk"); row != nil {
+			t.Fatalf("%s failed: <%s> no row must be returned for missing document, received %#v", name, q, row)
+		}
+	}
+}
+
+func TestStmtInsert_Ignore(t *testing.T) {
+	name := "TestStmtInsert_Ignore"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/dbs/db/colls/coll":
+			w.Write([]byte(`{"id":"coll","partitionKey":{"paths":["/id"],"kind":"Hash"}}`))
+		case r.Header.Get("X-Ms-Documentdb-Isquery") == "true":
+			w.Write([]byte(`{"Documents":[{"id":"1"},{"id":"2"}],"_count":2}`))
+		default:
+			body, _ := ioutil.ReadAll(r.Body)
+			if strings.Contains(string(body), `"id":"1"`) {
+				w.WriteHeader(http.StatusConflict)
+				w.Write([]byte(`{"code":"Conflict","message":"Entity with the specified id already exists in the system."}`))
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			w.Write(body)
+		}
+	}))
+	defer server.Close()
+	dconn, err := (&Driver{}).Open("AccountEndpoint=" + server.URL + ";AccountKey=cHJpbWFyeQ==")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	defer dconn.Close()
+	exec := func(query string, args ...driver.NamedValue) (driver.Result, error) {
+		stmt, err := dconn.(*Conn).PrepareContext(context.Background(), query)
+		if err != nil {
+			t.Fatalf("%s failed: <%s> %s", name, query, err)
+		}
+		return stmt.(driver.StmtExecContext).ExecContext(context.Background(), args)
+	}
+	args := []driver.NamedValue{{Ordinal: 1, Value: "1"}, {Ordinal: 2, Value: "1"}}
+
+	if _, err := exec("INSERT INTO db.coll (id) VALUES (?)", args...); !errors.Is(err, ErrConflict) {
+		t.Fatalf("%s failed: expected ErrConflict but received %#v", name, err)
+	}
+	if result, err := exec("INSERT IGNORE INTO db.coll (id) VALUES (?)", args...); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	} else if rowsAffected, _ := result.RowsAffected(); rowsAffected != 0 {
+		t.Fatalf("%s failed: expected 0 rows affected but received %d", name, rowsAffected)
+	}
+	if result, err := exec("INSERT IGNORE INTO db.coll SELECT * FROM c"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	} else if r := result.(*ResultInsertSelect); r.NumSucceeded != 1 || r.NumIgnored != 1 || r.NumFailed != 0 {
+		t.Fatalf("%s failed: unexpected result %#v", name, r)
+	}
+	if _, err := parseQuery(nil, "UPSERT IGNORE INTO db.coll (id) VALUES (1)"); err == nil {
+		t.Fatalf("%s failed: IGNORE must not be accepted by UPSERT", name)
+	}
+}
+
+func Test_parseQuery_InsertSelect(t *testing.T) {
+	name := "Test_parseQuery_InsertSelect"
+	type testStruct struct {
+		dbName     string
+		collName   string
+		isUpsert   bool
+		fields     []string
+		srcDbName  string
+		srcColl    string
+		numInput   int
+		batchSize  int
+		selectStmt string
+	}
+	testData := map[string]testStruct{
+		"INSERT INTO db1.coll2 SELECT * FROM coll1": {
+			dbName: "db1", collName: "coll2", srcDbName: "db1", srcColl: "coll1", selectStmt: "SELECT * FROM coll1"},
+		"upsert into coll2 (id, name) select c.id, c.username AS name from c where c.age>:1 WITH db=db0 WITH batch_size=10": {
+			dbName: "mydb", collName: "coll2", isUpsert: true, fields: []string{"id", "name"}, srcDbName: "db0", srcColl: "c",
+			numInput: 1, batchSize: 10, selectStmt: "select c.id, c.username AS name from c where c.age>@_1"},
+	}
+	for query, data := range testData {
+		if stmt, err := parseQueryWithDefaultDb(nil, "mydb", query); err != nil {
+			t.Fatalf("%s failed: %s", name+"/"+query, err)
+		} else if dbstmt, ok := stmt.(*StmtInsertSelect); !ok {
+			t.Fatalf("%s failed: the parsed stmt must be of type *StmtInsertSelect", name+"/"+query)
+		} else if dbstmt.dbName != data.dbName || dbstmt.collName != data.collName || dbstmt.isUpsert != data.isUpsert {
+			t.Fatalf("%s failed: unexpected target %s.%s (upsert: %#v)", name+"/"+query, dbstmt.dbName, dbstmt.collName, dbstmt.isUpsert)
+		} else if !reflect.DeepEqual(dbstmt.fields, data.fields) {
+			t.Fatalf("%s failed: <fields> expected %#v but received %#v", name+"/"+query, data.fields, dbstmt.fields)
+		} else if dbstmt.selectStmt.dbName != data.srcDbName || dbstmt.selectStmt.collName != data.srcColl {
+			t.Fatalf("%s failed: unexpected source %s.%s", name+"/"+query, dbstmt.selectStmt.dbName, dbstmt.selectStmt.collName)
+		} else if dbstmt.NumInput() != data.numInput || dbstmt.batchSize != data.batchSize {
+			t.Fatalf("%s failed: unexpected num input %d / batch size %d", name+"/"+query, dbstmt.NumInput(), dbstmt.batchSize)
+		} else if dbstmt.selectStmt.selectQuery != data.selectStmt {
+			t.Fatalf("%s failed: <select-query> expected %#v but received %#v", name+"/"+query, data.selectStmt, dbstmt.selectStmt.selectQuery)
+		}
+	}
+
+	invalidQueries := []string{
+		"INSERT INTO db1.coll2 SELECT CHANGES FROM db1.coll1",                 // only SELECT is supported
+		"INSERT INTO db1.coll2 SELECT * FROM c WITH db=db0 WITH batch_size=0", // invalid batch size
+		"INSERT INTO coll2 SELECT * FROM c",                                   // no database
+	}
+	for _, query := range invalidQueries {
+		if _, err := parseQuery(nil, query); err == nil {
+			t.Fatalf("%s failed: query must not be parsed/validated successfully", name+"/"+query)
+		}
+	}
+}
+
+func TestStmtInsertSelect_Exec(t *testing.T) {
+	name := "TestStmtInsertSelect_Exec"
+	var lock sync.Mutex
+	written := make(map[string]map[string]interface{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/dbs/mydb/colls/coll2":
+			w.Write([]byte(`{"id":"coll2","partitionKey":{"paths":["/pk"],"kind":"Hash"}}`))
+		case r.URL.Path == "/dbs/db0/colls/coll1/docs" && r.Header.Get("X-Ms-Documentdb-Isquery") == "true":
+			if r.Header.Get("X-Ms-Continuation") == "" {
+				w.Header().Set("X-Ms-Continuation", "page2")
+				w.Write([]byte(`{"Documents":[{"id":"1","pk":"a","_rid":"r1","_etag":"e1","_ts":1},{"pk":"b"}],"_count":2}`))
+			} else {
+				w.Write([]byte(`{"Documents":[{"id":"3","pk":"c","x":1}],"_count":1}`))
+			}
+		case r.Method == "POST" && r.URL.Path == "/dbs/mydb/colls/coll2/docs":
+			body, _ := ioutil.ReadAll(r.Body)
+			doc := make(map[string]interface{})
+			json.Unmarshal(body, &doc)
+			lock.Lock()
+			written[doc["pk"].(string)] = doc
+			lock.Unlock()
+			w.WriteHeader(http.StatusCreated)
+			w.Write(body)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":"NotFound","message":"Resource Not Found"}`))
+		}
+	}))
+	defer server.Close()
+	dconn, err := (&Driver{}).Open("AccountEndpoint=" + server.URL + ";AccountKey=cHJpbWFyeQ==;Db=mydb")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	defer dconn.Close()
+	stmt, err := dconn.(*Conn).PrepareContext(context.Background(), "INSERT INTO coll2 SELECT * FROM coll1 WITH db=db0")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	result, err := stmt.(driver.StmtExecContext).ExecContext(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected != 3 || len(written) != 3 {
+		t.Fatalf("%s failed: expected 3 documents written but received %d / %#v", name, rowsAffected, written)
+	}
+	if doc := written["a"]; doc["id"] != "1" || doc["_rid"] != nil || doc["_etag"] != nil || doc["_ts"] != nil {
+		t.Fatalf("%s failed: system properties must be removed, received %#v", name, doc)
+	}
+	if id, _ := written["b"]["id"].(string); id == "" {
+		t.Fatalf("%s failed: id must be generated for rows without id, received %#v", name, written["b"])
+	}
+	if written["c"]["x"] != 1.0 {
+		t.Fatalf("%s failed: rows of all pages must be written, received %#v", name, written["c"])
+	}
+}
+
+func Test_parseQuery_InsertDefaultDb(t *testing.T) {
+	name := "Test_parseQuery_InsertDefaultDb"
+	dbName := "mydb"
+	type testStruct struct {
+		dbName   string
+		collName string
+		fields   []string
+		values   []interface{}
+	}
+	testData := map[string]testStruct{
+		`INSERT INTO
+table1 (a, b, c, d, e, 
 f) VALUES
 	(null, 1.0, 
 true, "\"a string 'with' \\\"quote\\\"\"", "{\"key\":\"value\"}", "[2.0,null,false,\"a string 'with' \\\"quote\\\"\"]")`: {
@@ -656,6 +1642,75 @@ db_3-0.table-3_0 WHERE
 	}
 }
 
+func Test_parseQuery_DeleteByPk(t *testing.T) {
+	name := "Test_parseQuery_DeleteByPk"
+	testData := map[string]struct {
+		dbName, collName string
+		pkValue          interface{}
+		numInput         int
+	}{
+		`DELETE FROM db1.table1 WHERE pk=:1 ALL`:        {dbName: "db1", collName: "table1", pkValue: placeholder{1}, numInput: 1},
+		`DELETE FROM table2 WHERE pk="\"tenant1\"" ALL`: {dbName: "mydb", collName: "table2", pkValue: "tenant1", numInput: 0},
+		"DELETE\nFROM db3.table3\n\tWHERE pk = 123 all": {dbName: "db3", collName: "table3", pkValue: 123.0, numInput: 0},
+		`DELETE FROM db4.table4 WHERE pk=@2 ALL`:        {dbName: "db4", collName: "table4", pkValue: placeholder{2}, numInput: 2},
+	}
+	for query, data := range testData {
+		if stmt, err := parseQueryWithDefaultDb(nil, "mydb", query); err != nil {
+			t.Fatalf("%s failed: %s", name+"/"+query, err)
+		} else if dbstmt, ok := stmt.(*StmtDeleteByPk); !ok {
+			t.Fatalf("%s failed: the parsed stmt must be of type *StmtDeleteByPk", name+"/"+query)
+		} else if dbstmt.dbName != data.dbName || dbstmt.collName != data.collName {
+			t.Fatalf("%s failed: <db-name>.<collection-name> expected %s.%s but received %s.%s", name+"/"+query, data.dbName, data.collName, dbstmt.dbName, dbstmt.collName)
+		} else if !reflect.DeepEqual(dbstmt.pkValue, data.pkValue) || dbstmt.numInput != data.numInput {
+			t.Fatalf("%s failed: <pk-value> expected %#v (%d input) but received %#v (%d input)", name+"/"+query, data.pkValue, data.numInput, dbstmt.pkValue, dbstmt.numInput)
+		}
+	}
+
+	invalidQueries := []string{
+		`DELETE FROM db.table WHERE pk=:1`,                 // no ALL
+		`DELETE FROM db.table WHERE pk= ALL`,               // no pk value
+		`DELETE FROM db.table WHERE pk=tenant1 ALL`,        // unquoted string
+		`DELETE FROM db.table WHERE pk=:1 ALL RETURNING *`, // RETURNING is not supported
+		`DELETE FROM table WHERE pk=:1 ALL`,                // no database
+	}
+	for _, query := range invalidQueries {
+		if _, err := parseQuery(nil, query); err == nil {
+			t.Fatalf("%s failed: query must not be parsed/validated successfully", name+"/"+query)
+		}
+	}
+}
+
+func TestStmtDeleteByPk_Exec(t *testing.T) {
+	name := "TestStmtDeleteByPk_Exec"
+	var method, path, pk string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path, pk = r.Method, r.URL.Path, r.Header.Get("X-Ms-Documentdb-PartitionKey")
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	db, _ := sql.Open("gocosmos", "AccountEndpoint="+server.URL+";AccountKey=cHJpbWFyeQ==;DefaultDb=db")
+	defer db.Close()
+
+	for _, data := range []struct {
+		pkValue    interface{}
+		expectedPk string
+	}{{"tenant1", `["tenant1"]`}, {[]string{"tenant1", "user1"}, `["tenant1","user1"]`}} {
+		result, err := db.Exec("DELETE FROM coll WHERE pk=:1 ALL", data.pkValue)
+		if err != nil {
+			t.Fatalf("%s failed: %s", name, err)
+		}
+		if method != "POST" || path != "/dbs/db/colls/coll/operations/partitionkeydelete" || pk != data.expectedPk {
+			t.Fatalf("%s failed: unexpected request %s %s (pk %s)", name, method, path, pk)
+		}
+		if numRows, err := result.RowsAffected(); err != nil || numRows != 0 {
+			t.Fatalf("%s failed: expected 0 rows affected (deleted in background) but received %d/%s", name, numRows, err)
+		}
+	}
+	if _, err := db.Query("DELETE FROM coll WHERE pk=:1 ALL", "tenant1"); err == nil {
+		t.Fatalf("%s failed: Query must not be supported", name)
+	}
+}
+
 func Test_parseQuery_DeleteDefaultDb(t *testing.T) {
 	name := "Test_parseQuery_DeleteDefaultDb"
 	dbName := "mydb"
@@ -710,16 +1765,23 @@ func Test_parseQuery_Select(t *testing.T) {
 		collName         string
 		isCrossPartition bool
 		selectQuery      string
+		consistency      string
 	}
 	testData := map[string]testStruct{
 		`SELECT * FROM c WITH database=db WITH collection=tbl`: {
 			dbName: "db", collName: "tbl", isCrossPartition: false, selectQuery: `SELECT * FROM c`},
+		`SELECT * FROM c WITH database=db WITH collection=tbl WITH consistency=eventual`: {
+			dbName: "db", collName: "tbl", isCrossPartition: false, selectQuery: `SELECT * FROM c`, consistency: "Eventual"},
+		`SELECT * FROM c WITH db=db WITH CONSISTENCY=Session`: {
+			dbName: "db", collName: "c", isCrossPartition: false, selectQuery: `SELECT * FROM c`, consistency: "Session"},
 		`SELECT CROSS PARTITION * FROM c WHERE id="1" WITH db=db-1 WITH table=tbl_1`: {
 			dbName: "db-1", collName: "tbl_1", isCrossPartition: true, selectQuery: `SELECT * FROM c WHERE id="1"`},
 		`SELECT id,username,email FROM c WHERE username!=@1 AND (id>:2 OR email=$3) WITH CROSS_PARTITION=true WITH database=db WITH table=tbl`: {
 			dbName: "db", collName: "tbl", isCrossPartition: true, selectQuery: `SELECT id,username,email FROM c WHERE username!=@_1 AND (id>@_2 OR email=@_3)`},
 		`SELECT a,b,c FROM user u WHERE u.id="1" WITH db=dbtemp`: {
 			dbName: "dbtemp", collName: "user", isCrossPartition: false, selectQuery: `SELECT a,b,c FROM user u WHERE u.id="1"`},
+		`SELECT * FROM c WHERE c.note="a; WITH db=x WHERE" OR c.tag='WITH b=1' WITH db=db WITH collection=tbl`: {
+			dbName: "db", collName: "tbl", isCrossPartition: false, selectQuery: `SELECT * FROM c WHERE c.note="a; WITH db=x WHERE" OR c.tag='WITH b=1'`},
 	}
 	for query, data := range testData {
 		if stmt, err := parseQuery(nil, query); err != nil {
@@ -734,14 +1796,18 @@ func Test_parseQuery_Select(t *testing.T) {
 			t.Fatalf("%s failed: <cross-partition> expected %#v but received %#v", name+"/"+query, data.isCrossPartition, dbstmt.isCrossPartition)
 		} else if dbstmt.selectQuery != data.selectQuery {
 			t.Fatalf("%s failed: <select-query> expected %#v but received %#v", name+"/"+query, data.selectQuery, dbstmt.selectQuery)
+		} else if dbstmt.consistency != data.consistency {
+			t.Fatalf("%s failed: <consistency> expected %#v but received %#v", name+"/"+query, data.consistency, dbstmt.consistency)
 		}
 	}
 
 	invalidQueries := []string{
-		`SELECT * FROM db.table`,                   // database and collection must be specified by WITH database=<dbname> and WITH collection=<collname>
-		`SELECT * WITH db=dbname`,                  // no collection
-		`SELECT * FROM c WITH collection=collname`, // no database
+		`SELECT * FROM c WITH db=dbname WITH consistency=weak`, // invalid consistency level
+		`SELECT * FROM db.table`,                               // database and collection must be specified by WITH database=<dbname> and WITH collection=<collname>
+		`SELECT * WITH db=dbname`,                              // no collection
+		`SELECT * FROM c WITH collection=collname`,             // no database
 		`SELECT * FROM c WITH db=dbname WITH collection=collname WITH cross_partition=false`, // the only valid value for cross_partition is true
+		`SELECT * FROM c WITH db=dbname WITH objects_as_json=maybe`,                          // invalid objects_as_json value
 	}
 	for _, query := range invalidQueries {
 		if _, err := parseQuery(nil, query); err == nil {
@@ -750,6 +1816,206 @@ func Test_parseQuery_Select(t *testing.T) {
 	}
 }
 
+func TestStmtSelect_Distinct(t *testing.T) {
+	name := "TestStmtSelect_Distinct"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Ms-Continuation") == "" {
+			w.Header().Set("X-Ms-Continuation", "page2")
+			w.Write([]byte(`{"_count":2,"Documents":[{"a":1,"o":{"x":1,"y":2}},{"a":2}]}`))
+			return
+		}
+		w.Write([]byte(`{"_count":3,"Documents":[{"a":2},{"o":{"y":2,"x":1},"a":1},{"a":3}]}`))
+	}))
+	defer server.Close()
+	conn, _ := (&Driver{}).Open("AccountEndpoint=" + server.URL + ";AccountKey=cHJpbWFyeQ==;DefaultDb=db")
+	testData := map[string]int{
+		"SELECT CROSS PARTITION DISTINCT c.a, c.o FROM c":                  3,
+		"SELECT TOP 10 DISTINCT c.a, c.o FROM c WITH cross_partition=true": 3,
+		"SELECT DISTINCT c.a, c.o FROM c":                                  5, // single partition: deduplicated by the server
+		"SELECT CROSS PARTITION c.a, c.o FROM c":                           5,
+	}
+	for query, expected := range testData {
+		stmt, err := conn.(*Conn).PrepareContext(context.Background(), query)
+		if err != nil {
+			t.Fatalf("%s failed: <%s> %s", name, query, err)
+		}
+		rows, err := stmt.(driver.StmtQueryContext).QueryContext(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("%s failed: <%s> %s", name, query, err)
+		}
+		if count := rows.(*ResultSelect).count; count != expected {
+			t.Fatalf("%s failed: <%s> expected %d rows but received %d", name, query, expected, count)
+		}
+	}
+}
+
+func TestStmtSelect_TopOffsetLimit(t *testing.T) {
+	name := "TestStmtSelect_TopOffsetLimit"
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Write([]byte(`{"id":"coll","partitionKey":{"paths":["/id"]}}`))
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		queries = append(queries, string(body))
+		page, _ := strconv.Atoi(r.Header.Get("X-Ms-Continuation"))
+		if page < 2 {
+			w.Header().Set("X-Ms-Continuation", strconv.Itoa(page+1))
+		}
+		w.Write([]byte(fmt.Sprintf(`{"_count":2,"Documents":[{"id":"%d"},{"id":"%d"}]}`, 2*page+1, 2*page+2)))
+	}))
+	defer server.Close()
+	conn, _ := (&Driver{}).Open("AccountEndpoint=" + server.URL + ";AccountKey=cHJpbWFyeQ==;DefaultDb=db")
+	testData := []struct {
+		query     string
+		args      []driver.NamedValue
+		expected  []string
+		numPages  int
+		sentQuery string
+	}{
+		{"SELECT CROSS PARTITION TOP 3 c.id FROM c", nil, []string{"1", "2", "3"}, 2, "SELECT TOP 3 c.id FROM c"},
+		{"SELECT CROSS PARTITION c.id FROM c OFFSET :1 LIMIT :2", []driver.NamedValue{{Ordinal: 1, Value: int64(1)}, {Ordinal: 2, Value: int64(2)}},
+			[]string{"2", "3"}, 2, "SELECT c.id FROM c OFFSET 0 LIMIT 3"},
+		{"SELECT DISTINCT c.id FROM c OFFSET 4 LIMIT 10 WITH cross_partition=true", nil, []string{"5", "6"}, 3, "SELECT DISTINCT c.id FROM c OFFSET 0 LIMIT 14"},
+		{"SELECT TOP 3 c.id FROM c", nil, []string{"1", "2", "3", "4", "5", "6"}, 3, "SELECT TOP 3 c.id FROM c"}, // single partition: enforced by the server
+	}
+	for _, data := range testData {
+		queries = nil
+		stmt, err := conn.(*Conn).PrepareContext(context.Background(), data.query)
+		if err != nil {
+			t.Fatalf("%s failed: <%s> %s", name, data.query, err)
+		}
+		rows, err := stmt.(driver.StmtQueryContext).QueryContext(context.Background(), data.args)
+		if err != nil {
+			t.Fatalf("%s failed: <%s> %s", name, data.query, err)
+		}
+		ids := make([]string, 0)
+		for _, doc := range rows.(*ResultSelect).documents {
+			ids = append(ids, doc["id"].(string))
+		}
+		if !reflect.DeepEqual(ids, data.expected) || len(queries) != data.numPages {
+			t.Fatalf("%s failed: <%s> expected %v in %d pages but received %v in %d pages", name, data.query, data.expected, data.numPages, ids, len(queries))
+		}
+		if !strings.Contains(queries[0], fmt.Sprintf(`"query":%q`, data.sentQuery)) {
+			t.Fatalf("%s failed: <%s> expected query %q to be sent, request body %s", name, data.query, data.sentQuery, queries[0])
+		}
+	}
+
+	stmt, _ := conn.(*Conn).PrepareContext(context.Background(), "SELECT CROSS PARTITION TOP :1 c.id FROM c")
+	if _, err := stmt.(driver.StmtQueryContext).QueryContext(context.Background(), []driver.NamedValue{{Ordinal: 1, Value: "x"}}); err == nil {
+		t.Fatalf("%s failed: invalid TOP value must be rejected", name)
+	}
+}
+
+func TestStmtSelect_EnableScan(t *testing.T) {
+	name := "TestStmtSelect_EnableScan"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Ms-Documentdb-Query-Enable-Scan") != "true" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":"BadRequest","message":"An invalid query has been specified with filters against path(s) excluded from indexing. Consider adding allow scan header in the request."}`))
+			return
+		}
+		w.Write([]byte(`{"_count":1,"Documents":[{"id":"1"}]}`))
+	}))
+	defer server.Close()
+	db, _ := sql.Open("gocosmos", "AccountEndpoint="+server.URL+";AccountKey=cHJpbWFyeQ==;DefaultDb=db")
+	defer db.Close()
+
+	_, err := db.Query("SELECT * FROM c WHERE c.excluded=1")
+	var cosmosErr *CosmosError
+	if !errors.Is(err, ErrScanRequired) || !errors.Is(err, ErrBadRequest) || !errors.As(err, &cosmosErr) ||
+		!strings.Contains(err.Error(), "WITH enable_scan=true") {
+		t.Fatalf("%s failed: expected error matching ErrScanRequired but received %#v", name, err)
+	}
+	rows, err := db.Query("SELECT * FROM c WHERE c.excluded=1 WITH enable_scan=true")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	rows.Close()
+	if (&CosmosError{StatusCode: 400, Body: []byte(`{"message":"Syntax error"}`)}).Is(ErrScanRequired) {
+		t.Fatalf("%s failed: other bad requests must not match ErrScanRequired", name)
+	}
+}
+
+func TestStmtSelect_PkRangeId(t *testing.T) {
+	name := "TestStmtSelect_PkRangeId"
+	var pkRangeId, crossPartition string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pkRangeId, crossPartition = r.Header.Get("X-Ms-Documentdb-PartitionKeyRangeId"), r.Header.Get("X-Ms-Documentdb-Query-EnableCrossPartition")
+		w.Write([]byte(`{"_count":1,"Documents":[{"id":"1"}]}`))
+	}))
+	defer server.Close()
+	db, _ := sql.Open("gocosmos", "AccountEndpoint="+server.URL+";AccountKey=cHJpbWFyeQ==;DefaultDb=db")
+	defer db.Close()
+
+	for query, expected := range map[string]string{
+		"SELECT * FROM c WITH pkrangeid=2": "2",
+		"SELECT * FROM c":                  "",
+	} {
+		rows, err := db.Query(query)
+		if err != nil {
+			t.Fatalf("%s failed: <%s> %s", name, query, err)
+		}
+		rows.Close()
+		if pkRangeId != expected || (expected != "" && crossPartition != "true") {
+			t.Fatalf("%s failed: <%s> expected partition key range %#v but received %#v (cross-partition: %#v)", name, query, expected, pkRangeId, crossPartition)
+		}
+	}
+	if _, err := parseQuery(nil, "SELECT * FROM c WITH db=db WITH pkrangeid="); err == nil {
+		t.Fatalf("%s failed: empty pkrangeid must be rejected", name)
+	}
+}
+
+func TestStmtSelect_InSlice(t *testing.T) {
+	name := "TestStmtSelect_InSlice"
+	var received struct {
+		Query  string        `json:"query"`
+		Params []interface{} `json:"parameters"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received.Query, received.Params = "", nil
+		json.Unmarshal(body, &received)
+		w.Write([]byte(`{"_count":1,"Documents":[{"id":"1"}]}`))
+	}))
+	defer server.Close()
+	db, _ := sql.Open("gocosmos", "AccountEndpoint="+server.URL+";AccountKey=cHJpbWFyeQ==;DefaultDb=db")
+	defer db.Close()
+
+	testData := []struct {
+		query    string
+		args     []interface{}
+		expected string
+		params   []interface{}
+	}{
+		{"SELECT * FROM c WHERE c.id IN (:1) AND c.grade>:2", []interface{}{[]string{"a", "b"}, 1},
+			"SELECT * FROM c WHERE c.id IN (@_1_1, @_1_2) AND c.grade>@_2",
+			[]interface{}{map[string]interface{}{"name": "@_1_1", "value": "a"}, map[string]interface{}{"name": "@_1_2", "value": "b"},
+				map[string]interface{}{"name": "@_2", "value": 1.0}}},
+		{"SELECT * FROM c WHERE c.grade NOT IN (?)", []interface{}{[]int{1}},
+			"SELECT * FROM c WHERE c.grade NOT IN (@_1_1)", []interface{}{map[string]interface{}{"name": "@_1_1", "value": 1.0}}},
+		{"SELECT * FROM c WHERE c.id IN (:1, :2)", []interface{}{"a", "b"},
+			"SELECT * FROM c WHERE c.id IN (@_1, @_2)",
+			[]interface{}{map[string]interface{}{"name": "@_1", "value": "a"}, map[string]interface{}{"name": "@_2", "value": "b"}}},
+		{"SELECT * FROM c WHERE ARRAY_CONTAINS(:1, c.id)", []interface{}{[]string{"a", "b"}},
+			"SELECT * FROM c WHERE ARRAY_CONTAINS(@_1, c.id)", []interface{}{map[string]interface{}{"name": "@_1", "value": []interface{}{"a", "b"}}}},
+	}
+	for _, data := range testData {
+		rows, err := db.Query(data.query, data.args...)
+		if err != nil {
+			t.Fatalf("%s failed: <%s> %s", name, data.query, err)
+		}
+		rows.Close()
+		if received.Query != data.expected || !reflect.DeepEqual(received.Params, data.params) {
+			t.Fatalf("%s failed: <%s> expected %#v / %#v but received %#v / %#v", name, data.query, data.expected, data.params, received.Query, received.Params)
+		}
+	}
+	if _, err := db.Query("SELECT * FROM c WHERE c.id IN (:1)", []string{}); err == nil {
+		t.Fatalf("%s failed: empty list must be rejected", name)
+	}
+}
+
 func Test_parseQuery_SelectDefaultDb(t *testing.T) {
 	name := "Test_parseQuery_SelectDefaultDb"
 	dbName := "mydb"
@@ -851,6 +2117,114 @@ SET a=$1, b=
 	}
 }
 
+func Test_parseQuery_EtagCondition(t *testing.T) {
+	name := "Test_parseQuery_EtagCondition"
+	type testStruct struct {
+		idStr    string
+		etag     interface{}
+		numInput int
+	}
+	testData := map[string]testStruct{
+		`UPDATE db.coll SET a=1 WHERE id="1" AND _etag="\"0a00-1\""`:            {idStr: "1", etag: `"0a00-1"`, numInput: 1},
+		`UPDATE db.coll SET a=1 WHERE id=:1 and _ETAG = :2`:                     {idStr: ":1", etag: placeholder{2}, numInput: 3},
+		`UPDATE db.coll SET a=@1 WHERE id=@2 AND _etag=@3 WITH pretrigger=trg1`: {idStr: "@2", etag: placeholder{3}, numInput: 4},
+		`DELETE FROM db.coll WHERE id="1" AND _etag="\"0a00-1\""`:               {idStr: "1", etag: `"0a00-1"`, numInput: 1},
+		`DELETE FROM db.coll WHERE id=$1 AND _etag=$2 WITH posttrigger=trg1`:    {idStr: "$1", etag: placeholder{2}, numInput: 3},
+		`DELETE FROM db.coll WHERE id=1`:                                        {idStr: "1", etag: nil, numInput: 1},
+		`UPDATE db.coll SET a=1 WHERE id=abc`:                                   {idStr: "abc", etag: nil, numInput: 1},
+	}
+	for query, data := range testData {
+		stmt, err := parseQuery(nil, query)
+		if err != nil {
+			t.Fatalf("%s failed: %s", name+"/"+query, err)
+		}
+		var idStr string
+		var etag interface{}
+		switch dbstmt := stmt.(type) {
+		case *StmtUpdate:
+			idStr, etag = dbstmt.idStr, dbstmt.etag
+		case *StmtDelete:
+			idStr, etag = dbstmt.idStr, dbstmt.etag
+		default:
+			t.Fatalf("%s failed: unexpected stmt type %T", name+"/"+query, stmt)
+		}
+		if idStr != data.idStr {
+			t.Fatalf("%s failed: <id-str> expected %#v but received %#v", name+"/"+query, data.idStr, idStr)
+		} else if etag != data.etag {
+			t.Fatalf("%s failed: <etag> expected %#v but received %#v", name+"/"+query, data.etag, etag)
+		} else if stmt.NumInput() != data.numInput {
+			t.Fatalf("%s failed: <num-input> expected %#v but received %#v", name+"/"+query, data.numInput, stmt.NumInput())
+		}
+	}
+
+	invalidQueries := []string{
+		`UPDATE db.coll SET a=1 WHERE id=1 AND _etag=abc`, // etag must be a placeholder or a double-quoted string
+		`UPDATE db.coll SET a=1 WHERE id=1 AND _etag=""`,  // empty etag
+		`UPDATE db.coll SET a=1 WHERE id=1 AND _etag=`,    // missing etag
+		`DELETE FROM db.coll WHERE id=1 AND _etag=:x`,     // invalid placeholder
+	}
+	for _, query := range invalidQueries {
+		if _, err := parseQuery(nil, query); err == nil {
+			t.Fatalf("%s failed: query must not be parsed/validated successfully", name+"/"+query)
+		}
+	}
+}
+
+func TestStmtUpdate_OrInsert(t *testing.T) {
+	name := "TestStmtUpdate_OrInsert"
+	var requests []string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/dbs/db/colls/coll/docs/exist":
+			w.Write([]byte(`{"id":"exist","pk":"a","_etag":"e1"}`))
+		case r.Method == "GET":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":"NotFound","message":"Entity with the specified id does not exist in the system. ResourceType: Document"}`))
+		default:
+			body, _ = ioutil.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+			w.Write(body)
+		}
+	}))
+	defer server.Close()
+	dconn, err := (&Driver{}).Open("AccountEndpoint=" + server.URL + ";AccountKey=cHJpbWFyeQ==")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	defer dconn.Close()
+	stmt, err := dconn.(*Conn).PrepareContext(context.Background(), "UPDATE OR INSERT db.coll SET pk=:1, n=1 WHERE id=:2")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	for _, id := range []string{"exist", "new"} {
+		requests = nil
+		args := []driver.NamedValue{{Ordinal: 1, Value: "a"}, {Ordinal: 2, Value: id}, {Ordinal: 3, Value: "a"}}
+		result, err := stmt.(driver.StmtExecContext).ExecContext(context.Background(), args)
+		if err != nil {
+			t.Fatalf("%s failed: %s", name, err)
+		}
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected != 1 || result.(*ResultUpdate).Inserted != (id == "new") {
+			t.Fatalf("%s failed: <%s> unexpected result %#v", name, id, result)
+		}
+		expected := []string{"GET /dbs/db/colls/coll/docs/" + id, "PUT /dbs/db/colls/coll/docs/" + id}
+		if id == "new" {
+			expected[1] = "POST /dbs/db/colls/coll/docs"
+		}
+		if !reflect.DeepEqual(requests, expected) {
+			t.Fatalf("%s failed: <%s> expected requests %#v but received %#v", name, id, expected, requests)
+		}
+	}
+	if string(body) != `{"id":"new","n":1,"pk":"a"}` {
+		t.Fatalf("%s failed: unexpected document %s", name, body)
+	}
+
+	if _, err := parseQuery(nil, "UPDATE OR INSERT db.coll SET a=1 WHERE id=1 AND _etag=:1"); err == nil {
+		t.Fatalf("%s failed: _etag condition must be rejected", name)
+	}
+}
+
 func Test_parseQuery_UpdateDefaultDb(t *testing.T) {
 	name := "Test_parseQuery_UpdateDefaultDb"
 	dbName := "mydb"
@@ -908,3 +2282,617 @@ SET a=$1, b=
 		}
 	}
 }
+
+func Test_parseQuery_CreateProcedure(t *testing.T) {
+	name := "Test_parseQuery_CreateProcedure"
+	type testStruct struct {
+		dbName      string
+		collName    string
+		sprocId     string
+		ifNotExists bool
+		body        interface{}
+		numInput    int
+	}
+	testData := map[string]testStruct{
+		`CREATE PROCEDURE db1.table1.sp1 AS "function() {}"`:                                           {dbName: "db1", collName: "table1", sprocId: "sp1", body: "function() {}"},
+		"create\nprocedure\tIF NOT EXISTS\r\ndb-2.table_2.sp-2 as :1":                                  {dbName: "db-2", collName: "table_2", sprocId: "sp-2", ifNotExists: true, body: placeholder{1}, numInput: 1},
+		`CREATE PROCEDURE mytable.sp3 AS "function() { getContext().getResponse().setBody(\"hi\"); }"`: {dbName: "mydb", collName: "mytable", sprocId: "sp3", body: `function() { getContext().getResponse().setBody("hi"); }`},
+	}
+	for query, data := range testData {
+		if stmt, err := parseQueryWithDefaultDb(nil, "mydb", query); err != nil {
+			t.Fatalf("%s failed: %s", name+"/"+query, err)
+		} else if dbstmt, ok := stmt.(*StmtCreateProcedure); !ok {
+			t.Fatalf("%s failed: the parsed stmt must be of type *StmtCreateProcedure", name+"/"+query)
+		} else if dbstmt.dbName != data.dbName {
+			t.Fatalf("%s failed: <db-name> expected %#v but received %#v", name+"/"+query, data.dbName, dbstmt.dbName)
+		} else if dbstmt.collName != data.collName {
+			t.Fatalf("%s failed: <collection-name> expected %#v but received %#v", name+"/"+query, data.collName, dbstmt.collName)
+		} else if dbstmt.sprocId != data.sprocId {
+			t.Fatalf("%s failed: <procedure-id> expected %#v but received %#v", name+"/"+query, data.sprocId, dbstmt.sprocId)
+		} else if dbstmt.ifNotExists != data.ifNotExists {
+			t.Fatalf("%s failed: <if-not-exists> expected %#v but received %#v", name+"/"+query, data.ifNotExists, dbstmt.ifNotExists)
+		} else if !reflect.DeepEqual(dbstmt.body, data.body) {
+			t.Fatalf("%s failed: <body> expected %#v but received %#v", name+"/"+query, data.body, dbstmt.body)
+		} else if dbstmt.NumInput() != data.numInput {
+			t.Fatalf("%s failed: <num-input> expected %#v but received %#v", name+"/"+query, data.numInput, dbstmt.NumInput())
+		}
+	}
+
+	invalidQueries := []string{
+		`CREATE PROCEDURE db.coll.sp`,                  // no body
+		`CREATE PROCEDURE db.coll.sp AS function() {}`, // body is not a string literal
+		`CREATE PROCEDURE coll.sp AS "function() {}"`,  // no database
+		`CREATE PROCEDURE sp AS "function() {}"`,       // no collection
+	}
+	for _, query := range invalidQueries {
+		if _, err := parseQuery(nil, query); err == nil {
+			t.Fatalf("%s failed: query must not be parsed/validated successfully", name+"/"+query)
+		}
+	}
+}
+
+func Test_parseQuery_AlterProcedure(t *testing.T) {
+	name := "Test_parseQuery_AlterProcedure"
+	type testStruct struct {
+		dbName   string
+		collName string
+		sprocId  string
+		body     interface{}
+	}
+	testData := map[string]testStruct{
+		`ALTER PROCEDURE db1.table1.sp1 AS "function() {}"`: {dbName: "db1", collName: "table1", sprocId: "sp1", body: "function() {}"},
+		"alter\nprocedure\r\ndb-2.table_2.sp-2 as @2":       {dbName: "db-2", collName: "table_2", sprocId: "sp-2", body: placeholder{2}},
+		`ALTER PROCEDURE mytable.sp3 AS $1`:                 {dbName: "mydb", collName: "mytable", sprocId: "sp3", body: placeholder{1}},
+	}
+	for query, data := range testData {
+		if stmt, err := parseQueryWithDefaultDb(nil, "mydb", query); err != nil {
+			t.Fatalf("%s failed: %s", name+"/"+query, err)
+		} else if dbstmt, ok := stmt.(*StmtAlterProcedure); !ok {
+			t.Fatalf("%s failed: the parsed stmt must be of type *StmtAlterProcedure", name+"/"+query)
+		} else if dbstmt.dbName != data.dbName {
+			t.Fatalf("%s failed: <db-name> expected %#v but received %#v", name+"/"+query, data.dbName, dbstmt.dbName)
+		} else if dbstmt.collName != data.collName {
+			t.Fatalf("%s failed: <collection-name> expected %#v but received %#v", name+"/"+query, data.collName, dbstmt.collName)
+		} else if dbstmt.sprocId != data.sprocId {
+			t.Fatalf("%s failed: <procedure-id> expected %#v but received %#v", name+"/"+query, data.sprocId, dbstmt.sprocId)
+		} else if !reflect.DeepEqual(dbstmt.body, data.body) {
+			t.Fatalf("%s failed: <body> expected %#v but received %#v", name+"/"+query, data.body, dbstmt.body)
+		}
+	}
+
+	invalidQueries := []string{
+		`ALTER PROCEDURE db.coll.sp`,
+		`ALTER PROCEDURE db.coll.sp AS :0`,
+		`ALTER PROCEDURE db.coll.sp AS "function() {}`,
+	}
+	for _, query := range invalidQueries {
+		if _, err := parseQuery(nil, query); err == nil {
+			t.Fatalf("%s failed: query must not be parsed/validated successfully", name+"/"+query)
+		}
+	}
+}
+
+func Test_parseQuery_DropProcedure(t *testing.T) {
+	name := "Test_parseQuery_DropProcedure"
+	type testStruct struct {
+		dbName   string
+		collName string
+		sprocId  string
+		ifExists bool
+	}
+	testData := map[string]testStruct{
+		"DROP PROCEDURE db1.table1.sp1":                   {dbName: "db1", collName: "table1", sprocId: "sp1", ifExists: false},
+		"drop\nprocedure\tIF EXISTS\r\ndb-2.table_2.sp-2": {dbName: "db-2", collName: "table_2", sprocId: "sp-2", ifExists: true},
+		"DROP PROCEDURE mytable.sp3":                      {dbName: "mydb", collName: "mytable", sprocId: "sp3", ifExists: false},
+	}
+	for query, data := range testData {
+		if stmt, err := parseQueryWithDefaultDb(nil, "mydb", query); err != nil {
+			t.Fatalf("%s failed: %s", name+"/"+query, err)
+		} else if dbstmt, ok := stmt.(*StmtDropProcedure); !ok {
+			t.Fatalf("%s failed: the parsed stmt must be of type *StmtDropProcedure", name+"/"+query)
+		} else if dbstmt.dbName != data.dbName {
+			t.Fatalf("%s failed: <db-name> expected %#v but received %#v", name+"/"+query, data.dbName, dbstmt.dbName)
+		} else if dbstmt.collName != data.collName {
+			t.Fatalf("%s failed: <collection-name> expected %#v but received %#v", name+"/"+query, data.collName, dbstmt.collName)
+		} else if dbstmt.sprocId != data.sprocId {
+			t.Fatalf("%s failed: <procedure-id> expected %#v but received %#v", name+"/"+query, data.sprocId, dbstmt.sprocId)
+		} else if dbstmt.ifExists != data.ifExists {
+			t.Fatalf("%s failed: <if-exists> expected %#v but received %#v", name+"/"+query, data.ifExists, dbstmt.ifExists)
+		}
+	}
+
+	invalidQueries := []string{
+		"DROP PROCEDURE coll.sp", // no database
+		"DROP PROCEDURE sp",
+	}
+	for _, query := range invalidQueries {
+		if _, err := parseQuery(nil, query); err == nil {
+			t.Fatalf("%s failed: query must not be parsed/validated successfully", name+"/"+query)
+		}
+	}
+}
+
+func Test_parseQuery_CreateFunction(t *testing.T) {
+	name := "Test_parseQuery_CreateFunction"
+	type testStruct struct {
+		dbName      string
+		collName    string
+		udfId       string
+		ifNotExists bool
+		body        interface{}
+	}
+	testData := map[string]testStruct{
+		`CREATE FUNCTION db1.table1.fn1 AS "function(a) { return a; }"`: {dbName: "db1", collName: "table1", udfId: "fn1", body: "function(a) { return a; }"},
+		"create\nfunction\tIF NOT EXISTS\r\ndb-2.table_2.fn-2 as :1":    {dbName: "db-2", collName: "table_2", udfId: "fn-2", ifNotExists: true, body: placeholder{1}},
+		`CREATE FUNCTION mytable.fn3 AS @1`:                             {dbName: "mydb", collName: "mytable", udfId: "fn3", body: placeholder{1}},
+	}
+	for query, data := range testData {
+		if stmt, err := parseQueryWithDefaultDb(nil, "mydb", query); err != nil {
+			t.Fatalf("%s failed: %s", name+"/"+query, err)
+		} else if dbstmt, ok := stmt.(*StmtCreateFunction); !ok {
+			t.Fatalf("%s failed: the parsed stmt must be of type *StmtCreateFunction", name+"/"+query)
+		} else if dbstmt.dbName != data.dbName {
+			t.Fatalf("%s failed: <db-name> expected %#v but received %#v", name+"/"+query, data.dbName, dbstmt.dbName)
+		} else if dbstmt.collName != data.collName {
+			t.Fatalf("%s failed: <collection-name> expected %#v but received %#v", name+"/"+query, data.collName, dbstmt.collName)
+		} else if dbstmt.udfId != data.udfId {
+			t.Fatalf("%s failed: <function-id> expected %#v but received %#v", name+"/"+query, data.udfId, dbstmt.udfId)
+		} else if dbstmt.ifNotExists != data.ifNotExists {
+			t.Fatalf("%s failed: <if-not-exists> expected %#v but received %#v", name+"/"+query, data.ifNotExists, dbstmt.ifNotExists)
+		} else if !reflect.DeepEqual(dbstmt.body, data.body) {
+			t.Fatalf("%s failed: <body> expected %#v but received %#v", name+"/"+query, data.body, dbstmt.body)
+		}
+	}
+
+	invalidQueries := []string{
+		`CREATE FUNCTION db.coll.fn`,
+		`CREATE FUNCTION db.coll.fn AS function(a) { return a; }`,
+		`CREATE FUNCTION coll.fn AS "function(a) { return a; }"`, // no database
+	}
+	for _, query := range invalidQueries {
+		if _, err := parseQuery(nil, query); err == nil {
+			t.Fatalf("%s failed: query must not be parsed/validated successfully", name+"/"+query)
+		}
+	}
+}
+
+func Test_parseQuery_DropFunction(t *testing.T) {
+	name := "Test_parseQuery_DropFunction"
+	type testStruct struct {
+		dbName   string
+		collName string
+		udfId    string
+		ifExists bool
+	}
+	testData := map[string]testStruct{
+		"DROP FUNCTION db1.table1.fn1":                   {dbName: "db1", collName: "table1", udfId: "fn1", ifExists: false},
+		"drop\nfunction\tIF EXISTS\r\ndb-2.table_2.fn-2": {dbName: "db-2", collName: "table_2", udfId: "fn-2", ifExists: true},
+		"DROP FUNCTION mytable.fn3":                      {dbName: "mydb", collName: "mytable", udfId: "fn3", ifExists: false},
+	}
+	for query, data := range testData {
+		if stmt, err := parseQueryWithDefaultDb(nil, "mydb", query); err != nil {
+			t.Fatalf("%s failed: %s", name+"/"+query, err)
+		} else if dbstmt, ok := stmt.(*StmtDropFunction); !ok {
+			t.Fatalf("%s failed: the parsed stmt must be of type *StmtDropFunction", name+"/"+query)
+		} else if dbstmt.dbName != data.dbName {
+			t.Fatalf("%s failed: <db-name> expected %#v but received %#v", name+"/"+query, data.dbName, dbstmt.dbName)
+		} else if dbstmt.collName != data.collName {
+			t.Fatalf("%s failed: <collection-name> expected %#v but received %#v", name+"/"+query, data.collName, dbstmt.collName)
+		} else if dbstmt.udfId != data.udfId {
+			t.Fatalf("%s failed: <function-id> expected %#v but received %#v", name+"/"+query, data.udfId, dbstmt.udfId)
+		} else if dbstmt.ifExists != data.ifExists {
+			t.Fatalf("%s failed: <if-exists> expected %#v but received %#v", name+"/"+query, data.ifExists, dbstmt.ifExists)
+		}
+	}
+
+	invalidQueries := []string{
+		"DROP FUNCTION coll.fn", // no database
+		"DROP FUNCTION fn",
+	}
+	for _, query := range invalidQueries {
+		if _, err := parseQuery(nil, query); err == nil {
+			t.Fatalf("%s failed: query must not be parsed/validated successfully", name+"/"+query)
+		}
+	}
+}
+
+func Test_parseQuery_CreateTrigger(t *testing.T) {
+	name := "Test_parseQuery_CreateTrigger"
+	type testStruct struct {
+		dbName           string
+		collName         string
+		triggerId        string
+		ifNotExists      bool
+		triggerType      string
+		triggerOperation string
+		body             interface{}
+	}
+	testData := map[string]testStruct{
+		`CREATE TRIGGER db1.table1.trg1 WITH type=pre AS "function() {}"`:                                  {dbName: "db1", collName: "table1", triggerId: "trg1", triggerType: "Pre", triggerOperation: "All", body: "function() {}"},
+		"create\ntrigger\tIF NOT EXISTS\r\ndb-2.table_2.trg-2 with TYPE=Post\nWITH operation=create as :1": {dbName: "db-2", collName: "table_2", triggerId: "trg-2", ifNotExists: true, triggerType: "Post", triggerOperation: "Create", body: placeholder{1}},
+		`CREATE TRIGGER mytable.trg3 WITH operation=DELETE WITH type=PRE AS $1`:                            {dbName: "mydb", collName: "mytable", triggerId: "trg3", triggerType: "Pre", triggerOperation: "Delete", body: placeholder{1}},
+	}
+	for query, data := range testData {
+		if stmt, err := parseQueryWithDefaultDb(nil, "mydb", query); err != nil {
+			t.Fatalf("%s failed: %s", name+"/"+query, err)
+		} else if dbstmt, ok := stmt.(*StmtCreateTrigger); !ok {
+			t.Fatalf("%s failed: the parsed stmt must be of type *StmtCreateTrigger", name+"/"+query)
+		} else if dbstmt.dbName != data.dbName {
+			t.Fatalf("%s failed: <db-name> expected %#v but received %#v", name+"/"+query, data.dbName, dbstmt.dbName)
+		} else if dbstmt.collName != data.collName {
+			t.Fatalf("%s failed: <collection-name> expected %#v but received %#v", name+"/"+query, data.collName, dbstmt.collName)
+		} else if dbstmt.triggerId != data.triggerId {
+			t.Fatalf("%s failed: <trigger-id> expected %#v but received %#v", name+"/"+query, data.triggerId, dbstmt.triggerId)
+		} else if dbstmt.ifNotExists != data.ifNotExists {
+			t.Fatalf("%s failed: <if-not-exists> expected %#v but received %#v", name+"/"+query, data.ifNotExists, dbstmt.ifNotExists)
+		} else if dbstmt.triggerType != data.triggerType {
+			t.Fatalf("%s failed: <trigger-type> expected %#v but received %#v", name+"/"+query, data.triggerType, dbstmt.triggerType)
+		} else if dbstmt.triggerOperation != data.triggerOperation {
+			t.Fatalf("%s failed: <trigger-operation> expected %#v but received %#v", name+"/"+query, data.triggerOperation, dbstmt.triggerOperation)
+		} else if !reflect.DeepEqual(dbstmt.body, data.body) {
+			t.Fatalf("%s failed: <body> expected %#v but received %#v", name+"/"+query, data.body, dbstmt.body)
+		}
+	}
+
+	invalidQueries := []string{
+		`CREATE TRIGGER db.coll.trg AS "function() {}"`,                      // no trigger type
+		`CREATE TRIGGER db.coll.trg WITH type=before AS "function() {}"`,     // invalid trigger type
+		`CREATE TRIGGER db.coll.trg WITH type=pre WITH operation=read AS :1`, // invalid trigger operation
+		`CREATE TRIGGER db.coll.trg WITH type=pre AS function() {}`,          // body is not a string literal
+		`CREATE TRIGGER coll.trg WITH type=pre AS "function() {}"`,           // no database
+		`CREATE TRIGGER db.coll.trg WITH type=pre`,                           // no body
+	}
+	for _, query := range invalidQueries {
+		if _, err := parseQuery(nil, query); err == nil {
+			t.Fatalf("%s failed: query must not be parsed/validated successfully", name+"/"+query)
+		}
+	}
+}
+
+func Test_parseQuery_DropTrigger(t *testing.T) {
+	name := "Test_parseQuery_DropTrigger"
+	type testStruct struct {
+		dbName    string
+		collName  string
+		triggerId string
+		ifExists  bool
+	}
+	testData := map[string]testStruct{
+		"DROP TRIGGER db1.table1.trg1":                   {dbName: "db1", collName: "table1", triggerId: "trg1", ifExists: false},
+		"drop\ntrigger\tIF EXISTS\r\ndb-2.table_2.trg-2": {dbName: "db-2", collName: "table_2", triggerId: "trg-2", ifExists: true},
+		"DROP TRIGGER mytable.trg3":                      {dbName: "mydb", collName: "mytable", triggerId: "trg3", ifExists: false},
+	}
+	for query, data := range testData {
+		if stmt, err := parseQueryWithDefaultDb(nil, "mydb", query); err != nil {
+			t.Fatalf("%s failed: %s", name+"/"+query, err)
+		} else if dbstmt, ok := stmt.(*StmtDropTrigger); !ok {
+			t.Fatalf("%s failed: the parsed stmt must be of type *StmtDropTrigger", name+"/"+query)
+		} else if dbstmt.dbName != data.dbName {
+			t.Fatalf("%s failed: <db-name> expected %#v but received %#v", name+"/"+query, data.dbName, dbstmt.dbName)
+		} else if dbstmt.collName != data.collName {
+			t.Fatalf("%s failed: <collection-name> expected %#v but received %#v", name+"/"+query, data.collName, dbstmt.collName)
+		} else if dbstmt.triggerId != data.triggerId {
+			t.Fatalf("%s failed: <trigger-id> expected %#v but received %#v", name+"/"+query, data.triggerId, dbstmt.triggerId)
+		} else if dbstmt.ifExists != data.ifExists {
+			t.Fatalf("%s failed: <if-exists> expected %#v but received %#v", name+"/"+query, data.ifExists, dbstmt.ifExists)
+		}
+	}
+
+	invalidQueries := []string{
+		"DROP TRIGGER coll.trg", // no database
+		"DROP TRIGGER trg",
+	}
+	for _, query := range invalidQueries {
+		if _, err := parseQuery(nil, query); err == nil {
+			t.Fatalf("%s failed: query must not be parsed/validated successfully", name+"/"+query)
+		}
+	}
+}
+
+func Test_parseQuery_InsertIndexing(t *testing.T) {
+	name := "Test_parseQuery_InsertIndexing"
+	testData := map[string]string{
+		`INSERT INTO db.coll (a) VALUES (1) WITH indexing=exclude`:                      "Exclude",
+		`UPSERT INTO db.coll (a) VALUES (1) WITH pretrigger=trg1 WITH INDEXING=Include`: "Include",
+		`INSERT INTO db.coll (a) VALUES (1)`:                                            "",
+	}
+	for query, indexing := range testData {
+		if stmt, err := parseQuery(nil, query); err != nil {
+			t.Fatalf("%s failed: %s", name+"/"+query, err)
+		} else if dbstmt, ok := stmt.(*StmtInsert); !ok {
+			t.Fatalf("%s failed: the parsed stmt must be of type *StmtInsert", name+"/"+query)
+		} else if dbstmt.indexing != indexing {
+			t.Fatalf("%s failed: <indexing> expected %#v but received %#v", name+"/"+query, indexing, dbstmt.indexing)
+		}
+	}
+
+	invalidQueries := []string{
+		`INSERT INTO db.coll (a) VALUES (1) WITH indexing=none`,
+		`UPSERT INTO db.coll (a) VALUES (1) WITH indexing=`,
+	}
+	for _, query := range invalidQueries {
+		if _, err := parseQuery(nil, query); err == nil {
+			t.Fatalf("%s failed: query must not be parsed/validated successfully", name+"/"+query)
+		}
+	}
+}
+
+func Test_parseQuery_DocumentTriggers(t *testing.T) {
+	name := "Test_parseQuery_DocumentTriggers"
+	type testStruct struct {
+		preTriggers  []string
+		postTriggers []string
+	}
+	testData := map[string]testStruct{
+		`INSERT INTO db.coll (a) VALUES (1) WITH pretrigger=trg1`:                            {preTriggers: []string{"trg1"}},
+		`UPSERT INTO db.coll (a) VALUES (1) WITH pretrigger=trg1,trg2 WITH posttrigger=trg3`: {preTriggers: []string{"trg1", "trg2"}, postTriggers: []string{"trg3"}},
+		`UPDATE db.coll SET a=1 WHERE id="1" WITH posttrigger=trg1`:                          {postTriggers: []string{"trg1"}},
+		`UPDATE db.coll SET a=1 WHERE id=:1 WITH PRETRIGGER=trg1 WITH POSTTRIGGER=trg2`:      {preTriggers: []string{"trg1"}, postTriggers: []string{"trg2"}},
+		`DELETE FROM db.coll WHERE id="1" WITH pretrigger=trg1`:                              {preTriggers: []string{"trg1"}},
+		`DELETE FROM db.coll WHERE id=@1 WITH posttrigger=trg1,trg2`:                         {postTriggers: []string{"trg1", "trg2"}},
+		`INSERT INTO db.coll (a) VALUES (1)`:                                                 {},
+	}
+	for query, data := range testData {
+		stmt, err := parseQuery(nil, query)
+		if err != nil {
+			t.Fatalf("%s failed: %s", name+"/"+query, err)
+		}
+		var preTriggers, postTriggers []string
+		switch dbstmt := stmt.(type) {
+		case *StmtInsert:
+			preTriggers, postTriggers = dbstmt.preTriggers, dbstmt.postTriggers
+		case *StmtUpdate:
+			preTriggers, postTriggers = dbstmt.preTriggers, dbstmt.postTriggers
+		case *StmtDelete:
+			preTriggers, postTriggers = dbstmt.preTriggers, dbstmt.postTriggers
+		default:
+			t.Fatalf("%s failed: unexpected stmt type %T", name+"/"+query, stmt)
+		}
+		if len(preTriggers) != len(data.preTriggers) || (len(preTriggers) > 0 && !reflect.DeepEqual(preTriggers, data.preTriggers)) {
+			t.Fatalf("%s failed: <pre-triggers> expected %#v but received %#v", name+"/"+query, data.preTriggers, preTriggers)
+		}
+		if len(postTriggers) != len(data.postTriggers) || (len(postTriggers) > 0 && !reflect.DeepEqual(postTriggers, data.postTriggers)) {
+			t.Fatalf("%s failed: <post-triggers> expected %#v but received %#v", name+"/"+query, data.postTriggers, postTriggers)
+		}
+	}
+}
+
+func Test_parseQuery_SelectChanges(t *testing.T) {
+	name := "Test_parseQuery_SelectChanges"
+	type testStruct struct {
+		dbName       string
+		collName     string
+		continuation interface{}
+		pkRangeId    string
+		maxItemCount int
+		numInput     int
+	}
+	testData := map[string]testStruct{
+		"SELECT CHANGES FROM db1.table1":                                            {dbName: "db1", collName: "table1"},
+		"select\nchanges\r\nfrom db-2.table_2 WITH continuation=:1":                 {dbName: "db-2", collName: "table_2", continuation: placeholder{1}, numInput: 1},
+		`SELECT CHANGES FROM table3 WITH continuation="123" WITH pkrangeid=0`:       {dbName: "mydb", collName: "table3", continuation: `"123"`, pkRangeId: "0"},
+		"SELECT CHANGES FROM db4.table4 WITH CONTINUATION=* WITH max_item_count=10": {dbName: "db4", collName: "table4", continuation: "*", maxItemCount: 10},
+		`SELECT CHANGES FROM t5 WITH continuation="a WITH b=1; c" WITH pkrangeid=1`: {dbName: "mydb", collName: "t5", continuation: `"a WITH b=1; c"`, pkRangeId: "1"},
+	}
+	for query, data := range testData {
+		if stmt, err := parseQueryWithDefaultDb(nil, "mydb", query); err != nil {
+			t.Fatalf("%s failed: %s", name+"/"+query, err)
+		} else if dbstmt, ok := stmt.(*StmtSelectChanges); !ok {
+			t.Fatalf("%s failed: the parsed stmt must be of type *StmtSelectChanges", name+"/"+query)
+		} else if dbstmt.dbName != data.dbName {
+			t.Fatalf("%s failed: <db-name> expected %#v but received %#v", name+"/"+query, data.dbName, dbstmt.dbName)
+		} else if dbstmt.collName != data.collName {
+			t.Fatalf("%s failed: <collection-name> expected %#v but received %#v", name+"/"+query, data.collName, dbstmt.collName)
+		} else if !reflect.DeepEqual(dbstmt.continuation, data.continuation) {
+			t.Fatalf("%s failed: <continuation> expected %#v but received %#v", name+"/"+query, data.continuation, dbstmt.continuation)
+		} else if dbstmt.pkRangeId != data.pkRangeId {
+			t.Fatalf("%s failed: <pkrangeid> expected %#v but received %#v", name+"/"+query, data.pkRangeId, dbstmt.pkRangeId)
+		} else if dbstmt.maxItemCount != data.maxItemCount {
+			t.Fatalf("%s failed: <max-item-count> expected %#v but received %#v", name+"/"+query, data.maxItemCount, dbstmt.maxItemCount)
+		} else if dbstmt.NumInput() != data.numInput {
+			t.Fatalf("%s failed: <num-input> expected %#v but received %#v", name+"/"+query, data.numInput, dbstmt.NumInput())
+		}
+	}
+
+	invalidQueries := []string{
+		"SELECT CHANGES FROM table1", // no database
+		"SELECT CHANGES FROM db.table1 WITH max_item_count=0",
+		"SELECT CHANGES FROM db.table1 WITH max_item_count=abc",
+		"SELECT CHANGES FROM db.table1 WITH continuation=:0",
+	}
+	for _, query := range invalidQueries {
+		if _, err := parseQuery(nil, query); err == nil {
+			t.Fatalf("%s failed: query must not be parsed/validated successfully", name+"/"+query)
+		}
+	}
+}
+
+func Test_parseQuery_Load(t *testing.T) {
+	name := "Test_parseQuery_Load"
+	type testStruct struct {
+		dbName      string
+		collName    string
+		path        interface{}
+		format      string
+		isUpsert    bool
+		batchSize   int
+		concurrency int
+		numInput    int
+	}
+	testData := map[string]testStruct{
+		"LOAD 'data.ndjson' INTO db1.table1":                                     {dbName: "db1", collName: "table1", path: "data.ndjson"},
+		"load\ndata\r\n\"/tmp/data \\\"1\\\".csv\" INTO table_2 WITH format=CSV": {dbName: "mydb", collName: "table_2", path: `/tmp/data "1".csv`, format: "csv"},
+		"LOAD :1 INTO db-3.table-3 WITH upsert=true WITH batch_size=10":          {dbName: "db-3", collName: "table-3", path: placeholder{1}, isUpsert: true, batchSize: 10, numInput: 1},
+		"LOAD $2 INTO db4.table4 WITH CONCURRENCY=4 WITH UPSERT=false":           {dbName: "db4", collName: "table4", path: placeholder{2}, concurrency: 4, numInput: 2},
+	}
+	for query, data := range testData {
+		if stmt, err := parseQueryWithDefaultDb(nil, "mydb", query); err != nil {
+			t.Fatalf("%s failed: %s", name+"/"+query, err)
+		} else if dbstmt, ok := stmt.(*StmtLoad); !ok {
+			t.Fatalf("%s failed: the parsed stmt must be of type *StmtLoad", name+"/"+query)
+		} else if dbstmt.dbName != data.dbName {
+			t.Fatalf("%s failed: <db-name> expected %#v but received %#v", name+"/"+query, data.dbName, dbstmt.dbName)
+		} else if dbstmt.collName != data.collName {
+			t.Fatalf("%s failed: <collection-name> expected %#v but received %#v", name+"/"+query, data.collName, dbstmt.collName)
+		} else if !reflect.DeepEqual(dbstmt.path, data.path) {
+			t.Fatalf("%s failed: <path> expected %#v but received %#v", name+"/"+query, data.path, dbstmt.path)
+		} else if dbstmt.format != data.format {
+			t.Fatalf("%s failed: <format> expected %#v but received %#v", name+"/"+query, data.format, dbstmt.format)
+		} else if dbstmt.isUpsert != data.isUpsert {
+			t.Fatalf("%s failed: <upsert> expected %#v but received %#v", name+"/"+query, data.isUpsert, dbstmt.isUpsert)
+		} else if dbstmt.batchSize != data.batchSize || dbstmt.concurrency != data.concurrency {
+			t.Fatalf("%s failed: <batch-size/concurrency> expected %d/%d but received %d/%d", name+"/"+query, data.batchSize, data.concurrency, dbstmt.batchSize, dbstmt.concurrency)
+		} else if dbstmt.NumInput() != data.numInput {
+			t.Fatalf("%s failed: <num-input> expected %#v but received %#v", name+"/"+query, data.numInput, dbstmt.NumInput())
+		}
+	}
+
+	invalidQueries := []string{
+		"LOAD 'data.ndjson' INTO table1", // no database
+		"LOAD '' INTO db.table1",
+		"LOAD data.ndjson INTO db.table1",
+		"LOAD 'data.ndjson' INTO db.table1 WITH format=xml",
+		"LOAD 'data.ndjson' INTO db.table1 WITH upsert=abc",
+		"LOAD 'data.ndjson' INTO db.table1 WITH batch_size=0",
+		"LOAD :0 INTO db.table1",
+	}
+	for _, query := range invalidQueries {
+		if _, err := parseQuery(nil, query); err == nil {
+			t.Fatalf("%s failed: query must not be parsed/validated successfully", name+"/"+query)
+		}
+	}
+}
+
+func Test_parseQuery_WithTimeout(t *testing.T) {
+	name := "Test_parseQuery_WithTimeout"
+	testData := map[string]time.Duration{
+		"CREATE DATABASE db1 WITH timeout=5s":                                 5 * time.Second,
+		"DROP COLLECTION IF EXISTS db1.table1 with TIMEOUT = 500ms":           500 * time.Millisecond,
+		"SELECT * FROM c WITH database=db1 WITH timeout=2":                    2 * time.Second,
+		"DELETE FROM db1.table1 WHERE id=1 WITH pretrigger=t WITH timeout=1m": time.Minute,
+	}
+	for query, timeout := range testData {
+		if stmt, err := parseQueryWithDefaultDb(nil, "mydb", query); err != nil {
+			t.Fatalf("%s failed: %s", name+"/"+query, err)
+		} else if dbstmt, ok := stmt.(*StmtWithTimeout); !ok {
+			t.Fatalf("%s failed: the parsed stmt must be of type *StmtWithTimeout", name+"/"+query)
+		} else if dbstmt.Timeout() != timeout {
+			t.Fatalf("%s failed: <timeout> expected %#v but received %#v", name+"/"+query, timeout, dbstmt.Timeout())
+		} else if strings.Contains(strings.ToUpper(dbstmt.Stmt().(rebinder).baseStmt().query), "TIMEOUT") {
+			t.Fatalf("%s failed: timeout option must be removed from the wrapped statement", name+"/"+query)
+		}
+	}
+	if stmt, err := parseQueryWithDefaultDb(nil, "mydb", `INSERT INTO db1.table1 (a) VALUES ("\"x WITH timeout=1s\"")`); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	} else if _, ok := stmt.(*StmtWithTimeout); ok {
+		t.Fatalf("%s failed: timeout option inside string literal must be ignored", name)
+	}
+	if stmt, _ := parseQueryWithDefaultDb(nil, "mydb", "SELECT * FROM c WITH database=db1 WITH pkrangeid=0"); stmt == nil {
+		t.Fatalf("%s failed: query without timeout must be parsed", name)
+	} else if _, ok := stmt.(*StmtWithTimeout); ok {
+		t.Fatalf("%s failed: query without timeout must not be wrapped", name)
+	}
+
+	invalidQueries := []string{
+		"CREATE DATABASE db1 WITH timeout=abc",
+		"CREATE DATABASE db1 WITH timeout=0",
+		"CREATE DATABASE db1 WITH timeout=-1s",
+	}
+	for _, query := range invalidQueries {
+		if _, err := parseQuery(nil, query); err == nil {
+			t.Fatalf("%s failed: query must not be parsed/validated successfully", name+"/"+query)
+		}
+	}
+}
+
+func TestStmt_rebind(t *testing.T) {
+	name := "TestStmt_rebind"
+	queries := []string{
+		"CREATE DATABASE db1",
+		"ALTER DATABASE db1 WITH ru=400",
+		"DROP DATABASE db1",
+		"LIST DATABASES",
+		"CREATE COLLECTION db1.table1 WITH pk=/id",
+		"ALTER COLLECTION db1.table1 WITH ru=400",
+		"DROP COLLECTION db1.table1",
+		"LIST COLLECTIONS FROM db1",
+		"SHOW THROUGHPUT DATABASE db1",
+		"CREATE USER db1.user1",
+		"DROP USER db1.user1",
+		"LIST USERS FROM db1",
+		"GRANT ALL ON db1.coll1 TO user1",
+		"REVOKE ON db1.coll1 FROM user1",
+		"INSERT INTO db1.table1 (id) VALUES (:1)",
+		"INSERT INTO db1.coll2 SELECT * FROM coll1",
+		"DELETE FROM db1.table1 WHERE id=:1",
+		"DELETE FROM db1.table1 WHERE pk=123 ALL",
+		"SELECT * FROM c WITH database=db1",
+		"SELECT CHANGES FROM db1.table1",
+		"UPDATE db1.table1 SET a=1 WHERE id=:1",
+		"LOAD 'data.ndjson' INTO db1.table1",
+		`CREATE PROCEDURE db1.table1.sp1 AS "function() {}"`,
+		`ALTER PROCEDURE db1.table1.sp1 AS "function() {}"`,
+		"DROP PROCEDURE db1.table1.sp1",
+		`CREATE FUNCTION db1.table1.udf1 AS "function() {}"`,
+		"DROP FUNCTION db1.table1.udf1",
+		`CREATE TRIGGER db1.table1.trg1 WITH type=pre AS "function() {}"`,
+		"DROP TRIGGER db1.table1.trg1",
+		"CREATE DATABASE db1 WITH timeout=5s",
+	}
+
+	// collect all statement types, i.e. the ones that embed *Stmt
+	stmtTypes := make(map[string]bool)
+	fset := gotoken.NewFileSet()
+	pkgs, err := goparser.ParseDir(fset, ".", func(fi os.FileInfo) bool { return !strings.HasSuffix(fi.Name(), "_test.go") }, 0)
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	for _, file := range pkgs["gocosmos"].Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			if spec, ok := n.(*ast.TypeSpec); ok {
+				if st, ok := spec.Type.(*ast.StructType); ok {
+					for _, field := range st.Fields.List {
+						if star, ok := field.Type.(*ast.StarExpr); ok && len(field.Names) == 0 && fmt.Sprint(star.X) == "Stmt" {
+							stmtTypes["*gocosmos."+spec.Name.Name] = false
+						}
+					}
+				}
+			}
+			return true
+		})
+	}
+
+	conn := &Conn{}
+	for _, query := range queries {
+		stmt, err := parseQuery(conn, query)
+		if err != nil {
+			t.Fatalf("%s failed: %s", name+"/"+query, err)
+		}
+		bound := &Conn{}
+		clone := _rebindStmt(stmt, func(base *Stmt) *Conn { return bound })
+		if reflect.TypeOf(clone) != reflect.TypeOf(stmt) {
+			t.Fatalf("%s failed: expected %T but received %T", name+"/"+query, stmt, clone)
+		}
+		if clone.(rebinder).baseStmt().conn != bound {
+			t.Fatalf("%s failed: the copy must be bound to the new connection", name+"/"+query)
+		}
+		if base := stmt.(rebinder).baseStmt(); base.conn != conn {
+			t.Fatalf("%s failed: the original statement must be left untouched", name+"/"+query)
+		}
+		if wrapper, ok := stmt.(*StmtWithTimeout); ok {
+			stmt, clone = wrapper.Stmt(), clone.(*StmtWithTimeout).Stmt()
+		}
+		stmtTypes[reflect.TypeOf(stmt).String()] = true
+		expected := reflect.New(reflect.TypeOf(stmt).Elem())
+		expected.Elem().Set(reflect.ValueOf(stmt).Elem())
+		expected.Elem().FieldByName("Stmt").Set(reflect.ValueOf(clone).Elem().FieldByName("Stmt"))
+		if !reflect.DeepEqual(expected.Interface(), clone) {
+			t.Fatalf("%s failed: the statement fields must be copied", name+"/"+query)
+		}
+	}
+	for stmtType, covered := range stmtTypes {
+		if !covered {
+			t.Fatalf("%s failed: statement type %s is not covered", name, stmtType)
+		}
+	}
+}
@@ -0,0 +1,371 @@
+package gocosmos
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// StmtCreateUser implements "CREATE USER" operation.
+//
+// Syntax:
+//     CREATE USER [IF NOT EXISTS] [<db-name>.]<user-id>
+//
+// - If "IF NOT EXISTS" is specified, Exec will silently swallow the error "409 Conflict".
+//
+// Available since v0.1.1
+type StmtCreateUser struct {
+	*Stmt
+	dbName      string
+	userId      string
+	ifNotExists bool
+}
+
+// rebind returns a copy of the statement bound to conn, see _rebindStmt.
+func (s *StmtCreateUser) rebind(conn *Conn) driver.Stmt {
+	clone := *s
+	clone.Stmt = s.Stmt.withConn(conn)
+	return &clone
+}
+
+func (s *StmtCreateUser) validate() error {
+	if s.dbName == "" || s.userId == "" {
+		return errors.New("database or user is missing")
+	}
+	return nil
+}
+
+// Query implements driver.Stmt.Query.
+// This function is not implemented, use Exec instead.
+func (s *StmtCreateUser) Query(_ []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("this operation is not supported, please use exec")
+}
+
+// Exec implements driver.Stmt.Exec.
+// Upon successful call, this function return (*ResultCreateUser, nil).
+func (s *StmtCreateUser) Exec(_ []driver.Value) (driver.Result, error) {
+	restResult := s.conn.restClient.CreateUser(s.dbName, s.userId)
+	result := &ResultCreateUser{Successful: restResult.Error() == nil, InsertId: restResult.Rid}
+	err := restResult.Error()
+	if restResult.StatusCode == 409 && s.ifNotExists {
+		err = nil
+	}
+	return result, err
+}
+
+// ResultCreateUser captures the result from CREATE USER operation.
+//
+// Available since v0.1.1
+type ResultCreateUser struct {
+	// Successful flags if the operation was successful or not.
+	Successful bool
+	// InsertId holds the "_rid" if the operation was successful.
+	InsertId string
+}
+
+// LastInsertId implements driver.Result.LastInsertId.
+func (r *ResultCreateUser) LastInsertId() (int64, error) {
+	return 0, fmt.Errorf("this operation is not supported. {LastInsertId:%s}", r.InsertId)
+}
+
+// RowsAffected implements driver.Result.RowsAffected.
+func (r *ResultCreateUser) RowsAffected() (int64, error) {
+	if r.Successful {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+/*----------------------------------------------------------------------*/
+
+// StmtDropUser implements "DROP USER" operation.
+//
+// Syntax:
+//     DROP USER [IF EXISTS] [<db-name>.]<user-id>
+//
+// - Permissions of the user are deleted together with the user.
+//
+// - If "IF EXISTS" is specified, Exec will silently swallow the error "404 Not Found", reporting 0 rows affected.
+//
+// Available since v0.1.1
+type StmtDropUser struct {
+	*Stmt
+	dbName   string
+	userId   string
+	ifExists bool
+}
+
+// rebind returns a copy of the statement bound to conn, see _rebindStmt.
+func (s *StmtDropUser) rebind(conn *Conn) driver.Stmt {
+	clone := *s
+	clone.Stmt = s.Stmt.withConn(conn)
+	return &clone
+}
+
+func (s *StmtDropUser) validate() error {
+	if s.dbName == "" || s.userId == "" {
+		return errors.New("database or user is missing")
+	}
+	return nil
+}
+
+// Query implements driver.Stmt.Query.
+// This function is not implemented, use Exec instead.
+func (s *StmtDropUser) Query(_ []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("this operation is not supported, please use exec")
+}
+
+// Exec implements driver.Stmt.Exec.
+// Upon successful call, this function returns (*ResultDrop, nil).
+func (s *StmtDropUser) Exec(_ []driver.Value) (driver.Result, error) {
+	restResult := s.conn.restClient.DeleteUser(s.dbName, s.userId)
+	result := &ResultDrop{Successful: restResult.Error() == nil}
+	err := restResult.Error()
+	if restResult.StatusCode == 404 && s.ifExists {
+		err = nil
+	}
+	return result, err
+}
+
+/*----------------------------------------------------------------------*/
+
+// StmtListUsers implements "LIST USERS" operation.
+//
+// Syntax:
+//     LIST USERS|USER [FROM <db-name>]
+//
+// Available since v0.1.1
+type StmtListUsers struct {
+	*Stmt
+	dbName string
+}
+
+// rebind returns a copy of the statement bound to conn, see _rebindStmt.
+func (s *StmtListUsers) rebind(conn *Conn) driver.Stmt {
+	clone := *s
+	clone.Stmt = s.Stmt.withConn(conn)
+	return &clone
+}
+
+func (s *StmtListUsers) validate() error {
+	if s.dbName == "" {
+		return errors.New("database is missing")
+	}
+	return nil
+}
+
+// Exec implements driver.Stmt.Exec.
+// This function is not implemented, use Query instead.
+func (s *StmtListUsers) Exec(_ []driver.Value) (driver.Result, error) {
+	return nil, errors.New("this operation is not supported, please use query")
+}
+
+// Query implements driver.Stmt.Query.
+func (s *StmtListUsers) Query(_ []driver.Value) (driver.Rows, error) {
+	restResult := s.conn.restClient.ListUsers(s.dbName)
+	err := restResult.Error()
+	var rows driver.Rows
+	if err == nil {
+		rows = &RowsListUsers{
+			count:       int(restResult.Count),
+			users:       restResult.Users,
+			cursorCount: 0,
+		}
+	}
+	return rows, err
+}
+
+// RowsListUsers captures the result from LIST USERS operation.
+//
+// Available since v0.1.1
+type RowsListUsers struct {
+	count       int
+	users       []UserInfo
+	cursorCount int
+}
+
+// Columns implements driver.Rows.Columns.
+func (r *RowsListUsers) Columns() []string {
+	return []string{"id", "_rid", "_ts", "_self", "_etag", "_permissions"}
+}
+
+// Close implements driver.Rows.Close.
+func (r *RowsListUsers) Close() error {
+	return nil
+}
+
+// Next implements driver.Rows.Next.
+func (r *RowsListUsers) Next(dest []driver.Value) error {
+	if r.cursorCount >= r.count {
+		return io.EOF
+	}
+	rowData := r.users[r.cursorCount]
+	r.cursorCount++
+	dest[0] = rowData.Id
+	dest[1] = rowData.Rid
+	dest[2] = rowData.Ts
+	dest[3] = rowData.Self
+	dest[4] = rowData.Etag
+	dest[5] = rowData.Permissions
+	return nil
+}
+
+/*----------------------------------------------------------------------*/
+
+// StmtGrant implements "GRANT" operation.
+//
+// Syntax:
+//     GRANT ALL|READ ON [<db-name>.]<collection-name> TO <user-id> [WITH ID=<permission-id>]
+//
+// - The statement creates the permission of the user on the collection, or replaces it if it already exists.
+// Permission id is the collection name, unless ID is specified.
+//
+// - Resource tokens of the permission are obtained with RestClient.GetPermission.
+//
+// Available since v0.1.1
+type StmtGrant struct {
+	*Stmt
+	dbName       string
+	collName     string
+	userId       string
+	mode         string // permission mode, "All" or "Read"
+	permissionId string
+	withOptsStr  string
+}
+
+// rebind returns a copy of the statement bound to conn, see _rebindStmt.
+func (s *StmtGrant) rebind(conn *Conn) driver.Stmt {
+	clone := *s
+	clone.Stmt = s.Stmt.withConn(conn)
+	return &clone
+}
+
+func (s *StmtGrant) parse() error {
+	if err := s.Stmt.parseWithOpts(s.withOptsStr); err != nil {
+		return err
+	}
+	s.permissionId = s.collName
+	if id, ok := s.withOpts["ID"]; ok {
+		s.permissionId = strings.TrimSpace(id)
+	}
+	return nil
+}
+
+func (s *StmtGrant) validate() error {
+	if s.dbName == "" {
+		return errors.New("database is missing")
+	}
+	if s.permissionId == "" {
+		return errors.New("invalid ID value: permission id must not be empty")
+	}
+	return nil
+}
+
+// Query implements driver.Stmt.Query.
+// This function is not implemented, use Exec instead.
+func (s *StmtGrant) Query(_ []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("this operation is not supported, please use exec")
+}
+
+// Exec implements driver.Stmt.Exec.
+// Upon successful call, this function return (*ResultGrant, nil).
+func (s *StmtGrant) Exec(_ []driver.Value) (driver.Result, error) {
+	spec := PermissionSpec{DbName: s.dbName, UserId: s.userId, PermissionId: s.permissionId, PermissionMode: s.mode,
+		Resource: "dbs/" + s.dbName + "/colls/" + s.collName}
+	restResult := s.conn.restClient.ReplacePermission(spec).RestReponse
+	if restResult.StatusCode == 404 {
+		restResult = s.conn.restClient.CreatePermission(spec).RestReponse
+	}
+	err := restResult.Error()
+	return &ResultGrant{Successful: err == nil}, err
+}
+
+// ResultGrant captures the result from GRANT and REVOKE operations.
+//
+// Available since v0.1.1
+type ResultGrant struct {
+	// Successful flags if the operation was successful or not.
+	Successful bool
+}
+
+// LastInsertId implements driver.Result.LastInsertId.
+func (r *ResultGrant) LastInsertId() (int64, error) {
+	return 0, errors.New("this operation is not supported")
+}
+
+// RowsAffected implements driver.Result.RowsAffected.
+func (r *ResultGrant) RowsAffected() (int64, error) {
+	if r.Successful {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+/*----------------------------------------------------------------------*/
+
+// StmtRevoke implements "REVOKE" operation.
+//
+// Syntax:
+//     REVOKE [ALL|READ] ON [<db-name>.]<collection-name> FROM <user-id> [WITH ID=<permission-id>]
+//
+// - The statement deletes the permission of the user on the collection. Permission id is the collection name, unless
+// ID is specified.
+//
+// - If the permission does not exist, Exec reports 0 rows affected instead of an error.
+//
+// Available since v0.1.1
+type StmtRevoke struct {
+	*Stmt
+	dbName       string
+	collName     string
+	userId       string
+	permissionId string
+	withOptsStr  string
+}
+
+// rebind returns a copy of the statement bound to conn, see _rebindStmt.
+func (s *StmtRevoke) rebind(conn *Conn) driver.Stmt {
+	clone := *s
+	clone.Stmt = s.Stmt.withConn(conn)
+	return &clone
+}
+
+func (s *StmtRevoke) parse() error {
+	if err := s.Stmt.parseWithOpts(s.withOptsStr); err != nil {
+		return err
+	}
+	s.permissionId = s.collName
+	if id, ok := s.withOpts["ID"]; ok {
+		s.permissionId = strings.TrimSpace(id)
+	}
+	return nil
+}
+
+func (s *StmtRevoke) validate() error {
+	if s.dbName == "" {
+		return errors.New("database is missing")
+	}
+	if s.permissionId == "" {
+		return errors.New("invalid ID value: permission id must not be empty")
+	}
+	return nil
+}
+
+// Query implements driver.Stmt.Query.
+// This function is not implemented, use Exec instead.
+func (s *StmtRevoke) Query(_ []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("this operation is not supported, please use exec")
+}
+
+// Exec implements driver.Stmt.Exec.
+// Upon successful call, this function return (*ResultGrant, nil).
+func (s *StmtRevoke) Exec(_ []driver.Value) (driver.Result, error) {
+	restResult := s.conn.restClient.DeletePermission(s.dbName, s.userId, s.permissionId)
+	if restResult.StatusCode == 404 {
+		return &ResultGrant{Successful: false}, nil
+	}
+	err := restResult.Error()
+	return &ResultGrant{Successful: err == nil}, err
+}
@@ -0,0 +1,64 @@
+package gocosmos
+
+import (
+	"container/list"
+	"database/sql/driver"
+	"sync"
+)
+
+// defaultStmtCacheSize is the default number of parsed statements cached per connection.
+const defaultStmtCacheSize = 100
+
+// stmtCache is a LRU cache of parsed statements of a connection, so that repeated Prepare/Query of the same query
+// skips parsing. Statements are immutable once parsed, hence can be shared.
+type stmtCache struct {
+	lock    sync.Mutex
+	size    int
+	lru     *list.List               // most recently used first
+	entries map[string]*list.Element // key -> element of lru, whose value is a *stmtCacheEntry
+}
+
+type stmtCacheEntry struct {
+	key  string
+	stmt driver.Stmt
+}
+
+// newStmtCache creates a new stmtCache that holds at most size statements.
+func newStmtCache(size int) *stmtCache {
+	return &stmtCache{size: size, lru: list.New(), entries: make(map[string]*list.Element)}
+}
+
+// get returns the cached statement of key, if any.
+func (c *stmtCache) get(key string) (driver.Stmt, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if e, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(e)
+		return e.Value.(*stmtCacheEntry).stmt, true
+	}
+	return nil, false
+}
+
+// put caches the statement of key, evicting the least recently used statement if the cache is full.
+func (c *stmtCache) put(key string, stmt driver.Stmt) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if e, ok := c.entries[key]; ok {
+		e.Value.(*stmtCacheEntry).stmt = stmt
+		c.lru.MoveToFront(e)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&stmtCacheEntry{key: key, stmt: stmt})
+	for c.lru.Len() > c.size {
+		e := c.lru.Back()
+		c.lru.Remove(e)
+		delete(c.entries, e.Value.(*stmtCacheEntry).key)
+	}
+}
+
+// len returns the number of cached statements.
+func (c *stmtCache) len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.Len()
+}
@@ -0,0 +1,55 @@
+package gocosmos
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStmtCache(t *testing.T) {
+	name := "TestStmtCache"
+	cache := newStmtCache(2)
+	stmt1, stmt2, stmt3 := &StmtDropDatabase{dbName: "1"}, &StmtDropDatabase{dbName: "2"}, &StmtDropDatabase{dbName: "3"}
+	cache.put("1", stmt1)
+	cache.put("2", stmt2)
+	if stmt, ok := cache.get("1"); !ok || stmt != driver.Stmt(stmt1) {
+		t.Fatalf("%s failed: expected statement 1 to be cached", name)
+	}
+	cache.put("3", stmt3)
+	if _, ok := cache.get("2"); ok {
+		t.Fatalf("%s failed: least recently used statement 2 must be evicted", name)
+	}
+	if _, ok := cache.get("1"); !ok || cache.len() != 2 {
+		t.Fatalf("%s failed: expected statements 1 and 3 to be cached, cache size %d", name, cache.len())
+	}
+}
+
+func TestConn_StmtCache(t *testing.T) {
+	name := "TestConn_StmtCache"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	dconn, err := (&Driver{}).Open("AccountEndpoint=" + server.URL + ";AccountKey=cHJpbWFyeQ==;DefaultDb=mydb;StmtCacheSize=10")
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	conn := dconn.(*Conn)
+	stmt1, _ := conn.Prepare("DROP COLLECTION mycoll")
+	stmt2, _ := conn.Prepare("DROP COLLECTION mycoll")
+	if stmt1 != stmt2 || conn.stmtCache.len() != 1 {
+		t.Fatalf("%s failed: statement must be cached", name)
+	}
+	if _, err := conn.Prepare("DROP COLLECTION"); err == nil || conn.stmtCache.len() != 1 {
+		t.Fatalf("%s failed: invalid statement must not be cached", name)
+	}
+
+	dconn, _ = (&Driver{}).Open("AccountEndpoint=" + server.URL + ";AccountKey=cHJpbWFyeQ==;StmtCacheSize=0")
+	if dconn.(*Conn).stmtCache != nil {
+		t.Fatalf("%s failed: StmtCacheSize=0 must disable the cache", name)
+	}
+	if _, err := (&Driver{}).Open("AccountEndpoint=" + server.URL + ";AccountKey=cHJpbWFyeQ==;StmtCacheSize=-1"); err == nil {
+		t.Fatalf("%s failed: negative StmtCacheSize must be rejected", name)
+	}
+}
@@ -0,0 +1,141 @@
+package gocosmos
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// StructTagName is the name of struct tags that mark the id and partition key fields of documents supplied as structs,
+// e.g. to the typed document API (CreateDocumentTyped...) or as the argument of "INSERT...JSON :1":
+//
+//     type User struct {
+//         Username string `json:"username" cosmos:"id"`
+//         Tenant   string `json:"tenant" cosmos:"pk"`
+//         Email    string `json:"email"`
+//     }
+//
+// - id: the field's value (must be a string) is the document id, stored in field "id" of the document.
+//
+// - pk: the field is the partition key. Multiple pk fields, in order of declaration, form a hierarchical partition key.
+//
+// Options are comma-separated (e.g. `cosmos:"id,pk"`); other options are ignored so that tags of package cosmosrepo
+// (e.g. `cosmos:"tenant,pk"`) are understood as well. Fields of embedded structs are included.
+//
+// Available since v0.1.1
+const StructTagName = "cosmos"
+
+// structKeys holds the indexes of the id and partition key fields of a struct type.
+type structKeys struct {
+	id  []int   // index of the id field, nil if none
+	pks [][]int // indexes of the partition key fields, in order of declaration
+}
+
+type structKeysEntry struct {
+	keys *structKeys
+	err  error
+}
+
+// structKeysCache caches the structKeys of struct types, keyed by reflect.Type.
+var structKeysCache sync.Map
+
+func _parseStructKeys(typ reflect.Type, parentIndex []int, keys *structKeys) error {
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		index := append(append([]int{}, parentIndex...), i)
+		tag, hasTag := sf.Tag.Lookup(StructTagName)
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct && !hasTag {
+			if err := _parseStructKeys(sf.Type, index, keys); err != nil {
+				return err
+			}
+			continue
+		}
+		if sf.PkgPath != "" || !hasTag {
+			// unexported or untagged field
+			continue
+		}
+		for _, opt := range strings.Split(tag, ",") {
+			switch strings.TrimSpace(opt) {
+			case "id":
+				if sf.Type.Kind() != reflect.String {
+					return errors.New("id field " + sf.Name + " of " + typ.String() + " must be a string")
+				}
+				if keys.id != nil {
+					return errors.New(typ.String() + " has more than one id field")
+				}
+				keys.id = index
+			case "pk":
+				keys.pks = append(keys.pks, index)
+			}
+		}
+	}
+	return nil
+}
+
+func _structKeysOf(typ reflect.Type) (*structKeys, error) {
+	if entry, ok := structKeysCache.Load(typ); ok {
+		return entry.(structKeysEntry).keys, entry.(structKeysEntry).err
+	}
+	keys := &structKeys{}
+	err := _parseStructKeys(typ, nil, keys)
+	structKeysCache.Store(typ, structKeysEntry{keys: keys, err: err})
+	return keys, err
+}
+
+// DocumentKeys extracts the id and partition key values of a document supplied as a struct (or a pointer to struct) from
+// its fields tagged with `cosmos:"id"` and `cosmos:"pk"` (see StructTagName). id is empty if the struct has no id field,
+// pkValues is nil if it has no pk field. Both are empty if doc is not a struct, e.g. a map.
+//
+// Available since v0.1.1
+func DocumentKeys(doc interface{}) (id string, pkValues []interface{}, err error) {
+	v := reflect.ValueOf(doc)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", nil, nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return "", nil, nil
+	}
+	keys, err := _structKeysOf(v.Type())
+	if err != nil {
+		return "", nil, err
+	}
+	if keys.id != nil {
+		id = v.FieldByIndex(keys.id).String()
+	}
+	for _, index := range keys.pks {
+		pkValues = append(pkValues, v.FieldByIndex(index).Interface())
+	}
+	return id, pkValues, nil
+}
+
+// _applyDocumentKeys stores the tagged id of the document argument (see DocumentKeys) in field "id" of its converted
+// document data, and returns the tagged partition key values (nil if none).
+func _applyDocumentKeys(arg interface{}, doc map[string]interface{}) ([]interface{}, error) {
+	id, pkValues, err := DocumentKeys(arg)
+	if err != nil {
+		return nil, err
+	}
+	if id != "" {
+		doc["id"] = id
+	}
+	return pkValues, nil
+}
+
+// _setTaggedId sets the field tagged with `cosmos:"id"` of dest, a pointer to struct, to id. Other values of dest are
+// left untouched.
+func _setTaggedId(dest interface{}, id string) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	keys, err := _structKeysOf(v.Elem().Type())
+	if err != nil || keys.id == nil {
+		return err
+	}
+	v.Elem().FieldByIndex(keys.id).SetString(id)
+	return nil
+}
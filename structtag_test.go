@@ -0,0 +1,80 @@
+package gocosmos
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type _taggedBase struct {
+	Tenant string `json:"tenant" cosmos:"pk"`
+}
+
+type _taggedOrder struct {
+	_taggedBase
+	OrderNo string `json:"orderNo" cosmos:"id"`
+	Year    int    `json:"year" cosmos:"year,pk"`
+	Note    string `json:"note"`
+}
+
+func TestDocumentKeys(t *testing.T) {
+	name := "TestDocumentKeys"
+	order := _taggedOrder{_taggedBase: _taggedBase{Tenant: "t1"}, OrderNo: "o1", Year: 2024}
+	for _, doc := range []interface{}{order, &order} {
+		id, pkValues, err := DocumentKeys(doc)
+		if err != nil || id != "o1" || !reflect.DeepEqual(pkValues, []interface{}{"t1", 2024}) {
+			t.Fatalf("%s failed: unexpected keys %#v / %#v / %s", name, id, pkValues, err)
+		}
+	}
+	for _, doc := range []interface{}{nil, (*_taggedOrder)(nil), map[string]interface{}{"id": "1"}, struct{ Id string }{"1"}} {
+		if id, pkValues, err := DocumentKeys(doc); err != nil || id != "" || pkValues != nil {
+			t.Fatalf("%s failed: %#v has no tagged keys, received %#v / %#v / %s", name, doc, id, pkValues, err)
+		}
+	}
+	if _, _, err := DocumentKeys(struct {
+		Id int `cosmos:"id"`
+	}{1}); err == nil {
+		t.Fatalf("%s failed: non-string id field must fail", name)
+	}
+	if _, _, err := DocumentKeys(struct {
+		Id  string `cosmos:"id"`
+		Key string `cosmos:"id"`
+	}{}); err == nil {
+		t.Fatalf("%s failed: multiple id fields must fail", name)
+	}
+}
+
+func TestStmtInsert_TaggedDocument(t *testing.T) {
+	name := "TestStmtInsert_TaggedDocument"
+	var lock sync.Mutex
+	var pkHeader, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		if r.Method == http.MethodGet {
+			// the collection's partition key paths do not match the tagged fields, which take precedence
+			w.Write([]byte(`{"id":"coll","partitionKey":{"paths":["/note"],"kind":"Hash"}}`))
+			return
+		}
+		data, _ := ioutil.ReadAll(r.Body)
+		pkHeader, body = r.Header.Get("X-Ms-Documentdb-PartitionKey"), string(data)
+		w.WriteHeader(http.StatusCreated)
+		w.Write(data)
+	}))
+	defer server.Close()
+	db, _ := sql.Open("gocosmos", "AccountEndpoint="+server.URL+";AccountKey=cHJpbWFyeQ==;DefaultDb=db;AutoPartitionKey=true")
+	defer db.Close()
+
+	order := _taggedOrder{_taggedBase: _taggedBase{Tenant: "t1"}, OrderNo: "o1", Year: 2024}
+	if _, err := db.Exec("INSERT INTO coll JSON :1", order); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if pkHeader != `["t1",2024]` || !strings.Contains(body, `"id":"o1"`) {
+		t.Fatalf("%s failed: unexpected partition key %s / body %s", name, pkHeader, body)
+	}
+}
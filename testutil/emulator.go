@@ -0,0 +1,278 @@
+/*
+Package testutil runs integration tests against the Azure Cosmos DB emulator.
+
+StartEmulator starts the Linux emulator container via docker and waits until it accepts requests. The returned
+Emulator provisions the databases and collections of the tests, and removes the container when closed:
+
+	func TestMain(m *testing.M) {
+		emulator, err := testutil.StartEmulator(context.Background(), testutil.EmulatorOptions{})
+		if err != nil {
+			log.Fatal(err)
+		}
+		err = emulator.Provision(gocosmos.CollectionSpec{DbName: "mydb", CollName: "users",
+			PartitionKeyInfo: map[string]interface{}{"paths": []string{"/tenant"}, "kind": "Hash"}})
+		code := 1
+		if err == nil {
+			code = m.Run()
+		}
+		emulator.Close()
+		os.Exit(code)
+	}
+
+Tests then connect with sql.Open("gocosmos", emulator.ConnStr()+";DefaultDb=mydb") or use emulator.Client().
+
+If environment variable COSMOSDB_EMULATOR_ENDPOINT is set (e.g. to https://localhost:8081/ of the Windows emulator, or
+of an emulator service container of the CI), StartEmulator connects to the running emulator instead of starting a
+container; Close then drops the databases created by Provision.
+
+For tests that do not need a real emulator, see package cosmostest.
+
+Available since v0.1.1
+*/
+package testutil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/btnguyen2k/gocosmos"
+)
+
+const (
+	// DefaultEmulatorImage is the docker image of the Linux emulator started by StartEmulator.
+	DefaultEmulatorImage = "mcr.microsoft.com/cosmosdb/linux/azure-cosmos-emulator:latest"
+
+	// EmulatorAccountKey is the well-known account key of the emulator.
+	EmulatorAccountKey = "C2y6yDjf5/R+ob0N8A7Cgv30VRDJIWEHLM+4QDU5DE2nQ9nDuVTqobD4b8mGGyPMbIZnqyMsEcaGQy67XIw/Jw=="
+
+	// EnvEmulatorEndpoint is the environment variable that makes StartEmulator connect to a running emulator.
+	EnvEmulatorEndpoint = "COSMOSDB_EMULATOR_ENDPOINT"
+
+	// EnvEmulatorKey is the environment variable of the account key of the running emulator, EmulatorAccountKey if
+	// not set.
+	EnvEmulatorKey = "COSMOSDB_EMULATOR_KEY"
+)
+
+// readyPollInterval is the interval between requests sent to check whether the emulator accepts requests.
+const readyPollInterval = time.Second
+
+// EmulatorOptions specifies how StartEmulator starts the emulator container.
+type EmulatorOptions struct {
+	Image          string            // docker image of the emulator, default DefaultEmulatorImage
+	Port           int               // host port the emulator's endpoint (container port 8081) is published on, default 8081
+	PartitionCount int               // number of partitions of the emulator, 0 for the image's default
+	Env            map[string]string // other environment variables of the container
+	StartTimeout   time.Duration     // how long to wait for the emulator to accept requests, default 5 minutes
+	Docker         string            // docker executable, default "docker"
+}
+
+// Emulator is an Azure Cosmos DB emulator started (or connected to) by StartEmulator.
+type Emulator struct {
+	Endpoint    string // endpoint of the emulator, e.g. https://localhost:8081/
+	AccountKey  string // account key of the emulator
+	ContainerId string // id of the container started by StartEmulator, empty if connected to a running emulator
+
+	docker string
+	client *gocosmos.RestClient
+	lock   sync.Mutex
+	dbs    map[string]bool // databases created by Provision
+}
+
+// DockerAvailable returns true if the docker executable is found and the docker daemon is reachable.
+func DockerAvailable() bool {
+	return exec.Command("docker", "info").Run() == nil
+}
+
+// _dockerRunArgs builds the arguments of the "docker run" command that starts the emulator container.
+func _dockerRunArgs(opts EmulatorOptions) []string {
+	args := []string{"run", "-d", "-p", strconv.Itoa(opts.Port) + ":8081",
+		"-e", "AZURE_COSMOS_EMULATOR_ENABLE_DATA_PERSISTENCE=false",
+		"-e", "AZURE_COSMOS_EMULATOR_IP_ADDRESS_OVERRIDE=127.0.0.1"}
+	if opts.PartitionCount > 0 {
+		args = append(args, "-e", "AZURE_COSMOS_EMULATOR_PARTITION_COUNT="+strconv.Itoa(opts.PartitionCount))
+	}
+	names := make([]string, 0, len(opts.Env))
+	for name := range opts.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		args = append(args, "-e", name+"="+opts.Env[name])
+	}
+	return append(args, opts.Image)
+}
+
+// StartEmulator starts the emulator container and waits until it accepts requests, or connects to the running
+// emulator specified by environment variable COSMOSDB_EMULATOR_ENDPOINT (see EnvEmulatorEndpoint). The caller should
+// call Close when finished.
+func StartEmulator(ctx context.Context, opts EmulatorOptions) (*Emulator, error) {
+	if endpoint := os.Getenv(EnvEmulatorEndpoint); endpoint != "" {
+		key := os.Getenv(EnvEmulatorKey)
+		if key == "" {
+			key = EmulatorAccountKey
+		}
+		return ConnectEmulator(ctx, endpoint, key)
+	}
+	if opts.Image == "" {
+		opts.Image = DefaultEmulatorImage
+	}
+	if opts.Port <= 0 {
+		opts.Port = 8081
+	}
+	if opts.StartTimeout <= 0 {
+		opts.StartTimeout = 5 * time.Minute
+	}
+	if opts.Docker == "" {
+		opts.Docker = "docker"
+	}
+	out, err := _runDocker(ctx, opts.Docker, _dockerRunArgs(opts)...)
+	if err != nil {
+		return nil, err
+	}
+	e, err := newEmulator("https://localhost:"+strconv.Itoa(opts.Port)+"/", EmulatorAccountKey)
+	if err != nil {
+		return nil, err
+	}
+	e.ContainerId, e.docker = strings.TrimSpace(out), opts.Docker
+	waitCtx, cancel := context.WithTimeout(ctx, opts.StartTimeout)
+	defer cancel()
+	if err := e.waitReady(waitCtx); err != nil {
+		e.Close()
+		return nil, err
+	}
+	return e, nil
+}
+
+// ConnectEmulator connects to a running emulator (or any Cosmos DB account dedicated to tests) and waits until it
+// accepts requests.
+func ConnectEmulator(ctx context.Context, endpoint, accountKey string) (*Emulator, error) {
+	e, err := newEmulator(endpoint, accountKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.waitReady(ctx); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func newEmulator(endpoint, accountKey string) (*Emulator, error) {
+	e := &Emulator{Endpoint: endpoint, AccountKey: accountKey, dbs: make(map[string]bool)}
+	client, err := gocosmos.NewRestClient(nil, e.ConnStr())
+	if err != nil {
+		return nil, err
+	}
+	e.client = client
+	return e, nil
+}
+
+// _runDocker runs a docker command and returns its standard output.
+func _runDocker(ctx context.Context, docker string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, docker, args...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("docker %s: %s: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// waitReady polls the emulator until it accepts requests, the container (if any) exits or ctx is done.
+func (e *Emulator) waitReady(ctx context.Context) error {
+	client, err := gocosmos.NewRestClient(nil, e.ConnStr()+";TransientRetries=0;TimeoutMs=5000")
+	if err != nil {
+		return err
+	}
+	for {
+		result := client.WithContext(ctx).GetDatabaseAccount()
+		if err = result.Error(); err == nil {
+			return nil
+		}
+		if e.ContainerId != "" {
+			if out, err := _runDocker(ctx, e.docker, "inspect", "-f", "{{.State.Running}}", e.ContainerId); err == nil && strings.TrimSpace(out) == "false" {
+				logs, _ := _runDocker(context.Background(), e.docker, "logs", "--tail", "20", e.ContainerId)
+				return errors.New("emulator container exited: " + strings.TrimSpace(logs))
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("emulator at %s is not ready: %s", e.Endpoint, err)
+		case <-time.After(readyPollInterval):
+		}
+	}
+}
+
+// ConnStr returns the connection string to connect to the emulator, e.g. with sql.Open("gocosmos", emulator.ConnStr()).
+func (e *Emulator) ConnStr() string {
+	return "AccountEndpoint=" + e.Endpoint + ";AccountKey=" + e.AccountKey + ";InsecureSkipVerify=true"
+}
+
+// Client returns a RestClient connected to the emulator.
+func (e *Emulator) Client() *gocosmos.RestClient {
+	return e.client
+}
+
+// Provision creates the databases and collections of specs, skipping the ones that already exist. A collection without
+// PartitionKeyInfo is partitioned by /id. Databases are created for specs without CollName.
+func (e *Emulator) Provision(specs ...gocosmos.CollectionSpec) error {
+	for _, spec := range specs {
+		result := e.client.CreateDatabase(gocosmos.DatabaseSpec{Id: spec.DbName})
+		if err := result.Error(); err == nil {
+			e.lock.Lock()
+			e.dbs[spec.DbName] = true
+			e.lock.Unlock()
+		} else if !errors.Is(err, gocosmos.ErrConflict) {
+			return fmt.Errorf("cannot create database %s: %s", spec.DbName, err)
+		}
+		if spec.CollName == "" {
+			continue
+		}
+		if spec.PartitionKeyInfo == nil {
+			spec.PartitionKeyInfo = map[string]interface{}{"paths": []string{"/id"}, "kind": "Hash"}
+		}
+		if err := e.client.CreateCollection(spec).Error(); err != nil && !errors.Is(err, gocosmos.ErrConflict) {
+			return fmt.Errorf("cannot create collection %s.%s: %s", spec.DbName, spec.CollName, err)
+		}
+	}
+	return nil
+}
+
+// DropDatabases deletes the specified databases, e.g. to reset data between tests. Databases that do not exist are
+// skipped.
+func (e *Emulator) DropDatabases(dbNames ...string) error {
+	for _, dbName := range dbNames {
+		if err := e.client.DeleteDatabase(dbName).Error(); err != nil && !errors.Is(err, gocosmos.ErrNotFound) {
+			return fmt.Errorf("cannot drop database %s: %s", dbName, err)
+		}
+		e.lock.Lock()
+		delete(e.dbs, dbName)
+		e.lock.Unlock()
+	}
+	return nil
+}
+
+// Close tears down the emulator: the container started by StartEmulator is removed, otherwise the databases created by
+// Provision are dropped.
+func (e *Emulator) Close() error {
+	if e.ContainerId != "" {
+		_, err := _runDocker(context.Background(), e.docker, "rm", "-f", "-v", e.ContainerId)
+		return err
+	}
+	e.lock.Lock()
+	dbNames := make([]string, 0, len(e.dbs))
+	for dbName := range e.dbs {
+		dbNames = append(dbNames, dbName)
+	}
+	e.lock.Unlock()
+	sort.Strings(dbNames)
+	return e.DropDatabases(dbNames...)
+}
@@ -0,0 +1,108 @@
+package testutil
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/btnguyen2k/gocosmos"
+	"github.com/btnguyen2k/gocosmos/cosmostest"
+)
+
+func Test_dockerRunArgs(t *testing.T) {
+	name := "Test_dockerRunArgs"
+	args := _dockerRunArgs(EmulatorOptions{Image: "emulator:test", Port: 18081, PartitionCount: 3,
+		Env: map[string]string{"B": "2", "A": "1"}})
+	expected := []string{"run", "-d", "-p", "18081:8081",
+		"-e", "AZURE_COSMOS_EMULATOR_ENABLE_DATA_PERSISTENCE=false",
+		"-e", "AZURE_COSMOS_EMULATOR_IP_ADDRESS_OVERRIDE=127.0.0.1",
+		"-e", "AZURE_COSMOS_EMULATOR_PARTITION_COUNT=3", "-e", "A=1", "-e", "B=2", "emulator:test"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Fatalf("%s failed: expected %v but received %v", name, expected, args)
+	}
+}
+
+func TestStartEmulator_Running(t *testing.T) {
+	name := "TestStartEmulator_Running"
+	server := cosmostest.NewTLSServer()
+	defer server.Close()
+	defer os.Setenv(EnvEmulatorEndpoint, os.Getenv(EnvEmulatorEndpoint))
+	defer os.Setenv(EnvEmulatorKey, os.Getenv(EnvEmulatorKey))
+	os.Setenv(EnvEmulatorEndpoint, server.URL)
+	os.Setenv(EnvEmulatorKey, "")
+
+	emulator, err := StartEmulator(context.Background(), EmulatorOptions{Docker: "docker-not-found"})
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if emulator.ContainerId != "" || emulator.AccountKey != EmulatorAccountKey {
+		t.Fatalf("%s failed: unexpected emulator %#v", name, emulator)
+	}
+	if err := emulator.Client().CreateDatabase(gocosmos.DatabaseSpec{Id: "existing"}).Error(); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	specs := []gocosmos.CollectionSpec{
+		{DbName: "mydb", CollName: "users", PartitionKeyInfo: map[string]interface{}{"paths": []string{"/tenant"}, "kind": "Hash"}},
+		{DbName: "mydb", CollName: "orders"},
+		{DbName: "existing"},
+	}
+	for i := 0; i < 2; i++ {
+		if err := emulator.Provision(specs...); err != nil {
+			t.Fatalf("%s failed: provisioning must be idempotent, #%d: %s", name, i, err)
+		}
+	}
+	db, _ := sql.Open("gocosmos", emulator.ConnStr()+";DefaultDb=mydb")
+	defer db.Close()
+	if _, err := db.Exec("INSERT INTO users (id, tenant) VALUES (:1, :2)", "1", "t1", "t1"); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if result := emulator.Client().GetCollection("mydb", "orders"); result.Error() != nil ||
+		!reflect.DeepEqual(result.PartitionKey["paths"], []interface{}{"/id"}) {
+		t.Fatalf("%s failed: unexpected collection %#v / %s", name, result.CollInfo, result.Error())
+	}
+
+	if err := emulator.Close(); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if err := emulator.Client().GetDatabase("mydb").Error(); !errors.Is(err, gocosmos.ErrNotFound) {
+		t.Fatalf("%s failed: provisioned database must be dropped, received %s", name, err)
+	}
+	if err := emulator.Client().GetDatabase("existing").Error(); err != nil {
+		t.Fatalf("%s failed: existing database must be kept, received %s", name, err)
+	}
+}
+
+func TestConnectEmulator_NotReady(t *testing.T) {
+	name := "TestConnectEmulator_NotReady"
+	server := cosmostest.NewServer()
+	defer server.Close()
+	server.InjectFault(503, 100)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := ConnectEmulator(ctx, server.URL, cosmostest.AccountKey); err == nil {
+		t.Fatalf("%s failed: unavailable emulator must not be reported ready", name)
+	}
+	if _, err := StartEmulator(context.Background(), EmulatorOptions{Docker: "docker-not-found"}); os.Getenv(EnvEmulatorEndpoint) == "" && err == nil {
+		t.Fatalf("%s failed: missing docker executable must be reported", name)
+	}
+}
+
+func TestStartEmulator_Docker(t *testing.T) {
+	name := "TestStartEmulator_Docker"
+	if os.Getenv("COSMOSDB_EMULATOR_DOCKER") == "" || !DockerAvailable() {
+		t.Skipf("%s skipped", name)
+	}
+	emulator, err := StartEmulator(context.Background(), EmulatorOptions{})
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	defer emulator.Close()
+	if err := emulator.Provision(gocosmos.CollectionSpec{DbName: "testutil", CollName: "coll"}); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+}
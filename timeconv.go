@@ -0,0 +1,63 @@
+package gocosmos
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	timeFormatEpoch   = "epoch"    // TimeFormat value: seconds since the Unix epoch
+	timeFormatEpochMs = "epoch_ms" // TimeFormat value: milliseconds since the Unix epoch
+)
+
+// _parseTimeFormat validates the value of connection string option TimeFormat and returns the matching format:
+// time.RFC3339Nano for "" and "rfc3339", timeFormatEpoch/timeFormatEpochMs for "epoch"/"epoch_ms", or v itself if it
+// is a Go time layout (which must contain the year "2006").
+func _parseTimeFormat(v string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "", "rfc3339":
+		return time.RFC3339Nano, nil
+	case timeFormatEpoch:
+		return timeFormatEpoch, nil
+	case timeFormatEpochMs:
+		return timeFormatEpochMs, nil
+	}
+	if !strings.Contains(v, "2006") {
+		return "", fmt.Errorf("invalid TimeFormat <%s>", v)
+	}
+	return v, nil
+}
+
+// _formatTime converts a time.Time parameter to the value sent to the server according to the format returned by
+// _parseTimeFormat: a string, or an int64 for epoch formats.
+func _formatTime(t time.Time, format string) interface{} {
+	switch format {
+	case "":
+		return t.Format(time.RFC3339Nano)
+	case timeFormatEpoch:
+		return t.Unix()
+	case timeFormatEpochMs:
+		return t.UnixNano() / int64(time.Millisecond)
+	}
+	return t.Format(format)
+}
+
+// _parseTimeValue converts a column value of a query result to time.Time if possible: system property _ts (seconds
+// since the Unix epoch) and RFC3339 strings (e.g. "2021-01-02T03:04:05.1234567Z") are converted, other values are
+// returned as-is.
+func _parseTimeValue(colName string, v interface{}) interface{} {
+	switch val := v.(type) {
+	case float64:
+		if colName == "_ts" {
+			return time.Unix(int64(val), 0).UTC()
+		}
+	case string:
+		if len(val) >= len("2006-01-02T15:04:05Z") && val[4] == '-' && val[10] == 'T' {
+			if t, err := time.Parse(time.RFC3339Nano, val); err == nil {
+				return t
+			}
+		}
+	}
+	return v
+}
@@ -0,0 +1,107 @@
+package gocosmos
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_formatTime(t *testing.T) {
+	name := "Test_formatTime"
+	ts := time.Date(2021, 1, 2, 3, 4, 5, 678000000, time.UTC)
+	testData := map[string]interface{}{
+		"":           "2021-01-02T03:04:05.678Z",
+		"rfc3339":    "2021-01-02T03:04:05.678Z",
+		"epoch":      int64(1609556645),
+		"EPOCH_MS":   int64(1609556645678),
+		"2006-01-02": "2021-01-02",
+	}
+	for v, expected := range testData {
+		format, err := _parseTimeFormat(v)
+		if err != nil {
+			t.Fatalf("%s failed: <%s> %s", name, v, err)
+		}
+		if formatted := _formatTime(ts, format); formatted != expected {
+			t.Fatalf("%s failed: <%s> expected %#v but received %#v", name, v, expected, formatted)
+		}
+	}
+	if _, err := _parseTimeFormat("unix"); err == nil || err.Error() != "invalid TimeFormat <unix>" {
+		t.Fatalf("%s failed: invalid format must be rejected, received %v", name, err)
+	}
+}
+
+func Test_parseTimeValue(t *testing.T) {
+	name := "Test_parseTimeValue"
+	testData := []struct {
+		colName  string
+		value    interface{}
+		expected interface{}
+	}{
+		{"_ts", 1609556645.0, time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{"created", "2021-01-02T03:04:05.1234567Z", time.Date(2021, 1, 2, 3, 4, 5, 123456700, time.UTC)},
+		{"created", "2021-01-02T10:04:05+07:00", time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{"age", 1609556645.0, 1609556645.0},
+		{"date", "2021-01-02", "2021-01-02"},
+		{"name", "not a time, really", "not a time, really"},
+	}
+	for _, data := range testData {
+		v := _parseTimeValue(data.colName, data.value)
+		if ts, ok := data.expected.(time.Time); ok {
+			if vts, ok := v.(time.Time); !ok || !vts.Equal(ts) {
+				t.Fatalf("%s failed: <%s=%v> expected %v but received %#v", name, data.colName, data.value, ts, v)
+			}
+		} else if v != data.expected {
+			t.Fatalf("%s failed: <%s=%v> expected %#v but received %#v", name, data.colName, data.value, data.expected, v)
+		}
+	}
+}
+
+func TestConn_TimeParamsAndResults(t *testing.T) {
+	name := "TestConn_TimeParamsAndResults"
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		body = string(data)
+		w.Write([]byte(`{"_count":1,"Documents":[{"id":"1","created":"2021-01-02T03:04:05Z","_ts":1609556645}]}`))
+	}))
+	defer server.Close()
+	ts := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	dsn := "AccountEndpoint=" + server.URL + ";AccountKey=cHJpbWFyeQ==;DefaultDb=db"
+	db, _ := sql.Open("gocosmos", dsn+";TimeFormat=epoch_ms;ParseTime=true")
+	defer db.Close()
+	var id string
+	var created, tsCol time.Time
+	if err := db.QueryRow("SELECT c.id, c.created, c._ts FROM c WHERE c.created>=$1 WITH collection=coll", ts).Scan(&id, &created, &tsCol); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if !strings.Contains(body, `"value":1609556645000`) {
+		t.Fatalf("%s failed: time parameter must be sent as epoch milliseconds, request body %s", name, body)
+	}
+	if !created.Equal(ts) || !tsCol.Equal(ts) || id != "1" {
+		t.Fatalf("%s failed: unexpected result %v / %v / %s", name, created, tsCol, id)
+	}
+
+	// default TimeFormat (RFC3339) and ParseTime (off), then parse_time enabled per query
+	db2, _ := sql.Open("gocosmos", dsn)
+	defer db2.Close()
+	var createdStr string
+	var tsNum float64
+	if err := db2.QueryRow("SELECT * FROM c WHERE c.created>=$1 WITH collection=coll", &ts).Scan(&tsNum, &createdStr, &id); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if !strings.Contains(body, `"value":"2021-01-02T03:04:05Z"`) || createdStr != "2021-01-02T03:04:05Z" || tsNum != 1609556645 {
+		t.Fatalf("%s failed: unexpected result %s / %v, request body %s", name, createdStr, tsNum, body)
+	}
+	if err := db2.QueryRow("SELECT * FROM c WITH collection=coll WITH parse_time=true").Scan(&tsCol, &created, &id); err != nil || !tsCol.Equal(ts) {
+		t.Fatalf("%s failed: parse_time=true must convert _ts, received %v / %v", name, tsCol, err)
+	}
+
+	if _, err := (&Driver{}).Open(dsn + ";TimeFormat=unix"); err == nil || err.Error() != "invalid TimeFormat <unix>" {
+		t.Fatalf("%s failed: invalid TimeFormat must be rejected, received %v", name, err)
+	}
+}
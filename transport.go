@@ -0,0 +1,82 @@
+package gocosmos
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// transportIntParams lists the connection string parameters to tune the HTTP transport.
+var transportIntParams = []string{"MAXIDLECONNS", "MAXIDLECONNSPERHOST", "MAXCONNSPERHOST", "IDLECONNTIMEOUTMS", "KEEPALIVEMS", "DIALTIMEOUTMS", "TLSHANDSHAKETIMEOUTMS"}
+
+// _buildHttpClient builds a http.Client from the transport-related connection string parameters.
+// nil is returned if no transport-related parameter is specified, so that the default client is used.
+//
+// Supported parameters (all optional):
+//     - InsecureSkipVerify=true|false: skip TLS certificate verification.
+//     - MaxIdleConns=<n>: maximum number of idle (keep-alive) connections across all hosts.
+//     - MaxIdleConnsPerHost=<n>: maximum number of idle (keep-alive) connections per host.
+//     - MaxConnsPerHost=<n>: maximum number of connections per host, including connections in use.
+//     - IdleConnTimeoutMs=<ms>: how long an idle connection is kept in the pool.
+//     - KeepAliveMs=<ms>: interval of TCP keep-alive probes.
+//     - DialTimeoutMs=<ms>: timeout to establish TCP connections.
+//     - TlsHandshakeTimeoutMs=<ms>: timeout of TLS handshakes.
+func _buildHttpClient(params map[string]string, timeout time.Duration) (*http.Client, error) {
+	insecureSkipVerify := false
+	if v, ok := params["INSECURESKIPVERIFY"]; ok {
+		var err error
+		if insecureSkipVerify, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("invalid InsecureSkipVerify <%s>", v)
+		}
+	}
+	values := make(map[string]int)
+	for _, key := range transportIntParams {
+		if v, ok := params[key]; ok {
+			value, err := strconv.Atoi(v)
+			if err != nil || value < 0 {
+				return nil, fmt.Errorf("invalid %s <%s>", key, v)
+			}
+			values[key] = value
+		}
+	}
+	if !insecureSkipVerify && len(values) == 0 {
+		return nil, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if insecureSkipVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	if v, ok := values["MAXIDLECONNS"]; ok {
+		transport.MaxIdleConns = v
+	}
+	if v, ok := values["MAXIDLECONNSPERHOST"]; ok {
+		transport.MaxIdleConnsPerHost = v
+	}
+	if v, ok := values["MAXCONNSPERHOST"]; ok {
+		transport.MaxConnsPerHost = v
+	}
+	if v, ok := values["IDLECONNTIMEOUTMS"]; ok {
+		transport.IdleConnTimeout = time.Duration(v) * time.Millisecond
+	}
+	if v, ok := values["TLSHANDSHAKETIMEOUTMS"]; ok {
+		transport.TLSHandshakeTimeout = time.Duration(v) * time.Millisecond
+	}
+	_, hasKeepAlive := values["KEEPALIVEMS"]
+	_, hasDialTimeout := values["DIALTIMEOUTMS"]
+	if hasKeepAlive || hasDialTimeout {
+		// same defaults as http.DefaultTransport
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+		if hasKeepAlive {
+			dialer.KeepAlive = time.Duration(values["KEEPALIVEMS"]) * time.Millisecond
+		}
+		if hasDialTimeout {
+			dialer.Timeout = time.Duration(values["DIALTIMEOUTMS"]) * time.Millisecond
+		}
+		transport.DialContext = dialer.DialContext
+	}
+	return &http.Client{Transport: transport, Timeout: timeout}, nil
+}
@@ -0,0 +1,40 @@
+package gocosmos
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func Test_buildHttpClient(t *testing.T) {
+	name := "Test_buildHttpClient"
+	if client, err := _buildHttpClient(map[string]string{"TIMEOUTMS": "1000"}, time.Second); err != nil || client != nil {
+		t.Fatalf("%s failed: expected default client if no transport option is specified (%#v/%s)", name, client, err)
+	}
+	for _, key := range []string{"INSECURESKIPVERIFY", "MAXIDLECONNS", "KEEPALIVEMS"} {
+		if _, err := _buildHttpClient(map[string]string{key: "abc"}, time.Second); err == nil {
+			t.Fatalf("%s failed: expected error for invalid %s", name, key)
+		}
+	}
+	if _, err := _buildHttpClient(map[string]string{"MAXCONNSPERHOST": "-1"}, time.Second); err == nil {
+		t.Fatalf("%s failed: expected error for negative value", name)
+	}
+
+	params := map[string]string{"MAXIDLECONNS": "200", "MAXIDLECONNSPERHOST": "50", "MAXCONNSPERHOST": "100",
+		"IDLECONNTIMEOUTMS": "30000", "KEEPALIVEMS": "15000", "TLSHANDSHAKETIMEOUTMS": "5000"}
+	client, err := _buildHttpClient(params, 3*time.Second)
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	transport := client.Transport.(*http.Transport)
+	if client.Timeout != 3*time.Second || transport.MaxIdleConns != 200 || transport.MaxIdleConnsPerHost != 50 || transport.MaxConnsPerHost != 100 ||
+		transport.IdleConnTimeout != 30*time.Second || transport.TLSHandshakeTimeout != 5*time.Second || transport.DialContext == nil {
+		t.Fatalf("%s failed: transport is not configured as expected %#v", name, transport)
+	}
+	if transport.TLSClientConfig != nil && transport.TLSClientConfig.InsecureSkipVerify {
+		t.Fatalf("%s failed: TLS verification must be enabled by default", name)
+	}
+	if transport == http.DefaultTransport {
+		t.Fatalf("%s failed: default transport must not be modified", name)
+	}
+}
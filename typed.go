@@ -0,0 +1,240 @@
+//go:build go1.18
+// +build go1.18
+
+package gocosmos
+
+import "errors"
+
+// errDocumentWithoutId is returned when the document to be replaced or deleted has no id.
+var errDocumentWithoutId = errors.New("document must have a non-empty string field \"id\"")
+
+// QueryMeta captures the metadata of a page of query results returned by QueryDocumentsTyped: status code, headers,
+// request charge, session token and activity id of the response, number of documents and continuation token.
+//
+// Available since v0.1.1
+type QueryMeta struct {
+	RestReponse
+	Count             int64  // number of documents returned in the page
+	ContinuationToken string // token to fetch the next page, empty if there is no more page
+}
+
+// QueryDocumentsTyped invokes CosmosDB API to query a collection for documents, like RestClient.QueryDocuments, and
+// unmarshals the returned documents directly into values of type T (e.g. a struct with json tags) with the client's
+// JsonCodec, skipping the DocInfo round trip. Requires Go 1.18+.
+//
+// Example:
+//     type User struct {
+//         Id   string `json:"id"`
+//         Name string `json:"name"`
+//     }
+//     users, meta, err := gocosmos.QueryDocumentsTyped[User](client, gocosmos.QueryReq{DbName: "mydb", CollName: "users",
+//         Query: "SELECT * FROM c WHERE c.name=@name", Params: []interface{}{map[string]interface{}{"name": "@name", "value": "user1"}}})
+//
+// Like QueryDocuments, a single page of results is returned; pass meta.ContinuationToken in the next request to fetch the
+// next page. err is CallErr or ApiErr of meta, or the error of unmarshaling the documents.
+//
+// Available since v0.1.1
+func QueryDocumentsTyped[T any](c *RestClient, query QueryReq) ([]T, QueryMeta, error) {
+	resp := c.do(c.buildQueryRequest(query))
+	meta := QueryMeta{RestReponse: c.buildRestReponse(resp)}
+	if err := meta.Error(); err != nil {
+		return nil, meta, err
+	}
+	meta.ContinuationToken = meta.RespHeader["X-MS-CONTINUATION"]
+	var page struct {
+		Count     int64 `json:"_count"`
+		Documents []T   `json:"Documents"`
+	}
+	codec := c.jsonCodec()
+	if !c.encryptor.hasPolicy(query.DbName, query.CollName) {
+		if err := codec.Unmarshal(meta.RespBody, &page); err != nil {
+			return nil, meta, err
+		}
+		meta.Count = page.Count
+		return page.Documents, meta, nil
+	}
+	// encrypted fields are decrypted in the generic representation of documents first
+	result := RespQueryDocs{}
+	if err := codec.Unmarshal(meta.RespBody, &result); err != nil {
+		return nil, meta, err
+	}
+	if err := c.encryptor.decryptDocuments(query.DbName, query.CollName, codec, result.Documents...); err != nil {
+		return nil, meta, err
+	}
+	js, err := codec.Marshal(result.Documents)
+	if err == nil {
+		err = codec.Unmarshal(js, &page.Documents)
+	}
+	meta.Count = result.Count
+	return page.Documents, meta, err
+}
+
+// DocumentMeta captures the metadata of a document response returned by the typed document functions (e.g.
+// CreateDocumentTyped): status code, headers, request charge, session token and activity id of the response, and the
+// etag of the document.
+//
+// Available since v0.1.1
+type DocumentMeta struct {
+	RestReponse
+	Etag string // etag of the returned document, empty if no document is returned
+}
+
+// TypedDocumentSpec specifies a document of type T (e.g. a struct with json tags) to be written by the typed document
+// functions, the counterpart of DocumentSpec. Requires Go 1.18+.
+//
+// Available since v0.1.1
+type TypedDocumentSpec[T any] struct {
+	DbName, CollName   string
+	IsUpsert           bool          // used by CreateDocumentTyped
+	IndexingDirective  string        // used by CreateDocumentTyped, accepted value "", "Include" or "Exclude"
+	PartitionKeyValues []interface{} // if nil, taken from the document's `cosmos:"pk"` fields or extracted using the collection's partition key paths
+	Document           T
+	PreTriggers        []string
+	PostTriggers       []string
+}
+
+// toDocumentSpec converts the typed spec to a DocumentSpec: the document is converted to its JSON representation with
+// the client's JsonCodec and, if not specified, partition key values are taken from the fields tagged with
+// `cosmos:"pk"` or extracted from the converted document.
+func (spec TypedDocumentSpec[T]) toDocumentSpec(c *RestClient) (DocumentSpec, error) {
+	doc, err := _toDocument(spec.Document, c.jsonCodec())
+	if err != nil {
+		return DocumentSpec{}, err
+	}
+	taggedPkValues, err := _applyDocumentKeys(spec.Document, doc)
+	if err != nil {
+		return DocumentSpec{}, err
+	}
+	pkValues := spec.PartitionKeyValues
+	if pkValues == nil {
+		pkValues = taggedPkValues
+	}
+	if pkValues == nil {
+		if pkValues, err = c.docPkValues(spec.DbName, spec.CollName, doc); err != nil {
+			return DocumentSpec{}, err
+		}
+	}
+	return DocumentSpec{
+		DbName:             spec.DbName,
+		CollName:           spec.CollName,
+		IsUpsert:           spec.IsUpsert,
+		IndexingDirective:  spec.IndexingDirective,
+		PartitionKeyValues: pkValues,
+		DocumentData:       doc,
+		PreTriggers:        spec.PreTriggers,
+		PostTriggers:       spec.PostTriggers,
+	}, nil
+}
+
+// _decodeTyped unmarshals a returned document into a value of type T. If an encryption policy applies to the
+// collection, the (decrypted) generic representation of the document is used instead of the response body.
+func _decodeTyped[T any](c *RestClient, dbName, collName string, body []byte, doc DocInfo) (T, error) {
+	var v T
+	codec := c.jsonCodec()
+	if c.encryptor.hasPolicy(dbName, collName) {
+		js, err := codec.Marshal(doc)
+		if err != nil {
+			return v, err
+		}
+		body = js
+	}
+	err := codec.Unmarshal(body, &v)
+	return v, err
+}
+
+// CreateDocumentTyped invokes CosmosDB API to create a new document, like RestClient.CreateDocument, from a value of type
+// T (e.g. a struct with json tags). The document is converted with the client's JsonCodec. The document id and, if
+// spec.PartitionKeyValues is nil, partition key values are taken from the fields tagged with `cosmos:"id"` and
+// `cosmos:"pk"` (see StructTagName); otherwise the id is the document's "id" field and partition key values are
+// extracted from the converted document using the collection's partition key paths.
+// The stored document (with server-generated system properties) is unmarshaled into a value of type T. Requires Go 1.18+.
+//
+// Example:
+//     type User struct {
+//         Id       string `json:"id"`
+//         Username string `json:"username"`
+//     }
+//     user, meta, err := gocosmos.CreateDocumentTyped(client, gocosmos.TypedDocumentSpec[User]{DbName: "mydb",
+//         CollName: "users", Document: User{Id: "1", Username: "user1"}})
+//
+// err is CallErr or ApiErr of meta, or the error of converting the document.
+//
+// Available since v0.1.1
+func CreateDocumentTyped[T any](c *RestClient, spec TypedDocumentSpec[T]) (T, DocumentMeta, error) {
+	var v T
+	docSpec, err := spec.toDocumentSpec(c)
+	if err != nil {
+		return v, DocumentMeta{RestReponse: RestReponse{CallErr: err}}, err
+	}
+	result := c.CreateDocument(docSpec)
+	meta := DocumentMeta{RestReponse: result.RestReponse, Etag: result.DocInfo.Etag()}
+	if err := meta.Error(); err != nil {
+		return v, meta, err
+	}
+	v, err = _decodeTyped[T](c, spec.DbName, spec.CollName, result.RespBody, result.DocInfo)
+	return v, meta, err
+}
+
+// ReplaceDocumentTyped invokes CosmosDB API to replace an existing document, like RestClient.ReplaceDocument, with a
+// value of type T. The document is identified by its id, see CreateDocumentTyped. Requires Go 1.18+.
+//
+// Available since v0.1.1
+func ReplaceDocumentTyped[T any](c *RestClient, matchEtag string, spec TypedDocumentSpec[T]) (T, DocumentMeta, error) {
+	var v T
+	docSpec, err := spec.toDocumentSpec(c)
+	if err == nil {
+		if id, _ := docSpec.DocumentData["id"].(string); id == "" {
+			err = errDocumentWithoutId
+		}
+	}
+	if err != nil {
+		return v, DocumentMeta{RestReponse: RestReponse{CallErr: err}}, err
+	}
+	result := c.ReplaceDocument(matchEtag, docSpec)
+	meta := DocumentMeta{RestReponse: result.RestReponse, Etag: result.DocInfo.Etag()}
+	if err := meta.Error(); err != nil {
+		return v, meta, err
+	}
+	v, err = _decodeTyped[T](c, spec.DbName, spec.CollName, result.RespBody, result.DocInfo)
+	return v, meta, err
+}
+
+// GetDocumentTyped invokes CosmosDB API to get an existing document, like RestClient.GetDocument, and unmarshals it into
+// a value of type T. If the document has not been modified since r.NotMatchEtag, the zero value of T is returned with
+// meta.StatusCode=304. Requires Go 1.18+.
+//
+// Example:
+//     user, meta, err := gocosmos.GetDocumentTyped[User](client, gocosmos.DocReq{DbName: "mydb", CollName: "users",
+//         DocId: "1", PartitionKeyValues: []interface{}{"user1"}})
+//
+// Available since v0.1.1
+func GetDocumentTyped[T any](c *RestClient, r DocReq) (T, DocumentMeta, error) {
+	var v T
+	result := c.GetDocument(r)
+	meta := DocumentMeta{RestReponse: result.RestReponse, Etag: result.DocInfo.Etag()}
+	if err := meta.Error(); err != nil || meta.StatusCode == 304 {
+		return v, meta, err
+	}
+	v, err := _decodeTyped[T](c, r.DbName, r.CollName, result.RespBody, result.DocInfo)
+	return v, meta, err
+}
+
+// DeleteDocumentTyped invokes CosmosDB API to delete the existing document identified by the id and the partition key of
+// spec.Document (see CreateDocumentTyped), like RestClient.DeleteDocument. Requires Go 1.18+.
+//
+// Available since v0.1.1
+func DeleteDocumentTyped[T any](c *RestClient, matchEtag string, spec TypedDocumentSpec[T]) (DocumentMeta, error) {
+	docSpec, err := spec.toDocumentSpec(c)
+	id, _ := docSpec.DocumentData["id"].(string)
+	if err == nil && id == "" {
+		err = errDocumentWithoutId
+	}
+	if err != nil {
+		return DocumentMeta{RestReponse: RestReponse{CallErr: err}}, err
+	}
+	result := c.DeleteDocument(DocReq{DbName: spec.DbName, CollName: spec.CollName, DocId: id,
+		PartitionKeyValues: docSpec.PartitionKeyValues, MatchEtag: matchEtag,
+		PreTriggers: spec.PreTriggers, PostTriggers: spec.PostTriggers})
+	meta := DocumentMeta{RestReponse: result.RestReponse}
+	return meta, meta.Error()
+}
@@ -0,0 +1,178 @@
+//go:build go1.18
+// +build go1.18
+
+package gocosmos
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type _typedUser struct {
+	Id    string  `json:"id"`
+	Name  string  `json:"name"`
+	Grade int     `json:"grade"`
+	Ssn   string  `json:"ssn"`
+	Ts    float64 `json:"_ts"`
+}
+
+func TestQueryDocumentsTyped(t *testing.T) {
+	name := "TestQueryDocumentsTyped"
+	var ssn string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/dbs/db/colls/missing/docs" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":"NotFound"}`))
+			return
+		}
+		if r.Header.Get("X-Ms-Continuation") == "" {
+			w.Header().Set("X-Ms-Continuation", "next")
+		}
+		w.Header().Set("X-Ms-Request-Charge", "3.5")
+		w.Write([]byte(`{"_count":2,"Documents":[{"id":"1","name":"user1","grade":1,"ssn":"` + ssn + `","_ts":1700000000},{"id":"2","name":"user2","grade":2}]}`))
+	}))
+	defer server.Close()
+	client, _ := NewRestClient(nil, "AccountEndpoint="+server.URL+";AccountKey=cHJpbWFyeQ==")
+
+	ssn = "123-45-6789"
+	users, meta, err := QueryDocumentsTyped[_typedUser](client, QueryReq{DbName: "db", CollName: "coll", Query: "SELECT * FROM c"})
+	if err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if len(users) != 2 || users[0] != (_typedUser{Id: "1", Name: "user1", Grade: 1, Ssn: "123-45-6789", Ts: 1700000000}) || users[1].Grade != 2 {
+		t.Fatalf("%s failed: unexpected documents %#v", name, users)
+	}
+	if meta.Count != 2 || meta.ContinuationToken != "next" || meta.RequestCharge != 3.5 || meta.StatusCode != 200 {
+		t.Fatalf("%s failed: unexpected meta %#v", name, meta)
+	}
+	if _, meta, _ := QueryDocumentsTyped[map[string]interface{}](client, QueryReq{DbName: "db", CollName: "coll", ContinuationToken: "next"}); meta.ContinuationToken != "" {
+		t.Fatalf("%s failed: last page must not have continuation token, received %#v", name, meta.ContinuationToken)
+	}
+	if _, _, err := QueryDocumentsTyped[_typedUser](client, QueryReq{DbName: "db", CollName: "missing"}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("%s failed: expected ErrNotFound but received %s", name, err)
+	}
+	if _, _, err := QueryDocumentsTyped[int](client, QueryReq{DbName: "db", CollName: "coll"}); err == nil {
+		t.Fatalf("%s failed: documents that cannot be unmarshaled into the type must fail", name)
+	}
+
+	// encrypted fields are decrypted before being unmarshaled into the type
+	encryptor, _ := NewFieldEncryptor(StaticKeyProvider{"k1": bytes.Repeat([]byte{1}, 32)},
+		FieldEncryptionPolicy{DbName: "db", CollName: "coll", KeyId: "k1", Paths: []string{"/ssn"}})
+	encrypted, _ := encryptor.encryptDocument("db", "coll", map[string]interface{}{"ssn": "123-45-6789"}, StdJsonCodec)
+	ssn = encrypted["ssn"].(string)
+	users, meta, err = QueryDocumentsTyped[_typedUser](client.SetFieldEncryptor(encryptor), QueryReq{DbName: "db", CollName: "coll"})
+	if err != nil || len(users) != 2 || users[0].Ssn != "123-45-6789" || users[0].Ts != 1700000000 || meta.Count != 2 {
+		t.Fatalf("%s failed: unexpected documents %#v / %s", name, users, err)
+	}
+}
+
+func TestDocumentTyped(t *testing.T) {
+	name := "TestDocumentTyped"
+	var lock sync.Mutex
+	var pkHeaders []string
+	docs := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		if r.URL.Path == "/dbs/db/colls/coll" {
+			w.Write([]byte(`{"id":"coll","partitionKey":{"paths":["/name"],"kind":"Hash"}}`))
+			return
+		}
+		pkHeaders = append(pkHeaders, r.Header.Get("X-Ms-Documentdb-PartitionKey"))
+		id := strings.TrimPrefix(r.URL.Path, "/dbs/db/colls/coll/docs/")
+		switch r.Method {
+		case http.MethodPost, http.MethodPut:
+			body, _ := ioutil.ReadAll(r.Body)
+			var doc map[string]interface{}
+			json.Unmarshal(body, &doc)
+			doc["_etag"], doc["_ts"] = "etag1", 1700000000
+			js, _ := json.Marshal(doc)
+			docs[doc["id"].(string)] = string(js)
+			if r.Method == http.MethodPost {
+				w.WriteHeader(http.StatusCreated)
+			}
+			w.Write(js)
+		case http.MethodGet:
+			if js, ok := docs[id]; ok {
+				w.Write([]byte(js))
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":"NotFound"}`))
+		case http.MethodDelete:
+			delete(docs, id)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+	client, _ := NewRestClient(nil, "AccountEndpoint="+server.URL+";AccountKey=cHJpbWFyeQ==")
+
+	spec := TypedDocumentSpec[_typedUser]{DbName: "db", CollName: "coll", Document: _typedUser{Id: "1", Name: "user1", Grade: 1}}
+	user, meta, err := CreateDocumentTyped(client, spec)
+	if err != nil || user != (_typedUser{Id: "1", Name: "user1", Grade: 1, Ts: 1700000000}) || meta.StatusCode != 201 || meta.Etag != "etag1" {
+		t.Fatalf("%s failed: unexpected document %#v / %#v / %s", name, user, meta, err)
+	}
+	if pkHeaders[0] != `["user1"]` {
+		t.Fatalf("%s failed: partition key must be extracted from the document, received %s", name, pkHeaders[0])
+	}
+	user, meta, err = GetDocumentTyped[_typedUser](client, DocReq{DbName: "db", CollName: "coll", DocId: "1", PartitionKeyValues: []interface{}{"user1"}})
+	if err != nil || user.Name != "user1" || meta.Etag != "etag1" {
+		t.Fatalf("%s failed: unexpected document %#v / %s", name, user, err)
+	}
+	spec.Document.Grade = 2
+	spec.PartitionKeyValues = []interface{}{"explicit"}
+	if user, _, err = ReplaceDocumentTyped(client, "etag1", spec); err != nil || user.Grade != 2 || pkHeaders[len(pkHeaders)-1] != `["explicit"]` {
+		t.Fatalf("%s failed: unexpected document %#v / %s", name, user, err)
+	}
+	if _, err := DeleteDocumentTyped(client, "", spec); err != nil {
+		t.Fatalf("%s failed: %s", name, err)
+	}
+	if _, _, err := GetDocumentTyped[_typedUser](client, DocReq{DbName: "db", CollName: "coll", DocId: "1"}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("%s failed: expected ErrNotFound but received %s", name, err)
+	}
+
+	spec.Document.Id = ""
+	if _, _, err := ReplaceDocumentTyped(client, "", spec); err != errDocumentWithoutId {
+		t.Fatalf("%s failed: expected errDocumentWithoutId but received %s", name, err)
+	}
+	if _, err := DeleteDocumentTyped(client, "", spec); err != errDocumentWithoutId {
+		t.Fatalf("%s failed: expected errDocumentWithoutId but received %s", name, err)
+	}
+	if _, _, err := CreateDocumentTyped(client, TypedDocumentSpec[int]{DbName: "db", CollName: "coll", Document: 1}); err == nil {
+		t.Fatalf("%s failed: non-object document must fail", name)
+	}
+}
+
+func TestDocumentTyped_StructTags(t *testing.T) {
+	name := "TestDocumentTyped_StructTags"
+	var pkHeader, path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			t.Errorf("%s failed: partition key paths must not be fetched", name)
+		}
+		pkHeader, path = r.Header.Get("X-Ms-Documentdb-PartitionKey"), r.URL.Path
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	defer server.Close()
+	client, _ := NewRestClient(nil, "AccountEndpoint="+server.URL+";AccountKey=cHJpbWFyeQ==")
+
+	spec := TypedDocumentSpec[_taggedOrder]{DbName: "db", CollName: "coll",
+		Document: _taggedOrder{_taggedBase: _taggedBase{Tenant: "t1"}, OrderNo: "o1", Year: 2024}}
+	if order, _, err := CreateDocumentTyped(client, spec); err != nil || order != spec.Document || pkHeader != `["t1",2024]` {
+		t.Fatalf("%s failed: unexpected document %#v / partition key %s / %s", name, order, pkHeader, err)
+	}
+	if _, _, err := ReplaceDocumentTyped(client, "", spec); err != nil || path != "/dbs/db/colls/coll/docs/o1" {
+		t.Fatalf("%s failed: document must be identified by its tagged id, received %s / %s", name, path, err)
+	}
+	if _, err := DeleteDocumentTyped(client, "", spec); err != nil || path != "/dbs/db/colls/coll/docs/o1" || pkHeader != `["t1",2024]` {
+		t.Fatalf("%s failed: unexpected path %s / partition key %s / %s", name, path, pkHeader, err)
+	}
+}